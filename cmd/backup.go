@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"github.com/rumsystem/quorum/internal/pkg/options"
 	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
 	"github.com/spf13/cobra"
 )
@@ -10,6 +11,7 @@ var backupCmd = &cobra.Command{
 	Use:   "backup",
 	Short: "Backup rum data",
 	Run: func(cmd *cobra.Command, args []string) {
+		paths := backupNodePaths()
 		params := handlers.BackupParam{
 			Peername:     peerName,
 			Password:     keystorePassword,
@@ -17,11 +19,31 @@ var backupCmd = &cobra.Command{
 			KeystoreDir:  keystoreDir,
 			KeystoreName: keystoreName,
 			DataDir:      dataDir,
-			SeedDir:      seedDir,
+			SeedDir:      paths.Seeds,
+			Paths:        paths,
 			BackupFile:   backupFile,
+			Recipients:   backupRecipients,
+			Progress:     newProgressPrinter(),
+			WorkDir:      backupWorkDir,
 		}
 
-		handlers.Backup(params)
+		if backupGroupId != "" {
+			if err := handlers.BackupGroup(params, backupGroupId); err != nil {
+				logger.Fatalf("group backup failed: %s", err)
+			}
+			return
+		}
+
+		if incrementalFrom != "" {
+			if err := handlers.BackupIncremental(params, incrementalFrom); err != nil {
+				logger.Fatalf("incremental backup failed: %s", err)
+			}
+			return
+		}
+
+		if err := handlers.Backup(params); err != nil {
+			logger.Fatalf("backup failed: %s", err)
+		}
 	},
 }
 
@@ -38,8 +60,22 @@ func init() {
 	flags.StringVar(&keystorePassword, "keystorepass", "", "keystore password")
 
 	flags.StringVar(&dataDir, "datadir", "data", "data dir")
-	flags.StringVar(&seedDir, "seeddir", "seeds", "seed dir")
+	flags.StringVar(&seedDir, "seeddir", options.DefaultSeedDir, "seed dir")
+	flags.StringVar(&groupsDbDir, "groups-db-dir", "", "directory of the groups db, <datadir>/<peername> if not set")
+	flags.StringVar(&chainDbDir, "chain-db-dir", "", "directory of the chain db, <datadir>/<peername> if not set")
+	flags.StringVar(&appDbDir, "appdb-dir", "", "directory of the app db, <datadir>/<peername> if not set")
 	flags.StringVar(&backupFile, "file", "", "backup filename")
+	flags.StringSliceVar(&backupRecipients, "recipient", nil, "age X25519 recipient to encrypt the backup to, encrypt with keystore password if not set")
+	flags.StringVar(&backupGroupId, "group", "", "only backup the group with this group id")
+	flags.StringVar(&incrementalFrom, "incremental-from", "", "manifest file of the base backup, only backup blocks after it")
+	flags.StringVar(&backupWorkDir, "workdir", "", "work directory kept on failure, re-run with it to resume the block export")
 
 	backupCmd.MarkFlagRequired("file")
 }
+
+// backupNodePaths returns the node paths of the backup and restore flags
+func backupNodePaths() *options.NodePaths {
+	paths := nodePaths(dataDir, peerName, groupsDbDir, chainDbDir, appDbDir, "")
+	paths.Seeds = seedDir
+	return paths
+}
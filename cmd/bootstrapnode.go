@@ -9,7 +9,6 @@ import (
 	"time"
 
 	"github.com/fatih/color"
-	connmgr "github.com/libp2p/go-libp2p/p2p/net/connmgr"
 	"github.com/rumsystem/quorum/internal/pkg/cli"
 	"github.com/rumsystem/quorum/internal/pkg/conn/p2p"
 	"github.com/rumsystem/quorum/internal/pkg/nodectx"
@@ -34,6 +33,16 @@ var bootstrapNodeCmd = &cobra.Command{
 	Use:   "bootstrapnode",
 	Short: "Run bootstrapnode",
 	Run: func(cmd *cobra.Command, args []string) {
+		if err := loadConfigFile(bootstrapViper, cmd.Flags()); err != nil {
+			logger.Fatal(err)
+		}
+		if printConfig {
+			if err := printEffectiveConfig(bootstrapViper, cmd.Flags()); err != nil {
+				logger.Fatal(err)
+			}
+			return
+		}
+
 		if err := bootstrapViper.Unmarshal(&bootstrapNodeFlag); err != nil {
 			logger.Fatalf("viper unmarshal failed: %s", err)
 		}
@@ -49,7 +58,7 @@ var bootstrapNodeCmd = &cobra.Command{
 		}
 		if len(bootstrapNodeFlag.BootstrapPeers) == 0 {
 			if len(bootstrapViper.GetStringSlice("peer")) != 0 {
-				addrlist, err := cli.ParseAddrList(strings.Join(bootstrapViper.GetStringSlice("peer"), ","))
+				addrlist, err := cli.ParsePeerAddrList(strings.Join(bootstrapViper.GetStringSlice("peer"), ","))
 				if err != nil {
 					logger.Fatalf("parse bootstrap peer addr list failed: %s", err)
 				}
@@ -77,7 +86,7 @@ func init() {
 	flags.String("keystorepwd", "", "keystore password")
 	flags.String("configdir", "./config/", "config and keys dir")
 	flags.String("datadir", "./data/", "data dir")
-	flags.StringSlice("listen", nil, "Adds a multiaddress to the listen list, e.g.: --listen /ip4/127.0.0.1/tcp/4215 --listen /ip/127.0.0.1/tcp/5215/ws")
+	flags.StringSlice("listen", nil, "Adds a multiaddress to the listen list, e.g.: --listen /ip4/127.0.0.1/tcp/4215 --listen /ip/127.0.0.1/tcp/5215/ws --listen /ip4/127.0.0.1/udp/4215/quic-v1")
 
 	flags.String("apihost", "127.0.0.1", "Domain or public ip addresses for api server")
 	flags.Int("apiport", 4216, "api server listen port")
@@ -86,6 +95,7 @@ func init() {
 	if err := bootstrapViper.BindPFlags(flags); err != nil {
 		logger.Fatalf("viper bind flags failed: %s", err)
 	}
+	addConfigFileFlags(flags)
 }
 
 func runBootstrapNode(config cli.BootstrapNodeFlag) {
@@ -123,7 +133,7 @@ func runBootstrapNode(config cli.BootstrapNodeFlag) {
 		DefaultKeyName: defaultKeyName,
 	}
 
-	ks, defaultkey, err := InitDefaultKeystore(keystoreParam, nodeoptions)
+	ks, defaultkey, err := InitDefaultKeystore(&keystoreParam, nodeoptions)
 	if err != nil {
 		cancel()
 		logger.Fatalf(err.Error())
@@ -149,7 +159,7 @@ func runBootstrapNode(config cli.BootstrapNodeFlag) {
 	}
 
 	//bootstrop/relay node connections: low watermarks: 1000  hi watermarks 50000, grace 30s
-	cm, err := connmgr.NewConnManager(1000, 50000, connmgr.WithGracePeriod(30*time.Second))
+	cm, err := p2p.NewConnMgr(1000, 50000, 30*time.Second)
 	if err != nil {
 		logger.Fatalf(err.Error())
 	}
@@ -160,8 +170,7 @@ func runBootstrapNode(config cli.BootstrapNodeFlag) {
 		logger.Fatalf(err.Error())
 	}
 
-	datapath := config.DataDir + "/" + config.PeerName
-	dbManager, err := storage.CreateDb(datapath)
+	dbManager, err := storage.CreateDb(options.NewNodePaths(config.DataDir, config.PeerName).Root)
 	if err != nil {
 		logger.Fatalf(err.Error())
 	}
@@ -602,7 +602,7 @@ func ApproveAnnouncedProducer(groupId string, user *handlers.AnnouncedProducerLi
 
 	data := handlers.GrpProducerParam{
 		Action:         action,
-		ProducerPubkey: user.AnnouncedPubkey,
+		ProducerPubkey: []string{user.AnnouncedPubkey},
 		GroupId:        groupId,
 		Memo:           "by cli",
 	}
@@ -26,7 +26,10 @@ func main() {
 	}
 	version := flag.Bool("version", false, "Show the version")
 	update := flag.Bool("update", false, "Update to the latest version")
+	updateCheck := flag.Bool("update-check", false, "Report the latest version without installing it")
 	updateFrom := flag.String("from", "qingcloud", "Update from: github/qingcloud, default to qingcloud")
+	updateRollback := flag.Bool("update-rollback", false, "Restore the binary replaced by the last update")
+	updateTimeout := flag.Duration("update-timeout", utils.DefaultUpdateHealthCheckTimeout, "Roll back if the new binary fails to start within the timeout")
 	configPath := flag.String("config", "", "Default to $XDG_CONFIG_HOME/rumcli/config.toml")
 
 	flag.Parse()
@@ -36,16 +39,38 @@ func main() {
 		return
 	}
 
-	if *update {
+	if *updateCheck {
+		result, err := utils.CheckUpdateOnly(*updateFrom, ReleaseVersion, "rumcli")
+		if err != nil {
+			logging.Logger("main").Fatalf("Failed to check update: %s\n", err.Error())
+		}
+		if result.Available {
+			fmt.Printf("New version %s is available, current version: %s\n", result.LatestVersion, result.CurrentVersion)
+		} else {
+			fmt.Printf("%s is the latest version\n", result.CurrentVersion)
+		}
+		return
+	}
+
+	if *update || *updateRollback {
 		mainLog := logging.Logger("main")
 		lvl, _ := logging.LevelFromString("info")
 		logging.SetAllLoggers(lvl)
 
+		opts := utils.DefaultUpdateOptions()
+		opts.HealthCheckTimeout = *updateTimeout
+		if *updateRollback {
+			record, err := utils.RollbackUpdate(ReleaseVersion, opts)
+			if err != nil {
+				mainLog.Fatalf("Failed to roll back: %s\n", err.Error())
+			}
+			fmt.Printf("Rolled back from %s to %s\n", record.PrevVersion, record.Version)
+			return
+		}
+
 		err := errors.New(fmt.Sprintf("invalid `-from`: %s", *updateFrom))
-		if *updateFrom == "qingcloud" {
-			err = utils.CheckUpdateQingCloud(ReleaseVersion, "rumcli")
-		} else if *updateFrom == "github" {
-			err = utils.CheckUpdate(ReleaseVersion, "rumcli")
+		if *updateFrom == "qingcloud" || *updateFrom == "github" {
+			err = utils.CheckUpdateWithOptions(*updateFrom, ReleaseVersion, "rumcli", opts)
 		}
 		if err != nil {
 			mainLog.Fatalf("Failed to do self-update: %s\n", err.Error())
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// secret flags are masked by --print-config
+var secretConfigKeys = map[string]bool{
+	"keystorepwd":  true,
+	"keystorepass": true,
+}
+
+// addConfigFileFlags add --config-file and --print-config, they are not bound to viper
+func addConfigFileFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&configFile, "config-file", "", "yaml or json config file, keys are flag names, command-line flags override it")
+	flags.BoolVar(&printConfig, "print-config", false, "print the effective config and exit")
+}
+
+// loadConfigFile merge the config file into `v`, the precedence is: flags > config file > defaults
+func loadConfigFile(v *viper.Viper, flags *pflag.FlagSet) error {
+	if configFile == "" {
+		return nil
+	}
+
+	fv := viper.New()
+	fv.SetConfigFile(configFile)
+	if err := fv.ReadInConfig(); err != nil {
+		return fmt.Errorf("read config file %s failed: %s", configFile, err)
+	}
+
+	unknown := []string{}
+	for _, key := range fv.AllKeys() {
+		if flags.Lookup(key) == nil || key == "config-file" || key == "print-config" {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown keys in config file %s: %s", configFile, strings.Join(unknown, ", "))
+	}
+
+	return v.MergeConfigMap(fv.AllSettings())
+}
+
+// printEffectiveConfig print the merged config as json, it can be used as a config file
+func printEffectiveConfig(v *viper.Viper, flags *pflag.FlagSet) error {
+	config := map[string]interface{}{}
+	flags.VisitAll(func(f *pflag.Flag) {
+		if f.Name == "config-file" || f.Name == "print-config" {
+			return
+		}
+		value := v.Get(f.Name)
+		if secretConfigKeys[f.Name] && value != "" {
+			value = "******"
+		}
+		config[f.Name] = value
+	})
+
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 
@@ -228,3 +229,33 @@ func compactAll() error {
 
 	return nil
 }
+
+// migrateDataVersion runs the schema migrations of the node dbs, with dryRun it prints them and exits
+func migrateDataVersion(dbManager *storage.DbMgr, target int, dryRun bool) {
+	steps, err := dbManager.TryMigration(target, dryRun)
+	if err != nil {
+		logger.Fatalf("db migration failed: %s", err)
+	}
+	if dryRun {
+		version, err := dbManager.GetDataVersion()
+		if err != nil {
+			logger.Fatal(err)
+		}
+		fmt.Printf("db version: %d, latest version: %d\n", version, storage.LatestDataVersion())
+		if len(steps) == 0 {
+			fmt.Println("no migration to run")
+		}
+		for _, step := range steps {
+			fmt.Println(step)
+		}
+		dbManager.CloseDb()
+		os.Exit(0)
+	}
+}
+
+// enableDbEncryption encrypts the node dbs opened afterwards with keys derived from the keystore passphrase
+func enableDbEncryption(passphrase string, migrate bool) {
+	if err := storage.EnableEncryption([]byte(passphrase), migrate); err != nil {
+		logger.Fatalf("enable db encryption failed: %s", err)
+	}
+}
@@ -12,20 +12,22 @@ import (
 	"github.com/fatih/color"
 	_ "github.com/golang/protobuf/ptypes/timestamp" //import for swaggo
 	discovery "github.com/libp2p/go-libp2p/p2p/discovery/util"
-	connmgr "github.com/libp2p/go-libp2p/p2p/net/connmgr"
 	_ "github.com/multiformats/go-multiaddr" //import for swaggo
 	"github.com/rumsystem/quorum/internal/pkg/appdata"
+	"github.com/rumsystem/quorum/internal/pkg/blobstore"
 	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
 	"github.com/rumsystem/quorum/internal/pkg/cli"
 	"github.com/rumsystem/quorum/internal/pkg/conn"
 	"github.com/rumsystem/quorum/internal/pkg/conn/p2p"
 	"github.com/rumsystem/quorum/internal/pkg/nodectx"
 	"github.com/rumsystem/quorum/internal/pkg/options"
+	"github.com/rumsystem/quorum/internal/pkg/stats"
 	"github.com/rumsystem/quorum/internal/pkg/storage"
 	chainstorage "github.com/rumsystem/quorum/internal/pkg/storage/chain"
 	"github.com/rumsystem/quorum/internal/pkg/utils"
 	"github.com/rumsystem/quorum/pkg/chainapi/api"
 	appapi "github.com/rumsystem/quorum/pkg/chainapi/appapi"
+	"github.com/rumsystem/quorum/pkg/consensus"
 	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -42,6 +44,16 @@ var fullnodeCmd = &cobra.Command{
 	Use:   "fullnode",
 	Short: "Run fullnode",
 	Run: func(cmd *cobra.Command, args []string) {
+		if err := loadConfigFile(fullNodeViper, cmd.Flags()); err != nil {
+			logger.Fatal(err)
+		}
+		if printConfig {
+			if err := printEffectiveConfig(fullNodeViper, cmd.Flags()); err != nil {
+				logger.Fatal(err)
+			}
+			return
+		}
+
 		if err := fullNodeViper.Unmarshal(&fnodeFlag); err != nil {
 			logger.Fatalf("viper unmarshal failed: %s", err)
 		}
@@ -57,13 +69,18 @@ var fullnodeCmd = &cobra.Command{
 		}
 		if len(fnodeFlag.BootstrapPeers) == 0 {
 			if len(fullNodeViper.GetStringSlice("peer")) != 0 {
-				addrlist, err := cli.ParseAddrList(strings.Join(fullNodeViper.GetStringSlice("peer"), ","))
+				addrlist, err := cli.ParsePeerAddrList(strings.Join(fullNodeViper.GetStringSlice("peer"), ","))
 				if err != nil {
 					logger.Fatalf("parse bootstrap peer addr list failed: %s", err)
 				}
 				fnodeFlag.BootstrapPeers = *addrlist
 			}
 		}
+		persistentPeers, err := cli.NormalizePeerAddrs(fnodeFlag.PersistentPeers)
+		if err != nil {
+			logger.Fatalf("parse persistent peer addr list failed: %s", err)
+		}
+		fnodeFlag.PersistentPeers = persistentPeers
 
 		if fnodeFlag.KeyStorePwd == "" {
 			fnodeFlag.KeyStorePwd = os.Getenv("RUM_KSPASSWD")
@@ -85,21 +102,46 @@ func init() {
 	flags.String("keystoredir", "./keystore/", "keystore dir")
 	flags.String("keystorename", "default", "keystore name")
 	flags.String("keystorepwd", "", "keystore password")
-	flags.StringSlice("listen", nil, "Adds a multiaddress to the listen list, e.g.: --listen /ip4/127.0.0.1/tcp/4215 --listen /ip4/127.0.0.1/tcp/5215/ws")
+	flags.StringSlice("listen", nil, "Adds a multiaddress to the listen list, e.g.: --listen /ip4/127.0.0.1/tcp/4215 --listen /ip4/127.0.0.1/tcp/5215/ws --listen /ip4/127.0.0.1/udp/4215/quic-v1")
 	flags.String("apihost", "localhost", "Domain or public ip addresses for api server")
 	flags.Uint("apiport", 5215, "api server listen port")
-	flags.String("certdir", "certs", "ssl certificate directory")
+	flags.String("certdir", options.DefaultCertDir, "ssl certificate directory")
 	flags.String("zerosslaccesskey", "", "zerossl access key, get from: https://app.zerossl.com/developer")
 	flags.StringSlice("peer", nil, "bootstrap peer address")
 	flags.String("skippeers", "", "peer id lists, will be skipped in the pubsub connection")
 	flags.String("jsontracer", "", "output tracer data to a json file")
 	flags.Bool("autoack", true, "auto ack the transactions in pubqueue")
 	flags.Bool("autorelay", true, "enable relay")
+	flags.Bool("enable-metrics", true, "serve prometheus metrics at /metrics")
+	flags.Duration("shutdown-timeout", defaultShutdownTimeout, "wait for active api requests and group teardown on exit")
+	flags.String("consensus", consensus.DefaultConsensus, fmt.Sprintf("consensus of new groups, one of: %v, loaded groups keep the one they are created with", consensus.Names()))
+	flags.Uint64("snapshot-interval", consensus.SnapshotInterval, "create a signed snapshot every n blocks on producers, 0 to disable")
+	flags.Int("heartbeat-missed-intervals", consensus.HBMissIntervals, "warn if no consensus heartbeat is received from a producer for the number of propose intervals")
+	flags.String("storage-backend", storage.DefaultBackend, fmt.Sprintf("storage backend of the node dbs, one of: %v", storage.BackendNames()))
+	flags.Int("db-migrate-to", -1, "migrate the dbs to the schema version, a lower version rolls back, -1 for the latest")
+	flags.Bool("db-migrate-dry-run", false, "print the db migrations to run and exit")
+	flags.Bool("db-encrypt-migrate", false, "encrypt the existing plaintext dbs when EnableDbEncrypt is set, an interrupted run is resumed")
+	flags.String("api-unix-socket", "", "also serve the api on the unix socket, set --apiport 0 to serve it only on the socket")
+	flags.String("grpc-listen", "", "serve the grpc api on the address, e.g. 127.0.0.1:8003, disabled if not set")
+	flags.String("grpc-tls-cert", "", "tls certificate file of the grpc api, the certificate of a public apihost is used if not set")
+	flags.String("grpc-tls-key", "", "tls private key file of the grpc api")
+	flags.String("pprof-listen", "", "serve pprof on the address, e.g. :6060, the host defaults to localhost, disabled if not set")
+	flags.StringSlice("persistent-peer", nil, "p2p multiaddr of a peer kept connected and redialed when it drops, can be repeated")
+	flags.Bool("enable-mdns", false, "discover the peers on the local network by mdns")
+	flags.String("peerstore-import", "", "merge the peers of a file exported by GET /api/v1/network/peerstore into the peerstore on startup, and dial them")
+	flags.Bool("systemd-notify", false, "notify systemd READY=1 once the api server is listening and the groups are loaded, and send the watchdog pings if WatchdogSec is set")
+	flags.String("groups-db-dir", "", "directory of the groups db, <datadir>/<peername> if not set")
+	flags.String("chain-db-dir", "", "directory of the chain db keeping blocks, trxs and posts, <datadir>/<peername> if not set")
+	flags.String("appdb-dir", "", "directory of the app db, <datadir>/<peername> if not set")
+	flags.Int("appsync-interval", 10, "seconds between the passes applying new blocks to the app data, POST /api/v1/appsync/trigger runs a pass at once")
+	flags.Bool("print-identity", false, "print the peer id, eth address, public key and listen addrs as json and exit, GET /api/v1/node/identity returns them from a running node")
+	flags.Bool("archive", false, "only keep and serve the blocks of the groups, the app data is not synced and the content and timeline apis are disabled")
 
 	fullNodeViper = options.NewViper()
 	if err := fullNodeViper.BindPFlags(flags); err != nil {
 		logger.Fatalf("viper bind flags failed: %s", err)
 	}
+	addConfigFileFlags(flags)
 }
 
 func runFullnode(config cli.FullNodeFlag) {
@@ -108,6 +150,26 @@ func runFullnode(config cli.FullNodeFlag) {
 
 	color.Green("Version: %s", utils.GitCommit)
 
+	if config.PprofListen != "" {
+		addr, err := utils.StartPprofServer(config.PprofListen, config.APIPort)
+		if err != nil {
+			logger.Fatalf("start pprof failed: %s", err)
+		}
+		logger.Infof("pprof listens on http://%s/debug/pprof/", addr)
+	}
+
+	if !consensus.IsRegistered(config.Consensus) {
+		logger.Fatalf("unknown consensus: %s, registered: %v", config.Consensus, consensus.Names())
+	}
+	consensus.SnapshotInterval = config.SnapshotInterval
+	consensus.HBMissIntervals = config.HBMissIntervals
+	if !storage.IsBackendRegistered(config.StorageBackend) {
+		logger.Fatalf("unknown storage backend: %s, registered: %v", config.StorageBackend, storage.BackendNames())
+	}
+	if config.StorageBackend != "" {
+		storage.DefaultBackend = config.StorageBackend
+	}
+
 	fullNodeSignalch = make(chan os.Signal, 1)
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -124,6 +186,7 @@ func runFullnode(config cli.FullNodeFlag) {
 		cancel()
 		logger.Fatalf(err.Error())
 	}
+	applyLogLevels(nodeoptions.LogLevels)
 
 	// overwrite by cli flags
 	nodeoptions.EnableRelay = config.EnableRelay
@@ -137,7 +200,7 @@ func runFullnode(config cli.FullNodeFlag) {
 		DefaultKeyName: defaultKeyName,
 	}
 
-	ks, defaultkey, err := InitDefaultKeystore(keystoreParam, nodeoptions)
+	ks, defaultkey, err := InitDefaultKeystore(&keystoreParam, nodeoptions)
 	if err != nil {
 		cancel()
 		logger.Fatalf(err.Error())
@@ -164,15 +227,31 @@ func runFullnode(config cli.FullNodeFlag) {
 
 	nodename := "fullnode_default"
 
-	datapath := config.DataDir + "/" + config.PeerName
-	dbManager, err := storage.CreateDb(datapath)
+	if config.PrintIdentity {
+		printNodeIdentity(ctx, nodename, nodeoptions, defaultkey, config.ListenAddresses, ethaddr)
+		return
+	}
+
+	if nodeoptions.EnableDbEncrypt {
+		enableDbEncryption(keystoreParam.KeystorePwd, config.DbEncryptMigrate)
+	}
+
+	paths := nodePaths(config.DataDir, config.PeerName, config.GroupsDbDir, config.ChainDbDir, config.AppDbDir, config.CertDir)
+	dbManager, err := storage.CreateDbWithPaths(paths.GroupsDb, paths.ChainDb, storage.DefaultBackend)
 	if err != nil {
 		logger.Fatalf(err.Error())
 	}
+	migrateDataVersion(dbManager, config.DbMigrateTo, config.DbMigrateDryRun)
 	newchainstorage := chainstorage.NewChainStorage(dbManager)
+	if err := stats.InitDB(ctx, paths.Root, storage.DefaultBackend, nodeoptions.StatsMinuteKeep, nodeoptions.StatsHourKeep); err != nil {
+		logger.Fatalf("open stats db failed: %s", err)
+	}
+	if err := blobstore.Init(nodeoptions.BlobStore, paths.Root, nodeoptions.BlobDir, nodeoptions.IPFSAPI); err != nil {
+		logger.Fatalf("open blob store failed: %s", err)
+	}
 
-	//normal node connections: low watermarks: 10  hi watermarks 200, grace 60s
-	cm, err := connmgr.NewConnManager(10, nodeoptions.ConnsHi, connmgr.WithGracePeriod(60*time.Second))
+	//normal node connections: watermarks and grace period of the node options, default low 10 hi 100 grace 60s
+	cm, err := p2p.NewConnMgr(nodeoptions.ConnsLo, nodeoptions.ConnsHi, nodeoptions.ConnsGrace)
 	if err != nil {
 		logger.Fatalf(err.Error())
 	}
@@ -190,6 +269,7 @@ func runFullnode(config cli.FullNodeFlag) {
 	}
 
 	nodectx.InitCtx(ctx, nodename, fullNode, dbManager, newchainstorage, "pubsub", utils.GitCommit, nodectx.FULL_NODE)
+	nodectx.GetNodeCtx().Consensus = config.Consensus
 	nodectx.GetNodeCtx().Keystore = ks
 	nodectx.GetNodeCtx().PublicKey = keys.PubKey
 	nodectx.GetNodeCtx().PeerId = peerid
@@ -199,6 +279,16 @@ func runFullnode(config cli.FullNodeFlag) {
 
 	//initial group manager
 	chain.InitGroupMgr()
+	chain.GetGroupMgr().SetMaxSyncingGroups(nodeoptions.MaxSyncingGroups)
+	chain.SetIdempotencyKeyWindow(nodeoptions.PubQueKeyWindow)
+	chain.SetRexResultQueueSize(nodeoptions.RexBufferSize)
+	if nodeoptions.EnablePubQue {
+		chain.SetDefaultPublishRetryPolicy(nodeoptions.PubQueMaxAttempts, nodeoptions.PubQueBaseBackoff, nodeoptions.PubQueMaxBackoff)
+		chain.GetGroupMgr().StartPublishQueueWatcher(ctx, time.Second, nodeoptions.PubQueWorkers)
+	}
+	if nodeoptions.StorageGCInterval > 0 {
+		dbManager.StartCompaction(ctx, nodeoptions.StorageGCInterval, nodeoptions.StorageGCRatio)
+	}
 	//if nodeoptions.IsRexTestMode == true {
 	//	chain.GetGroupMgr().SetRumExchangeTestMode()
 	//}
@@ -212,6 +302,26 @@ func runFullnode(config cli.FullNodeFlag) {
 	if err := fullNode.Bootstrap(ctx, config.BootstrapPeers); err != nil {
 		logger.Fatal(err)
 	}
+	if config.PeerstoreImport != "" {
+		peers, err := fullNode.ImportPeerstoreFile(config.PeerstoreImport)
+		if err != nil {
+			logger.Fatalf("import peerstore failed: %s", err)
+		}
+		if len(peers) > nodeoptions.MaxPeers {
+			peers = peers[:nodeoptions.MaxPeers]
+		}
+		go fullNode.AddPeers(ctx, peers)
+	}
+	persistentPeers, err := p2p.ParsePeerAddrs(append(config.PersistentPeers, nodeoptions.PersistentPeers...))
+	if err != nil {
+		logger.Fatal(err)
+	}
+	fullNode.KeepPersistentPeers(ctx, persistentPeers)
+	if config.EnableMdns {
+		if err := fullNode.StartMdns(ctx); err != nil {
+			logger.Fatalf("start mdns failed: %s", err)
+		}
+	}
 	//Discovery and Advertise had been replaced by PeerExchange
 	logger.Infof("Announcing ourselves...")
 	discovery.Advertise(ctx, fullNode.RoutingDiscovery, config.RendezvousString)
@@ -219,7 +329,7 @@ func runFullnode(config cli.FullNodeFlag) {
 	peerok := make(chan struct{})
 	go fullNode.ConnectPeers(ctx, peerok, nodeoptions.MaxPeers, config.RendezvousString)
 
-	appdb, err := appdata.CreateAppDb(datapath)
+	appdb, err := appdata.CreateAppDb(paths.AppDb)
 	if err != nil {
 		logger.Fatalf(err.Error())
 	}
@@ -248,8 +358,22 @@ func runFullnode(config cli.FullNodeFlag) {
 	}
 
 	apiaddress := fmt.Sprintf("http://localhost:%d/api/v1", config.APIPort)
-	appsync := appdata.NewAppSyncAgent(apiaddress, nodectx.GetNodeCtx().Name, appdb, dbManager)
-	appsync.Start(10)
+	caughtUp := appdata.NewCaughtUpWatcher(appdb)
+	caughtUp.Start(1)
+	if config.Archive {
+		logger.Infof("archive mode, the app data is not synced")
+	} else {
+		appsync := appdata.NewAppSyncAgent(apiaddress, nodectx.GetNodeCtx().Name, appdb, dbManager)
+		if config.AppSyncInterval < 1 {
+			logger.Fatalf("invalid appsync interval %d, should be at least 1 second", config.AppSyncInterval)
+		}
+		appsync.Start(config.AppSyncInterval)
+		h.AppSync = appsync
+		webhooks := appdata.NewWebhookDispatcher(appdb, nodectx.GetNodeCtx().Name)
+		webhooks.Start(1)
+		searchIndexer := appdata.NewSearchIndexer(appdb, nodectx.GetNodeCtx().Name)
+		searchIndexer.Start(10)
+	}
 	apph := &appapi.Handler{
 		Appdb:     appdb,
 		Trxdb:     newchainstorage,
@@ -263,21 +387,36 @@ func runFullnode(config cli.FullNodeFlag) {
 		IsDebug:       config.IsDebug,
 		APIHost:       config.APIHost,
 		APIPort:       config.APIPort,
-		CertDir:       config.CertDir,
+		CertDir:       paths.Certs,
 		ZeroAccessKey: config.ZeroAccessKey,
+		UnixSocket:    config.APIUnixSocket,
+		EnableMetrics: config.EnableMetrics,
+		Archive:       config.Archive,
 	}
 	go api.StartFullNodeServer(startParam, fullNodeSignalch, h, apph, fullNode, nodeoptions, ks, ethaddr)
+	if config.GRPCListen != "" {
+		grpcParam := api.GRPCServerParam{Listen: config.GRPCListen, CertFile: config.GRPCTLSCert, KeyFile: config.GRPCTLSKey}
+		if err := api.StartGRPCServer(grpcParam, startParam, h, nodeoptions); err != nil {
+			logger.Fatalf("start grpc api failed: %s", err)
+		}
+	}
+	if config.SystemdNotify {
+		go notifySystemd(ctx)
+	}
 
-	//attach signal
-	signal.Notify(fullNodeSignalch, os.Interrupt, syscall.SIGTERM)
+	//attach signal, SIGHUP reloads the options file
+	signal.Notify(fullNodeSignalch, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
 	signalType := <-fullNodeSignalch
+	for signalType == syscall.SIGHUP {
+		reloadNodeOptions(ctx, fullNode, nodeoptions, config.PersistentPeers)
+		signalType = <-fullNodeSignalch
+	}
 	signal.Stop(fullNodeSignalch)
 
-	chain.GetGroupMgr().StopSyncAllGroups()
-	//teardown all groups
-	chain.GetGroupMgr().TeardownAllGroups()
-	//close ctx db
-	nodectx.GetDbMgr().CloseDb()
+	if config.SystemdNotify {
+		notifySystemdStopping()
+	}
+	shutdownNode(config.ShutdownTimeout)
 
 	//cleanup before exit
 	logger.Infof("On Signal <%s>", signalType)
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/rumsystem/quorum/internal/pkg/options"
+	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
+	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// import
+	importKeyAlias    string
+	importPrivateKey  string
+	importKeyFile     string
+	importKeyPassword string
+)
+
+// keystoreCmd represents the keystore command
+var keystoreCmd = &cobra.Command{
+	Use:   "keystore",
+	Short: "A keystore tool, run it when the node is stopped",
+}
+
+var keystoreImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import an eth private key, raw hex or keystore json file, under a new key alias",
+	Run: func(cmd *cobra.Command, args []string) {
+		params := &handlers.ImportKeyParam{KeyAlias: importKeyAlias, PrivateKey: importPrivateKey, KeyPassword: importKeyPassword}
+		if importKeyFile != "" {
+			keyjson, err := os.ReadFile(importKeyFile)
+			if err != nil {
+				logger.Fatalf("read key file failed: %s", err)
+			}
+			params.KeyJson = string(keyjson)
+		}
+		if params.PrivateKey == "" && params.KeyJson == "" {
+			logger.Fatal("one of --privkey and --keyfile is required")
+		}
+
+		nodeoptions, err := options.InitNodeOptions(configDir, peerName)
+		if err != nil {
+			logger.Fatalf("load node options failed: %s", err)
+		}
+		password, err := handlers.GetKeystorePassword(keystorePassword)
+		if err != nil {
+			logger.Fatalf("handlers.GetKeystorePassword failed: %s", err)
+		}
+		ks, _, err := localcrypto.InitDirKeyStore(keystoreName, keystoreDir)
+		if err != nil {
+			logger.Fatalf("open keystore failed: %s", err)
+		}
+		if err := ks.Unlock(nodeoptions.SignKeyMap, password); err != nil {
+			logger.Fatalf("unlock keystore failed: %s", err)
+		}
+
+		res, err := handlers.ImportKey(ks, nodeoptions, params)
+		if err != nil {
+			logger.Fatalf("import key failed: %s", err)
+		}
+
+		output, err := json.MarshalIndent(res, "", "  ")
+		if err != nil {
+			logger.Fatalf("json.Marshal failed: %s", err)
+		}
+		fmt.Println(string(output))
+	},
+}
+
+func init() {
+	keystoreCmd.AddCommand(keystoreImportCmd)
+	rootCmd.AddCommand(keystoreCmd)
+
+	flags := keystoreImportCmd.Flags()
+	flags.SortFlags = false
+
+	flags.StringVar(&peerName, "peername", "peer", "peer name")
+	flags.StringVar(&configDir, "configdir", "config", "config dir")
+	flags.StringVar(&keystoreDir, "keystoredir", "keystore", "keystore dir")
+	flags.StringVar(&keystoreName, "keystorename", "defaultkeystore", "keystore name")
+	flags.StringVar(&keystorePassword, "keystorepass", "", "keystore password")
+
+	flags.StringVar(&importKeyAlias, "alias", "", "key alias of the imported key")
+	flags.StringVar(&importPrivateKey, "privkey", "", "hex encoded eth private key")
+	flags.StringVar(&importKeyFile, "keyfile", "", "eth keystore json file")
+	flags.StringVar(&importKeyPassword, "keyfile-password", "", "password of the keystore json file")
+
+	keystoreImportCmd.MarkFlagRequired("alias")
+}
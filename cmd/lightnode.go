@@ -30,6 +30,16 @@ var lightnodeCmd = &cobra.Command{
 	Use:   "lightnode",
 	Short: "Run lightnode",
 	Run: func(cmd *cobra.Command, args []string) {
+		if err := loadConfigFile(lnodeViper, cmd.Flags()); err != nil {
+			logger.Fatal(err)
+		}
+		if printConfig {
+			if err := printEffectiveConfig(lnodeViper, cmd.Flags()); err != nil {
+				logger.Fatal(err)
+			}
+			return
+		}
+
 		if err := lnodeViper.Unmarshal(&lnodeFlag); err != nil {
 			logger.Fatalf("viper unmarshal failed: %s", err)
 		}
@@ -63,6 +73,7 @@ func init() {
 	if err := lnodeViper.BindPFlags(flags); err != nil {
 		logger.Fatalf("viper bind flags failed: %s", err)
 	}
+	addConfigFileFlags(flags)
 }
 
 func runLightnode(config cli.LightnodeFlag) {
@@ -94,7 +105,7 @@ func runLightnode(config cli.LightnodeFlag) {
 		ConfigDir:      config.ConfigDir,
 		PeerName:       config.PeerName,
 	}
-	ks, defaultkey, err := InitDefaultKeystore(keystoreParam, nodeoptions)
+	ks, defaultkey, err := InitDefaultKeystore(&keystoreParam, nodeoptions)
 	if err != nil {
 		cancel()
 		logger.Fatalf(err.Error())
@@ -115,8 +126,7 @@ func runLightnode(config cli.LightnodeFlag) {
 
 	nodename := "nodesdk_default"
 
-	datapath := config.DataDir + "/" + config.PeerName
-	dbManager, err := storage.CreateDb(datapath)
+	dbManager, err := storage.CreateDb(options.NewNodePaths(config.DataDir, config.PeerName).Root)
 	if err != nil {
 		logger.Fatalf(err.Error())
 	}
@@ -2,20 +2,37 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/host"
 	peerstore "github.com/libp2p/go-libp2p/core/peer"
+	maddr "github.com/multiformats/go-multiaddr"
 	"github.com/rumsystem/quorum/internal/pkg/cli"
 	"github.com/rumsystem/quorum/internal/pkg/conn/p2p"
+	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
 	"github.com/spf13/cobra"
 )
 
+var (
+	pingCount    int
+	pingInterval time.Duration
+	pingTimeout  time.Duration
+	pingJson     bool
+	pingWorkers  int
+)
+
 var pingCmd = &cobra.Command{
 	Use:   "ping",
 	Short: "Ping peer",
 	Run: func(cmd *cobra.Command, args []string) {
-		ping(peerList)
+		opt := p2p.PingOptions{Count: pingCount, Interval: pingInterval, Timeout: pingTimeout}
+		peers, err := cli.NormalizePeerAddrList(peerList)
+		cobra.CheckErr(err)
+		cobra.CheckErr(ping(*peers, opt, pingWorkers, pingJson))
 	},
 }
 
@@ -25,40 +42,138 @@ func init() {
 	flags := pingCmd.Flags()
 	flags.SortFlags = false
 	flags.VarP(&peerList, "peer", "p", "peer address")
+	flags.IntVar(&pingCount, "ping-count", p2p.DefaultPingCount, "number of ping rounds for each peer")
+	flags.DurationVar(&pingInterval, "ping-interval", p2p.DefaultPingInterval, "wait between ping rounds")
+	flags.DurationVar(&pingTimeout, "ping-timeout", p2p.DefaultPingTimeout, "wait for each reply, the round is lost after it")
+	flags.IntVar(&pingWorkers, "ping-workers", 8, "number of peers pinged concurrently")
+	flags.BoolVar(&pingJson, "ping-json", false, "print the results of all peers as a json array")
 	pingCmd.MarkFlagRequired("peer")
 }
 
-func ping(peerList cli.AddrList) {
+// peerPing is the ping of a peer, err is set if the peer could not be connected
+type peerPing struct {
+	addr    maddr.Multiaddr
+	rounds  []p2p.Result
+	summary *handlers.PingResult
+	err     error
+}
+
+// ping pings the peers by at most `workers` concurrent probes, the results are printed in the order of the peers.
+// With asJson, the results are printed as a json array, and a peer which could not be connected is reported by its error
+func ping(peerList cli.AddrList, opt p2p.PingOptions, workers int, asJson bool) error {
 	tcpAddr := "/ip4/127.0.0.1/tcp/0"
 	wsAddr := "/ip4/127.0.0.1/tcp/0/ws"
+	quicAddr := "/ip4/127.0.0.1/udp/0/quic-v1"
 	ctx := context.Background()
 	node, err := libp2p.New(
-		libp2p.ListenAddrStrings(tcpAddr, wsAddr),
+		libp2p.ListenAddrStrings(tcpAddr, wsAddr, quicAddr),
 		libp2p.Ping(false),
 	)
 	if err != nil {
-		logger.Fatal(err)
+		return fmt.Errorf("could not start the ping node: %s", err)
 	}
+	defer node.Close()
 
 	// configure our ping protocol
-	pingService := &p2p.PingService{Host: node}
-	node.SetStreamHandler(p2p.PingID, pingService.PingHandler)
+	pingService := p2p.NewPingService(node)
 
-	for _, addr := range peerList {
-		peer, err := peerstore.AddrInfoFromP2pAddr(addr)
-		if err != nil {
-			logger.Fatal(err)
+	if workers <= 0 {
+		workers = 1
+	}
+	pings := make([]*peerPing, len(peerList))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < len(peerList); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				pings[i] = pingPeer(ctx, node, pingService, peerList[i], opt)
+			}
+		}()
+	}
+	for i := range peerList {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	failed := 0
+	for _, p := range pings {
+		if p.err != nil {
+			failed++
+		}
+	}
+	failedErr := func() error {
+		if failed > 0 {
+			return fmt.Errorf("could not connect to %d of %d peers", failed, len(pings))
 		}
+		return nil
+	}
 
-		if err := node.Connect(ctx, *peer); err != nil {
-			logger.Fatal(err)
+	if asJson {
+		summaries := make([]*handlers.PingResult, len(pings))
+		for i, p := range pings {
+			summaries[i] = p.summary
+		}
+		output, err := json.MarshalIndent(summaries, "", "  ")
+		if err != nil {
+			return err
 		}
-		ch := pingService.Ping(ctx, peer.ID)
+		fmt.Println(string(output))
+		return failedErr()
+	}
+
+	for _, p := range pings {
 		fmt.Println()
-		fmt.Println("pinging remote peer at", addr)
-		for i := 0; i < 4; i++ {
-			res := <-ch
-			fmt.Println("PING", addr, "in", res.RTT)
+		if p.err != nil {
+			fmt.Println(p.err)
+			continue
+		}
+		fmt.Println("pinging remote peer at", p.addr)
+		for _, res := range p.rounds {
+			if res.Error != nil {
+				fmt.Println("PING", p.addr, "failed:", res.Error)
+			} else {
+				fmt.Println("PING", p.addr, "in", res.RTT)
+			}
+		}
+		summary := p.summary
+		fmt.Printf("%d sent, %d received, %d lost\n", summary.Sent, summary.Sent-summary.Lost, summary.Lost)
+		if len(summary.RTTs) > 0 {
+			fmt.Printf("rtt min/avg/max/stddev = %.3f/%.3f/%.3f/%.3f ms\n", summary.Min, summary.Avg, summary.Max, summary.Stddev)
 		}
 	}
+	return failedErr()
+}
+
+// pingPeer connects to the peer in the ping timeout and pings it
+func pingPeer(ctx context.Context, node host.Host, pingService *p2p.PingService, addr maddr.Multiaddr, opt p2p.PingOptions) *peerPing {
+	p := &peerPing{addr: addr}
+	peer, err := peerstore.AddrInfoFromP2pAddr(addr)
+	if err != nil {
+		p.err = fmt.Errorf("invalid peer addr %s: %s", addr, err)
+		p.summary = handlers.NewPingResult(addr.String(), nil)
+		p.summary.Error = p.err.Error()
+		return p
+	}
+
+	timeout := opt.Timeout
+	if timeout <= 0 {
+		timeout = p2p.DefaultPingTimeout
+	}
+	connectCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if err := node.Connect(connectCtx, *peer); err != nil {
+		p.err = fmt.Errorf("could not connect to %s: %s", addr, err)
+		p.summary = handlers.NewPingResult(addr.String(), nil)
+		p.summary.PeerId = peer.ID.String()
+		p.summary.Error = p.err.Error()
+		return p
+	}
+
+	p.rounds = pingService.PingPeer(ctx, peer.ID, opt)
+	p.summary = handlers.NewPingResult(addr.String(), p.rounds)
+	p.summary.PeerId = peer.ID.String()
+	return p
 }
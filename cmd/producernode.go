@@ -11,7 +11,6 @@ import (
 
 	"github.com/fatih/color"
 	discovery "github.com/libp2p/go-libp2p/p2p/discovery/util"
-	connmgr "github.com/libp2p/go-libp2p/p2p/net/connmgr"
 	"github.com/rumsystem/quorum/internal/pkg/appdata"
 	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
 	"github.com/rumsystem/quorum/internal/pkg/cli"
@@ -19,10 +18,12 @@ import (
 	"github.com/rumsystem/quorum/internal/pkg/conn/p2p"
 	"github.com/rumsystem/quorum/internal/pkg/nodectx"
 	"github.com/rumsystem/quorum/internal/pkg/options"
+	"github.com/rumsystem/quorum/internal/pkg/stats"
 	"github.com/rumsystem/quorum/internal/pkg/storage"
 	chainstorage "github.com/rumsystem/quorum/internal/pkg/storage/chain"
 	"github.com/rumsystem/quorum/internal/pkg/utils"
 	"github.com/rumsystem/quorum/pkg/chainapi/api"
+	"github.com/rumsystem/quorum/pkg/consensus"
 	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -39,6 +40,16 @@ var producerNodeCmd = &cobra.Command{
 	Use:   "producernode",
 	Short: "Run producernode",
 	Run: func(cmd *cobra.Command, args []string) {
+		if err := loadConfigFile(producerViper, cmd.Flags()); err != nil {
+			logger.Fatal(err)
+		}
+		if printConfig {
+			if err := printEffectiveConfig(producerViper, cmd.Flags()); err != nil {
+				logger.Fatal(err)
+			}
+			return
+		}
+
 		if err := producerViper.Unmarshal(&producerNodeFlag); err != nil {
 			logger.Fatalf("viper unmarshal failed: %s", err)
 		}
@@ -54,13 +65,18 @@ var producerNodeCmd = &cobra.Command{
 		}
 		if len(producerNodeFlag.BootstrapPeers) == 0 {
 			if len(producerViper.GetStringSlice("peer")) != 0 {
-				addrlist, err := cli.ParseAddrList(strings.Join(producerViper.GetStringSlice("peer"), ","))
+				addrlist, err := cli.ParsePeerAddrList(strings.Join(producerViper.GetStringSlice("peer"), ","))
 				if err != nil {
 					logger.Fatalf("parse bootstrap peer addr list failed: %s", err)
 				}
 				producerNodeFlag.BootstrapPeers = *addrlist
 			}
 		}
+		persistentPeers, err := cli.NormalizePeerAddrs(producerNodeFlag.PersistentPeers)
+		if err != nil {
+			logger.Fatalf("parse persistent peer addr list failed: %s", err)
+		}
+		producerNodeFlag.PersistentPeers = persistentPeers
 
 		if producerNodeFlag.KeyStorePwd == "" {
 			producerNodeFlag.KeyStorePwd = os.Getenv("RUM_KSPASSWD")
@@ -82,24 +98,63 @@ func init() {
 	flags.String("keystoredir", "./keystore/", "keystore dir")
 	flags.String("keystorename", "default", "keystore name")
 	flags.String("keystorepass", "", "keystore password")
-	flags.StringSlice("listen", nil, "Adds a multiaddress to the listen list, e.g.: --listen /ip4/127.0.0.1/tcp/4215 --listen /ip/127.0.0.1/tcp/5215/ws")
+	flags.StringSlice("listen", nil, "Adds a multiaddress to the listen list, e.g.: --listen /ip4/127.0.0.1/tcp/4215 --listen /ip/127.0.0.1/tcp/5215/ws --listen /ip4/127.0.0.1/udp/4215/quic-v1")
 	flags.String("apihost", "localhost", "Domain or public ip addresses for api server")
 	flags.Int("apiport", 5215, "api server listen port")
-	flags.String("certdir", "certs", "ssl certificate directory")
+	flags.String("certdir", options.DefaultCertDir, "ssl certificate directory")
 	flags.String("zerosslaccesskey", "", "zerossl access key, get from: https://app.zerossl.com/developer")
 	flags.StringSlice("peer", nil, "bootstrap peer address")
 	flags.String("jsontracer", "", "output tracer data to a json file")
 	flags.Bool("debug", false, "show debug log")
+	flags.Duration("shutdown-timeout", defaultShutdownTimeout, "wait for active api requests and group teardown on exit")
+	flags.String("consensus", consensus.DefaultConsensus, fmt.Sprintf("consensus of new groups, one of: %v, loaded groups keep the one they are created with", consensus.Names()))
+	flags.Uint64("snapshot-interval", consensus.SnapshotInterval, "create a signed snapshot every n blocks on producers, 0 to disable")
+	flags.Int("heartbeat-missed-intervals", consensus.HBMissIntervals, "warn if no consensus heartbeat is received from a producer for the number of propose intervals")
+	flags.String("storage-backend", storage.DefaultBackend, fmt.Sprintf("storage backend of the node dbs, one of: %v", storage.BackendNames()))
+	flags.Int("db-migrate-to", -1, "migrate the dbs to the schema version, a lower version rolls back, -1 for the latest")
+	flags.Bool("db-migrate-dry-run", false, "print the db migrations to run and exit")
+	flags.Bool("db-encrypt-migrate", false, "encrypt the existing plaintext dbs when EnableDbEncrypt is set, an interrupted run is resumed")
+	flags.String("api-unix-socket", "", "also serve the api on the unix socket, set --apiport 0 to serve it only on the socket")
+	flags.String("pprof-listen", "", "serve pprof on the address, e.g. :6060, the host defaults to localhost, disabled if not set")
+	flags.StringSlice("persistent-peer", nil, "p2p multiaddr of a peer kept connected and redialed when it drops, can be repeated")
+	flags.Bool("enable-mdns", false, "discover the peers on the local network by mdns")
+	flags.String("peerstore-import", "", "merge the peers of a file exported by GET /api/v1/network/peerstore into the peerstore on startup, and dial them")
+	flags.Bool("systemd-notify", false, "notify systemd READY=1 once the api server is listening and the groups are loaded, and send the watchdog pings if WatchdogSec is set")
+	flags.String("groups-db-dir", "", "directory of the groups db, <datadir>/<peername> if not set")
+	flags.String("chain-db-dir", "", "directory of the chain db keeping blocks, trxs and posts, <datadir>/<peername> if not set")
+	flags.String("appdb-dir", "", "directory of the app db, <datadir>/<peername> if not set")
+	flags.Bool("print-identity", false, "print the peer id, eth address, public key and listen addrs as json and exit, GET /api/v1/node/identity returns them from a running node")
 
 	if err := producerViper.BindPFlags(flags); err != nil {
 		logger.Fatalf("viper bind flags failed: %s", err)
 	}
+	addConfigFileFlags(flags)
 }
 
 func runProducerNode(config cli.ProducerNodeFlag) {
 	color.Green("Version:%s", utils.GitCommit)
 	const defaultKeyName = "default"
 
+	if config.PprofListen != "" {
+		addr, err := utils.StartPprofServer(config.PprofListen, config.APIPort)
+		if err != nil {
+			logger.Fatalf("start pprof failed: %s", err)
+		}
+		logger.Infof("pprof listens on http://%s/debug/pprof/", addr)
+	}
+
+	if !consensus.IsRegistered(config.Consensus) {
+		logger.Fatalf("unknown consensus: %s, registered: %v", config.Consensus, consensus.Names())
+	}
+	consensus.SnapshotInterval = config.SnapshotInterval
+	consensus.HBMissIntervals = config.HBMissIntervals
+	if !storage.IsBackendRegistered(config.StorageBackend) {
+		logger.Fatalf("unknown storage backend: %s, registered: %v", config.StorageBackend, storage.BackendNames())
+	}
+	if config.StorageBackend != "" {
+		storage.DefaultBackend = config.StorageBackend
+	}
+
 	producerSignalCh = make(chan os.Signal, 1)
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -113,6 +168,7 @@ func runProducerNode(config cli.ProducerNodeFlag) {
 		cancel()
 		logger.Fatalf(err.Error())
 	}
+	applyLogLevels(nodeoptions.LogLevels)
 
 	nodeoptions.EnableRelay = false
 
@@ -125,7 +181,7 @@ func runProducerNode(config cli.ProducerNodeFlag) {
 		DefaultKeyName: defaultKeyName,
 	}
 
-	ks, defaultkey, err := InitDefaultKeystore(keystoreParam, nodeoptions)
+	ks, defaultkey, err := InitDefaultKeystore(&keystoreParam, nodeoptions)
 	if err != nil {
 		cancel()
 		logger.Fatalf(err.Error())
@@ -152,17 +208,30 @@ func runProducerNode(config cli.ProducerNodeFlag) {
 
 	nodename := "producernode_default"
 
-	datapath := config.DataDir + "/" + config.PeerName
-	dbManager, err := storage.CreateDb(datapath)
+	if config.PrintIdentity {
+		printNodeIdentity(ctx, nodename, nodeoptions, defaultkey, config.ListenAddresses, ethaddr)
+		return
+	}
+
+	if nodeoptions.EnableDbEncrypt {
+		enableDbEncryption(keystoreParam.KeystorePwd, config.DbEncryptMigrate)
+	}
+
+	paths := nodePaths(config.DataDir, config.PeerName, config.GroupsDbDir, config.ChainDbDir, config.AppDbDir, config.CertDir)
+	dbManager, err := storage.CreateDbWithPaths(paths.GroupsDb, paths.ChainDb, storage.DefaultBackend)
 
 	if err != nil {
 		logger.Fatalf(err.Error())
 	}
+	migrateDataVersion(dbManager, config.DbMigrateTo, config.DbMigrateDryRun)
 
 	newchainstorage := chainstorage.NewChainStorage(dbManager)
+	if err := stats.InitDB(ctx, paths.Root, storage.DefaultBackend, nodeoptions.StatsMinuteKeep, nodeoptions.StatsHourKeep); err != nil {
+		logger.Fatalf("open stats db failed: %s", err)
+	}
 
-	//normal node connections: low watermarks: 10  hi watermarks 200, grace 60s
-	cm, err := connmgr.NewConnManager(10, nodeoptions.ConnsHi, connmgr.WithGracePeriod(60*time.Second))
+	//normal node connections: watermarks and grace period of the node options, default low 10 hi 100 grace 60s
+	cm, err := p2p.NewConnMgr(nodeoptions.ConnsLo, nodeoptions.ConnsHi, nodeoptions.ConnsGrace)
 	if err != nil {
 		logger.Fatalf(err.Error())
 	}
@@ -172,6 +241,7 @@ func runProducerNode(config cli.ProducerNodeFlag) {
 	}
 
 	nodectx.InitCtx(ctx, nodename, producerNode, dbManager, newchainstorage, "pubsub", utils.GitCommit, nodectx.PRODUCER_NODE)
+	nodectx.GetNodeCtx().Consensus = config.Consensus
 	nodectx.GetNodeCtx().Keystore = ks
 	nodectx.GetNodeCtx().PublicKey = keys.PubKey
 	nodectx.GetNodeCtx().PeerId = peerid
@@ -181,6 +251,16 @@ func runProducerNode(config cli.ProducerNodeFlag) {
 
 	//initial group manager
 	chain.InitGroupMgr()
+	chain.GetGroupMgr().SetMaxSyncingGroups(nodeoptions.MaxSyncingGroups)
+	chain.SetIdempotencyKeyWindow(nodeoptions.PubQueKeyWindow)
+	chain.SetRexResultQueueSize(nodeoptions.RexBufferSize)
+	if nodeoptions.EnablePubQue {
+		chain.SetDefaultPublishRetryPolicy(nodeoptions.PubQueMaxAttempts, nodeoptions.PubQueBaseBackoff, nodeoptions.PubQueMaxBackoff)
+		chain.GetGroupMgr().StartPublishQueueWatcher(ctx, time.Second, nodeoptions.PubQueWorkers)
+	}
+	if nodeoptions.StorageGCInterval > 0 {
+		dbManager.StartCompaction(ctx, nodeoptions.StorageGCInterval, nodeoptions.StorageGCRatio)
+	}
 
 	//load all groups
 	err = chain.GetGroupMgr().LoadAllGroups()
@@ -188,7 +268,7 @@ func runProducerNode(config cli.ProducerNodeFlag) {
 		logger.Fatalf(err.Error())
 	}
 
-	appdb, err := appdata.CreateAppDb(datapath)
+	appdb, err := appdata.CreateAppDb(paths.AppDb)
 	if err != nil {
 		logger.Fatalf(err.Error())
 	}
@@ -198,6 +278,26 @@ func runProducerNode(config cli.ProducerNodeFlag) {
 	if err := producerNode.Bootstrap(ctx, config.BootstrapPeers); err != nil {
 		logger.Fatal(err)
 	}
+	if config.PeerstoreImport != "" {
+		peers, err := producerNode.ImportPeerstoreFile(config.PeerstoreImport)
+		if err != nil {
+			logger.Fatalf("import peerstore failed: %s", err)
+		}
+		if len(peers) > nodeoptions.MaxPeers {
+			peers = peers[:nodeoptions.MaxPeers]
+		}
+		go producerNode.AddPeers(ctx, peers)
+	}
+	persistentPeers, err := p2p.ParsePeerAddrs(append(config.PersistentPeers, nodeoptions.PersistentPeers...))
+	if err != nil {
+		logger.Fatal(err)
+	}
+	producerNode.KeepPersistentPeers(ctx, persistentPeers)
+	if config.EnableMdns {
+		if err := producerNode.StartMdns(ctx); err != nil {
+			logger.Fatalf("start mdns failed: %s", err)
+		}
+	}
 
 	for _, addr := range producerNode.Host.Addrs() {
 		p2paddr := fmt.Sprintf("%s/p2p/%s", addr.String(), producerNode.Host.ID())
@@ -232,23 +332,29 @@ func runProducerNode(config cli.ProducerNodeFlag) {
 		IsDebug:       config.IsDebug,
 		APIHost:       config.APIHost,
 		APIPort:       config.APIPort,
-		CertDir:       config.CertDir,
+		CertDir:       paths.Certs,
 		ZeroAccessKey: config.ZeroAccessKey,
+		UnixSocket:    config.APIUnixSocket,
 	}
 
 	go api.StartProducerServer(startParam, producerSignalCh, h, producerNode, nodeoptions, ks, ethaddr)
+	if config.SystemdNotify {
+		go notifySystemd(ctx)
+	}
 
-	//attach signal
-	signal.Notify(producerSignalCh, os.Interrupt, os.Kill, syscall.SIGTERM)
+	//attach signal, SIGHUP reloads the options file
+	signal.Notify(producerSignalCh, os.Interrupt, os.Kill, syscall.SIGTERM, syscall.SIGHUP)
 	signalType := <-producerSignalCh
+	for signalType == syscall.SIGHUP {
+		reloadNodeOptions(ctx, producerNode, nodeoptions, config.PersistentPeers)
+		signalType = <-producerSignalCh
+	}
 	signal.Stop(producerSignalCh)
 
-	//Stop sync all groups
-	chain.GetGroupMgr().StopSyncAllGroups()
-	//teardown all groups
-	chain.GetGroupMgr().TeardownAllGroups()
-	//close ctx db
-	nodectx.GetDbMgr().CloseDb()
+	if config.SystemdNotify {
+		notifySystemdStopping()
+	}
+	shutdownNode(config.ShutdownTimeout)
 
 	//cleanup before exit
 	logger.Infof("On Signal <%s>", signalType)
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
+)
+
+// newProgressPrinter returns a progress callback which prints the percentage of each stage to stderr
+func newProgressPrinter() handlers.ProgressFunc {
+	lastStage := ""
+	lastPercent := int64(-1)
+
+	return func(stage string, done, total int64) {
+		if stage != lastStage {
+			if lastStage != "" {
+				fmt.Fprintln(os.Stderr)
+			}
+			lastStage = stage
+			lastPercent = -1
+		}
+
+		if total <= 0 {
+			fmt.Fprintf(os.Stderr, "\r%-10s %d", stage, done)
+			return
+		}
+
+		percent := done * 100 / total
+		if percent == lastPercent {
+			return
+		}
+		lastPercent = percent
+		fmt.Fprintf(os.Stderr, "\r%-10s %3d%% (%d/%d)", stage, percent, done, total)
+		if done >= total {
+			fmt.Fprintln(os.Stderr)
+			lastStage = ""
+		}
+	}
+}
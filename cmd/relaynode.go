@@ -26,6 +26,16 @@ var relaynodeCmd = &cobra.Command{
 	Use:   "relaynode",
 	Short: "Run relaynode",
 	Run: func(cmd *cobra.Command, args []string) {
+		if err := loadConfigFile(rnodeViper, cmd.Flags()); err != nil {
+			logger.Fatal(err)
+		}
+		if printConfig {
+			if err := printEffectiveConfig(rnodeViper, cmd.Flags()); err != nil {
+				logger.Fatal(err)
+			}
+			return
+		}
+
 		if err := rnodeViper.Unmarshal(&rnodeFlag); err != nil {
 			logger.Fatalf("viper unmarshal failed: %s", err)
 		}
@@ -41,7 +51,7 @@ var relaynodeCmd = &cobra.Command{
 		}
 		if len(rnodeFlag.BootstrapPeers) == 0 {
 			if len(rnodeViper.GetStringSlice("peer")) != 0 {
-				addrlist, err := cli.ParseAddrList(strings.Join(rnodeViper.GetStringSlice("peer"), ","))
+				addrlist, err := cli.ParsePeerAddrList(strings.Join(rnodeViper.GetStringSlice("peer"), ","))
 				if err != nil {
 					logger.Fatalf("parse bootstrap peer addr list failed: %s", err)
 				}
@@ -79,6 +89,7 @@ func init() {
 	if err := rnodeViper.BindPFlags(flags); err != nil {
 		logger.Fatalf("viper bind flags failed: %s", err)
 	}
+	addConfigFileFlags(flags)
 }
 
 func runRelaynode(config cli.RelayNodeFlag) {
@@ -120,7 +131,7 @@ func runRelaynode(config cli.RelayNodeFlag) {
 	logger.Infof("eth addresss: <%s>", ethaddr)
 
 	bucket := "relaydb"
-	rdb, err := storage.NewStore(ctx, config.DataDir+"/"+config.PeerName, bucket)
+	rdb, err := storage.NewStore(ctx, options.NewNodePaths(config.DataDir, config.PeerName).Root, bucket)
 	if err != nil {
 		logger.Fatalf(err.Error())
 	}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/rumsystem/quorum/internal/pkg/conn/p2p"
+	"github.com/rumsystem/quorum/internal/pkg/logging"
+	"github.com/rumsystem/quorum/internal/pkg/options"
+	"github.com/rumsystem/quorum/pkg/chainapi/api"
+)
+
+// reloadNodeOptions applies options.ReloadableOptions of the options file on SIGHUP,
+// the libp2p host and the groups keep running. flagPeers are the persistent peers of the command line.
+func reloadNodeOptions(ctx context.Context, node *p2p.Node, nodeoptions *options.NodeOptions, flagPeers []string) {
+	ignored, err := nodeoptions.Reload()
+	if err != nil {
+		logger.Errorf("reload options failed: %s", err)
+		return
+	}
+
+	applyLogLevels(nodeoptions.LogLevels)
+	node.ResetPeerLists(nodeoptions.PeerAllowList, nodeoptions.PeerBlockList)
+	api.ReloadRateLimits(nodeoptions)
+	peers, err := p2p.ParsePeerAddrs(append(append([]string{}, flagPeers...), nodeoptions.PersistentPeers...))
+	if err != nil {
+		logger.Errorf("persistent peers not reloaded: %s", err)
+	} else {
+		node.KeepPersistentPeers(ctx, peers)
+	}
+
+	logger.Infof("reloaded options %v", options.ReloadableOptions)
+	if len(ignored) > 0 {
+		logger.Warnf("ignored changed options %v, they need a restart", ignored)
+	}
+}
+
+func applyLogLevels(levels map[string]string) {
+	for subsystem, level := range levels {
+		if err := logging.SetLogLevel(subsystem, level); err != nil {
+			logger.Warnf("set log level of %s failed: %s", subsystem, err)
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/phayes/freeport"
+	"github.com/rumsystem/quorum/internal/pkg/options"
 	"github.com/rumsystem/quorum/internal/pkg/utils"
 	"github.com/rumsystem/quorum/pkg/chainapi/api"
 	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
@@ -38,6 +39,12 @@ var restoreCmd = &cobra.Command{
 			KeystoreDir: keystoreDir,
 			DataDir:     dataDir,
 			SeedDir:     seedDir,
+			Paths:       backupNodePaths(),
+
+			IdentityFile:     identityFile,
+			IncrementalFiles: incrementalFiles,
+			DryRun:           restoreDryRun,
+			Progress:         newProgressPrinter(),
 		}
 		restore(params)
 	},
@@ -52,9 +59,16 @@ func init() {
 	flags.StringVar(&configDir, "configdir", "config", "config directory")
 	flags.StringVar(&keystoreDir, "keystoredir", "keystore", "keystore directory")
 	flags.StringVar(&dataDir, "datadir", "data", "data directory")
-	flags.StringVar(&seedDir, "seeddir", "seeds", "seeds directory")
+	flags.StringVar(&seedDir, "seeddir", options.DefaultSeedDir, "seeds directory")
+	flags.StringVar(&groupsDbDir, "groups-db-dir", "", "directory of the groups db, <datadir>/<peername> if not set")
+	flags.StringVar(&chainDbDir, "chain-db-dir", "", "directory of the chain db, <datadir>/<peername> if not set")
+	flags.StringVar(&appDbDir, "appdb-dir", "", "directory of the app db, <datadir>/<peername> if not set")
 	flags.StringVar(&keystorePassword, "keystorepass", "", "keystore password")
 	flags.StringVar(&backupFile, "file", "", "backup file path")
+	flags.StringVar(&identityFile, "identity-file", "", "age identity file to decrypt the backup, decrypt with keystore password if not set")
+	flags.BoolVar(&restoreDryRun, "dry-run", false, "only print what would be changed by restore")
+	flags.BoolVar(&restoreGroup, "group", false, "restore a group backup and merge it into the existing node")
+	flags.StringSliceVar(&incrementalFiles, "increment", nil, "incremental backup file path, apply in order after the full backup")
 
 	restoreCmd.MarkFlagRequired("file")
 }
@@ -81,6 +95,33 @@ func restore(params handlers.RestoreParam) {
 	if err != nil {
 		logger.Fatalf("get absolute path for %s failed: %s", params.SeedDir, err)
 	}
+	params.Paths.Seeds = params.SeedDir
+	// the restore runs in the directory of the data dir
+	for _, dir := range []*string{&params.Paths.Root, &params.Paths.GroupsDb, &params.Paths.ChainDb, &params.Paths.AppDb} {
+		if *dir, err = filepath.Abs(*dir); err != nil {
+			logger.Fatalf("get absolute path for %s failed: %s", *dir, err)
+		}
+	}
+	if params.IdentityFile != "" {
+		params.IdentityFile, err = filepath.Abs(params.IdentityFile)
+		if err != nil {
+			logger.Fatalf("get absolute path for %s failed: %s", params.IdentityFile, err)
+		}
+	}
+	for i, incFile := range params.IncrementalFiles {
+		params.IncrementalFiles[i], err = filepath.Abs(incFile)
+		if err != nil {
+			logger.Fatalf("get absolute path for %s failed: %s", incFile, err)
+		}
+	}
+
+	// print restore plan before forking the temporary node
+	if params.DryRun {
+		if err := handlers.Restore(params); err != nil {
+			logger.Fatalf("restore dry run failed: %s", err)
+		}
+		return
+	}
 
 	// go to restore directory before restore
 	restoreDir := filepath.Dir(params.DataDir)
@@ -96,7 +137,19 @@ func restore(params handlers.RestoreParam) {
 	os.Chdir(restoreDir)
 	defer os.Chdir(currentDir)
 
-	handlers.Restore(params)
+	// seed file names to join after restore, empty means all seeds in seed directory
+	var seedNames []string
+	if restoreGroup {
+		groupId, err := handlers.RestoreGroup(params)
+		if err != nil {
+			logger.Fatalf("restore group failed: %s", err)
+		}
+		seedNames = append(seedNames, fmt.Sprintf("%s.json", groupId))
+	} else {
+		if err := handlers.Restore(params); err != nil {
+			logger.Fatalf("restore failed: %s", err)
+		}
+	}
 
 	var pidch chan int
 	process := os.Args[0]
@@ -113,6 +166,9 @@ func restore(params handlers.RestoreParam) {
 		"--configdir", params.ConfigDir,
 		"--keystoredir", params.KeystoreDir,
 		"--datadir", params.DataDir,
+		"--groups-db-dir", params.Paths.GroupsDb,
+		"--chain-db-dir", params.Paths.ChainDb,
+		"--appdb-dir", params.Paths.AppDb,
 	)
 
 	peerBaseUrl := fmt.Sprintf("http://127.0.0.1:%d", apiPort)
@@ -122,33 +178,35 @@ func restore(params handlers.RestoreParam) {
 		logger.Fatal("api server start failed")
 	}
 
-	if utils.DirExist(params.SeedDir) {
+	if utils.DirExist(params.SeedDir) && len(seedNames) == 0 {
 		seeds, err := ioutil.ReadDir(params.SeedDir)
 		if err != nil {
 			logger.Errorf("read seeds directory failed: %s", err)
 		}
 
 		for _, seed := range seeds {
-			if seed.IsDir() {
-				continue
+			if !seed.IsDir() {
+				seedNames = append(seedNames, seed.Name())
 			}
+		}
+	}
 
-			path := filepath.Join(params.SeedDir, seed.Name())
-			seedByte, err := ioutil.ReadFile(path)
-			if err != nil {
-				logger.Errorf("read seed file failed: %s", err)
-				continue
-			}
+	for _, name := range seedNames {
+		path := filepath.Join(params.SeedDir, name)
+		seedByte, err := ioutil.ReadFile(path)
+		if err != nil {
+			logger.Errorf("read seed file failed: %s", err)
+			continue
+		}
 
-			var seed handlers.CreateGroupResult
-			if err := json.Unmarshal(seedByte, &seed); err != nil {
-				logger.Errorf("unmarshal seed file failed: %s", err)
-				continue
-			}
+		var seed handlers.CreateGroupResult
+		if err := json.Unmarshal(seedByte, &seed); err != nil {
+			logger.Errorf("unmarshal seed file failed: %s", err)
+			continue
+		}
 
-			if _, err := api.JoinGroupByHTTPRequest(peerBaseUrl, &seed); err != nil {
-				logger.Errorf("join group %s failed: %s", seed.GroupId, err)
-			}
+		if _, err := api.JoinGroupByHTTPRequest(peerBaseUrl, &seed); err != nil {
+			logger.Errorf("join group %s failed: %s", seed.GroupId, err)
 		}
 	}
 
@@ -17,14 +17,19 @@ var (
 	logger = logging.Logger("cmd")
 
 	logLevel      string
+	logFormat     string // console or json
 	logFile       string
 	logMaxSize    int // megabytes
 	logMaxBackups int
 	logMaxAge     int // days
 	logCompress   bool
+	logStderr     bool // mirror the log file to stderr
 
 	isDebug bool // true is lower(logLevel) == "debug" else false
 
+	configFile  string // node config file
+	printConfig bool
+
 	// flags
 	peerName         string
 	peerList         cli.AddrList
@@ -34,7 +39,18 @@ var (
 	keystorePassword string
 	dataDir          string
 	seedDir          string
+	groupsDbDir      string // backup and restore
+	chainDbDir       string // backup and restore
+	appDbDir         string // backup and restore
 	backupFile       string
+	incrementalFrom  string   // backup
+	backupGroupId    string   // backup
+	backupRecipients []string // backup
+	backupWorkDir    string   // backup
+	identityFile     string   // restore
+	restoreGroup     bool     // restore
+	restoreDryRun    bool     // restore
+	incrementalFiles []string // restore
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -53,11 +69,15 @@ func Execute() {
 func init() {
 	cobra.OnInitialize(initConfig)
 	rootCmd.PersistentFlags().StringVar(&logLevel, "loglevel", "error", "log level")
-	rootCmd.PersistentFlags().StringVar(&logFile, "logfile", "", "log file, default output to stdout")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "console", "log format, console or json")
+	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", "", "log file rotated by size and age, default output to stderr")
+	rootCmd.PersistentFlags().StringVar(&logFile, "logfile", "", "log file, default output to stderr")
+	rootCmd.PersistentFlags().MarkDeprecated("logfile", "use --log-file instead")
 	rootCmd.PersistentFlags().IntVar(&logMaxSize, "log-max-size", 100, "log file max size, unit: megabytes")
 	rootCmd.PersistentFlags().IntVar(&logMaxAge, "log-max-age", 7, "log file max ages, unit: day")
 	rootCmd.PersistentFlags().IntVar(&logMaxBackups, "log-max-backups", 3, "log file max backups count")
 	rootCmd.PersistentFlags().BoolVar(&logCompress, "log-compress", true, "is log file compress")
+	rootCmd.PersistentFlags().BoolVar(&logStderr, "log-stderr", false, "also write the logs to stderr when --log-file is set")
 }
 
 func initConfig() {
@@ -80,23 +100,35 @@ func initConfig() {
 	logging.SetLogLevel("reuseport-transport", "error")
 	logging.SetLogLevel("upgrader", "error")
 
+	if logFormat != "console" && logFormat != "json" {
+		logger.Fatalf("unknown log format: %s", logFormat)
+	}
+	if logFile == "" && logFormat == "console" {
+		return
+	}
+
+	w := zapcore.Lock(os.Stderr)
 	if logFile != "" {
-		w := zapcore.AddSync(&lumberjack.Logger{
+		w = zapcore.AddSync(&lumberjack.Logger{
 			Filename:   logFile,
 			MaxSize:    logMaxSize,
 			MaxBackups: logMaxBackups,
 			MaxAge:     logMaxAge,
 			Compress:   logCompress,
 		})
+	}
 
-		encoderCfg := zap.NewProductionEncoderConfig()
-		encoderCfg.EncodeTime = zapcore.TimeEncoderOfLayout(time.RFC3339)
+	encoderCfg := zap.NewProductionEncoderConfig()
+	encoderCfg.EncodeTime = zapcore.TimeEncoderOfLayout(time.RFC3339)
+	encoder := zapcore.NewConsoleEncoder(encoderCfg)
+	if logFormat == "json" {
+		encoder = zapcore.NewJSONEncoder(encoderCfg)
+	}
 
-		core := zapcore.NewCore(
-			zapcore.NewConsoleEncoder(encoderCfg),
-			w,
-			zapcore.Level(lvl),
-		)
-		logging.SetPrimaryCore(core)
+	// the levels are filtered by the loggers, so that they can be changed at runtime
+	core := zapcore.NewCore(encoder, w, zapcore.DebugLevel)
+	if logFile != "" && logStderr {
+		core = zapcore.NewTee(core, zapcore.NewCore(encoder, zapcore.Lock(os.Stderr), zapcore.DebugLevel))
 	}
+	logging.SetPrimaryCore(core)
 }
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
+	"github.com/rumsystem/quorum/internal/pkg/nodectx"
+	"github.com/rumsystem/quorum/pkg/chainapi/api"
+)
+
+const defaultShutdownTimeout = 30 * time.Second
+
+// shutdownNode stop the api server after active requests are done, then stop sync and teardown all groups,
+// the db is left open when timeout elapses, the teardown still running may write to it
+func shutdownNode(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := api.ShutdownServer(ctx); err != nil {
+		logger.Warnf("shutdown api server failed: %s", err)
+	}
+	api.ShutdownGRPCServer()
+
+	if failed := chain.GetGroupMgr().ShutdownAllGroups(ctx); len(failed) > 0 {
+		logger.Warnf("shutdown timeout, groups not torn down: %v, skip closing db", failed)
+		return
+	}
+
+	//close ctx db
+	nodectx.GetDbMgr().CloseDb()
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/coreos/go-systemd/v22/daemon"
+	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
+	"github.com/rumsystem/quorum/pkg/chainapi/api"
+)
+
+const systemdReadyPollInterval = 200 * time.Millisecond
+
+// notifySystemd sends READY=1 once the api server is listening and the groups are loaded,
+// then sends WATCHDOG=1 at half of WatchdogSec until ctx is done. It does nothing if NOTIFY_SOCKET is not set.
+func notifySystemd(ctx context.Context) {
+	ticker := time.NewTicker(systemdReadyPollInterval)
+	defer ticker.Stop()
+	for !api.ServerListening() || !chain.GetGroupMgr().Loaded() {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+
+	sent, err := daemon.SdNotify(false, daemon.SdNotifyReady)
+	if err != nil {
+		logger.Warnf("notify systemd ready failed: %s", err)
+		return
+	}
+	if !sent {
+		logger.Warn("systemd notify is enabled, but NOTIFY_SOCKET is not set")
+		return
+	}
+	logger.Info("notified systemd ready")
+
+	interval, err := daemon.SdWatchdogEnabled(false)
+	if err != nil {
+		logger.Warnf("get systemd watchdog interval failed: %s", err)
+		return
+	}
+	if interval == 0 {
+		return
+	}
+	ticker.Reset(interval / 2)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := daemon.SdNotify(false, daemon.SdNotifyWatchdog); err != nil {
+				logger.Warnf("send systemd watchdog ping failed: %s", err)
+			}
+		}
+	}
+}
+
+// notifySystemdStopping tells systemd the node is shutting down
+func notifySystemdStopping() {
+	if _, err := daemon.SdNotify(false, daemon.SdNotifyStopping); err != nil {
+		logger.Warnf("notify systemd stopping failed: %s", err)
+	}
+}
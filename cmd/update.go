@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	updateFrom          string
+	updateCheck         bool
+	updateRollback      bool
+	updateHistory       bool
+	updateHealthTimeout time.Duration
+)
+
+// updateCmd represents the update command
+var updateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Update to the latest version, or roll back to the previous one",
+	Long: `Update to the latest version, the replaced binary is kept as <binary>.prev.
+The new binary is rolled back automatically if "<binary> version" fails within the health check timeout.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := utils.UpdateOptions{
+			HealthCheckArgs:    []string{"version"},
+			HealthCheckTimeout: updateHealthTimeout,
+		}
+
+		switch {
+		case updateCheck:
+			result, err := utils.CheckUpdateOnly(updateFrom, utils.ReleaseVersion, "quorum")
+			if err != nil {
+				logger.Fatalf("check update failed: %s", err)
+			}
+			output, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				logger.Fatalf("json.Marshal failed: %s", err)
+			}
+			fmt.Println(string(output))
+		case updateHistory:
+			target, err := opts.Target()
+			if err != nil {
+				logger.Fatal(err)
+			}
+			history, err := utils.GetUpdateHistory(target)
+			if err != nil {
+				logger.Fatalf("read update history failed: %s", err)
+			}
+			output, err := json.MarshalIndent(history, "", "  ")
+			if err != nil {
+				logger.Fatalf("json.Marshal failed: %s", err)
+			}
+			fmt.Println(string(output))
+		case updateRollback:
+			record, err := utils.RollbackUpdate(utils.ReleaseVersion, opts)
+			if err != nil {
+				logger.Fatalf("rollback failed: %s", err)
+			}
+			fmt.Printf("rolled back from %s to %s\n", record.PrevVersion, record.Version)
+		default:
+			if err := utils.CheckUpdateWithOptions(updateFrom, utils.ReleaseVersion, "quorum", opts); err != nil {
+				logger.Fatalf("Failed to do self-update: %s", err)
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(updateCmd)
+
+	flags := updateCmd.Flags()
+	flags.SortFlags = false
+
+	flags.StringVar(&updateFrom, "from", "qingcloud", "update from: github/qingcloud")
+	flags.BoolVar(&updateCheck, "check", false, "only report the latest version, nothing is installed")
+	flags.BoolVar(&updateRollback, "rollback", false, "restore the binary replaced by the last update")
+	flags.BoolVar(&updateHistory, "history", false, "show the update history")
+	flags.DurationVar(&updateHealthTimeout, "health-timeout", utils.DefaultUpdateHealthCheckTimeout, "roll back if the new binary fails to start within the timeout")
+}
@@ -1,13 +1,18 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
 
 	ethkeystore "github.com/ethereum/go-ethereum/accounts/keystore"
+	maddr "github.com/multiformats/go-multiaddr"
 	"github.com/rumsystem/quorum/internal/pkg/cli"
+	"github.com/rumsystem/quorum/internal/pkg/conn/p2p"
 	"github.com/rumsystem/quorum/internal/pkg/options"
+	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
 	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
 )
 
@@ -31,7 +36,8 @@ type InitKeystoreParam struct {
 	PeerName       string
 }
 
-func InitDefaultKeystore(config InitKeystoreParam, nodeoptions *options.NodeOptions) (localcrypto.Keystore, *ethkeystore.Key, error) {
+// InitDefaultKeystore unlocks or creates the keystore, config.KeystorePwd is set to the password prompted for
+func InitDefaultKeystore(config *InitKeystoreParam, nodeoptions *options.NodeOptions) (localcrypto.Keystore, *ethkeystore.Key, error) {
 	signkeycount, err := localcrypto.InitKeystore(config.KeystoreName, config.KeystoreDir)
 	ksi := localcrypto.GetKeystore()
 	if err != nil {
@@ -50,6 +56,7 @@ func InitDefaultKeystore(config InitKeystoreParam, nodeoptions *options.NodeOpti
 	if signkeycount > 0 {
 		if password == "" {
 			password, err = localcrypto.PassphrasePromptForUnlock()
+			config.KeystorePwd = password
 		}
 		err = ks.Unlock(nodeoptions.SignKeyMap, password)
 		if err != nil {
@@ -65,6 +72,7 @@ func InitDefaultKeystore(config InitKeystoreParam, nodeoptions *options.NodeOpti
 			fmt.Println("Your password:", password)
 			fmt.Println("After saving the password, press any key to continue.")
 			os.Stdin.Read(make([]byte, 1))
+			config.KeystorePwd = password
 		}
 		signkeyhexstr, err := localcrypto.LoadEncodedKeyFrom(config.ConfigDir, config.PeerName, "txt")
 		if err != nil {
@@ -188,3 +196,37 @@ func InitRelayNodeKeystore(config cli.RelayNodeFlag, defaultKeyName string, rela
 	}
 	return ks, defaultkey, nil
 }
+
+// nodePaths returns the default layout under <datadir>/<peername>, overridden by the non-empty dirs
+func nodePaths(dataDir, peerName, groupsDbDir, chainDbDir, appDbDir, certDir string) *options.NodePaths {
+	return options.NewNodePaths(dataDir, peerName).Override(&options.NodePaths{
+		GroupsDb: groupsDbDir,
+		ChainDb:  chainDbDir,
+		AppDb:    appDbDir,
+		Certs:    certDir,
+	})
+}
+
+// printNodeIdentity starts the host only, without the dbs, prints the identity of the node as json and stops it.
+// There are no observed addrs as no peer is connected.
+func printNodeIdentity(ctx context.Context, nodename string, nodeoptions *options.NodeOptions, key *ethkeystore.Key, listenAddresses []maddr.Multiaddr, ethaddr string) {
+	cm, err := p2p.NewConnMgr(nodeoptions.ConnsLo, nodeoptions.ConnsHi, nodeoptions.ConnsGrace)
+	if err != nil {
+		logger.Fatalf(err.Error())
+	}
+	node, err := p2p.NewNode(ctx, nodename, nodeoptions, false, key, cm, listenAddresses, []string{}, "")
+	if err != nil {
+		logger.Fatalf("create node failed: %s", err)
+	}
+	defer node.Host.Close()
+
+	identity, err := handlers.GetNodeIdentity(node, ethaddr)
+	if err != nil {
+		logger.Fatalf("get node identity failed: %s", err)
+	}
+	output, err := json.MarshalIndent(identity, "", "  ")
+	if err != nil {
+		logger.Fatalf("json.Marshal failed: %s", err)
+	}
+	fmt.Println(string(output))
+}
@@ -0,0 +1,111 @@
+package appdata
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
+)
+
+/*
+The blocklist of a group is a local view filter of this node: the posts of the blocked authors are left out of the
+content, search and feed apis of this node only. The blocks and the trxs are kept and synced as before, nothing is
+sent to the chain and other nodes are not affected, an author is not removed from the group by it.
+The pubkeys are keyed in the eth base64 format, an author blocked in the libp2p format hides the posts in both formats.
+*/
+
+type BlockedAuthor struct {
+	GroupId   string `json:"group_id" example:"5ed3f9fe-81e2-450d-9146-7a329aac2b62"`
+	Pubkey    string `json:"pubkey" example:"CAISIQOlA37+ghb05D5ZAKExjsto/H7eeCmkagcZ+BY/pjSOKw=="`
+	Memo      string `json:"memo,omitempty" example:"spam"`
+	CreatedAt int64  `json:"created_at" example:"1675324567888542000"` // unix nano
+}
+
+// BlockedSet is the blocklist of a group, the pubkeys are in the eth base64 format
+type BlockedSet map[string]bool
+
+// Has reports whether the author is blocked, `pubkey` is in the libp2p or the eth base64 format
+func (s BlockedSet) Has(pubkey string) bool {
+	return len(s) > 0 && s[normalizePubkey(pubkey)]
+}
+
+func blockedAuthorPrefix(groupId string) string {
+	return fmt.Sprintf("%s%s_", BLK_PREFIX, groupId)
+}
+
+func blockedAuthorKey(groupId, pubkey string) []byte {
+	return []byte(blockedAuthorPrefix(groupId) + normalizePubkey(pubkey))
+}
+
+// normalizePubkey converts the libp2p pubkey to the eth base64 format, other pubkeys are returned as is
+func normalizePubkey(pubkey string) string {
+	if ethPubkey, err := localcrypto.Libp2pPubkeyToEthBase64(pubkey); err == nil {
+		return ethPubkey
+	}
+	return pubkey
+}
+
+// BlockAuthor adds the author to the blocklist of the group, blocking a blocked author updates the memo
+func (appdb *AppDb) BlockAuthor(groupId, pubkey, memo string) (*BlockedAuthor, error) {
+	author := &BlockedAuthor{GroupId: groupId, Pubkey: pubkey, Memo: memo, CreatedAt: time.Now().UnixNano()}
+	value, err := json.Marshal(author)
+	if err != nil {
+		return nil, err
+	}
+	if err := appdb.Db.Set(blockedAuthorKey(groupId, pubkey), value); err != nil {
+		return nil, err
+	}
+	return author, nil
+}
+
+// UnblockAuthor removes the author from the blocklist of the group
+func (appdb *AppDb) UnblockAuthor(groupId, pubkey string) error {
+	key := blockedAuthorKey(groupId, pubkey)
+	exist, err := appdb.Db.IsExist(key)
+	if err != nil {
+		return err
+	}
+	if !exist {
+		return fmt.Errorf("%s is not blocked", pubkey)
+	}
+	return appdb.Db.Delete(key)
+}
+
+// GetBlockedAuthors returns the blocklist of the group
+func (appdb *AppDb) GetBlockedAuthors(groupId string) ([]*BlockedAuthor, error) {
+	authors := []*BlockedAuthor{}
+	err := appdb.Db.PrefixForeach([]byte(blockedAuthorPrefix(groupId)), func(k []byte, v []byte, err error) error {
+		if err != nil {
+			return err
+		}
+		var author BlockedAuthor
+		if err := json.Unmarshal(v, &author); err != nil {
+			appdatalog.Warnf("skip invalid blocked author %s: %s", k, err)
+			return nil
+		}
+		authors = append(authors, &author)
+		return nil
+	})
+	return authors, err
+}
+
+// GetBlockedSet returns the pubkeys of the blocklist of the group, empty if no author is blocked
+func (appdb *AppDb) GetBlockedSet(groupId string) (BlockedSet, error) {
+	blocked := BlockedSet{}
+	prefix := blockedAuthorPrefix(groupId)
+	_, err := appdb.Db.PrefixForeachKey([]byte(prefix), []byte(prefix), false, func(k []byte, err error) error {
+		if err != nil {
+			return err
+		}
+		blocked[string(k[len(prefix):])] = true
+		return nil
+	})
+	return blocked, err
+}
+
+// DelGroupBlockedAuthors deletes the blocklist of the group
+func (appdb *AppDb) DelGroupBlockedAuthors(groupId string) error {
+	_, err := appdb.Db.PrefixDelete([]byte(blockedAuthorPrefix(groupId)))
+	return err
+}
@@ -0,0 +1,155 @@
+package appdata
+
+import (
+	"reflect"
+	"testing"
+
+	p2pcrypto "github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/rumsystem/quorum/internal/pkg/storage"
+	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+func TestBlockedAuthors(t *testing.T) {
+	appdb := NewAppDb()
+	appdb.Db = storage.NewMemStore()
+	groupId := "5ed3f9fe-81e2-450d-9146-7a329aac2b62"
+	alice := "A0rTbiviB1KMgrcehCU9uYiEM2oSUigv_qdCJgW_etO3"
+	bob := "AgPnM0Pgg2zr71R4B8Ha2ueoF8l2iEzfZNZJVbhVbHy8"
+
+	trxs := []*quorumpb.Trx{
+		{TrxId: "b2a3b9aa-bd16-4e80-8497-6d95eddfec51", GroupId: groupId, Type: quorumpb.TrxType_POST, SenderPubkey: alice},
+		{TrxId: "b2a3b9aa-bd16-4e80-8497-6d95eddfec52", GroupId: groupId, Type: quorumpb.TrxType_POST, SenderPubkey: bob},
+		{TrxId: "b2a3b9aa-bd16-4e80-8497-6d95eddfec53", GroupId: groupId, Type: quorumpb.TrxType_POST, SenderPubkey: alice},
+	}
+	if err := appdb.AddMetaByTrx(1, groupId, trxs); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := appdb.BlockAuthor(groupId, bob, "spam"); err != nil {
+		t.Fatal(err)
+	}
+	authors, err := appdb.GetBlockedAuthors(groupId)
+	if err != nil || len(authors) != 1 || authors[0].Pubkey != bob || authors[0].Memo != "spam" {
+		t.Fatalf("want bob blocked, got %+v %v", authors, err)
+	}
+	if blocked, _ := appdb.GetBlockedSet("other-group"); len(blocked) != 0 {
+		t.Errorf("the blocklist is per group, got %v", blocked)
+	}
+
+	trxIds, err := appdb.GetGroupContentBySenders(groupId, nil, "", 10, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{trxs[0].TrxId, trxs[2].TrxId}; !reflect.DeepEqual(trxIds, want) {
+		t.Errorf("want the posts of bob left out %v, got %v", want, trxIds)
+	}
+	// the post of a blocked author still works as the start trx
+	if trxIds, _ = appdb.GetGroupContentBySenders(groupId, nil, trxs[1].TrxId, 10, false, true); !reflect.DeepEqual(trxIds, []string{trxs[2].TrxId}) {
+		t.Errorf("want the posts after the start trx of bob, got %v", trxIds)
+	}
+
+	if err := appdb.UnblockAuthor(groupId, bob); err != nil {
+		t.Fatal(err)
+	}
+	if err := appdb.UnblockAuthor(groupId, bob); err == nil {
+		t.Errorf("unblocking an author not blocked should fail")
+	}
+	if trxIds, _ = appdb.GetGroupContentBySenders(groupId, nil, "", 10, false, false); len(trxIds) != 3 {
+		t.Errorf("want all posts after unblocking, got %v", trxIds)
+	}
+
+	if _, err := appdb.BlockAuthor(groupId, alice, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := appdb.DelGroupBlockedAuthors(groupId); err != nil {
+		t.Fatal(err)
+	}
+	if authors, _ = appdb.GetBlockedAuthors(groupId); len(authors) != 0 {
+		t.Errorf("want the blocklist deleted, got %+v", authors)
+	}
+}
+
+// newPubkeyForms returns a pubkey in the libp2p and the eth base64 format
+func newPubkeyForms(t *testing.T) (string, string) {
+	_, pubkey, err := p2pcrypto.GenerateSecp256k1Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := p2pcrypto.MarshalPublicKey(pubkey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	libp2pPubkey := p2pcrypto.ConfigEncodeKey(b)
+	ethPubkey, err := localcrypto.Libp2pPubkeyToEthBase64(libp2pPubkey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return libp2pPubkey, ethPubkey
+}
+
+func TestBlockedAuthorPubkeyFormats(t *testing.T) {
+	appdb := NewAppDb()
+	appdb.Db = storage.NewMemStore()
+	groupId := "5ed3f9fe-81e2-450d-9146-7a329aac2b62"
+	carolLibp2p, carolEth := newPubkeyForms(t)
+	daveLibp2p, daveEth := newPubkeyForms(t)
+	erin := "A0rTbiviB1KMgrcehCU9uYiEM2oSUigv_qdCJgW_etO3"
+
+	// carol posts in the libp2p format, dave in the eth format
+	trxs := []*quorumpb.Trx{
+		{TrxId: "b2a3b9aa-bd16-4e80-8497-6d95eddfec51", GroupId: groupId, Type: quorumpb.TrxType_POST, SenderPubkey: carolLibp2p},
+		{TrxId: "b2a3b9aa-bd16-4e80-8497-6d95eddfec52", GroupId: groupId, Type: quorumpb.TrxType_POST, SenderPubkey: daveEth},
+		{TrxId: "b2a3b9aa-bd16-4e80-8497-6d95eddfec53", GroupId: groupId, Type: quorumpb.TrxType_POST, SenderPubkey: erin},
+	}
+	if err := appdb.AddMetaByTrx(1, groupId, trxs); err != nil {
+		t.Fatal(err)
+	}
+	if trxIds, _ := appdb.GetGroupContentBySenders(groupId, nil, "", 10, false, false); len(trxIds) != 3 {
+		t.Fatalf("want all posts before blocking, got %v", trxIds)
+	}
+
+	// each author is blocked in the other format
+	if _, err := appdb.BlockAuthor(groupId, carolEth, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := appdb.BlockAuthor(groupId, daveLibp2p, ""); err != nil {
+		t.Fatal(err)
+	}
+	blocked, err := appdb.GetBlockedSet(groupId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pubkey := range []string{carolLibp2p, carolEth, daveLibp2p, daveEth} {
+		if !blocked.Has(pubkey) {
+			t.Errorf("want %s blocked in both formats", pubkey)
+		}
+	}
+	if blocked.Has(erin) {
+		t.Errorf("want %s not blocked", erin)
+	}
+	trxIds, err := appdb.GetGroupContentBySenders(groupId, nil, "", 10, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{trxs[2].TrxId}; !reflect.DeepEqual(trxIds, want) {
+		t.Errorf("want the posts of carol and dave left out %v, got %v", want, trxIds)
+	}
+
+	// blocking again in the other format updates the same entry, unblocking works in either format
+	if _, err := appdb.BlockAuthor(groupId, carolLibp2p, "spam"); err != nil {
+		t.Fatal(err)
+	}
+	if authors, _ := appdb.GetBlockedAuthors(groupId); len(authors) != 2 {
+		t.Errorf("want 2 blocked authors, got %+v", authors)
+	}
+	if err := appdb.UnblockAuthor(groupId, carolEth); err != nil {
+		t.Fatal(err)
+	}
+	if err := appdb.UnblockAuthor(groupId, daveEth); err != nil {
+		t.Fatal(err)
+	}
+	if trxIds, _ = appdb.GetGroupContentBySenders(groupId, nil, "", 10, false, false); len(trxIds) != 3 {
+		t.Errorf("want all posts after unblocking, got %v", trxIds)
+	}
+}
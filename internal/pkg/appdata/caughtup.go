@@ -0,0 +1,192 @@
+package appdata
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
+	chaindef "github.com/rumsystem/quorum/internal/pkg/chainsdk/def"
+)
+
+/*
+A group is caught up when the locally applied block id equals the highest block id seen from the peers (see the sync
+progress of the group status), and it stays so for CaughtUpStableInterval. The highest block must be known, a group which
+has not heard of any block from the peers is never caught up. The first time a group is caught up is saved to the app data,
+the event is sent once to the subscribers, the webhooks of the group and the SSE stream of /api/v1/group/{group_id}/caughtup.
+*/
+
+// CaughtUpStableInterval is how long the applied block must stay at the highest block of the peers
+var CaughtUpStableInterval = 30 * time.Second
+
+const caughtUpStatusName = "CaughtUp"
+
+type CaughtUpEvent struct {
+	GroupId   string `json:"group_id" example:"5ed3f9fe-81e2-450d-9146-7a329aac2b62"`
+	BlockId   uint64 `json:"block_id" example:"800"`                  // the applied block when caught up
+	Timestamp int64  `json:"timestamp" example:"1675324567888542000"` // unix nano when caught up
+}
+
+// GetGroupCaughtUp returns the event of the group caught up, nil if it is not caught up yet
+func (appdb *AppDb) GetGroupCaughtUp(groupId string) (*CaughtUpEvent, error) {
+	value, err := appdb.GetGroupStatus(groupId, caughtUpStatusName)
+	if err != nil || value == "" {
+		return nil, err
+	}
+	event := &CaughtUpEvent{}
+	if err := json.Unmarshal([]byte(value), event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+func (appdb *AppDb) setGroupCaughtUp(event *CaughtUpEvent) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return appdb.setGroupStatus(event.GroupId, caughtUpStatusName, string(value))
+}
+
+// DelGroupCaughtUp forgets the group was caught up, the event is sent again if the group is joined again
+func (appdb *AppDb) DelGroupCaughtUp(groupId string) error {
+	key := fmt.Sprintf("%s%s_%s", STATUS_PREFIX, groupId, caughtUpStatusName)
+	return appdb.Db.Delete([]byte(key))
+}
+
+// caughtUpTracker tracks how long a group has stayed at the highest block of the peers
+type caughtUpTracker struct {
+	since time.Time
+}
+
+// observe returns true if the group has stayed caught up for CaughtUpStableInterval
+func (t *caughtUpTracker) observe(progress *chaindef.SyncProgress, now time.Time) bool {
+	if progress == nil || progress.Indeterminate || progress.Applied < progress.Total {
+		t.since = time.Time{}
+		return false
+	}
+	if t.since.IsZero() {
+		t.since = now
+	}
+	return now.Sub(t.since) >= CaughtUpStableInterval
+}
+
+// CaughtUpSubscriber receives the event of a group caught up for the first time, C is closed by UnsubscribeGroupCaughtUp
+type CaughtUpSubscriber struct {
+	GroupId string
+	C       chan *CaughtUpEvent
+
+	closed bool
+}
+
+type caughtUpHub struct {
+	mu   sync.Mutex
+	subs map[string]map[*CaughtUpSubscriber]struct{}
+}
+
+var caughtUpSubs = &caughtUpHub{subs: make(map[string]map[*CaughtUpSubscriber]struct{})}
+
+// SubscribeGroupCaughtUp registers a subscriber for the caught up event of the group, the caller should check
+// GetGroupCaughtUp after subscribing as the event is not sent again, and must call UnsubscribeGroupCaughtUp when done
+func SubscribeGroupCaughtUp(groupId string) *CaughtUpSubscriber {
+	sub := &CaughtUpSubscriber{GroupId: groupId, C: make(chan *CaughtUpEvent, 1)}
+
+	caughtUpSubs.mu.Lock()
+	defer caughtUpSubs.mu.Unlock()
+	if _, ok := caughtUpSubs.subs[groupId]; !ok {
+		caughtUpSubs.subs[groupId] = make(map[*CaughtUpSubscriber]struct{})
+	}
+	caughtUpSubs.subs[groupId][sub] = struct{}{}
+	return sub
+}
+
+// UnsubscribeGroupCaughtUp removes the subscriber, it is safe to call more than once
+func UnsubscribeGroupCaughtUp(sub *CaughtUpSubscriber) {
+	caughtUpSubs.mu.Lock()
+	defer caughtUpSubs.mu.Unlock()
+	if subs, ok := caughtUpSubs.subs[sub.GroupId]; ok {
+		delete(subs, sub)
+		if len(subs) == 0 {
+			delete(caughtUpSubs.subs, sub.GroupId)
+		}
+	}
+	if !sub.closed {
+		sub.closed = true
+		close(sub.C)
+	}
+}
+
+func publishGroupCaughtUp(event *CaughtUpEvent) {
+	caughtUpSubs.mu.Lock()
+	defer caughtUpSubs.mu.Unlock()
+	for sub := range caughtUpSubs.subs[event.GroupId] {
+		select {
+		case sub.C <- event:
+		default:
+		}
+	}
+}
+
+// CaughtUpWatcher checks the sync progress of the groups and saves and publishes the first time each is caught up
+type CaughtUpWatcher struct {
+	appdb    *AppDb
+	trackers map[string]*caughtUpTracker
+}
+
+func NewCaughtUpWatcher(appdb *AppDb) *CaughtUpWatcher {
+	return &CaughtUpWatcher{appdb: appdb, trackers: make(map[string]*caughtUpTracker)}
+}
+
+func (w *CaughtUpWatcher) Start(interval int) {
+	go func() {
+		for {
+			progress := map[string]*chaindef.SyncProgress{}
+			for groupId, group := range chain.GetGroupMgr().Groups {
+				progress[groupId] = group.GetSyncProgress()
+			}
+			w.check(progress, time.Now())
+
+			time.Sleep(time.Duration(interval) * time.Second)
+		}
+	}()
+}
+
+// check observes the progress of the groups, the trackers of the groups not in progress are dropped
+func (w *CaughtUpWatcher) check(progress map[string]*chaindef.SyncProgress, now time.Time) {
+	for groupId := range w.trackers {
+		if _, ok := progress[groupId]; !ok {
+			delete(w.trackers, groupId)
+		}
+	}
+
+	for groupId, p := range progress {
+		event, err := w.appdb.GetGroupCaughtUp(groupId)
+		if err != nil {
+			appsynclog.Errorf("<%s> get caught up status failed: %s", groupId, err)
+			continue
+		}
+		if event != nil {
+			delete(w.trackers, groupId)
+			continue
+		}
+
+		tracker, ok := w.trackers[groupId]
+		if !ok {
+			tracker = &caughtUpTracker{}
+			w.trackers[groupId] = tracker
+		}
+		if !tracker.observe(p, now) {
+			continue
+		}
+
+		event = &CaughtUpEvent{GroupId: groupId, BlockId: p.Applied, Timestamp: now.UnixNano()}
+		if err := w.appdb.setGroupCaughtUp(event); err != nil {
+			appsynclog.Errorf("<%s> save caught up status failed: %s", groupId, err)
+			continue
+		}
+		delete(w.trackers, groupId)
+		appsynclog.Infof("<%s> caught up at block %d", groupId, event.BlockId)
+		publishGroupCaughtUp(event)
+	}
+}
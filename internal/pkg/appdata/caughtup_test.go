@@ -0,0 +1,79 @@
+package appdata
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	chaindef "github.com/rumsystem/quorum/internal/pkg/chainsdk/def"
+	"github.com/rumsystem/quorum/internal/pkg/storage"
+)
+
+func TestCaughtUpTracker(t *testing.T) {
+	tracker := &caughtUpTracker{}
+	now := time.Now()
+
+	if tracker.observe(&chaindef.SyncProgress{Applied: 10, Indeterminate: true}, now) {
+		t.Errorf("a group without the highest block of peers should not be caught up")
+	}
+	tracker.observe(&chaindef.SyncProgress{Applied: 10, Total: 10}, now)
+	if tracker.observe(&chaindef.SyncProgress{Applied: 10, Total: 10}, now.Add(CaughtUpStableInterval/2)) {
+		t.Errorf("should not be caught up before the stable interval")
+	}
+	// falling behind restarts the interval
+	tracker.observe(&chaindef.SyncProgress{Applied: 10, Total: 12}, now.Add(CaughtUpStableInterval/2))
+	if tracker.observe(&chaindef.SyncProgress{Applied: 12, Total: 12}, now.Add(CaughtUpStableInterval)) {
+		t.Errorf("should not be caught up right after catching up again")
+	}
+	if !tracker.observe(&chaindef.SyncProgress{Applied: 12, Total: 12}, now.Add(2*CaughtUpStableInterval)) {
+		t.Errorf("should be caught up after the stable interval")
+	}
+}
+
+func TestCaughtUpWatcher(t *testing.T) {
+	db, err := storage.NewStore(context.Background(), t.TempDir(), "appdb")
+	if err != nil {
+		t.Fatalf("create store failed: %s", err)
+	}
+	defer db.Close()
+	appdb := NewAppDb()
+	appdb.Db = db
+
+	groupId := "5ed3f9fe-81e2-450d-9146-7a329aac2b62"
+	sub := SubscribeGroupCaughtUp(groupId)
+	defer UnsubscribeGroupCaughtUp(sub)
+
+	w := NewCaughtUpWatcher(appdb)
+	now := time.Now()
+	progress := map[string]*chaindef.SyncProgress{groupId: {Applied: 20, Total: 20}}
+	w.check(progress, now)
+	if event, _ := appdb.GetGroupCaughtUp(groupId); event != nil {
+		t.Fatalf("should not be caught up before the stable interval")
+	}
+
+	w.check(progress, now.Add(CaughtUpStableInterval))
+	select {
+	case event := <-sub.C:
+		if event.GroupId != groupId || event.BlockId != 20 {
+			t.Errorf("unexpected event %+v", event)
+		}
+	default:
+		t.Fatalf("subscriber should receive the caught up event")
+	}
+	if event, err := appdb.GetGroupCaughtUp(groupId); err != nil || event == nil || event.BlockId != 20 {
+		t.Fatalf("want the caught up event saved, got %+v err: %v", event, err)
+	}
+
+	// the event is sent only once
+	w.check(map[string]*chaindef.SyncProgress{groupId: {Applied: 30, Total: 30}}, now.Add(3*CaughtUpStableInterval))
+	if len(sub.C) != 0 {
+		t.Errorf("the caught up event should not be sent again")
+	}
+
+	if err := appdb.DelGroupCaughtUp(groupId); err != nil {
+		t.Fatalf("DelGroupCaughtUp failed: %s", err)
+	}
+	if event, _ := appdb.GetGroupCaughtUp(groupId); event != nil {
+		t.Errorf("caught up status should be deleted")
+	}
+}
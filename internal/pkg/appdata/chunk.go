@@ -0,0 +1,260 @@
+package appdata
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rumsystem/quorum/internal/pkg/storage/def"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+/*
+An object too large for one post is posted as ordered chunk posts of the content type Chunk, each carries a part of
+the json data of the object
+
+	{"type":"Chunk","object":{"id":"<object id>","index":0,"total":3,"size":524288,"hash":"<sha256 hex of the data>","content":"<base64>"}}
+
+appsync indexes the chunks by chk_<group id>_<object id>_<sender>_<index>, the object is reassembled from the chunks
+of one sender when it is read, the chunks of the other senders are not mixed in.
+*/
+const (
+	ChunkType = "Chunk"
+	MaxChunks = 256
+)
+
+const (
+	CHUNKED_STATE_COMPLETE   = "COMPLETE"   // all chunks are received, data is the object
+	CHUNKED_STATE_INCOMPLETE = "INCOMPLETE" // some chunks are not received yet, or deleted
+	CHUNKED_STATE_INVALID    = "INVALID"    // all chunks are received but the data does not match the size or the hash
+)
+
+type Chunk struct {
+	Id      string `json:"id"`
+	Index   int    `json:"index"`
+	Total   int    `json:"total"`
+	Size    int    `json:"size"` // bytes of the object
+	Hash    string `json:"hash"` // sha256 hex of the object
+	Content []byte `json:"content"`
+}
+
+type chunkPost struct {
+	Type   string `json:"type"`
+	Object *Chunk `json:"object"`
+}
+
+// ChunkedObject is an object reassembled from its chunk posts
+type ChunkedObject struct {
+	Id       string          `json:"id" example:"4b2f6e1c-8d3a-4f5e-9a7b-2c1d0e9f8a6b"`
+	Sender   string          `json:"sender" example:"CAISIQOlA37+ghb05D5ZAKExjsto/H7eeCmkagcZ+BY/pjSOKw=="`
+	State    string          `json:"state" example:"COMPLETE"`
+	Total    int             `json:"total" example:"3"`
+	Received int             `json:"received" example:"3"`
+	Missing  []int           `json:"missing,omitempty"`                   // indexes of the chunks not received
+	Data     json.RawMessage `json:"data,omitempty" swaggertype:"object"` // the object, set if complete
+}
+
+// SplitObject splits the json data of an object into chunk posts of at most chunkSize bytes of data each,
+// it returns the object id and the posts in order
+func SplitObject(data []byte, chunkSize int) (string, [][]byte, error) {
+	if len(data) == 0 {
+		return "", nil, errors.New("empty object")
+	}
+	if chunkSize <= 0 {
+		return "", nil, fmt.Errorf("invalid chunk size %d", chunkSize)
+	}
+	total := (len(data) + chunkSize - 1) / chunkSize
+	if total > MaxChunks {
+		return "", nil, fmt.Errorf("object of %d bytes needs %d chunks, over the limit %d", len(data), total, MaxChunks)
+	}
+
+	sum := sha256.Sum256(data)
+	id := uuid.New().String()
+	posts := make([][]byte, 0, total)
+	for i := 0; i < total; i++ {
+		end := (i + 1) * chunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+		chunk := &Chunk{Id: id, Index: i, Total: total, Size: len(data), Hash: hex.EncodeToString(sum[:]), Content: data[i*chunkSize : end]}
+		post, err := json.Marshal(&chunkPost{Type: ChunkType, Object: chunk})
+		if err != nil {
+			return "", nil, err
+		}
+		posts = append(posts, post)
+	}
+	return id, posts, nil
+}
+
+// ParseChunk returns the chunk of the decrypted data of a post, nil if it is not a valid chunk post
+func ParseChunk(data []byte) *Chunk {
+	var post chunkPost
+	if err := json.Unmarshal(data, &post); err != nil || post.Type != ChunkType || post.Object == nil {
+		return nil
+	}
+	c := post.Object
+	if c.Id == "" || strings.Contains(c.Id, "_") || c.Total < 1 || c.Total > MaxChunks || c.Index < 0 || c.Index >= c.Total {
+		return nil
+	}
+	return c
+}
+
+func chunkPrefix(groupId, objectId, sender string) string {
+	return fmt.Sprintf("%s%s_%s_%s_", CHK_PREFIX, groupId, objectId, sender)
+}
+
+// AddChunksByTrx indexes the chunk posts in trxs
+func (appdb *AppDb) AddChunksByTrx(groupitem *quorumpb.GroupItem, trxs []*quorumpb.Trx) error {
+	keys := [][]byte{}
+	values := [][]byte{}
+	for _, trx := range trxs {
+		if trx.Type != quorumpb.TrxType_POST {
+			continue
+		}
+		decrypted := proto.Clone(trx).(*quorumpb.Trx)
+		if err := DecryptPostTrx(groupitem, decrypted); err != nil {
+			continue
+		}
+		chunk := ParseChunk(decrypted.Data)
+		if chunk == nil {
+			continue
+		}
+		key := fmt.Sprintf("%s%06d", chunkPrefix(trx.GroupId, chunk.Id, trx.SenderPubkey), chunk.Index)
+		keys = append(keys, []byte(key))
+		values = append(values, []byte(trx.TrxId))
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return appdb.Db.BatchWrite(keys, values)
+}
+
+// DelGroupChunks deletes the chunk index of the group
+func (appdb *AppDb) DelGroupChunks(groupId string) error {
+	_, err := appdb.Db.PrefixDelete([]byte(fmt.Sprintf("%s%s_", CHK_PREFIX, groupId)))
+	return err
+}
+
+// GetChunkedObject reassembles the object from the chunks posted by sender, the chunks which disagree with
+// the first one found on the total, the size or the hash are ignored
+func (appdb *AppDb) GetChunkedObject(trxdb def.TrxStorageIface, nodename string, groupitem *quorumpb.GroupItem, objectId string, sender string) (*ChunkedObject, error) {
+	prefix := chunkPrefix(groupitem.GroupId, objectId, sender)
+	trxIds := map[int]string{}
+	err := appdb.Db.PrefixForeach([]byte(prefix), func(k []byte, v []byte, err error) error {
+		if err != nil {
+			return err
+		}
+		index, err := strconv.Atoi(string(k[len(prefix):]))
+		if err != nil {
+			appdatalog.Warnf("skip invalid chunk key %s", k)
+			return nil
+		}
+		trxIds[index] = string(v)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(trxIds) == 0 {
+		return nil, fmt.Errorf("object %s of %s not found", objectId, sender)
+	}
+
+	var first *Chunk
+	chunks := map[int]*Chunk{}
+	for index := 0; index < MaxChunks && (first == nil || index < first.Total); index++ {
+		trxId, ok := trxIds[index]
+		if !ok || isRemoved(groupitem.GroupId, trxId) {
+			continue
+		}
+		stored, err := trxdb.GetTrx(groupitem.GroupId, trxId, def.Chain, nodename)
+		if err != nil {
+			return nil, err
+		}
+		if stored.TrxId == "" {
+			continue
+		}
+		trx := proto.Clone(stored).(*quorumpb.Trx)
+		if DecryptPostTrx(groupitem, trx) != nil {
+			continue
+		}
+		chunk := ParseChunk(trx.Data)
+		if chunk == nil || chunk.Id != objectId || chunk.Index != index {
+			continue
+		}
+		if first == nil {
+			first = chunk
+		} else if chunk.Total != first.Total || chunk.Size != first.Size || chunk.Hash != first.Hash {
+			continue
+		}
+		chunks[index] = chunk
+	}
+	if first == nil {
+		return nil, fmt.Errorf("object %s of %s not found", objectId, sender)
+	}
+
+	obj := &ChunkedObject{Id: objectId, Sender: sender, Total: first.Total, Received: len(chunks)}
+	for i := 0; i < first.Total; i++ {
+		if _, ok := chunks[i]; !ok {
+			obj.Missing = append(obj.Missing, i)
+		}
+	}
+	if len(obj.Missing) > 0 {
+		obj.State = CHUNKED_STATE_INCOMPLETE
+		return obj, nil
+	}
+
+	data := make([]byte, 0, first.Size)
+	for i := 0; i < first.Total; i++ {
+		data = append(data, chunks[i].Content...)
+	}
+	sum := sha256.Sum256(data)
+	if len(data) != first.Size || hex.EncodeToString(sum[:]) != first.Hash || !json.Valid(data) {
+		obj.State = CHUNKED_STATE_INVALID
+		return obj, nil
+	}
+	obj.State = CHUNKED_STATE_COMPLETE
+	obj.Data = data
+	return obj, nil
+}
+
+// ContentData is the data of the object in a content list, the object itself if complete,
+// otherwise a Chunked post with the state and the id to query it by
+func (obj *ChunkedObject) ContentData() []byte {
+	if obj.State == CHUNKED_STATE_COMPLETE {
+		return obj.Data
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"type": "Chunked",
+		"object": map[string]interface{}{
+			"id":       obj.Id,
+			"state":    obj.State,
+			"total":    obj.Total,
+			"received": obj.Received,
+		},
+	})
+	return data
+}
+
+// ChunkContent returns the data of a decrypted post of sender in a content list. The chunks of an object are listed
+// once, at the first chunk, as the data of the reassembled object, ok is false for the other chunks to hide them.
+// The data of the other posts is returned as is.
+func (appdb *AppDb) ChunkContent(trxdb def.TrxStorageIface, nodename string, groupitem *quorumpb.GroupItem, data []byte, sender string) ([]byte, bool, error) {
+	chunk := ParseChunk(data)
+	if chunk == nil {
+		return data, true, nil
+	}
+	if chunk.Index != 0 {
+		return nil, false, nil
+	}
+	obj, err := appdb.GetChunkedObject(trxdb, nodename, groupitem, chunk.Id, sender)
+	if err != nil {
+		return nil, false, err
+	}
+	return obj.ContentData(), true, nil
+}
@@ -0,0 +1,138 @@
+package appdata
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/rumsystem/quorum/internal/pkg/storage"
+	"github.com/rumsystem/quorum/internal/pkg/storage/def"
+	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+type memTrxdb map[string]*quorumpb.Trx
+
+func (m memTrxdb) GetTrx(groupId string, trxId string, storagetype def.TrxStorageType, prefix ...string) (*quorumpb.Trx, error) {
+	if trx, ok := m[trxId]; ok {
+		return trx, nil
+	}
+	return &quorumpb.Trx{}, nil
+}
+
+func TestSplitObject(t *testing.T) {
+	data := []byte(`{"type":"Create","object":{"type":"Image","content":"0123456789abcdef"}}`)
+	id, posts, err := SplitObject(data, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != (len(data)+15)/16 {
+		t.Fatalf("want %d chunks, got %d", (len(data)+15)/16, len(posts))
+	}
+	var joined []byte
+	for i, post := range posts {
+		chunk := ParseChunk(post)
+		if chunk == nil || chunk.Id != id || chunk.Index != i || chunk.Total != len(posts) || chunk.Size != len(data) {
+			t.Fatalf("unexpected chunk %d: %+v", i, chunk)
+		}
+		joined = append(joined, chunk.Content...)
+	}
+	if !bytes.Equal(joined, data) {
+		t.Errorf("want %s, got %s", data, joined)
+	}
+
+	if _, _, err := SplitObject(data, 0); err == nil {
+		t.Errorf("chunk size 0 should be rejected")
+	}
+	if _, _, err := SplitObject(bytes.Repeat([]byte("a"), MaxChunks+1), 1); err == nil {
+		t.Errorf("more than %d chunks should be rejected", MaxChunks)
+	}
+	if ParseChunk([]byte(`{"type":"Note","object":{"id":"1"}}`)) != nil {
+		t.Errorf("a note is not a chunk")
+	}
+	if ParseChunk([]byte(`{"type":"Chunk","object":{"id":"1","index":2,"total":2}}`)) != nil {
+		t.Errorf("index out of total should be rejected")
+	}
+}
+
+func TestGetChunkedObject(t *testing.T) {
+	appdb := NewAppDb()
+	appdb.Db = storage.NewMemStore()
+	key := bytes.Repeat([]byte{1}, 32)
+	groupitem := &quorumpb.GroupItem{GroupId: "5ed3f9fe-81e2-450d-9146-7a329aac2b62", CipherKey: hex.EncodeToString(key)}
+	sender := "CAISIQOlA37+ghb05D5ZAKExjsto/H7eeCmkagcZ+BY/pjSOKw=="
+
+	data := []byte(`{"type":"Create","object":{"type":"File","content":"0123456789abcdefghijklmnopqrstuvwxyz"}}`)
+	id, posts, err := SplitObject(data, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trxdb := memTrxdb{}
+	var trxs []*quorumpb.Trx
+	for i, post := range posts {
+		encrypted, err := localcrypto.AesEncrypt(post, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		trx := &quorumpb.Trx{TrxId: fmt.Sprintf("trx-%d", i), GroupId: groupitem.GroupId, Type: quorumpb.TrxType_POST, SenderPubkey: sender, Data: encrypted}
+		trxs = append(trxs, trx)
+		trxdb[trx.TrxId] = trx
+	}
+	// a chunk of the object posted by another sender is not mixed in
+	forged := &quorumpb.Trx{TrxId: "trx-forged", GroupId: groupitem.GroupId, Type: quorumpb.TrxType_POST, SenderPubkey: "other", Data: trxs[0].Data}
+	trxdb[forged.TrxId] = forged
+
+	// the last chunk is not received yet
+	if err := appdb.AddChunksByTrx(groupitem, append(trxs[:len(trxs)-1:len(trxs)-1], forged)); err != nil {
+		t.Fatal(err)
+	}
+	obj, err := appdb.GetChunkedObject(trxdb, "", groupitem, id, sender)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.State != CHUNKED_STATE_INCOMPLETE || obj.Received != len(posts)-1 || len(obj.Missing) != 1 || obj.Missing[0] != len(posts)-1 || obj.Data != nil {
+		t.Fatalf("want incomplete without the last chunk, got %+v", obj)
+	}
+	if ParseChunk(obj.ContentData()) != nil || !bytes.Contains(obj.ContentData(), []byte(CHUNKED_STATE_INCOMPLETE)) {
+		t.Errorf("want a Chunked item with the state, got %s", obj.ContentData())
+	}
+
+	if err := appdb.AddChunksByTrx(groupitem, trxs[len(trxs)-1:]); err != nil {
+		t.Fatal(err)
+	}
+	obj, err = appdb.GetChunkedObject(trxdb, "", groupitem, id, sender)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.State != CHUNKED_STATE_COMPLETE || obj.Received != len(posts) || !bytes.Equal(obj.ContentData(), data) {
+		t.Fatalf("want the complete object, got %+v", obj)
+	}
+
+	for i, post := range posts {
+		content, ok, err := appdb.ChunkContent(trxdb, "", groupitem, post, sender)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 && (!ok || !bytes.Equal(content, data)) {
+			t.Errorf("want the object listed at the first chunk, got %v %s", ok, content)
+		}
+		if i > 0 && ok {
+			t.Errorf("want chunk %d hidden, got %s", i, content)
+		}
+	}
+	note := []byte(`{"type":"Note","content":"hello"}`)
+	if content, ok, err := appdb.ChunkContent(trxdb, "", groupitem, note, sender); err != nil || !ok || !bytes.Equal(content, note) {
+		t.Errorf("want a note returned as is, got %v %s %v", ok, content, err)
+	}
+
+	if _, err := appdb.GetChunkedObject(trxdb, "", groupitem, "unknown", sender); err == nil {
+		t.Errorf("unknown object should not be found")
+	}
+	if err := appdb.DelGroupChunks(groupitem.GroupId); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := appdb.GetChunkedObject(trxdb, "", groupitem, id, sender); err == nil {
+		t.Errorf("object should not be found after the chunk index is deleted")
+	}
+}
@@ -7,10 +7,15 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/orderedcode"
 	"github.com/rumsystem/quorum/internal/pkg/logging"
+	"github.com/rumsystem/quorum/internal/pkg/nodectx"
 	"github.com/rumsystem/quorum/internal/pkg/storage"
+	rumchaindata "github.com/rumsystem/quorum/pkg/data"
 	quorumpb "github.com/rumsystem/quorum/pkg/pb"
 )
 
@@ -23,11 +28,15 @@ const SEQ_PREFIX string = "seq_"
 const TRX_PREFIX string = "trx_"
 const SED_PREFIX string = "sed_"
 const STATUS_PREFIX string = "stu_"
+const CHK_PREFIX string = "chk_"
+const PRF_PREFIX string = "prf_"
+const BLK_PREFIX string = "blk_"
 
 type AppDb struct {
 	Db       storage.QuorumStorage
 	seq      map[string]storage.Sequence
 	DataPath string
+	searchMu sync.Mutex // guards the search index status against deleting while indexing
 }
 
 func NewAppDb() *AppDb {
@@ -67,12 +76,17 @@ func (appdb *AppDb) Rebuild(vertag string, chainDb storage.QuorumStorage) error
 	return nil
 }
 
+// GetGroupContentBySenders returns the trxs of the content index, the posts of the blocked authors are left out
 func (appdb *AppDb) GetGroupContentBySenders(groupid string, senders []string, starttrx string, num int, reverse bool, starttrxinclude bool) (trxidList []string, err error) {
 	prefix := fmt.Sprintf("%s%s-%s", CNT_PREFIX, GRP_PREFIX, groupid)
 	sendermap := make(map[string]bool)
 	for _, s := range senders {
 		sendermap[s] = true
 	}
+	blocked, err := appdb.GetBlockedSet(groupid)
+	if err != nil {
+		return nil, err
+	}
 
 	trxids := []string{}
 	runcollector := false
@@ -98,11 +112,13 @@ func (appdb *AppDb) GetGroupContentBySenders(groupid string, senders []string, s
 			return nil
 		}
 
-		// Note: sender, trxid contains bytes "\x00\x01"
+		// Note: sender, trxid contains bytes "\x00\x01", the sender follows the last one
 		sender, trxid := string(parts[0]), string(parts[1])[:36]
-		sender = sender[len(sender)-44:]
-		if len(sender) != 44 {
-			appdatalog.Warnf("key hex: %s prefix: %s invalid sender hex: <%s> len(sender): %d", hex.EncodeToString(k), prefix, hex.EncodeToString([]byte(sender)), len(sender))
+		if idx := strings.LastIndex(sender, "\x00\x01"); idx >= 0 {
+			sender = sender[idx+2:]
+		}
+		if sender == "" {
+			appdatalog.Warnf("key hex: %s prefix: %s empty sender", hex.EncodeToString(k), prefix)
 			return nil
 		}
 		if len(trxid) != 36 {
@@ -110,14 +126,14 @@ func (appdb *AppDb) GetGroupContentBySenders(groupid string, senders []string, s
 			return nil
 		}
 
-		if runcollector {
+		if runcollector && !isRemoved(groupid, trxid) && !blocked.Has(sender) {
 			if len(senders) == 0 || sendermap[sender] == true {
 				trxids = append(trxids, trxid)
 			}
 		}
 		if trxid == starttrx && !runcollector { //start collecting after this item
 			runcollector = true
-			if starttrxinclude && runcollector {
+			if starttrxinclude && runcollector && !blocked.Has(sender) {
 				trxids = append(trxids, trxid)
 			}
 		}
@@ -211,9 +227,10 @@ func (appdb *AppDb) AddMetaByTrx(blockId uint64, groupid string, trxs []*quorump
 
 	seqkey := SEQ_PREFIX + CNT_PREFIX + GRP_PREFIX + groupid
 
+	now := time.Now()
 	keylist := [][]byte{}
 	for _, trx := range trxs {
-		if trx.Type == quorumpb.TrxType_POST {
+		if trx.Type == quorumpb.TrxType_POST && !rumchaindata.IsTrxExpired(trx, now) {
 			seqid, err := appdb.GetSeqId(seqkey)
 			if err != nil {
 				return err
@@ -264,6 +281,20 @@ func (appdb *AppDb) Close() {
 	appdb.Db.Close()
 }
 
+// isRemoved reports whether the post is deleted by a tombstone trx or expired
+func isRemoved(groupId, trxId string) bool {
+	ctx := nodectx.GetNodeCtx()
+	if ctx == nil || ctx.GetChainStorage() == nil {
+		return false
+	}
+	tombstone, err := ctx.GetChainStorage().GetTombstone(groupId, trxId, ctx.Name)
+	if err == nil && tombstone != "" {
+		return true
+	}
+	expired, err := ctx.GetChainStorage().IsPostExpired(groupId, trxId, time.Now(), ctx.Name)
+	return err == nil && expired
+}
+
 func groupSeedKey(groupID string) []byte {
 	return []byte(fmt.Sprintf("%s%s", SED_PREFIX, groupID))
 }
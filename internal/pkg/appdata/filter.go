@@ -0,0 +1,205 @@
+package appdata
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/google/orderedcode"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+const appSyncFilterKey = "appsync_filter"
+
+// AppSyncFilter selects the content materialized into appdata by appsync, an empty include list includes all.
+// The content type of a post is the "type" of its json data or of its "object", e.g. "Note".
+type AppSyncFilter struct {
+	IncludeGroups       []string `json:"include_groups,omitempty" example:"ac0eea7c-2f3c-4c67-80b3-136e46b924a8"`
+	ExcludeGroups       []string `json:"exclude_groups,omitempty"`
+	IncludeTrxTypes     []string `json:"include_trx_types,omitempty" example:"POST"`
+	ExcludeTrxTypes     []string `json:"exclude_trx_types,omitempty"`
+	IncludeContentTypes []string `json:"include_content_types,omitempty" example:"Note"`
+	ExcludeContentTypes []string `json:"exclude_content_types,omitempty"`
+}
+
+func (f *AppSyncFilter) Validate() error {
+	for _, types := range [][]string{f.IncludeTrxTypes, f.ExcludeTrxTypes} {
+		for _, t := range types {
+			if _, ok := quorumpb.TrxType_value[t]; !ok {
+				return fmt.Errorf("unknown trx type %s", t)
+			}
+		}
+	}
+	return nil
+}
+
+func matchList(include, exclude []string, value string) bool {
+	for _, v := range exclude {
+		if v == value {
+			return false
+		}
+	}
+	if len(include) == 0 {
+		return true
+	}
+	for _, v := range include {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *AppSyncFilter) MatchGroup(groupId string) bool {
+	return f == nil || matchList(f.IncludeGroups, f.ExcludeGroups, groupId)
+}
+
+// NeedContent reports whether the posts should be decrypted to match the content types
+func (f *AppSyncFilter) NeedContent() bool {
+	return f != nil && (len(f.IncludeContentTypes) > 0 || len(f.ExcludeContentTypes) > 0)
+}
+
+// MatchTrx matches the trx type, and the content type of a POST trx, data is the decrypted data of the trx
+func (f *AppSyncFilter) MatchTrx(trx *quorumpb.Trx, data []byte) bool {
+	if f == nil {
+		return true
+	}
+	if !matchList(f.IncludeTrxTypes, f.ExcludeTrxTypes, trx.Type.String()) {
+		return false
+	}
+	if trx.Type != quorumpb.TrxType_POST || !f.NeedContent() {
+		return true
+	}
+	types := contentTypes(data)
+	for _, t := range types {
+		for _, v := range f.ExcludeContentTypes {
+			if v == t {
+				return false
+			}
+		}
+	}
+	if len(f.IncludeContentTypes) == 0 {
+		return true
+	}
+	for _, t := range types {
+		if matchList(f.IncludeContentTypes, nil, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// contentTypes returns the "type" of the json data and of its "object"
+func contentTypes(data []byte) []string {
+	var obj struct {
+		Type   string `json:"type"`
+		Object struct {
+			Type string `json:"type"`
+		} `json:"object"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil
+	}
+	types := []string{}
+	for _, t := range []string{obj.Type, obj.Object.Type} {
+		if t != "" {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// sameTrxFilter reports whether the trx and content type lists of the filters are the same
+func sameTrxFilter(a, b *AppSyncFilter) bool {
+	if a == nil {
+		a = &AppSyncFilter{}
+	}
+	if b == nil {
+		b = &AppSyncFilter{}
+	}
+	x, _ := json.Marshal(&AppSyncFilter{IncludeTrxTypes: a.IncludeTrxTypes, ExcludeTrxTypes: a.ExcludeTrxTypes, IncludeContentTypes: a.IncludeContentTypes, ExcludeContentTypes: a.ExcludeContentTypes})
+	y, _ := json.Marshal(&AppSyncFilter{IncludeTrxTypes: b.IncludeTrxTypes, ExcludeTrxTypes: b.ExcludeTrxTypes, IncludeContentTypes: b.IncludeContentTypes, ExcludeContentTypes: b.ExcludeContentTypes})
+	return string(x) == string(y)
+}
+
+// GetAppSyncFilter returns the saved filter, nil if there is none
+func (appdb *AppDb) GetAppSyncFilter() (*AppSyncFilter, error) {
+	exist, err := appdb.Db.IsExist([]byte(appSyncFilterKey))
+	if err != nil || !exist {
+		return nil, err
+	}
+	value, err := appdb.Db.Get([]byte(appSyncFilterKey))
+	if err != nil {
+		return nil, err
+	}
+	filter := &AppSyncFilter{}
+	if err := json.Unmarshal(value, filter); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+func (appdb *AppDb) SetAppSyncFilter(filter *AppSyncFilter) error {
+	if filter == nil {
+		return appdb.Db.Delete([]byte(appSyncFilterKey))
+	}
+	value, err := json.Marshal(filter)
+	if err != nil {
+		return err
+	}
+	return appdb.Db.Set([]byte(appSyncFilterKey), value)
+}
+
+func (appdb *AppDb) setGroupStatus(groupid string, name string, value string) error {
+	key := fmt.Sprintf("%s%s_%s", STATUS_PREFIX, groupid, name)
+	return appdb.Db.Set([]byte(key), []byte(value))
+}
+
+// DelGroupContent deletes the content index, the chunk index and the profiles of the group
+func (appdb *AppDb) DelGroupContent(groupId string) error {
+	prefix, err := orderedcode.Append(nil, fmt.Sprintf("%s%s-%s", CNT_PREFIX, GRP_PREFIX, groupId))
+	if err != nil {
+		return err
+	}
+	if _, err = appdb.Db.PrefixDelete(prefix); err != nil {
+		return err
+	}
+	if err := appdb.DelGroupChunks(groupId); err != nil {
+		return err
+	}
+	return appdb.DelGroupProfiles(groupId)
+}
+
+// ResetGroupContent deletes the content index of the group and moves the appsync status back to the block `from`,
+// the blocks up to the current status are marked as reindexed, so they are not published to the streams again
+func (appdb *AppDb) ResetGroupContent(groupId string, from uint64) error {
+	if err := appdb.DelGroupContent(groupId); err != nil {
+		return err
+	}
+
+	synced, err := appdb.getGroupBlockStatus(groupId, "Block")
+	if err != nil {
+		return err
+	}
+	if synced <= from {
+		return nil
+	}
+	reindexTo, err := appdb.getGroupBlockStatus(groupId, "ReindexTo")
+	if err != nil {
+		return err
+	}
+	if synced > reindexTo {
+		if err := appdb.setGroupStatus(groupId, "ReindexTo", strconv.FormatUint(synced, 10)); err != nil {
+			return err
+		}
+	}
+	return appdb.setGroupStatus(groupId, "Block", strconv.FormatUint(from, 10))
+}
+
+func (appdb *AppDb) getGroupBlockStatus(groupId string, name string) (uint64, error) {
+	value, err := appdb.GetGroupStatus(groupId, name)
+	if err != nil || value == "" {
+		return 0, err
+	}
+	return strconv.ParseUint(value, 10, 64)
+}
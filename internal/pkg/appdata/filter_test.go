@@ -0,0 +1,101 @@
+package appdata
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rumsystem/quorum/internal/pkg/storage"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+func TestAppSyncFilterMatch(t *testing.T) {
+	var none *AppSyncFilter
+	post := &quorumpb.Trx{Type: quorumpb.TrxType_POST}
+	if !none.MatchGroup("a") || !none.MatchTrx(post, nil) || none.NeedContent() {
+		t.Fatalf("expect nil filter matches all")
+	}
+
+	filter := &AppSyncFilter{
+		IncludeGroups:       []string{"a", "b"},
+		ExcludeGroups:       []string{"b"},
+		IncludeTrxTypes:     []string{"POST", "ANNOUNCE"},
+		IncludeContentTypes: []string{"Note"},
+		ExcludeContentTypes: []string{"Delete"},
+	}
+	for group, expect := range map[string]bool{"a": true, "b": false, "c": false} {
+		if filter.MatchGroup(group) != expect {
+			t.Errorf("MatchGroup(%s) expect %v", group, expect)
+		}
+	}
+	cases := []struct {
+		trx    *quorumpb.Trx
+		data   string
+		expect bool
+	}{
+		{post, `{"type":"Create","object":{"type":"Note"}}`, true},
+		{post, `{"type":"Note"}`, true},
+		{post, `{"type":"Create","object":{"type":"Image"}}`, false},
+		{post, `{"type":"Delete","object":{"type":"Note"}}`, false},
+		{post, `not json`, false},
+		{&quorumpb.Trx{Type: quorumpb.TrxType_ANNOUNCE}, "", true},
+		{&quorumpb.Trx{Type: quorumpb.TrxType_USER}, "", false},
+	}
+	for i, c := range cases {
+		if got := filter.MatchTrx(c.trx, []byte(c.data)); got != c.expect {
+			t.Errorf("case %d: expect %v, got %v", i, c.expect, got)
+		}
+	}
+
+	if err := (&AppSyncFilter{ExcludeTrxTypes: []string{"NOPE"}}).Validate(); err == nil {
+		t.Errorf("expect unknown trx type rejected")
+	}
+	if sameTrxFilter(nil, filter) || !sameTrxFilter(nil, &AppSyncFilter{IncludeGroups: []string{"a"}}) {
+		t.Errorf("expect only trx and content types compared")
+	}
+}
+
+func TestResetGroupContent(t *testing.T) {
+	db, err := storage.NewStore(context.Background(), t.TempDir(), "appdb")
+	if err != nil {
+		t.Fatalf("create store failed: %s", err)
+	}
+	defer db.Close()
+	appdb := NewAppDb()
+	appdb.Db = db
+
+	if filter, err := appdb.GetAppSyncFilter(); err != nil || filter != nil {
+		t.Fatalf("expect no filter, got %v %v", filter, err)
+	}
+	if err := appdb.SetAppSyncFilter(&AppSyncFilter{IncludeGroups: []string{"a"}}); err != nil {
+		t.Fatal(err)
+	}
+	if filter, err := appdb.GetAppSyncFilter(); err != nil || len(filter.IncludeGroups) != 1 {
+		t.Fatalf("expect the filter saved, got %v %v", filter, err)
+	}
+
+	groupId := "5ed3f9fe-81e2-450d-9146-7a329aac2b62"
+	other := "6fe4a0ff-92f3-461e-a257-8b43ab1b3c73"
+	sender := "CAISIQKDY1R5hZ09yG1+i/Kdk8E/KDT8Wm/PrKmgtsdtXFHXEg=="
+	for i, g := range []string{groupId, other} {
+		trxs := []*quorumpb.Trx{{TrxId: []string{"d4c1b0a2-0d3e-4f5a-9b8c-1a2b3c4d5e6f", "e5d2c1b3-1e4f-4a6b-8c9d-2b3c4d5e6f70"}[i], Type: quorumpb.TrxType_POST, SenderPubkey: sender}}
+		if err := appdb.AddMetaByTrx(5, g, trxs); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := appdb.ResetGroupContent(groupId, 2); err != nil {
+		t.Fatalf("ResetGroupContent failed: %s", err)
+	}
+	if trxids, _ := appdb.GetGroupContentBySenders(groupId, nil, "", 10, false, false); len(trxids) != 0 {
+		t.Errorf("expect content of the group deleted, got %v", trxids)
+	}
+	if trxids, _ := appdb.GetGroupContentBySenders(other, nil, "", 10, false, false); len(trxids) != 1 {
+		t.Errorf("expect content of the other group kept, got %v", trxids)
+	}
+	if synced, _ := appdb.getGroupBlockStatus(groupId, "Block"); synced != 2 {
+		t.Errorf("expect status moved back to block 2, got %d", synced)
+	}
+	if reindexTo, _ := appdb.getGroupBlockStatus(groupId, "ReindexTo"); reindexTo != 5 {
+		t.Errorf("expect blocks up to 5 marked reindexed, got %d", reindexTo)
+	}
+}
@@ -0,0 +1,167 @@
+package appdata
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+/*
+A user announces its profile in a group by a post of the content type Profile
+
+	{"type":"Profile","object":{"name":"<nickname>","avatar":"<blob hash or url>","bio":"<bio>"}}
+
+appsync keeps the latest profile of each sender by prf_<group id>_<sender>. The latest is the one in the highest block,
+and the last one in the block if a sender posts more than one, so all nodes applying the same blocks resolve the same
+profile whatever the timestamps of the trxs are. A profile post replaces the whole profile, empty fields clear them.
+*/
+const (
+	ProfileType = "Profile"
+
+	MaxProfileNameLen   = 64
+	MaxProfileAvatarLen = 512
+	MaxProfileBioLen    = 1024
+)
+
+type Profile struct {
+	Name   string `json:"name,omitempty" example:"alice"`
+	Avatar string `json:"avatar,omitempty" example:"bafkreihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvyku"` // blob hash or url of the avatar image
+	Bio    string `json:"bio,omitempty" example:"hello quorum"`
+}
+
+type profilePost struct {
+	Type   string   `json:"type"`
+	Object *Profile `json:"object"`
+}
+
+// UserProfile is the latest profile posted by a user of the group
+type UserProfile struct {
+	GroupId   string `json:"group_id" example:"5ed3f9fe-81e2-450d-9146-7a329aac2b62"`
+	Pubkey    string `json:"pubkey" example:"CAISIQOlA37+ghb05D5ZAKExjsto/H7eeCmkagcZ+BY/pjSOKw=="`
+	Name      string `json:"name" example:"alice"`
+	Avatar    string `json:"avatar" example:"bafkreihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvyku"`
+	Bio       string `json:"bio" example:"hello quorum"`
+	TrxId     string `json:"trx_id" example:"9e54c173-c1dd-429d-91fa-a6b43c14da77"` // the profile post
+	BlockId   uint64 `json:"block_id" example:"800"`                                // the block of the profile post
+	TimeStamp int64  `json:"timestamp" example:"1675324567888542000"`               // of the profile post, not used to resolve the latest
+}
+
+// Validate checks the lengths of the fields, a profile post failing it is ignored by appsync
+func (p *Profile) Validate() error {
+	if utf8.RuneCountInString(p.Name) > MaxProfileNameLen {
+		return fmt.Errorf("name is longer than %d characters", MaxProfileNameLen)
+	}
+	if len(p.Avatar) > MaxProfileAvatarLen {
+		return fmt.Errorf("avatar is longer than %d bytes", MaxProfileAvatarLen)
+	}
+	if utf8.RuneCountInString(p.Bio) > MaxProfileBioLen {
+		return fmt.Errorf("bio is longer than %d characters", MaxProfileBioLen)
+	}
+	return nil
+}
+
+// NewProfilePost returns the content of the post announcing the profile
+func NewProfilePost(p *Profile) ([]byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(&profilePost{Type: ProfileType, Object: p})
+}
+
+// ParseProfile returns the profile of the decrypted data of a post, nil if it is not a valid profile post
+func ParseProfile(data []byte) *Profile {
+	var post profilePost
+	if err := json.Unmarshal(data, &post); err != nil || post.Type != ProfileType || post.Object == nil {
+		return nil
+	}
+	if post.Object.Validate() != nil {
+		return nil
+	}
+	return post.Object
+}
+
+func profileKey(groupId, pubkey string) []byte {
+	return []byte(fmt.Sprintf("%s%s_%s", PRF_PREFIX, groupId, pubkey))
+}
+
+// AddProfilesByTrx keeps the profile posts in trxs of the block if they are not older than the saved ones,
+// trxs must be in the order of the block
+func (appdb *AppDb) AddProfilesByTrx(groupitem *quorumpb.GroupItem, blockId uint64, trxs []*quorumpb.Trx) error {
+	latest := map[string]*UserProfile{}
+	for _, trx := range trxs {
+		if trx.Type != quorumpb.TrxType_POST {
+			continue
+		}
+		decrypted := proto.Clone(trx).(*quorumpb.Trx)
+		if err := DecryptPostTrx(groupitem, decrypted); err != nil {
+			continue
+		}
+		profile := ParseProfile(decrypted.Data)
+		if profile == nil {
+			continue
+		}
+		latest[trx.SenderPubkey] = &UserProfile{
+			GroupId:   trx.GroupId,
+			Pubkey:    trx.SenderPubkey,
+			Name:      profile.Name,
+			Avatar:    profile.Avatar,
+			Bio:       profile.Bio,
+			TrxId:     trx.TrxId,
+			BlockId:   blockId,
+			TimeStamp: trx.TimeStamp,
+		}
+	}
+
+	keys := [][]byte{}
+	values := [][]byte{}
+	for pubkey, profile := range latest {
+		saved, err := appdb.GetProfile(profile.GroupId, pubkey)
+		if err != nil {
+			return err
+		}
+		if saved != nil && saved.BlockId > blockId {
+			continue
+		}
+		value, err := json.Marshal(profile)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, profileKey(profile.GroupId, pubkey))
+		values = append(values, value)
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return appdb.Db.BatchWrite(keys, values)
+}
+
+// GetProfile returns the latest profile of the user, nil if the user has posted no profile
+func (appdb *AppDb) GetProfile(groupId, pubkey string) (*UserProfile, error) {
+	key := profileKey(groupId, pubkey)
+	exist, err := appdb.Db.IsExist(key)
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		return nil, nil
+	}
+
+	value, err := appdb.Db.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	var profile UserProfile
+	if err := json.Unmarshal(value, &profile); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// DelGroupProfiles deletes the profiles of the group
+func (appdb *AppDb) DelGroupProfiles(groupId string) error {
+	_, err := appdb.Db.PrefixDelete([]byte(fmt.Sprintf("%s%s_", PRF_PREFIX, groupId)))
+	return err
+}
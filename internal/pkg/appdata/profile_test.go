@@ -0,0 +1,76 @@
+package appdata
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/rumsystem/quorum/internal/pkg/storage"
+	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+func TestAddProfilesByTrx(t *testing.T) {
+	appdb := NewAppDb()
+	appdb.Db = storage.NewMemStore()
+	key := bytes.Repeat([]byte{1}, 32)
+	groupitem := &quorumpb.GroupItem{GroupId: "5ed3f9fe-81e2-450d-9146-7a329aac2b62", CipherKey: hex.EncodeToString(key)}
+	sender := "CAISIQOlA37+ghb05D5ZAKExjsto/H7eeCmkagcZ+BY/pjSOKw=="
+
+	newTrx := func(trxId string, timestamp int64, data []byte) *quorumpb.Trx {
+		encrypted, err := localcrypto.AesEncrypt(data, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &quorumpb.Trx{TrxId: trxId, GroupId: groupitem.GroupId, Type: quorumpb.TrxType_POST, SenderPubkey: sender, TimeStamp: timestamp, Data: encrypted}
+	}
+	newProfileTrx := func(trxId string, timestamp int64, name string) *quorumpb.Trx {
+		post, err := NewProfilePost(&Profile{Name: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return newTrx(trxId, timestamp, post)
+	}
+
+	// the last profile in the block wins, not the one of the latest timestamp
+	trxs := []*quorumpb.Trx{
+		newProfileTrx("trx-1", 300, "first"),
+		newTrx("trx-note", 400, []byte(`{"type":"Note","content":"hi"}`)),
+		newProfileTrx("trx-2", 100, "second"),
+	}
+	if err := appdb.AddProfilesByTrx(groupitem, 10, trxs); err != nil {
+		t.Fatal(err)
+	}
+	profile, err := appdb.GetProfile(groupitem.GroupId, sender)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile == nil || profile.Name != "second" || profile.TrxId != "trx-2" || profile.BlockId != 10 {
+		t.Fatalf("want the profile of trx-2, got %+v", profile)
+	}
+
+	// a profile of a lower block does not replace it
+	if err := appdb.AddProfilesByTrx(groupitem, 9, []*quorumpb.Trx{newProfileTrx("trx-old", 500, "old")}); err != nil {
+		t.Fatal(err)
+	}
+	if profile, _ = appdb.GetProfile(groupitem.GroupId, sender); profile.TrxId != "trx-2" {
+		t.Errorf("profile of a lower block should be ignored, got %+v", profile)
+	}
+
+	// an invalid profile is ignored
+	invalid := newTrx("trx-invalid", 0, []byte(`{"type":"Profile","object":{"name":"`+strings.Repeat("a", MaxProfileNameLen+1)+`"}}`))
+	if err := appdb.AddProfilesByTrx(groupitem, 11, []*quorumpb.Trx{invalid, newProfileTrx("trx-3", 0, "third")}); err != nil {
+		t.Fatal(err)
+	}
+	if profile, _ = appdb.GetProfile(groupitem.GroupId, sender); profile.TrxId != "trx-3" {
+		t.Errorf("want the profile of trx-3, got %+v", profile)
+	}
+
+	if err := appdb.DelGroupProfiles(groupitem.GroupId); err != nil {
+		t.Fatal(err)
+	}
+	if profile, err = appdb.GetProfile(groupitem.GroupId, sender); err != nil || profile != nil {
+		t.Errorf("profile should be deleted, got %+v %v", profile, err)
+	}
+}
@@ -0,0 +1,380 @@
+package appdata
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
+	"github.com/rumsystem/quorum/internal/pkg/nodectx"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+const SCH_PREFIX string = "sch_" // search index status of a group
+const IDX_PREFIX string = "idx_" // search index postings
+
+const (
+	searchMinTokenLen   = 2  // in runes, except the cjk characters which are indexed one by one
+	searchMaxTokenLen   = 64 // in bytes
+	searchMaxTrxTokens  = 1000
+	searchMaxPostings   = 10000 // postings read for a token on search
+	searchMaxQueryTerms = 16
+)
+
+// SearchIndexStatus is saved for each group with search enabled, IndexedBlock is the last indexed block
+type SearchIndexStatus struct {
+	GroupId      string `json:"group_id"`
+	Enabled      bool   `json:"enabled"`
+	IndexedBlock uint64 `json:"indexed_block"`
+	EnabledAt    int64  `json:"enabled_at,omitempty"`
+}
+
+type SearchResultItem struct {
+	TrxId     string `json:"trx_id"`
+	TimeStamp int64  `json:"timestamp"`
+}
+
+func searchStatusKey(groupId string) []byte {
+	return []byte(SCH_PREFIX + groupId)
+}
+
+func searchPostingPrefix(groupId, token string) string {
+	return IDX_PREFIX + groupId + "_" + token + "_"
+}
+
+// searchPostingKey sorts the postings of a token from the newest to the oldest
+func searchPostingKey(groupId, token string, timestamp int64, trxId string) []byte {
+	if timestamp < 0 {
+		timestamp = 0
+	}
+	return []byte(fmt.Sprintf("%s%016x_%s", searchPostingPrefix(groupId, token), uint64(math.MaxInt64-timestamp), trxId))
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// Tokenize splits the text into lower case words, the cjk characters are single tokens
+func Tokenize(text string) []string {
+	tokens := []string{}
+	seen := make(map[string]bool)
+	add := func(token string) {
+		if len(token) > searchMaxTokenLen || seen[token] {
+			return
+		}
+		seen[token] = true
+		tokens = append(tokens, token)
+	}
+
+	var word strings.Builder
+	flush := func() {
+		if utf8.RuneCountInString(word.String()) >= searchMinTokenLen {
+			add(word.String())
+		}
+		word.Reset()
+	}
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case isCJK(r):
+			flush()
+			add(string(r))
+		case unicode.IsLetter(r) || unicode.IsNumber(r):
+			word.WriteRune(r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
+
+// searchableText returns the string values of json data, or the data itself if it is utf8 text
+func searchableText(data []byte) string {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		if utf8.Valid(data) {
+			return string(data)
+		}
+		return ""
+	}
+
+	var texts []string
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case string:
+			texts = append(texts, v)
+		case []interface{}:
+			for _, item := range v {
+				walk(item)
+			}
+		case map[string]interface{}:
+			for _, item := range v {
+				walk(item)
+			}
+		}
+	}
+	walk(v)
+	return strings.Join(texts, " ")
+}
+
+func (appdb *AppDb) GetSearchIndexStatus(groupId string) (*SearchIndexStatus, error) {
+	key := searchStatusKey(groupId)
+	exist, err := appdb.Db.IsExist(key)
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		return &SearchIndexStatus{GroupId: groupId}, nil
+	}
+
+	value, err := appdb.Db.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	var status SearchIndexStatus
+	if err := json.Unmarshal(value, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// GetSearchIndexStatuses returns the groups with search enabled
+func (appdb *AppDb) GetSearchIndexStatuses() ([]*SearchIndexStatus, error) {
+	statuses := []*SearchIndexStatus{}
+	err := appdb.Db.PrefixForeach([]byte(SCH_PREFIX), func(k []byte, v []byte, err error) error {
+		if err != nil {
+			return err
+		}
+		var status SearchIndexStatus
+		if err := json.Unmarshal(v, &status); err != nil {
+			return err
+		}
+		statuses = append(statuses, &status)
+		return nil
+	})
+	return statuses, err
+}
+
+// EnableSearchIndex starts indexing the group from the first block, it does nothing if the group is indexed
+func (appdb *AppDb) EnableSearchIndex(groupId string) (*SearchIndexStatus, error) {
+	status, err := appdb.GetSearchIndexStatus(groupId)
+	if err != nil || status.Enabled {
+		return status, err
+	}
+	status = &SearchIndexStatus{GroupId: groupId, Enabled: true, EnabledAt: time.Now().UnixNano()}
+	value, err := json.Marshal(status)
+	if err != nil {
+		return nil, err
+	}
+	return status, appdb.Db.Set(searchStatusKey(groupId), value)
+}
+
+// DelSearchIndex disables search of the group and deletes its index
+func (appdb *AppDb) DelSearchIndex(groupId string) error {
+	appdb.searchMu.Lock()
+	defer appdb.searchMu.Unlock()
+	if err := appdb.Db.Delete(searchStatusKey(groupId)); err != nil {
+		return err
+	}
+	_, err := appdb.Db.PrefixDelete([]byte(IDX_PREFIX + groupId + "_"))
+	return err
+}
+
+// IndexTrxs adds the POST trxs of the block to the index and moves the status to the block in one batch,
+// the chunk posts are left out. Data of the trxs should be decrypted. Indexing a block again is harmless.
+func (appdb *AppDb) IndexTrxs(status *SearchIndexStatus, blockId uint64, trxs []*quorumpb.Trx) error {
+	appdb.searchMu.Lock()
+	defer appdb.searchMu.Unlock()
+	// the index may be deleted while the block is being indexed
+	exist, err := appdb.Db.IsExist(searchStatusKey(status.GroupId))
+	if err != nil || !exist {
+		return err
+	}
+
+	keys := [][]byte{}
+	values := [][]byte{}
+	for _, trx := range trxs {
+		// the chunks are parts of an object, their text is not searchable
+		if trx.Type != quorumpb.TrxType_POST || len(trx.Data) == 0 || ParseChunk(trx.Data) != nil {
+			continue
+		}
+		tokens := Tokenize(searchableText(trx.Data))
+		if len(tokens) > searchMaxTrxTokens {
+			tokens = tokens[:searchMaxTrxTokens]
+		}
+		for _, token := range tokens {
+			keys = append(keys, searchPostingKey(status.GroupId, token, trx.TimeStamp, trx.TrxId))
+			values = append(values, []byte(strconv.FormatInt(trx.TimeStamp, 10)))
+		}
+	}
+
+	status.IndexedBlock = blockId
+	value, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	keys = append(keys, searchStatusKey(status.GroupId))
+	values = append(values, value)
+	return appdb.Db.BatchWrite(keys, values)
+}
+
+// SearchGroupContent returns the trxs containing all words of the query, from the newest to the oldest,
+// the trxs for which skip returns true are left out, skip can be nil
+func (appdb *AppDb) SearchGroupContent(groupId, query string, num int, skip func(trxId string) bool) ([]*SearchResultItem, error) {
+	tokens := Tokenize(query)
+	if len(tokens) == 0 {
+		return nil, errors.New("no searchable word in the query")
+	}
+	if len(tokens) > searchMaxQueryTerms {
+		return nil, fmt.Errorf("too many words in the query, the maximum is %d", searchMaxQueryTerms)
+	}
+
+	var matched map[string]int64
+	for _, token := range tokens {
+		postings, err := appdb.getSearchPostings(groupId, token)
+		if err != nil {
+			return nil, err
+		}
+		if matched == nil {
+			matched = postings
+			continue
+		}
+		for trxId := range matched {
+			if _, ok := postings[trxId]; !ok {
+				delete(matched, trxId)
+			}
+		}
+	}
+
+	items := make([]*SearchResultItem, 0, len(matched))
+	for trxId, ts := range matched {
+		if isRemoved(groupId, trxId) {
+			continue
+		}
+		items = append(items, &SearchResultItem{TrxId: trxId, TimeStamp: ts})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].TimeStamp != items[j].TimeStamp {
+			return items[i].TimeStamp > items[j].TimeStamp
+		}
+		return items[i].TrxId < items[j].TrxId
+	})
+	res := make([]*SearchResultItem, 0, num)
+	for _, item := range items {
+		if len(res) >= num {
+			break
+		}
+		if skip != nil && skip(item.TrxId) {
+			continue
+		}
+		res = append(res, item)
+	}
+	return res, nil
+}
+
+func (appdb *AppDb) getSearchPostings(groupId, token string) (map[string]int64, error) {
+	prefix := searchPostingPrefix(groupId, token)
+	postings := make(map[string]int64)
+	err := appdb.Db.PrefixForeach([]byte(prefix), func(k []byte, v []byte, err error) error {
+		if err != nil {
+			return err
+		}
+		// key tail: <inverted timestamp>_<trx id>
+		tail := string(k[len(prefix):])
+		idx := strings.IndexByte(tail, '_')
+		if idx < 0 {
+			return nil
+		}
+		ts, err := strconv.ParseInt(string(v), 10, 64)
+		if err != nil {
+			return nil
+		}
+		postings[tail[idx+1:]] = ts
+		if len(postings) >= searchMaxPostings {
+			return errSearchPostingsFull
+		}
+		return nil
+	})
+	if errors.Is(err, errSearchPostingsFull) {
+		err = nil
+	}
+	return postings, err
+}
+
+var errSearchPostingsFull = errors.New("search postings full")
+
+// SearchIndexer indexes the blocks handled by appsync for the groups with search enabled,
+// the status is saved with each block, so indexing resumes after restart
+type SearchIndexer struct {
+	appdb    *AppDb
+	nodename string
+}
+
+func NewSearchIndexer(appdb *AppDb, nodename string) *SearchIndexer {
+	return &SearchIndexer{appdb: appdb, nodename: nodename}
+}
+
+func (s *SearchIndexer) Start(interval int) {
+	go func() {
+		for {
+			statuses, err := s.appdb.GetSearchIndexStatuses()
+			if err != nil {
+				appsynclog.Errorf("get search index statuses failed: %s", err)
+			}
+			for _, status := range statuses {
+				s.index(status)
+			}
+
+			time.Sleep(time.Duration(interval) * time.Second)
+		}
+	}()
+}
+
+// index indexes the blocks of the group until it catches up with appsync
+func (s *SearchIndexer) index(status *SearchIndexStatus) {
+	group, ok := chain.GetGroupMgr().Groups[status.GroupId]
+	if !ok {
+		return
+	}
+	syncedStr, err := s.appdb.GetGroupStatus(status.GroupId, "Block")
+	if err != nil || syncedStr == "" {
+		return
+	}
+	synced, err := strconv.ParseUint(syncedStr, 10, 64)
+	if err != nil {
+		return
+	}
+
+	for blockId := status.IndexedBlock + 1; blockId <= synced; blockId++ {
+		block, err := nodectx.GetNodeCtx().GetChainStorage().GetBlock(status.GroupId, blockId, false, s.nodename)
+		if err != nil {
+			appsynclog.Errorf("<%s> search index get block %d failed: %s", status.GroupId, blockId, err)
+			return
+		}
+
+		trxs := make([]*quorumpb.Trx, 0, len(block.Trxs))
+		for _, trx := range block.Trxs {
+			if trx.Type != quorumpb.TrxType_POST {
+				continue
+			}
+			trx = proto.Clone(trx).(*quorumpb.Trx)
+			if err := DecryptPostTrx(group.Item, trx); err != nil {
+				continue
+			}
+			trxs = append(trxs, trx)
+		}
+		if err := s.appdb.IndexTrxs(status, blockId, trxs); err != nil {
+			appsynclog.Errorf("<%s> search index block %d failed: %s", status.GroupId, blockId, err)
+			return
+		}
+	}
+}
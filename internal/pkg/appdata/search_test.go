@@ -0,0 +1,107 @@
+package appdata
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/rumsystem/quorum/internal/pkg/storage"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+func TestTokenize(t *testing.T) {
+	cases := []struct {
+		text   string
+		tokens []string
+	}{
+		{"Hello, World! hello a x1", []string{"hello", "world", "x1"}},
+		{"量子 rum", []string{"量", "子", "rum"}},
+		{"  ...  ", []string{}},
+	}
+	for _, c := range cases {
+		if got := Tokenize(c.text); !reflect.DeepEqual(got, c.tokens) {
+			t.Errorf("Tokenize(%q) want %v, got %v", c.text, c.tokens, got)
+		}
+	}
+}
+
+func TestSearchableText(t *testing.T) {
+	text := searchableText([]byte(`{"type":"Note","object":{"content":"hello quorum","tags":["rum"]},"n":1}`))
+	for _, token := range []string{"note", "hello", "quorum", "rum"} {
+		found := false
+		for _, got := range Tokenize(text) {
+			if got == token {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expect %s in the text of the json data, got %q", token, text)
+		}
+	}
+	if text := searchableText([]byte{0xff, 0xfe}); text != "" {
+		t.Errorf("expect no text for binary data, got %q", text)
+	}
+}
+
+func TestSearchIndex(t *testing.T) {
+	db, err := storage.NewStore(context.Background(), t.TempDir(), "appdb")
+	if err != nil {
+		t.Fatalf("create store failed: %s", err)
+	}
+	defer db.Close()
+	appdb := NewAppDb()
+	appdb.Db = db
+
+	groupId := "5ed3f9fe-81e2-450d-9146-7a329aac2b62"
+	trxs := []*quorumpb.Trx{
+		{TrxId: "d4c1b0a2-0d3e-4f5a-9b8c-1a2b3c4d5e6f", Type: quorumpb.TrxType_POST, TimeStamp: 100, Data: []byte(`{"content":"hello quorum"}`)},
+		{TrxId: "e5d2c1b3-1e4f-4a6b-8c9d-2b3c4d5e6f70", Type: quorumpb.TrxType_POST, TimeStamp: 200, Data: []byte(`{"content":"hello world"}`)},
+		{TrxId: "f6e3d2c4-2f5a-4b7c-9d0e-3c4d5e6f7081", Type: quorumpb.TrxType_ANNOUNCE, TimeStamp: 300, Data: []byte(`hello`)},
+		{TrxId: "a7f4e3d5-3a6b-4c8d-8e1f-4d5e6f708192", Type: quorumpb.TrxType_POST, TimeStamp: 400, Data: []byte(`{"type":"Chunk","object":{"id":"1","index":0,"total":2,"hash":"hello"}}`)},
+	}
+
+	status, err := appdb.EnableSearchIndex(groupId)
+	if err != nil || !status.Enabled {
+		t.Fatalf("EnableSearchIndex failed: %+v %v", status, err)
+	}
+	if err := appdb.IndexTrxs(status, 1, trxs); err != nil {
+		t.Fatalf("IndexTrxs failed: %s", err)
+	}
+	if status, _ := appdb.GetSearchIndexStatus(groupId); status.IndexedBlock != 1 {
+		t.Errorf("expect indexed block 1 saved, got %d", status.IndexedBlock)
+	}
+
+	items, err := appdb.SearchGroupContent(groupId, "Hello", 10, nil)
+	if err != nil {
+		t.Fatalf("SearchGroupContent failed: %s", err)
+	}
+	if len(items) != 2 || items[0].TrxId != trxs[1].TrxId || items[1].TrxId != trxs[0].TrxId {
+		t.Errorf("expect the posts ranked by recency without the chunk, got %+v", items)
+	}
+	if items, _ := appdb.SearchGroupContent(groupId, "hello quorum", 10, nil); len(items) != 1 || items[0].TrxId != trxs[0].TrxId {
+		t.Errorf("expect only the post with all words, got %+v", items)
+	}
+	if items, _ := appdb.SearchGroupContent(groupId, "hello", 1, nil); len(items) != 1 || items[0].TrxId != trxs[1].TrxId {
+		t.Errorf("expect the newest post, got %+v", items)
+	}
+	skip := func(trxId string) bool { return trxId == trxs[1].TrxId }
+	if items, _ := appdb.SearchGroupContent(groupId, "hello", 1, skip); len(items) != 1 || items[0].TrxId != trxs[0].TrxId {
+		t.Errorf("expect the skipped post left out before the limit, got %+v", items)
+	}
+	if _, err := appdb.SearchGroupContent(groupId, "!", 10, nil); err == nil {
+		t.Errorf("expect error for a query without words")
+	}
+
+	if err := appdb.DelSearchIndex(groupId); err != nil {
+		t.Fatalf("DelSearchIndex failed: %s", err)
+	}
+	if items, _ := appdb.SearchGroupContent(groupId, "hello", 10, nil); len(items) != 0 {
+		t.Errorf("expect the index deleted, got %+v", items)
+	}
+	if err := appdb.IndexTrxs(status, 2, trxs); err != nil {
+		t.Fatalf("IndexTrxs failed: %s", err)
+	}
+	if status, _ := appdb.GetSearchIndexStatus(groupId); status.Enabled {
+		t.Errorf("expect a deleted index not recreated by indexing")
+	}
+}
@@ -0,0 +1,92 @@
+package appdata
+
+import (
+	"sync"
+
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+const maxTrxSubscriberBuffer = 1024
+
+// TrxSubscriber receives content trxs of a group once they are indexed by appsync.
+// C is closed when the subscriber can not keep up, the client should resume from the last received trx.
+type TrxSubscriber struct {
+	GroupId string
+	C       chan *quorumpb.Trx
+
+	closed bool
+}
+
+type trxHub struct {
+	mu   sync.Mutex
+	subs map[string]map[*TrxSubscriber]struct{}
+}
+
+var hub = &trxHub{subs: make(map[string]map[*TrxSubscriber]struct{})}
+
+// SubscribeGroupTrx registers a subscriber for the new content trxs of the group,
+// the caller must call UnsubscribeGroupTrx when done
+func SubscribeGroupTrx(groupId string) *TrxSubscriber {
+	sub := &TrxSubscriber{
+		GroupId: groupId,
+		C:       make(chan *quorumpb.Trx, maxTrxSubscriberBuffer),
+	}
+
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	if _, ok := hub.subs[groupId]; !ok {
+		hub.subs[groupId] = make(map[*TrxSubscriber]struct{})
+	}
+	hub.subs[groupId][sub] = struct{}{}
+	return sub
+}
+
+// UnsubscribeGroupTrx removes the subscriber, it is safe to call more than once
+func UnsubscribeGroupTrx(sub *TrxSubscriber) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	hub.remove(sub)
+}
+
+// GetGroupTrxSubscriberCount returns the number of subscribers of the group
+func GetGroupTrxSubscriberCount(groupId string) int {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	return len(hub.subs[groupId])
+}
+
+func (h *trxHub) remove(sub *TrxSubscriber) {
+	if subs, ok := h.subs[sub.GroupId]; ok {
+		delete(subs, sub)
+		if len(subs) == 0 {
+			delete(h.subs, sub.GroupId)
+		}
+	}
+	if !sub.closed {
+		sub.closed = true
+		close(sub.C)
+	}
+}
+
+// publishGroupTrx never blocks appsync, a subscriber with full buffer is dropped
+func publishGroupTrx(groupId string, trxs []*quorumpb.Trx) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	for sub := range hub.subs[groupId] {
+		for _, trx := range trxs {
+			if trx.Type != quorumpb.TrxType_POST {
+				continue
+			}
+			select {
+			case sub.C <- trx:
+			default:
+				appsynclog.Warningf("<%s> trx subscriber is too slow, drop it", groupId)
+				hub.remove(sub)
+			}
+			if sub.closed {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package appdata
+
+import (
+	"testing"
+
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+func TestGroupTrxSubscriber(t *testing.T) {
+	groupId := "5ed3f9fe-81e2-450d-9146-7a329aac2b62"
+	sub := SubscribeGroupTrx(groupId)
+	other := SubscribeGroupTrx("other-group")
+	defer UnsubscribeGroupTrx(other)
+
+	publishGroupTrx(groupId, []*quorumpb.Trx{
+		{TrxId: "trx-1", Type: quorumpb.TrxType_POST},
+		{TrxId: "trx-2", Type: quorumpb.TrxType_ANNOUNCE},
+		{TrxId: "trx-3", Type: quorumpb.TrxType_POST},
+	})
+
+	for _, want := range []string{"trx-1", "trx-3"} {
+		trx := <-sub.C
+		if trx.TrxId != want {
+			t.Errorf("want trx %s, got %s", want, trx.TrxId)
+		}
+	}
+	if len(other.C) != 0 {
+		t.Errorf("subscriber of other group should not receive trx")
+	}
+
+	UnsubscribeGroupTrx(sub)
+	UnsubscribeGroupTrx(sub)
+	if _, ok := <-sub.C; ok {
+		t.Errorf("channel should be closed after unsubscribe")
+	}
+	if n := GetGroupTrxSubscriberCount(groupId); n != 0 {
+		t.Errorf("want 0 subscriber, got %d", n)
+	}
+}
+
+func TestSlowGroupTrxSubscriberDropped(t *testing.T) {
+	groupId := "ac0eea7c-2f3c-4c67-80b3-136e46b924a8"
+	sub := SubscribeGroupTrx(groupId)
+	defer UnsubscribeGroupTrx(sub)
+
+	trxs := make([]*quorumpb.Trx, maxTrxSubscriberBuffer+1)
+	for i := range trxs {
+		trxs[i] = &quorumpb.Trx{Type: quorumpb.TrxType_POST}
+	}
+	publishGroupTrx(groupId, trxs)
+
+	if n := GetGroupTrxSubscriberCount(groupId); n != 0 {
+		t.Errorf("slow subscriber should be dropped, got %d subscriber", n)
+	}
+	count := 0
+	for range sub.C {
+		count++
+	}
+	if count != maxTrxSubscriberBuffer {
+		t.Errorf("want %d buffered trx, got %d", maxTrxSubscriberBuffer, count)
+	}
+}
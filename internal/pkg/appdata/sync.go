@@ -1,7 +1,8 @@
 package appdata
 
 import (
-	"strconv"
+	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/rumsystem/quorum/internal/pkg/nodectx"
 	"github.com/rumsystem/quorum/internal/pkg/storage"
 	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+	"google.golang.org/protobuf/proto"
 )
 
 var (
@@ -26,12 +28,45 @@ type OnChainTrxEvent struct {
 	TrxId   string `json:"trx_id"`
 }
 
+// pending blocks read at most by GetStatus to count the pending trxs
+const appSyncLagScanBlocks = 1000
+
 type AppSync struct {
 	appdb    *AppDb
 	dbmgr    *storage.DbMgr
 	groupmgr *chain.GroupMgr
 	apiroot  string
 	nodename string
+
+	trigger chan struct{}
+	syncMu  sync.Mutex // serializes the passes and the filter changes
+
+	mu          sync.Mutex
+	filter      *AppSyncFilter
+	interval    int
+	lastSyncAt  time.Time
+	lastError   string
+	lastErrorAt time.Time
+	errorCount  uint64
+}
+
+type AppSyncGroupStatus struct {
+	GroupId      string `json:"group_id" example:"ac0eea7c-2f3c-4c67-80b3-136e46b924a8"`
+	SyncedBlock  uint64 `json:"synced_block" example:"1200"`
+	CurrentBlock uint64 `json:"current_block" example:"1210"`
+	LagBlocks    uint64 `json:"lag_blocks" example:"10"`
+	LagTrxs      uint64 `json:"lag_trxs" example:"25"` // trxs in the pending blocks, at most 1000 pending blocks are counted
+}
+
+type AppSyncStatus struct {
+	Interval    int                   `json:"interval" example:"10"` // seconds between passes
+	LastSyncAt  time.Time             `json:"last_sync_at"`          // end of the last pass, zero if no pass is done
+	LastError   string                `json:"last_error,omitempty"`
+	LastErrorAt time.Time             `json:"last_error_at,omitempty"`
+	ErrorCount  uint64                `json:"error_count" example:"0"`
+	LagBlocks   uint64                `json:"lag_blocks" example:"10"`
+	LagTrxs     uint64                `json:"lag_trxs" example:"25"`
+	Groups      []*AppSyncGroupStatus `json:"groups"`
 }
 
 func GetOnChainTrxQueue() *deque.Deque[*OnChainTrxEvent] {
@@ -59,7 +94,19 @@ func pushOnChainTrxQueue(trxs []*quorumpb.Trx) {
 
 func NewAppSyncAgent(apiroot string, nodename string, appdb *AppDb, dbmgr *storage.DbMgr) *AppSync {
 	groupmgr := chain.GetGroupMgr()
-	appsync := &AppSync{appdb, dbmgr, groupmgr, apiroot, nodename}
+	appsync := &AppSync{
+		appdb:    appdb,
+		dbmgr:    dbmgr,
+		groupmgr: groupmgr,
+		apiroot:  apiroot,
+		nodename: nodename,
+		trigger:  make(chan struct{}, 1),
+	}
+	filter, err := appdb.GetAppSyncFilter()
+	if err != nil {
+		appsynclog.Errorf("get appsync filter failed: %s", err)
+	}
+	appsync.filter = filter
 	return appsync
 }
 
@@ -73,72 +120,272 @@ func (appsync *AppSync) GetGroups() []*quorumpb.GroupItem {
 
 func (appsync *AppSync) ParseBlockTrxs(groupid string, block *quorumpb.Block) error {
 	appsynclog.Infof("ParseBlockTrxs %d trx(s) on group %s blockId <%d>", len(block.Trxs), groupid, block.BlockId)
-	err := appsync.appdb.AddMetaByTrx(block.BlockId, groupid, block.Trxs)
+	trxs := appsync.filterTrxs(groupid, block.Trxs)
+	if group, ok := appsync.groupmgr.Groups[groupid]; ok {
+		if err := appsync.appdb.AddChunksByTrx(group.Item, trxs); err != nil {
+			appsynclog.Errorf("ParseBlockTrxs on group %s err: %s", groupid, err)
+			return err
+		}
+		if err := appsync.appdb.AddProfilesByTrx(group.Item, block.BlockId, trxs); err != nil {
+			appsynclog.Errorf("ParseBlockTrxs on group %s err: %s", groupid, err)
+			return err
+		}
+	}
+	err := appsync.appdb.AddMetaByTrx(block.BlockId, groupid, trxs)
 	if err != nil {
 		appsynclog.Errorf("ParseBlockTrxs on group %s err:  ", groupid, err)
 		return err
 	}
 
-	pushOnChainTrxQueue(block.Trxs)
+	// blocks reindexed after a filter change were published
+	reindexTo, err := appsync.appdb.getGroupBlockStatus(groupid, "ReindexTo")
+	if err != nil {
+		return err
+	}
+	if block.BlockId > reindexTo {
+		pushOnChainTrxQueue(block.Trxs)
+		publishGroupTrx(groupid, block.Trxs)
+	}
 
 	return nil
 }
 
-func (appsync *AppSync) RunSync(groupid string, lastSyncBlock uint64, highestBlock uint64) {
+// filterTrxs returns the trxs matching the filter
+func (appsync *AppSync) filterTrxs(groupid string, trxs []*quorumpb.Trx) []*quorumpb.Trx {
+	filter := appsync.GetFilter()
+	if filter == nil {
+		return trxs
+	}
+	if !filter.MatchGroup(groupid) {
+		return nil
+	}
 
-	for {
-		if lastSyncBlock >= highestBlock {
-			break
+	var groupitem *quorumpb.GroupItem
+	if group, ok := appsync.groupmgr.Groups[groupid]; ok {
+		groupitem = group.Item
+	}
+	matched := make([]*quorumpb.Trx, 0, len(trxs))
+	for _, trx := range trxs {
+		var data []byte
+		if trx.Type == quorumpb.TrxType_POST && filter.NeedContent() && groupitem != nil {
+			decrypted := proto.Clone(trx).(*quorumpb.Trx)
+			if err := DecryptPostTrx(groupitem, decrypted); err == nil {
+				data = decrypted.Data
+			}
 		}
-		lastSyncBlock++
-		block, err := nodectx.GetNodeCtx().GetChainStorage().GetBlock(groupid, lastSyncBlock, false, appsync.nodename)
-		if err == nil {
-			err := appsync.ParseBlockTrxs(groupid, block)
-			if err != nil {
-				appsynclog.Errorf("<%s> epoch %d ParseBlockTrxs error %s", groupid, block.Epoch, err)
-				break
+		if filter.MatchTrx(trx, data) {
+			matched = append(matched, trx)
+		}
+	}
+	return matched
+}
+
+func (appsync *AppSync) GetFilter() *AppSyncFilter {
+	appsync.mu.Lock()
+	defer appsync.mu.Unlock()
+	return appsync.filter
+}
+
+// SetFilter saves the filter and reindexes the groups whose content changes by it, nil removes the filter.
+// It returns the groups to reindex, they are reindexed by the next pass which is triggered at once.
+func (appsync *AppSync) SetFilter(filter *AppSyncFilter) ([]string, error) {
+	if filter != nil {
+		if err := filter.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	appsync.syncMu.Lock()
+	defer appsync.syncMu.Unlock()
+
+	old := appsync.GetFilter()
+	if err := appsync.appdb.SetAppSyncFilter(filter); err != nil {
+		return nil, err
+	}
+	appsync.mu.Lock()
+	appsync.filter = filter
+	appsync.mu.Unlock()
+
+	reindexed := []string{}
+	trxFilterChanged := !sameTrxFilter(old, filter)
+	for _, groupitem := range appsync.GetGroups() {
+		groupId := groupitem.GroupId
+		included := filter.MatchGroup(groupId)
+		if !included && !old.MatchGroup(groupId) {
+			continue
+		}
+		if included == old.MatchGroup(groupId) && !trxFilterChanged {
+			continue
+		}
+
+		if !included {
+			// the content of an excluded group is dropped, the blocks are not read again
+			if err := appsync.appdb.DelGroupContent(groupId); err != nil {
+				return reindexed, err
 			}
+			continue
+		}
+		if err := appsync.appdb.ResetGroupContent(groupId, appsync.firstUnprunedBlock(groupId)); err != nil {
+			return reindexed, err
+		}
+		reindexed = append(reindexed, groupId)
+	}
+	sort.Strings(reindexed)
+
+	appsync.Trigger()
+	return reindexed, nil
+}
 
-		} else {
+// firstUnprunedBlock returns the block before the first block kept by pruning, the reindex starts after it
+func (appsync *AppSync) firstUnprunedBlock(groupId string) uint64 {
+	prunedBefore, err := nodectx.GetNodeCtx().GetChainStorage().GetPrunedBefore(groupId, appsync.nodename)
+	if err != nil || prunedBefore < 1 {
+		return 0
+	}
+	return prunedBefore - 1
+}
+
+func (appsync *AppSync) RunSync(groupid string, lastSyncBlock uint64, highestBlock uint64) error {
+	for lastSyncBlock < highestBlock {
+		lastSyncBlock++
+		block, err := nodectx.GetNodeCtx().GetChainStorage().GetBlock(groupid, lastSyncBlock, false, appsync.nodename)
+		if err != nil {
 			appsynclog.Errorf("db read err: %s, groupid: %s, lastSyncEpoch : %d, HighestEpoch: %d", err, groupid, lastSyncBlock, highestBlock)
-			break
+			return err
+		}
+		if err := appsync.ParseBlockTrxs(groupid, block); err != nil {
+			appsynclog.Errorf("<%s> epoch %d ParseBlockTrxs error %s", groupid, block.Epoch, err)
+			return err
 		}
 	}
+	return nil
+}
+
+// syncedBlock returns the last block handled by appsync of the group
+func (appsync *AppSync) syncedBlock(groupId string) (uint64, error) {
+	return appsync.appdb.getGroupBlockStatus(groupId, "Block")
+}
+
+func (appsync *AppSync) onError(err error) {
+	appsync.mu.Lock()
+	defer appsync.mu.Unlock()
+	appsync.errorCount++
+	appsync.lastError = err.Error()
+	appsync.lastErrorAt = time.Now()
+}
+
+// syncOnce applies the new blocks of all groups to the appdb
+func (appsync *AppSync) syncOnce() {
+	appsync.syncMu.Lock()
+	defer appsync.syncMu.Unlock()
+
+	groups := appsync.GetGroups()
+	for _, groupitem := range groups {
+		groupId := groupitem.GroupId
+		group, ok := appsync.groupmgr.Groups[groupId]
+		if !ok {
+			appsynclog.Errorf("can not find group : %s", groupId)
+			continue
+		}
+
+		lastSyncBlock, err := appsync.syncedBlock(groupId)
+		if err != nil {
+			appsynclog.Errorf("sync group : %s Get Group last sync block err %s", groupId, err)
+			appsync.onError(fmt.Errorf("group %s: %s", groupId, err))
+			continue
+		}
+
+		if group.GetCurrentBlockId() > lastSyncBlock {
+			if err := appsync.RunSync(groupId, lastSyncBlock, group.GetCurrentBlockId()); err != nil {
+				appsync.onError(fmt.Errorf("group %s: %s", groupId, err))
+			}
+		}
+	}
+
+	appsync.mu.Lock()
+	appsync.lastSyncAt = time.Now()
+	appsync.mu.Unlock()
 }
 
+// Start runs a pass every interval seconds, or when it is triggered
 func (appsync *AppSync) Start(interval int) {
+	appsync.mu.Lock()
+	appsync.interval = interval
+	appsync.mu.Unlock()
+
 	go func() {
 		for {
-			groups := appsync.GetGroups()
-			for _, groupitem := range groups {
-				groupId := groupitem.GroupId
-				group, ok := appsync.groupmgr.Groups[groupId]
-				if !ok {
-					appsynclog.Errorf("can not find group : %s", groupId)
-					continue
-				}
-
-				blockIdStr, err := appsync.appdb.GetGroupStatus(groupId, "Block")
-				if err == nil {
-					if blockIdStr == "" { //init, set to 0
-						blockIdStr = "0"
-					}
-				} else {
-					appsynclog.Errorf("sync group : %s GetGroupStatus err %s", groupId, err)
-					continue
-				}
-
-				lastSyncBlock, err := strconv.ParseUint(blockIdStr, 10, 64)
-				if err == nil {
-					if group.GetCurrentBlockId() > lastSyncBlock {
-						appsync.RunSync(groupId, lastSyncBlock, group.GetCurrentBlockId())
-					}
-				} else {
-					appsynclog.Errorf("sync group : %s Get Group last sync block err %s", groupId, err)
-				}
-			}
+			appsync.syncOnce()
 
-			time.Sleep(time.Duration(interval) * time.Second)
+			select {
+			case <-time.After(time.Duration(interval) * time.Second):
+			case <-appsync.trigger:
+			}
 		}
 	}()
 }
+
+// Trigger starts a pass immediately, or right after the running one
+func (appsync *AppSync) Trigger() {
+	select {
+	case appsync.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// GetStatus returns the last pass and the lag of the groups behind their chains
+func (appsync *AppSync) GetStatus() *AppSyncStatus {
+	appsync.mu.Lock()
+	status := &AppSyncStatus{
+		Interval:    appsync.interval,
+		LastSyncAt:  appsync.lastSyncAt,
+		LastError:   appsync.lastError,
+		LastErrorAt: appsync.lastErrorAt,
+		ErrorCount:  appsync.errorCount,
+		Groups:      []*AppSyncGroupStatus{},
+	}
+	appsync.mu.Unlock()
+
+	for _, groupitem := range appsync.GetGroups() {
+		group, ok := appsync.groupmgr.Groups[groupitem.GroupId]
+		if !ok {
+			continue
+		}
+		synced, err := appsync.syncedBlock(groupitem.GroupId)
+		if err != nil {
+			appsynclog.Warningf("get synced block of group %s failed: %s", groupitem.GroupId, err)
+			continue
+		}
+		groupStatus := &AppSyncGroupStatus{
+			GroupId:      groupitem.GroupId,
+			SyncedBlock:  synced,
+			CurrentBlock: group.GetCurrentBlockId(),
+		}
+		if groupStatus.CurrentBlock > synced {
+			groupStatus.LagBlocks = groupStatus.CurrentBlock - synced
+			groupStatus.LagTrxs = appsync.pendingTrxs(groupitem.GroupId, synced, groupStatus.CurrentBlock)
+		}
+		status.LagBlocks += groupStatus.LagBlocks
+		status.LagTrxs += groupStatus.LagTrxs
+		status.Groups = append(status.Groups, groupStatus)
+	}
+	sort.Slice(status.Groups, func(i, j int) bool { return status.Groups[i].GroupId < status.Groups[j].GroupId })
+
+	return status
+}
+
+// pendingTrxs counts the trxs of the blocks after `synced`, at most appSyncLagScanBlocks blocks are read
+func (appsync *AppSync) pendingTrxs(groupId string, synced, current uint64) uint64 {
+	if current-synced > appSyncLagScanBlocks {
+		current = synced + appSyncLagScanBlocks
+	}
+	var trxs uint64
+	for blockId := synced + 1; blockId <= current; blockId++ {
+		block, err := nodectx.GetNodeCtx().GetChainStorage().GetBlock(groupId, blockId, false, appsync.nodename)
+		if err != nil {
+			break
+		}
+		trxs += uint64(len(block.Trxs))
+	}
+	return trxs
+}
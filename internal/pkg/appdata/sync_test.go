@@ -0,0 +1,39 @@
+package appdata
+
+import (
+	"errors"
+	"testing"
+
+	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
+)
+
+func TestAppSyncStatus(t *testing.T) {
+	appsync := &AppSync{
+		groupmgr: &chain.GroupMgr{Groups: map[string]*chain.Group{}},
+		trigger:  make(chan struct{}, 1),
+		interval: 10,
+	}
+
+	status := appsync.GetStatus()
+	if status.Interval != 10 || !status.LastSyncAt.IsZero() || status.ErrorCount != 0 || len(status.Groups) != 0 {
+		t.Fatalf("unexpected initial status %+v", status)
+	}
+
+	appsync.onError(errors.New("first"))
+	appsync.onError(errors.New("second"))
+	appsync.syncOnce()
+	status = appsync.GetStatus()
+	if status.ErrorCount != 2 || status.LastError != "second" || status.LastErrorAt.IsZero() {
+		t.Errorf("expect 2 errors with the last one kept, got %+v", status)
+	}
+	if status.LastSyncAt.IsZero() {
+		t.Errorf("expect the last sync time set after a pass")
+	}
+
+	// triggers are coalesced while a pass is pending
+	appsync.Trigger()
+	appsync.Trigger()
+	if len(appsync.trigger) != 1 {
+		t.Errorf("expect 1 pending trigger, got %d", len(appsync.trigger))
+	}
+}
@@ -0,0 +1,34 @@
+package appdata
+
+import (
+	"encoding/hex"
+
+	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
+	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+// DecryptPostTrx replaces the data of a POST trx with the decrypted content,
+// data of a private group post which can not be decrypted by this node is set to nil
+func DecryptPostTrx(groupitem *quorumpb.GroupItem, trx *quorumpb.Trx) error {
+	if groupitem.EncryptType == quorumpb.GroupEncryptType_PRIVATE {
+		decryptData, err := chain.DecryptPrivatePost(groupitem, trx.Data)
+		if err != nil {
+			trx.Data = nil
+			return nil
+		}
+		trx.Data = decryptData
+		return nil
+	}
+
+	ciperKey, err := hex.DecodeString(groupitem.CipherKey)
+	if err != nil {
+		return err
+	}
+	decryptData, err := localcrypto.AesDecode(trx.Data, ciperKey)
+	if err != nil {
+		return err
+	}
+	trx.Data = decryptData
+	return nil
+}
@@ -0,0 +1,345 @@
+package appdata
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
+	"github.com/rumsystem/quorum/internal/pkg/nodectx"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+const WHK_PREFIX string = "whk_"
+
+const (
+	WebhookSignatureHeader = "X-Quorum-Signature"
+	WebhookIdHeader        = "X-Quorum-Webhook-Id"
+	WebhookDeliveryHeader  = "X-Quorum-Delivery"
+)
+
+// the events of WebhookPayload, caught_up is sent once after the blocks up to the caught up block, see CaughtUpEvent
+const (
+	WebhookEventBlock    = "block"
+	WebhookEventCaughtUp = "caught_up"
+)
+
+var (
+	webhookTimeout    = 10 * time.Second
+	webhookMinBackoff = 1 * time.Second
+	webhookMaxBackoff = 10 * time.Minute
+)
+
+// Webhook is a callback registered for a group, LastBlock is the last block delivered successfully
+type Webhook struct {
+	Id           string   `json:"id"`
+	GroupId      string   `json:"group_id"`
+	Url          string   `json:"url"`
+	Secret       string   `json:"secret,omitempty"`
+	TrxTypes     []string `json:"trx_types"` // empty for all types
+	LastBlock    uint64   `json:"last_block"`
+	CaughtUpSent bool     `json:"caught_up_sent"` // true if the caught up event is delivered, or the group was caught up when it was added
+	CreatedAt    int64    `json:"created_at"`
+}
+
+// WebhookPayload is the body posted to the webhook url, one delivery per block,
+// and one caught_up event without trxs when the group is caught up for the first time
+type WebhookPayload struct {
+	WebhookId string          `json:"webhook_id"`
+	GroupId   string          `json:"group_id"`
+	Event     string          `json:"event"`
+	BlockId   uint64          `json:"block_id"`
+	Trxs      []*quorumpb.Trx `json:"trxs"`
+}
+
+func webhookPrefix(groupId string) string {
+	return WHK_PREFIX + groupId + "_"
+}
+
+func webhookKey(groupId, id string) []byte {
+	return []byte(webhookPrefix(groupId) + id)
+}
+
+func (w *Webhook) Match(trx *quorumpb.Trx) bool {
+	if len(w.TrxTypes) == 0 {
+		return true
+	}
+	for _, t := range w.TrxTypes {
+		if t == trx.Type.String() {
+			return true
+		}
+	}
+	return false
+}
+
+func (appdb *AppDb) SetWebhook(w *Webhook) error {
+	value, err := json.Marshal(w)
+	if err != nil {
+		return err
+	}
+	return appdb.Db.Set(webhookKey(w.GroupId, w.Id), value)
+}
+
+func (appdb *AppDb) GetWebhook(groupId, id string) (*Webhook, error) {
+	key := webhookKey(groupId, id)
+	exist, err := appdb.Db.IsExist(key)
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		return nil, nil
+	}
+
+	value, err := appdb.Db.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	var w Webhook
+	if err := json.Unmarshal(value, &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// GetWebhooks returns webhooks of the group, or of all groups if groupId is empty
+func (appdb *AppDb) GetWebhooks(groupId string) ([]*Webhook, error) {
+	prefix := WHK_PREFIX
+	if groupId != "" {
+		prefix = webhookPrefix(groupId)
+	}
+
+	webhooks := []*Webhook{}
+	err := appdb.Db.PrefixForeach([]byte(prefix), func(k []byte, v []byte, err error) error {
+		if err != nil {
+			return err
+		}
+		var w Webhook
+		if err := json.Unmarshal(v, &w); err != nil {
+			return err
+		}
+		webhooks = append(webhooks, &w)
+		return nil
+	})
+
+	return webhooks, err
+}
+
+func (appdb *AppDb) DelWebhook(groupId, id string) error {
+	return appdb.Db.Delete(webhookKey(groupId, id))
+}
+
+func (appdb *AppDb) DelGroupWebhooks(groupId string) error {
+	_, err := appdb.Db.PrefixDelete([]byte(webhookPrefix(groupId)))
+	return err
+}
+
+// SignWebhookPayload returns the hex encoded HMAC-SHA256 of body with secret
+func SignWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+type webhookState struct {
+	failures    int
+	nextAttempt time.Time
+}
+
+// WebhookDispatcher delivers the blocks indexed by appsync to webhooks,
+// the cursor only moves forward after a 2xx response, so delivery is at-least-once
+type WebhookDispatcher struct {
+	appdb    *AppDb
+	nodename string
+	client   *http.Client
+
+	mu    sync.Mutex
+	state map[string]*webhookState
+}
+
+func NewWebhookDispatcher(appdb *AppDb, nodename string) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		appdb:    appdb,
+		nodename: nodename,
+		client:   &http.Client{Timeout: webhookTimeout},
+		state:    make(map[string]*webhookState),
+	}
+}
+
+func (d *WebhookDispatcher) Start(interval int) {
+	go func() {
+		for {
+			webhooks, err := d.appdb.GetWebhooks("")
+			if err != nil {
+				appsynclog.Errorf("get webhooks failed: %s", err)
+			}
+			for _, w := range webhooks {
+				d.dispatch(w)
+			}
+
+			time.Sleep(time.Duration(interval) * time.Second)
+		}
+	}()
+}
+
+// dispatch delivers pending blocks of the webhook in order until it fails or catches up
+func (d *WebhookDispatcher) dispatch(w *Webhook) {
+	state := d.getState(w.Id)
+	if time.Now().Before(state.nextAttempt) {
+		return
+	}
+
+	group, ok := chain.GetGroupMgr().Groups[w.GroupId]
+	if !ok {
+		return
+	}
+	syncedStr, err := d.appdb.GetGroupStatus(w.GroupId, "Block")
+	if err != nil || syncedStr == "" {
+		return
+	}
+	synced, err := strconv.ParseUint(syncedStr, 10, 64)
+	if err != nil {
+		return
+	}
+	caughtUp, err := d.appdb.GetGroupCaughtUp(w.GroupId)
+	if err != nil {
+		appsynclog.Errorf("<%s> webhook %s get caught up status failed: %s", w.GroupId, w.Id, err)
+		return
+	}
+	if !d.dispatchCaughtUp(w, state, caughtUp) {
+		return
+	}
+
+	for blockId := w.LastBlock + 1; blockId <= synced; blockId++ {
+		block, err := nodectx.GetNodeCtx().GetChainStorage().GetBlock(w.GroupId, blockId, false, d.nodename)
+		if err != nil {
+			appsynclog.Errorf("<%s> webhook %s get block %d failed: %s", w.GroupId, w.Id, blockId, err)
+			return
+		}
+
+		payload := &WebhookPayload{WebhookId: w.Id, GroupId: w.GroupId, Event: WebhookEventBlock, BlockId: blockId, Trxs: []*quorumpb.Trx{}}
+		for _, trx := range block.Trxs {
+			if !w.Match(trx) {
+				continue
+			}
+			trx = proto.Clone(trx).(*quorumpb.Trx)
+			if trx.Type == quorumpb.TrxType_POST {
+				if err := DecryptPostTrx(group.Item, trx); err != nil {
+					trx.Data = nil
+				}
+			}
+			payload.Trxs = append(payload.Trxs, trx)
+		}
+
+		if len(payload.Trxs) > 0 {
+			if err := d.deliver(w, payload); err != nil {
+				d.retryLater(w, state, fmt.Sprintf("block %d", blockId), err)
+				return
+			}
+		}
+
+		state.failures = 0
+		w.LastBlock = blockId
+		if err := d.updCursor(w); err != nil {
+			appsynclog.Errorf("<%s> webhook %s save cursor failed: %s", w.GroupId, w.Id, err)
+			return
+		}
+		if !d.dispatchCaughtUp(w, state, caughtUp) {
+			return
+		}
+	}
+}
+
+// dispatchCaughtUp delivers the caught up event once the blocks up to the caught up block are delivered,
+// it returns false if the delivery failed
+func (d *WebhookDispatcher) dispatchCaughtUp(w *Webhook, state *webhookState, caughtUp *CaughtUpEvent) bool {
+	if w.CaughtUpSent || caughtUp == nil || w.LastBlock < caughtUp.BlockId {
+		return true
+	}
+
+	payload := &WebhookPayload{WebhookId: w.Id, GroupId: w.GroupId, Event: WebhookEventCaughtUp, BlockId: caughtUp.BlockId, Trxs: []*quorumpb.Trx{}}
+	if err := d.deliver(w, payload); err != nil {
+		d.retryLater(w, state, "caught up event", err)
+		return false
+	}
+
+	state.failures = 0
+	w.CaughtUpSent = true
+	if err := d.updCursor(w); err != nil {
+		appsynclog.Errorf("<%s> webhook %s save cursor failed: %s", w.GroupId, w.Id, err)
+		return false
+	}
+	return true
+}
+
+// retryLater backs off the webhook exponentially after a failed delivery
+func (d *WebhookDispatcher) retryLater(w *Webhook, state *webhookState, what string, err error) {
+	state.failures++
+	backoff := webhookMinBackoff << (state.failures - 1)
+	if backoff > webhookMaxBackoff || backoff <= 0 {
+		backoff = webhookMaxBackoff
+	}
+	state.nextAttempt = time.Now().Add(backoff)
+	appsynclog.Warningf("<%s> webhook %s deliver %s failed: %s, retry in %s", w.GroupId, w.Id, what, err, backoff)
+}
+
+// updCursor saves LastBlock and CaughtUpSent unless the webhook was removed meanwhile
+func (d *WebhookDispatcher) updCursor(w *Webhook) error {
+	cur, err := d.appdb.GetWebhook(w.GroupId, w.Id)
+	if err != nil || cur == nil {
+		return err
+	}
+	cur.LastBlock = w.LastBlock
+	cur.CaughtUpSent = w.CaughtUpSent
+	return d.appdb.SetWebhook(cur)
+}
+
+func (d *WebhookDispatcher) getState(id string) *webhookState {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	state, ok := d.state[id]
+	if !ok {
+		state = &webhookState{}
+		d.state[id] = state
+	}
+	return state
+}
+
+func (d *WebhookDispatcher) deliver(w *Webhook, payload *WebhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, w.Url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookIdHeader, w.Id)
+	delivery := fmt.Sprintf("%s:%d", w.GroupId, payload.BlockId)
+	if payload.Event != WebhookEventBlock {
+		delivery += ":" + payload.Event
+	}
+	req.Header.Set(WebhookDeliveryHeader, delivery)
+	if w.Secret != "" {
+		req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhookPayload(w.Secret, body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
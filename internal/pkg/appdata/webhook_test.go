@@ -0,0 +1,91 @@
+package appdata
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rumsystem/quorum/internal/pkg/storage"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+func TestWebhookStore(t *testing.T) {
+	db, err := storage.NewStore(context.Background(), t.TempDir(), "appdb")
+	if err != nil {
+		t.Fatalf("create store failed: %s", err)
+	}
+	defer db.Close()
+	appdb := NewAppDb()
+	appdb.Db = db
+
+	groupId := "5ed3f9fe-81e2-450d-9146-7a329aac2b62"
+	w := &Webhook{Id: "22d5c38d-5921-4b75-8562-c110dcfd5ee8", GroupId: groupId, Url: "http://127.0.0.1/hook", LastBlock: 10}
+	if err := appdb.SetWebhook(w); err != nil {
+		t.Fatalf("SetWebhook failed: %s", err)
+	}
+	other := &Webhook{Id: "c8e1b7f2-2a4d-4b3c-9e1f-7d6a5b4c3d2e", GroupId: "ac0eea7c-2f3c-4c67-80b3-136e46b924a8", Url: "http://127.0.0.1/hook"}
+	if err := appdb.SetWebhook(other); err != nil {
+		t.Fatalf("SetWebhook failed: %s", err)
+	}
+
+	got, err := appdb.GetWebhook(groupId, w.Id)
+	if err != nil || got == nil || got.LastBlock != 10 {
+		t.Fatalf("GetWebhook want %+v, got %+v err: %v", w, got, err)
+	}
+	if all, _ := appdb.GetWebhooks(""); len(all) != 2 {
+		t.Errorf("want 2 webhooks, got %d", len(all))
+	}
+	if err := appdb.DelGroupWebhooks(groupId); err != nil {
+		t.Fatalf("DelGroupWebhooks failed: %s", err)
+	}
+	if list, _ := appdb.GetWebhooks(groupId); len(list) != 0 {
+		t.Errorf("webhooks of group should be deleted, got %d", len(list))
+	}
+	if all, _ := appdb.GetWebhooks(""); len(all) != 1 {
+		t.Errorf("webhooks of other groups should be kept, got %d", len(all))
+	}
+}
+
+func TestWebhookMatch(t *testing.T) {
+	post := &quorumpb.Trx{Type: quorumpb.TrxType_POST}
+	announce := &quorumpb.Trx{Type: quorumpb.TrxType_ANNOUNCE}
+
+	all := &Webhook{}
+	if !all.Match(post) || !all.Match(announce) {
+		t.Errorf("webhook without trx types should match all trx")
+	}
+	onlyPost := &Webhook{TrxTypes: []string{"POST"}}
+	if !onlyPost.Match(post) || onlyPost.Match(announce) {
+		t.Errorf("webhook should only match POST trx")
+	}
+}
+
+func TestWebhookDeliver(t *testing.T) {
+	status := http.StatusOK
+	var gotSig string
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSig = r.Header.Get(WebhookSignatureHeader)
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	d := NewWebhookDispatcher(nil, "")
+	w := &Webhook{Id: "22d5c38d-5921-4b75-8562-c110dcfd5ee8", GroupId: "5ed3f9fe-81e2-450d-9146-7a329aac2b62", Url: srv.URL, Secret: "s3cret"}
+	payload := &WebhookPayload{WebhookId: w.Id, GroupId: w.GroupId, BlockId: 1}
+
+	if err := d.deliver(w, payload); err != nil {
+		t.Fatalf("deliver failed: %s", err)
+	}
+	if want := "sha256=" + SignWebhookPayload(w.Secret, gotBody); gotSig != want {
+		t.Errorf("want signature %s, got %s", want, gotSig)
+	}
+
+	status = http.StatusInternalServerError
+	if err := d.deliver(w, payload); err == nil {
+		t.Errorf("deliver should fail on non-2xx response")
+	}
+}
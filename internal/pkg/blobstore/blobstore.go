@@ -0,0 +1,140 @@
+package blobstore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+)
+
+/*
+A blob store keeps the bytes of the content too large for the chain, e.g. images and videos, by the sha256 of the bytes.
+The trx of the content carries only the hash, see BlobRef, the readers fetch the bytes from the blob store and verify them
+against the hash. The backends are
+
+	fs    the files under a local dir, shared with the other nodes by the operator if needed
+	ipfs  the raw blocks of an ipfs node by its http rpc api, fetched from the ipfs network by the other nodes
+*/
+const (
+	BackendFS   = "fs"
+	BackendIPFS = "ipfs"
+)
+
+const DefaultBlobDir = "blobs"
+
+var (
+	ErrBlobNotFound  = errors.New("blob not found")
+	ErrHashMismatch  = errors.New("blob does not match the hash")
+	ErrInvalidHash   = errors.New("invalid blob hash, should be the sha256 hex")
+	ErrNotConfigured = errors.New("blob store is not configured, set blobstore to fs or ipfs")
+)
+
+type BlobStore interface {
+	// Put saves data and returns its hash, it is a noop if the blob exists
+	Put(data []byte) (string, error)
+	// Get returns the data of the hash verified, ErrBlobNotFound if it does not exist
+	Get(hash string) ([]byte, error)
+	Exists(hash string) (bool, error)
+}
+
+var store BlobStore
+
+// Init opens the blob store of the backend, an empty backend leaves the blob store disabled.
+// dir is the dir of the fs backend, <root>/blobs if empty, ipfsApi is the rpc api of the ipfs backend.
+func Init(backend string, root string, dir string, ipfsApi string) error {
+	switch backend {
+	case "":
+		store = nil
+		return nil
+	case BackendFS:
+		if dir == "" {
+			dir = filepath.Join(root, DefaultBlobDir)
+		}
+		s, err := NewFSStore(dir)
+		if err != nil {
+			return err
+		}
+		store = s
+	case BackendIPFS:
+		s, err := NewIPFSStore(ipfsApi)
+		if err != nil {
+			return err
+		}
+		store = s
+	default:
+		return fmt.Errorf("unknown blob store %s, should be %s or %s", backend, BackendFS, BackendIPFS)
+	}
+	return nil
+}
+
+// GetStore returns ErrNotConfigured if no blob store is set
+func GetStore() (BlobStore, error) {
+	if store == nil {
+		return nil, ErrNotConfigured
+	}
+	return store, nil
+}
+
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ValidHash reports whether hash is a lowercase sha256 hex
+func ValidHash(hash string) bool {
+	if len(hash) != 2*sha256.Size {
+		return false
+	}
+	for _, c := range hash {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// Verify returns ErrHashMismatch if data is not the blob of hash
+func Verify(hash string, data []byte) error {
+	if Hash(data) != hash {
+		return fmt.Errorf("%w %s", ErrHashMismatch, hash)
+	}
+	return nil
+}
+
+// BlobType is the content type of a post referring to a blob, the post is
+//
+//	{"type":"Blob","object":{"hash":"<sha256 hex>","size":1024,"mediaType":"image/png","name":"cat.png"}}
+//
+// The blob of a private group is sealed by the group key, the hash is of the sealed bytes in the blob store
+const BlobType = "Blob"
+
+type BlobRef struct {
+	Hash      string `json:"hash"`
+	Size      int    `json:"size"` // bytes of the media, before it is sealed
+	MediaType string `json:"mediaType,omitempty"`
+	Name      string `json:"name,omitempty"`
+}
+
+// NewBlobPost returns the data of a post referring to the blob
+func NewBlobPost(ref *BlobRef) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{"type": BlobType, "object": ref})
+}
+
+type blobPost struct {
+	Type   string   `json:"type"`
+	Object *BlobRef `json:"object"`
+}
+
+// ParseBlobPost returns the blob referred to by the decrypted data of a post, nil if it is not a valid Blob post
+func ParseBlobPost(data []byte) *BlobRef {
+	var post blobPost
+	if err := json.Unmarshal(data, &post); err != nil || post.Type != BlobType || post.Object == nil {
+		return nil
+	}
+	if !ValidHash(post.Object.Hash) || post.Object.Size < 0 {
+		return nil
+	}
+	return post.Object
+}
@@ -0,0 +1,96 @@
+package blobstore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFSStore(t *testing.T) {
+	s, err := NewFSStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("hello world")
+	hash, err := s.Put(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9" {
+		t.Fatalf("want the sha256 hex, got %s", hash)
+	}
+	if exist, err := s.Exists(hash); err != nil || !exist {
+		t.Fatalf("want the blob exists, got %v %v", exist, err)
+	}
+	if got, err := s.Get(hash); err != nil || string(got) != string(data) {
+		t.Fatalf("want %s, got %s %v", data, got, err)
+	}
+	if _, err := s.Put(data); err != nil {
+		t.Errorf("put an existing blob again failed: %s", err)
+	}
+
+	missing := Hash([]byte("missing"))
+	if exist, err := s.Exists(missing); err != nil || exist {
+		t.Errorf("want the blob not exists, got %v %v", exist, err)
+	}
+	if _, err := s.Get(missing); !errors.Is(err, ErrBlobNotFound) {
+		t.Errorf("want ErrBlobNotFound, got %v", err)
+	}
+	if _, err := s.Get("../../etc/passwd"); !errors.Is(err, ErrInvalidHash) {
+		t.Errorf("want ErrInvalidHash, got %v", err)
+	}
+
+	// a corrupted blob file is not returned
+	if err := os.WriteFile(filepath.Join(s.dir, hash[:2], hash), []byte("hello quorum"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(hash); !errors.Is(err, ErrHashMismatch) {
+		t.Errorf("want ErrHashMismatch, got %v", err)
+	}
+}
+
+func TestInit(t *testing.T) {
+	defer Init("", "", "", "")
+
+	if err := Init("", "", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetStore(); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("want ErrNotConfigured, got %v", err)
+	}
+	root := t.TempDir()
+	if err := Init(BackendFS, root, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if s, err := GetStore(); err != nil || s.(*FSStore).dir != filepath.Join(root, DefaultBlobDir) {
+		t.Errorf("want the fs store in the root, got %+v %v", s, err)
+	}
+	if err := Init("s3", root, "", ""); err == nil {
+		t.Errorf("unknown backend should be rejected")
+	}
+	if err := Init(BackendIPFS, root, "", "localhost:5001"); err == nil {
+		t.Errorf("ipfs api without scheme should be rejected")
+	}
+}
+
+func TestParseBlobPost(t *testing.T) {
+	ref := &BlobRef{Hash: Hash([]byte("hello world")), Size: 11, MediaType: "text/plain", Name: "hello.txt"}
+	post, err := NewBlobPost(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ParseBlobPost(post); got == nil || *got != *ref {
+		t.Errorf("want %+v, got %+v", ref, got)
+	}
+	for _, data := range []string{
+		`{"type":"Note","object":{"hash":"b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"}}`,
+		`{"type":"Blob","object":{"hash":"../../etc/passwd","size":11}}`,
+		`{"type":"Blob"}`,
+		`not json`,
+	} {
+		if got := ParseBlobPost([]byte(data)); got != nil {
+			t.Errorf("%s is not a blob post, got %+v", data, got)
+		}
+	}
+}
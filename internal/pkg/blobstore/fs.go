@@ -0,0 +1,81 @@
+package blobstore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// FSStore keeps a blob in the file <dir>/<hash[:2]>/<hash>
+type FSStore struct {
+	dir string
+}
+
+func NewFSStore(dir string) (*FSStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &FSStore{dir: dir}, nil
+}
+
+func (s *FSStore) path(hash string) string {
+	return filepath.Join(s.dir, hash[:2], hash)
+}
+
+// Put writes the blob to a temp file renamed to the blob file, a blob file is never partially written
+func (s *FSStore) Put(data []byte) (string, error) {
+	hash := Hash(data)
+	exist, err := s.Exists(hash)
+	if err != nil || exist {
+		return hash, err
+	}
+
+	p := s.path(hash)
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), hash+".tmp*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+func (s *FSStore) Get(hash string) ([]byte, error) {
+	if !ValidHash(hash) {
+		return nil, ErrInvalidHash
+	}
+	data, err := os.ReadFile(s.path(hash))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrBlobNotFound, hash)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := Verify(hash, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *FSStore) Exists(hash string) (bool, error) {
+	if !ValidHash(hash) {
+		return false, ErrInvalidHash
+	}
+	_, err := os.Stat(s.path(hash))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,156 @@
+package blobstore
+
+import (
+	"bytes"
+	"encoding/base32"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const DefaultIPFSAPI = "http://127.0.0.1:5001"
+
+const (
+	ipfsTimeout     = 2 * time.Minute
+	ipfsStatTimeout = 10 * time.Second
+)
+
+// IPFSStore keeps a blob as one raw block of an ipfs node by its http rpc api. The cid of a raw block is derived from
+// the sha256 of the bytes, so the other nodes find the blob in the ipfs network by the hash in the trx.
+// The blocks over 1MiB are put with allow-big-block, they are not exchanged by bitswap but served by the node and the gateways.
+type IPFSStore struct {
+	api    string
+	client *http.Client
+}
+
+func NewIPFSStore(api string) (*IPFSStore, error) {
+	if api == "" {
+		api = DefaultIPFSAPI
+	}
+	u, err := url.Parse(api)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid ipfs api %s, should be like %s", api, DefaultIPFSAPI)
+	}
+	return &IPFSStore{api: strings.TrimSuffix(api, "/"), client: &http.Client{Timeout: ipfsTimeout}}, nil
+}
+
+// CID returns the cid v1 of the raw block of the sha256 hash, in base32
+func CID(hash string) (string, error) {
+	if !ValidHash(hash) {
+		return "", ErrInvalidHash
+	}
+	digest, _ := hex.DecodeString(hash)
+	// version 1, codec raw, multihash sha2-256 of 32 bytes
+	b := append([]byte{0x01, 0x55, 0x12, 0x20}, digest...)
+	return "b" + strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b)), nil
+}
+
+type ipfsError struct {
+	Message string
+}
+
+func (e *ipfsError) Error() string {
+	return "ipfs: " + e.Message
+}
+
+// call posts to the rpc command, a non 200 response is returned as an *ipfsError
+func (s *IPFSStore) call(cmd string, query url.Values, body io.Reader, contentType string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodPost, s.api+"/api/v0/"+cmd+"?"+query.Encode(), body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		e := &ipfsError{}
+		if json.Unmarshal(data, e) != nil || e.Message == "" {
+			e.Message = resp.Status
+		}
+		return nil, e
+	}
+	return data, nil
+}
+
+func (s *IPFSStore) Put(data []byte) (string, error) {
+	hash := Hash(data)
+	cid, _ := CID(hash)
+
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	part, err := w.CreateFormFile("file", hash)
+	if err != nil {
+		return "", err
+	}
+	if _, err := part.Write(data); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	query := url.Values{"cid-codec": {"raw"}, "mhtype": {"sha2-256"}, "pin": {"true"}, "allow-big-block": {"true"}}
+	res, err := s.call("block/put", query, &buf, w.FormDataContentType())
+	if err != nil {
+		return "", err
+	}
+	var put struct {
+		Key string
+	}
+	if err := json.Unmarshal(res, &put); err != nil {
+		return "", err
+	}
+	if put.Key != cid {
+		return "", fmt.Errorf("ipfs put blob %s as %s, want %s", hash, put.Key, cid)
+	}
+	return hash, nil
+}
+
+func (s *IPFSStore) Get(hash string) ([]byte, error) {
+	cid, err := CID(hash)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.call("block/get", url.Values{"arg": {cid}}, nil, "")
+	var e *ipfsError
+	if errors.As(err, &e) {
+		return nil, fmt.Errorf("%w: %s, %s", ErrBlobNotFound, hash, e.Message)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := Verify(hash, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Exists looks for the block in the ipfs network for ipfsStatTimeout, the block not found in time does not exist
+func (s *IPFSStore) Exists(hash string) (bool, error) {
+	cid, err := CID(hash)
+	if err != nil {
+		return false, err
+	}
+	_, err = s.call("block/stat", url.Values{"arg": {cid}, "timeout": {ipfsStatTimeout.String()}}, nil, "")
+	var e *ipfsError
+	if errors.As(err, &e) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,91 @@
+package blobstore
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeIPFS serves block/put, block/get and block/stat of the raw blocks
+func fakeIPFS(t *testing.T) *httptest.Server {
+	blocks := map[string][]byte{}
+	fail := func(w http.ResponseWriter, msg string) {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{"Message": msg, "Code": 0, "Type": "error"})
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v0/block/put":
+			if r.URL.Query().Get("cid-codec") != "raw" || r.URL.Query().Get("mhtype") != "sha2-256" {
+				fail(w, "unexpected options")
+				return
+			}
+			f, _, err := r.FormFile("file")
+			if err != nil {
+				fail(w, err.Error())
+				return
+			}
+			data, _ := io.ReadAll(f)
+			cid, _ := CID(Hash(data))
+			blocks[cid] = data
+			json.NewEncoder(w).Encode(map[string]interface{}{"Key": cid, "Size": len(data)})
+		case "/api/v0/block/get", "/api/v0/block/stat":
+			data, ok := blocks[r.URL.Query().Get("arg")]
+			if !ok {
+				fail(w, "block was not found locally (offline)")
+				return
+			}
+			w.Write(data)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestCID(t *testing.T) {
+	cid, err := CID(Hash([]byte("hello world")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cid != "bafkreifzjut3te2nhyekklss27nh3k72ysco7y32koao5eei66wof36n5e" {
+		t.Errorf("unexpected cid %s", cid)
+	}
+	if _, err := CID("abc"); !errors.Is(err, ErrInvalidHash) {
+		t.Errorf("want ErrInvalidHash, got %v", err)
+	}
+}
+
+func TestIPFSStore(t *testing.T) {
+	srv := fakeIPFS(t)
+	defer srv.Close()
+	s, err := NewIPFSStore(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []byte("hello quorum")
+	hash, err := s.Put(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != Hash(data) {
+		t.Fatalf("want hash %s, got %s", Hash(data), hash)
+	}
+	if exist, err := s.Exists(hash); err != nil || !exist {
+		t.Fatalf("want the blob exists, got %v %v", exist, err)
+	}
+	if got, err := s.Get(hash); err != nil || string(got) != string(data) {
+		t.Fatalf("want %s, got %s %v", data, got, err)
+	}
+
+	missing := Hash([]byte("missing"))
+	if exist, err := s.Exists(missing); err != nil || exist {
+		t.Errorf("want the blob not exists, got %v %v", exist, err)
+	}
+	if _, err := s.Get(missing); !errors.Is(err, ErrBlobNotFound) {
+		t.Errorf("want ErrBlobNotFound, got %v", err)
+	}
+}
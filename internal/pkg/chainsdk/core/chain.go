@@ -2,17 +2,21 @@ package chain
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"runtime"
 	"sync/atomic"
 	"time"
 
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/rumsystem/quorum/internal/pkg/conn"
 	"github.com/rumsystem/quorum/internal/pkg/logging"
+	"github.com/rumsystem/quorum/internal/pkg/metric"
 	"github.com/rumsystem/quorum/internal/pkg/nodectx"
+	"github.com/rumsystem/quorum/internal/pkg/stats"
 	"github.com/rumsystem/quorum/internal/pkg/utils"
 	"github.com/rumsystem/quorum/pkg/consensus"
 	"github.com/rumsystem/quorum/pkg/consensus/def"
@@ -34,6 +38,9 @@ type Chain struct {
 	userPool     map[string]*quorumpb.UserItem
 	trxFactory   *rumchaindata.TrxFactory
 	rexSyncer    *RexSyncer
+	syncProgress *syncProgress
+	pubQueue     *pubQueue // trxs sent by this node and not applied yet
+	confirms     *confirmTracker
 	chaindata    *ChainData
 	Consensus    def.Consensus
 	CurrBlock    uint64
@@ -53,6 +60,9 @@ func (chain *Chain) NewChain(item *quorumpb.GroupItem, nodename string, loadChai
 
 	//initial Syncer
 	chain.rexSyncer = NewRexSyncer(chain.groupItem.GroupId, chain.nodename, chain, chain)
+	chain.syncProgress = &syncProgress{}
+	chain.pubQueue = newPubQueue(&chainPublishStore{groupId: item.GroupId, nodename: nodename})
+	chain.confirms = newConfirmTracker()
 
 	//initial chaindata manager
 	chain.chaindata = &ChainData{
@@ -71,6 +81,9 @@ func (chain *Chain) NewChain(item *quorumpb.GroupItem, nodename string, loadChai
 		chain.SetCurrEpoch(currEpoch)
 		chain.SetLastUpdate(lastUpdate)
 		chain.SetCurrBlockId(currBlockId)
+		if err := chain.loadPublishQueue(); err != nil {
+			return err
+		}
 	} else {
 		currEpoch := uint64(0)
 		currBlock := uint64(0)
@@ -151,6 +164,7 @@ func (chain *Chain) HandlePsConnMessage(pkg *quorumpb.Package) error {
 		if err != nil {
 			chain_log.Warningf(err.Error())
 		} else {
+			metric.TrxTotal.WithLabelValues(chain.groupItem.GroupId, metric.TrxDirection.Received).Inc()
 			err = chain.HandleTrxPsConn(trx)
 		}
 	} else if pkg.Type == quorumpb.PackageType_HBB {
@@ -201,15 +215,39 @@ func (chain *Chain) HandleTrxPsConn(trx *quorumpb.Trx) error {
 		return fmt.Errorf("invalid trx, signature verify failed")
 	}
 
+	if err := rumchaindata.CheckTrxExpiry(trx, time.Now()); err != nil {
+		chain_log.Warningf("<%s> reject trx <%s>: %s", chain.groupItem.GroupId, trx.TrxId, err)
+		return err
+	}
+
 	switch trx.Type {
 	case
 		quorumpb.TrxType_POST,
 		quorumpb.TrxType_ANNOUNCE,
 		quorumpb.TrxType_PRODUCER,
 		quorumpb.TrxType_USER,
-		quorumpb.TrxType_APP_CONFIG,
 		quorumpb.TrxType_CHAIN_CONFIG:
 		chain.producerAddTrx(trx)
+	case quorumpb.TrxType_APP_CONFIG:
+		data, err := chain.decryptTrxData(trx)
+		if err == nil {
+			err = chain.checkGroupKeyConfig(trx, data)
+		}
+		if err != nil {
+			chain_log.Warningf("<%s> reject APP_CONFIG trx <%s>: %s", chain.groupItem.GroupId, trx.TrxId, err)
+			return err
+		}
+		chain.producerAddTrx(trx)
+	case quorumpb.TrxType_TOMBSTONE:
+		trxId, err := chain.decodeTombstone(trx)
+		if err == nil {
+			err = chain.checkTombstone(trx, trxId, chain.nodename)
+		}
+		if err != nil {
+			chain_log.Warningf("<%s> reject TOMBSTONE trx <%s>: %s", chain.groupItem.GroupId, trx.TrxId, err)
+			return err
+		}
+		chain.producerAddTrx(trx)
 	default:
 		chain_log.Warningf("<%s> unsupported msg type", chain.groupItem.GroupId)
 		err := errors.New("unsupported msg type")
@@ -244,6 +282,10 @@ func (chain *Chain) HandleBlockPsConn(block *quorumpb.Block) error {
 		chain_log.Warningf("<%s> received block <%d> from unknown producer, reject it", chain.groupItem.GroupId, block.Epoch, block.ProducerPubkey)
 		return nil
 	}
+	chain.syncProgress.SeenBlock(block.BlockId)
+	defer func() {
+		chain.syncProgress.Applied(chain.GetCurrBlockId(), time.Now())
+	}()
 
 	if nodectx.GetNodeCtx().NodeType == nodectx.PRODUCER_NODE {
 		chain_log.Debugf("<%s> producer node add block", chain.groupItem.GroupId)
@@ -414,9 +456,20 @@ func (chain *Chain) handleReqBlockResp(trx *quorumpb.Trx) {
 }
 
 func (chain *Chain) ApplyBlocks(blocks []*quorumpb.Block) error {
+	for _, block := range blocks {
+		chain.syncProgress.SeenBlock(block.BlockId)
+	}
+	defer func() {
+		chain.syncProgress.Applied(chain.GetCurrBlockId(), time.Now())
+	}()
+
+	//verify the signs of the blocks in parallel ahead of adding them in order
+	verified := rumchaindata.VerifyBlocksAhead(blocks, runtime.NumCPU())
+
 	//PRODUCER_NODE add SYNC
 	if nodectx.GetNodeCtx().NodeType == nodectx.PRODUCER_NODE {
-		for _, block := range blocks {
+		for i, block := range blocks {
+			<-verified[i]
 			err := chain.Consensus.Producer().AddBlock(block)
 			if err != nil {
 				chain_log.Warningf("<%s> ApplyBlocks error <%s>", chain.groupItem.GroupId, err.Error())
@@ -428,7 +481,8 @@ func (chain *Chain) ApplyBlocks(blocks []*quorumpb.Block) error {
 	}
 
 	//FULLNODE (include owner) Add synced Block
-	for _, block := range blocks {
+	for i, block := range blocks {
+		<-verified[i]
 		err := chain.Consensus.User().AddBlock(block)
 		if err != nil {
 			chain_log.Warningf("<%s> ApplyBlocks error <%s>", chain.groupItem.GroupId, err.Error())
@@ -547,11 +601,9 @@ func (chain *Chain) GetUsesEncryptPubKeys() ([]string, error) {
 	return keys, nil
 }
 
-func (chain *Chain) CreateConsensus() error {
-	chain_log.Debugf("<%s> CreateConsensus called", chain.groupItem.GroupId)
-
-	var user def.User
-	var producer def.Producer
+// CreateConsensus creates the consensus implementation registered by the name
+func (chain *Chain) CreateConsensus(name string) error {
+	chain_log.Debugf("<%s> CreateConsensus <%s> called", chain.groupItem.GroupId, name)
 
 	var shouldCreateUser, shouldCreateProducer bool
 
@@ -570,20 +622,11 @@ func (chain *Chain) CreateConsensus() error {
 		return fmt.Errorf("unknow nodetype")
 	}
 
-	if shouldCreateProducer {
-		chain_log.Infof("<%s> Create and initial molasses producer", chain.groupItem.GroupId)
-		producer = &consensus.MolassesProducer{}
-		producer.NewProducer(chain.groupItem, chain.nodename, chain)
-		producer.StartPropose()
-	}
-
-	if shouldCreateUser {
-		chain_log.Infof("<%s> Create and initial molasses user", chain.groupItem.GroupId)
-		user = &consensus.MolassesUser{}
-		user.NewUser(chain.groupItem, chain.nodename, chain)
+	cons, err := consensus.New(name, chain.groupItem, chain.nodename, chain, shouldCreateProducer, shouldCreateUser)
+	if err != nil {
+		return err
 	}
-
-	chain.Consensus = consensus.NewMolasses(producer, user)
+	chain.Consensus = cons
 	return nil
 }
 
@@ -617,19 +660,241 @@ func (chain *Chain) GetRexSyncerStatus() string {
 		statusStr = "SYNCING"
 	case CLOSED:
 		statusStr = "CLOSED"
+	case PAUSED:
+		statusStr = "PAUSED"
 	default:
 
 	}
 	return statusStr
 }
 
+// GetPendingTrxCount returns the number of trxs sent by this node and not applied yet
+func (chain *Chain) GetPendingTrxCount() int {
+	return chain.pubQueue.count()
+}
+
+// GetPublishQueueDepth returns the number of the trxs in the publish queue per state
+func (chain *Chain) GetPublishQueueDepth() map[string]int {
+	return chain.pubQueue.depth()
+}
+
+func (chain *Chain) addPendingTrx(trx *quorumpb.Trx, policy *PublishRetryPolicy) {
+	chain.pubQueue.add(trx, policy, time.Now())
+}
+
+// chainPublishStore keeps the publish queue of the group in db, the sync queue also counts the saved
+// entries to prioritize the group after a restart
+type chainPublishStore struct {
+	groupId  string
+	nodename string
+}
+
+func (s *chainPublishStore) save(entry *PublishEntry) error {
+	data, err := encodePublishEntry(entry)
+	if err != nil {
+		return err
+	}
+	return nodectx.GetNodeCtx().GetChainStorage().SetPublishEntry(s.groupId, entry.Trx.TrxId, data, s.nodename)
+}
+
+func (s *chainPublishStore) remove(trxId string) error {
+	return nodectx.GetNodeCtx().GetChainStorage().RmPublishEntry(s.groupId, trxId, s.nodename)
+}
+
+func (s *chainPublishStore) saveKey(key string, record *idempotencyRecord) error {
+	data, err := encodeIdempotencyRecord(record)
+	if err != nil {
+		return err
+	}
+	return nodectx.GetNodeCtx().GetChainStorage().SetIdempotencyKey(s.groupId, key, data, s.nodename)
+}
+
+func (s *chainPublishStore) removeKey(key string) error {
+	return nodectx.GetNodeCtx().GetChainStorage().RmIdempotencyKey(s.groupId, key, s.nodename)
+}
+
+// loadPublishQueue replays the saved publish queue and idempotency keys, the trxs not applied before the restart
+// are resent when due
+func (chain *Chain) loadPublishQueue() error {
+	items, err := nodectx.GetNodeCtx().GetChainStorage().GetPublishEntries(chain.groupItem.GroupId, chain.nodename)
+	if err != nil {
+		return err
+	}
+	entries := make([]*PublishEntry, 0, len(items))
+	for _, item := range items {
+		entry, err := decodePublishEntry(item)
+		if err != nil {
+			chain_log.Warningf("<%s> skip invalid publish entry: %s", chain.groupItem.GroupId, err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	chain.pubQueue.load(entries)
+	chain_log.Debugf("<%s> <%d> trxs loaded to publish queue", chain.groupItem.GroupId, len(entries))
+
+	keyItems, err := nodectx.GetNodeCtx().GetChainStorage().GetIdempotencyKeys(chain.groupItem.GroupId, chain.nodename)
+	if err != nil {
+		return err
+	}
+	records := make(map[string]*idempotencyRecord, len(keyItems))
+	for key, item := range keyItems {
+		record, err := decodeIdempotencyRecord(item)
+		if err != nil {
+			chain_log.Warningf("<%s> skip invalid idempotency key <%s>: %s", chain.groupItem.GroupId, key, err)
+			continue
+		}
+		records[key] = record
+	}
+	chain.pubQueue.loadKeys(records, time.Now())
+	return nil
+}
+
+// PublishOnce publishes by publish once per idempotency key in the key window, a retry with the same key
+// and payload hash returns the trx id of the first publish and true, a retry with another payload hash
+// gets ErrIdempotencyKeyReused. An empty key publishes as is.
+func (chain *Chain) PublishOnce(key, payloadHash string, publish func() (string, error)) (string, bool, error) {
+	if key == "" {
+		trxId, err := publish()
+		return trxId, false, err
+	}
+	return chain.pubQueue.publishOnce(key, payloadHash, time.Now(), publish)
+}
+
+// OnBlockAdded records the blocks including the trxs sent by this node and indexes the trxs of the block,
+// it is called before the trxs of the block are applied
+func (chain *Chain) OnBlockAdded(block *quorumpb.Block) {
+	chain.confirms.blockAdded(block, chain.pubQueue.has, time.Now())
+	if err := nodectx.GetNodeCtx().GetChainStorage().AddTrxBlock(block, chain.nodename); err != nil {
+		chain_log.Warningf("<%s> index trxs of block <%d> failed: %s", chain.groupItem.GroupId, block.BlockId, err)
+	}
+	stats.Inc(stats.BlockApplied, chain.groupItem.GroupId, 1)
+}
+
+// GetTrxConfirmation returns the block including a trx sent by this node recently, nil if it is not included yet
+func (chain *Chain) GetTrxConfirmation(trxId string) *TrxConfirmation {
+	return chain.confirms.get(trxId)
+}
+
+// GetTrxBlock returns the block including a trx from the chain storage, nil if the trx is not indexed
+func (chain *Chain) GetTrxBlock(trxId string) (*TrxConfirmation, error) {
+	storage := nodectx.GetNodeCtx().GetChainStorage()
+	blockId, ok, err := storage.GetTrxBlock(chain.groupItem.GroupId, trxId, chain.nodename)
+	if err != nil || !ok {
+		return nil, err
+	}
+	conf := &TrxConfirmation{BlockId: blockId}
+	if head := chain.GetCurrBlockId(); head >= blockId {
+		conf.Depth = head - blockId + 1
+	}
+	// the block may be pruned, its epoch is unknown then
+	if block, err := storage.GetBlock(chain.groupItem.GroupId, blockId, false, chain.nodename); err == nil && block != nil {
+		conf.Epoch = block.Epoch
+	}
+	return conf, nil
+}
+
+// WaitTrxConfirmed waits until a trx sent by this node is included in a block and depth-1 blocks are added on top of it,
+// it returns the last known confirmation, nil if not included, with the ctx error if ctx is done first
+func (chain *Chain) WaitTrxConfirmed(ctx context.Context, trxId string, depth uint64) (*TrxConfirmation, error) {
+	return chain.confirms.wait(ctx, trxId, depth)
+}
+
+// GetPublishQueue returns the trxs sent by this node and not applied yet in the given state, empty for all states
+func (chain *Chain) GetPublishQueue(state string) []*PublishEntry {
+	return chain.pubQueue.list(state)
+}
+
+// GetPublishEntry returns the trx in the publish queue, nil if it is not queued
+func (chain *Chain) GetPublishEntry(trxId string) *PublishEntry {
+	return chain.pubQueue.get(trxId)
+}
+
+// GetPublishInFlight returns the number of the queued trxs being resent
+func (chain *Chain) GetPublishInFlight() int {
+	return chain.pubQueue.inFlight()
+}
+
+// RequeueTrx moves a dead-letter trx back to the publish queue, it is resent immediately
+func (chain *Chain) RequeueTrx(trxId string) error {
+	if !chain.pubQueue.requeue(trxId, time.Now()) {
+		return fmt.Errorf("trx %s is not in dead-letter", trxId)
+	}
+	return nil
+}
+
+// RetryTrxNow resends a trx in the publish queue immediately, ignoring its backoff
+func (chain *Chain) RetryTrxNow(trxId string) error {
+	trx, err := chain.pubQueue.claim(trxId)
+	if err != nil {
+		return err
+	}
+	return chain.resendTrxs([]*quorumpb.Trx{trx})
+}
+
+// CancelTrx removes a trx from the publish queue, it is not resent anymore
+func (chain *Chain) CancelTrx(trxId string) error {
+	if !chain.pubQueue.cancel(trxId) {
+		return fmt.Errorf("trx %s is not in publish queue", trxId)
+	}
+	return nil
+}
+
+// retryPublish resends the trxs which are not applied after their backoff
+func (chain *Chain) retryPublish() {
+	trxs := chain.pubQueue.due(time.Now())
+	if len(trxs) == 0 {
+		return
+	}
+	chain.resendTrxs(trxs)
+}
+
+// resendTrxs sends the claimed trxs and records the results, it returns the last send error
+func (chain *Chain) resendTrxs(trxs []*quorumpb.Trx) error {
+	connMgr, err := conn.GetConn().GetConnMgr(chain.groupItem.GroupId)
+	if err != nil {
+		chain_log.Warningf("<%s> get connMgr failed: %s", chain.groupItem.GroupId, err)
+		for _, trx := range trxs {
+			chain.pubQueue.resent(trx.TrxId, err, time.Now())
+		}
+		return err
+	}
+	var lastErr error
+	for _, trx := range trxs {
+		chain_log.Debugf("<%s> resend trx <%s>", chain.groupItem.GroupId, trx.TrxId)
+		err := connMgr.SendUserTrxPubsub(trx)
+		if err != nil {
+			lastErr = err
+		}
+		chain.pubQueue.resent(trx.TrxId, err, time.Now())
+	}
+	return lastErr
+}
+
+// GetSyncProgress returns the applied block id against the highest block seen from peers
+func (chain *Chain) GetSyncProgress() *chaindef.SyncProgress {
+	if chain.isOwner() {
+		//owner produces the blocks, it is always caught up
+		chain.syncProgress.SeenBlock(chain.GetCurrBlockId())
+	}
+	return chain.syncProgress.Get(chain.GetCurrBlockId(), time.Now())
+}
+
 func (chain *Chain) GetLastRexSyncResult() (*chaindef.RexSyncResult, error) {
 	return chain.rexSyncer.GetLastRexSyncResult()
 }
 
+// GetLastSyncTime returns the unix seconds of the last sync response, 0 if the group never got one
+func (chain *Chain) GetLastSyncTime() int64 {
+	if res := chain.rexSyncer.LastSyncResult; res != nil {
+		return res.LastSyncTaskTimestamp
+	}
+	return 0
+}
+
 func (chain *Chain) ApplyTrxsFullNode(trxs []*quorumpb.Trx, nodename string) error {
 	chain_log.Debugf("<%s> ApplyTrxsFullNode called", chain.groupItem.GroupId)
 	for _, trx := range trxs {
+		chain.pubQueue.remove(trx.TrxId)
 		//check if trx already applied
 		isExist, err := nodectx.GetNodeCtx().GetChainStorage().IsTrxExist(trx.GroupId, trx.TrxId, nodename)
 		if err != nil {
@@ -647,9 +912,8 @@ func (chain *Chain) ApplyTrxsFullNode(trxs []*quorumpb.Trx, nodename string) err
 
 		originalData := trx.Data
 		if trx.Type == quorumpb.TrxType_POST && chain.groupItem.EncryptType == quorumpb.GroupEncryptType_PRIVATE {
-			//for post, private Group, encrypted by pgp for all announced Group user
-			ks := localcrypto.GetKeystore()
-			decryptData, err := ks.Decrypt(chain.groupItem.GroupId, trx.Data)
+			//for post, private Group, sealed by the group key or encrypted by age for all announced Group user
+			decryptData, err := DecryptPrivatePost(chain.groupItem, trx.Data)
 			if err != nil {
 				//if decrypt error, set trxdata to empty []
 				trx.Data = []byte("")
@@ -690,10 +954,17 @@ func (chain *Chain) ApplyTrxsFullNode(trxs []*quorumpb.Trx, nodename string) err
 			nodectx.GetNodeCtx().GetChainStorage().UpdateAnnounce(trx.Data, nodename)
 		case quorumpb.TrxType_APP_CONFIG:
 			chain_log.Debugf("<%s> apply APP_CONFIG trx", chain.groupItem.GroupId)
-			nodectx.GetNodeCtx().GetChainStorage().UpdateAppConfigTrx(trx, nodename)
+			if err := chain.checkGroupKeyConfig(trx, trx.Data); err != nil {
+				chain_log.Warningf("<%s> skip APP_CONFIG trx <%s>: %s", chain.groupItem.GroupId, trx.TrxId, err)
+			} else {
+				nodectx.GetNodeCtx().GetChainStorage().UpdateAppConfigTrx(trx, nodename)
+			}
 		case quorumpb.TrxType_CHAIN_CONFIG:
 			chain_log.Debugf("<%s> apply CHAIN_CONFIG trx", chain.groupItem.GroupId)
 			nodectx.GetNodeCtx().GetChainStorage().UpdateChainConfigTrx(trx, nodename)
+		case quorumpb.TrxType_TOMBSTONE:
+			chain_log.Debugf("<%s> apply TOMBSTONE trx", chain.groupItem.GroupId)
+			chain.applyTombstone(trx, nodename)
 		default:
 			chain_log.Warningf("<%s> unsupported msgType <%s>", chain.groupItem.GroupId, trx.Type.String())
 		}
@@ -710,6 +981,13 @@ func (chain *Chain) ApplyTrxsFullNode(trxs []*quorumpb.Trx, nodename string) err
 func (chain *Chain) ApplyTrxsProducerNode(trxs []*quorumpb.Trx, nodename string) error {
 	chain_log.Debugf("<%s> ApplyTrxsProducerNode called", chain.groupItem.GroupId)
 	for _, trx := range trxs {
+		chain.pubQueue.remove(trx.TrxId)
+		//producer node keeps the author of POST only, to check the tombstones
+		if trx.Type == quorumpb.TrxType_POST {
+			if err := nodectx.GetNodeCtx().GetChainStorage().AddPostAuthor(trx.GroupId, trx.TrxId, trx.SenderPubkey, nodename); err != nil {
+				chain_log.Warningf("<%s> save author of trx <%s> failed: %s", chain.groupItem.GroupId, trx.TrxId, err)
+			}
+		}
 		//producer node does not handle APP_CONFIG and POST
 		if trx.Type == quorumpb.TrxType_APP_CONFIG || trx.Type == quorumpb.TrxType_POST {
 			//chain_log.Infof("Skip TRX %s with type %s", trx.TrxId, trx.Type.String())
@@ -763,6 +1041,9 @@ func (chain *Chain) ApplyTrxsProducerNode(trxs []*quorumpb.Trx, nodename string)
 		case quorumpb.TrxType_CHAIN_CONFIG:
 			chain_log.Debugf("<%s> apply CHAIN_CONFIG trx", chain.groupItem.GroupId)
 			nodectx.GetNodeCtx().GetChainStorage().UpdateChainConfigTrx(trx, nodename)
+		case quorumpb.TrxType_TOMBSTONE:
+			chain_log.Debugf("<%s> apply TOMBSTONE trx", chain.groupItem.GroupId)
+			chain.applyTombstone(trx, nodename)
 		default:
 			chain_log.Warningf("<%s> unsupported msgType <%s>", chain.groupItem.GroupId, trx.Type)
 		}
@@ -815,6 +1096,29 @@ func (chain *Chain) StopSync() {
 	}
 }
 
+// onSyncCaughtUp is called when the owner reports no more blocks after the local one
+func (chain *Chain) onSyncCaughtUp() {
+	if groupMgr != nil {
+		groupMgr.onSyncCaughtUp(chain.groupItem.GroupId)
+	}
+}
+
+func (chain *Chain) PauseSync() error {
+	chain_log.Debugf("<%s> PauseSync called", chain.groupItem.GroupId)
+	if chain.isOwner() {
+		return fmt.Errorf("owner of group %s does not sync", chain.groupItem.GroupId)
+	}
+	return chain.rexSyncer.Pause()
+}
+
+func (chain *Chain) ResumeSync() error {
+	chain_log.Debugf("<%s> ResumeSync called", chain.groupItem.GroupId)
+	if chain.isOwner() {
+		return fmt.Errorf("owner of group %s does not sync", chain.groupItem.GroupId)
+	}
+	return chain.rexSyncer.Resume()
+}
+
 //local sync
 //TODO
 //func (chain *Chain) SyncLocalBlock() error {
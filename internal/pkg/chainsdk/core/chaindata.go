@@ -21,6 +21,7 @@ type ChainData struct {
 }
 
 // TBD, move this to chain confi
+// it keeps a block response under the chain data exchange limit p2p.MessageSizeMax
 const MAX_BLOCK_IN_RESP_BYTES = 10485760 //10MB
 
 func (d *ChainData) GetReqBlocks(trx *quorumpb.Trx) (requester string, fromBlock uint64, reqBlocks int32, blocks []*quorumpb.Block, result quorumpb.ReqBlkResult, err error) {
@@ -81,7 +82,7 @@ func (d *ChainData) GetReqBlocks(trx *quorumpb.Trx) (requester string, fromBlock
 		totalBlockBytes = totalBlockBytes + len(pdate)
 		//check if reach maximum length, may have more
 		if totalBlockBytes > MAX_BLOCK_IN_RESP_BYTES {
-			return reqBlockItem.ReqPubkey, reqBlockItem.FromBlock, reqBlockItem.BlksRequested, blocks, quorumpb.ReqBlkResult_BLOCK_IN_RESP, nil
+			return reqBlockItem.ReqPubkey, reqBlockItem.FromBlock, reqBlockItem.BlksRequested, bs, quorumpb.ReqBlkResult_BLOCK_IN_RESP, nil
 		}
 
 		//put block into blocks list
@@ -0,0 +1,151 @@
+package chain
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+// the blocks including the trxs sent by this node are kept for confirmRetention after they are added
+const confirmRetention = time.Hour
+
+// TrxConfirmation is the block including a trx, Depth is the number of blocks from that block to the head of the chain,
+// the including block counted, so a trx just included has depth 1
+type TrxConfirmation struct {
+	BlockId uint64
+	Epoch   uint64
+	Depth   uint64
+}
+
+type trxInclusion struct {
+	blockId uint64
+	epoch   uint64
+	added   time.Time
+}
+
+type confirmWaiter struct {
+	depth uint64
+	done  chan struct{}
+}
+
+// confirmTracker records the blocks including the trxs sent by this node, and wakes up the callers waiting for them
+type confirmTracker struct {
+	mu        sync.Mutex
+	head      uint64
+	included  map[string]*trxInclusion
+	waiters   map[string][]*confirmWaiter
+	lastSweep time.Time
+}
+
+func newConfirmTracker() *confirmTracker {
+	return &confirmTracker{included: make(map[string]*trxInclusion), waiters: make(map[string][]*confirmWaiter)}
+}
+
+// blockAdded records the trxs of the block which are sent by this node or waited for, sent tells if a trx is sent by this node
+func (t *confirmTracker) blockAdded(block *quorumpb.Block, sent func(trxId string) bool, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if block.BlockId > t.head {
+		t.head = block.BlockId
+	}
+	for _, trx := range block.Trxs {
+		if _, ok := t.waiters[trx.TrxId]; ok || sent(trx.TrxId) {
+			t.included[trx.TrxId] = &trxInclusion{blockId: block.BlockId, epoch: block.Epoch, added: now}
+		}
+	}
+
+	for trxId, waiters := range t.waiters {
+		conf := t.confirmation(trxId)
+		if conf == nil {
+			continue
+		}
+		pending := waiters[:0]
+		for _, w := range waiters {
+			if conf.Depth >= w.depth {
+				close(w.done)
+			} else {
+				pending = append(pending, w)
+			}
+		}
+		if len(pending) == 0 {
+			delete(t.waiters, trxId)
+		} else {
+			t.waiters[trxId] = pending
+		}
+	}
+
+	if now.Sub(t.lastSweep) > time.Minute {
+		for trxId, inc := range t.included {
+			if _, ok := t.waiters[trxId]; !ok && now.Sub(inc.added) > confirmRetention {
+				delete(t.included, trxId)
+			}
+		}
+		t.lastSweep = now
+	}
+}
+
+// confirmation returns nil if the trx is not included yet, caller must hold the lock
+func (t *confirmTracker) confirmation(trxId string) *TrxConfirmation {
+	inc, ok := t.included[trxId]
+	if !ok {
+		return nil
+	}
+	conf := &TrxConfirmation{BlockId: inc.blockId, Epoch: inc.epoch}
+	if t.head >= inc.blockId {
+		conf.Depth = t.head - inc.blockId + 1
+	}
+	return conf
+}
+
+func (t *confirmTracker) get(trxId string) *TrxConfirmation {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.confirmation(trxId)
+}
+
+// wait blocks until the trx reaches depth or ctx is done, it returns the last known confirmation with the ctx error
+func (t *confirmTracker) wait(ctx context.Context, trxId string, depth uint64) (*TrxConfirmation, error) {
+	if depth < 1 {
+		depth = 1
+	}
+
+	t.mu.Lock()
+	if conf := t.confirmation(trxId); conf != nil && conf.Depth >= depth {
+		t.mu.Unlock()
+		return conf, nil
+	}
+	w := &confirmWaiter{depth: depth, done: make(chan struct{})}
+	t.waiters[trxId] = append(t.waiters[trxId], w)
+	t.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return t.get(trxId), nil
+	case <-ctx.Done():
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	select {
+	case <-w.done:
+		// confirmed right before ctx is done
+		return t.confirmation(trxId), nil
+	default:
+	}
+	waiters := t.waiters[trxId]
+	for i, v := range waiters {
+		if v == w {
+			waiters = append(waiters[:i], waiters[i+1:]...)
+			break
+		}
+	}
+	if len(waiters) == 0 {
+		delete(t.waiters, trxId)
+	} else {
+		t.waiters[trxId] = waiters
+	}
+	return t.confirmation(trxId), ctx.Err()
+}
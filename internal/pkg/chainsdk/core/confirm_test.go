@@ -0,0 +1,78 @@
+package chain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+func TestConfirmTrackerWait(t *testing.T) {
+	tracker := newConfirmTracker()
+	now := time.Now()
+	sent := map[string]bool{"trx-1": true}
+	isSent := func(trxId string) bool { return sent[trxId] }
+
+	done := make(chan *TrxConfirmation)
+	go func() {
+		conf, err := tracker.wait(context.Background(), "trx-1", 2)
+		if err != nil {
+			t.Errorf("wait failed: %s", err)
+		}
+		done <- conf
+	}()
+
+	// wait until the waiter is registered
+	for {
+		tracker.mu.Lock()
+		n := len(tracker.waiters["trx-1"])
+		tracker.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	tracker.blockAdded(&quorumpb.Block{BlockId: 5, Epoch: 5, Trxs: []*quorumpb.Trx{{TrxId: "trx-2"}, {TrxId: "trx-1"}}}, isSent, now)
+	if conf := tracker.get("trx-1"); conf == nil || conf.BlockId != 5 || conf.Depth != 1 {
+		t.Fatalf("trx-1 should be included at depth 1, got %+v", conf)
+	}
+	if conf := tracker.get("trx-2"); conf != nil {
+		t.Errorf("trx-2 is not sent by this node, got %+v", conf)
+	}
+	select {
+	case <-done:
+		t.Fatalf("depth 2 should not be reached by one block")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	tracker.blockAdded(&quorumpb.Block{BlockId: 6, Epoch: 6}, isSent, now)
+	select {
+	case conf := <-done:
+		if conf.BlockId != 5 || conf.Epoch != 5 || conf.Depth != 2 {
+			t.Errorf("want block 5 at depth 2, got %+v", conf)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("waiter should be woken up at depth 2")
+	}
+
+	// already confirmed, returned at once
+	if conf, err := tracker.wait(context.Background(), "trx-1", 1); err != nil || conf.Depth != 2 {
+		t.Errorf("want depth 2 at once, got %+v %v", conf, err)
+	}
+}
+
+func TestConfirmTrackerTimeout(t *testing.T) {
+	tracker := newConfirmTracker()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	conf, err := tracker.wait(ctx, "trx-1", 1)
+	if err == nil || conf != nil {
+		t.Fatalf("want timeout without confirmation, got %+v %v", conf, err)
+	}
+	if len(tracker.waiters) != 0 {
+		t.Errorf("waiter should be removed after timeout")
+	}
+}
@@ -3,24 +3,51 @@ package chain
 import (
 	"bytes"
 	"encoding/hex"
+	"fmt"
 	"time"
 
+	chaindef "github.com/rumsystem/quorum/internal/pkg/chainsdk/def"
 	"github.com/rumsystem/quorum/internal/pkg/conn"
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
 	"github.com/rumsystem/quorum/internal/pkg/logging"
+	"github.com/rumsystem/quorum/internal/pkg/metric"
 	"github.com/rumsystem/quorum/internal/pkg/nodectx"
 	"github.com/rumsystem/quorum/internal/pkg/storage/def"
+	"github.com/rumsystem/quorum/pkg/consensus"
 	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
 	quorumpb "github.com/rumsystem/quorum/pkg/pb"
 )
 
 var group_log = logging.Logger("group")
 
+// readOnlyKeyName is the node key which signs the sync requests of the groups joined read-only
+const readOnlyKeyName = "default"
+
 type Group struct {
 	//Group Item
 	Item     *quorumpb.GroupItem
 	ChainCtx *Chain
 	GroupId  string
 	Nodename string
+
+	readOnly bool
+}
+
+// LinkReadOnlyGroupKey links the sign key of the group to the node key in memory, so a read-only group has no key of its own
+func LinkReadOnlyGroupKey(groupId string) error {
+	ks := nodectx.GetNodeCtx().Keystore
+	dirks, ok := ks.(*localcrypto.DirKeyStore)
+	if !ok {
+		return fmt.Errorf("unknown keystore type %v", ks)
+	}
+	return dirks.LinkKey(groupId, readOnlyKeyName, localcrypto.Sign)
+}
+
+// NewReadOnlyGroup creates the group joined read-only, the group syncs and serves blocks but never publishes,
+// the sign key of the group must be linked by LinkReadOnlyGroupKey
+func (grp *Group) NewReadOnlyGroup(item *quorumpb.GroupItem) error {
+	grp.readOnly = true
+	return grp.NewGroup(item)
 }
 
 func (grp *Group) NewGroup(item *quorumpb.GroupItem) error {
@@ -80,8 +107,14 @@ func (grp *Group) NewGroup(item *quorumpb.GroupItem) error {
 	//update producer list for ConnMgr just created
 	grp.ChainCtx.UpdConnMgrProducer()
 
-	//create group consensus
-	grp.ChainCtx.CreateConsensus()
+	//create group consensus by the node setting, the group keeps it once created
+	cnsName := nodectx.GetNodeCtx().Consensus
+	if cnsName == "" {
+		cnsName = consensus.DefaultConsensus
+	}
+	if err := grp.ChainCtx.CreateConsensus(cnsName); err != nil {
+		return err
+	}
 
 	//save groupItem to db
 	err = nodectx.GetNodeCtx().GetChainStorage().AddGroup(grp.Item)
@@ -89,6 +122,16 @@ func (grp *Group) NewGroup(item *quorumpb.GroupItem) error {
 		return err
 	}
 
+	if err := nodectx.GetNodeCtx().GetChainStorage().SetGroupConsensusName(grp.Item.GroupId, cnsName); err != nil {
+		return err
+	}
+
+	if grp.readOnly {
+		if err := nodectx.GetNodeCtx().GetChainStorage().SetGroupReadOnly(grp.Item.GroupId); err != nil {
+			return err
+		}
+	}
+
 	group_log.Debugf("Group <%s> created", grp.Item.GroupId)
 	return nil
 }
@@ -100,6 +143,18 @@ func (grp *Group) LoadGroup(item *quorumpb.GroupItem) {
 	grp.GroupId = item.GroupId
 	grp.Nodename = nodectx.GetNodeCtx().Name
 
+	//link the sign key of read-only group before anything is signed
+	readOnly, err := nodectx.GetNodeCtx().GetChainStorage().IsGroupReadOnly(item.GroupId)
+	if err != nil {
+		group_log.Warningf("<%s> get read-only flag failed: %s", item.GroupId, err)
+	}
+	if readOnly {
+		grp.readOnly = true
+		if err := LinkReadOnlyGroupKey(item.GroupId); err != nil {
+			group_log.Errorf("<%s> link read-only group key failed: %s", item.GroupId, err)
+		}
+	}
+
 	//create and initial chain
 	grp.ChainCtx = &Chain{}
 	grp.ChainCtx.NewChain(item, grp.Nodename, true)
@@ -132,8 +187,17 @@ func (grp *Group) LoadGroup(item *quorumpb.GroupItem) {
 	//update producer list for ConnMgr just created
 	grp.ChainCtx.UpdConnMgrProducer()
 
-	//create group consensus
-	grp.ChainCtx.CreateConsensus()
+	//create group consensus, groups created before the consensus is saved use the default
+	cnsName, err := nodectx.GetNodeCtx().GetChainStorage().GetGroupConsensusName(item.GroupId)
+	if err != nil {
+		group_log.Warningf("<%s> get consensus name failed: %s", item.GroupId, err)
+	}
+	if cnsName == "" {
+		cnsName = consensus.DefaultConsensus
+	}
+	if err := grp.ChainCtx.CreateConsensus(cnsName); err != nil {
+		group_log.Errorf("<%s> create consensus <%s> failed: %s", item.GroupId, cnsName, err)
+	}
 
 	group_log.Infof("Group <%s> loaded", grp.Item.GroupId)
 }
@@ -156,10 +220,22 @@ func (grp *Group) LeaveGrp() error {
 		return err
 	}
 
+	//drop the key linked for read-only group
+	if grp.readOnly {
+		if dirks, ok := nodectx.GetNodeCtx().Keystore.(*localcrypto.DirKeyStore); ok {
+			dirks.UnlinkKey(grp.Item.GroupId, localcrypto.Sign)
+		}
+	}
+
 	//remove group from local db
 	return nodectx.GetNodeCtx().GetChainStorage().RmGroup(grp.Item.GroupId)
 }
 
+// IsReadOnly reports whether the group is joined read-only
+func (grp *Group) IsReadOnly() bool {
+	return grp.readOnly
+}
+
 func (grp *Group) ClearGroupData() error {
 	group_log.Debugf("<%s> ClearGroupData called", grp.Item.GroupId)
 
@@ -183,6 +259,10 @@ func (grp *Group) GetNodeName() string {
 	return grp.Nodename
 }
 
+func (grp *Group) GetSyncProgress() *chaindef.SyncProgress {
+	return grp.ChainCtx.GetSyncProgress()
+}
+
 func (grp *Group) GetRexSyncerStatus() string {
 	return grp.ChainCtx.GetRexSyncerStatus()
 }
@@ -239,25 +319,68 @@ func (grp *Group) UpdAnnounce(item *quorumpb.AnnounceItem) (string, error) {
 
 // send POST trx
 func (grp *Group) PostToGroup(content []byte) (string, error) {
+	return grp.PostToGroupWithPolicy(content, nil)
+}
+
+// send POST trx, resend it by policy if it is not applied in time
+func (grp *Group) PostToGroupWithPolicy(content []byte, policy *PublishRetryPolicy) (string, error) {
+	return grp.PostToGroupWithExpiry(content, 0, policy)
+}
+
+// send POST trx expiring at `expired` (unix nano), zero means never expire
+func (grp *Group) PostToGroupWithExpiry(content []byte, expired int64, policy *PublishRetryPolicy) (string, error) {
 	group_log.Debugf("<%s> PostToGroup called", grp.Item.GroupId)
 	if grp.Item.EncryptType == quorumpb.GroupEncryptType_PRIVATE {
+		sealed, err := sealPrivatePost(grp.Item, content)
+		if err != nil {
+			return "", err
+		}
+		if sealed != nil {
+			trx, err := grp.ChainCtx.GetTrxFactory().GetPostAnyTrxWithExpiry("", sealed, expired)
+			if err != nil {
+				return "", err
+			}
+			return grp.sendTrxWithPolicy(trx, policy)
+		}
+
 		keys, err := grp.ChainCtx.GetUsesEncryptPubKeys()
 		if err != nil {
 			return "", err
 		}
 
-		trx, err := grp.ChainCtx.GetTrxFactory().GetPostAnyTrx("", content, keys)
+		trx, err := grp.ChainCtx.GetTrxFactory().GetPostAnyTrxWithExpiry("", content, expired, keys)
 		if err != nil {
 			return "", err
 		}
-		return grp.sendTrx(trx)
+		return grp.sendTrxWithPolicy(trx, policy)
 	}
 
-	trx, err := grp.ChainCtx.GetTrxFactory().GetPostAnyTrx("", content)
+	trx, err := grp.ChainCtx.GetTrxFactory().GetPostAnyTrxWithExpiry("", content, expired)
 	if err != nil {
 		return "", err
 	}
 
+	return grp.sendTrxWithPolicy(trx, policy)
+}
+
+// send TOMBSTONE trx to delete the post, the post should be sent by this node or the node is the group owner
+func (grp *Group) DeletePost(trxId string) (string, error) {
+	group_log.Debugf("<%s> DeletePost called", grp.Item.GroupId)
+	tombstone, err := nodectx.GetNodeCtx().GetChainStorage().GetTombstone(grp.Item.GroupId, trxId, grp.Nodename)
+	if err != nil {
+		return "", err
+	}
+	if tombstone != "" {
+		return "", fmt.Errorf("post %s is deleted by trx %s", trxId, tombstone)
+	}
+
+	trx, err := grp.ChainCtx.GetTrxFactory().GetTombstoneTrx("", trxId)
+	if err != nil {
+		return "", err
+	}
+	if err := grp.ChainCtx.checkTombstone(trx, trxId, grp.Nodename); err != nil {
+		return "", err
+	}
 	return grp.sendTrx(trx)
 }
 
@@ -265,7 +388,7 @@ func (grp *Group) UpdProducer(item *quorumpb.BFTProducerBundleItem) (string, err
 	group_log.Debugf("<%s> UpdProducer called", grp.Item.GroupId)
 	trx, err := grp.ChainCtx.GetTrxFactory().GetRegProducerBundleTrx("", item)
 	if err != nil {
-		return "", nil
+		return "", err
 	}
 	return grp.sendTrx(trx)
 }
@@ -304,6 +427,15 @@ func (grp *Group) SendRawTrx(trx *quorumpb.Trx) (string, error) {
 }
 
 func (grp *Group) sendTrx(trx *quorumpb.Trx) (string, error) {
+	return grp.sendTrxWithPolicy(trx, nil)
+}
+
+// sendTrxWithPolicy publishes the trx and keeps it in the publish queue until it is applied,
+// nil policy uses the node default
+func (grp *Group) sendTrxWithPolicy(trx *quorumpb.Trx, policy *PublishRetryPolicy) (string, error) {
+	if grp.readOnly {
+		return "", fmt.Errorf("%w: %s", rumerrors.ErrReadOnlyGroup, grp.Item.GroupId)
+	}
 	connMgr, err := conn.GetConn().GetConnMgr(grp.Item.GroupId)
 	if err != nil {
 		return "", err
@@ -312,6 +444,8 @@ func (grp *Group) sendTrx(trx *quorumpb.Trx) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	metric.TrxTotal.WithLabelValues(grp.Item.GroupId, metric.TrxDirection.Sent).Inc()
+	grp.ChainCtx.addPendingTrx(trx, policy)
 
 	return trx.TrxId, nil
 }
@@ -321,6 +455,16 @@ func (grp *Group) StartSync(restart bool) error {
 	return grp.ChainCtx.StartSync()
 }
 
+func (grp *Group) PauseSync() error {
+	group_log.Debugf("<%s> PauseSync called", grp.Item.GroupId)
+	return grp.ChainCtx.PauseSync()
+}
+
+func (grp *Group) ResumeSync() error {
+	group_log.Debugf("<%s> ResumeSync called", grp.Item.GroupId)
+	return grp.ChainCtx.ResumeSync()
+}
+
 func (grp *Group) StopSync() error {
 	group_log.Debugf("<%s> StopSync called", grp.Item.GroupId)
 	grp.ChainCtx.StopSync()
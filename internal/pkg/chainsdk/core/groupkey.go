@@ -0,0 +1,264 @@
+package chain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rumsystem/quorum/internal/pkg/nodectx"
+	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+/*
+A private group can seal its posts with a group key instead of encrypting each post by age to all the users.
+The owner grants the key to a member by a share, which is the key encrypted by age to the encrypt pubkey of the member.
+A version of the key is created with the shares of its members in one app config item _groupkey_<version>, the value
+is the json object of the shares by the sign pubkeys of the members, so a member gets the version and its share in the
+same trx. A member granted later gets its share by the app config item _groupkey_<version>_<member sign pubkey>.
+The posts are sealed with the newest version, a node without a share of the version can't read the posts of the version,
+the blocks it syncs carry only the sealed data.
+
+Revoking a member rotates the key, the next version is shared with the other members only, the posts sealed before
+stay readable by the holders of their version.
+*/
+
+const GROUP_KEY_PREFIX = "_groupkey_"
+
+var (
+	ErrNoGroupKey = errors.New("this node has no share of the group key")
+	// a blob can't be encrypted to each user by age like a post, a private group needs a group key to post blobs
+	ErrNoGroupKeyForBlob = errors.New("the private group has no group key, its blobs can't be encrypted")
+)
+
+// GroupKeyName returns the app config name of the version, its value is the shares of the members by their sign pubkeys
+func GroupKeyName(version uint32) string {
+	return fmt.Sprintf("%s%d", GROUP_KEY_PREFIX, version)
+}
+
+// GroupKeyShareName returns the app config name of the share of a member granted after the version is created
+func GroupKeyShareName(version uint32, memberPubkey string) string {
+	return fmt.Sprintf("%s%d_%s", GROUP_KEY_PREFIX, version, memberPubkey)
+}
+
+// group keys opened from the shares of this node, by <group id>_<version>
+var groupKeys = struct {
+	sync.Mutex
+	keys map[string][]byte
+}{keys: make(map[string][]byte)}
+
+func getAppConfigValue(groupId string, name string) (string, bool, error) {
+	item, err := nodectx.GetNodeCtx().GetChainStorage().GetAppConfigItem(name, groupId, nodectx.GetNodeCtx().Name)
+	if err != nil {
+		return "", false, err
+	}
+	if item.Name == "" {
+		return "", false, nil
+	}
+	return item.Value, true, nil
+}
+
+// GetGroupKeyVersion returns the version of the group key new posts are sealed with, 0 if the group has no group key
+func GetGroupKeyVersion(groupId string) (uint32, error) {
+	names, _, err := nodectx.GetNodeCtx().GetChainStorage().GetAppConfigKey(groupId, nodectx.GetNodeCtx().Name)
+	if err != nil {
+		return 0, err
+	}
+	return groupKeyVersion(names), nil
+}
+
+// groupKeyVersion returns the newest version of the app config names
+func groupKeyVersion(names []string) uint32 {
+	var newest uint32
+	for _, name := range names {
+		if !strings.HasPrefix(name, GROUP_KEY_PREFIX) {
+			continue
+		}
+		version, err := strconv.ParseUint(strings.TrimPrefix(name, GROUP_KEY_PREFIX), 10, 32)
+		if err == nil && uint32(version) > newest {
+			newest = uint32(version)
+		}
+	}
+	return newest
+}
+
+// getGroupKeyShares returns the shares of the version created with it by the sign pubkeys of the members
+func getGroupKeyShares(groupId string, version uint32) (map[string]string, error) {
+	shares := map[string]string{}
+	value, ok, err := getAppConfigValue(groupId, GroupKeyName(version))
+	if err != nil || !ok {
+		return shares, err
+	}
+	if err := json.Unmarshal([]byte(value), &shares); err != nil {
+		return nil, fmt.Errorf("invalid group key version %d: %s", version, err)
+	}
+	return shares, nil
+}
+
+// getGroupKeyShare returns the share of the version of the member, granted later or created with the version
+func getGroupKeyShare(groupId string, version uint32, memberPubkey string) (string, bool, error) {
+	share, ok, err := getAppConfigValue(groupId, GroupKeyShareName(version, memberPubkey))
+	if err != nil || ok {
+		return share, ok, err
+	}
+	shares, err := getGroupKeyShares(groupId, version)
+	if err != nil {
+		return "", false, err
+	}
+	share, ok = shares[memberPubkey]
+	return share, ok, nil
+}
+
+// GetGroupKey opens the group key of the version from the share of this node, ErrNoGroupKey if the node has no share
+func GetGroupKey(item *quorumpb.GroupItem, version uint32) ([]byte, error) {
+	cacheKey := fmt.Sprintf("%s_%d", item.GroupId, version)
+	groupKeys.Lock()
+	key, ok := groupKeys.keys[cacheKey]
+	groupKeys.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	share, ok, err := getGroupKeyShare(item.GroupId, version, item.UserSignPubkey)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w, version %d", ErrNoGroupKey, version)
+	}
+	encrypted, err := base64.StdEncoding.DecodeString(share)
+	if err != nil {
+		return nil, err
+	}
+	key, err = nodectx.GetNodeCtx().Keystore.Decrypt(item.GroupId, encrypted)
+	if err != nil {
+		return nil, err
+	}
+
+	groupKeys.Lock()
+	groupKeys.keys[cacheKey] = key
+	groupKeys.Unlock()
+	return key, nil
+}
+
+// GetGroupKeyMembers returns the sign pubkeys of the members having a share of the version, sorted
+func GetGroupKeyMembers(groupId string, version uint32) ([]string, error) {
+	names, _, err := nodectx.GetNodeCtx().GetChainStorage().GetAppConfigKey(groupId, nodectx.GetNodeCtx().Name)
+	if err != nil {
+		return nil, err
+	}
+	shares, err := getGroupKeyShares(groupId, version)
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range groupKeyMembers(names, version) {
+		shares[member] = ""
+	}
+	members := make([]string, 0, len(shares))
+	for member := range shares {
+		members = append(members, member)
+	}
+	sort.Strings(members)
+	return members, nil
+}
+
+// groupKeyMembers returns the members granted a share of the version after it is created
+func groupKeyMembers(names []string, version uint32) []string {
+	prefix := fmt.Sprintf("%s%d_", GROUP_KEY_PREFIX, version)
+	members := []string{}
+	for _, name := range names {
+		if strings.HasPrefix(name, prefix) {
+			members = append(members, strings.TrimPrefix(name, prefix))
+		}
+	}
+	return members
+}
+
+// NewGroupKeyShare encrypts the group key to the encrypt pubkey of a member, the share is the value of GroupKeyShareName
+func NewGroupKeyShare(key []byte, encryptPubkey string) (string, error) {
+	encrypted, err := nodectx.GetNodeCtx().Keystore.EncryptTo([]string{encryptPubkey}, key)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(encrypted), nil
+}
+
+// DecryptPrivatePost decrypts the data of a post of a private group, sealed by a group key or encrypted by age to the users
+func DecryptPrivatePost(item *quorumpb.GroupItem, data []byte) ([]byte, error) {
+	version, err := localcrypto.GroupKeyVersion(data)
+	if err != nil {
+		return nodectx.GetNodeCtx().Keystore.Decrypt(item.GroupId, data)
+	}
+	key, err := GetGroupKey(item, version)
+	if err != nil {
+		return nil, err
+	}
+	return localcrypto.GroupKeyOpen(key, data)
+}
+
+// sealPrivatePost seals the content with the current group key, it returns nil if the group has no group key.
+// A node without the share of the current version can't post, the post would be readable by the revoked members otherwise
+func sealPrivatePost(item *quorumpb.GroupItem, content []byte) ([]byte, error) {
+	version, err := GetGroupKeyVersion(item.GroupId)
+	if err != nil || version == 0 {
+		return nil, err
+	}
+	key, err := GetGroupKey(item, version)
+	if err != nil {
+		return nil, err
+	}
+	return localcrypto.GroupKeySeal(version, key, content)
+}
+
+// checkGroupKeyConfig returns error if the app config trx sets a group key item and is not sent by the group owner,
+// data is the decrypted data of the trx. A member could undo its revocation, or seal with a version no one else holds
+func (chain *Chain) checkGroupKeyConfig(trx *quorumpb.Trx, data []byte) error {
+	item := &quorumpb.AppConfigItem{}
+	if err := proto.Unmarshal(data, item); err != nil {
+		return err
+	}
+	if strings.HasPrefix(item.Name, GROUP_KEY_PREFIX) && !chain.isOwnerByPubkey(trx.SenderPubkey) {
+		return fmt.Errorf("group key item %s is not sent by the group owner", item.Name)
+	}
+	return nil
+}
+
+// GetUserEncryptPubkey returns the encrypt pubkey of a user of the private group
+func (chain *Chain) GetUserEncryptPubkey(userPubkey string) (string, bool) {
+	usr, ok := chain.userPool[userPubkey]
+	if !ok {
+		return "", false
+	}
+	return usr.EncryptPubkey, true
+}
+
+// SealPrivateBlob seals the bytes of a blob of the private group with the current group key
+func SealPrivateBlob(item *quorumpb.GroupItem, data []byte) ([]byte, error) {
+	sealed, err := sealPrivatePost(item, data)
+	if err != nil {
+		return nil, err
+	}
+	if sealed == nil {
+		return nil, ErrNoGroupKeyForBlob
+	}
+	return sealed, nil
+}
+
+// OpenPrivateBlob opens the bytes of a blob sealed by SealPrivateBlob with the group key of its version
+func OpenPrivateBlob(item *quorumpb.GroupItem, data []byte) ([]byte, error) {
+	version, err := localcrypto.GroupKeyVersion(data)
+	if err != nil {
+		return nil, err
+	}
+	key, err := GetGroupKey(item, version)
+	if err != nil {
+		return nil, err
+	}
+	return localcrypto.GroupKeyOpen(key, data)
+}
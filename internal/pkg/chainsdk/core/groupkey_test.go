@@ -0,0 +1,278 @@
+package chain
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/rumsystem/quorum/internal/pkg/nodectx"
+	"github.com/rumsystem/quorum/internal/pkg/storage"
+	chainstorage "github.com/rumsystem/quorum/internal/pkg/storage/chain"
+	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestGroupKeyMembers(t *testing.T) {
+	names := []string{
+		GroupKeyName(1),
+		GroupKeyShareName(1, "owner"),
+		GroupKeyShareName(1, "alice"),
+		GroupKeyShareName(11, "bob"),
+		GroupKeyName(2),
+		GroupKeyShareName(2, "owner"),
+		"group_announcement",
+	}
+
+	if members := groupKeyMembers(names, 1); !reflect.DeepEqual(members, []string{"owner", "alice"}) {
+		t.Errorf("unexpected members of version 1: %v", members)
+	}
+	if members := groupKeyMembers(names, 11); !reflect.DeepEqual(members, []string{"bob"}) {
+		t.Errorf("unexpected members of version 11: %v", members)
+	}
+	if members := groupKeyMembers(names, 3); len(members) != 0 {
+		t.Errorf("version 3 should have no member: %v", members)
+	}
+	// a share granted later does not create its version
+	if version := groupKeyVersion(names); version != 2 {
+		t.Errorf("want version 2, got %d", version)
+	}
+	if version := groupKeyVersion([]string{"group_announcement"}); version != 0 {
+		t.Errorf("want no version, got %d", version)
+	}
+}
+
+const groupKeyTestGroupId = "5ed3f9fe-81e2-450d-9146-7a329aac2b62"
+
+// groupKeyNode is a node of the group, it holds the encrypt key of the group under its sign pubkey
+type groupKeyNode struct {
+	item          *quorumpb.GroupItem
+	ks            *localcrypto.DirKeyStore
+	encryptPubkey string
+}
+
+func newGroupKeyNode(t *testing.T, pubkey string) *groupKeyNode {
+	ks, _, err := localcrypto.InitDirKeyStore(pubkey, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.Unlock(map[string]string{}, "password"); err != nil {
+		t.Fatal(err)
+	}
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encryptPubkey, err := ks.ImportEncryptKey(groupKeyTestGroupId, identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &groupKeyNode{
+		item:          &quorumpb.GroupItem{GroupId: groupKeyTestGroupId, UserSignPubkey: pubkey, EncryptType: quorumpb.GroupEncryptType_PRIVATE},
+		ks:            ks,
+		encryptPubkey: encryptPubkey,
+	}
+}
+
+// use switches the node context to the node, the group keys opened by the other node are dropped
+func (n *groupKeyNode) use() {
+	nodectx.GetNodeCtx().Keystore = n.ks
+	groupKeys.Lock()
+	groupKeys.keys = make(map[string][]byte)
+	groupKeys.Unlock()
+}
+
+func initGroupKeyNodeCtx(t *testing.T) *chainstorage.Storage {
+	dbMgr, err := storage.CreateDbWithBackend(t.TempDir(), storage.BACKEND_MEMORY)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		dbMgr.GroupInfoDb.Close()
+		dbMgr.Db.Close()
+	})
+	cs := chainstorage.NewChainStorage(dbMgr)
+	nodectx.InitCtx(context.Background(), "test", nil, dbMgr, cs, "", "", nodectx.FULL_NODE)
+	return cs
+}
+
+// addGroupKeyConfig applies the app config item as the trx of the owner would
+func addGroupKeyConfig(t *testing.T, cs *chainstorage.Storage, name string, value string) {
+	data, err := proto.Marshal(&quorumpb.AppConfigItem{GroupId: groupKeyTestGroupId, Action: quorumpb.ActionType_ADD, Name: name, Type: quorumpb.AppConfigType_STRING, Value: value})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cs.UpdateAppConfig(data, "test"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// addGroupKeyVersion creates the version of the key with the shares of the members in one item
+func addGroupKeyVersion(t *testing.T, cs *chainstorage.Storage, version uint32, key []byte, members ...*groupKeyNode) {
+	shares := map[string]string{}
+	for _, member := range members {
+		share, err := NewGroupKeyShare(key, member.encryptPubkey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		shares[member.item.UserSignPubkey] = share
+	}
+	value, err := json.Marshal(shares)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addGroupKeyConfig(t, cs, GroupKeyName(version), string(value))
+}
+
+func TestGroupKeyRotation(t *testing.T) {
+	cs := initGroupKeyNodeCtx(t)
+	owner := newGroupKeyNode(t, "owner")
+	alice := newGroupKeyNode(t, "alice")
+	bob := newGroupKeyNode(t, "bob")
+	carol := newGroupKeyNode(t, "carol")
+	owner.use()
+
+	key1, _ := localcrypto.CreateAesKey()
+	addGroupKeyVersion(t, cs, 1, key1, owner, alice, bob)
+	// the version and the shares arrive in one item, a member can post as soon as it sees the version
+	alice.use()
+	if version, err := GetGroupKeyVersion(groupKeyTestGroupId); err != nil || version != 1 {
+		t.Fatalf("want version 1, got %d %v", version, err)
+	}
+	sealed1, err := sealPrivatePost(alice.item, []byte("hello v1"))
+	if err != nil || sealed1 == nil {
+		t.Fatalf("alice should post with version 1, got %v", err)
+	}
+
+	// bob is revoked, version 2 is shared with the others only
+	key2, _ := localcrypto.CreateAesKey()
+	addGroupKeyVersion(t, cs, 2, key2, owner, alice)
+	if members, err := GetGroupKeyMembers(groupKeyTestGroupId, 2); err != nil || !reflect.DeepEqual(members, []string{"alice", "owner"}) {
+		t.Errorf("unexpected members of version 2: %v %v", members, err)
+	}
+	sealed2, err := sealPrivatePost(alice.item, []byte("hello v2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version, _ := localcrypto.GroupKeyVersion(sealed2); version != 2 {
+		t.Fatalf("want the post sealed with version 2, got %d", version)
+	}
+
+	bob.use()
+	if data, err := DecryptPrivatePost(bob.item, sealed1); err != nil || string(data) != "hello v1" {
+		t.Errorf("bob should still read the posts of version 1, got %s %v", data, err)
+	}
+	if _, err := GetGroupKey(bob.item, 2); !errors.Is(err, ErrNoGroupKey) {
+		t.Errorf("revoked bob should not open version 2, got %v", err)
+	}
+	if _, err := DecryptPrivatePost(bob.item, sealed2); err == nil {
+		t.Errorf("revoked bob should not read the posts of version 2")
+	}
+	if _, err := sealPrivatePost(bob.item, []byte("hello")); !errors.Is(err, ErrNoGroupKey) {
+		t.Errorf("revoked bob should not post, got %v", err)
+	}
+
+	// carol is not a member of any version
+	carol.use()
+	for _, sealed := range [][]byte{sealed1, sealed2} {
+		if _, err := DecryptPrivatePost(carol.item, sealed); err == nil {
+			t.Errorf("carol should not read the posts")
+		}
+	}
+
+	// carol is granted version 2 later by a share of her own
+	share, err := NewGroupKeyShare(key2, carol.encryptPubkey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addGroupKeyConfig(t, cs, GroupKeyShareName(2, "carol"), share)
+	if data, err := DecryptPrivatePost(carol.item, sealed2); err != nil || string(data) != "hello v2" {
+		t.Errorf("carol should read the posts of version 2 once granted, got %s %v", data, err)
+	}
+	if members, _ := GetGroupKeyMembers(groupKeyTestGroupId, 2); !reflect.DeepEqual(members, []string{"alice", "carol", "owner"}) {
+		t.Errorf("unexpected members of version 2: %v", members)
+	}
+}
+
+func TestDecryptPrivatePostByAge(t *testing.T) {
+	initGroupKeyNodeCtx(t)
+	alice := newGroupKeyNode(t, "alice")
+	bob := newGroupKeyNode(t, "bob")
+
+	// a post of a group without a group key is encrypted by age to the users
+	alice.use()
+	encrypted, err := alice.ks.EncryptTo([]string{alice.encryptPubkey}, []byte("hello age"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err := DecryptPrivatePost(alice.item, encrypted); err != nil || string(data) != "hello age" {
+		t.Errorf("want the post decrypted by age, got %s %v", data, err)
+	}
+	bob.use()
+	if _, err := DecryptPrivatePost(bob.item, encrypted); err == nil {
+		t.Errorf("bob is not a recipient, the post should not be decrypted")
+	}
+}
+
+func TestGroupKeyConfigOfOwnerOnly(t *testing.T) {
+	cs := initGroupKeyNodeCtx(t)
+	owner := newGroupKeyNode(t, "owner")
+	bob := newGroupKeyNode(t, "bob")
+	bob.use()
+
+	cipherKey, _ := localcrypto.CreateAesKey()
+	item := &quorumpb.GroupItem{GroupId: groupKeyTestGroupId, OwnerPubKey: "owner", UserSignPubkey: "bob", CipherKey: hex.EncodeToString(cipherKey)}
+	chain := &Chain{groupItem: item, nodename: "test", pubQueue: newPubQueue(nil)}
+
+	newTrx := func(trxId string, sender string, name string) *quorumpb.Trx {
+		data, err := proto.Marshal(&quorumpb.AppConfigItem{GroupId: groupKeyTestGroupId, Action: quorumpb.ActionType_ADD, Name: name, Type: quorumpb.AppConfigType_STRING, Value: "{}"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		encrypted, err := localcrypto.AesEncrypt(data, cipherKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &quorumpb.Trx{TrxId: trxId, GroupId: groupKeyTestGroupId, Type: quorumpb.TrxType_APP_CONFIG, SenderPubkey: sender, Data: encrypted}
+	}
+
+	// revoked bob publishes the next version to undo the revocation, a producer rejects it
+	forged := newTrx("trx-forged", "bob", GroupKeyName(2))
+	data, err := chain.decryptTrxData(forged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.checkGroupKeyConfig(forged, data); err == nil {
+		t.Errorf("a group key item not sent by the owner should be rejected")
+	}
+
+	// a full node applying the block skips it, the other app config items of the trxs apply
+	key1, _ := localcrypto.CreateAesKey()
+	addGroupKeyVersion(t, cs, 1, key1, owner, bob)
+	trxs := []*quorumpb.Trx{
+		forged,
+		newTrx("trx-share", "bob", GroupKeyShareName(1, "carol")),
+		newTrx("trx-config", "bob", "group_announcement"),
+		newTrx("trx-owner", "owner", GroupKeyName(3)),
+	}
+	if err := chain.ApplyTrxsFullNode(trxs, "test"); err != nil {
+		t.Fatal(err)
+	}
+	names, _, err := cs.GetAppConfigKey(groupKeyTestGroupId, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version := groupKeyVersion(names); version != 3 {
+		t.Errorf("want version 3 of the owner only, got %d", version)
+	}
+	if members := groupKeyMembers(names, 1); len(members) != 0 {
+		t.Errorf("a share not sent by the owner should be skipped, got %v", members)
+	}
+	if _, ok, _ := getAppConfigValue(groupKeyTestGroupId, "group_announcement"); !ok {
+		t.Errorf("an app config item of another name should apply")
+	}
+}
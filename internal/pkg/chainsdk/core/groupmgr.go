@@ -1,7 +1,12 @@
 package chain
 
 import (
+	"context"
 	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	chaindef "github.com/rumsystem/quorum/internal/pkg/chainsdk/def"
 	"github.com/rumsystem/quorum/internal/pkg/logging"
@@ -14,10 +19,21 @@ var groupMgr_log = logging.Logger("groupmgr")
 
 type GroupMgr struct {
 	Groups map[string]*Group
+	loaded atomic.Bool
+
+	syncMu     sync.Mutex
+	maxSyncing int                  // maximum number of groups catching up at the same time, 0 means no limit
+	syncing    map[string]time.Time // groups catching up and when they got the slot
+	syncQueue  []string             // groups waiting to start sync
+
+	pubWorkers atomic.Value // *publishWorkers of the publish queue watcher
 }
 
 var groupMgr *GroupMgr
 
+// SyncSlotTimeout is how long a group may hold a sync slot without catching up, the slot is then given to the next queued group
+var SyncSlotTimeout = 10 * time.Minute
+
 func GetGroupMgr() *GroupMgr {
 	return groupMgr
 }
@@ -26,6 +42,7 @@ func InitGroupMgr() error {
 	groupMgr_log.Debug("InitGroupMgr called")
 	groupMgr = &GroupMgr{}
 	groupMgr.Groups = make(map[string]*Group)
+	groupMgr.syncing = make(map[string]time.Time)
 	return nil
 }
 
@@ -50,23 +67,200 @@ func (groupMgr *GroupMgr) LoadAllGroups() error {
 			group.LoadGroup(item)
 		}
 	}
+	groupMgr.loaded.Store(true)
 	return nil
 }
 
+// Loaded reports whether LoadAllGroups is done
+func (groupmgr *GroupMgr) Loaded() bool {
+	return groupmgr.loaded.Load()
+}
+
+// SetMaxSyncingGroups limits the number of groups catching up at the same time, 0 means no limit
+func (groupmgr *GroupMgr) SetMaxSyncingGroups(n int) {
+	groupmgr.syncMu.Lock()
+	defer groupmgr.syncMu.Unlock()
+	groupmgr.maxSyncing = n
+}
+
 // load and group and start syncing
 func (groupMgr *GroupMgr) StartSyncAllGroups() error {
 	groupMgr_log.Debug("SyncAllGroup called")
 
-	for _, grp := range groupMgr.Groups {
-		groupMgr_log.Debugf("Start sync group: <%s>", grp.Item.GroupId)
-		grp.StartSync(false)
+	groupMgr.syncMu.Lock()
+	defer groupMgr.syncMu.Unlock()
+
+	if groupMgr.maxSyncing <= 0 {
+		for _, grp := range groupMgr.Groups {
+			groupMgr_log.Debugf("Start sync group: <%s>", grp.Item.GroupId)
+			grp.StartSync(false)
+		}
+		return nil
 	}
 
+	groupMgr.syncQueue = groupMgr.syncQueue[:0]
+	for groupId, grp := range groupMgr.Groups {
+		if grp.ChainCtx.isOwner() {
+			//owner does not sync, no need to wait in queue
+			grp.StartSync(false)
+			continue
+		}
+		groupMgr.syncQueue = append(groupMgr.syncQueue, groupId)
+	}
+	sortSyncQueue(groupMgr.syncQueue, groupMgr.getPendingTrxCounts(groupMgr.syncQueue))
+	groupMgr.promoteSyncQueue()
+
 	return nil
 }
 
+// EnqueueSync starts sync of a group joined or imported at runtime, the group waits in the sync queue if the limit is reached
+func (groupmgr *GroupMgr) EnqueueSync(grp *Group) error {
+	groupmgr.syncMu.Lock()
+	defer groupmgr.syncMu.Unlock()
+
+	if groupmgr.maxSyncing <= 0 || grp.ChainCtx.isOwner() {
+		return grp.StartSync(false)
+	}
+
+	groupId := grp.Item.GroupId
+	if _, ok := groupmgr.syncing[groupId]; ok {
+		return nil
+	}
+	for _, id := range groupmgr.syncQueue {
+		if id == groupId {
+			return nil
+		}
+	}
+	groupmgr.syncQueue = append(groupmgr.syncQueue, groupId)
+	sortSyncQueue(groupmgr.syncQueue, groupmgr.getPendingTrxCounts(groupmgr.syncQueue))
+	groupmgr.promoteSyncQueue()
+	return nil
+}
+
+// ReleaseSync frees the sync slot or the queue position of a group which stops syncing, such as a left group
+func (groupmgr *GroupMgr) ReleaseSync(groupId string) {
+	groupmgr.syncMu.Lock()
+	defer groupmgr.syncMu.Unlock()
+
+	delete(groupmgr.syncing, groupId)
+	for i, id := range groupmgr.syncQueue {
+		if id == groupId {
+			groupmgr.syncQueue = append(groupmgr.syncQueue[:i], groupmgr.syncQueue[i+1:]...)
+			break
+		}
+	}
+	groupmgr.promoteSyncQueue()
+}
+
+// getPendingTrxCounts reads the number of the pending trxs of the groups from db, the publish queue in memory is empty after a restart
+func (groupmgr *GroupMgr) getPendingTrxCounts(groupIds []string) map[string]int {
+	pending := map[string]int{}
+	for _, groupId := range groupIds {
+		grp, ok := groupmgr.Groups[groupId]
+		if !ok {
+			continue
+		}
+		n, err := nodectx.GetNodeCtx().GetChainStorage().GetPublishEntryCount(groupId, grp.Nodename)
+		if err != nil {
+			groupMgr_log.Warningf("get pending trxs of group <%s> failed: %s", groupId, err)
+			continue
+		}
+		pending[groupId] = n
+	}
+	return pending
+}
+
+// sortSyncQueue puts groups with more pending trxs first
+func sortSyncQueue(queue []string, pending map[string]int) {
+	sort.SliceStable(queue, func(i, j int) bool {
+		if pending[queue[i]] != pending[queue[j]] {
+			return pending[queue[i]] > pending[queue[j]]
+		}
+		return queue[i] < queue[j]
+	})
+}
+
+// promoteSyncQueue starts sync of queued groups until the limit is reached, caller should hold syncMu
+func (groupmgr *GroupMgr) promoteSyncQueue() {
+	for len(groupmgr.syncQueue) > 0 && len(groupmgr.syncing) < groupmgr.maxSyncing {
+		groupId := groupmgr.syncQueue[0]
+		groupmgr.syncQueue = groupmgr.syncQueue[1:]
+
+		grp, ok := groupmgr.Groups[groupId]
+		if !ok {
+			continue
+		}
+		groupMgr_log.Debugf("Start sync group: <%s>, <%d> groups in queue", groupId, len(groupmgr.syncQueue))
+		started := time.Now()
+		groupmgr.syncing[groupId] = started
+		time.AfterFunc(SyncSlotTimeout, func() {
+			groupmgr.expireSyncSlot(groupId, started)
+		})
+		grp.StartSync(false)
+	}
+}
+
+// expireSyncSlot gives the slot of a group which does not catch up in time to the next queued group,
+// the group keeps syncing without holding a slot
+func (groupmgr *GroupMgr) expireSyncSlot(groupId string, started time.Time) {
+	groupmgr.syncMu.Lock()
+	defer groupmgr.syncMu.Unlock()
+
+	if s, ok := groupmgr.syncing[groupId]; !ok || !s.Equal(started) {
+		return
+	}
+	delete(groupmgr.syncing, groupId)
+	groupMgr_log.Warningf("group <%s> does not catch up in <%s>, release its sync slot", groupId, SyncSlotTimeout)
+	groupmgr.promoteSyncQueue()
+}
+
+// onSyncCaughtUp frees the sync slot of the group and starts the next queued group
+func (groupmgr *GroupMgr) onSyncCaughtUp(groupId string) {
+	groupmgr.syncMu.Lock()
+	defer groupmgr.syncMu.Unlock()
+
+	if _, ok := groupmgr.syncing[groupId]; !ok {
+		return
+	}
+	delete(groupmgr.syncing, groupId)
+	groupMgr_log.Debugf("group <%s> caught up", groupId)
+	groupmgr.promoteSyncQueue()
+}
+
+// GetSyncQueuePosition returns the 1-based position of the group in sync queue, 0 if not queued
+func (groupmgr *GroupMgr) GetSyncQueuePosition(groupId string) int {
+	groupmgr.syncMu.Lock()
+	defer groupmgr.syncMu.Unlock()
+
+	for i, id := range groupmgr.syncQueue {
+		if id == groupId {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// GetSyncQueue returns the groups waiting to start sync in order
+func (groupmgr *GroupMgr) GetSyncQueue() []string {
+	groupmgr.syncMu.Lock()
+	defer groupmgr.syncMu.Unlock()
+	return append([]string{}, groupmgr.syncQueue...)
+}
+
+// GetSyncingCount returns the number of the groups catching up
+func (groupmgr *GroupMgr) GetSyncingCount() int {
+	groupmgr.syncMu.Lock()
+	defer groupmgr.syncMu.Unlock()
+	return len(groupmgr.syncing)
+}
+
 func (groupmgr *GroupMgr) StopSyncAllGroups() error {
 	groupMgr_log.Debug("StopSyncAllGroup called")
+	groupmgr.syncMu.Lock()
+	groupmgr.syncQueue = nil
+	groupmgr.syncing = make(map[string]time.Time)
+	groupmgr.syncMu.Unlock()
+
 	for _, grp := range groupMgr.Groups {
 		groupMgr_log.Debugf("Stop sync group: <%s>", grp.Item.GroupId)
 		grp.StopSync()
@@ -75,6 +269,24 @@ func (groupmgr *GroupMgr) StopSyncAllGroups() error {
 	return nil
 }
 
+// PauseSync stops fetching blocks of the group, other groups keep syncing
+func (groupmgr *GroupMgr) PauseSync(groupId string) error {
+	grp, ok := groupmgr.Groups[groupId]
+	if !ok {
+		return fmt.Errorf("group not exist: %s", groupId)
+	}
+	return grp.PauseSync()
+}
+
+// ResumeSync starts fetching blocks of a paused group
+func (groupmgr *GroupMgr) ResumeSync(groupId string) error {
+	grp, ok := groupmgr.Groups[groupId]
+	if !ok {
+		return fmt.Errorf("group not exist: %s", groupId)
+	}
+	return grp.ResumeSync()
+}
+
 func (groupmgr *GroupMgr) TeardownAllGroups() {
 	groupMgr_log.Debug("Release called")
 	for groupId, group := range groupmgr.Groups {
@@ -83,6 +295,48 @@ func (groupmgr *GroupMgr) TeardownAllGroups() {
 	}
 }
 
+// ShutdownAllGroups stop sync and teardown all groups,
+// returns groups which are not torn down when ctx is done
+func (groupmgr *GroupMgr) ShutdownAllGroups(ctx context.Context) []string {
+	groupMgr_log.Debug("ShutdownAllGroups called")
+
+	var mu sync.Mutex
+	pending := map[string]bool{}
+	groups := make([]*Group, 0, len(groupmgr.Groups))
+	for groupId, group := range groupmgr.Groups {
+		pending[groupId] = true
+		groups = append(groups, group)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		groupmgr.StopSyncAllGroups()
+		for _, group := range groups {
+			groupMgr_log.Debugf("group: <%s> teardown", group.Item.GroupId)
+			group.Teardown()
+
+			mu.Lock()
+			delete(pending, group.Item.GroupId)
+			mu.Unlock()
+		}
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	failed := make([]string, 0, len(pending))
+	for groupId := range pending {
+		failed = append(failed, groupId)
+	}
+	return failed
+}
+
 func (groupmgr *GroupMgr) GetGroupItem(groupId string) (*quorumpb.GroupItem, error) {
 	if grp, ok := groupmgr.Groups[groupId]; ok {
 		return grp.Item, nil
@@ -96,3 +350,37 @@ func (groupmgr *GroupMgr) GetGroup(groupId string) (chaindef.GroupIface, error)
 	}
 	return nil, fmt.Errorf("group not exist: %s", groupId)
 }
+
+// StartPublishQueueWatcher resends the trxs sent by this node which are not applied in time, until ctx is done.
+// Up to workers groups are resent at the same time, the trxs of a group are resent in order by one worker.
+func (groupMgr *GroupMgr) StartPublishQueueWatcher(ctx context.Context, interval time.Duration, workers int) {
+	w := newPublishWorkers(workers)
+	groupMgr.pubWorkers.Store(w)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				chains := map[string]*Chain{}
+				for groupId, grp := range groupMgr.Groups {
+					chains[groupId] = grp.ChainCtx
+				}
+				for groupId, chain := range chains {
+					w.dispatch(ctx, groupId, chain.retryPublish)
+				}
+			}
+		}
+	}()
+}
+
+// GetPublishWorkers returns the number of the publish queue workers and the number of them resending trxs
+func (groupMgr *GroupMgr) GetPublishWorkers() (int, int) {
+	w, ok := groupMgr.pubWorkers.Load().(*publishWorkers)
+	if !ok {
+		return 0, 0
+	}
+	return cap(w.sem), w.active()
+}
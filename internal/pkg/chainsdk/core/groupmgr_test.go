@@ -0,0 +1,61 @@
+package chain
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSortSyncQueue(t *testing.T) {
+	queue := []string{"g3", "g1", "g2", "g4"}
+	pending := map[string]int{"g2": 1, "g4": 5}
+
+	sortSyncQueue(queue, pending)
+	if !reflect.DeepEqual(queue, []string{"g4", "g2", "g1", "g3"}) {
+		t.Errorf("groups with pending trxs should be synced first: %v", queue)
+	}
+}
+
+func TestSyncQueueCaughtUp(t *testing.T) {
+	mgr := &GroupMgr{Groups: map[string]*Group{}, syncing: map[string]time.Time{"g1": time.Now()}, maxSyncing: 1}
+	mgr.syncQueue = []string{"g2", "g3"}
+
+	if pos := mgr.GetSyncQueuePosition("g3"); pos != 2 {
+		t.Errorf("expect g3 at position 2, got %d", pos)
+	}
+
+	// queued groups which are not loaded anymore are dropped
+	mgr.onSyncCaughtUp("g1")
+	if len(mgr.syncing) != 0 || len(mgr.GetSyncQueue()) != 0 {
+		t.Errorf("unexpected syncing %v or queue %v", mgr.syncing, mgr.GetSyncQueue())
+	}
+}
+
+func TestSyncSlotExpire(t *testing.T) {
+	started := time.Now()
+	mgr := &GroupMgr{Groups: map[string]*Group{}, syncing: map[string]time.Time{"g1": started}, maxSyncing: 1}
+
+	// a slot taken again is not released by the timer of the former one
+	mgr.expireSyncSlot("g1", started.Add(-time.Minute))
+	if mgr.GetSyncingCount() != 1 {
+		t.Errorf("slot should be kept")
+	}
+	mgr.expireSyncSlot("g1", started)
+	if mgr.GetSyncingCount() != 0 {
+		t.Errorf("slot should be released after timeout")
+	}
+}
+
+func TestReleaseSync(t *testing.T) {
+	mgr := &GroupMgr{Groups: map[string]*Group{}, syncing: map[string]time.Time{"g1": time.Now()}, maxSyncing: 1}
+	mgr.syncQueue = []string{"g2", "g3"}
+
+	mgr.ReleaseSync("g2")
+	if !reflect.DeepEqual(mgr.GetSyncQueue(), []string{"g3"}) || mgr.GetSyncingCount() != 1 {
+		t.Errorf("unexpected syncing %v or queue %v", mgr.syncing, mgr.GetSyncQueue())
+	}
+	mgr.ReleaseSync("g1")
+	if mgr.GetSyncingCount() != 0 {
+		t.Errorf("slot of g1 should be released")
+	}
+}
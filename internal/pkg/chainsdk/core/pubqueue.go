@@ -0,0 +1,560 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	PUBQUEUE_PENDING = "PENDING" // sent, waiting to be applied or to be resent
+	PUBQUEUE_DEAD    = "DEAD"    // retries exhausted, kept aside until requeued
+)
+
+// PublishRetryPolicy controls how a trx which is not applied in time is resent,
+// the n-th retry waits BaseBackoff * 2^(n-1), capped by MaxBackoff
+type PublishRetryPolicy struct {
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+var DefaultPublishRetryPolicy = PublishRetryPolicy{
+	MaxAttempts: 5,
+	BaseBackoff: 10 * time.Second,
+	MaxBackoff:  5 * time.Minute,
+}
+
+// SetDefaultPublishRetryPolicy sets the node default policy, zero values keep the builtin default
+func SetDefaultPublishRetryPolicy(maxAttempts int, baseBackoff, maxBackoff time.Duration) {
+	policy := &PublishRetryPolicy{MaxAttempts: maxAttempts, BaseBackoff: baseBackoff, MaxBackoff: maxBackoff}
+	DefaultPublishRetryPolicy = policy.withDefault()
+}
+
+// withDefault fills the zero fields of policy by the node default
+func (policy *PublishRetryPolicy) withDefault() PublishRetryPolicy {
+	res := DefaultPublishRetryPolicy
+	if policy == nil {
+		return res
+	}
+	if policy.MaxAttempts > 0 {
+		res.MaxAttempts = policy.MaxAttempts
+	}
+	if policy.BaseBackoff > 0 {
+		res.BaseBackoff = policy.BaseBackoff
+	}
+	if policy.MaxBackoff > 0 {
+		res.MaxBackoff = policy.MaxBackoff
+	}
+	if res.MaxBackoff < res.BaseBackoff {
+		res.MaxBackoff = res.BaseBackoff
+	}
+	return res
+}
+
+// IdempotencyKeyWindow is how long the idempotency key of a publish is kept after the publish
+var IdempotencyKeyWindow = 24 * time.Hour
+
+// ErrIdempotencyKeyReused is returned if an idempotency key in the key window is used with another payload
+var ErrIdempotencyKeyReused = errors.New("idempotency key is already used with a different payload")
+
+// SetIdempotencyKeyWindow sets the node key window, zero keeps the builtin default
+func SetIdempotencyKeyWindow(window time.Duration) {
+	if window > 0 {
+		IdempotencyKeyWindow = window
+	}
+}
+
+func (policy *PublishRetryPolicy) backoff(attempts int) time.Duration {
+	d := policy.BaseBackoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= policy.MaxBackoff || d <= 0 {
+			return policy.MaxBackoff
+		}
+	}
+	return d
+}
+
+// PublishEntry is a trx sent by this node and not applied yet
+type PublishEntry struct {
+	Trx         *quorumpb.Trx
+	State       string
+	Attempts    int
+	LastAttempt time.Time
+	NextRetry   time.Time
+	LastError   string
+	Policy      PublishRetryPolicy
+
+	IdempotencyKey string // the client key of the publish, empty if the client sent none
+
+	sending bool // a resend is in flight
+}
+
+// publishStore persists the publish queue entries and the idempotency keys, so that the queue is replayed
+// and the keys are still deduplicated after a restart
+type publishStore interface {
+	save(entry *PublishEntry) error
+	remove(trxId string) error
+	saveKey(key string, record *idempotencyRecord) error
+	removeKey(key string) error
+}
+
+// publishRecord is the persisted form of a PublishEntry
+type publishRecord struct {
+	Trx            []byte
+	State          string
+	Attempts       int
+	LastAttempt    time.Time
+	NextRetry      time.Time
+	LastError      string
+	Policy         PublishRetryPolicy
+	IdempotencyKey string
+}
+
+func encodePublishEntry(entry *PublishEntry) ([]byte, error) {
+	trx, err := proto.Marshal(entry.Trx)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&publishRecord{
+		Trx:            trx,
+		State:          entry.State,
+		Attempts:       entry.Attempts,
+		LastAttempt:    entry.LastAttempt,
+		NextRetry:      entry.NextRetry,
+		LastError:      entry.LastError,
+		Policy:         entry.Policy,
+		IdempotencyKey: entry.IdempotencyKey,
+	})
+}
+
+func decodePublishEntry(data []byte) (*PublishEntry, error) {
+	record := &publishRecord{}
+	if err := json.Unmarshal(data, record); err != nil {
+		return nil, err
+	}
+	trx := &quorumpb.Trx{}
+	if err := proto.Unmarshal(record.Trx, trx); err != nil {
+		return nil, err
+	}
+	return &PublishEntry{
+		Trx:            trx,
+		State:          record.State,
+		Attempts:       record.Attempts,
+		LastAttempt:    record.LastAttempt,
+		NextRetry:      record.NextRetry,
+		LastError:      record.LastError,
+		Policy:         record.Policy,
+		IdempotencyKey: record.IdempotencyKey,
+	}, nil
+}
+
+// idempotencyKey maps a client key to the hash of the payload and the trx published with it,
+// done is closed once the publish returns
+type idempotencyKey struct {
+	hash    string
+	trxId   string
+	expires time.Time
+	done    chan struct{}
+}
+
+// idempotencyRecord is the persisted form of an idempotency key which is published
+type idempotencyRecord struct {
+	PayloadHash string
+	TrxId       string
+	Expires     time.Time
+}
+
+func encodeIdempotencyRecord(record *idempotencyRecord) ([]byte, error) {
+	return json.Marshal(record)
+}
+
+func decodeIdempotencyRecord(data []byte) (*idempotencyRecord, error) {
+	record := &idempotencyRecord{}
+	if err := json.Unmarshal(data, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+type pubQueue struct {
+	mu        sync.Mutex
+	entries   map[string]*PublishEntry
+	keys      map[string]*idempotencyKey // kept after the trxs are applied, until they expire
+	lastSweep time.Time
+	store     publishStore // nil if the queue is not persisted
+}
+
+func newPubQueue(store publishStore) *pubQueue {
+	return &pubQueue{entries: make(map[string]*PublishEntry), keys: make(map[string]*idempotencyKey), store: store}
+}
+
+// load restores the persisted entries, the trxs are resent by the watcher when they are due
+func (q *pubQueue) load(entries []*PublishEntry) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, entry := range entries {
+		entry.sending = false
+		q.entries[entry.Trx.TrxId] = entry
+	}
+}
+
+// loadKeys restores the persisted idempotency keys, the expired ones are removed
+func (q *pubQueue) loadKeys(records map[string]*idempotencyRecord, now time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for key, record := range records {
+		if now.After(record.Expires) {
+			q.unpersistKey(key)
+			continue
+		}
+		done := make(chan struct{})
+		close(done)
+		q.keys[key] = &idempotencyKey{hash: record.PayloadHash, trxId: record.TrxId, expires: record.Expires, done: done}
+	}
+}
+
+// persistKey saves the published key, caller must hold the lock
+func (q *pubQueue) persistKey(key string, k *idempotencyKey) {
+	if q.store == nil {
+		return
+	}
+	if err := q.store.saveKey(key, &idempotencyRecord{PayloadHash: k.hash, TrxId: k.trxId, Expires: k.expires}); err != nil {
+		chain_log.Warningf("save idempotency key <%s> failed: %s", key, err)
+	}
+}
+
+// unpersistKey removes the saved key, caller must hold the lock
+func (q *pubQueue) unpersistKey(key string) {
+	if q.store == nil {
+		return
+	}
+	if err := q.store.removeKey(key); err != nil {
+		chain_log.Warningf("remove idempotency key <%s> failed: %s", key, err)
+	}
+}
+
+// persist saves the entry, caller must hold the lock
+func (q *pubQueue) persist(entry *PublishEntry) {
+	if q.store == nil {
+		return
+	}
+	if err := q.store.save(entry); err != nil {
+		chain_log.Warningf("save publish entry <%s> failed: %s", entry.Trx.TrxId, err)
+	}
+}
+
+// unpersist removes the saved entry, caller must hold the lock
+func (q *pubQueue) unpersist(trxId string) {
+	if q.store == nil {
+		return
+	}
+	if err := q.store.remove(trxId); err != nil {
+		chain_log.Warningf("remove publish entry <%s> failed: %s", trxId, err)
+	}
+}
+
+// publishOnce calls publish for the first use of the key in the key window and records the hash of the payload
+// and the trx id it returns. A later use of the key with the same hash gets the recorded trx id, and waits for
+// the first one if it is still in flight, a later use with another hash gets ErrIdempotencyKeyReused.
+// A failed publish does not record the key, so that the client can retry with it.
+func (q *pubQueue) publishOnce(key, hash string, now time.Time, publish func() (string, error)) (string, bool, error) {
+	for {
+		q.mu.Lock()
+		if now.Sub(q.lastSweep) > time.Minute {
+			for k, v := range q.keys {
+				if v.trxId != "" && now.After(v.expires) {
+					delete(q.keys, k)
+					q.unpersistKey(k)
+				}
+			}
+			q.lastSweep = now
+		}
+		k, ok := q.keys[key]
+		if ok && (k.trxId == "" || !now.After(k.expires)) {
+			if k.hash != hash {
+				q.mu.Unlock()
+				return "", false, ErrIdempotencyKeyReused
+			}
+			q.mu.Unlock()
+			<-k.done
+			if k.trxId == "" {
+				continue // the first publish failed, try again
+			}
+			return k.trxId, true, nil
+		}
+		k = &idempotencyKey{hash: hash, done: make(chan struct{})}
+		q.keys[key] = k
+		q.mu.Unlock()
+
+		trxId, err := publish()
+
+		q.mu.Lock()
+		if err != nil || trxId == "" {
+			delete(q.keys, key)
+		} else {
+			k.trxId = trxId
+			k.expires = now.Add(IdempotencyKeyWindow)
+			q.persistKey(key, k)
+			if entry, ok := q.entries[trxId]; ok {
+				entry.IdempotencyKey = key
+				q.persist(entry)
+			}
+		}
+		close(k.done)
+		q.mu.Unlock()
+		return trxId, false, err
+	}
+}
+
+// add records the first send of the trx
+func (q *pubQueue) add(trx *quorumpb.Trx, policy *PublishRetryPolicy, now time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, ok := q.entries[trx.TrxId]
+	if !ok {
+		entry = &PublishEntry{Trx: trx, State: PUBQUEUE_PENDING, Policy: policy.withDefault()}
+		q.entries[trx.TrxId] = entry
+	}
+	q.attempted(entry, nil, now)
+	q.persist(entry)
+}
+
+// attempted updates the entry after a send, caller must hold the lock
+func (q *pubQueue) attempted(entry *PublishEntry, sendErr error, now time.Time) {
+	entry.Attempts++
+	entry.LastAttempt = now
+	entry.LastError = ""
+	if sendErr != nil {
+		entry.LastError = sendErr.Error()
+	}
+	entry.NextRetry = now.Add(entry.Policy.backoff(entry.Attempts))
+}
+
+// remove deletes the entry of the applied trx, it returns false if the trx is not in the queue
+func (q *pubQueue) remove(trxId string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.entries[trxId]; !ok {
+		return false
+	}
+	delete(q.entries, trxId)
+	q.unpersist(trxId)
+	return true
+}
+
+// due returns the pending trxs to resend oldest first, entries which exhausted their attempts are moved to dead-letter
+func (q *pubQueue) due(now time.Time) []*quorumpb.Trx {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	trxs := []*quorumpb.Trx{}
+	for _, entry := range q.entries {
+		if entry.State != PUBQUEUE_PENDING || entry.sending || now.Before(entry.NextRetry) {
+			continue
+		}
+		if entry.Attempts >= entry.Policy.MaxAttempts {
+			entry.State = PUBQUEUE_DEAD
+			q.persist(entry)
+			continue
+		}
+		entry.sending = true
+		entry.LastError = "" // the error of the last attempt is stale once the trx is resent
+		trxs = append(trxs, entry.Trx)
+	}
+	sort.Slice(trxs, func(i, j int) bool {
+		return trxs[i].TimeStamp < trxs[j].TimeStamp
+	})
+	return trxs
+}
+
+// resent records the result of a resend, the entry may have been cancelled or applied meanwhile
+func (q *pubQueue) resent(trxId string, sendErr error, now time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if entry, ok := q.entries[trxId]; ok && entry.sending {
+		entry.sending = false
+		q.attempted(entry, sendErr, now)
+		q.persist(entry)
+	}
+}
+
+// claim marks the entry as being resent right now, a dead-letter entry gets one more attempt
+func (q *pubQueue) claim(trxId string) (*quorumpb.Trx, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	entry, ok := q.entries[trxId]
+	if !ok {
+		return nil, fmt.Errorf("trx %s is not in publish queue", trxId)
+	}
+	if entry.sending {
+		return nil, fmt.Errorf("trx %s is being sent", trxId)
+	}
+	if entry.State == PUBQUEUE_DEAD {
+		entry.State = PUBQUEUE_PENDING
+		if entry.Attempts >= entry.Policy.MaxAttempts {
+			entry.Attempts = entry.Policy.MaxAttempts - 1
+		}
+		q.persist(entry)
+	}
+	entry.sending = true
+	entry.LastError = ""
+	return entry.Trx, nil
+}
+
+// cancel removes the entry so that it is not resent anymore, a send in flight is not recorded
+func (q *pubQueue) cancel(trxId string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.entries[trxId]; !ok {
+		return false
+	}
+	delete(q.entries, trxId)
+	q.unpersist(trxId)
+	return true
+}
+
+// requeue moves a dead-letter entry back to pending with fresh attempts
+func (q *pubQueue) requeue(trxId string, now time.Time) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	entry, ok := q.entries[trxId]
+	if !ok || entry.State != PUBQUEUE_DEAD {
+		return false
+	}
+	entry.State = PUBQUEUE_PENDING
+	entry.Attempts = 0
+	entry.NextRetry = now
+	q.persist(entry)
+	return true
+}
+
+// get returns a copy of the entry, nil if the trx is not in the queue
+func (q *pubQueue) get(trxId string) *PublishEntry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	entry, ok := q.entries[trxId]
+	if !ok {
+		return nil
+	}
+	e := *entry
+	return &e
+}
+
+func (q *pubQueue) has(trxId string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	_, ok := q.entries[trxId]
+	return ok
+}
+
+func (q *pubQueue) count() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.entries)
+}
+
+// depth returns the number of the entries per state
+func (q *pubQueue) depth() map[string]int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	res := map[string]int{PUBQUEUE_PENDING: 0, PUBQUEUE_DEAD: 0}
+	for _, entry := range q.entries {
+		res[entry.State]++
+	}
+	return res
+}
+
+// inFlight returns the number of the trxs being resent
+func (q *pubQueue) inFlight() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	n := 0
+	for _, entry := range q.entries {
+		if entry.sending {
+			n++
+		}
+	}
+	return n
+}
+
+// list returns copies of the entries in state, or all entries if state is empty, oldest first
+func (q *pubQueue) list(state string) []*PublishEntry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	res := []*PublishEntry{}
+	for _, entry := range q.entries {
+		if state != "" && entry.State != state {
+			continue
+		}
+		e := *entry
+		res = append(res, &e)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Trx.TimeStamp < res[j].Trx.TimeStamp
+	})
+	return res
+}
+
+// publishWorkers resends the publish queues of the groups in parallel, a group is resent by one worker at a time,
+// so that the trxs of a group are not reordered
+type publishWorkers struct {
+	sem  chan struct{}
+	mu   sync.Mutex
+	busy map[string]bool
+}
+
+func newPublishWorkers(n int) *publishWorkers {
+	if n < 1 {
+		n = 1
+	}
+	return &publishWorkers{sem: make(chan struct{}, n), busy: make(map[string]bool)}
+}
+
+// dispatch runs fn of the group on a free worker, it waits for a free worker until ctx is done,
+// and skips the group if its last run has not finished
+func (w *publishWorkers) dispatch(ctx context.Context, groupId string, fn func()) bool {
+	w.mu.Lock()
+	if w.busy[groupId] {
+		w.mu.Unlock()
+		return false
+	}
+	w.busy[groupId] = true
+	w.mu.Unlock()
+
+	select {
+	case w.sem <- struct{}{}:
+	case <-ctx.Done():
+		w.release(groupId)
+		return false
+	}
+	go func() {
+		defer func() {
+			<-w.sem
+			w.release(groupId)
+		}()
+		fn()
+	}()
+	return true
+}
+
+func (w *publishWorkers) release(groupId string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.busy, groupId)
+}
+
+// active returns the number of the groups being resent
+func (w *publishWorkers) active() int {
+	return len(w.sem)
+}
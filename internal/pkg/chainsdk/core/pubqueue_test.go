@@ -0,0 +1,459 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+func TestPublishRetryBackoff(t *testing.T) {
+	policy := (&PublishRetryPolicy{MaxAttempts: 3, BaseBackoff: time.Second, MaxBackoff: 5 * time.Second}).withDefault()
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := policy.backoff(i + 1); got != w {
+			t.Errorf("attempt %d: want backoff %s, got %s", i+1, w, got)
+		}
+	}
+
+	var nilPolicy *PublishRetryPolicy
+	if nilPolicy.withDefault() != DefaultPublishRetryPolicy {
+		t.Errorf("nil policy should use the default")
+	}
+}
+
+func TestPubQueueDeadLetter(t *testing.T) {
+	q := newPubQueue(nil)
+	now := time.Now()
+	trx := &quorumpb.Trx{TrxId: "9e54c173-c1dd-429d-91fa-a6b43c14da77"}
+	q.add(trx, &PublishRetryPolicy{MaxAttempts: 2, BaseBackoff: time.Second, MaxBackoff: time.Second}, now)
+
+	if due := q.due(now); len(due) != 0 {
+		t.Fatalf("trx should not be resent before backoff")
+	}
+	now = now.Add(time.Second)
+	if due := q.due(now); len(due) != 1 {
+		t.Fatalf("trx should be resent after backoff")
+	}
+	q.resent(trx.TrxId, errors.New("no peer"), now)
+
+	now = now.Add(time.Second)
+	if due := q.due(now); len(due) != 0 {
+		t.Fatalf("trx exhausted retries should not be resent")
+	}
+	dead := q.list(PUBQUEUE_DEAD)
+	if len(dead) != 1 || dead[0].Attempts != 2 || dead[0].LastError != "no peer" {
+		t.Fatalf("trx should be in dead-letter, got %+v", dead)
+	}
+	if depth := q.depth(); depth[PUBQUEUE_PENDING] != 0 || depth[PUBQUEUE_DEAD] != 1 {
+		t.Errorf("unexpected queue depth: %v", depth)
+	}
+
+	if !q.requeue(trx.TrxId, now) {
+		t.Fatalf("requeue failed")
+	}
+	if q.requeue(trx.TrxId, now) {
+		t.Errorf("pending trx should not be requeued")
+	}
+	if due := q.due(now); len(due) != 1 {
+		t.Errorf("requeued trx should be resent immediately")
+	}
+
+	q.remove(trx.TrxId)
+	if q.count() != 0 {
+		t.Errorf("applied trx should be removed")
+	}
+}
+
+func TestPubQueueCancelInFlight(t *testing.T) {
+	q := newPubQueue(nil)
+	now := time.Now()
+	trx := &quorumpb.Trx{TrxId: "22d5c38d-5921-4b75-8562-c110dcfd5ee8"}
+	q.add(trx, nil, now)
+
+	if _, err := q.claim(trx.TrxId); err != nil {
+		t.Fatalf("claim failed: %s", err)
+	}
+	if _, err := q.claim(trx.TrxId); err == nil {
+		t.Errorf("trx in flight should not be claimed twice")
+	}
+	if due := q.due(now.Add(time.Hour)); len(due) != 0 {
+		t.Errorf("trx in flight should not be resent by the watcher")
+	}
+
+	if !q.cancel(trx.TrxId) {
+		t.Fatalf("cancel failed")
+	}
+	// the send in flight finishes after cancel
+	q.resent(trx.TrxId, nil, now)
+	if q.count() != 0 {
+		t.Errorf("cancelled trx should not come back, got %+v", q.list(""))
+	}
+	if q.cancel(trx.TrxId) {
+		t.Errorf("cancel of unknown trx should fail")
+	}
+}
+
+func TestPubQueueResendClearsError(t *testing.T) {
+	q := newPubQueue(nil)
+	now := time.Now()
+	trx := &quorumpb.Trx{TrxId: "0b3c2f5e-30b5-4f1b-8a36-7d8e0c1d4a52"}
+	q.add(trx, &PublishRetryPolicy{MaxAttempts: 3, BaseBackoff: time.Second, MaxBackoff: time.Second}, now)
+
+	now = now.Add(time.Second)
+	q.due(now)
+	q.resent(trx.TrxId, errors.New("no peer"), now)
+	if e := q.get(trx.TrxId); e.LastError != "no peer" {
+		t.Fatalf("failed resend should be recorded, got %q", e.LastError)
+	}
+
+	// the watcher resends it, the error of the last attempt is not reported while the resend is in flight
+	now = now.Add(time.Second)
+	if due := q.due(now); len(due) != 1 {
+		t.Fatalf("trx should be resent after backoff")
+	}
+	if e := q.get(trx.TrxId); e.LastError != "" {
+		t.Errorf("resending trx should have no error, got %q", e.LastError)
+	}
+	q.resent(trx.TrxId, nil, now)
+	if e := q.get(trx.TrxId); e.LastError != "" || e.Attempts != 3 {
+		t.Errorf("successful resend should clear the error, got %+v", e)
+	}
+
+	// a manual resend of a dead-letter trx clears the error as well
+	dead := &quorumpb.Trx{TrxId: "5f2a9d1c-6b7e-4c3a-9e2f-1a8b7c6d5e4f"}
+	q.add(dead, &PublishRetryPolicy{MaxAttempts: 2, BaseBackoff: time.Second, MaxBackoff: time.Second}, now)
+	now = now.Add(time.Second)
+	q.due(now)
+	q.resent(dead.TrxId, errors.New("no peer"), now)
+	q.due(now.Add(time.Second))
+	if e := q.get(dead.TrxId); e.State != PUBQUEUE_DEAD || e.LastError != "no peer" {
+		t.Fatalf("trx should be in dead-letter with the error, got %+v", e)
+	}
+	if _, err := q.claim(dead.TrxId); err != nil {
+		t.Fatal(err)
+	}
+	if e := q.get(dead.TrxId); e.State != PUBQUEUE_PENDING || e.LastError != "" {
+		t.Errorf("claimed trx should be pending with no error, got %+v", e)
+	}
+}
+
+func TestPubQueueDueInOrder(t *testing.T) {
+	q := newPubQueue(nil)
+	now := time.Now()
+	policy := &PublishRetryPolicy{MaxAttempts: 3, BaseBackoff: time.Second, MaxBackoff: time.Second}
+	for i := 10; i > 0; i-- {
+		q.add(&quorumpb.Trx{TrxId: fmt.Sprintf("trx-%d", i), TimeStamp: int64(i)}, policy, now)
+	}
+
+	due := q.due(now.Add(time.Second))
+	if len(due) != 10 {
+		t.Fatalf("expect 10 trxs to resend, got %d", len(due))
+	}
+	for i, trx := range due {
+		if trx.TimeStamp != int64(i+1) {
+			t.Fatalf("expect trxs resent oldest first, got %d at %d", trx.TimeStamp, i)
+		}
+	}
+	if n := q.inFlight(); n != 10 {
+		t.Errorf("expect 10 trxs in flight, got %d", n)
+	}
+	q.resent(due[0].TrxId, nil, now)
+	if n := q.inFlight(); n != 9 {
+		t.Errorf("expect 9 trxs in flight, got %d", n)
+	}
+}
+
+func TestPubQueuePublishOnce(t *testing.T) {
+	q := newPubQueue(nil)
+	now := time.Now()
+
+	var published int32
+	publish := func() (string, error) {
+		n := atomic.AddInt32(&published, 1)
+		trx := &quorumpb.Trx{TrxId: fmt.Sprintf("trx-%d", n)}
+		q.add(trx, nil, now)
+		return trx.TrxId, nil
+	}
+
+	// concurrent retries of the same key publish once
+	var wg sync.WaitGroup
+	ids := make([]string, 8)
+	for i := range ids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ids[i], _, _ = q.publishOnce("key-1", "hash-1", now, publish)
+		}(i)
+	}
+	wg.Wait()
+	for _, id := range ids {
+		if id != "trx-1" {
+			t.Fatalf("want trx-1 for all retries, got %v", ids)
+		}
+	}
+	if entries := q.list(""); len(entries) != 1 || entries[0].IdempotencyKey != "key-1" {
+		t.Fatalf("the key should be recorded on the entry, got %+v", entries)
+	}
+
+	// the key is kept after the trx is applied
+	q.remove("trx-1")
+	if id, dup, _ := q.publishOnce("key-1", "hash-1", now.Add(time.Hour), publish); id != "trx-1" || !dup {
+		t.Errorf("want trx-1 after apply, got %s", id)
+	}
+
+	// the key is refused with another payload in the window
+	if id, _, err := q.publishOnce("key-1", "hash-other", now.Add(time.Hour), publish); err != ErrIdempotencyKeyReused || id != "" {
+		t.Errorf("want ErrIdempotencyKeyReused for another payload, got %s %v", id, err)
+	}
+
+	// a failed publish does not keep the key
+	if _, _, err := q.publishOnce("key-2", "hash-2", now, func() (string, error) { return "", errors.New("no peer") }); err == nil {
+		t.Fatalf("want publish error")
+	}
+	if id, dup, _ := q.publishOnce("key-2", "hash-2", now, publish); id != "trx-2" || dup {
+		t.Errorf("want a new trx after the failed publish, got %s", id)
+	}
+
+	// an expired key publishes again
+	later := now.Add(IdempotencyKeyWindow + time.Minute)
+	if id, dup, _ := q.publishOnce("key-1", "hash-3", later, publish); id != "trx-3" || dup {
+		t.Errorf("want a new trx after the key window, got %s", id)
+	}
+}
+
+// memPublishStore keeps the encoded entries and keys like the chain storage does
+type memPublishStore struct {
+	entries map[string][]byte
+	keys    map[string][]byte
+}
+
+func newMemPublishStore() *memPublishStore {
+	return &memPublishStore{entries: make(map[string][]byte), keys: make(map[string][]byte)}
+}
+
+func (s *memPublishStore) save(entry *PublishEntry) error {
+	data, err := encodePublishEntry(entry)
+	if err != nil {
+		return err
+	}
+	s.entries[entry.Trx.TrxId] = data
+	return nil
+}
+
+func (s *memPublishStore) remove(trxId string) error {
+	delete(s.entries, trxId)
+	return nil
+}
+
+func (s *memPublishStore) saveKey(key string, record *idempotencyRecord) error {
+	data, err := encodeIdempotencyRecord(record)
+	if err != nil {
+		return err
+	}
+	s.keys[key] = data
+	return nil
+}
+
+func (s *memPublishStore) removeKey(key string) error {
+	delete(s.keys, key)
+	return nil
+}
+
+func (s *memPublishStore) reload(t *testing.T) *pubQueue {
+	return s.reloadAt(t, time.Now())
+}
+
+func (s *memPublishStore) reloadAt(t *testing.T, now time.Time) *pubQueue {
+	entries := []*PublishEntry{}
+	for _, data := range s.entries {
+		entry, err := decodePublishEntry(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, entry)
+	}
+	records := map[string]*idempotencyRecord{}
+	for key, data := range s.keys {
+		record, err := decodeIdempotencyRecord(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		records[key] = record
+	}
+	q := newPubQueue(s)
+	q.load(entries)
+	q.loadKeys(records, now)
+	return q
+}
+
+func TestPubQueueReplay(t *testing.T) {
+	store := newMemPublishStore()
+	q := newPubQueue(store)
+	now := time.Now()
+	policy := &PublishRetryPolicy{MaxAttempts: 3, BaseBackoff: time.Second, MaxBackoff: time.Second}
+	trx1 := &quorumpb.Trx{TrxId: "trx1", TimeStamp: 1, Data: []byte("data1")}
+	trx2 := &quorumpb.Trx{TrxId: "trx2", TimeStamp: 2}
+	q.add(trx1, policy, now)
+	q.add(trx2, policy, now)
+
+	now = now.Add(time.Second)
+	if due := q.due(now); len(due) != 2 {
+		t.Fatalf("want 2 trxs to resend, got %d", len(due))
+	}
+	// restart with trx1 in flight, only the recorded attempts are kept
+	q.resent(trx2.TrxId, errors.New("no peer"), now)
+	q.remove(trx2.TrxId)
+	if len(store.entries) != 1 {
+		t.Fatalf("applied trx should be removed from store, got %d entries", len(store.entries))
+	}
+
+	q = store.reload(t)
+	entry := q.get(trx1.TrxId)
+	if entry == nil || entry.sending || entry.Attempts != 1 || string(entry.Trx.Data) != "data1" || entry.Policy.MaxAttempts != 3 {
+		t.Fatalf("unexpected replayed entry %+v", entry)
+	}
+	if due := q.due(now); len(due) != 1 || due[0].TrxId != trx1.TrxId {
+		t.Fatalf("replayed trx should be resent, got %v", due)
+	}
+	q.resent(trx1.TrxId, errors.New("timeout"), now)
+
+	q = store.reload(t)
+	if entry := q.get(trx1.TrxId); entry == nil || entry.Attempts != 2 || entry.LastError != "timeout" {
+		t.Fatalf("resend should be persisted, got %+v", entry)
+	}
+	if !q.cancel(trx1.TrxId) || len(store.entries) != 0 {
+		t.Errorf("cancelled trx should be removed from store")
+	}
+}
+
+func TestPubQueueReplayDeadLetter(t *testing.T) {
+	store := newMemPublishStore()
+	q := newPubQueue(store)
+	now := time.Now()
+	trx := &quorumpb.Trx{TrxId: "trx1"}
+	q.add(trx, &PublishRetryPolicy{MaxAttempts: 1, BaseBackoff: time.Second, MaxBackoff: time.Second}, now)
+
+	now = now.Add(time.Second)
+	if due := q.due(now); len(due) != 0 {
+		t.Fatalf("trx exhausted retries should not be resent")
+	}
+	q = store.reload(t)
+	if dead := q.list(PUBQUEUE_DEAD); len(dead) != 1 || dead[0].Trx.TrxId != trx.TrxId {
+		t.Fatalf("dead-letter should be replayed, got %+v", dead)
+	}
+
+	if !q.requeue(trx.TrxId, now) {
+		t.Fatalf("requeue failed")
+	}
+	q = store.reload(t)
+	if entry := q.get(trx.TrxId); entry == nil || entry.State != PUBQUEUE_PENDING || entry.Attempts != 0 {
+		t.Fatalf("requeue should be persisted, got %+v", entry)
+	}
+
+	q.due(now.Add(time.Second))
+	q = store.reload(t)
+	if _, err := q.claim(trx.TrxId); err != nil {
+		t.Fatal(err)
+	}
+	q = store.reload(t)
+	if entry := q.get(trx.TrxId); entry == nil || entry.State != PUBQUEUE_PENDING {
+		t.Errorf("claimed dead-letter should be persisted as pending, got %+v", entry)
+	}
+}
+
+func TestPubQueueReplayIdempotencyKeys(t *testing.T) {
+	store := newMemPublishStore()
+	q := newPubQueue(store)
+	now := time.Now()
+	publish := func(trxId string) func() (string, error) {
+		return func() (string, error) {
+			q.add(&quorumpb.Trx{TrxId: trxId}, nil, now)
+			return trxId, nil
+		}
+	}
+
+	if _, _, err := q.publishOnce("key-1", "hash-1", now, publish("trx-1")); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := q.publishOnce("key-2", "hash-2", now.Add(-IdempotencyKeyWindow), publish("trx-2")); err != nil {
+		t.Fatal(err)
+	}
+	q.remove("trx-1")
+	if len(store.keys) != 2 {
+		t.Fatalf("want 2 keys saved, got %d", len(store.keys))
+	}
+
+	// the keys survive a restart, and the applied trx is still deduplicated
+	q = store.reloadAt(t, now.Add(time.Minute))
+	if id, dup, err := q.publishOnce("key-1", "hash-1", now.Add(time.Minute), publish("trx-3")); err != nil || id != "trx-1" || !dup {
+		t.Errorf("want trx-1 after restart, got %s %v %v", id, dup, err)
+	}
+	if _, _, err := q.publishOnce("key-1", "hash-other", now.Add(time.Minute), publish("trx-3")); err != ErrIdempotencyKeyReused {
+		t.Errorf("want ErrIdempotencyKeyReused after restart, got %v", err)
+	}
+
+	// the expired key is dropped on load
+	if _, ok := store.keys["key-2"]; ok {
+		t.Errorf("expired key should be removed from store")
+	}
+	if id, dup, err := q.publishOnce("key-2", "hash-other", now.Add(time.Minute), publish("trx-4")); err != nil || id != "trx-4" || dup {
+		t.Errorf("want a new trx for the expired key, got %s %v %v", id, dup, err)
+	}
+
+	// the sweep removes the expired keys from store
+	q.publishOnce("key-5", "hash-5", now.Add(2*IdempotencyKeyWindow), publish("trx-5"))
+	if _, ok := store.keys["key-1"]; ok {
+		t.Errorf("swept key should be removed from store")
+	}
+}
+
+func TestPublishWorkers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := newPublishWorkers(2)
+
+	release := make(chan struct{})
+	var running int32
+	var wg sync.WaitGroup
+	run := func() {
+		defer wg.Done()
+		atomic.AddInt32(&running, 1)
+		<-release
+	}
+
+	wg.Add(2)
+	if !w.dispatch(ctx, "group-a", run) || !w.dispatch(ctx, "group-b", run) {
+		t.Fatalf("expect two groups dispatched")
+	}
+	// the last run of group a has not finished
+	if w.dispatch(ctx, "group-a", run) {
+		t.Fatalf("expect a busy group skipped")
+	}
+	for atomic.LoadInt32(&running) != 2 {
+		time.Sleep(time.Millisecond)
+	}
+	if n := w.active(); n != 2 {
+		t.Errorf("expect 2 active workers, got %d", n)
+	}
+
+	// no worker is free until ctx is done
+	waitCtx, waitCancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer waitCancel()
+	if w.dispatch(waitCtx, "group-c", run) {
+		t.Fatalf("expect no free worker")
+	}
+
+	close(release)
+	wg.Wait()
+	wg.Add(1)
+	for !w.dispatch(ctx, "group-a", run) {
+		time.Sleep(time.Millisecond)
+	}
+	wg.Wait()
+}
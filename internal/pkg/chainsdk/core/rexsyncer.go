@@ -10,6 +10,7 @@ import (
 	"github.com/rumsystem/quorum/internal/pkg/chainsdk/def"
 	"github.com/rumsystem/quorum/internal/pkg/conn"
 	"github.com/rumsystem/quorum/internal/pkg/logging"
+	"github.com/rumsystem/quorum/internal/pkg/stats"
 
 	quorumpb "github.com/rumsystem/quorum/pkg/pb"
 
@@ -21,6 +22,15 @@ var REQ_BLOCKS_PER_REQUEST = int32(10) // ask for n blocks per request
 var SYNC_BLOCK_TASK_TIMEOUT = 4 * 1000 // in millseconds
 var SYNC_BLOCK_FREQ_ADJ = 5 * 1000     // in millseconds
 var MAXIMUM_DELAY_DURATION = 60 * 1000 // is millseconds
+var REX_RESULT_QUEUE_SIZE = 16         // sync results buffered, the others are dropped until the buffered ones are applied
+
+// SetRexResultQueueSize sets the sync results buffered per group, it should be called before the groups are loaded.
+// The queue is always bounded, n <= 0 keeps the default 16 though the rex service takes 0 as no limit
+func SetRexResultQueueSize(n int) {
+	if n > 0 {
+		REX_RESULT_QUEUE_SIZE = n
+	}
+}
 
 var rex_syncer_log = logging.Logger("rsyncer")
 
@@ -35,6 +45,7 @@ const (
 	IDLE SyncerStatus = iota
 	SYNCING
 	CLOSED
+	PAUSED
 )
 
 type SyncTask struct {
@@ -55,6 +66,7 @@ type RexSyncer struct {
 	resultq chan *SyncResult
 
 	Status            SyncerStatus
+	started           bool
 	mustatus          sync.RWMutex
 	CurrRetryCount    uint
 	CurrentDely       int
@@ -75,7 +87,7 @@ func NewRexSyncer(groupid string, nodename string, cdnIface def.ChainDataSyncIfa
 
 	rex_syncer_log.Debugf("<%s> Init rex syncer channels", rs.GroupId)
 	rs.taskq = make(chan *SyncTask)
-	rs.resultq = make(chan *SyncResult)
+	rs.resultq = make(chan *SyncResult, REX_RESULT_QUEUE_SIZE)
 
 	rs.Status = IDLE
 	rs.CurrentTask = nil
@@ -95,6 +107,7 @@ func (rs *RexSyncer) GetSyncerStatus() SyncerStatus {
 
 func (rs *RexSyncer) Start() {
 	rex_syncer_log.Debugf("<%s> Start called", rs.GroupId)
+	rs.started = true
 
 	//start taskq
 	go func() {
@@ -141,6 +154,55 @@ func (rs *RexSyncer) Stop() {
 	rex_syncer_log.Debugf("<%s> rexsyncer stop success.", rs.GroupId)
 }
 
+// Pause stops fetching blocks until Resume is called, blocks are still served to other peers
+func (rs *RexSyncer) Pause() error {
+	rex_syncer_log.Debugf("<%s> Pause called", rs.GroupId)
+	rs.mustatus.Lock()
+	defer rs.mustatus.Unlock()
+
+	switch rs.Status {
+	case CLOSED:
+		return fmt.Errorf("syncer of group %s is closed", rs.GroupId)
+	case PAUSED:
+		return nil
+	}
+
+	rs.Status = PAUSED
+	if rs.CurrentTaskCancel != nil {
+		rs.CurrentTaskCancel()
+	}
+	rs.CurrentTask = nil
+	rs.CurrentTaskCancel = nil
+	return nil
+}
+
+// Resume starts fetching blocks from the current block of a paused syncer
+func (rs *RexSyncer) Resume() error {
+	rex_syncer_log.Debugf("<%s> Resume called", rs.GroupId)
+	rs.mustatus.Lock()
+	if rs.Status != PAUSED {
+		rs.mustatus.Unlock()
+		return fmt.Errorf("syncer of group %s is not paused", rs.GroupId)
+	}
+	rs.Status = IDLE
+	rs.CurrRetryCount = 0
+	rs.CurrentDely = 0
+	rs.mustatus.Unlock()
+
+	if !rs.started {
+		rs.Start()
+		return nil
+	}
+
+	task := rs.newSyncBlockTask()
+	rs.AddTask(task)
+	return nil
+}
+
+func (rs *RexSyncer) isRunning() bool {
+	return rs.Status != CLOSED && rs.Status != PAUSED
+}
+
 func (rs *RexSyncer) GetLastRexSyncResult() (*def.RexSyncResult, error) {
 	if rs.LastSyncResult == nil {
 		return nil, fmt.Errorf("no valid rex sync result yet")
@@ -213,7 +275,7 @@ func (rs *RexSyncer) runTask(ctx context.Context, task *SyncTask, cancel context
 	case <-ctx.Done():
 		switch ctx.Err() {
 		case context.DeadlineExceeded:
-			if rs.Status != CLOSED {
+			if rs.isRunning() {
 				//a workround, should cancel the ctx for current task
 				if rs.CurrentTask != nil {
 					rex_syncer_log.Debugf("task <%d> timeout", task.TaskId)
@@ -242,20 +304,25 @@ func (rs *RexSyncer) AddTask(task *SyncTask) {
 	go func() {
 		rs.mustatus.Lock()
 		defer rs.mustatus.Unlock()
-		if rs.Status != CLOSED {
+		if rs.isRunning() {
 			rs.taskq <- task
 		}
 	}()
 }
 
+// AddResult buffers the result without blocking the stream handler, a result is dropped if the buffer is full,
+// the task of the result is requested again after timeout
 func (rs *RexSyncer) AddResult(result *SyncResult) {
-	go func() {
-		rs.mustatus.Lock()
-		defer rs.mustatus.Unlock()
-		if rs.Status != CLOSED {
-			rs.resultq <- result
-		}
-	}()
+	rs.mustatus.Lock()
+	defer rs.mustatus.Unlock()
+	if !rs.isRunning() {
+		return
+	}
+	select {
+	case rs.resultq <- result:
+	default:
+		rex_syncer_log.Debugf("<%s> result queue is full, drop result of task <%d>", rs.GroupId, result.TaskId)
+	}
 }
 
 // task generators
@@ -269,14 +336,6 @@ func (rs *RexSyncer) newSyncBlockTask() *SyncTask {
 func (rs *RexSyncer) syncBlockTaskSender(task *SyncTask) error {
 	rex_syncer_log.Debugf("<%s> syncBlockTaskSender called", rs.GroupId)
 
-	var trx *quorumpb.Trx
-	var trxerr error
-
-	trx, trxerr = rs.chainCtx.GetTrxFactory().GetReqBlocksTrx("", rs.GroupId, task.TaskId, task.ReqBlockNum)
-	if trxerr != nil {
-		return trxerr
-	}
-
 	connMgr, err := conn.GetConn().GetConnMgr(rs.GroupId)
 	if err != nil {
 		return err
@@ -285,25 +344,59 @@ func (rs *RexSyncer) syncBlockTaskSender(task *SyncTask) error {
 	rex_syncer_log.Debugf("<%s> sleep <%d> millseconds before send the req", rs.GroupId, task.DelayTime)
 	time.Sleep(time.Duration(task.DelayTime) * time.Millisecond)
 
+	if !rs.isRunning() {
+		return nil
+	}
+
 	//set status to SYNCING since the syncing task is always running and the "real" sync work (after send out reqBlock) only start after sleep
 	rs.Status = SYNCING
-	return connMgr.SendReqTrxRex(trx)
+	newTrx := func(fromBlock uint64, blkNum int32) (*quorumpb.Trx, error) {
+		return rs.chainCtx.GetTrxFactory().GetReqBlocksTrx("", rs.GroupId, fromBlock, blkNum)
+	}
+	return connMgr.SendReqBlocksRex(task.TaskId, task.ReqBlockNum, newTrx, func(err error) {
+		if err != nil {
+			rex_syncer_log.Debugf("<%s> request of task <%d> failed: %s", rs.GroupId, task.TaskId, err)
+		}
+	})
+}
+
+// resultCovers tells if the response serves the task, the requests of the overlapping ranges are merged,
+// so the response including the first block of a task may start before it
+func resultCovers(resp *quorumpb.ReqBlockResp, taskId uint64) bool {
+	if resp.FromBlock == taskId {
+		return true
+	}
+	if resp.FromBlock > taskId {
+		return false
+	}
+	for _, block := range resp.Blocks.GetBlocks() {
+		if block.BlockId == taskId {
+			return true
+		}
+	}
+	return false
 }
 
 func (rs *RexSyncer) handleResult(result *SyncResult) error {
 	rex_syncer_log.Debugf("<%s> handleResult called", rs.GroupId)
+	if !rs.isRunning() {
+		rex_syncer_log.Debugf("<%s> syncer is not running, ignore", rs.GroupId)
+		return nil
+	}
 	//check if the resp is what we are waiting for
 	if rs.CurrentTask == nil {
 		rex_syncer_log.Debugf("<%s> CurrentTask is nil, ignore", rs.GroupId)
 		return rumerrors.ErrTaskIdMismatch
 	}
-	if result.TaskId != rs.CurrentTask.TaskId {
-		//chain_log.Warningf("<%s> HandleReqBlockResp error <%s>", sr.groupId, rumerrors.ErrEpochMismatch)
+	reqBlockResp := result.Data.(*quorumpb.ReqBlockResp)
+	if !resultCovers(reqBlockResp, rs.CurrentTask.TaskId) {
+		//the response of a request merged with the task, its blocks are cached until their parents are applied
+		if reqBlockResp.Result != quorumpb.ReqBlkResult_BLOCK_NOT_FOUND && reqBlockResp.FromBlock > rs.CurrentTask.TaskId {
+			rs.chainCtx.ApplyBlocks(reqBlockResp.Blocks.GetBlocks())
+		}
 		return rumerrors.ErrTaskIdMismatch
 	}
 
-	reqBlockResp := result.Data.(*quorumpb.ReqBlockResp)
-
 	rex_syncer_log.Debugf("- Receive valid reqBlockResp, provider <%s> result <%s> from block <%d> total <%d> blocks provided",
 		reqBlockResp.ProviderPubkey,
 		reqBlockResp.Result.String(),
@@ -323,6 +416,9 @@ func (rs *RexSyncer) handleResult(result *SyncResult) error {
 	switch reqBlockResp.Result {
 	case quorumpb.ReqBlkResult_BLOCK_NOT_FOUND:
 		if isOwner {
+			//owner has no block after the local one, the group is caught up
+			rs.chainCtx.syncProgress.SeenBlock(rs.cdnIface.GetCurrBlockId())
+			rs.chainCtx.onSyncCaughtUp()
 			rs.CurrentDely = MAXIMUM_DELAY_DURATION
 			chain_log.Debugf("<%s> receive BLOCK_NOT_FOUND from group owner, set delay to <%d>", rs.GroupId, rs.CurrentDely)
 		}
@@ -330,6 +426,7 @@ func (rs *RexSyncer) handleResult(result *SyncResult) error {
 	case quorumpb.ReqBlkResult_BLOCK_IN_RESP_ON_TOP:
 		rs.chainCtx.ApplyBlocks(reqBlockResp.Blocks.Blocks)
 		if isOwner {
+			rs.chainCtx.onSyncCaughtUp()
 			rs.CurrentDely = MAXIMUM_DELAY_DURATION
 			chain_log.Debugf("<%s> receive BLOCK_IN_RESP_ON_TOP from group owner, apply blocks, set task delay to <%d>", rs.GroupId, rs.CurrentDely)
 		}
@@ -342,6 +439,8 @@ func (rs *RexSyncer) handleResult(result *SyncResult) error {
 
 	}
 
+	stats.Inc(stats.SyncRound, rs.GroupId, 1)
+
 	//received something, reset current retry count
 	rs.CurrRetryCount = 0
 
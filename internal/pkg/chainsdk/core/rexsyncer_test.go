@@ -0,0 +1,68 @@
+package chain
+
+import (
+	"testing"
+
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+func TestRexSyncerAddResultBounded(t *testing.T) {
+	rs := NewRexSyncer("ac0eea7c-2f3c-4c67-80b3-136e46b924a8", "test", nil, nil)
+
+	// nothing consumes the results, the ones over the queue size are dropped without blocking
+	for i := 0; i < REX_RESULT_QUEUE_SIZE*2; i++ {
+		rs.AddResult(&SyncResult{TaskId: uint64(i)})
+	}
+	if n := len(rs.resultq); n != REX_RESULT_QUEUE_SIZE {
+		t.Fatalf("want %d buffered results, got %d", REX_RESULT_QUEUE_SIZE, n)
+	}
+	if first := <-rs.resultq; first.TaskId != 0 {
+		t.Errorf("want the first result kept, got task %d", first.TaskId)
+	}
+
+	rs.Stop()
+	rs.AddResult(&SyncResult{TaskId: 100})
+	if n := len(rs.resultq); n != REX_RESULT_QUEUE_SIZE-1 {
+		t.Errorf("results should not be buffered after stop, got %d", n)
+	}
+}
+
+func TestSetRexResultQueueSize(t *testing.T) {
+	defer func(n int) { REX_RESULT_QUEUE_SIZE = n }(REX_RESULT_QUEUE_SIZE)
+
+	// 0 is no limit for the rex service, the result queue keeps the default
+	SetRexResultQueueSize(0)
+	if REX_RESULT_QUEUE_SIZE != 16 {
+		t.Errorf("want the default 16 kept for 0, got %d", REX_RESULT_QUEUE_SIZE)
+	}
+	SetRexResultQueueSize(4)
+	if rs := NewRexSyncer("ac0eea7c-2f3c-4c67-80b3-136e46b924a8", "test", nil, nil); cap(rs.resultq) != 4 {
+		t.Errorf("want 4 buffered results, got %d", cap(rs.resultq))
+	}
+}
+
+func TestResultCovers(t *testing.T) {
+	blocks := func(ids ...uint64) *quorumpb.BlocksBundle {
+		bundle := &quorumpb.BlocksBundle{}
+		for _, id := range ids {
+			bundle.Blocks = append(bundle.Blocks, &quorumpb.Block{BlockId: id})
+		}
+		return bundle
+	}
+	cases := []struct {
+		name string
+		resp *quorumpb.ReqBlockResp
+		want bool
+	}{
+		{"same start", &quorumpb.ReqBlockResp{FromBlock: 11, Result: quorumpb.ReqBlkResult_BLOCK_NOT_FOUND}, true},
+		{"merged request", &quorumpb.ReqBlockResp{FromBlock: 5, Result: quorumpb.ReqBlkResult_BLOCK_IN_RESP, Blocks: blocks(5, 6, 7, 8, 9, 10, 11, 12)}, true},
+		{"ends before the task", &quorumpb.ReqBlockResp{FromBlock: 5, Result: quorumpb.ReqBlkResult_BLOCK_IN_RESP, Blocks: blocks(5, 6, 7)}, false},
+		{"stale not found", &quorumpb.ReqBlockResp{FromBlock: 5, Result: quorumpb.ReqBlkResult_BLOCK_NOT_FOUND}, false},
+		{"after the task", &quorumpb.ReqBlockResp{FromBlock: 21, Result: quorumpb.ReqBlkResult_BLOCK_IN_RESP, Blocks: blocks(21)}, false},
+	}
+	for _, c := range cases {
+		if got := resultCovers(c.resp, 11); got != c.want {
+			t.Errorf("%s: want %v, got %v", c.name, c.want, got)
+		}
+	}
+}
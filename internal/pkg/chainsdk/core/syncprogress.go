@@ -0,0 +1,85 @@
+package chain
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rumsystem/quorum/internal/pkg/chainsdk/def"
+)
+
+// SYNC_RATE_WINDOW is the duration of recent applied blocks used to estimate the apply rate
+var SYNC_RATE_WINDOW = 60 * time.Second
+
+type progressSample struct {
+	blockId uint64
+	time    time.Time
+}
+
+// syncProgress tracks the highest block seen from peers and the recent apply rate
+type syncProgress struct {
+	mu      sync.Mutex
+	highest uint64
+	known   bool
+	samples []progressSample
+}
+
+// SeenBlock records a block id known to exist on peers
+func (p *syncProgress) SeenBlock(blockId uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.known || blockId > p.highest {
+		p.highest = blockId
+	}
+	p.known = true
+}
+
+// Applied records the local block id after applying blocks
+func (p *syncProgress) Applied(blockId uint64, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.samples = append(p.samples, progressSample{blockId: blockId, time: now})
+	i := 0
+	for i < len(p.samples)-1 && now.Sub(p.samples[i].time) > SYNC_RATE_WINDOW {
+		i++
+	}
+	p.samples = p.samples[i:]
+}
+
+// Get returns the progress of the local block id towards the highest block seen from peers
+func (p *syncProgress) Get(applied uint64, now time.Time) *def.SyncProgress {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	progress := &def.SyncProgress{Applied: applied, ETA: -1}
+
+	if n := len(p.samples); n > 1 {
+		first, last := p.samples[0], p.samples[n-1]
+		if now.Sub(last.time) <= SYNC_RATE_WINDOW && last.blockId > first.blockId {
+			if elapsed := last.time.Sub(first.time).Seconds(); elapsed > 0 {
+				progress.Rate = float64(last.blockId-first.blockId) / elapsed
+			}
+		}
+	}
+
+	if !p.known {
+		progress.Indeterminate = true
+		return progress
+	}
+
+	progress.Total = p.highest
+	if applied >= p.highest {
+		progress.Total = applied
+		progress.Percent = 100
+		progress.ETA = 0
+		return progress
+	}
+
+	if p.highest > 0 {
+		progress.Percent = float64(applied) / float64(p.highest) * 100
+	}
+	if progress.Rate > 0 {
+		progress.ETA = int64(float64(p.highest-applied) / progress.Rate)
+	}
+	return progress
+}
@@ -0,0 +1,40 @@
+package chain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSyncProgress(t *testing.T) {
+	p := &syncProgress{}
+	now := time.Now()
+
+	progress := p.Get(10, now)
+	if !progress.Indeterminate || progress.Percent != 0 || progress.ETA != -1 {
+		t.Errorf("progress should be indeterminate before any block is seen: %+v", progress)
+	}
+
+	p.SeenBlock(100)
+	p.SeenBlock(50)
+	p.Applied(10, now)
+	p.Applied(30, now.Add(10*time.Second))
+	progress = p.Get(30, now.Add(10*time.Second))
+	if progress.Indeterminate || progress.Total != 100 || progress.Percent != 30 {
+		t.Errorf("unexpected progress: %+v", progress)
+	}
+	if progress.Rate != 2 || progress.ETA != 35 {
+		t.Errorf("unexpected rate %f or eta %d", progress.Rate, progress.ETA)
+	}
+
+	// samples out of the window are dropped
+	p.Applied(40, now.Add(SYNC_RATE_WINDOW+20*time.Second))
+	progress = p.Get(40, now.Add(SYNC_RATE_WINDOW+20*time.Second))
+	if progress.Rate != 0 || progress.ETA != -1 {
+		t.Errorf("rate should be unknown with a single recent sample: %+v", progress)
+	}
+
+	progress = p.Get(100, now)
+	if progress.Percent != 100 || progress.ETA != 0 {
+		t.Errorf("progress should be done: %+v", progress)
+	}
+}
@@ -0,0 +1,69 @@
+package chain
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/rumsystem/quorum/internal/pkg/nodectx"
+	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+// decryptTrxData returns the data of a trx encrypted by the cipher key of the group, all but the private posts are
+func (chain *Chain) decryptTrxData(trx *quorumpb.Trx) ([]byte, error) {
+	ciperKey, err := hex.DecodeString(chain.groupItem.CipherKey)
+	if err != nil {
+		return nil, err
+	}
+	return localcrypto.AesDecode(trx.Data, ciperKey)
+}
+
+// decodeTombstone returns the id of the post deleted by the encrypted tombstone trx
+func (chain *Chain) decodeTombstone(trx *quorumpb.Trx) (string, error) {
+	data, err := chain.decryptTrxData(trx)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// checkTombstone returns error if the tombstone trx is not signed by the author of the post or the group owner
+func (chain *Chain) checkTombstone(trx *quorumpb.Trx, trxId string, nodename string) error {
+	if trxId == "" {
+		return errors.New("tombstone without trx id")
+	}
+	author, err := nodectx.GetNodeCtx().GetChainStorage().GetPostAuthor(trx.GroupId, trxId, nodename)
+	if err != nil {
+		return err
+	}
+	if author == "" {
+		return fmt.Errorf("post %s not found", trxId)
+	}
+	if samePubkey(author, trx.SenderPubkey) || chain.isOwnerByPubkey(trx.SenderPubkey) {
+		return nil
+	}
+	return fmt.Errorf("tombstone of post %s is not signed by the author or the group owner", trxId)
+}
+
+// applyTombstone hides the post, trx.Data is the decrypted post id
+func (chain *Chain) applyTombstone(trx *quorumpb.Trx, nodename string) {
+	trxId := string(trx.Data)
+	if err := chain.checkTombstone(trx, trxId, nodename); err != nil {
+		chain_log.Warningf("<%s> skip TOMBSTONE trx <%s>: %s", chain.groupItem.GroupId, trx.TrxId, err)
+		return
+	}
+	if err := nodectx.GetNodeCtx().GetChainStorage().AddTombstone(trx.GroupId, trxId, trx.TrxId, nodename); err != nil {
+		chain_log.Warningf("<%s> apply TOMBSTONE trx <%s> failed: %s", chain.groupItem.GroupId, trx.TrxId, err)
+	}
+}
+
+// samePubkey compares the pubkeys in the libp2p or the eth base64 format
+func samePubkey(a, b string) bool {
+	if a == b {
+		return true
+	}
+	ethA, _ := localcrypto.Libp2pPubkeyToEthBase64(a)
+	ethB, _ := localcrypto.Libp2pPubkeyToEthBase64(b)
+	return ethA != "" && ethA == ethB
+}
@@ -0,0 +1,145 @@
+package chain
+
+import (
+	"encoding/hex"
+	"testing"
+
+	p2pcrypto "github.com/libp2p/go-libp2p/core/crypto"
+	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+const (
+	tombstoneTestPost1 = "b2a3b9aa-bd16-4e80-8497-6d95eddfec51"
+	tombstoneTestPost2 = "b2a3b9aa-bd16-4e80-8497-6d95eddfec52"
+	tombstoneTestPost3 = "b2a3b9aa-bd16-4e80-8497-6d95eddfec53"
+	tombstoneTestPost4 = "b2a3b9aa-bd16-4e80-8497-6d95eddfec54"
+)
+
+// newTombstoneTestPubkey returns a pubkey in the libp2p and the eth base64 format
+func newTombstoneTestPubkey(t *testing.T) (string, string) {
+	_, pubkey, err := p2pcrypto.GenerateSecp256k1Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := p2pcrypto.MarshalPublicKey(pubkey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	libp2pPubkey := p2pcrypto.ConfigEncodeKey(b)
+	ethPubkey, err := localcrypto.Libp2pPubkeyToEthBase64(libp2pPubkey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return libp2pPubkey, ethPubkey
+}
+
+func newTombstoneTestChain(t *testing.T, owner string) (*Chain, []byte) {
+	cipherKey, err := localcrypto.CreateAesKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := &quorumpb.GroupItem{GroupId: groupKeyTestGroupId, OwnerPubKey: owner, UserSignPubkey: owner, CipherKey: hex.EncodeToString(cipherKey)}
+	return &Chain{groupItem: item, nodename: "test", pubQueue: newPubQueue(nil)}, cipherKey
+}
+
+func newTombstoneTestTrx(t *testing.T, cipherKey []byte, trxType quorumpb.TrxType, trxId, sender string, data []byte) *quorumpb.Trx {
+	encrypted, err := localcrypto.AesEncrypt(data, cipherKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &quorumpb.Trx{TrxId: trxId, GroupId: groupKeyTestGroupId, Type: trxType, SenderPubkey: sender, Data: encrypted, TimeStamp: 1}
+}
+
+func TestCheckTombstone(t *testing.T) {
+	cs := initGroupKeyNodeCtx(t)
+	aliceLibp2p, aliceEth := newTombstoneTestPubkey(t)
+	_, bobEth := newTombstoneTestPubkey(t)
+	_, ownerEth := newTombstoneTestPubkey(t)
+	chain, cipherKey := newTombstoneTestChain(t, ownerEth)
+
+	// the post of alice is signed by the libp2p pubkey, the node does not save posts
+	if err := cs.AddPostAuthor(groupKeyTestGroupId, tombstoneTestPost1, aliceLibp2p, "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		name   string
+		sender string
+		trxId  string
+		ok     bool
+	}{
+		{"author", aliceLibp2p, tombstoneTestPost1, true},
+		{"author in the eth format", aliceEth, tombstoneTestPost1, true},
+		{"owner", ownerEth, tombstoneTestPost1, true},
+		{"third party", bobEth, tombstoneTestPost1, false},
+		{"unknown post", aliceLibp2p, tombstoneTestPost2, false},
+		{"unknown post of owner", ownerEth, tombstoneTestPost2, false},
+		{"empty trx id", ownerEth, "", false},
+	} {
+		trx := newTombstoneTestTrx(t, cipherKey, quorumpb.TrxType_TOMBSTONE, "tombstone", c.sender, []byte(c.trxId))
+		trxId, err := chain.decodeTombstone(trx)
+		if err != nil {
+			t.Fatalf("%s: decodeTombstone failed: %s", c.name, err)
+		}
+		if trxId != c.trxId {
+			t.Errorf("%s: want post %s, got %s", c.name, c.trxId, trxId)
+		}
+		if err := chain.checkTombstone(trx, trxId, "test"); (err == nil) != c.ok {
+			t.Errorf("%s: want accepted %v, got %v", c.name, c.ok, err)
+		}
+	}
+}
+
+func TestApplyTombstoneFullNode(t *testing.T) {
+	cs := initGroupKeyNodeCtx(t)
+	alice, _ := newTombstoneTestPubkey(t)
+	bob, _ := newTombstoneTestPubkey(t)
+	owner, _ := newTombstoneTestPubkey(t)
+	chain, cipherKey := newTombstoneTestChain(t, owner)
+
+	trxs := []*quorumpb.Trx{
+		newTombstoneTestTrx(t, cipherKey, quorumpb.TrxType_POST, tombstoneTestPost1, alice, []byte("post 1")),
+		newTombstoneTestTrx(t, cipherKey, quorumpb.TrxType_POST, tombstoneTestPost2, alice, []byte("post 2")),
+		newTombstoneTestTrx(t, cipherKey, quorumpb.TrxType_POST, tombstoneTestPost3, alice, []byte("post 3")),
+	}
+	if err := chain.ApplyTrxsFullNode(trxs, "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the tombstones reached a block, the full node checks them again before applying
+	tombstones := []*quorumpb.Trx{
+		newTombstoneTestTrx(t, cipherKey, quorumpb.TrxType_TOMBSTONE, "tombstone-author", alice, []byte(tombstoneTestPost1)),
+		newTombstoneTestTrx(t, cipherKey, quorumpb.TrxType_TOMBSTONE, "tombstone-owner", owner, []byte(tombstoneTestPost2)),
+		newTombstoneTestTrx(t, cipherKey, quorumpb.TrxType_TOMBSTONE, "tombstone-forged", bob, []byte(tombstoneTestPost3)),
+		newTombstoneTestTrx(t, cipherKey, quorumpb.TrxType_TOMBSTONE, "tombstone-unknown", owner, []byte(tombstoneTestPost4)),
+	}
+	if err := chain.ApplyTrxsFullNode(tombstones, "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		trxId     string
+		tombstone string
+	}{
+		{tombstoneTestPost1, "tombstone-author"},
+		{tombstoneTestPost2, "tombstone-owner"},
+		{tombstoneTestPost3, ""},
+		{tombstoneTestPost4, ""},
+	} {
+		tombstone, err := cs.GetTombstone(groupKeyTestGroupId, c.trxId, "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tombstone != c.tombstone {
+			t.Errorf("post %s: want tombstone %q, got %q", c.trxId, c.tombstone, tombstone)
+		}
+	}
+
+	// the skipped tombstones are still saved as trxs of the chain
+	for _, trx := range tombstones {
+		if exist, err := cs.IsTrxExist(groupKeyTestGroupId, trx.TrxId, "test"); err != nil || !exist {
+			t.Errorf("want trx %s saved, got %v %v", trx.TrxId, exist, err)
+		}
+	}
+}
@@ -21,3 +21,12 @@ type RexSyncResult struct {
 	LastSyncTaskTimestamp int64
 	NextSyncTaskTimeStamp int64
 }
+
+type SyncProgress struct {
+	Applied       uint64  `json:"applied" example:"800"`         // locally applied block id
+	Total         uint64  `json:"total" example:"1000"`          // highest block id seen from peers, 0 if unknown
+	Indeterminate bool    `json:"indeterminate" example:"false"` // true if the highest block of peers is unknown
+	Percent       float64 `json:"percent" example:"80"`          // applied/total in percent, 0 if indeterminate
+	Rate          float64 `json:"rate" example:"12.5"`           // blocks applied per second recently
+	ETA           int64   `json:"eta" example:"16"`              // estimated seconds remaining, -1 if unknown
+}
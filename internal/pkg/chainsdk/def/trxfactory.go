@@ -11,6 +11,8 @@ type TrxFactoryIface interface {
 	GetUpdAppConfigTrx(keyalias string, item *quorumpb.AppConfigItem) (*quorumpb.Trx, error)
 	GetRegUserTrx(keyalias string, item *quorumpb.UserItem) (*quorumpb.Trx, error)
 	GetPostAnyTrx(keyalias string, content []byte, encryptto ...[]string) (*quorumpb.Trx, error)
+	GetPostAnyTrxWithExpiry(keyalias string, content []byte, expired int64, encryptto ...[]string) (*quorumpb.Trx, error)
+	GetTombstoneTrx(keyalias string, trxId string) (*quorumpb.Trx, error)
 	GetReqBlocksTrx(keyalias string, groupId string, fromBlock uint64, blkReq int32) (*quorumpb.Trx, error)
 	GetReqBlocksRespTrx(keyalias string, groupId string, requester string, fromBlock uint64, blkReq int32, blocks []*quorumpb.Block, result quorumpb.ReqBlkResult) (*quorumpb.Trx, error)
 }
@@ -1,8 +1,11 @@
 package cli
 
 import (
+	"fmt"
 	"strings"
+	"time"
 
+	"github.com/libp2p/go-libp2p/core/peer"
 	maddr "github.com/multiformats/go-multiaddr"
 )
 
@@ -28,6 +31,30 @@ type FullNodeFlag struct {
 	KeyStorePwd      string
 	AutoAck          bool
 	EnableRelay      bool
+	EnableMetrics    bool          `mapstructure:"enable-metrics"`
+	ShutdownTimeout  time.Duration `mapstructure:"shutdown-timeout"`
+	Consensus        string
+	SnapshotInterval uint64   `mapstructure:"snapshot-interval"`
+	HBMissIntervals  int      `mapstructure:"heartbeat-missed-intervals"`
+	StorageBackend   string   `mapstructure:"storage-backend"`
+	DbMigrateTo      int      `mapstructure:"db-migrate-to"`
+	DbMigrateDryRun  bool     `mapstructure:"db-migrate-dry-run"`
+	DbEncryptMigrate bool     `mapstructure:"db-encrypt-migrate"`
+	APIUnixSocket    string   `mapstructure:"api-unix-socket"`
+	PprofListen      string   `mapstructure:"pprof-listen"`
+	GRPCListen       string   `mapstructure:"grpc-listen"`
+	GRPCTLSCert      string   `mapstructure:"grpc-tls-cert"`
+	GRPCTLSKey       string   `mapstructure:"grpc-tls-key"`
+	PersistentPeers  []string `mapstructure:"persistent-peer"`
+	EnableMdns       bool     `mapstructure:"enable-mdns"`
+	PeerstoreImport  string   `mapstructure:"peerstore-import"`
+	SystemdNotify    bool     `mapstructure:"systemd-notify"`
+	AppSyncInterval  int      `mapstructure:"appsync-interval"`
+	GroupsDbDir      string   `mapstructure:"groups-db-dir"`
+	ChainDbDir       string   `mapstructure:"chain-db-dir"`
+	AppDbDir         string   `mapstructure:"appdb-dir"`
+	PrintIdentity    bool     `mapstructure:"print-identity"`
+	Archive          bool     `mapstructure:"archive"`
 }
 
 // TBD remove unused flags
@@ -96,6 +123,24 @@ type ProducerNodeFlag struct {
 	KeyStoreDir      string
 	KeyStoreName     string
 	KeyStorePwd      string
+	ShutdownTimeout  time.Duration `mapstructure:"shutdown-timeout"`
+	Consensus        string
+	SnapshotInterval uint64   `mapstructure:"snapshot-interval"`
+	HBMissIntervals  int      `mapstructure:"heartbeat-missed-intervals"`
+	StorageBackend   string   `mapstructure:"storage-backend"`
+	DbMigrateTo      int      `mapstructure:"db-migrate-to"`
+	DbMigrateDryRun  bool     `mapstructure:"db-migrate-dry-run"`
+	DbEncryptMigrate bool     `mapstructure:"db-encrypt-migrate"`
+	APIUnixSocket    string   `mapstructure:"api-unix-socket"`
+	PprofListen      string   `mapstructure:"pprof-listen"`
+	PersistentPeers  []string `mapstructure:"persistent-peer"`
+	EnableMdns       bool     `mapstructure:"enable-mdns"`
+	PeerstoreImport  string   `mapstructure:"peerstore-import"`
+	SystemdNotify    bool     `mapstructure:"systemd-notify"`
+	GroupsDbDir      string   `mapstructure:"groups-db-dir"`
+	ChainDbDir       string   `mapstructure:"chain-db-dir"`
+	AppDbDir         string   `mapstructure:"appdb-dir"`
+	PrintIdentity    bool     `mapstructure:"print-identity"`
 }
 
 func (al *AddrList) String() string {
@@ -120,17 +165,68 @@ func (al *AddrList) Type() string {
 	return "AddrList"
 }
 
+// ParseAddrList parses the comma separated multiaddrs, the blank entries are skipped
 func ParseAddrList(s string) (*AddrList, error) {
-	addrlist := strings.Split(s, ",")
 	var al AddrList
-
-	for _, v := range addrlist {
+	for i, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
 		addr, err := maddr.NewMultiaddr(v)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("invalid multiaddr #%d %q: %s", i+1, v, err)
 		}
 		al = append(al, addr)
 	}
 
 	return &al, nil
 }
+
+// ParsePeerAddrList parses the comma separated p2p multiaddrs of peers, see NormalizePeerAddrList
+func ParsePeerAddrList(s string) (*AddrList, error) {
+	al, err := ParseAddrList(s)
+	if err != nil {
+		return nil, err
+	}
+	return NormalizePeerAddrList(*al)
+}
+
+// NormalizePeerAddrList checks every addr ends with the /p2p/ peer id, the addrs are rewritten
+// in the canonical form, e.g. /ipfs/ becomes /p2p/, and the duplicates are dropped
+func NormalizePeerAddrList(al AddrList) (*AddrList, error) {
+	seen := make(map[string]bool, len(al))
+	var peers AddrList
+	for i, addr := range al {
+		if _, err := peer.AddrInfoFromP2pAddr(addr); err != nil {
+			return nil, fmt.Errorf("invalid peer addr #%d %q: %s, should be like /ip4/1.2.3.4/tcp/10666/p2p/<peer id>", i+1, addr, err)
+		}
+		canonical, err := maddr.NewMultiaddr(addr.String())
+		if err != nil {
+			return nil, fmt.Errorf("invalid peer addr #%d %q: %s", i+1, addr, err)
+		}
+		if seen[canonical.String()] {
+			continue
+		}
+		seen[canonical.String()] = true
+		peers = append(peers, canonical)
+	}
+	return &peers, nil
+}
+
+// NormalizePeerAddrs is NormalizePeerAddrList of the multiaddr strings, e.g. the persistent peers
+func NormalizePeerAddrs(addrs []string) ([]string, error) {
+	al, err := ParseAddrList(strings.Join(addrs, ","))
+	if err != nil {
+		return nil, err
+	}
+	peers, err := NormalizePeerAddrList(*al)
+	if err != nil {
+		return nil, err
+	}
+	strs := make([]string, len(*peers))
+	for i, addr := range *peers {
+		strs[i] = addr.String()
+	}
+	return strs, nil
+}
@@ -0,0 +1,26 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePeerAddrList(t *testing.T) {
+	id := "16Uiu2HAm8XVpfQrJYaeL7XtrHC3FvfKt2QW7P8R3MBenYyHxu8Kk"
+	al, err := ParsePeerAddrList(" /ip4/127.0.0.1/tcp/10666/ipfs/" + id + ",,/ip4/127.0.0.1/tcp/10666/p2p/" + id)
+	if err != nil {
+		t.Fatalf("ParsePeerAddrList failed: %s", err)
+	}
+	if len(*al) != 1 || (*al)[0].String() != "/ip4/127.0.0.1/tcp/10666/p2p/"+id {
+		t.Errorf("expect one normalized addr, got %s", al.String())
+	}
+
+	for _, s := range []string{
+		"/ip4/127.0.0.1/tcp/10666/p2p/" + id + ",/ip4/127.0.0.1/tcp/10666",
+		"/ip4/127.0.0.1/tcp/10666/p2p/" + id + ",/ip4/127.0.0.1/tcp/abc/p2p/" + id,
+	} {
+		if _, err := ParsePeerAddrList(s); err == nil || !strings.Contains(err.Error(), "#2") {
+			t.Errorf("expect error naming the second addr of %s, got %v", s, err)
+		}
+	}
+}
@@ -11,7 +11,9 @@ import (
 	logging "github.com/ipfs/go-log/v2"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
 	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
 	chaindef "github.com/rumsystem/quorum/internal/pkg/chainsdk/def"
+	"github.com/rumsystem/quorum/internal/pkg/conn/p2p"
 	"github.com/rumsystem/quorum/internal/pkg/conn/pubsubconn"
 	"github.com/rumsystem/quorum/internal/pkg/nodectx"
 	"github.com/rumsystem/quorum/internal/pkg/utils"
@@ -227,34 +229,70 @@ func (connMgr *ConnMgr) SendUserTrxPubsub(trx *quorumpb.Trx, channelId ...string
 
 func (connMgr *ConnMgr) SendReqTrxRex(trx *quorumpb.Trx) error {
 	conn_log.Debugf("<%s> SendTrxRex called", connMgr.GroupId)
+	rummsg, channelpeers, err := connMgr.reqTrxRexMsg(trx)
+	if err != nil {
+		return err
+	}
+	return nodectx.GetNodeCtx().Node.RumExchange.Publish(trx.GroupId, channelpeers, rummsg)
+}
+
+// SendReqBlocksRex sends the request of blkNum blocks from fromBlock, the blocks being requested from the peer are not
+// requested again, the rest are requested by the trxs of newTrx. done is called once the responses are handled.
+func (connMgr *ConnMgr) SendReqBlocksRex(fromBlock uint64, blkNum int32, newTrx func(fromBlock uint64, blkNum int32) (*quorumpb.Trx, error), done func(error)) error {
+	conn_log.Debugf("<%s> SendReqBlocksRex called", connMgr.GroupId)
 	if nodectx.GetNodeCtx().Node.RumExchange == nil {
 		return errors.New("RumExchange is nil, please set enablerumexchange as true")
 	}
+	psconn := connMgr.getUserConn()
+	if psconn == nil {
+		return fmt.Errorf("no user conn for %s. (can be ignored)", connMgr.GroupId)
+	}
+	build := func(rng p2p.RexRange) (*quorumpb.RumDataMsg, error) {
+		trx, err := newTrx(rng.From, int32(rng.Num))
+		if err != nil {
+			return nil, err
+		}
+		rummsg, _, err := connMgr.reqTrxRexMsg(trx)
+		return rummsg, err
+	}
+	rng := p2p.RexRange{GroupId: connMgr.GroupId, From: fromBlock, Num: uint64(blkNum)}
+	_, err := nodectx.GetNodeCtx().Node.RumExchange.PublishRange(rng, psconn.Topic.ListPeers(), build, done)
+	return err
+}
+
+// reqTrxRexMsg compresses the trx to a rex msg, and returns the peers of the user channel to send it to
+func (connMgr *ConnMgr) reqTrxRexMsg(trx *quorumpb.Trx) (*quorumpb.RumDataMsg, []peer.ID, error) {
+	if nodectx.GetNodeCtx().Node.RumExchange == nil {
+		return nil, nil, errors.New("RumExchange is nil, please set enablerumexchange as true")
+	}
 
 	// compress trx.Data
 	compressedContent := new(bytes.Buffer)
 	if err := utils.Compress(bytes.NewReader(trx.Data), compressedContent); err != nil {
-		return err
+		return nil, nil, err
 	}
 	trx.Data = compressedContent.Bytes()
 
 	pbBytes, err := proto.Marshal(trx)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	pkg := &quorumpb.Package{
 		Type: quorumpb.PackageType_TRX,
 		Data: pbBytes,
 	}
+	// the trx is refused by the limit of pubsub as well, whichever way it is sent
+	if err := p2p.CheckMessageSize(proto.Size(pkg), p2p.MaxMessageSize()); err != nil {
+		return nil, nil, err
+	}
 	rummsg := &quorumpb.RumDataMsg{MsgType: quorumpb.RumDataMsgType_CHAIN_DATA, DataPackage: pkg}
 
 	psconn := connMgr.getUserConn()
 	if psconn == nil {
-		return fmt.Errorf("no user conn for %s. (can be ignored)", connMgr.GroupId)
+		return nil, nil, fmt.Errorf("no user conn for %s. (can be ignored)", connMgr.GroupId)
 	}
-	channelpeers := psconn.Topic.ListPeers()
-	return nodectx.GetNodeCtx().Node.RumExchange.Publish(trx.GroupId, channelpeers, rummsg)
+	return rummsg, psconn.Topic.ListPeers(), nil
 }
 
 func (connMgr *ConnMgr) SendRespTrxRex(trx *quorumpb.Trx, s network.Stream) error {
@@ -282,6 +320,9 @@ func (connMgr *ConnMgr) SendRespTrxRex(trx *quorumpb.Trx, s network.Stream) erro
 		Type: quorumpb.PackageType_TRX,
 		Data: pbBytes,
 	}
+	if err := p2p.CheckMessageSize(proto.Size(pkg), p2p.MaxMessageSize()); err != nil {
+		return err
+	}
 	rummsg := &quorumpb.RumDataMsg{MsgType: quorumpb.RumDataMsgType_CHAIN_DATA, DataPackage: pkg}
 	return nodectx.GetNodeCtx().Node.RumExchange.PublishToStream(rummsg, s) //publish to a stream
 }
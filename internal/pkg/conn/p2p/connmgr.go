@@ -0,0 +1,225 @@
+package p2p
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/net/connmgr"
+)
+
+// ConnMgr is a connection manager whose watermarks and grace period can be changed at runtime.
+// The embedded BasicConnMgr keeps the peer tags and protections, it is created without watermarks so it never trims,
+// the conns are trimmed by ConnMgr instead.
+type ConnMgr struct {
+	*connmgr.BasicConnMgr
+
+	mu      sync.RWMutex
+	low     int
+	high    int
+	grace   time.Duration
+	network network.Network
+
+	trimMu   sync.Mutex
+	lastTrim time.Time
+	trimch   chan struct{}
+	ctx      context.Context
+	cancel   context.CancelFunc
+}
+
+type ConnMgrInfo struct {
+	LowWater    int
+	HighWater   int
+	GracePeriod time.Duration
+	LastTrim    time.Time
+	ConnCount   int
+}
+
+// ValidateWatermarks checks the conns are trimmed to low once there are more than high
+func ValidateWatermarks(low, high int, grace time.Duration) error {
+	if low < 0 {
+		return errors.New("low watermark should not be negative")
+	}
+	if high <= low {
+		return errors.New("low watermark should be less than high watermark")
+	}
+	if grace < 0 {
+		return errors.New("grace period should not be negative")
+	}
+	return nil
+}
+
+func NewConnMgr(low, high int, grace time.Duration) (*ConnMgr, error) {
+	if err := ValidateWatermarks(low, high, grace); err != nil {
+		return nil, err
+	}
+	basic, err := connmgr.NewConnManager(0, 0, connmgr.WithGracePeriod(grace))
+	if err != nil {
+		return nil, err
+	}
+
+	cm := &ConnMgr{BasicConnMgr: basic, low: low, high: high, grace: grace, trimch: make(chan struct{}, 1)}
+	cm.ctx, cm.cancel = context.WithCancel(context.Background())
+	go cm.background()
+	return cm, nil
+}
+
+func (cm *ConnMgr) Watermarks() (low, high int, grace time.Duration) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return cm.low, cm.high, cm.grace
+}
+
+// SetWatermarks changes the watermarks and the grace period, the conns are trimmed at once if there are more than high
+func (cm *ConnMgr) SetWatermarks(low, high int, grace time.Duration) error {
+	if err := ValidateWatermarks(low, high, grace); err != nil {
+		return err
+	}
+	cm.mu.Lock()
+	cm.low, cm.high, cm.grace = low, high, grace
+	cm.mu.Unlock()
+	cm.requestTrim()
+	return nil
+}
+
+func (cm *ConnMgr) Info() ConnMgrInfo {
+	low, high, grace := cm.Watermarks()
+	info := ConnMgrInfo{LowWater: low, HighWater: high, GracePeriod: grace}
+	cm.trimMu.Lock()
+	info.LastTrim = cm.lastTrim
+	cm.trimMu.Unlock()
+	if n := cm.getNetwork(); n != nil {
+		info.ConnCount = len(n.Conns())
+	}
+	return info
+}
+
+// TrimOpenConns closes the conns of the least valuable peers until there are low conns left
+func (cm *ConnMgr) TrimOpenConns(_ context.Context) {
+	cm.trim(true)
+}
+
+func (cm *ConnMgr) Notifee() network.Notifiee {
+	inner := cm.BasicConnMgr.Notifee()
+	return &network.NotifyBundle{
+		ConnectedF: func(n network.Network, c network.Conn) {
+			cm.setNetwork(n)
+			inner.Connected(n, c)
+			if _, high, _ := cm.Watermarks(); len(n.Conns()) > high {
+				cm.requestTrim()
+			}
+		},
+		DisconnectedF: inner.Disconnected,
+	}
+}
+
+func (cm *ConnMgr) Close() error {
+	cm.cancel()
+	return cm.BasicConnMgr.Close()
+}
+
+func (cm *ConnMgr) setNetwork(n network.Network) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.network = n
+}
+
+func (cm *ConnMgr) getNetwork() network.Network {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return cm.network
+}
+
+func (cm *ConnMgr) requestTrim() {
+	select {
+	case cm.trimch <- struct{}{}:
+	default:
+	}
+}
+
+func (cm *ConnMgr) background() {
+	for {
+		_, _, grace := cm.Watermarks()
+		interval := grace / 2
+		if interval < 10*time.Second {
+			interval = 10 * time.Second
+		}
+		timer := time.NewTimer(interval)
+		select {
+		case <-cm.ctx.Done():
+			timer.Stop()
+			return
+		case <-cm.trimch:
+			timer.Stop()
+		case <-timer.C:
+		}
+		cm.trim(false)
+	}
+}
+
+// trim closes the conns of the unprotected peers out of the grace period, from the lowest tag value,
+// until there are low conns left. It does nothing if there are no more than high conns unless forced.
+func (cm *ConnMgr) trim(force bool) {
+	n := cm.getNetwork()
+	if n == nil {
+		return
+	}
+	cm.trimMu.Lock()
+	defer cm.trimMu.Unlock()
+
+	low, high, grace := cm.Watermarks()
+	conns := n.Conns()
+	if len(conns) <= low || (!force && len(conns) <= high) {
+		return
+	}
+
+	type candidate struct {
+		value int
+		conns []network.Conn
+	}
+	young := time.Now().Add(-grace)
+	byPeer := make(map[peer.ID]*candidate)
+	skip := make(map[peer.ID]bool)
+	for _, c := range conns {
+		p := c.RemotePeer()
+		if skip[p] {
+			continue
+		}
+		if cm.IsProtected(p, "") || c.Stat().Opened.After(young) {
+			skip[p] = true
+			delete(byPeer, p)
+			continue
+		}
+		item, ok := byPeer[p]
+		if !ok {
+			item = &candidate{}
+			if info := cm.GetTagInfo(p); info != nil {
+				item.value = info.Value
+			}
+			byPeer[p] = item
+		}
+		item.conns = append(item.conns, c)
+	}
+
+	candidates := make([]*candidate, 0, len(byPeer))
+	for _, item := range byPeer {
+		candidates = append(candidates, item)
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].value < candidates[j].value })
+
+	target := len(conns) - low
+	for _, item := range candidates {
+		if target <= 0 {
+			break
+		}
+		for _, c := range item.conns {
+			c.Close()
+		}
+		target -= len(item.conns)
+	}
+	cm.lastTrim = time.Now()
+}
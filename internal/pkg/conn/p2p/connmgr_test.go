@@ -0,0 +1,65 @@
+package p2p
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestConnMgrTrim(t *testing.T) {
+	cm, err := NewConnMgr(1, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"), libp2p.ConnectionManager(cm))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	peers := []host.Host{}
+	for i := 0; i < 4; i++ {
+		p, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer p.Close()
+		if err := h.Connect(context.Background(), peer.AddrInfo{ID: p.ID(), Addrs: p.Addrs()}); err != nil {
+			t.Fatal(err)
+		}
+		peers = append(peers, p)
+	}
+	cm.Protect(peers[0].ID(), "test")
+	cm.TagPeer(peers[1].ID(), "test", 100)
+
+	// no more conns than high watermark
+	cm.trim(false)
+	if len(h.Network().Conns()) != 4 {
+		t.Fatalf("expect no trim below high watermark, got %d conns", len(h.Network().Conns()))
+	}
+
+	if err := cm.SetWatermarks(1, 3, 0); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50 && len(h.Network().Conns()) > 1; i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if h.Network().Connectedness(peers[0].ID()) != network.Connected {
+		t.Errorf("expect protected peer kept")
+	}
+	if n := len(h.Network().Conns()); n != 1 {
+		t.Errorf("expect conns trimmed to low watermark, got %d", n)
+	}
+	if info := cm.Info(); info.HighWater != 3 || info.LastTrim.IsZero() {
+		t.Errorf("expect info of the trim, got %+v", info)
+	}
+
+	if err := cm.SetWatermarks(5, 5, 0); err == nil {
+		t.Errorf("expect error for low watermark not less than high")
+	}
+}
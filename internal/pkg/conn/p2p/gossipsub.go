@@ -0,0 +1,80 @@
+package p2p
+
+import (
+	"fmt"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/rumsystem/quorum/internal/pkg/options"
+)
+
+// The safe ranges of the gossipsub options. A small private network does well with a low degree like D 3 Dlo 2 Dhi 4,
+// so that the messages are not sent to every peer several times, a large group wants a higher degree and a longer
+// message cache to reach the peers which miss a message from the mesh.
+const (
+	gossipMaxD         = 32
+	gossipMaxDhi       = 64
+	gossipMinHeartbeat = 100 * time.Millisecond
+	gossipMaxHeartbeat = 10 * time.Second
+	gossipMaxHistory   = 64
+)
+
+// GossipSubParams returns the default gossipsub params with the non-zero gossip options applied, the options must satisfy
+//
+//	1 <= GossipDlo <= GossipD <= GossipDhi, GossipD <= 32, GossipDhi <= 64
+//	100ms <= GossipHeartbeat <= 10s
+//	1 <= GossipWindow <= GossipHistory <= 64
+//
+// Dout and Dscore are lowered to fit the degree if needed.
+func GossipSubParams(opt *options.NodeOptions) (pubsub.GossipSubParams, error) {
+	params := pubsub.DefaultGossipSubParams()
+	if opt.GossipD != 0 {
+		params.D = opt.GossipD
+	}
+	if opt.GossipDlo != 0 {
+		params.Dlo = opt.GossipDlo
+	}
+	if opt.GossipDhi != 0 {
+		params.Dhi = opt.GossipDhi
+	}
+	if opt.GossipHeartbeat != 0 {
+		params.HeartbeatInterval = opt.GossipHeartbeat
+	}
+	if opt.GossipHistory != 0 {
+		params.HistoryLength = opt.GossipHistory
+	}
+	if opt.GossipWindow != 0 {
+		params.HistoryGossip = opt.GossipWindow
+	}
+
+	if params.D < 1 || params.D > gossipMaxD {
+		return params, fmt.Errorf("gossip D %d should be in [1, %d]", params.D, gossipMaxD)
+	}
+	if params.Dlo < 1 || params.Dlo > params.D {
+		return params, fmt.Errorf("gossip Dlo %d should be in [1, D %d]", params.Dlo, params.D)
+	}
+	if params.Dhi < params.D || params.Dhi > gossipMaxDhi {
+		return params, fmt.Errorf("gossip Dhi %d should be in [D %d, %d]", params.Dhi, params.D, gossipMaxDhi)
+	}
+	if params.HeartbeatInterval < gossipMinHeartbeat || params.HeartbeatInterval > gossipMaxHeartbeat {
+		return params, fmt.Errorf("gossip heartbeat %s should be in [%s, %s]", params.HeartbeatInterval, gossipMinHeartbeat, gossipMaxHeartbeat)
+	}
+	if params.HistoryLength < 1 || params.HistoryLength > gossipMaxHistory {
+		return params, fmt.Errorf("gossip history %d should be in [1, %d]", params.HistoryLength, gossipMaxHistory)
+	}
+	if params.HistoryGossip < 1 || params.HistoryGossip > params.HistoryLength {
+		return params, fmt.Errorf("gossip window %d should be in [1, history %d]", params.HistoryGossip, params.HistoryLength)
+	}
+
+	// the outbound quota must be less than Dlo and at most D/2, the peers kept by score at most D
+	if params.Dout >= params.Dlo {
+		params.Dout = params.Dlo - 1
+	}
+	if params.Dout > params.D/2 {
+		params.Dout = params.D / 2
+	}
+	if params.Dscore > params.D {
+		params.Dscore = params.D
+	}
+	return params, nil
+}
@@ -0,0 +1,44 @@
+package p2p
+
+import (
+	"testing"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/rumsystem/quorum/internal/pkg/options"
+)
+
+func TestGossipSubParams(t *testing.T) {
+	params, err := GossipSubParams(&options.NodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params != pubsub.DefaultGossipSubParams() {
+		t.Errorf("want the default params without the gossip options")
+	}
+
+	// a small private network
+	params, err = GossipSubParams(&options.NodeOptions{GossipD: 3, GossipDlo: 2, GossipDhi: 4, GossipHeartbeat: 500 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.D != 3 || params.Dlo != 2 || params.Dhi != 4 || params.HeartbeatInterval != 500*time.Millisecond {
+		t.Errorf("gossip options are not applied: %+v", params)
+	}
+	if params.Dout >= params.Dlo || params.Dout > params.D/2 || params.Dscore > params.D {
+		t.Errorf("Dout %d and Dscore %d should fit the degree", params.Dout, params.Dscore)
+	}
+
+	for _, opt := range []*options.NodeOptions{
+		{GossipD: 40},
+		{GossipD: 4},                             // Dlo 5 > D
+		{GossipD: 8, GossipDlo: 6, GossipDhi: 7}, // Dhi < D
+		{GossipHeartbeat: 10 * time.Millisecond},
+		{GossipHistory: 2}, // window 3 > history
+		{GossipHistory: 10, GossipWindow: 11},
+	} {
+		if _, err := GossipSubParams(opt); err == nil {
+			t.Errorf("want error for %+v", opt)
+		}
+	}
+}
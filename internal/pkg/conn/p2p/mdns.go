@@ -0,0 +1,43 @@
+//go:build !js
+// +build !js
+
+package p2p
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/discovery/mdns"
+)
+
+type mdnsNotifee struct {
+	ctx  context.Context
+	node *Node
+}
+
+func (n *mdnsNotifee) HandlePeerFound(pi peer.AddrInfo) {
+	if n.node.connectDiscoveredPeer(n.ctx, pi) {
+		networklog.Infof("connected mdns peer %s", pi.ID)
+	}
+}
+
+// MdnsServiceName is the mdns service of the network, nodes of other networks on the lan are not discovered
+func MdnsServiceName(networkname string) string {
+	return fmt.Sprintf("_quorum-%s._udp", networkname)
+}
+
+// StartMdns discovers the peers on the local network by mdns, the found peers are connected like the peers found by dht,
+// the connections are checked by the peer gater
+func (node *Node) StartMdns(ctx context.Context) error {
+	service := mdns.NewMdnsService(node.Host, MdnsServiceName(node.NetworkName), &mdnsNotifee{ctx: ctx, node: node})
+	if err := service.Start(); err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		service.Close()
+	}()
+	networklog.Infof("Enable mdns discovery: %s", MdnsServiceName(node.NetworkName))
+	return nil
+}
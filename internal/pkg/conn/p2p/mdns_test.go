@@ -0,0 +1,48 @@
+//go:build !js
+// +build !js
+
+package p2p
+
+import (
+	"context"
+	"testing"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestMdnsNotifee(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gater := NewPeerGater(nil, nil)
+	h1, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"), libp2p.ConnectionGater(gater))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h1.Close()
+	h2, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h2.Close()
+	h3, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h3.Close()
+	gater.Block(h3.ID())
+
+	notifee := &mdnsNotifee{ctx: ctx, node: &Node{Host: h1, PeerGater: gater}}
+	notifee.HandlePeerFound(peer.AddrInfo{ID: h1.ID(), Addrs: h1.Addrs()})
+	notifee.HandlePeerFound(peer.AddrInfo{ID: h2.ID(), Addrs: h2.Addrs()})
+	notifee.HandlePeerFound(peer.AddrInfo{ID: h3.ID(), Addrs: h3.Addrs()})
+
+	if h1.Network().Connectedness(h2.ID()) != network.Connected {
+		t.Errorf("expect found peer connected")
+	}
+	if h1.Network().Connectedness(h3.ID()) == network.Connected {
+		t.Errorf("expect blocked peer not connected")
+	}
+}
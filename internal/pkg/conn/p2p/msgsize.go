@@ -0,0 +1,50 @@
+package p2p
+
+import (
+	"fmt"
+
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
+)
+
+/*
+Two limits are enforced on the messages of a node:
+
+	maxMessageSize   the pubsub messages and the trxs sent over the chain data exchange, set by the maxmessagesize option
+	MessageSizeMax   a chain data exchange message, which may carry many blocks in a sync response
+
+A message over its limit is refused when it is published, the peers would drop it anyway. The trx data is limited to
+300kb before the message is checked, a larger content should be split into several trxs which refer to each other,
+or stored out of the chain with only its link and hash posted.
+*/
+const (
+	DefaultMaxMessageSize = 1 << 20 // the libp2p pubsub default
+	minMaxMessageSize     = 64 << 10
+)
+
+var maxMessageSize = DefaultMaxMessageSize
+
+// SetMaxMessageSize sets the limit of the pubsub messages, 0 keeps the default. The peers of a group should use the
+// same limit, a message over the limit of a peer is dropped by it.
+func SetMaxMessageSize(size int) error {
+	if size == 0 {
+		size = DefaultMaxMessageSize
+	}
+	if size < minMaxMessageSize || size > MessageSizeMax {
+		return fmt.Errorf("max message size %d should be in [%d, %d]", size, minMaxMessageSize, MessageSizeMax)
+	}
+	maxMessageSize = size
+	return nil
+}
+
+// MaxMessageSize returns the limit of the pubsub messages
+func MaxMessageSize() int {
+	return maxMessageSize
+}
+
+// CheckMessageSize returns an error wrapping ErrMessageTooLarge if size is over limit
+func CheckMessageSize(size int, limit int) error {
+	if size > limit {
+		return fmt.Errorf("%w: %d bytes over the limit %d bytes, split the content into several trxs or post a link to it instead", rumerrors.ErrMessageTooLarge, size, limit)
+	}
+	return nil
+}
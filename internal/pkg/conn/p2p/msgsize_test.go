@@ -0,0 +1,36 @@
+package p2p
+
+import (
+	"errors"
+	"testing"
+
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
+)
+
+func TestSetMaxMessageSize(t *testing.T) {
+	defer SetMaxMessageSize(0)
+
+	if err := SetMaxMessageSize(0); err != nil || MaxMessageSize() != DefaultMaxMessageSize {
+		t.Fatalf("want the default size, got %d %v", MaxMessageSize(), err)
+	}
+	if err := SetMaxMessageSize(4 << 20); err != nil || MaxMessageSize() != 4<<20 {
+		t.Fatalf("want 4MiB, got %d %v", MaxMessageSize(), err)
+	}
+	for _, size := range []int{1024, MessageSizeMax + 1} {
+		if err := SetMaxMessageSize(size); err == nil {
+			t.Errorf("size %d should be rejected", size)
+		}
+	}
+	if MaxMessageSize() != 4<<20 {
+		t.Errorf("a rejected size should not be set, got %d", MaxMessageSize())
+	}
+}
+
+func TestCheckMessageSize(t *testing.T) {
+	if err := CheckMessageSize(100, 100); err != nil {
+		t.Errorf("size at the limit should pass, got %s", err)
+	}
+	if err := CheckMessageSize(101, 100); !errors.Is(err, rumerrors.ErrMessageTooLarge) {
+		t.Errorf("want ErrMessageTooLarge, got %v", err)
+	}
+}
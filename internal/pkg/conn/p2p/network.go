@@ -2,18 +2,21 @@ package p2p
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/libp2p/go-libp2p-kad-dht/dual"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
 	"github.com/libp2p/go-libp2p/core/event"
 	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/metrics"
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 	discoveryrouting "github.com/libp2p/go-libp2p/p2p/discovery/routing"
 	"github.com/rumsystem/quorum/internal/pkg/logging"
 	"github.com/rumsystem/quorum/internal/pkg/metric"
 	"github.com/rumsystem/quorum/internal/pkg/options"
+	"github.com/rumsystem/quorum/internal/pkg/stats"
 )
 
 const ProtocolPrefix string = "/quorum"
@@ -32,12 +35,37 @@ type Node struct {
 	SkipPeers        []string
 	Pubsub           *pubsub.PubSub
 	RumExchange      *RexService
+	PingService      *PingService
 	Ddht             *dual.DHT
 	Info             *NodeInfo
 	RoutingDiscovery *discoveryrouting.RoutingDiscovery
+	PeerGater        *PeerGater
+	Bandwidth        *metrics.BandwidthCounter
+	OutboundThrottle *OutboundThrottle
+	ConnMgr          *ConnMgr
+	RelaySource      *RelaySource // nil if relay is disabled
 	//PubSubConnMgr    *pubsubconn.PubSubConnMgr
 	//peerStatus       *PeerStatus
 	Nodeopt *options.NodeOptions
+
+	persistentMu    sync.Mutex
+	persistentPeers map[peer.ID]*persistentPeer // nil until KeepPersistentPeers is called
+}
+
+// recordPeerStats counts the peers connected and disconnected in the node stats, a peer may have several conns
+func (node *Node) recordPeerStats() {
+	node.Host.Network().Notify(&network.NotifyBundle{
+		ConnectedF: func(n network.Network, c network.Conn) {
+			if len(n.ConnsToPeer(c.RemotePeer())) == 1 {
+				stats.Inc(stats.PeerConnect, "", 1)
+			}
+		},
+		DisconnectedF: func(n network.Network, c network.Conn) {
+			if n.Connectedness(c.RemotePeer()) != network.Connected {
+				stats.Inc(stats.PeerDisconnect, "", 1)
+			}
+		},
+	})
 }
 
 func (node *Node) eventhandler(ctx context.Context) {
@@ -134,7 +162,12 @@ func (node *Node) SetRumExchange(ctx context.Context) {
 	//peerStatus := NewPeerStatus()
 	var rexservice *RexService
 	//rexservice = NewRexService(node.Host, node.PubSubConnMgr, node.NetworkName, ProtocolPrefix)
-	rexservice = NewRexService(node.Host, node.NetworkName, ProtocolPrefix)
+	inboundLimit := 0
+	if node.Nodeopt != nil {
+		inboundLimit = node.Nodeopt.RexBufferSize
+	}
+	rexservice = NewRexService(node.Host, node.NetworkName, ProtocolPrefix, inboundLimit)
+	rexservice.Throttle = node.OutboundThrottle
 	rexservice.SetDelegate()
 	rexchaindata := NewRexChainData(rexservice)
 	rexservice.SetHandlerMatchMsgType("rumchaindata", rexchaindata.Handler)
@@ -5,6 +5,7 @@ package p2p
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -20,13 +21,14 @@ import (
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
 	p2pcrypto "github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/metrics"
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/core/protocol"
 	"github.com/libp2p/go-libp2p/core/routing"
 	discoveryrouting "github.com/libp2p/go-libp2p/p2p/discovery/routing"
 	"github.com/libp2p/go-libp2p/p2p/host/autorelay"
-	connmgr "github.com/libp2p/go-libp2p/p2p/net/connmgr"
+	quic "github.com/libp2p/go-libp2p/p2p/transport/quic"
 	tcp "github.com/libp2p/go-libp2p/p2p/transport/tcp"
 	ws "github.com/libp2p/go-libp2p/p2p/transport/websocket"
 	maddr "github.com/multiformats/go-multiaddr"
@@ -40,7 +42,7 @@ func GetRelayPeerChan() chan peer.AddrInfo {
 	return peerChan
 }
 
-func NewNode(ctx context.Context, nodename string, nodeopt *options.NodeOptions, isBootstrap bool, key *ethkeystore.Key, cmgr *connmgr.BasicConnMgr, listenAddresses []maddr.Multiaddr, skippeers []string, jsontracerfile string) (*Node, error) {
+func NewNode(ctx context.Context, nodename string, nodeopt *options.NodeOptions, isBootstrap bool, key *ethkeystore.Key, cmgr *ConnMgr, listenAddresses []maddr.Multiaddr, skippeers []string, jsontracerfile string) (*Node, error) {
 	var ddht *dual.DHT
 	var routingDiscovery *discoveryrouting.RoutingDiscovery
 	var err error
@@ -75,10 +77,15 @@ func NewNode(ctx context.Context, nodename string, nodeopt *options.NodeOptions,
 
 	identity := libp2p.Identity(priv)
 
+	gater := NewPeerGater(nodeopt.PeerAllowList, nodeopt.PeerBlockList)
+	bandwidth := metrics.NewBandwidthCounter()
+
 	libp2poptions := []libp2p.Option{routing,
 		libp2p.ListenAddrs(listenAddresses...),
 		libp2p.NATPortMap(),
 		libp2p.ConnectionManager(cmgr),
+		libp2p.ConnectionGater(gater),
+		libp2p.BandwidthReporter(bandwidth),
 		libp2p.Ping(false),
 		libp2p.ChainOptions(
 			libp2p.Transport(tcp.NewTCPTransport),
@@ -87,10 +94,25 @@ func NewNode(ctx context.Context, nodename string, nodeopt *options.NodeOptions,
 		identity,
 	}
 
+	// quic is optional, the node still works over tcp where udp is blocked
+	if err := checkListenAddrs(listenAddresses, nodeopt.EnableQuic); err != nil {
+		return nil, err
+	}
+	if nodeopt.EnableQuic {
+		libp2poptions = append(libp2poptions, libp2p.Transport(quic.NewTransport))
+		networklog.Infof("QUIC transport enabled")
+	}
+
+	var relaySource *RelaySource
 	if nodeopt.EnableRelay {
+		preferred, err := ParsePeerAddrs(nodeopt.PreferredRelays)
+		if err != nil {
+			return nil, err
+		}
+		relaySource = NewRelaySource(preferred, peerChan)
 		libp2poptions = append(libp2poptions,
 			libp2p.EnableAutoRelay(
-				autorelay.WithPeerSource(func(context.Context, int) <-chan peer.AddrInfo { return peerChan }),
+				autorelay.WithPeerSource(relaySource.PeerSource),
 				autorelay.WithMaxCandidates(1),
 				autorelay.WithNumRelays(99999),
 				autorelay.WithBootDelay(0)),
@@ -115,8 +137,7 @@ func NewNode(ctx context.Context, nodename string, nodeopt *options.NodeOptions,
 		return nil, err
 	}
 	// configure our own ping protocol
-	pingService := &PingService{Host: host}
-	host.SetStreamHandler(PingID, pingService.PingHandler)
+	pingService := NewPingService(host)
 
 	pubsubblocklist := pubsub.NewMapBlacklist()
 
@@ -130,7 +151,10 @@ func NewNode(ctx context.Context, nodename string, nodeopt *options.NodeOptions,
 			}
 		}
 	}
-	options := []pubsub.Option{pubsub.WithPeerExchange(true), pubsub.WithPeerOutboundQueueSize(128), pubsub.WithBlacklist(pubsubblocklist)}
+	if err := SetMaxMessageSize(nodeopt.MaxMessageSize); err != nil {
+		return nil, err
+	}
+	options := []pubsub.Option{pubsub.WithPeerExchange(true), pubsub.WithPeerOutboundQueueSize(128), pubsub.WithBlacklist(pubsubblocklist), pubsub.WithMaxMessageSize(MaxMessageSize())}
 
 	networklog.Infof("Network Name %s", nodenetworkname)
 	if isBootstrap {
@@ -142,6 +166,12 @@ func NewNode(ctx context.Context, nodename string, nodeopt *options.NodeOptions,
 		pubsub.GossipSubDout = 0
 		pubsub.GossipSubDlazy = 1024
 		pubsub.GossipSubGossipFactor = 0.5
+	} else {
+		params, err := GossipSubParams(nodeopt)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, pubsub.WithGossipSubParams(params))
 	}
 
 	var ps *pubsub.PubSub
@@ -179,12 +209,84 @@ func NewNode(ctx context.Context, nodename string, nodeopt *options.NodeOptions,
 	//psPing.EnablePing()
 
 	info := &NodeInfo{NATType: network.ReachabilityUnknown}
-	newnode := &Node{NetworkName: nodenetworkname, NodeName: nodename, Host: host, SkipPeers: skippeers, Pubsub: ps, Ddht: ddht, RoutingDiscovery: routingDiscovery, Info: info, Nodeopt: nodeopt}
+	newnode := &Node{NetworkName: nodenetworkname, NodeName: nodename, Host: host, SkipPeers: skippeers, Pubsub: ps, Ddht: ddht, RoutingDiscovery: routingDiscovery, Info: info, Nodeopt: nodeopt, PingService: pingService, PeerGater: gater, Bandwidth: bandwidth, OutboundThrottle: NewOutboundThrottle(nodeopt.OutboundLimit), ConnMgr: cmgr, RelaySource: relaySource}
 
+	newnode.recordPeerStats()
 	go newnode.eventhandler(ctx)
 	return newnode, nil
 }
 
+// BlockPeer adds the peer to the blocklist and disconnects it
+func (node *Node) BlockPeer(pid peer.ID) error {
+	if !node.PeerGater.Block(pid) {
+		return nil
+	}
+	if err := node.savePeerLists(); err != nil {
+		return err
+	}
+	return node.Host.Network().ClosePeer(pid)
+}
+
+func (node *Node) UnblockPeer(pid peer.ID) error {
+	if !node.PeerGater.Unblock(pid) {
+		return nil
+	}
+	return node.savePeerLists()
+}
+
+// AllowPeer adds the peer to the allowlist, the allowlist is checked on new connections only,
+// the connected peers are kept
+func (node *Node) AllowPeer(pid peer.ID) error {
+	if !node.PeerGater.Allow(pid) {
+		return nil
+	}
+	return node.savePeerLists()
+}
+
+func (node *Node) DisallowPeer(pid peer.ID) error {
+	if !node.PeerGater.Disallow(pid) {
+		return nil
+	}
+	return node.savePeerLists()
+}
+
+// SetPreferredRelays saves the relays tried first for the relay reservation, the reservation held is kept until it drops
+func (node *Node) SetPreferredRelays(addrs []string) error {
+	if node.RelaySource == nil {
+		return errors.New("relay is not enabled")
+	}
+	preferred, err := ParsePeerAddrs(addrs)
+	if err != nil {
+		return err
+	}
+	if err := node.Nodeopt.SetPreferredRelays(addrs); err != nil {
+		return err
+	}
+	node.RelaySource.SetPreferred(preferred)
+	return nil
+}
+
+// ResetPeerLists replaces the peer lists without saving them, e.g. on reloading the options file,
+// the connected peers in the new blocklist are disconnected
+func (node *Node) ResetPeerLists(allow, block []string) {
+	node.PeerGater.Reset(allow, block)
+	node.closeBlockedPeers()
+}
+
+func (node *Node) savePeerLists() error {
+	return node.Nodeopt.SetPeerLists(node.PeerGater.AllowList(), node.PeerGater.BlockList())
+}
+
+func (node *Node) closeBlockedPeers() {
+	for _, pid := range node.Host.Network().Peers() {
+		if node.PeerGater.Blocked(pid) {
+			if err := node.Host.Network().ClosePeer(pid); err != nil {
+				networklog.Warningf("close peer %s failed: %s", pid, err)
+			}
+		}
+	}
+}
+
 func (node *Node) Bootstrap(ctx context.Context, bootstrapPeers cli.AddrList) error {
 	return bootstrap(ctx, node.Host, bootstrapPeers)
 }
@@ -207,6 +309,25 @@ func bootstrap(ctx context.Context, h host.Host, addrs cli.AddrList) error {
 	return nil
 }
 
+// connectDiscoveredPeer connects to a peer found by discovery, it skips the node itself and the skip peers
+func (node *Node) connectDiscoveredPeer(ctx context.Context, pi peer.AddrInfo) bool {
+	if pi.ID == node.Host.ID() {
+		return false
+	}
+	for _, sp := range node.SkipPeers {
+		if sp == pi.ID.Pretty() {
+			return false
+		}
+	}
+	pctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+	if err := node.Host.Connect(pctx, pi); err != nil {
+		networklog.Warningf("connect peer failure: %s", pi)
+		return false
+	}
+	return true
+}
+
 func (node *Node) ConnectPeers(ctx context.Context, peerok chan struct{}, maxpeers int, rendezvousStr string) error {
 	notify := false
 	ticker := time.NewTicker(time.Second * 30)
@@ -225,26 +346,7 @@ func (node *Node) ConnectPeers(ctx context.Context, peerok chan struct{}, maxpee
 					return err
 				}
 				for _, peer := range peers {
-					if peer.ID == node.Host.ID() {
-						continue
-					}
-					skip := false
-					for _, sp := range node.SkipPeers {
-						if sp == peer.ID.Pretty() {
-							skip = true
-						}
-					}
-					if skip == true {
-						continue
-					}
-					pctx, cancel := context.WithTimeout(ctx, time.Second*10)
-					defer cancel()
-					err := node.Host.Connect(pctx, peer)
-					if err != nil {
-						networklog.Warningf("connect peer failure: %s", peer)
-						cancel()
-						continue
-					} else {
+					if node.connectDiscoveredPeer(ctx, peer) {
 						connectedCount++
 					}
 				}
@@ -0,0 +1,148 @@
+package p2p
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/control"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// PeerGater is the connection gater of the node, it refuses the blocked peers,
+// and the peers not in the allowlist if the allowlist is not empty.
+// The lists are checked on new connections, only blocking a peer closes its connections.
+type PeerGater struct {
+	mu    sync.RWMutex
+	allow map[peer.ID]struct{}
+	block map[peer.ID]struct{}
+}
+
+func NewPeerGater(allow, block []string) *PeerGater {
+	return &PeerGater{
+		allow: decodePeerSet(allow),
+		block: decodePeerSet(block),
+	}
+}
+
+func decodePeerSet(ids []string) map[peer.ID]struct{} {
+	set := make(map[peer.ID]struct{}, len(ids))
+	for _, s := range ids {
+		pid, err := peer.Decode(s)
+		if err != nil {
+			networklog.Warningf("skip invalid peer id %s in the peer list: %s", s, err)
+			continue
+		}
+		set[pid] = struct{}{}
+	}
+	return set
+}
+
+// Reset replaces the allowlist and the blocklist
+func (g *PeerGater) Reset(allow, block []string) {
+	allowSet, blockSet := decodePeerSet(allow), decodePeerSet(block)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.allow, g.block = allowSet, blockSet
+}
+
+// Allowed reports whether the node may connect to the peer
+func (g *PeerGater) Allowed(pid peer.ID) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if _, ok := g.block[pid]; ok {
+		return false
+	}
+	if len(g.allow) == 0 {
+		return true
+	}
+	_, ok := g.allow[pid]
+	return ok
+}
+
+// Blocked reports whether the peer is in the blocklist
+func (g *PeerGater) Blocked(pid peer.ID) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	_, ok := g.block[pid]
+	return ok
+}
+
+// Block adds the peer to the blocklist, it returns false if the peer was blocked
+func (g *PeerGater) Block(pid peer.ID) bool {
+	return g.add(g.block, pid)
+}
+
+func (g *PeerGater) Unblock(pid peer.ID) bool {
+	return g.del(g.block, pid)
+}
+
+// Allow adds the peer to the allowlist, it returns false if the peer was allowed
+func (g *PeerGater) Allow(pid peer.ID) bool {
+	return g.add(g.allow, pid)
+}
+
+func (g *PeerGater) Disallow(pid peer.ID) bool {
+	return g.del(g.allow, pid)
+}
+
+func (g *PeerGater) AllowList() []string {
+	return g.list(g.allow)
+}
+
+func (g *PeerGater) BlockList() []string {
+	return g.list(g.block)
+}
+
+func (g *PeerGater) add(set map[peer.ID]struct{}, pid peer.ID) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := set[pid]; ok {
+		return false
+	}
+	set[pid] = struct{}{}
+	return true
+}
+
+func (g *PeerGater) del(set map[peer.ID]struct{}, pid peer.ID) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := set[pid]; !ok {
+		return false
+	}
+	delete(set, pid)
+	return true
+}
+
+func (g *PeerGater) list(set map[peer.ID]struct{}) []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	ids := make([]string, 0, len(set))
+	for pid := range set {
+		ids = append(ids, pid.String())
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (g *PeerGater) InterceptPeerDial(p peer.ID) bool {
+	return g.Allowed(p)
+}
+
+func (g *PeerGater) InterceptAddrDial(p peer.ID, _ ma.Multiaddr) bool {
+	return g.Allowed(p)
+}
+
+// InterceptAccept allows all inbound connections, the remote peer is checked after the handshake by InterceptSecured
+func (g *PeerGater) InterceptAccept(network.ConnMultiaddrs) bool {
+	return true
+}
+
+func (g *PeerGater) InterceptSecured(_ network.Direction, p peer.ID, _ network.ConnMultiaddrs) bool {
+	return g.Allowed(p)
+}
+
+func (g *PeerGater) InterceptUpgraded(network.Conn) (bool, control.DisconnectReason) {
+	return true, 0
+}
@@ -0,0 +1,65 @@
+package p2p
+
+import (
+	"crypto/rand"
+	"testing"
+
+	p2pcrypto "github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func newTestPeerID(t *testing.T) peer.ID {
+	_, pub, err := p2pcrypto.GenerateSecp256k1Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := peer.IDFromPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pid
+}
+
+func TestPeerGater(t *testing.T) {
+	p1, p2, p3 := newTestPeerID(t), newTestPeerID(t), newTestPeerID(t)
+
+	g := NewPeerGater(nil, []string{p1.String(), "invalid"})
+	if g.Allowed(p1) || g.InterceptPeerDial(p1) || g.InterceptSecured(network.DirInbound, p1, nil) {
+		t.Errorf("expect blocked peer %s refused", p1)
+	}
+	if !g.Allowed(p2) || !g.InterceptSecured(network.DirInbound, p2, nil) {
+		t.Errorf("expect peer %s allowed with an empty allowlist", p2)
+	}
+	if !g.Blocked(p1) || g.Blocked(p2) {
+		t.Errorf("expect only peer %s blocked", p1)
+	}
+
+	if !g.Allow(p2) || g.Allow(p2) {
+		t.Errorf("expect allow to report whether the allowlist changed")
+	}
+	if !g.Allowed(p2) || g.Allowed(p3) {
+		t.Errorf("expect only peers in the allowlist allowed")
+	}
+	if g.Blocked(p3) {
+		t.Errorf("expect peer %s not in the allowlist not blocked, its connections are kept", p3)
+	}
+	g.Allow(p1)
+	if g.Allowed(p1) {
+		t.Errorf("expect blocklist to take precedence over allowlist")
+	}
+
+	if !g.Unblock(p1) || g.Unblock(p1) || !g.Allowed(p1) {
+		t.Errorf("expect unblocked peer %s in the allowlist allowed", p1)
+	}
+	g.Disallow(p1)
+	g.Disallow(p2)
+	if !g.Allowed(p3) || len(g.AllowList()) != 0 || len(g.BlockList()) != 0 {
+		t.Errorf("expect all peers allowed with empty lists")
+	}
+
+	g.Reset([]string{p2.String()}, []string{p3.String()})
+	if g.Allowed(p1) || !g.Allowed(p2) || g.Allowed(p3) {
+		t.Errorf("expect the lists replaced on reset")
+	}
+}
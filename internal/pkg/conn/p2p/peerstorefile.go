@@ -0,0 +1,127 @@
+package p2p
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	maddr "github.com/multiformats/go-multiaddr"
+)
+
+const PeerstoreFileVersion = 1
+
+// ImportedAddrTTL is the ttl of the imported addrs, they are replaced by fresh addrs once the peers are connected
+const ImportedAddrTTL = 24 * time.Hour
+
+type PeerstoreEntry struct {
+	Id        string   `json:"id"`
+	Addrs     []string `json:"addrs"`
+	Protocols []string `json:"protocols,omitempty"`
+}
+
+// PeerstoreFile is the portable form of the peerstore, to warm start a new node from a known peer set
+type PeerstoreFile struct {
+	Version    int              `json:"version"`
+	ExportedAt time.Time        `json:"exported_at"`
+	Peers      []PeerstoreEntry `json:"peers"`
+}
+
+// ExportPeerstore returns the peers with addrs in the peerstore, except the node itself
+func ExportPeerstore(ps peerstore.Peerstore, self peer.ID) *PeerstoreFile {
+	file := &PeerstoreFile{Version: PeerstoreFileVersion, ExportedAt: time.Now(), Peers: []PeerstoreEntry{}}
+	for _, pid := range ps.PeersWithAddrs() {
+		if pid == self {
+			continue
+		}
+		addrs := ps.Addrs(pid)
+		if len(addrs) == 0 {
+			continue
+		}
+		entry := PeerstoreEntry{Id: pid.String()}
+		for _, addr := range addrs {
+			entry.Addrs = append(entry.Addrs, addr.String())
+		}
+		if protos, err := ps.GetProtocols(pid); err == nil {
+			for _, proto := range protos {
+				entry.Protocols = append(entry.Protocols, string(proto))
+			}
+			sort.Strings(entry.Protocols)
+		}
+		file.Peers = append(file.Peers, entry)
+	}
+	sort.Slice(file.Peers, func(i, j int) bool { return file.Peers[i].Id < file.Peers[j].Id })
+	return file
+}
+
+func WritePeerstore(w io.Writer, ps peerstore.Peerstore, self peer.ID) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(ExportPeerstore(ps, self))
+}
+
+// ImportPeerstore merges the peers of the file into the peerstore, the entries failed to parse are skipped.
+// It returns the imported peers.
+func ImportPeerstore(r io.Reader, ps peerstore.Peerstore, self peer.ID) ([]peer.AddrInfo, int, error) {
+	var file PeerstoreFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, 0, fmt.Errorf("decode peerstore file failed: %s", err)
+	}
+	if file.Version != PeerstoreFileVersion {
+		return nil, 0, fmt.Errorf("unsupported peerstore file version %d", file.Version)
+	}
+
+	imported := []peer.AddrInfo{}
+	skipped := 0
+	for _, entry := range file.Peers {
+		pid, err := peer.Decode(entry.Id)
+		if err != nil || pid == self {
+			skipped++
+			continue
+		}
+		addrs := make([]maddr.Multiaddr, 0, len(entry.Addrs))
+		for _, s := range entry.Addrs {
+			addr, err := maddr.NewMultiaddr(s)
+			if err != nil {
+				continue
+			}
+			addrs = append(addrs, addr)
+		}
+		if len(addrs) == 0 {
+			skipped++
+			continue
+		}
+
+		ps.AddAddrs(pid, addrs, ImportedAddrTTL)
+		if len(entry.Protocols) > 0 {
+			protos := make([]protocol.ID, 0, len(entry.Protocols))
+			for _, proto := range entry.Protocols {
+				protos = append(protos, protocol.ID(proto))
+			}
+			ps.AddProtocols(pid, protos...)
+		}
+		imported = append(imported, peer.AddrInfo{ID: pid, Addrs: addrs})
+	}
+	return imported, skipped, nil
+}
+
+// ImportPeerstoreFile merges the peers of the exported peerstore file into the peerstore of the node
+func (node *Node) ImportPeerstoreFile(path string) ([]peer.AddrInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	peers, skipped, err := ImportPeerstore(f, node.Host.Peerstore(), node.Host.ID())
+	if err != nil {
+		return nil, err
+	}
+	networklog.Infof("imported %d peers from %s, skipped %d invalid entries", len(peers), path, skipped)
+	return peers, nil
+}
@@ -0,0 +1,107 @@
+package p2p
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/peerstore"
+	"github.com/libp2p/go-libp2p/p2p/host/peerstore/pstoremem"
+	maddr "github.com/multiformats/go-multiaddr"
+)
+
+func newTestPeerstore(t *testing.T) peerstore.Peerstore {
+	ps, err := pstoremem.NewPeerstore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ps.Close() })
+	return ps
+}
+
+func TestPeerstoreRoundTrip(t *testing.T) {
+	self, p1, p2 := newTestPeerID(t), newTestPeerID(t), newTestPeerID(t)
+	addr1 := maddr.StringCast("/ip4/10.0.0.1/tcp/7002")
+	addr2 := maddr.StringCast("/ip4/10.0.0.2/udp/7002/quic-v1")
+
+	src := newTestPeerstore(t)
+	src.AddAddr(self, maddr.StringCast("/ip4/127.0.0.1/tcp/7002"), peerstore.PermanentAddrTTL)
+	src.AddAddr(p1, addr1, peerstore.PermanentAddrTTL)
+	src.AddAddr(p2, addr2, peerstore.PermanentAddrTTL)
+	if err := src.AddProtocols(p1, "/quorum/nevis/meshsub/1.1.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := WritePeerstore(&buf, src, self); err != nil {
+		t.Fatal(err)
+	}
+
+	existing := maddr.StringCast("/ip4/192.168.1.1/tcp/7002")
+	dst := newTestPeerstore(t)
+	dst.AddAddr(p1, existing, peerstore.PermanentAddrTTL)
+
+	peers, skipped, err := ImportPeerstore(&buf, dst, self)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(peers) != 2 || skipped != 0 {
+		t.Fatalf("expect 2 peers imported and none skipped, got %d and %d", len(peers), skipped)
+	}
+	if len(dst.Addrs(p1)) != 2 {
+		t.Errorf("expect the imported addr merged with the existing addr, got %v", dst.Addrs(p1))
+	}
+	protos, err := dst.GetProtocols(p1)
+	if err != nil || len(protos) != 1 || protos[0] != "/quorum/nevis/meshsub/1.1.0" {
+		t.Errorf("expect the protocols imported, got %v %v", protos, err)
+	}
+	if len(dst.Addrs(p2)) != 1 || !dst.Addrs(p2)[0].Equal(addr2) {
+		t.Errorf("expect addr %s of %s imported, got %v", addr2, p2, dst.Addrs(p2))
+	}
+	if len(dst.Addrs(self)) != 0 {
+		t.Errorf("expect the node itself not exported")
+	}
+}
+
+func TestImportPeerstoreSkipInvalid(t *testing.T) {
+	self, p1, p2 := newTestPeerID(t), newTestPeerID(t), newTestPeerID(t)
+	file := PeerstoreFile{
+		Version: PeerstoreFileVersion,
+		Peers: []PeerstoreEntry{
+			{Id: "invalid", Addrs: []string{"/ip4/10.0.0.1/tcp/7002"}},
+			{Id: self.String(), Addrs: []string{"/ip4/10.0.0.1/tcp/7002"}},
+			{Id: p1.String(), Addrs: []string{"invalid"}},
+			{Id: p2.String(), Addrs: []string{"invalid", "/ip4/10.0.0.2/tcp/7002"}},
+		},
+	}
+	data, err := json.Marshal(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ps := newTestPeerstore(t)
+	peers, skipped, err := ImportPeerstore(bytes.NewReader(data), ps, self)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(peers) != 1 || peers[0].ID != p2 || len(peers[0].Addrs) != 1 {
+		t.Errorf("expect only %s imported with the valid addr, got %v", p2, peers)
+	}
+	if skipped != 3 {
+		t.Errorf("expect 3 entries skipped, got %d", skipped)
+	}
+	if len(ps.Addrs(p1)) != 0 || len(ps.Addrs(self)) != 0 {
+		t.Errorf("expect the skipped entries not in the peerstore")
+	}
+}
+
+func TestImportPeerstoreVersion(t *testing.T) {
+	ps := newTestPeerstore(t)
+	if _, _, err := ImportPeerstore(strings.NewReader(`{"version":2,"peers":[]}`), ps, newTestPeerID(t)); err == nil {
+		t.Errorf("expect an error for an unsupported version")
+	}
+	if _, _, err := ImportPeerstore(strings.NewReader(`not json`), ps, newTestPeerID(t)); err == nil {
+		t.Errorf("expect an error for an invalid file")
+	}
+}
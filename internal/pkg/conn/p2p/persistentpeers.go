@@ -0,0 +1,152 @@
+package p2p
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+	maddr "github.com/multiformats/go-multiaddr"
+)
+
+const persistentPeerTag = "quorum-persistent"
+
+var (
+	persistentPeerMinBackoff     = time.Second
+	persistentPeerMaxBackoff     = 5 * time.Minute
+	persistentPeerCheckInterval  = time.Minute
+	persistentPeerConnectTimeout = 10 * time.Second
+)
+
+// ParsePeerAddrs parses p2p multiaddrs, the addrs of the same peer are merged, the peers keep the order of the addrs
+func ParsePeerAddrs(addrs []string) ([]peer.AddrInfo, error) {
+	infos := []peer.AddrInfo{}
+	index := make(map[peer.ID]int)
+	for _, addr := range addrs {
+		if addr == "" {
+			continue
+		}
+		ma, err := maddr.NewMultiaddr(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid peer addr %s: %s", addr, err)
+		}
+		info, err := peer.AddrInfoFromP2pAddr(ma)
+		if err != nil {
+			return nil, fmt.Errorf("invalid peer addr %s: %s", addr, err)
+		}
+		i, ok := index[info.ID]
+		if !ok {
+			index[info.ID] = len(infos)
+			infos = append(infos, *info)
+			continue
+		}
+		infos[i].Addrs = append(infos[i].Addrs, info.Addrs...)
+	}
+	return infos, nil
+}
+
+func nextPersistentPeerBackoff(d time.Duration) time.Duration {
+	d *= 2
+	if d > persistentPeerMaxBackoff {
+		d = persistentPeerMaxBackoff
+	}
+	return d
+}
+
+type persistentPeer struct {
+	cancel  context.CancelFunc
+	dropped chan struct{}
+}
+
+// KeepPersistentPeers protects the peers from the connection manager trimming and redials them whenever they drop,
+// failed dials are retried with an exponential backoff.
+// Calling it again replaces the persistent peers, the peers not in the new list are unprotected and no longer redialed.
+func (node *Node) KeepPersistentPeers(ctx context.Context, peers []peer.AddrInfo) {
+	node.persistentMu.Lock()
+	defer node.persistentMu.Unlock()
+
+	if node.persistentPeers == nil {
+		if len(peers) == 0 {
+			return
+		}
+		node.persistentPeers = make(map[peer.ID]*persistentPeer)
+		node.Host.Network().Notify(&network.NotifyBundle{
+			DisconnectedF: func(n network.Network, c network.Conn) {
+				if n.Connectedness(c.RemotePeer()) == network.Connected {
+					return
+				}
+				node.persistentMu.Lock()
+				p, ok := node.persistentPeers[c.RemotePeer()]
+				node.persistentMu.Unlock()
+				if !ok {
+					return
+				}
+				select {
+				case p.dropped <- struct{}{}:
+				default:
+				}
+			},
+		})
+	}
+
+	keep := make(map[peer.ID]bool, len(peers))
+	for _, pi := range peers {
+		if pi.ID != node.Host.ID() {
+			keep[pi.ID] = true
+		}
+	}
+	for pid, p := range node.persistentPeers {
+		if !keep[pid] {
+			p.cancel()
+			node.Host.ConnManager().Unprotect(pid, persistentPeerTag)
+			delete(node.persistentPeers, pid)
+		}
+	}
+
+	for _, pi := range peers {
+		if !keep[pi.ID] {
+			continue
+		}
+		node.Host.Peerstore().AddAddrs(pi.ID, pi.Addrs, peerstore.PermanentAddrTTL)
+		if _, ok := node.persistentPeers[pi.ID]; ok {
+			continue
+		}
+		pctx, cancel := context.WithCancel(ctx)
+		p := &persistentPeer{cancel: cancel, dropped: make(chan struct{}, 1)}
+		node.persistentPeers[pi.ID] = p
+		node.Host.ConnManager().Protect(pi.ID, persistentPeerTag)
+		go node.keepPersistentPeer(pctx, pi, p.dropped)
+	}
+}
+
+func (node *Node) keepPersistentPeer(ctx context.Context, pi peer.AddrInfo, dropped <-chan struct{}) {
+	backoff := persistentPeerMinBackoff
+	for {
+		wait := persistentPeerCheckInterval
+		if node.Host.Network().Connectedness(pi.ID) != network.Connected {
+			cctx, cancel := context.WithTimeout(ctx, persistentPeerConnectTimeout)
+			err := node.Host.Connect(cctx, pi)
+			cancel()
+			if err != nil {
+				networklog.Warningf("connect persistent peer %s failed, retry in %s: %s", pi.ID, backoff, err)
+				wait = backoff
+				backoff = nextPersistentPeerBackoff(backoff)
+			} else {
+				networklog.Infof("connected persistent peer %s", pi.ID)
+				backoff = persistentPeerMinBackoff
+			}
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-dropped:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
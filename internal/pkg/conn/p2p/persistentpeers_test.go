@@ -0,0 +1,93 @@
+package p2p
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/network"
+)
+
+func TestParsePeerAddrs(t *testing.T) {
+	pid := "16Uiu2HAm5waftP3s4oE1EzGF2SyWeK726P5B8BSgFJqSiz6xScGz"
+	peers, err := ParsePeerAddrs([]string{
+		"/ip4/127.0.0.1/tcp/4215/p2p/" + pid,
+		"/ip4/127.0.0.1/tcp/5215/ws/p2p/" + pid,
+		"",
+	})
+	if err != nil {
+		t.Fatalf("parse persistent peers failed: %s", err)
+	}
+	if len(peers) != 1 || len(peers[0].Addrs) != 2 {
+		t.Errorf("expect addrs of the same peer merged, got %+v", peers)
+	}
+
+	if _, err := ParsePeerAddrs([]string{"/ip4/127.0.0.1/tcp/4215"}); err == nil {
+		t.Errorf("expect error for addr without peer id")
+	}
+}
+
+func TestNextPersistentPeerBackoff(t *testing.T) {
+	if d := nextPersistentPeerBackoff(time.Second); d != 2*time.Second {
+		t.Errorf("expect backoff doubled, got %s", d)
+	}
+	if d := nextPersistentPeerBackoff(persistentPeerMaxBackoff); d != persistentPeerMaxBackoff {
+		t.Errorf("expect backoff capped, got %s", d)
+	}
+}
+
+func TestKeepPersistentPeers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h1, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h1.Close()
+	h2, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h2.Close()
+
+	peers, err := ParsePeerAddrs([]string{fmt.Sprintf("%s/p2p/%s", h2.Addrs()[0], h2.ID())})
+	if err != nil {
+		t.Fatal(err)
+	}
+	node := &Node{Host: h1}
+	node.KeepPersistentPeers(ctx, peers)
+
+	waitConnected := func() bool {
+		for i := 0; i < 50; i++ {
+			if h1.Network().Connectedness(h2.ID()) == network.Connected {
+				return true
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		return false
+	}
+	if !waitConnected() {
+		t.Fatalf("expect persistent peer connected")
+	}
+	if !h1.ConnManager().IsProtected(h2.ID(), persistentPeerTag) {
+		t.Errorf("expect persistent peer protected")
+	}
+
+	h1.Network().ClosePeer(h2.ID())
+	if !waitConnected() {
+		t.Errorf("expect persistent peer redialed after disconnect")
+	}
+
+	node.KeepPersistentPeers(ctx, nil)
+	if h1.ConnManager().IsProtected(h2.ID(), persistentPeerTag) {
+		t.Errorf("expect the peer unprotected once it is removed from the persistent peers")
+	}
+	h1.Network().ClosePeer(h2.ID())
+	time.Sleep(500 * time.Millisecond)
+	if h1.Network().Connectedness(h2.ID()) == network.Connected {
+		t.Errorf("expect the removed peer not redialed")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"io"
+	"net"
 	"time"
 
 	u "github.com/ipfs/go-ipfs-util"
@@ -22,6 +23,14 @@ const PingID = "/quorum/ping/1.0.0"
 
 const pingTimeout = time.Second * 5
 
+const (
+	DefaultPingCount    = 4
+	DefaultPingInterval = time.Second
+	DefaultPingTimeout  = time.Second * 5
+)
+
+var ErrPingTimeout = errors.New("ping timeout")
+
 type PingService struct {
 	Host host.Host
 }
@@ -77,6 +86,73 @@ type Result struct {
 	Error error
 }
 
+// PingOptions controls the rounds of PingPeer
+type PingOptions struct {
+	Count    int           // number of rounds
+	Interval time.Duration // wait between rounds
+	Timeout  time.Duration // a round without reply in Timeout is lost
+}
+
+// PingPeer pings the peer `opt.Count` rounds and returns the result of each round,
+// a lost round has ErrPingTimeout and the next round is sent on a new stream
+func (ps *PingService) PingPeer(ctx context.Context, p peer.ID, opt PingOptions) []Result {
+	if opt.Count <= 0 {
+		opt.Count = DefaultPingCount
+	}
+	if opt.Timeout <= 0 {
+		opt.Timeout = DefaultPingTimeout
+	}
+
+	var s network.Stream
+	defer func() {
+		if s != nil {
+			s.Reset()
+		}
+	}()
+
+	results := make([]Result, 0, opt.Count)
+	for i := 0; i < opt.Count; i++ {
+		if i > 0 && opt.Interval > 0 {
+			select {
+			case <-time.After(opt.Interval):
+			case <-ctx.Done():
+				return results
+			}
+		}
+		if ctx.Err() != nil {
+			return results
+		}
+
+		var res Result
+		if s == nil {
+			sctx, cancel := context.WithTimeout(ctx, opt.Timeout)
+			s, res.Error = ps.Host.NewStream(sctx, p, PingID)
+			cancel()
+		}
+		if res.Error == nil {
+			s.SetDeadline(time.Now().Add(opt.Timeout))
+			res.RTT, res.Error = ping(s)
+		}
+
+		if res.Error != nil {
+			var ne net.Error
+			if errors.Is(res.Error, context.DeadlineExceeded) || (errors.As(res.Error, &ne) && ne.Timeout()) {
+				res.Error = ErrPingTimeout
+			}
+			// the stream may be stalled, open a new one for the next round
+			if s != nil {
+				s.Reset()
+				s = nil
+			}
+		} else {
+			ps.Host.Peerstore().RecordLatency(p, res.RTT)
+		}
+		results = append(results, res)
+	}
+
+	return results
+}
+
 func (ps *PingService) Ping(ctx context.Context, p peer.ID) <-chan Result {
 	// we allow transient connection pingable
 	return Ping(ctx, ps.Host, p)
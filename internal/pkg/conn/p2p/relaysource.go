@@ -0,0 +1,97 @@
+package p2p
+
+import (
+	"context"
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	maddr "github.com/multiformats/go-multiaddr"
+)
+
+// RelaySource feeds the relay candidates of autorelay, the preferred relays are offered first in order,
+// then the relays added by AddRelayServers. An unreachable relay is backed off by autorelay so the next one is tried.
+type RelaySource struct {
+	mu        sync.RWMutex
+	preferred []peer.AddrInfo
+	extra     <-chan peer.AddrInfo
+}
+
+func NewRelaySource(preferred []peer.AddrInfo, extra <-chan peer.AddrInfo) *RelaySource {
+	return &RelaySource{preferred: preferred, extra: extra}
+}
+
+func (rs *RelaySource) Preferred() []peer.AddrInfo {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	return append([]peer.AddrInfo(nil), rs.preferred...)
+}
+
+// SetPreferred replaces the preferred relays, it applies to the next search of relay candidates
+func (rs *RelaySource) SetPreferred(preferred []peer.AddrInfo) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.preferred = preferred
+}
+
+// PeerSource is the autorelay.PeerSource of the node
+func (rs *RelaySource) PeerSource(ctx context.Context, num int) <-chan peer.AddrInfo {
+	out := make(chan peer.AddrInfo)
+	go func() {
+		defer close(out)
+		sent := 0
+		for _, pi := range rs.Preferred() {
+			if sent >= num {
+				return
+			}
+			select {
+			case out <- pi:
+				sent++
+			case <-ctx.Done():
+				return
+			}
+		}
+		for sent < num {
+			select {
+			case pi := <-rs.extra:
+				select {
+				case out <- pi:
+					sent++
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+type RelayReservation struct {
+	RelayId string
+	Addrs   []maddr.Multiaddr // circuit addrs of the node through the relay
+}
+
+// RelayReservations returns the relays the node holds reservations with, by the circuit addrs the node announces
+func (node *Node) RelayReservations() []RelayReservation {
+	reservations := []RelayReservation{}
+	index := make(map[string]int)
+	for _, addr := range node.Host.Addrs() {
+		relayaddr, _ := maddr.SplitFunc(addr, func(c maddr.Component) bool { return c.Protocol().Code == maddr.P_CIRCUIT })
+		if relayaddr == nil || relayaddr.Equal(addr) {
+			continue
+		}
+		relayid, err := relayaddr.ValueForProtocol(maddr.P_P2P)
+		if err != nil {
+			continue
+		}
+		i, ok := index[relayid]
+		if !ok {
+			i = len(reservations)
+			index[relayid] = i
+			reservations = append(reservations, RelayReservation{RelayId: relayid})
+		}
+		reservations[i].Addrs = append(reservations[i].Addrs, addr)
+	}
+	return reservations
+}
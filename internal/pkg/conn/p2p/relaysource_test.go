@@ -0,0 +1,62 @@
+package p2p
+
+import (
+	"context"
+	"testing"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/peer"
+	maddr "github.com/multiformats/go-multiaddr"
+)
+
+func TestRelaySource(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	preferred, err := ParsePeerAddrs([]string{
+		"/ip4/127.0.0.1/tcp/4215/p2p/16Uiu2HAm5waftP3s4oE1EzGF2SyWeK726P5B8BSgFJqSiz6xScGz",
+		"/ip4/127.0.0.1/tcp/4216/p2p/16Uiu2HAmTovb8kAJiYK8saskzz7cRQhb45NRK5AsbtdmYsLfD3RM",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	extra := make(chan peer.AddrInfo, 1)
+	extraPeer := newTestPeerID(t)
+	extra <- peer.AddrInfo{ID: extraPeer}
+
+	rs := NewRelaySource(preferred, extra)
+	got := []peer.ID{}
+	for pi := range rs.PeerSource(ctx, 3) {
+		got = append(got, pi.ID)
+	}
+	if len(got) != 3 || got[0] != preferred[0].ID || got[1] != preferred[1].ID || got[2] != extraPeer {
+		t.Errorf("expect preferred relays in order then the added relay, got %v", got)
+	}
+
+	rs.SetPreferred(preferred[1:])
+	got = got[:0]
+	for pi := range rs.PeerSource(ctx, 1) {
+		got = append(got, pi.ID)
+	}
+	if len(got) != 1 || got[0] != preferred[1].ID {
+		t.Errorf("expect the new preferred relay, got %v", got)
+	}
+}
+
+func TestRelayReservations(t *testing.T) {
+	relayid := "16Uiu2HAm5waftP3s4oE1EzGF2SyWeK726P5B8BSgFJqSiz6xScGz"
+	circuit := maddr.StringCast("/ip4/94.23.17.189/tcp/62777/p2p/" + relayid + "/p2p-circuit")
+	h, err := libp2p.New(
+		libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"),
+		libp2p.AddrsFactory(func(addrs []maddr.Multiaddr) []maddr.Multiaddr { return append(addrs, circuit) }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	reservations := (&Node{Host: h}).RelayReservations()
+	if len(reservations) != 1 || reservations[0].RelayId != relayid || !reservations[0].Addrs[0].Equal(circuit) {
+		t.Errorf("expect the reservation of relay %s, got %+v", relayid, reservations)
+	}
+}
@@ -0,0 +1,222 @@
+package p2p
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// a request is forgotten after rexRequestTimeout even if its stream is not closed, so that it can be sent again
+const rexRequestTimeout = 10 * time.Second
+
+var errRexRequestTimeout = errors.New("rex range request timeout")
+
+// RexRange is the blocks [From, From+Num) of a group requested from the peers
+type RexRange struct {
+	GroupId string
+	From    uint64
+	Num     uint64
+}
+
+func (rng RexRange) end() uint64 {
+	return rng.From + rng.Num
+}
+
+func (rng RexRange) overlaps(other RexRange) bool {
+	return rng.GroupId == other.GroupId && rng.From < other.end() && other.From < rng.end()
+}
+
+// rexRequest is a range request sent to a peer, its waiters are notified once it is released
+type rexRequest struct {
+	peer    peer.ID
+	rng     RexRange
+	expires time.Time
+	waiters []*rexWaiter
+}
+
+// rexWaiter is a caller of a range, its range is split into the requests in flight it is merged into
+// and the new requests of the rest, done is called once all of them are released
+type rexWaiter struct {
+	pending int
+	err     error
+	done    func(error)
+}
+
+// rexRequests are the range requests in flight per peer. A range overlapping the requests in flight to a peer
+// is merged into them, only the rest of the range is requested. The responses are handled by the chain of the group,
+// so a response serves every waiter of its request, and the waiters are notified when the requests are released.
+type rexRequests struct {
+	mu       sync.Mutex
+	inflight map[peer.ID][]*rexRequest
+}
+
+func newRexRequests() *rexRequests {
+	return &rexRequests{inflight: make(map[peer.ID][]*rexRequest)}
+}
+
+// expire drops the timed out requests of the peer, it returns the waiters to notify, caller must hold the lock
+func (r *rexRequests) expire(p peer.ID, now time.Time) []*rexWaiter {
+	var finished []*rexWaiter
+	reqs := r.inflight[p][:0]
+	for _, req := range r.inflight[p] {
+		if now.After(req.expires) {
+			finished = append(finished, req.finish(errRexRequestTimeout)...)
+			continue
+		}
+		reqs = append(reqs, req)
+	}
+	if len(reqs) == 0 {
+		delete(r.inflight, p)
+	} else {
+		r.inflight[p] = reqs
+	}
+	return finished
+}
+
+// finish detaches the waiters of the request, it returns the waiters whose requests are all released
+func (req *rexRequest) finish(err error) []*rexWaiter {
+	var finished []*rexWaiter
+	for _, w := range req.waiters {
+		w.pending--
+		if err != nil && w.err == nil {
+			w.err = err
+		}
+		if w.pending == 0 {
+			finished = append(finished, w)
+		}
+	}
+	req.waiters = nil
+	return finished
+}
+
+func notify(waiters []*rexWaiter) {
+	for _, w := range waiters {
+		if w.done != nil {
+			w.done(w.err)
+		}
+	}
+}
+
+// peers returns the peers ordered by whether a request overlapping rng is in flight to them, so that
+// the range is merged into the requests in flight first
+func (r *rexRequests) peers(rng RexRange, peers []peer.ID, now time.Time) []peer.ID {
+	r.mu.Lock()
+	var finished []*rexWaiter
+	merging := []peer.ID{}
+	others := []peer.ID{}
+	for _, p := range peers {
+		finished = append(finished, r.expire(p, now)...)
+		overlapped := false
+		for _, req := range r.inflight[p] {
+			if req.rng.overlaps(rng) {
+				overlapped = true
+				break
+			}
+		}
+		if overlapped {
+			merging = append(merging, p)
+		} else {
+			others = append(others, p)
+		}
+	}
+	r.mu.Unlock()
+	notify(finished)
+	return append(merging, others...)
+}
+
+// acquire merges rng into the requests in flight to the peer overlapping it, and returns the new requests
+// of the rest of rng to be sent and released, merged is true if rng overlaps the requests in flight.
+// done is called with the first error once all of them are released.
+func (r *rexRequests) acquire(p peer.ID, rng RexRange, now time.Time, done func(error)) (w *rexWaiter, reqs []*rexRequest, merged bool) {
+	r.mu.Lock()
+	finished := r.expire(p, now)
+
+	w = &rexWaiter{done: done}
+	// the parts of rng not requested yet, the requests in flight are cut off from them
+	gaps := []RexRange{rng}
+	for _, req := range r.inflight[p] {
+		if !req.rng.overlaps(rng) {
+			continue
+		}
+		req.waiters = append(req.waiters, w)
+		w.pending++
+		rest := []RexRange{}
+		for _, gap := range gaps {
+			if !req.rng.overlaps(gap) {
+				rest = append(rest, gap)
+				continue
+			}
+			if gap.From < req.rng.From {
+				rest = append(rest, RexRange{GroupId: gap.GroupId, From: gap.From, Num: req.rng.From - gap.From})
+			}
+			if req.rng.end() < gap.end() {
+				rest = append(rest, RexRange{GroupId: gap.GroupId, From: req.rng.end(), Num: gap.end() - req.rng.end()})
+			}
+		}
+		gaps = rest
+	}
+
+	merged = w.pending > 0
+	reqs = make([]*rexRequest, 0, len(gaps))
+	for _, gap := range gaps {
+		req := &rexRequest{peer: p, rng: gap, expires: now.Add(rexRequestTimeout), waiters: []*rexWaiter{w}}
+		w.pending++
+		r.inflight[p] = append(r.inflight[p], req)
+		reqs = append(reqs, req)
+	}
+	r.mu.Unlock()
+	notify(finished)
+	return w, reqs, merged
+}
+
+// abort detaches the waiter from the requests it is merged into and drops its new requests without notifying it,
+// e.g. the requests could not be sent to the peer and the range is tried on the next one
+func (r *rexRequests) abort(w *rexWaiter, reqs []*rexRequest) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, req := range reqs {
+		r.remove(req)
+	}
+	for _, peerReqs := range r.inflight {
+		for _, req := range peerReqs {
+			for i, v := range req.waiters {
+				if v == w {
+					req.waiters = append(req.waiters[:i], req.waiters[i+1:]...)
+					break
+				}
+			}
+		}
+	}
+	w.pending = 0
+}
+
+// remove forgets the request, caller must hold the lock
+func (r *rexRequests) remove(req *rexRequest) bool {
+	reqs := r.inflight[req.peer]
+	for i, v := range reqs {
+		if v == req {
+			reqs = append(reqs[:i], reqs[i+1:]...)
+			if len(reqs) == 0 {
+				delete(r.inflight, req.peer)
+			} else {
+				r.inflight[req.peer] = reqs
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// release forgets the request once its response is handled or it failed, and notifies its waiters
+// whose requests are all released
+func (r *rexRequests) release(req *rexRequest, err error) {
+	r.mu.Lock()
+	var finished []*rexWaiter
+	if r.remove(req) {
+		finished = req.finish(err)
+	}
+	r.mu.Unlock()
+	notify(finished)
+}
@@ -0,0 +1,138 @@
+package p2p
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func rexRanges(reqs []*rexRequest) []RexRange {
+	res := []RexRange{}
+	for _, req := range reqs {
+		res = append(res, req.rng)
+	}
+	return res
+}
+
+func TestRexRequestsCoalesce(t *testing.T) {
+	reqs := newRexRequests()
+	now := time.Now()
+	groupId := "ac0eea7c-2f3c-4c67-80b3-136e46b924a8"
+	peerA, peerB := peer.ID("peer-a"), peer.ID("peer-b")
+
+	_, first, merged := reqs.acquire(peerA, RexRange{GroupId: groupId, From: 11, Num: 10}, now, nil)
+	if merged || len(first) != 1 {
+		t.Fatalf("first request should be sent, got %v %v", rexRanges(first), merged)
+	}
+	if _, sent, merged := reqs.acquire(peerA, RexRange{GroupId: groupId, From: 11, Num: 10}, now, nil); !merged || len(sent) != 0 {
+		t.Errorf("retry of the same range should be merged, got %v", rexRanges(sent))
+	}
+	if _, sent, merged := reqs.acquire(peerA, RexRange{GroupId: groupId, From: 15, Num: 5}, now, nil); !merged || len(sent) != 0 {
+		t.Errorf("covered range should be merged, got %v", rexRanges(sent))
+	}
+	if _, sent, _ := reqs.acquire(peerB, RexRange{GroupId: groupId, From: 11, Num: 10}, now, nil); len(sent) != 1 {
+		t.Errorf("range requested from another peer should be sent")
+	}
+	if _, sent, merged := reqs.acquire(peerA, RexRange{GroupId: "other", From: 11, Num: 10}, now, nil); merged || len(sent) != 1 {
+		t.Errorf("range of another group should be sent")
+	}
+
+	reqs.release(first[0], nil)
+	if _, sent, merged := reqs.acquire(peerA, RexRange{GroupId: groupId, From: 11, Num: 10}, now, nil); merged || len(sent) != 1 {
+		t.Errorf("range should be sent again after the response")
+	}
+
+	later := now.Add(rexRequestTimeout + time.Second)
+	if _, sent, merged := reqs.acquire(peerA, RexRange{GroupId: groupId, From: 11, Num: 10}, later, nil); merged || len(sent) != 1 {
+		t.Errorf("range should be sent again after the request timeout")
+	}
+}
+
+func TestRexRequestsMergeOverlapping(t *testing.T) {
+	reqs := newRexRequests()
+	now := time.Now()
+	groupId := "ac0eea7c-2f3c-4c67-80b3-136e46b924a8"
+	p := peer.ID("peer-a")
+
+	results := map[string][]error{}
+	waiter := func(name string) func(error) {
+		return func(err error) { results[name] = append(results[name], err) }
+	}
+
+	_, first, _ := reqs.acquire(p, RexRange{GroupId: groupId, From: 11, Num: 10}, now, waiter("first"))
+	_, second, _ := reqs.acquire(p, RexRange{GroupId: groupId, From: 31, Num: 10}, now, waiter("second"))
+
+	// [5, 46) overlaps both, only the gaps are requested
+	_, sent, merged := reqs.acquire(p, RexRange{GroupId: groupId, From: 5, Num: 41}, now, waiter("third"))
+	want := []RexRange{
+		{GroupId: groupId, From: 5, Num: 6},
+		{GroupId: groupId, From: 21, Num: 10},
+		{GroupId: groupId, From: 41, Num: 5},
+	}
+	if !merged || !reflect.DeepEqual(rexRanges(sent), want) {
+		t.Fatalf("want gaps %v, got %v", want, rexRanges(sent))
+	}
+
+	// the response of the first request is fanned out to both of its waiters
+	reqs.release(first[0], nil)
+	if len(results["first"]) != 1 || results["first"][0] != nil {
+		t.Errorf("first waiter should be done, got %v", results["first"])
+	}
+	if len(results["third"]) != 0 {
+		t.Errorf("third waiter should wait for the rest of its requests")
+	}
+
+	failed := errors.New("stream reset")
+	reqs.release(sent[0], nil)
+	reqs.release(sent[1], failed)
+	reqs.release(second[0], nil)
+	reqs.release(sent[2], nil)
+	reqs.release(sent[2], nil) // released twice, e.g. by the stream and after a timeout
+	if len(results["second"]) != 1 || results["second"][0] != nil {
+		t.Errorf("second waiter should be done, got %v", results["second"])
+	}
+	if len(results["third"]) != 1 || results["third"][0] != failed {
+		t.Errorf("third waiter should be done once with the error, got %v", results["third"])
+	}
+	if len(reqs.inflight) != 0 {
+		t.Errorf("all requests should be released, got %v", reqs.inflight)
+	}
+}
+
+func TestRexRequestsExpireAndAbort(t *testing.T) {
+	reqs := newRexRequests()
+	now := time.Now()
+	groupId := "ac0eea7c-2f3c-4c67-80b3-136e46b924a8"
+	p := peer.ID("peer-a")
+
+	var expired []error
+	reqs.acquire(p, RexRange{GroupId: groupId, From: 11, Num: 10}, now, func(err error) { expired = append(expired, err) })
+
+	// a waiter merged into the request is detached if its own requests are not sent
+	var aborted []error
+	w, sent, _ := reqs.acquire(p, RexRange{GroupId: groupId, From: 15, Num: 10}, now, func(err error) { aborted = append(aborted, err) })
+	reqs.abort(w, sent)
+	if n := len(reqs.inflight[p]); n != 1 {
+		t.Errorf("the new request of the aborted waiter should be dropped, %d left", n)
+	}
+
+	other := peer.ID("peer-b")
+	if ordered := reqs.peers(RexRange{GroupId: groupId, From: 20, Num: 10}, []peer.ID{other, p}, now); !reflect.DeepEqual(ordered, []peer.ID{p, other}) {
+		t.Errorf("peer with an overlapping request should be tried first, got %v", ordered)
+	}
+
+	later := now.Add(rexRequestTimeout + time.Second)
+	reqs.peers(RexRange{GroupId: groupId, From: 20, Num: 10}, []peer.ID{p}, later)
+	if len(expired) != 1 || expired[0] != errRexRequestTimeout {
+		t.Errorf("waiter of the expired request should get the timeout, got %v", expired)
+	}
+	if len(aborted) != 0 {
+		t.Errorf("aborted waiter should not be notified, got %v", aborted)
+	}
+	if len(reqs.inflight) != 0 {
+		t.Errorf("expired request should be dropped")
+	}
+}
@@ -3,6 +3,7 @@ package p2p
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"sync"
@@ -19,6 +20,7 @@ import (
 	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
 	"github.com/rumsystem/quorum/internal/pkg/logging"
 	"github.com/rumsystem/quorum/internal/pkg/metric"
+	"github.com/rumsystem/quorum/internal/pkg/stats"
 	quorumpb "github.com/rumsystem/quorum/pkg/pb"
 	"google.golang.org/protobuf/proto"
 )
@@ -26,7 +28,12 @@ import (
 var rumexchangelog = logging.Logger("rumexchange")
 
 const IDVer = "2.0.0"
-const MessageSizeMax = 1 << 24 //16MB
+const MessageSizeMax = 1 << 24 //16MB, see msgsize.go
+
+// an inbound message waits rexInboundWait for a free slot before its stream is reset,
+// and a stream holding a slot is reset if its message is not read in rexReadTimeout
+const rexInboundWait = 10 * time.Second
+const rexReadTimeout = 30 * time.Second
 
 type Chain interface {
 	HandleTrxWithRex(trx *quorumpb.Trx, from peer.ID) error
@@ -48,13 +55,22 @@ type RexService struct {
 	peerstore          *RumGroupPeerStore
 	msgtypehandlers    []RumHandler
 	msgtypehandlerlock sync.RWMutex
+	Throttle           *OutboundThrottle // limits the bytes written to the rex streams, nil means no limit
+	inbound            chan struct{}     // slots of the messages read and handled at the same time, nil means no limit
+	requests           *rexRequests      // range requests in flight
 }
 
-func NewRexService(h host.Host, Networkname string, ProtocolPrefix string) *RexService {
+// NewRexService serves the rex protocol, at most inboundLimit messages are read and handled at the same time,
+// the streams of the others wait and are reset if no slot is free in time, so that the peers back off
+// instead of the node buffering their blocks. 0 means no limit
+func NewRexService(h host.Host, Networkname string, ProtocolPrefix string, inboundLimit int) *RexService {
 	customprotocol := fmt.Sprintf("%s/%s/rex/%s", ProtocolPrefix, Networkname, IDVer)
 	chainmgr := make(map[string]chaindef.ChainDataSyncIface)
 	rumpeerstore := NewRumGroupPeerStore()
-	rexs := &RexService{Host: h, peerstore: rumpeerstore, ProtocolId: protocol.ID(customprotocol), chainmgr: chainmgr}
+	rexs := &RexService{Host: h, peerstore: rumpeerstore, ProtocolId: protocol.ID(customprotocol), chainmgr: chainmgr, requests: newRexRequests()}
+	if inboundLimit > 0 {
+		rexs.inbound = make(chan struct{}, inboundLimit)
+	}
 	rumexchangelog.Debug("new rex service")
 	h.SetStreamHandler(rexs.ProtocolId, rexs.Handler)
 	rumexchangelog.Debugf("new rex service SetStreamHandler: %s", customprotocol)
@@ -79,6 +95,11 @@ func (r *RexService) SetHandlerMatchMsgType(name string, handler RumHandlerFunc)
 }
 
 func (r *RexService) NewStream(peerid peer.ID) (network.Stream, error) {
+	return r.newStream(peerid, nil)
+}
+
+// newStream opens a stream and reads the response on it, done is called once the response is handled or the stream is closed
+func (r *RexService) newStream(peerid peer.ID, done func()) (network.Stream, error) {
 	//only request trx need to create new stream, so a handler gorutine will be create to waiting the resp.
 	//TODO:  the ctx will timeout after x sec.
 
@@ -96,7 +117,12 @@ func (r *RexService) NewStream(peerid peer.ID) (network.Stream, error) {
 	}
 	//r.streampool.Store(peerid, newpoolitem)
 
-	go r.HandlerProcessStream(ctx, s)
+	go func() {
+		r.HandlerProcessStream(ctx, s)
+		if done != nil {
+			done()
+		}
+	}()
 
 	return s, nil
 }
@@ -113,7 +139,11 @@ func (r *RexService) PublishToStream(msg *quorumpb.RumDataMsg, s network.Stream)
 	//TODO:  add a timeout ctx to close the steam after timeout
 	remotePeer := s.Conn().RemotePeer()
 	rumexchangelog.Debugf("PublishResponse msg to peer: %s", remotePeer)
-	bufw := bufio.NewWriter(s)
+	if err := CheckMessageSize(proto.Size(msg), MessageSizeMax); err != nil {
+		metric.FailedCount.WithLabelValues(metric.ActionType.PublishToStream).Inc()
+		return err
+	}
+	bufw := bufio.NewWriter(r.Throttle.Writer(context.Background(), s))
 	wc := protoio.NewDelimitedWriter(bufw)
 	err := wc.WriteMsg(msg)
 	if err != nil {
@@ -126,12 +156,20 @@ func (r *RexService) PublishToStream(msg *quorumpb.RumDataMsg, s network.Stream)
 		metric.SuccessCount.WithLabelValues(metric.ActionType.PublishToStream).Inc()
 		metric.OutBytes.WithLabelValues(metric.ActionType.PublishToStream).Set(size)
 		metric.OutBytesTotal.WithLabelValues(metric.ActionType.PublishToStream).Add(size)
+		stats.Inc(stats.BytesOut, "", uint64(size))
 	}
 	bufw.Flush()
 	return nil
 }
 
 func (r *RexService) PublishToPeerId(msg *quorumpb.RumDataMsg, to string) error {
+	if err := CheckMessageSize(proto.Size(msg), MessageSizeMax); err != nil {
+		return err
+	}
+	return r.publishToPeerId(msg, to, nil)
+}
+
+func (r *RexService) publishToPeerId(msg *quorumpb.RumDataMsg, to string, done func()) error {
 	rumexchangelog.Debugf("PublishResponse msg to peer: %s", to)
 
 	toid, err := peer.Decode(to)
@@ -139,7 +177,7 @@ func (r *RexService) PublishToPeerId(msg *quorumpb.RumDataMsg, to string) error
 		return err
 	}
 
-	s, err := r.NewStream(toid)
+	s, err := r.newStream(toid, done)
 	if err != nil {
 		rumexchangelog.Debugf("create network stream to %s err: %s", to, err)
 		return err
@@ -147,7 +185,7 @@ func (r *RexService) PublishToPeerId(msg *quorumpb.RumDataMsg, to string) error
 	//s := poolitem.s
 	//remotePeer := s.Conn().RemotePeer()
 
-	bufw := bufio.NewWriter(s)
+	bufw := bufio.NewWriter(r.Throttle.Writer(context.Background(), s))
 	wc := protoio.NewDelimitedWriter(bufw)
 	err = wc.WriteMsg(msg)
 	if err != nil {
@@ -161,6 +199,7 @@ func (r *RexService) PublishToPeerId(msg *quorumpb.RumDataMsg, to string) error
 		metric.SuccessCount.WithLabelValues(metric.ActionType.PublishToPeerid).Inc()
 		metric.OutBytes.WithLabelValues(metric.ActionType.PublishToPeerid).Set(size)
 		metric.OutBytesTotal.WithLabelValues(metric.ActionType.PublishToPeerid).Add(size)
+		stats.Inc(stats.BytesOut, "", uint64(size))
 		rumexchangelog.Debugf("writemsg to network stream succ: %s.", to)
 	}
 	bufw.Flush()
@@ -170,7 +209,67 @@ func (r *RexService) PublishToPeerId(msg *quorumpb.RumDataMsg, to string) error
 
 // Publish to 1 random connected peers
 func (r *RexService) Publish(groupid string, channelpeers []peer.ID, msg *quorumpb.RumDataMsg) error {
+	return r.publish(groupid, channelpeers, msg, nil)
+}
+
+// PublishRange publishes the requests of the blocks in rng to a peer like Publish. The range is merged into the requests
+// in flight to the peer overlapping it, only the rest of it is requested by the msgs of build. The responses are handled
+// by the chain of the group, so they serve every merged range. done is called once all the requests of rng are
+// handled, with the first error of them, it may be nil. It returns true if rng is merged into the requests in flight.
+func (r *RexService) PublishRange(rng RexRange, channelpeers []peer.ID, build func(RexRange) (*quorumpb.RumDataMsg, error), done func(error)) (bool, error) {
+	connectedpeers := r.Host.Network().Peers()
+	if len(channelpeers) > 0 {
+		connectedpeers = channelpeers
+	}
+	peers := r.requests.peers(rng, r.peerstore.filterPeers(context.Background(), connectedpeers, 0.7), time.Now())
+
+	for _, p := range peers {
+		w, reqs, merged := r.requests.acquire(p, rng, time.Now(), done)
+		err := r.publishRequests(reqs, build)
+		if err == nil {
+			if merged {
+				rumexchangelog.Debugf("<%s> blocks from <%d> are being requested from %s, merge the request", rng.GroupId, rng.From, p)
+			}
+			r.peerstore.Scorers().BlockProviderScorer().Touch(p)
+			return merged, nil
+		}
+		r.requests.abort(w, reqs)
+		if errors.Is(err, errRexBuild) {
+			return false, err
+		}
+		r.peerstore.Scorers().BadResponsesScorer().Increment(p)
+		rumexchangelog.Debugf("writemsg to network stream err: %s", err)
+	}
+	return false, rumerrors.ErrNoPeersAvailable
+}
+
+// errRexBuild wraps the errors of building the requests, they are not the errors of the peer
+var errRexBuild = errors.New("build rex request failed")
+
+// publishRequests sends the range requests to their peer, a request is released once its response is handled
+func (r *RexService) publishRequests(reqs []*rexRequest, build func(RexRange) (*quorumpb.RumDataMsg, error)) error {
+	for _, req := range reqs {
+		msg, err := build(req.rng)
+		if err != nil {
+			return fmt.Errorf("%w: %s", errRexBuild, err)
+		}
+		if err := CheckMessageSize(proto.Size(msg), MessageSizeMax); err != nil {
+			return fmt.Errorf("%w: %s", errRexBuild, err)
+		}
+		req := req
+		if err := r.publishToPeerId(msg, peer.Encode(req.peer), func() { r.requests.release(req, nil) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *RexService) publish(groupid string, channelpeers []peer.ID, msg *quorumpb.RumDataMsg, done func()) error {
 	//TODO: save good peers?
+	// refused before a peer is tried, the peers should not be blamed for it
+	if err := CheckMessageSize(proto.Size(msg), MessageSizeMax); err != nil {
+		return err
+	}
 	ctx := context.Background()
 	connectedpeers := r.Host.Network().Peers()
 	//UserChannelId := constants.USER_CHANNEL_PREFIX + groupid
@@ -187,7 +286,7 @@ func (r *RexService) Publish(groupid string, channelpeers []peer.ID, msg *quorum
 	//defer cancel()
 
 	for _, p := range peers {
-		if err := r.PublishToPeerId(msg, peer.Encode(p)); err == nil {
+		if err := r.publishToPeerId(msg, peer.Encode(p), done); err == nil {
 			r.peerstore.Scorers().BlockProviderScorer().Touch(p)
 			rumexchangelog.Debugf("writemsg to network stream succ: %s.", p)
 			return nil
@@ -230,6 +329,18 @@ func (r *RexService) HandlerProcessStream(ctx context.Context, s network.Stream)
 		_ = s.Close()
 	}()
 
+	if r.inbound != nil {
+		select {
+		case r.inbound <- struct{}{}:
+			defer func() { <-r.inbound }()
+		case <-time.After(rexInboundWait):
+			rumexchangelog.Debugf("RumExchange inbound messages are full, reset stream from %s", remotePeer)
+			_ = s.Reset()
+			return
+		}
+		_ = s.SetReadDeadline(time.Now().Add(rexReadTimeout))
+	}
+
 	reader := msgio.NewVarintReaderSize(s, MessageSizeMax)
 	select {
 	case <-ctx.Done():
@@ -248,6 +359,7 @@ func (r *RexService) HandlerProcessStream(ctx context.Context, s network.Stream)
 				return
 			}
 		}
+		stats.Inc(stats.BytesIn, "", uint64(len(msgdata)))
 		var rummsg quorumpb.RumDataMsg
 		if err = proto.Unmarshal(msgdata, &rummsg); err == nil {
 			r.HandleRumExchangeMsg(&rummsg, s)
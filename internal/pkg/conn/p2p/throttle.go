@@ -0,0 +1,74 @@
+package p2p
+
+import (
+	"context"
+	"io"
+	"math"
+
+	"golang.org/x/time/rate"
+)
+
+// OutboundThrottle is a token bucket of the bytes written to the node streams, shared by all streams of the node
+type OutboundThrottle struct {
+	limiter *rate.Limiter
+}
+
+// NewOutboundThrottle returns a throttle of bytesPerSec bytes per second, 0 means no limit
+func NewOutboundThrottle(bytesPerSec int) *OutboundThrottle {
+	t := &OutboundThrottle{limiter: rate.NewLimiter(rate.Inf, 0)}
+	t.SetLimit(bytesPerSec)
+	return t
+}
+
+// SetLimit changes the limit at runtime, the bucket holds one second of bytes
+func (t *OutboundThrottle) SetLimit(bytesPerSec int) {
+	if bytesPerSec <= 0 {
+		t.limiter.SetLimit(rate.Inf)
+		t.limiter.SetBurst(0)
+		return
+	}
+	t.limiter.SetBurst(bytesPerSec)
+	t.limiter.SetLimit(rate.Limit(bytesPerSec))
+}
+
+// Limit returns the limit in bytes per second, 0 means no limit
+func (t *OutboundThrottle) Limit() int {
+	limit := t.limiter.Limit()
+	if limit == rate.Inf {
+		return 0
+	}
+	return int(math.Round(float64(limit)))
+}
+
+// Writer wraps w, writes to it wait for the tokens of their bytes
+func (t *OutboundThrottle) Writer(ctx context.Context, w io.Writer) io.Writer {
+	if t == nil {
+		return w
+	}
+	return &throttledWriter{ctx: ctx, w: w, t: t}
+}
+
+type throttledWriter struct {
+	ctx context.Context
+	w   io.Writer
+	t   *OutboundThrottle
+}
+
+func (tw *throttledWriter) Write(p []byte) (int, error) {
+	written := 0
+	for written < len(p) {
+		chunk := len(p) - written
+		if burst := tw.t.limiter.Burst(); tw.t.limiter.Limit() != rate.Inf && chunk > burst {
+			chunk = burst
+		}
+		if err := tw.t.limiter.WaitN(tw.ctx, chunk); err != nil {
+			return written, err
+		}
+		n, err := tw.w.Write(p[written : written+chunk])
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
@@ -0,0 +1,49 @@
+package p2p
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestOutboundThrottle(t *testing.T) {
+	throttle := NewOutboundThrottle(0)
+	if throttle.Limit() != 0 {
+		t.Errorf("expect no limit, got %d", throttle.Limit())
+	}
+
+	var buf bytes.Buffer
+	data := bytes.Repeat([]byte("a"), 4096)
+	if n, err := throttle.Writer(context.Background(), &buf).Write(data); err != nil || n != len(data) {
+		t.Fatalf("write without limit failed: %d %v", n, err)
+	}
+
+	throttle.SetLimit(2048)
+	if throttle.Limit() != 2048 {
+		t.Errorf("expect limit 2048, got %d", throttle.Limit())
+	}
+	buf.Reset()
+	start := time.Now()
+	// 4096 bytes at 2048 bytes per second take at least a second
+	if n, err := throttle.Writer(context.Background(), &buf).Write(data); err != nil || n != len(data) {
+		t.Fatalf("write with limit failed: %d %v", n, err)
+	}
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Errorf("expect throttled write, took %s", elapsed)
+	}
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Errorf("expect all bytes written in order")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := throttle.Writer(ctx, &buf).Write(data); err == nil {
+		t.Errorf("expect error of canceled write")
+	}
+
+	var nilThrottle *OutboundThrottle
+	if w := nilThrottle.Writer(context.Background(), &buf); w != &buf {
+		t.Errorf("expect nil throttle to return the writer")
+	}
+}
@@ -0,0 +1,30 @@
+package p2p
+
+import (
+	"fmt"
+
+	maddr "github.com/multiformats/go-multiaddr"
+)
+
+// IsQuicAddr reports whether the multiaddr is a quic addr, e.g. /ip4/0.0.0.0/udp/7002/quic-v1
+func IsQuicAddr(addr maddr.Multiaddr) bool {
+	quic := false
+	maddr.ForEach(addr, func(c maddr.Component) bool {
+		quic = c.Protocol().Code == maddr.P_QUIC || c.Protocol().Code == maddr.P_QUIC_V1
+		return !quic
+	})
+	return quic
+}
+
+// checkListenAddrs refuses the quic listen addrs if the quic transport is disabled
+func checkListenAddrs(addrs []maddr.Multiaddr, enableQuic bool) error {
+	if enableQuic {
+		return nil
+	}
+	for _, addr := range addrs {
+		if IsQuicAddr(addr) {
+			return fmt.Errorf("listen addr %s needs the quic transport, set EnableQuic to use it", addr)
+		}
+	}
+	return nil
+}
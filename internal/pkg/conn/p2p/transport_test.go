@@ -0,0 +1,66 @@
+package p2p
+
+import (
+	"context"
+	"testing"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/peer"
+	quic "github.com/libp2p/go-libp2p/p2p/transport/quic"
+	tcp "github.com/libp2p/go-libp2p/p2p/transport/tcp"
+	maddr "github.com/multiformats/go-multiaddr"
+)
+
+func TestCheckListenAddrs(t *testing.T) {
+	tcpAddr := maddr.StringCast("/ip4/0.0.0.0/tcp/7002")
+	quicAddr := maddr.StringCast("/ip4/0.0.0.0/udp/7002/quic-v1")
+	if IsQuicAddr(tcpAddr) || !IsQuicAddr(quicAddr) || !IsQuicAddr(maddr.StringCast("/ip4/0.0.0.0/udp/7002/quic")) {
+		t.Errorf("expect only the quic addrs detected")
+	}
+
+	addrs := []maddr.Multiaddr{tcpAddr, quicAddr}
+	if err := checkListenAddrs(addrs, true); err != nil {
+		t.Errorf("expect quic addr allowed with quic enabled: %s", err)
+	}
+	if err := checkListenAddrs(addrs, false); err == nil {
+		t.Errorf("expect error for quic addr with quic disabled")
+	}
+	if err := checkListenAddrs(addrs[:1], false); err != nil {
+		t.Errorf("expect tcp addr allowed with quic disabled: %s", err)
+	}
+}
+
+func TestQuicTransport(t *testing.T) {
+	newHost := func() *peer.AddrInfo {
+		h, err := libp2p.New(
+			libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0", "/ip4/127.0.0.1/udp/0/quic-v1"),
+			libp2p.Transport(tcp.NewTCPTransport),
+			libp2p.Transport(quic.NewTransport),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { h.Close() })
+		return &peer.AddrInfo{ID: h.ID(), Addrs: h.Addrs()}
+	}
+
+	h1, err := libp2p.New(libp2p.NoListenAddrs, libp2p.Transport(quic.NewTransport))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h1.Close()
+
+	h2 := newHost()
+	quicAddrs := []maddr.Multiaddr{}
+	for _, addr := range h2.Addrs {
+		if IsQuicAddr(addr) {
+			quicAddrs = append(quicAddrs, addr)
+		}
+	}
+	if len(quicAddrs) == 0 {
+		t.Fatalf("expect a quic listen addr, got %v", h2.Addrs)
+	}
+	if err := h1.Connect(context.Background(), peer.AddrInfo{ID: h2.ID, Addrs: quicAddrs}); err != nil {
+		t.Errorf("connect by quic failed: %s", err)
+	}
+}
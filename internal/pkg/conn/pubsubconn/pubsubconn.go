@@ -3,13 +3,17 @@ package pubsubconn
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
 	chaindef "github.com/rumsystem/quorum/internal/pkg/chainsdk/def"
+	"github.com/rumsystem/quorum/internal/pkg/conn/p2p"
 	"github.com/rumsystem/quorum/internal/pkg/logging"
 	"github.com/rumsystem/quorum/internal/pkg/metric"
+	"github.com/rumsystem/quorum/internal/pkg/stats"
+	"github.com/rumsystem/quorum/pkg/constants"
 	quorumpb "github.com/rumsystem/quorum/pkg/pb"
 
 	"google.golang.org/protobuf/proto"
@@ -99,6 +103,10 @@ func (psconn *P2pPubSubConn) Publish(data []byte) error {
 	if psconn.Topic == nil {
 		return fmt.Errorf("Topic has been closed.")
 	}
+	if err := p2p.CheckMessageSize(len(data), p2p.MaxMessageSize()); err != nil {
+		metric.FailedCount.WithLabelValues(metric.ActionType.PublishToTopic).Inc()
+		return err
+	}
 
 	//set a 2 Second timeout for pubsub Publish
 	err := psconn.Topic.Publish(publishctx, data)
@@ -109,6 +117,7 @@ func (psconn *P2pPubSubConn) Publish(data []byte) error {
 		metric.SuccessCount.WithLabelValues(metric.ActionType.PublishToTopic).Inc()
 		metric.OutBytes.WithLabelValues(metric.ActionType.PublishToTopic).Set(size)
 		metric.OutBytesTotal.WithLabelValues(metric.ActionType.PublishToTopic).Add(size)
+		stats.Inc(stats.BytesOut, channelGroupId(psconn.Cid), uint64(len(data)))
 	}
 
 	return err
@@ -118,6 +127,7 @@ func (psconn *P2pPubSubConn) handleGroupChannel(ctx context.Context) error {
 	for {
 		msg, err := psconn.Subscription.Next(ctx)
 		if err == nil {
+			stats.Inc(stats.BytesIn, channelGroupId(psconn.Cid), uint64(len(msg.Data)))
 
 			var pkg quorumpb.Package
 			if err := proto.Unmarshal(msg.Data, &pkg); err == nil {
@@ -138,3 +148,13 @@ func (psconn *P2pPubSubConn) handleGroupChannel(ctx context.Context) error {
 		}
 	}
 }
+
+// channelGroupId returns the group id of the user or producer channel of a group
+func channelGroupId(cid string) string {
+	for _, prefix := range []string{constants.USER_CHANNEL_PREFIX, constants.PRODUCER_CHANNEL_PREFIX, constants.SYNC_CHANNEL_PREFIX} {
+		if strings.HasPrefix(cid, prefix) {
+			return strings.TrimPrefix(cid, prefix)
+		}
+	}
+	return cid
+}
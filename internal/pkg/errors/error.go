@@ -13,6 +13,7 @@ var (
 	ErrClearJoinedGroup = errors.New("Can not clear joined group")
 	ErrInvalidGroupData = errors.New("Invalid group data")
 	ErrOnlyGroupOwner   = errors.New("Only group owner can do this")
+	ErrReadOnlyGroup    = errors.New("Group is joined read-only, publishing is not allowed")
 
 	ErrInvalidBlockID  = errors.New("Invalid block id")
 	ErrBlockIDNotFound = errors.New("Block id not found")
@@ -38,6 +39,7 @@ var (
 	ErrInvalidJWT = errors.New("Invalid JWT")
 
 	ErrNoPeersAvailable = errors.New("no peers available, waiting for reconnect")
+	ErrMessageTooLarge  = errors.New("message too large")
 
 	//syncer
 	ErrNotAskedByMe   = errors.New("Error Get Sync Resp but not asked by me")
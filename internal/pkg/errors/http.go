@@ -22,6 +22,15 @@ func NewNotFoundError(message ...interface{}) *echo.HTTPError {
 	return echo.NewHTTPError(http.StatusNotFound, message...)
 }
 
+func NewUnprocessableEntityError(message ...interface{}) *echo.HTTPError {
+	return echo.NewHTTPError(http.StatusUnprocessableEntity, message...)
+}
+
 func NewInternalServerError(message ...interface{}) *echo.HTTPError {
 	return echo.NewHTTPError(http.StatusInternalServerError, message...)
 }
+
+// ErrorResponse is the json body of the api error responses
+type ErrorResponse struct {
+	Message string `json:"message" example:"Group 3bb7a3be-d145-44af-94cf-e64b992ff8f0 not exist"`
+}
@@ -4,10 +4,14 @@
 package logging
 
 import (
+	"sort"
+
 	log "github.com/ipfs/go-log/v2"
 	"go.uber.org/zap/zapcore"
 )
 
+var ErrNoSuchLogger = log.ErrNoSuchLogger
+
 func Logger(system string) QuorumLogger {
 	return log.Logger(system)
 }
@@ -28,3 +32,10 @@ func LevelFromString(level string) (int, error) {
 func SetPrimaryCore(core zapcore.Core) {
 	log.SetPrimaryCore(core)
 }
+
+// GetSubsystems returns the sorted names of the loggers
+func GetSubsystems() []string {
+	subs := log.GetSubsystems()
+	sort.Strings(subs)
+	return subs
+}
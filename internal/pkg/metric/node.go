@@ -0,0 +1,55 @@
+package metric
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	connectedPeersDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "connected_peers"),
+		"Current count of connected peers",
+		nil, nil,
+	)
+	groupBlockHeightDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "group", "block_height"),
+		"Current block id of the group",
+		[]string{"group_id"}, nil,
+	)
+	publishQueueDepthDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "group", "publish_queue_depth"),
+		"Current count of trxs in the publish queue of the group",
+		[]string{"group_id", "state"}, nil,
+	)
+)
+
+// NodeCollector collects node status when scraped
+type NodeCollector struct {
+	PeerCount    func() int
+	BlockHeights func() map[string]uint64 // group id => current block id
+
+	PublishQueueDepth func() map[string]map[string]int // group id => state => count
+}
+
+func (c *NodeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- connectedPeersDesc
+	ch <- groupBlockHeightDesc
+	ch <- publishQueueDepthDesc
+}
+
+func (c *NodeCollector) Collect(ch chan<- prometheus.Metric) {
+	if c.PeerCount != nil {
+		ch <- prometheus.MustNewConstMetric(connectedPeersDesc, prometheus.GaugeValue, float64(c.PeerCount()))
+	}
+	if c.BlockHeights != nil {
+		for groupId, height := range c.BlockHeights() {
+			ch <- prometheus.MustNewConstMetric(groupBlockHeightDesc, prometheus.GaugeValue, float64(height), groupId)
+		}
+	}
+	if c.PublishQueueDepth != nil {
+		for groupId, depth := range c.PublishQueueDepth() {
+			for state, count := range depth {
+				ch <- prometheus.MustNewConstMetric(publishQueueDepthDesc, prometheus.GaugeValue, float64(count), groupId, state)
+			}
+		}
+	}
+}
@@ -34,6 +34,14 @@ var (
 		RumRelayResp:     "rum_relay_resp",
 	}
 
+	TrxDirection = struct {
+		Sent     string
+		Received string
+	}{
+		Sent:     "sent",
+		Received: "received",
+	}
+
 	SuccessCount = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: namespace,
@@ -87,4 +95,31 @@ var (
 		},
 		[]string{"action"},
 	)
+
+	TrxTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "trx_total",
+			Help:      "Total count of trxs sent or received",
+		},
+		[]string{"group_id", "direction"},
+	)
+
+	HeartbeatTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "heartbeat_total",
+			Help:      "Total count of consensus heartbeats received",
+		},
+		[]string{"group_id"},
+	)
+
+	HeartbeatInterval = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "heartbeat_interval_seconds",
+			Help:      "Seconds between the last two consensus heartbeats received",
+		},
+		[]string{"group_id"},
+	)
 )
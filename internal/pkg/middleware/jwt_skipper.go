@@ -1,17 +1,23 @@
 package middleware
 
 import (
+	"net/http"
 	"strings"
 
 	"github.com/labstack/echo/v4"
 )
 
 func JWTSkipper(c echo.Context) bool {
-	if LocalhostSkipper(c) {
+	if LocalhostSkipper(c) || ProbeSkipper(c) {
 		return true
 	}
 
 	path := c.Request().URL.Path
+	// minting a token needs the keystore password instead of a jwt
+	if path == "/api/v1/token" && c.Request().Method == http.MethodPost {
+		return true
+	}
+
 	skipPathPrefix := []string{
 		"/api/v1/ws/trx",
 	}
@@ -60,7 +60,7 @@ type (
 
 // Errors
 var (
-	ErrNoPermission    = echo.NewHTTPError(http.StatusUnauthorized, "opa no permission error")
+	ErrNoPermission    = echo.NewHTTPError(http.StatusForbidden, "opa no permission error")
 	ErrEval            = echo.NewHTTPError(http.StatusInternalServerError, "opa eval error")
 	ErrUndefinedResult = echo.NewHTTPError(http.StatusInternalServerError, "opa undefined result error")
 )
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+)
+
+// ProbePaths are the liveness and readiness probes, they are served without auth and rate limit
+var ProbePaths = []string{"/healthz", "/readyz"}
+
+func ProbeSkipper(c echo.Context) bool {
+	path := c.Request().URL.Path
+	for _, v := range ProbePaths {
+		if path == v {
+			return true
+		}
+	}
+	return false
+}
+
+// LocalhostOrProbeSkipper skips the requests from localhost and the probes
+func LocalhostOrProbeSkipper(c echo.Context) bool {
+	return LocalhostSkipper(c) || ProbeSkipper(c)
+}
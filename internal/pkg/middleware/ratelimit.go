@@ -0,0 +1,290 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"golang.org/x/time/rate"
+)
+
+const (
+	RateLimitBucketGlobal  = "global"
+	RateLimitBucketPublish = "publish"
+
+	// TokenRoute mints jwts by the keystore password, it is limited by remote ip against password guessing
+	TokenRoute = "POST /api/v1/token"
+)
+
+// DefaultTokenLimit is the limit of TokenRoute unless it is set in the route limits
+var DefaultTokenLimit = RateLimit{Rate: 0.1, Burst: 5}
+
+// idle client limiters are dropped after rateLimitIdleTimeout, the least recently seen ones are dropped
+// when there are more than rateLimitMaxClients
+const (
+	rateLimitIdleTimeout = 5 * time.Minute
+	rateLimitMaxClients  = 10000
+)
+
+type (
+	// RateLimit is a token bucket refilled by Rate requests per second and holding Burst tokens, zero Rate means no limit
+	RateLimit struct {
+		Rate  float64
+		Burst int
+	}
+
+	// RateLimitConfig defines the config for rate limit middleware.
+	RateLimitConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper middleware.Skipper
+
+		// Global is the limit of the routes without their own limit
+		Global RateLimit
+
+		// Publish is the limit of PublishRoutes
+		Publish       RateLimit
+		PublishRoutes []string
+
+		// Routes are limits by "METHOD path", path is the registered route path, e.g. "POST /api/v1/group/:group_id/content"
+		Routes map[string]RateLimit
+
+		// ContextKey is where the jwt middleware stores the verified token, the subject of the token is the client.
+		// Requests without a verified token are limited by remote ip. Optional. Default value "user".
+		ContextKey string
+	}
+
+	clientLimiter struct {
+		limiter  *rate.Limiter
+		lastSeen time.Time
+	}
+
+	rateLimitStore struct {
+		mu        sync.Mutex
+		limiters  map[string]*clientLimiter
+		lastSweep time.Time
+	}
+)
+
+// DefaultPublishRoutes are the routes which post trxs to groups
+var DefaultPublishRoutes = []string{
+	"POST /api/v1/group/:group_id/content",
+	"POST /api/v1/group/:group_id/content/batch",
+	"POST /api/v1/group/:group_id/content/chunked",
+	"POST /api/v1/group/:group_id/blob",
+	"POST /api/v1/group/:group_id/profile",
+	"DELETE /api/v1/group/:group_id/content/:trx_id",
+	"POST /api/v1/node/:group_id/trx",
+}
+
+// ParseRateLimit parses "rate:burst", e.g. "5:10", burst defaults to ceil(rate)
+func ParseRateLimit(s string) (RateLimit, error) {
+	var limit RateLimit
+	parts := strings.SplitN(strings.TrimSpace(s), ":", 2)
+	r, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil || r < 0 {
+		return limit, fmt.Errorf("invalid rate limit %q", s)
+	}
+	limit.Rate = r
+	limit.Burst = int(math.Ceil(r))
+	if len(parts) == 2 {
+		burst, err := strconv.Atoi(parts[1])
+		if err != nil || burst < 0 {
+			return limit, fmt.Errorf("invalid rate limit burst %q", s)
+		}
+		limit.Burst = burst
+	}
+	if limit.Rate > 0 && limit.Burst < 1 {
+		limit.Burst = 1
+	}
+	return limit, nil
+}
+
+func newRateLimitStore() *rateLimitStore {
+	return &rateLimitStore{limiters: make(map[string]*clientLimiter), lastSweep: time.Now()}
+}
+
+// sweep drops the limiters idle for rateLimitIdleTimeout
+func (s *rateLimitStore) sweep(now time.Time) {
+	for k, l := range s.limiters {
+		if now.Sub(l.lastSeen) > rateLimitIdleTimeout {
+			delete(s.limiters, k)
+		}
+	}
+	s.lastSweep = now
+}
+
+// evict makes room for a new client, it drops the least recently seen limiter if none is idle
+func (s *rateLimitStore) evict(now time.Time) {
+	s.sweep(now)
+	if len(s.limiters) < rateLimitMaxClients {
+		return
+	}
+	var oldest string
+	var oldestSeen time.Time
+	for k, l := range s.limiters {
+		if oldest == "" || l.lastSeen.Before(oldestSeen) {
+			oldest, oldestSeen = k, l.lastSeen
+		}
+	}
+	delete(s.limiters, oldest)
+}
+
+// reserve takes a token of the client in the bucket, it returns the wait before the next token if none is left
+func (s *rateLimitStore) reserve(bucket, client string, limit RateLimit, now time.Time) (bool, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) > time.Minute {
+		s.sweep(now)
+	}
+
+	key := bucket + "|" + client
+	l, ok := s.limiters[key]
+	if !ok {
+		if len(s.limiters) >= rateLimitMaxClients {
+			s.evict(now)
+		}
+		l = &clientLimiter{limiter: rate.NewLimiter(rate.Limit(limit.Rate), limit.Burst)}
+		s.limiters[key] = l
+	}
+	l.lastSeen = now
+
+	r := l.limiter.ReserveN(now, 1)
+	if !r.OK() {
+		return false, time.Second
+	}
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+// rateLimitClient returns the subject of the verified jwt if there is one, or the remote ip.
+// The raw Authorization header is not used, any header would give a new bucket.
+func rateLimitClient(c echo.Context, contextKey string) string {
+	token, _ := c.Get(contextKey).(*jwt.Token)
+	return RateLimitClient(token, c.RealIP())
+}
+
+// RateLimitClient returns the client of the verified token, or the ip if the token is nil or has no subject
+func RateLimitClient(token *jwt.Token, ip string) string {
+	if token != nil && token.Valid {
+		if subject := jwtSubject(token); subject != "" {
+			return "jwt:" + subject
+		}
+	}
+	return "ip:" + ip
+}
+
+// jwtSubject returns the sub claim of the token, or the name claim of the quorum jwts
+func jwtSubject(token *jwt.Token) string {
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return ""
+	}
+	for _, key := range []string{"sub", "name"} {
+		if v, ok := claims[key].(string); ok && v != "" {
+			return key + ":" + v
+		}
+	}
+	return ""
+}
+
+// RateLimiter is the rate limit middleware, its limits can be replaced at runtime
+type RateLimiter struct {
+	mu            sync.RWMutex
+	config        RateLimitConfig
+	publishRoutes map[string]bool
+	store         *rateLimitStore
+}
+
+func NewRateLimiter(config RateLimitConfig) *RateLimiter {
+	l := &RateLimiter{}
+	l.SetConfig(config)
+	return l
+}
+
+// SetConfig replaces the limits, the tokens taken by the clients are reset
+func (l *RateLimiter) SetConfig(config RateLimitConfig) {
+	if config.Skipper == nil {
+		config.Skipper = middleware.DefaultSkipper
+	}
+	if config.ContextKey == "" {
+		config.ContextKey = "user"
+	}
+	publishRoutes := make(map[string]bool, len(config.PublishRoutes))
+	for _, route := range config.PublishRoutes {
+		publishRoutes[route] = true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.config = config
+	l.publishRoutes = publishRoutes
+	l.store = newRateLimitStore()
+}
+
+// limit returns the bucket and the limit of the route
+func (l *RateLimiter) limit(route string) (string, RateLimit, *rateLimitStore, RateLimitConfig) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if limit, ok := l.config.Routes[route]; ok {
+		return route, limit, l.store, l.config
+	}
+	if l.publishRoutes[route] {
+		return RateLimitBucketPublish, l.config.Publish, l.store, l.config
+	}
+	return RateLimitBucketGlobal, l.config.Global, l.store, l.config
+}
+
+// Reserve takes a token of the client for the route, it returns the wait before the next token if none is left.
+// It is for the apis served without echo, e.g. the grpc api limited by the routes of the same http apis.
+func (l *RateLimiter) Reserve(route, client string) (bool, time.Duration) {
+	bucket, limit, store, _ := l.limit(route)
+	if limit.Rate <= 0 {
+		return true, 0
+	}
+	return store.reserve(bucket, client, limit, time.Now())
+}
+
+// Middleware returns a token bucket rate limit middleware, a client is a jwt subject or a remote ip.
+// It should be used after the jwt middleware. A request over the limit gets 429 with the Retry-After header.
+func (l *RateLimiter) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			bucket, limit, store, config := l.limit(c.Request().Method + " " + c.Path())
+			if config.Skipper(c) || limit.Rate <= 0 {
+				return next(c)
+			}
+
+			client := "ip:" + c.RealIP()
+			// the token route has no jwt, it is limited by remote ip against password guessing
+			if bucket != TokenRoute {
+				client = rateLimitClient(c, config.ContextKey)
+			}
+			ok, wait := store.reserve(bucket, client, limit, time.Now())
+			if !ok {
+				retry := int(math.Ceil(wait.Seconds()))
+				if retry < 1 {
+					retry = 1
+				}
+				c.Response().Header().Set(echo.HeaderRetryAfter, strconv.Itoa(retry))
+				return echo.NewHTTPError(http.StatusTooManyRequests, "rate limit exceeded")
+			}
+			return next(c)
+		}
+	}
+}
+
+// RateLimitWithConfig returns a token bucket rate limit middleware with fixed limits
+func RateLimitWithConfig(config RateLimitConfig) echo.MiddlewareFunc {
+	return NewRateLimiter(config).Middleware()
+}
@@ -0,0 +1,181 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+// fakeJWT stores a verified token named by the Authorization header, as the jwt middleware does
+func fakeJWT(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if name := c.Request().Header.Get(echo.HeaderAuthorization); name != "" {
+			c.Set("user", &jwt.Token{Valid: name != "invalid", Claims: jwt.MapClaims{"name": name}})
+		}
+		return next(c)
+	}
+}
+
+func TestParseRateLimit(t *testing.T) {
+	cases := []struct {
+		s     string
+		limit RateLimit
+		err   bool
+	}{
+		{"5:10", RateLimit{Rate: 5, Burst: 10}, false},
+		{"0.5", RateLimit{Rate: 0.5, Burst: 1}, false},
+		{"0", RateLimit{}, false},
+		{"-1:2", RateLimit{}, true},
+		{"a:b", RateLimit{}, true},
+	}
+	for _, c := range cases {
+		limit, err := ParseRateLimit(c.s)
+		if (err != nil) != c.err {
+			t.Errorf("%s: expect error %v, got %v", c.s, c.err, err)
+			continue
+		}
+		if !c.err && limit != c.limit {
+			t.Errorf("%s: expect %+v, got %+v", c.s, c.limit, limit)
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	e := echo.New()
+	e.Use(fakeJWT)
+	e.Use(RateLimitWithConfig(RateLimitConfig{
+		Global:        RateLimit{Rate: 1, Burst: 3},
+		Publish:       RateLimit{Rate: 1, Burst: 1},
+		PublishRoutes: DefaultPublishRoutes,
+	}))
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+	e.GET("/api/v1/groups", ok)
+	e.POST("/api/v1/group/:group_id/content", ok)
+
+	do := func(method, path, auth string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if auth != "" {
+			req.Header.Set(echo.HeaderAuthorization, auth)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodPost, "/api/v1/group/g1/content", ""); rec.Code != http.StatusOK {
+		t.Fatalf("expect first publish to pass, got %d", rec.Code)
+	}
+	rec := do(http.MethodPost, "/api/v1/group/g1/content", "")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expect publish over the limit to get 429, got %d", rec.Code)
+	}
+	if rec.Header().Get(echo.HeaderRetryAfter) == "" {
+		t.Errorf("expect Retry-After header")
+	}
+
+	// reads have their own bucket
+	for i := 0; i < 3; i++ {
+		if rec := do(http.MethodGet, "/api/v1/groups", ""); rec.Code != http.StatusOK {
+			t.Fatalf("expect read %d to pass, got %d", i, rec.Code)
+		}
+	}
+	if rec := do(http.MethodGet, "/api/v1/groups", ""); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expect read over the limit to get 429, got %d", rec.Code)
+	}
+
+	// another jwt subject is another client, an unverified token is still the remote ip
+	if rec := do(http.MethodGet, "/api/v1/groups", "invalid"); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expect unverified token to share the ip bucket, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/api/v1/groups", "other"); rec.Code != http.StatusOK {
+		t.Fatalf("expect other client to pass, got %d", rec.Code)
+	}
+}
+
+func TestRateLimitStoreEvict(t *testing.T) {
+	s := newRateLimitStore()
+	limit := RateLimit{Rate: 1, Burst: 1}
+	now := time.Now()
+	for i := 0; i < rateLimitMaxClients; i++ {
+		s.reserve(RateLimitBucketGlobal, fmt.Sprintf("ip:%d", i), limit, now.Add(time.Duration(i)*time.Millisecond))
+	}
+	if ok, _ := s.reserve(RateLimitBucketGlobal, "ip:new", limit, now.Add(time.Second)); !ok {
+		t.Fatalf("expect new client to pass")
+	}
+	if len(s.limiters) != rateLimitMaxClients {
+		t.Fatalf("expect %d limiters, got %d", rateLimitMaxClients, len(s.limiters))
+	}
+	if _, ok := s.limiters[RateLimitBucketGlobal+"|ip:0"]; ok {
+		t.Errorf("expect the least recently seen limiter to be evicted")
+	}
+
+	// idle limiters are dropped by the sweep
+	s.reserve(RateLimitBucketGlobal, "ip:new", limit, now.Add(2*rateLimitIdleTimeout))
+	if len(s.limiters) != 1 {
+		t.Errorf("expect idle limiters to be dropped, got %d", len(s.limiters))
+	}
+}
+
+func TestRateLimiterSetConfig(t *testing.T) {
+	limiter := NewRateLimiter(RateLimitConfig{Global: RateLimit{Rate: 1, Burst: 1}})
+	e := echo.New()
+	e.Use(limiter.Middleware())
+	e.GET("/api/v1/groups", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+	do := func() int {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/groups", nil))
+		return rec.Code
+	}
+	if code := do(); code != http.StatusOK {
+		t.Fatalf("expect first request to pass, got %d", code)
+	}
+	if code := do(); code != http.StatusTooManyRequests {
+		t.Fatalf("expect 429 over the limit, got %d", code)
+	}
+
+	limiter.SetConfig(RateLimitConfig{})
+	for i := 0; i < 3; i++ {
+		if code := do(); code != http.StatusOK {
+			t.Fatalf("expect no limit after the limits are removed, got %d", code)
+		}
+	}
+}
+
+func TestRateLimitTokenRoute(t *testing.T) {
+	e := echo.New()
+	e.Use(fakeJWT)
+	e.Use(RateLimitWithConfig(RateLimitConfig{
+		Routes: map[string]RateLimit{TokenRoute: {Rate: 0.1, Burst: 2}},
+	}))
+	e.POST("/api/v1/token", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+	do := func(auth, ip string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/token", nil)
+		req.RemoteAddr = ip + ":1234"
+		if auth != "" {
+			req.Header.Set(echo.HeaderAuthorization, auth)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	for i := 0; i < 2; i++ {
+		if code := do("", "10.0.0.1"); code != http.StatusOK {
+			t.Fatalf("expect request %d to pass, got %d", i, code)
+		}
+	}
+	// a new Authorization header is not a new client on the token route
+	if code := do("guess", "10.0.0.1"); code != http.StatusTooManyRequests {
+		t.Fatalf("expect 429 over the limit, got %d", code)
+	}
+	if code := do("", "10.0.0.2"); code != http.StatusOK {
+		t.Fatalf("expect other ip to pass, got %d", code)
+	}
+}
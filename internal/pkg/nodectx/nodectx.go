@@ -37,6 +37,7 @@ type NodeCtx struct {
 	Ctx       context.Context
 	Version   string
 	Status    NodeStatus
+	Consensus string // name of the consensus for groups, empty means the default
 	chaindb   *chainstorage.Storage
 }
 
@@ -2,23 +2,63 @@ package options
 
 import (
 	"sync"
+	"time"
 
 	"github.com/rumsystem/quorum/internal/pkg/logging"
 )
 
 var optionslog = logging.Logger("options")
 
+// NodeOptions are loaded from the options file of the peer, the fields in ReloadableOptions are applied
+// on SIGHUP without restart
 type NodeOptions struct {
 	Password          string
 	EnableRelay       bool
 	EnableNat         bool
+	EnableQuic        bool // add the quic transport, listen on it by a quic listen addr, e.g. /ip4/0.0.0.0/udp/7002/quic-v1
 	EnableRumExchange bool
 	EnableDevNetwork  bool
 	EnableSnapshot    bool
 	EnablePubQue      bool
+	EnableDbEncrypt   bool // encrypt the values of the node dbs with keys derived from the keystore password
 	MaxPeers          int
+	ConnsLo           int // conns are trimmed to ConnsLo once there are more than ConnsHi
 	ConnsHi           int
+	ConnsGrace        time.Duration // new conns are not trimmed in the grace period
+	MaxSyncingGroups  int           // maximum number of groups catching up at the same time, 0 means no limit
+	PubQueMaxAttempts int           // send attempts of a trx before it is moved to dead-letter
+	PubQueBaseBackoff time.Duration // wait before the first resend of a trx not applied, doubled on each attempt
+	PubQueMaxBackoff  time.Duration // cap of the resend backoff
+	PubQueWorkers     int           // groups resent at the same time by the publish queue watcher
+	PubQueKeyWindow   time.Duration // how long the idempotency key of a publish is kept, a retry in the window gets the original trx
+	StorageGCInterval time.Duration // interval of the background storage gc, 0 disables it
+	StorageGCRatio    float64       // minimum fraction of free space in a db to compact it
+	StatsMinuteKeep   time.Duration // how long the per minute stats are kept before they are rolled up into per hour stats
+	StatsHourKeep     time.Duration // how long the per hour stats are kept
+	BlobStore         string        // backend of the blob store of the media referred by Blob trxs, fs or ipfs, empty disables it
+	BlobDir           string        // dir of the fs blob store, <datadir>/<peername>/blobs if empty
+	IPFSAPI           string        // http rpc api of the ipfs node of the ipfs blob store
 	NetworkName       string
+	CORSAllowOrigins  []string // origins allowed to call the api, empty allows all origins only if the api listens on localhost
+	CORSAllowMethods  []string
+	CORSAllowHeaders  []string
+	APIRateLimit      string            // "rate:burst" requests per second of a client, by jwt subject or remote ip, empty or 0 for no limit
+	APIPublishLimit   string            // "rate:burst" of the routes posting trxs to groups
+	APIRouteLimits    map[string]string // "METHOD path" to "rate:burst", path is the route path, e.g. "POST /api/v1/group/:group_id/content"
+	PeerAllowList     []string          // peer ids allowed to connect, empty allows all peers not blocked
+	PeerBlockList     []string          // peer ids refused and disconnected
+	OutboundLimit     int               // bytes per second written to the rumexchange streams, 0 means no limit
+	RexBufferSize     int               // rumexchange messages handled at the same time, 0 means no limit, and sync results buffered per group, 0 keeps the default 16
+	GossipD           int               // gossipsub mesh degree of the group channels, default 6, 0 keeps the default for all gossip options, ranges in p2p.GossipSubParams
+	GossipDlo         int               // lower bound of the mesh degree, default 5
+	GossipDhi         int               // upper bound of the mesh degree, default 12
+	GossipHeartbeat   time.Duration     // interval of the mesh maintenance and the gossip, default 1s
+	GossipHistory     int               // heartbeats the messages are kept in the message cache, default 5
+	GossipWindow      int               // heartbeats of the message cache gossiped, default 3
+	MaxMessageSize    int               // bytes of a pubsub message and of a trx sent over the chain data exchange, 0 means 1MiB, see p2p.SetMaxMessageSize
+	PersistentPeers   []string          // p2p multiaddrs of the peers kept connected and redialed when they drop
+	PreferredRelays   []string          // p2p multiaddrs of the relays tried first for the relay reservation, in order
+	LogLevels         map[string]string // logger subsystem to level, e.g. chain = "debug", applied on startup and reload
 	JWT               *JWT
 	SignKeyMap        map[string]string
 	mu                sync.RWMutex
@@ -28,6 +28,24 @@ func (opt *NodeOptions) NewChainJWT(name string, exp time.Time) (string, error)
 	return token, opt.writeToconfig()
 }
 
+// NewScopedChainJWT creates a chain jwt limited to scope, it is revoked like other chain jwts
+func (opt *NodeOptions) NewScopedChainJWT(name string, scope string, exp time.Time) (string, error) {
+	opt.mu.Lock()
+	defer opt.mu.Unlock()
+
+	if opt.JWT.Chain == nil {
+		opt.JWT.Chain = &JWTListItem{}
+	}
+
+	token, err := utils.NewScopedJWTToken(name, "chain", "*", scope, opt.JWT.Key, exp)
+	if err != nil {
+		return "", err
+	}
+	opt.JWT.Chain.Normal = append(opt.JWT.Chain.Normal, &TokenItem{Remark: name, Token: token})
+
+	return token, opt.writeToconfig()
+}
+
 func (opt *NodeOptions) GetOrCreateNodeJwt(groupid, name string, exp time.Time) (string, error) {
 	if opt.JWT.Node == nil {
 		opt.JWT.Node = map[string]*JWTListItem{}
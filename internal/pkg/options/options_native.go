@@ -6,6 +6,7 @@ package options
 import (
 	"fmt"
 	"path/filepath"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/rumsystem/quorum/internal/pkg/logging"
@@ -25,6 +26,18 @@ const JWTKeyLength = 32
 const defaultNetworkName = "staten"
 const defaultMaxPeers = 50
 const defaultConnsHi = 100
+const defaultConnsLo = 10
+const defaultConnsGrace = 60 * time.Second
+const defaultPubQueMaxAttempts = 5
+const defaultPubQueBaseBackoff = 10 * time.Second
+const defaultPubQueMaxBackoff = 5 * time.Minute
+const defaultPubQueWorkers = 4
+const defaultPubQueKeyWindow = 24 * time.Hour
+const defaultRexBufferSize = 16
+const defaultStorageGCRatio = 0.5
+const defaultStatsMinuteKeep = 48 * time.Hour
+const defaultStatsHourKeep = 30 * 24 * time.Hour
+const defaultIPFSAPI = "http://127.0.0.1:5001"
 
 func GetNodeOptions() *NodeOptions {
 	return nodeopts
@@ -65,10 +78,30 @@ func (opt *NodeOptions) writeToconfig() error {
 	viper.Set("EnableDevNetwork", opt.EnableDevNetwork)
 	viper.Set("SignKeyMap", opt.SignKeyMap)
 	viper.Set("JWT", opt.JWT)
+	viper.Set("PeerAllowList", opt.PeerAllowList)
+	viper.Set("PeerBlockList", opt.PeerBlockList)
+	viper.Set("PreferredRelays", opt.PreferredRelays)
 
 	return viper.WriteConfig()
 }
 
+// SetPreferredRelays saves the relays tried first for the relay reservation
+func (opt *NodeOptions) SetPreferredRelays(relays []string) error {
+	opt.mu.Lock()
+	defer opt.mu.Unlock()
+	opt.PreferredRelays = relays
+	return opt.writeToconfig()
+}
+
+// SetPeerLists saves the peer allowlist and blocklist of the connection gater
+func (opt *NodeOptions) SetPeerLists(allow, block []string) error {
+	opt.mu.Lock()
+	defer opt.mu.Unlock()
+	opt.PeerAllowList = allow
+	opt.PeerBlockList = block
+	return opt.writeToconfig()
+}
+
 func (opt *NodeOptions) SetSignKeyMap(keyname, addr string) error {
 	opt.mu.Lock()
 	defer opt.mu.Unlock()
@@ -117,12 +150,20 @@ func initConfigfile(dir, keyname string) error {
 
 	// set default value
 	viper.SetDefault("EnableNat", true)
+	viper.SetDefault("EnableQuic", true)
 	viper.SetDefault("EnableRumExchange", false)
 	viper.SetDefault("EnableDevNetwork", false)
 	viper.SetDefault("NetworkName", defaultNetworkName)
 	viper.SetDefault("MaxPeers", defaultMaxPeers)
+	viper.SetDefault("ConnsLo", defaultConnsLo)
 	viper.SetDefault("ConnsHi", defaultConnsHi)
+	viper.SetDefault("ConnsGrace", defaultConnsGrace)
+	viper.SetDefault("MaxSyncingGroups", 0)
 	viper.SetDefault("SignKeyMap", map[string]string{})
+	viper.SetDefault("PeerAllowList", []string{})
+	viper.SetDefault("PeerBlockList", []string{})
+	viper.SetDefault("PreferredRelays", []string{})
+	viper.SetDefault("LogLevels", map[string]string{})
 	viper.SetDefault("JWT", JWT{
 		Key:   utils.GetRandomStr(JWTKeyLength),
 		Chain: &JWTListItem{},
@@ -130,6 +171,27 @@ func initConfigfile(dir, keyname string) error {
 	})
 	viper.SetDefault("EnableSnapshot", true)
 	viper.SetDefault("EnablePubQue", true)
+	viper.SetDefault("EnableDbEncrypt", false)
+	viper.SetDefault("PubQueMaxAttempts", defaultPubQueMaxAttempts)
+	viper.SetDefault("PubQueBaseBackoff", defaultPubQueBaseBackoff)
+	viper.SetDefault("PubQueMaxBackoff", defaultPubQueMaxBackoff)
+	viper.SetDefault("PubQueWorkers", defaultPubQueWorkers)
+	viper.SetDefault("PubQueKeyWindow", defaultPubQueKeyWindow)
+	viper.SetDefault("RexBufferSize", defaultRexBufferSize)
+	viper.SetDefault("GossipD", 0)
+	viper.SetDefault("GossipDlo", 0)
+	viper.SetDefault("GossipDhi", 0)
+	viper.SetDefault("GossipHeartbeat", time.Duration(0))
+	viper.SetDefault("GossipHistory", 0)
+	viper.SetDefault("GossipWindow", 0)
+	viper.SetDefault("MaxMessageSize", 0)
+	viper.SetDefault("StorageGCInterval", time.Duration(0))
+	viper.SetDefault("StorageGCRatio", defaultStorageGCRatio)
+	viper.SetDefault("StatsMinuteKeep", defaultStatsMinuteKeep)
+	viper.SetDefault("StatsHourKeep", defaultStatsHourKeep)
+	viper.SetDefault("BlobStore", "")
+	viper.SetDefault("BlobDir", "")
+	viper.SetDefault("IPFSAPI", defaultIPFSAPI)
 
 	return nil
 }
@@ -162,13 +224,45 @@ func init() {
 	pflag.String("password", "", "keystore password")
 	pflag.Bool("enablerelay", true, "enable relay")
 	pflag.Bool("enablenat", true, "enable nat")
+	pflag.Bool("enablequic", true, "enable the quic transport")
 	pflag.Bool("enablerumexchange", true, "enable rumexchange")
 	pflag.Bool("enabledevnetwork", true, "enable dev network")
 	pflag.Bool("enablesnapshot", true, "enable snapshot")
 	pflag.Bool("enablepubque", true, "enable pubque")
+	pflag.Bool("enabledbencrypt", false, "encrypt the values of the node dbs at rest")
+	pflag.Int("pubquemaxattempts", defaultPubQueMaxAttempts, "send attempts of a trx before it is moved to dead-letter")
+	pflag.Duration("pubquebasebackoff", defaultPubQueBaseBackoff, "wait before the first resend of a trx not applied, doubled on each attempt")
+	pflag.Duration("pubquemaxbackoff", defaultPubQueMaxBackoff, "max wait between resends of a trx")
+	pflag.Int("pubqueworkers", defaultPubQueWorkers, "groups resent at the same time by the publish queue, the trxs of a group are resent in order")
+	pflag.Duration("pubquekeywindow", defaultPubQueKeyWindow, "how long the idempotency key of a publish is kept, a retried publish with the key in the window gets the original trx id")
+	pflag.Duration("storagegcinterval", 0, "interval of the background storage gc, 0 disables it")
+	pflag.Float64("storagegcratio", defaultStorageGCRatio, "minimum fraction of free space in a db to compact it")
+	pflag.Duration("statsminutekeep", defaultStatsMinuteKeep, "how long the per minute node stats are kept, older ones are rolled up into per hour stats")
+	pflag.Duration("statshourkeep", defaultStatsHourKeep, "how long the per hour node stats are kept")
+	pflag.String("blobstore", "", "blob store of the media posted by hash instead of on chain, fs or ipfs, empty disables it")
+	pflag.String("blobdir", "", "dir of the fs blob store, default to blobs in the data dir of the peer")
+	pflag.String("ipfsapi", defaultIPFSAPI, "http rpc api of the ipfs node used by the ipfs blob store")
 	pflag.Int("maxpeers", defaultMaxPeers, "max peer number")
+	pflag.Int("connslo", defaultConnsLo, "conns are trimmed to connslo once there are more than connshi")
 	pflag.Int("connshi", defaultConnsHi, "max connshi")
+	pflag.Duration("connsgrace", defaultConnsGrace, "new conns are not trimmed in the grace period")
+	pflag.Int("outboundlimit", 0, "bytes per second written to the chain data exchange streams, 0 means no limit")
+	pflag.Int("rexbuffersize", defaultRexBufferSize, "chain data exchange messages handled at the same time, the peers wait beyond it, 0 means no limit; also the sync results buffered per group, 0 keeps the default 16 for them")
+	pflag.Int("gossipd", 0, "gossipsub mesh degree, 1-32, 0 for the default 6, lower it for a few nodes, raise it for a large group")
+	pflag.Int("gossipdlo", 0, "gossipsub lower bound of the mesh degree, 1-gossipd, 0 for the default 5")
+	pflag.Int("gossipdhi", 0, "gossipsub upper bound of the mesh degree, gossipd-64, 0 for the default 12")
+	pflag.Duration("gossipheartbeat", 0, "gossipsub heartbeat interval, 100ms-10s, 0 for the default 1s")
+	pflag.Int("gossiphistory", 0, "gossipsub heartbeats the messages are kept in the message cache, 1-64, 0 for the default 5")
+	pflag.Int("gossipwindow", 0, "gossipsub heartbeats of the message cache gossiped, 1-gossiphistory, 0 for the default 3")
+	pflag.Int("maxmessagesize", 0, "max bytes of a pubsub message, 65536-16777216, 0 for the default 1048576, the peers of a group should use the same size. a larger content should be split into several trxs")
+	pflag.Int("maxsyncinggroups", 0, "max number of groups catching up at the same time, 0 means no limit")
 	pflag.String("networkname", defaultNetworkName, "peer network name")
+	pflag.StringSlice("corsalloworigins", nil, "origins allowed to call the api, e.g. https://app.example.com, all origins are allowed on a localhost api if not set")
+	pflag.StringSlice("corsallowmethods", nil, "methods allowed in cross origin requests, default to all methods used by the api")
+	pflag.String("apiratelimit", "", "requests per second of an api client, by jwt subject or remote ip, as rate:burst, e.g. 20:40, no limit if not set")
+	pflag.String("apipublishlimit", "", "requests per second of an api client to post content, as rate:burst, e.g. 2:5, no limit if not set")
+	pflag.StringToString("apiroutelimits", nil, "per route limits, e.g. \"GET /api/v1/group/:group_id/content\"=5:10")
+	pflag.StringSlice("corsallowheaders", nil, "headers allowed in cross origin requests, default to the headers requested by the preflight")
 	// pflag.String("skippeers", "", "peer id lists, will be skipped in the pubsub connection")
 	pflag.String("jsontracer", "", "output tracer data to a json file")
 
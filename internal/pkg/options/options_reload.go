@@ -0,0 +1,62 @@
+//go:build !js
+// +build !js
+
+package options
+
+import (
+	"reflect"
+
+	"github.com/spf13/viper"
+)
+
+// ReloadableOptions are the fields applied on reloading the options file without restart,
+// changes of the other fields are ignored until the node restarts
+var ReloadableOptions = []string{
+	"LogLevels",
+	"PeerAllowList",
+	"PeerBlockList",
+	"APIRateLimit",
+	"APIPublishLimit",
+	"APIRouteLimits",
+	"PersistentPeers",
+}
+
+// Reload reads the options file again and applies the reloadable fields,
+// it returns the names of the changed fields which are ignored because they need a restart
+func (opt *NodeOptions) Reload() ([]string, error) {
+	if err := initConfigfile(nodeconfigdir, nodepeername); err != nil {
+		return nil, err
+	}
+	newopt := &NodeOptions{}
+	if err := viper.Unmarshal(newopt); err != nil {
+		return nil, err
+	}
+
+	opt.mu.Lock()
+	defer opt.mu.Unlock()
+	opt.LogLevels = newopt.LogLevels
+	opt.PeerAllowList = newopt.PeerAllowList
+	opt.PeerBlockList = newopt.PeerBlockList
+	opt.APIRateLimit = newopt.APIRateLimit
+	opt.APIPublishLimit = newopt.APIPublishLimit
+	opt.APIRouteLimits = newopt.APIRouteLimits
+	opt.PersistentPeers = newopt.PersistentPeers
+
+	return changedOptions(opt, newopt), nil
+}
+
+// changedOptions returns the exported fields of a and b which are different
+func changedOptions(a, b *NodeOptions) []string {
+	changed := []string{}
+	va, vb := reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem()
+	for i := 0; i < va.NumField(); i++ {
+		field := va.Type().Field(i)
+		if !field.IsExported() || field.Name == "Password" {
+			continue
+		}
+		if !reflect.DeepEqual(va.Field(i).Interface(), vb.Field(i).Interface()) {
+			changed = append(changed, field.Name)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,53 @@
+package options
+
+import (
+	"path/filepath"
+)
+
+// NodePaths are the locations of the node data. By default all dbs are in <datadir>/<peername>,
+// each of them can be moved to another volume, e.g. the chain db to a big disk and the groups db to a ssd.
+type NodePaths struct {
+	Root     string // <datadir>/<peername>
+	GroupsDb string // dir of the groups db, the group items
+	ChainDb  string // dir of the chain db, the blocks, trxs and posts
+	AppDb    string // dir of the app db, the app content index, seeds and webhooks
+	Certs    string // certificates of the api server
+	Seeds    string // group seed files written by backup and joined after restore
+}
+
+const (
+	DefaultCertDir = "certs"
+	DefaultSeedDir = "seeds"
+)
+
+func NewNodePaths(dataDir, peerName string) *NodePaths {
+	root := filepath.Join(dataDir, peerName)
+	return &NodePaths{
+		Root:     root,
+		GroupsDb: root,
+		ChainDb:  root,
+		AppDb:    root,
+		Certs:    DefaultCertDir,
+		Seeds:    DefaultSeedDir,
+	}
+}
+
+// Override replaces the paths by the non-empty paths of o
+func (p *NodePaths) Override(o *NodePaths) *NodePaths {
+	for _, pair := range []struct {
+		dst *string
+		src string
+	}{
+		{&p.Root, o.Root},
+		{&p.GroupsDb, o.GroupsDb},
+		{&p.ChainDb, o.ChainDb},
+		{&p.AppDb, o.AppDb},
+		{&p.Certs, o.Certs},
+		{&p.Seeds, o.Seeds},
+	} {
+		if pair.src != "" {
+			*pair.dst = pair.src
+		}
+	}
+	return p
+}
@@ -0,0 +1,440 @@
+package stats
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rumsystem/quorum/internal/pkg/logging"
+	"github.com/rumsystem/quorum/internal/pkg/storage"
+)
+
+var stats_log = logging.Logger("stats")
+
+// The metrics are counters summed per bucket, the node level ones are recorded with the empty group
+const (
+	PeerConnect    = "peer_connect"    // node level, the first conn to a peer is opened
+	PeerDisconnect = "peer_disconnect" // node level, the last conn to a peer is closed
+	SyncRound      = "sync_round"      // a block sync request of the group got a response
+	BlockApplied   = "block_applied"   // a block is added to the chain of the group
+	BytesIn        = "bytes_in"        // received, by group on the group channels, node level on the chain data exchange
+	BytesOut       = "bytes_out"       // sent, as bytes_in
+)
+
+// Metrics are all the recorded metrics
+var Metrics = []string{PeerConnect, PeerDisconnect, SyncRound, BlockApplied, BytesIn, BytesOut}
+
+/*
+The counters are kept in memory and added to the db every flushInterval, keyed by
+
+	stats_m_<bucket>_<metric>_<group>  per minute, kept for minuteKeep
+	stats_h_<bucket>_<metric>_<group>  per hour, kept for hourKeep
+
+bucket is the zero padded unix seconds of the bucket start, the value is a big endian uint64.
+The minute buckets older than minuteKeep are summed into the hour buckets and deleted by the rollup.
+*/
+const (
+	statsPrefix  = "stats_"
+	minutePrefix = "stats_m_"
+	hourPrefix   = "stats_h_"
+	bucketDigits = 20
+)
+
+const (
+	flushInterval  = 10 * time.Second
+	rollupInterval = 10 * time.Minute
+)
+
+var errStopIter = errors.New("stop iteration")
+
+// Point is the sum of a metric in the bucket starting at Time, in unix seconds
+type Point struct {
+	Time  int64  `json:"time" example:"1663900020"`
+	Value uint64 `json:"value" example:"12"`
+}
+
+type Series struct {
+	Metric string  `json:"metric" example:"block_applied"`
+	Group  string  `json:"group" example:"ac0eea7c-2f3c-4c67-80b3-136e46b924a8"` // empty for the node level metrics
+	Points []Point `json:"points"`
+}
+
+type counterKey struct {
+	bucket int64
+	metric string
+	group  string
+}
+
+// Store records the counters of the node in a db
+type Store struct {
+	db         storage.QuorumStorage
+	minuteKeep time.Duration
+	hourKeep   time.Duration
+
+	mu      sync.Mutex
+	pending map[counterKey]uint64 // added since the last flush
+
+	writeMu sync.Mutex // serializes the read-modify-writes of the flushes, the rollups and the resets
+}
+
+var store *Store
+
+func NewStore(db storage.QuorumStorage, minuteKeep time.Duration, hourKeep time.Duration) *Store {
+	if minuteKeep < time.Hour {
+		minuteKeep = time.Hour
+	}
+	if hourKeep < minuteKeep {
+		hourKeep = minuteKeep
+	}
+	return &Store{db: db, minuteKeep: minuteKeep, hourKeep: hourKeep, pending: make(map[counterKey]uint64)}
+}
+
+// InitDB opens the stats db in dir and starts recording, the counters are flushed and rolled up until ctx is done
+func InitDB(ctx context.Context, dir string, backend string, minuteKeep time.Duration, hourKeep time.Duration) error {
+	db, err := storage.NewBackendStore(ctx, backend, dir, "stats")
+	if err != nil {
+		return err
+	}
+	s := NewStore(db, minuteKeep, hourKeep)
+	store = s
+	go s.run(ctx)
+	return nil
+}
+
+// GetStore returns nil if InitDB is not called
+func GetStore() *Store {
+	return store
+}
+
+// Inc adds n to the metric of the group in the current minute, it does nothing if the stats db is not opened
+func Inc(metric string, group string, n uint64) {
+	if s := store; s != nil {
+		s.Add(metric, group, n, time.Now())
+	}
+}
+
+// Query returns the series of the group between from and to, see Store.Query
+func Query(from time.Time, to time.Time, group string, step time.Duration) ([]*Series, error) {
+	s := store
+	if s == nil {
+		return nil, errors.New("stats db is not opened")
+	}
+	return s.Query(from, to, group, step)
+}
+
+// Reset zeroes all the counters, see Store.Reset
+func Reset() error {
+	s := store
+	if s == nil {
+		return errors.New("stats db is not opened")
+	}
+	return s.Reset()
+}
+
+func (s *Store) Add(metric string, group string, n uint64, now time.Time) {
+	if n == 0 {
+		return
+	}
+	k := counterKey{bucket: now.Truncate(time.Minute).Unix(), metric: metric, group: group}
+	s.mu.Lock()
+	s.pending[k] += n
+	s.mu.Unlock()
+}
+
+func (s *Store) run(ctx context.Context) {
+	flush := time.NewTicker(flushInterval)
+	defer flush.Stop()
+	rollup := time.NewTicker(rollupInterval)
+	defer rollup.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := s.Flush(); err != nil {
+				stats_log.Warningf("flush stats failed: %s", err)
+			}
+			return
+		case <-flush.C:
+			if err := s.Flush(); err != nil {
+				stats_log.Warningf("flush stats failed: %s", err)
+			}
+		case now := <-rollup.C:
+			if err := s.Rollup(now); err != nil {
+				stats_log.Warningf("rollup stats failed: %s", err)
+			}
+		}
+	}
+}
+
+// Flush adds the pending counters to the db
+func (s *Store) Flush() error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.flush()
+}
+
+// flush must be called with writeMu held
+func (s *Store) flush() error {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = make(map[counterKey]uint64)
+	s.mu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	keys := make([][]byte, 0, len(pending))
+	vals := make([][]byte, 0, len(pending))
+	for k, n := range pending {
+		key := statsKey(minutePrefix, k)
+		v, err := s.get(key)
+		if err != nil {
+			s.restore(pending)
+			return err
+		}
+		keys = append(keys, key)
+		vals = append(vals, encodeValue(v+n))
+	}
+	if err := s.db.BatchWrite(keys, vals); err != nil {
+		s.restore(pending)
+		return err
+	}
+	return nil
+}
+
+// restore puts the counters of a failed flush back, to be retried by the next flush
+func (s *Store) restore(pending map[counterKey]uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, n := range pending {
+		s.pending[k] += n
+	}
+}
+
+// Reset drops the pending counters and deletes the recorded ones in one db transaction. It waits for the flush or
+// the rollup in progress, the counters added while resetting are kept.
+func (s *Store) Reset() error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.mu.Lock()
+	s.pending = make(map[counterKey]uint64)
+	s.mu.Unlock()
+
+	n, err := s.db.PrefixDelete([]byte(statsPrefix))
+	if err != nil {
+		return err
+	}
+	stats_log.Infof("stats reset, %d counters deleted", n)
+	return nil
+}
+
+// Rollup sums the minute buckets older than minuteKeep into hour buckets and deletes the hour buckets older than hourKeep
+func (s *Store) Rollup(now time.Time) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if err := s.flush(); err != nil {
+		return err
+	}
+
+	// only whole hours are rolled up, so that an hour bucket is never added to by the minute buckets later
+	cutoff := now.Add(-s.minuteKeep).Truncate(time.Hour).Unix()
+	hours := make(map[counterKey]uint64)
+	var rolled [][]byte
+	err := s.scan(minutePrefix, 0, cutoff, func(key []byte, k counterKey, v uint64) {
+		k.bucket -= k.bucket % 3600
+		hours[k] += v
+		rolled = append(rolled, key)
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(hours) > 0 {
+		keys := make([][]byte, 0, len(hours))
+		vals := make([][]byte, 0, len(hours))
+		for k, n := range hours {
+			key := statsKey(hourPrefix, k)
+			v, err := s.get(key)
+			if err != nil {
+				return err
+			}
+			keys = append(keys, key)
+			vals = append(vals, encodeValue(v+n))
+		}
+		if err := s.db.BatchWrite(keys, vals); err != nil {
+			return err
+		}
+		for _, key := range rolled {
+			if err := s.db.Delete(key); err != nil {
+				return err
+			}
+		}
+		stats_log.Debugf("rolled up %d minute stats into %d hour stats", len(rolled), len(hours))
+	}
+
+	expired := now.Add(-s.hourKeep).Unix()
+	var deleted [][]byte
+	err = s.scan(hourPrefix, 0, expired, func(key []byte, k counterKey, v uint64) {
+		deleted = append(deleted, key)
+	})
+	if err != nil {
+		return err
+	}
+	for _, key := range deleted {
+		if err := s.db.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Query returns the series of the metrics between from and to, summed by step. group filters the series of a group,
+// "node" for the node level metrics, empty for all. Step 0 is one minute if from is in the minute stats, one hour otherwise.
+// The minute stats rolled up are only available by the hour, a step less than one hour returns them at the hour start.
+func (s *Store) Query(from time.Time, to time.Time, group string, step time.Duration) ([]*Series, error) {
+	if !to.After(from) {
+		return nil, fmt.Errorf("to %s should be after from %s", to, from)
+	}
+	if step == 0 {
+		step = time.Minute
+		if from.Before(time.Now().Add(-s.minuteKeep)) {
+			step = time.Hour
+		}
+	}
+	if step < time.Minute || step%time.Minute != 0 {
+		return nil, fmt.Errorf("step %s should be whole minutes", step)
+	}
+	if err := s.Flush(); err != nil {
+		return nil, err
+	}
+
+	stepSecs := int64(step / time.Second)
+	type seriesKey struct {
+		metric string
+		group  string
+	}
+	sums := make(map[seriesKey]map[int64]uint64)
+	add := func(key []byte, k counterKey, v uint64) {
+		if group == "node" && k.group != "" || group != "" && group != "node" && k.group != group {
+			return
+		}
+		sk := seriesKey{metric: k.metric, group: k.group}
+		if sums[sk] == nil {
+			sums[sk] = make(map[int64]uint64)
+		}
+		sums[sk][k.bucket-k.bucket%stepSecs] += v
+	}
+	// an hour bucket overlapping from is included, it can't be split
+	fromHour := from.Truncate(time.Hour).Unix()
+	if err := s.scan(hourPrefix, fromHour, to.Unix(), add); err != nil {
+		return nil, err
+	}
+	if err := s.scan(minutePrefix, from.Truncate(time.Minute).Unix(), to.Unix(), add); err != nil {
+		return nil, err
+	}
+
+	result := make([]*Series, 0, len(sums))
+	for sk, buckets := range sums {
+		series := &Series{Metric: sk.metric, Group: sk.group, Points: make([]Point, 0, len(buckets))}
+		for t, v := range buckets {
+			series.Points = append(series.Points, Point{Time: t, Value: v})
+		}
+		sort.Slice(series.Points, func(i, j int) bool { return series.Points[i].Time < series.Points[j].Time })
+		result = append(result, series)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Metric != result[j].Metric {
+			return result[i].Metric < result[j].Metric
+		}
+		return result[i].Group < result[j].Group
+	})
+	return result, nil
+}
+
+// scan calls fn with the counters of the buckets in [from, to), the keys are collected before the values are read
+func (s *Store) scan(prefix string, from int64, to int64, fn func(key []byte, k counterKey, v uint64)) error {
+	type scanned struct {
+		key []byte
+		k   counterKey
+	}
+	var keys []scanned
+	seek := []byte(fmt.Sprintf("%s%0*d", prefix, bucketDigits, from))
+	_, err := s.db.PrefixForeachKey(seek, []byte(prefix), false, func(key []byte, err error) error {
+		if err != nil {
+			return err
+		}
+		k, err := parseStatsKey(prefix, key)
+		if err != nil {
+			stats_log.Warningf("skip invalid stats key %s: %s", key, err)
+			return nil
+		}
+		if k.bucket >= to {
+			return errStopIter
+		}
+		keys = append(keys, scanned{key: append([]byte(nil), key...), k: k})
+		return nil
+	})
+	if err != nil && err != errStopIter {
+		return err
+	}
+
+	for _, item := range keys {
+		v, err := s.get(item.key)
+		if err != nil {
+			return err
+		}
+		fn(item.key, item.k, v)
+	}
+	return nil
+}
+
+// get returns 0 if the key does not exist
+func (s *Store) get(key []byte) (uint64, error) {
+	exist, err := s.db.IsExist(key)
+	if err != nil || !exist {
+		return 0, err
+	}
+	v, err := s.db.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	if len(v) != 8 {
+		return 0, fmt.Errorf("invalid stats value of %s", key)
+	}
+	return binary.BigEndian.Uint64(v), nil
+}
+
+func statsKey(prefix string, k counterKey) []byte {
+	return []byte(fmt.Sprintf("%s%0*d_%s_%s", prefix, bucketDigits, k.bucket, k.metric, k.group))
+}
+
+// parseStatsKey splits the key at the last "_", the metric names have "_" but the group ids don't
+func parseStatsKey(prefix string, key []byte) (counterKey, error) {
+	rest := strings.TrimPrefix(string(key), prefix)
+	if len(rest) < bucketDigits+1 || rest[bucketDigits] != '_' {
+		return counterKey{}, errors.New("malformed key")
+	}
+	bucket, err := strconv.ParseInt(rest[:bucketDigits], 10, 64)
+	if err != nil {
+		return counterKey{}, err
+	}
+	rest = rest[bucketDigits+1:]
+	i := strings.LastIndex(rest, "_")
+	if i < 0 {
+		return counterKey{}, errors.New("malformed key")
+	}
+	return counterKey{bucket: bucket, metric: rest[:i], group: rest[i+1:]}, nil
+}
+
+func encodeValue(v uint64) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, v)
+	return buf
+}
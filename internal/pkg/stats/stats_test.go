@@ -0,0 +1,158 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rumsystem/quorum/internal/pkg/storage"
+)
+
+func findSeries(series []*Series, metric string, group string) *Series {
+	for _, s := range series {
+		if s.Metric == metric && s.Group == group {
+			return s
+		}
+	}
+	return nil
+}
+
+func TestStoreQuery(t *testing.T) {
+	s := NewStore(storage.NewMemStore(), 48*time.Hour, 30*24*time.Hour)
+	now := time.Now().Truncate(time.Hour)
+
+	s.Add(BlockApplied, "group-1", 2, now)
+	s.Add(BlockApplied, "group-1", 3, now.Add(30*time.Second))
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	// added to the flushed bucket by the next flush
+	s.Add(BlockApplied, "group-1", 1, now.Add(40*time.Second))
+	s.Add(BlockApplied, "group-1", 4, now.Add(3*time.Minute))
+	s.Add(BlockApplied, "group-2", 7, now)
+	s.Add(PeerConnect, "", 1, now)
+
+	series, err := s.Query(now, now.Add(time.Hour), "", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series) != 3 {
+		t.Fatalf("want 3 series, got %d", len(series))
+	}
+	blocks := findSeries(series, BlockApplied, "group-1")
+	if blocks == nil || len(blocks.Points) != 2 {
+		t.Fatalf("want 2 points of group-1, got %+v", blocks)
+	}
+	if blocks.Points[0] != (Point{Time: now.Unix(), Value: 6}) || blocks.Points[1] != (Point{Time: now.Add(3 * time.Minute).Unix(), Value: 4}) {
+		t.Errorf("unexpected points %+v", blocks.Points)
+	}
+
+	series, err = s.Query(now, now.Add(time.Hour), "group-1", 5*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series) != 1 || len(series[0].Points) != 1 || series[0].Points[0].Value != 10 {
+		t.Errorf("want group-1 summed in one 5m point, got %+v", series)
+	}
+
+	series, err = s.Query(now, now.Add(time.Hour), "node", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series) != 1 || series[0].Metric != PeerConnect {
+		t.Errorf("want the node level series only, got %+v", series)
+	}
+
+	// to is exclusive
+	series, err = s.Query(now.Add(time.Minute), now.Add(3*time.Minute), "", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series) != 0 {
+		t.Errorf("want no series out of range, got %+v", series)
+	}
+
+	if _, err := s.Query(now, now.Add(time.Hour), "", 30*time.Second); err == nil {
+		t.Errorf("step less than a minute should be rejected")
+	}
+}
+
+func TestStoreRollup(t *testing.T) {
+	db := storage.NewMemStore()
+	s := NewStore(db, 2*time.Hour, 24*time.Hour)
+	now := time.Now().Truncate(time.Hour)
+
+	old := now.Add(-5 * time.Hour)
+	s.Add(SyncRound, "group-1", 1, old)
+	s.Add(SyncRound, "group-1", 2, old.Add(10*time.Minute))
+	s.Add(SyncRound, "group-1", 5, now.Add(-time.Hour))
+	s.Add(SyncRound, "group-1", 9, now.Add(-30*time.Hour))
+	if err := s.Rollup(now); err != nil {
+		t.Fatal(err)
+	}
+
+	minutes, hours := 0, 0
+	db.PrefixForeach([]byte(minutePrefix), func(k []byte, v []byte, err error) error {
+		minutes++
+		return nil
+	})
+	db.PrefixForeach([]byte(hourPrefix), func(k []byte, v []byte, err error) error {
+		hours++
+		return nil
+	})
+	if minutes != 1 || hours != 1 {
+		t.Fatalf("want 1 minute and 1 hour stats left, got %d and %d", minutes, hours)
+	}
+
+	series, err := s.Query(now.Add(-24*time.Hour), now, "group-1", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series) != 1 {
+		t.Fatalf("want 1 series, got %+v", series)
+	}
+	want := []Point{{Time: old.Unix(), Value: 3}, {Time: now.Add(-time.Hour).Unix(), Value: 5}}
+	if len(series[0].Points) != 2 || series[0].Points[0] != want[0] || series[0].Points[1] != want[1] {
+		t.Errorf("want %+v, got %+v", want, series[0].Points)
+	}
+}
+
+func TestParseStatsKey(t *testing.T) {
+	k := counterKey{bucket: 1663900020, metric: PeerDisconnect, group: ""}
+	got, err := parseStatsKey(minutePrefix, statsKey(minutePrefix, k))
+	if err != nil || got != k {
+		t.Errorf("want %+v, got %+v %v", k, got, err)
+	}
+	if _, err := parseStatsKey(minutePrefix, []byte("stats_m_123")); err == nil {
+		t.Errorf("malformed key should be rejected")
+	}
+}
+
+func TestStoreReset(t *testing.T) {
+	s := NewStore(storage.NewMemStore(), 48*time.Hour, 30*24*time.Hour)
+	now := time.Now().Truncate(time.Hour)
+
+	s.Add(BytesIn, "", 100, now)
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	s.Add(BytesIn, "", 50, now)
+	if err := s.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	series, err := s.Query(now, now.Add(time.Hour), "", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series) != 0 {
+		t.Fatalf("want no series after reset, got %+v", series)
+	}
+
+	s.Add(BytesIn, "", 7, now)
+	series, err = s.Query(now, now.Add(time.Hour), "", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series) != 1 || series[0].Points[0].Value != 7 {
+		t.Errorf("want the counter added after reset only, got %+v", series)
+	}
+}
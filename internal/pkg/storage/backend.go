@@ -0,0 +1,262 @@
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
+)
+
+const BACKEND_BOLT = "bolt"
+
+// Backend is the key value engine of a store, the rest of QuorumStorage is built on it by NewBackendStore
+type Backend interface {
+	Init(path string) error
+	// Get returns a nil value if the key does not exist
+	Get(key []byte) ([]byte, error)
+	Set(key []byte, val []byte) error
+	Delete(key []byte) error
+	// Iterate calls fn in key order for the keys with prefix, forward from seek, or backward from the last key if reverse.
+	// k and v are only valid during the call.
+	Iterate(prefix []byte, seek []byte, reverse bool, fn func(k []byte, v []byte) error) error
+	Close() error
+}
+
+// batchWriter is implemented by backends which can write several keys atomically
+type batchWriter interface {
+	BatchWrite(keys [][]byte, values [][]byte) error
+}
+
+// BackendFactory opens the bucket of a storage backend in dir
+type BackendFactory func(ctx context.Context, dir string, bucket string) (Backend, error)
+
+// DefaultBackend is used by CreateDb and the other dbs of the node
+var DefaultBackend = BACKEND_BOLT
+
+var (
+	backendsMu sync.RWMutex
+	backends   = map[string]BackendFactory{}
+)
+
+// RegisterBackend makes a storage backend available by the name, it panics if the name is registered twice
+func RegisterBackend(name string, factory BackendFactory) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+
+	if factory == nil {
+		panic("storage: RegisterBackend factory is nil")
+	}
+	if _, ok := backends[name]; ok {
+		panic("storage: RegisterBackend called twice for " + name)
+	}
+	backends[name] = factory
+}
+
+// IsBackendRegistered returns true if the backend is registered, empty name means the default backend
+func IsBackendRegistered(name string) bool {
+	if name == "" {
+		return true
+	}
+
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+	_, ok := backends[name]
+	return ok
+}
+
+// BackendNames returns the sorted names of registered backends
+func BackendNames() []string {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewBackendStore opens the bucket in dir with the backend, empty name means the default backend.
+// A backend which implements QuorumStorage itself is used as is.
+func NewBackendStore(ctx context.Context, name string, dir string, bucket string) (QuorumStorage, error) {
+	if name == "" {
+		name = DefaultBackend
+	}
+
+	backendsMu.RLock()
+	factory, ok := backends[name]
+	backendsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown storage backend: %s, registered: %v", name, BackendNames())
+	}
+	backend, err := factory(ctx, dir, bucket)
+	if err != nil {
+		return nil, err
+	}
+	db, ok := backend.(QuorumStorage)
+	if !ok {
+		db = newBackendStore(backend)
+	}
+	store, err := wrapEncryption(bucket, db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+// backendStore implements QuorumStorage on a Backend
+type backendStore struct {
+	Backend
+	seqMu sync.Mutex
+}
+
+func newBackendStore(backend Backend) *backendStore {
+	return &backendStore{Backend: backend}
+}
+
+func (s *backendStore) Get(key []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, rumerrors.ErrEmptyKey
+	}
+	return s.Backend.Get(key)
+}
+
+func (s *backendStore) Set(key []byte, val []byte) error {
+	if len(key) == 0 {
+		return rumerrors.ErrEmptyKey
+	}
+	return s.Backend.Set(key, val)
+}
+
+func (s *backendStore) IsExist(key []byte) (bool, error) {
+	val, err := s.Get(key)
+	return val != nil, err
+}
+
+// deleteKeys deletes the keys after the iteration, a backend does not have to support writes during Iterate
+func (s *backendStore) deleteKeys(keys [][]byte) (int, error) {
+	for i, k := range keys {
+		if err := s.Backend.Delete(k); err != nil {
+			return i, err
+		}
+	}
+	return len(keys), nil
+}
+
+func (s *backendStore) PrefixDelete(prefix []byte) (int, error) {
+	return s.PrefixCondDelete(prefix, func(k []byte, v []byte, err error) (bool, error) {
+		return true, nil
+	})
+}
+
+func (s *backendStore) PrefixCondDelete(prefix []byte, fn func(k []byte, v []byte, err error) (bool, error)) (int, error) {
+	keys := [][]byte{}
+	err := s.Backend.Iterate(prefix, prefix, false, func(k []byte, v []byte) error {
+		ok, err := fn(k, v, nil)
+		if err != nil {
+			return err
+		}
+		if ok {
+			keys = append(keys, append([]byte{}, k...))
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return s.deleteKeys(keys)
+}
+
+// PrefixForeachKey iterates keys with the `valid` prefix, forward from `prefix`, or backward from the last key
+func (s *backendStore) PrefixForeachKey(prefix []byte, valid []byte, reverse bool, fn func([]byte, error) error) (int, error) {
+	matched := 0
+	err := s.Backend.Iterate(valid, prefix, reverse, func(k []byte, v []byte) error {
+		if err := fn(k, nil); err != nil {
+			return err
+		}
+		matched += 1
+		return nil
+	})
+	return matched, err
+}
+
+func (s *backendStore) PrefixForeach(prefix []byte, fn func([]byte, []byte, error) error) error {
+	return s.Backend.Iterate(prefix, prefix, false, func(k []byte, v []byte) error {
+		return fn(k, v, nil)
+	})
+}
+
+func (s *backendStore) Foreach(fn func([]byte, []byte, error) error) error {
+	return s.PrefixForeach(nil, fn)
+}
+
+// BatchWrite is only atomic if the backend supports it
+func (s *backendStore) BatchWrite(keys [][]byte, values [][]byte) error {
+	if len(keys) != len(values) {
+		return errors.New("keys' and values' length should be equal")
+	}
+	if w, ok := s.Backend.(batchWriter); ok {
+		return w.BatchWrite(keys, values)
+	}
+	for i, k := range keys {
+		if err := s.Set(k, values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *backendStore) GetSequence(key []byte, bandwidth uint64) (Sequence, error) {
+	if len(key) == 0 {
+		return nil, errors.New("empty key")
+	}
+	if bandwidth == 0 {
+		return nil, errors.New("zero bandwidth")
+	}
+	return &backendSequence{store: s, key: append([]byte{}, key...)}, nil
+}
+
+// Stats reports the stats of the backend if it supports it
+func (s *backendStore) Stats() (*StoreStats, error) {
+	p, ok := s.Backend.(StatsProvider)
+	if !ok {
+		return nil, errors.New("storage backend does not support stats")
+	}
+	return p.Stats()
+}
+
+// backendSequence stores the next value on every call, no lease is kept
+type backendSequence struct {
+	store *backendStore
+	key   []byte
+}
+
+func (seq *backendSequence) Next() (uint64, error) {
+	seq.store.seqMu.Lock()
+	defer seq.store.seqMu.Unlock()
+
+	val, err := seq.store.Get(seq.key)
+	if err != nil {
+		return 0, err
+	}
+	var next uint64
+	if val != nil {
+		next = binary.BigEndian.Uint64(val)
+	}
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], next+1)
+	if err := seq.store.Set(seq.key, buf[:]); err != nil {
+		return 0, err
+	}
+	return next, nil
+}
+
+func (seq *backendSequence) Release() error {
+	return nil
+}
@@ -159,6 +159,11 @@ func (cs *Storage) AddPost(trx *quorumpb.Trx, prefix ...string) error {
 		return err
 	}
 
+	if trx.ExpiresAt > 0 {
+		if err := cs.addPostExpiry(trx, prefix...); err != nil {
+			return err
+		}
+	}
 	return cs.dbmgr.Db.Set([]byte(key), ctnBytes)
 }
 
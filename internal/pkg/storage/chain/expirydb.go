@@ -0,0 +1,96 @@
+package chainstorage
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	s "github.com/rumsystem/quorum/internal/pkg/storage"
+	rumchaindata "github.com/rumsystem/quorum/pkg/data"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+// the value of the expiry key is "<expired>_<timestamp>", the timestamp locates the post key when pruning
+func (cs *Storage) addPostExpiry(trx *quorumpb.Trx, prefix ...string) error {
+	key := s.GetPostExpiryKey(trx.GroupId, trx.TrxId, prefix...)
+	value := strconv.FormatInt(trx.ExpiresAt, 10) + "_" + strconv.FormatInt(trx.TimeStamp, 10)
+	return cs.dbmgr.Db.Set([]byte(key), []byte(value))
+}
+
+func parsePostExpiry(v []byte) (expired int64, timestamp int64, err error) {
+	parts := strings.SplitN(string(v), "_", 2)
+	if len(parts) != 2 {
+		return 0, 0, errors.New("invalid post expiry")
+	}
+	if expired, err = strconv.ParseInt(parts[0], 10, 64); err != nil {
+		return 0, 0, errors.New("invalid post expiry")
+	}
+	if timestamp, err = strconv.ParseInt(parts[1], 10, 64); err != nil {
+		return 0, 0, errors.New("invalid post expiry")
+	}
+	return expired, timestamp, nil
+}
+
+// GetPostExpiry returns the expiry timestamp (unix nano) of the post, zero if the post never expires
+func (cs *Storage) GetPostExpiry(groupId, trxId string, prefix ...string) (int64, error) {
+	key := []byte(s.GetPostExpiryKey(groupId, trxId, prefix...))
+	exist, err := cs.dbmgr.Db.IsExist(key)
+	if err != nil || !exist {
+		return 0, err
+	}
+	v, err := cs.dbmgr.Db.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	expired, _, err := parsePostExpiry(v)
+	return expired, err
+}
+
+// IsPostExpired reports whether the post is expired at `now`, the grace window of rumchaindata.TrxExpiryGrace is applied
+func (cs *Storage) IsPostExpired(groupId, trxId string, now time.Time, prefix ...string) (bool, error) {
+	expired, err := cs.GetPostExpiry(groupId, trxId, prefix...)
+	if err != nil {
+		return false, err
+	}
+	return rumchaindata.IsExpired(expired, now), nil
+}
+
+// PruneExpiredPosts removes the content of the posts expired at `now`, the trxs are kept in the chain.
+// It returns the number of removed posts.
+func (cs *Storage) PruneExpiredPosts(groupId string, now time.Time, prefix ...string) (int, error) {
+	pre := s.GetPostExpiryPrefix(groupId, prefix...)
+	keys := [][]byte{}
+	postKeys := [][]byte{}
+	err := cs.dbmgr.Db.PrefixForeach([]byte(pre), func(k []byte, v []byte, err error) error {
+		if err != nil {
+			return err
+		}
+		expired, timestamp, err := parsePostExpiry(v)
+		if err != nil {
+			chaindb_log.Warningf("skip invalid post expiry %s", k)
+			return nil
+		}
+		if !rumchaindata.IsExpired(expired, now) {
+			return nil
+		}
+		trxId := string(k[len(pre):])
+		keys = append(keys, append([]byte{}, k...))
+		postKeys = append(postKeys, []byte(s.GetPostKey(groupId, fmt.Sprint(timestamp), trxId, prefix...)))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range keys {
+		if err := cs.dbmgr.Db.Delete(postKeys[i]); err != nil {
+			return i, err
+		}
+		if err := cs.dbmgr.Db.Delete(keys[i]); err != nil {
+			return i, err
+		}
+	}
+	return len(keys), nil
+}
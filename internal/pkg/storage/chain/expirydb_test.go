@@ -0,0 +1,65 @@
+package chainstorage
+
+import (
+	"testing"
+	"time"
+
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+func TestPostExpiry(t *testing.T) {
+	cs := newMemChainStorage(t)
+	groupId := "5ed3f9fe-81e2-450d-9146-7a329aac2b62"
+	now := time.Now()
+	block := &quorumpb.Block{GroupId: groupId, TimeStamp: now.Add(-time.Hour).UnixNano()}
+	// post-2 is expired beyond the grace window, post-3 is expired within it
+	for i, expired := range []int64{0, now.Add(-time.Hour).UnixNano(), now.Add(-time.Second).UnixNano(), 0} {
+		trx := &quorumpb.Trx{
+			GroupId:   groupId,
+			TrxId:     []string{"post-1", "post-2", "post-3", "post-4"}[i],
+			Type:      quorumpb.TrxType_POST,
+			TimeStamp: now.Add(-2*time.Hour).UnixNano() + int64(i),
+			ExpiresAt: expired,
+		}
+		if err := cs.AddPost(trx, "peer"); err != nil {
+			t.Fatal(err)
+		}
+		block.Trxs = append(block.Trxs, trx)
+	}
+	if err := cs.AddBlock(block, false, "peer"); err != nil {
+		t.Fatal(err)
+	}
+
+	if expired, err := cs.IsPostExpired(groupId, "post-2", now, "peer"); err != nil || !expired {
+		t.Errorf("expect post-2 expired, got %v %v", expired, err)
+	}
+	if expired, err := cs.IsPostExpired(groupId, "post-3", now, "peer"); err != nil || expired {
+		t.Errorf("expect post-3 in the grace window, got %v %v", expired, err)
+	}
+	if expired, _ := cs.GetPostExpiry(groupId, "post-1", "peer"); expired != 0 {
+		t.Errorf("expect post-1 never expire, got %d", expired)
+	}
+
+	posts, last, _, err := cs.GetPostsByRange(groupId, 0, 0, nil, 0, 2, "peer")
+	if err != nil || len(posts) != 2 || posts[0].TrxId != "post-1" || posts[1].TrxId != "post-3" {
+		t.Fatalf("expect post-1 and post-3 in the first page, got %v %v", posts, err)
+	}
+	posts, _, _, err = cs.GetPostsByRange(groupId, 0, 0, last, 0, 2, "peer")
+	if err != nil || len(posts) != 1 || posts[0].TrxId != "post-4" {
+		t.Fatalf("expect post-4 in the second page, got %v %v", posts, err)
+	}
+
+	pruned, err := cs.PruneExpiredPosts(groupId, now, "peer")
+	if err != nil || pruned != 1 {
+		t.Fatalf("expect 1 post pruned, got %d %v", pruned, err)
+	}
+	if expired, _ := cs.GetPostExpiry(groupId, "post-2", "peer"); expired != 0 {
+		t.Errorf("expect the expiry of post-2 removed")
+	}
+	if pruned, _ := cs.PruneExpiredPosts(groupId, now.Add(time.Minute), "peer"); pruned != 1 {
+		t.Errorf("expect post-3 pruned after the grace window, got %d", pruned)
+	}
+	if posts, _, _, _ := cs.GetPostsByRange(groupId, 0, 0, nil, 0, 0, "peer"); len(posts) != 2 {
+		t.Errorf("expect 2 posts left, got %d", len(posts))
+	}
+}
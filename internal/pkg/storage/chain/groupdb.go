@@ -46,10 +46,82 @@ func (cs *Storage) RmGroup(groupId string) error {
 		return errors.New("Group Not Found")
 	}
 
+	//delete consensus config of group
+	cnsKey := s.GetGroupConsensusConfigKey(groupId)
+	if exist, _ := cs.dbmgr.GroupInfoDb.IsExist([]byte(cnsKey)); exist {
+		if err := cs.dbmgr.GroupInfoDb.Delete([]byte(cnsKey)); err != nil {
+			return err
+		}
+	}
+
+	//delete consensus name of group
+	nameKey := s.GetGroupConsensusNameKey(groupId)
+	if exist, _ := cs.dbmgr.GroupInfoDb.IsExist([]byte(nameKey)); exist {
+		if err := cs.dbmgr.GroupInfoDb.Delete([]byte(nameKey)); err != nil {
+			return err
+		}
+	}
+
+	//delete read-only flag of group
+	roKey := s.GetGroupReadOnlyKey(groupId)
+	if exist, _ := cs.dbmgr.GroupInfoDb.IsExist([]byte(roKey)); exist {
+		if err := cs.dbmgr.GroupInfoDb.Delete([]byte(roKey)); err != nil {
+			return err
+		}
+	}
+
 	//delete group
 	return cs.dbmgr.GroupInfoDb.Delete([]byte(key))
 }
 
+// SetGroupReadOnly marks the group joined read-only
+func (cs *Storage) SetGroupReadOnly(groupId string) error {
+	key := s.GetGroupReadOnlyKey(groupId)
+	return cs.dbmgr.GroupInfoDb.Set([]byte(key), []byte{1})
+}
+
+// IsGroupReadOnly reports whether the group is joined read-only
+func (cs *Storage) IsGroupReadOnly(groupId string) (bool, error) {
+	key := s.GetGroupReadOnlyKey(groupId)
+	return cs.dbmgr.GroupInfoDb.IsExist([]byte(key))
+}
+
+// SetGroupConsensusName save the name of the consensus implementation the group is created with
+func (cs *Storage) SetGroupConsensusName(groupId string, name string) error {
+	key := s.GetGroupConsensusNameKey(groupId)
+	return cs.dbmgr.GroupInfoDb.Set([]byte(key), []byte(name))
+}
+
+// GetGroupConsensusName returns the name of the consensus implementation of group, empty if not set
+func (cs *Storage) GetGroupConsensusName(groupId string) (string, error) {
+	key := s.GetGroupConsensusNameKey(groupId)
+	exist, err := cs.dbmgr.GroupInfoDb.IsExist([]byte(key))
+	if err != nil || !exist {
+		return "", err
+	}
+	value, err := cs.dbmgr.GroupInfoDb.Get([]byte(key))
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+// SetGroupConsensusConfig save the encoded consensus config of group
+func (cs *Storage) SetGroupConsensusConfig(groupId string, data []byte) error {
+	key := s.GetGroupConsensusConfigKey(groupId)
+	return cs.dbmgr.GroupInfoDb.Set([]byte(key), data)
+}
+
+// GetGroupConsensusConfig returns the encoded consensus config of group, nil if not set
+func (cs *Storage) GetGroupConsensusConfig(groupId string) ([]byte, error) {
+	key := s.GetGroupConsensusConfigKey(groupId)
+	exist, err := cs.dbmgr.GroupInfoDb.IsExist([]byte(key))
+	if err != nil || !exist {
+		return nil, err
+	}
+	return cs.dbmgr.GroupInfoDb.Get([]byte(key))
+}
+
 func (cs *Storage) GetGroupInfo(groupId string) (*quorumpb.GroupItem, error) {
 	//check if group exist
 	key := s.GetGroupItemKey(groupId)
@@ -126,6 +198,18 @@ func RemoveGroupData(db s.QuorumStorage, groupId string, prefix ...string) error
 	key = s.GetTrxPrefix(groupId, prefix...)
 	keys = append(keys, key)
 
+	// publish queue
+	key = s.GetPublishQueuePrefix(groupId, prefix...)
+	keys = append(keys, key)
+
+	// idempotency keys
+	key = s.GetIdempotencyKeyPrefix(groupId, prefix...)
+	keys = append(keys, key)
+
+	// blocks including trxs
+	key = s.GetTrxBlockPrefix(groupId, prefix...)
+	keys = append(keys, key)
+
 	//remove all
 	for _, key_prefix := range keys {
 		_, err := db.PrefixDelete([]byte(key_prefix))
@@ -0,0 +1,55 @@
+package chainstorage
+
+import (
+	"testing"
+
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+func TestGroupReadOnly(t *testing.T) {
+	cs := newMemChainStorage(t)
+	groupId := "5ed3f9fe-81e2-450d-9146-7a329aac2b62"
+	if err := cs.AddGroup(&quorumpb.GroupItem{GroupId: groupId}); err != nil {
+		t.Fatal(err)
+	}
+	if ro, err := cs.IsGroupReadOnly(groupId); err != nil || ro {
+		t.Fatalf("expect group not read-only, got %v %v", ro, err)
+	}
+	if err := cs.SetGroupReadOnly(groupId); err != nil {
+		t.Fatal(err)
+	}
+	if ro, err := cs.IsGroupReadOnly(groupId); err != nil || !ro {
+		t.Fatalf("expect group read-only, got %v %v", ro, err)
+	}
+
+	if err := cs.RmGroup(groupId); err != nil {
+		t.Fatal(err)
+	}
+	if ro, _ := cs.IsGroupReadOnly(groupId); ro {
+		t.Errorf("expect read-only flag removed with the group")
+	}
+}
+
+func TestGroupConsensusName(t *testing.T) {
+	cs := newMemChainStorage(t)
+	groupId := "0b4b8d3e-7d0f-4a59-a4a4-5d5c1b7c3a10"
+	if err := cs.AddGroup(&quorumpb.GroupItem{GroupId: groupId}); err != nil {
+		t.Fatal(err)
+	}
+	if name, err := cs.GetGroupConsensusName(groupId); err != nil || name != "" {
+		t.Fatalf("expect no consensus name, got %q %v", name, err)
+	}
+	if err := cs.SetGroupConsensusName(groupId, "molasses"); err != nil {
+		t.Fatal(err)
+	}
+	if name, err := cs.GetGroupConsensusName(groupId); err != nil || name != "molasses" {
+		t.Fatalf("expect consensus name molasses, got %q %v", name, err)
+	}
+
+	if err := cs.RmGroup(groupId); err != nil {
+		t.Fatal(err)
+	}
+	if name, _ := cs.GetGroupConsensusName(groupId); name != "" {
+		t.Errorf("expect consensus name removed with the group")
+	}
+}
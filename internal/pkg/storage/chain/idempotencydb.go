@@ -0,0 +1,36 @@
+package chainstorage
+
+import (
+	"strings"
+
+	s "github.com/rumsystem/quorum/internal/pkg/storage"
+)
+
+// SetIdempotencyKey saves the encoded record of an idempotency key of the group
+func (cs *Storage) SetIdempotencyKey(groupId, key string, data []byte, prefix ...string) error {
+	return cs.dbmgr.Db.Set([]byte(s.GetIdempotencyKeyKey(groupId, key, prefix...)), data)
+}
+
+// RmIdempotencyKey removes the record of the idempotency key, it is not an error if there is none
+func (cs *Storage) RmIdempotencyKey(groupId, key string, prefix ...string) error {
+	k := []byte(s.GetIdempotencyKeyKey(groupId, key, prefix...))
+	exist, err := cs.dbmgr.Db.IsExist(k)
+	if err != nil || !exist {
+		return err
+	}
+	return cs.dbmgr.Db.Delete(k)
+}
+
+// GetIdempotencyKeys returns the encoded records of the idempotency keys of the group by key
+func (cs *Storage) GetIdempotencyKeys(groupId string, prefix ...string) (map[string][]byte, error) {
+	keyPrefix := s.GetIdempotencyKeyPrefix(groupId, prefix...)
+	records := make(map[string][]byte)
+	err := cs.dbmgr.Db.PrefixForeach([]byte(keyPrefix), func(k []byte, v []byte, err error) error {
+		if err != nil {
+			return err
+		}
+		records[strings.TrimPrefix(string(k), keyPrefix)] = append([]byte{}, v...)
+		return nil
+	})
+	return records, err
+}
@@ -0,0 +1,42 @@
+package chainstorage
+
+import (
+	"testing"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	cs := newMemChainStorage(t)
+	groupId := "5ed3f9fe-81e2-450d-9146-7a329aac2b62"
+	for _, key := range []string{"key_1", "key-2"} {
+		if err := cs.SetIdempotencyKey(groupId, key, []byte("record "+key), "peer"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cs.SetIdempotencyKey("other", "key-3", []byte("record key-3"), "peer"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cs.RmIdempotencyKey(groupId, "key-2", "peer"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cs.RmIdempotencyKey(groupId, "unknown", "peer"); err != nil {
+		t.Errorf("removing an unknown key should not fail: %s", err)
+	}
+	records, err := cs.GetIdempotencyKeys(groupId, "peer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || string(records["key_1"]) != "record key_1" {
+		t.Errorf("unexpected records: %q", records)
+	}
+
+	if err := cs.RemoveGroupData(groupId, "peer"); err != nil {
+		t.Fatal(err)
+	}
+	if records, _ := cs.GetIdempotencyKeys(groupId, "peer"); len(records) != 0 {
+		t.Errorf("keys should be removed with the group data, got %q", records)
+	}
+	if records, _ := cs.GetIdempotencyKeys("other", "peer"); len(records) != 1 {
+		t.Errorf("keys of other group should be kept, got %q", records)
+	}
+}
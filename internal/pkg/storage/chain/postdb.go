@@ -0,0 +1,153 @@
+package chainstorage
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	s "github.com/rumsystem/quorum/internal/pkg/storage"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// MaxPostRangeScanBlocks is the maximum number of blocks scanned by one GetPostsByRange call
+var MaxPostRangeScanBlocks = 1000
+
+// PostPosition identifies a trx inside the group chain, trxs are ordered by the block id and the index in the block,
+// which do not change when new blocks arrive, unlike the sender timestamps
+type PostPosition struct {
+	BlockId uint64
+	Index   int
+}
+
+// String returns "<blockid>_<index>"
+func (p *PostPosition) String() string {
+	return strconv.FormatUint(p.BlockId, 10) + "_" + strconv.Itoa(p.Index)
+}
+
+// ParsePostPosition parses the string returned by PostPosition.String
+func ParsePostPosition(str string) (*PostPosition, error) {
+	parts := strings.SplitN(str, "_", 2)
+	if len(parts) != 2 {
+		return nil, errors.New("invalid post position")
+	}
+	blockId, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid post position")
+	}
+	index, err := strconv.Atoi(parts[1])
+	if err != nil || index < 0 {
+		return nil, errors.New("invalid post position")
+	}
+	return &PostPosition{BlockId: blockId, Index: index}, nil
+}
+
+// GetPostsByRange returns at most `limit` posts with since <= TimeStamp <= until in chain order, since/until <= 0 means no bound.
+// It scans the trxs behind `after`, or from the first block produced at or after since, up to the block currBlockId,
+// the blocks produced after until are not scanned, neither more than MaxPostRangeScanBlocks blocks in one call.
+// It returns the position of the last scanned trx, nil if none is scanned, and whether the scan stopped before the end.
+// Deleted and expired posts are skipped.
+func (cs *Storage) GetPostsByRange(groupId string, since, until int64, after *PostPosition, currBlockId uint64, limit int, prefix ...string) ([]*quorumpb.PostItem, *PostPosition, bool, error) {
+	posts := []*quorumpb.PostItem{}
+
+	//the blocks between the genesis block and prunedBefore are pruned
+	pruned, err := cs.GetPrunedBefore(groupId, prefix...)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	var blockId uint64
+	index := 0
+	if after != nil {
+		blockId, index = after.BlockId, after.Index+1
+	} else if since > 0 {
+		if blockId, err = cs.searchBlockByTime(groupId, pruned, currBlockId, since, prefix...); err != nil {
+			return nil, nil, false, err
+		}
+	}
+
+	now := time.Now()
+	var last *PostPosition
+	for scanned := 0; blockId <= currBlockId; blockId, index = blockId+1, 0 {
+		if blockId > 0 && blockId < pruned {
+			blockId, index = pruned, 0
+			if blockId > currBlockId {
+				break
+			}
+		}
+		if scanned == MaxPostRangeScanBlocks {
+			return posts, last, true, nil
+		}
+		scanned++
+
+		block, err := cs.GetBlock(groupId, blockId, false, prefix...)
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("get block %d failed: %s", blockId, err)
+		}
+		if until > 0 && block.TimeStamp > until {
+			return posts, last, false, nil
+		}
+
+		for ; index < len(block.Trxs); index++ {
+			if limit > 0 && len(posts) == limit {
+				return posts, last, true, nil
+			}
+			last = &PostPosition{BlockId: blockId, Index: index}
+
+			trx := block.Trxs[index]
+			if trx.Type != quorumpb.TrxType_POST || (since > 0 && trx.TimeStamp < since) || (until > 0 && trx.TimeStamp > until) {
+				continue
+			}
+			item, err := cs.getPost(groupId, trx, now, prefix...)
+			if err != nil {
+				return nil, nil, false, err
+			}
+			if item != nil {
+				posts = append(posts, item)
+			}
+		}
+	}
+	return posts, last, false, nil
+}
+
+// getPost returns the post saved for the trx, nil if it is deleted, expired or not saved by this node
+func (cs *Storage) getPost(groupId string, trx *quorumpb.Trx, now time.Time, prefix ...string) (*quorumpb.PostItem, error) {
+	key := []byte(s.GetPostKey(groupId, fmt.Sprint(trx.TimeStamp), trx.TrxId, prefix...))
+	exist, err := cs.dbmgr.Db.IsExist(key)
+	if err != nil || !exist {
+		return nil, err
+	}
+	if expired, err := cs.IsPostExpired(groupId, trx.TrxId, now, prefix...); err != nil || expired {
+		return nil, err
+	}
+	v, err := cs.dbmgr.Db.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	item := &quorumpb.PostItem{}
+	if err := proto.Unmarshal(v, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// searchBlockByTime returns the first block in [first, last] produced at or after ts, last+1 if there is none,
+// block timestamps grow with the block id
+func (cs *Storage) searchBlockByTime(groupId string, first, last uint64, ts int64, prefix ...string) (uint64, error) {
+	lo, hi := first, last+1
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		block, err := cs.GetBlock(groupId, mid, false, prefix...)
+		if err != nil {
+			return 0, fmt.Errorf("get block %d failed: %s", mid, err)
+		}
+		if block.TimeStamp < ts {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo, nil
+}
@@ -0,0 +1,92 @@
+package chainstorage
+
+import (
+	"fmt"
+	"testing"
+
+	s "github.com/rumsystem/quorum/internal/pkg/storage"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+func newMemChainStorage(t *testing.T) *Storage {
+	dbMgr, err := s.CreateDbWithBackend(t.TempDir(), s.BACKEND_MEMORY)
+	if err != nil {
+		t.Fatalf("create db failed: %s", err)
+	}
+	t.Cleanup(func() {
+		dbMgr.GroupInfoDb.Close()
+		dbMgr.Db.Close()
+	})
+	return NewChainStorage(dbMgr)
+}
+
+func TestGetPostsByRange(t *testing.T) {
+	cs := newMemChainStorage(t)
+	groupId := "5ed3f9fe-81e2-450d-9146-7a329aac2b62"
+	base := int64(1663900000000000000)
+
+	addBlock := func(blockId uint64, trxs ...*quorumpb.Trx) {
+		for _, trx := range trxs {
+			if trx.Type == quorumpb.TrxType_POST {
+				if err := cs.AddPost(trx, "peer"); err != nil {
+					t.Fatalf("AddPost failed: %s", err)
+				}
+			}
+		}
+		block := &quorumpb.Block{GroupId: groupId, BlockId: blockId, TimeStamp: base + int64(blockId)*10, Trxs: trxs}
+		if err := cs.AddBlock(block, false, "peer"); err != nil {
+			t.Fatalf("AddBlock failed: %s", err)
+		}
+	}
+	post := func(i int, ts int64) *quorumpb.Trx {
+		return &quorumpb.Trx{GroupId: groupId, TrxId: fmt.Sprintf("trx-%d", i), Type: quorumpb.TrxType_POST, TimeStamp: ts}
+	}
+	addBlock(0)
+	addBlock(1, post(0, base+5), &quorumpb.Trx{GroupId: groupId, TrxId: "announce", Type: quorumpb.TrxType_ANNOUNCE, TimeStamp: base + 6})
+	addBlock(2, post(1, base+11), post(2, base+12))
+	addBlock(3, post(3, base+25))
+
+	posts, last, more, err := cs.GetPostsByRange(groupId, base+10, base+20, nil, 3, 0, "peer")
+	if err != nil {
+		t.Fatalf("GetPostsByRange failed: %s", err)
+	}
+	if len(posts) != 2 || posts[0].TrxId != "trx-1" || posts[1].TrxId != "trx-2" || more {
+		t.Fatalf("unexpected posts in range: %v more %v", posts, more)
+	}
+	if last == nil || *last != (PostPosition{BlockId: 2, Index: 1}) {
+		t.Errorf("unexpected last position %v", last)
+	}
+
+	// page after trx-0, a post arriving meanwhile with an older timestamp is not skipped
+	posts, last, more, err = cs.GetPostsByRange(groupId, 0, 0, nil, 3, 1, "peer")
+	if err != nil || len(posts) != 1 || posts[0].TrxId != "trx-0" || !more {
+		t.Fatalf("unexpected first page: %v more %v err %v", posts, more, err)
+	}
+	addBlock(4, post(4, base+1))
+	var ids []string
+	for more {
+		posts, last, more, err = cs.GetPostsByRange(groupId, 0, 0, last, 4, 2, "peer")
+		if err != nil {
+			t.Fatalf("GetPostsByRange failed: %s", err)
+		}
+		for _, p := range posts {
+			ids = append(ids, p.TrxId)
+		}
+	}
+	if fmt.Sprint(ids) != "[trx-1 trx-2 trx-3 trx-4]" {
+		t.Errorf("unexpected posts after trx-0: %v", ids)
+	}
+}
+
+func TestPostPosition(t *testing.T) {
+	pos := &PostPosition{BlockId: 12, Index: 3}
+	got, err := ParsePostPosition(pos.String())
+	if err != nil || *got != *pos {
+		t.Fatalf("unexpected position %v %v", got, err)
+	}
+	for _, bad := range []string{"", "12", "a_1", "12_-1"} {
+		if _, err := ParsePostPosition(bad); err == nil {
+			t.Errorf("ParsePostPosition(%q) should fail", bad)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package chainstorage
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	s "github.com/rumsystem/quorum/internal/pkg/storage"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+// GetPrunedBefore returns the first block not pruned, 0 if the group is never pruned
+func (cs *Storage) GetPrunedBefore(groupId string, prefix ...string) (uint64, error) {
+	key := []byte(s.GetChainInfoPrunedBefore(groupId, prefix...))
+	exist, err := cs.dbmgr.Db.IsExist(key)
+	if err != nil || !exist {
+		return 0, err
+	}
+	v, err := cs.dbmgr.Db.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	if len(v) != 8 {
+		return 0, fmt.Errorf("invalid pruned block of group %s", groupId)
+	}
+	return binary.LittleEndian.Uint64(v), nil
+}
+
+func (cs *Storage) setPrunedBefore(groupId string, before uint64, prefix ...string) error {
+	v := make([]byte, 8)
+	binary.LittleEndian.PutUint64(v, before)
+	return cs.dbmgr.Db.Set([]byte(s.GetChainInfoPrunedBefore(groupId, prefix...)), v)
+}
+
+// PruneBlocks removes the blocks before the block `before` with their trxs and posts, the genesis block is kept.
+// The group state (users, producers, configs) is not touched. The progress is saved after each block,
+// so an interrupted pruning continues from where it stopped. It returns the number of the removed blocks.
+func (cs *Storage) PruneBlocks(groupId string, before uint64, prefix ...string) (uint64, error) {
+	from, err := cs.GetPrunedBefore(groupId, prefix...)
+	if err != nil {
+		return 0, err
+	}
+	if from < 1 {
+		from = 1
+	}
+
+	var pruned uint64
+	for blockId := from; blockId < before; blockId++ {
+		exist, err := cs.IsBlockExist(groupId, blockId, false, prefix...)
+		if err != nil {
+			return pruned, err
+		}
+		if exist {
+			block, err := cs.GetBlock(groupId, blockId, false, prefix...)
+			if err != nil {
+				return pruned, err
+			}
+			if err := cs.removeBlockData(block, prefix...); err != nil {
+				return pruned, err
+			}
+			pruned++
+		}
+		if err := cs.setPrunedBefore(groupId, blockId+1, prefix...); err != nil {
+			return pruned, err
+		}
+	}
+	return pruned, nil
+}
+
+func (cs *Storage) removeBlockData(block *quorumpb.Block, prefix ...string) error {
+	for _, trx := range block.Trxs {
+		if err := cs.dbmgr.Db.Delete([]byte(s.GetTrxKey(block.GroupId, trx.TrxId, prefix...))); err != nil {
+			return err
+		}
+		if trx.Type == quorumpb.TrxType_POST {
+			key := s.GetPostKey(block.GroupId, fmt.Sprint(trx.TimeStamp), trx.TrxId, prefix...)
+			if err := cs.dbmgr.Db.Delete([]byte(key)); err != nil {
+				return err
+			}
+		}
+	}
+	return cs.dbmgr.Db.Delete([]byte(s.GetBlockKey(block.GroupId, block.BlockId, prefix...)))
+}
@@ -0,0 +1,57 @@
+package chainstorage
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/rumsystem/quorum/internal/pkg/storage/def"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+func TestPruneBlocks(t *testing.T) {
+	cs := newMemChainStorage(t)
+	groupId := "5ed3f9fe-81e2-450d-9146-7a329aac2b62"
+	for i := uint64(0); i < 5; i++ {
+		trx := &quorumpb.Trx{GroupId: groupId, TrxId: fmt.Sprintf("trx-%d", i), Type: quorumpb.TrxType_POST, TimeStamp: int64(100 + i)}
+		if err := cs.AddTrx(trx, "peer"); err != nil {
+			t.Fatal(err)
+		}
+		if err := cs.AddPost(trx, "peer"); err != nil {
+			t.Fatal(err)
+		}
+		block := &quorumpb.Block{GroupId: groupId, BlockId: i, Trxs: []*quorumpb.Trx{trx}}
+		if err := cs.AddBlock(block, false, "peer"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pruned, err := cs.PruneBlocks(groupId, 3, "peer")
+	if err != nil {
+		t.Fatalf("PruneBlocks failed: %s", err)
+	}
+	if pruned != 2 {
+		t.Errorf("expect blocks 1 and 2 pruned, got %d", pruned)
+	}
+	for i := uint64(0); i < 5; i++ {
+		exist, _ := cs.IsBlockExist(groupId, i, false, "peer")
+		trxExist, _ := cs.IsTrxExist(groupId, fmt.Sprintf("trx-%d", i), "peer")
+		kept := i == 0 || i >= 3
+		if exist != kept || trxExist != kept {
+			t.Errorf("block %d: expect kept %v, got block %v trx %v", i, kept, exist, trxExist)
+		}
+	}
+	posts, _, _, err := cs.GetPostsByRange(groupId, 0, 0, nil, 4, 0, "peer")
+	if err != nil || len(posts) != 3 {
+		t.Errorf("expect the posts of pruned blocks removed, got %d %v", len(posts), err)
+	}
+	if _, err := cs.GetTrx(groupId, "trx-4", def.Chain, "peer"); err != nil {
+		t.Errorf("expect trx of kept block readable, got %s", err)
+	}
+
+	if before, _ := cs.GetPrunedBefore(groupId, "peer"); before != 3 {
+		t.Errorf("expect pruned before 3, got %d", before)
+	}
+	if pruned, _ := cs.PruneBlocks(groupId, 3, "peer"); pruned != 0 {
+		t.Errorf("expect nothing pruned again, got %d", pruned)
+	}
+}
@@ -0,0 +1,41 @@
+package chainstorage
+
+import (
+	s "github.com/rumsystem/quorum/internal/pkg/storage"
+)
+
+// SetPublishEntry saves the encoded publish queue entry of a trx sent by this node
+func (cs *Storage) SetPublishEntry(groupId, trxId string, data []byte, prefix ...string) error {
+	return cs.dbmgr.Db.Set([]byte(s.GetPublishQueueKey(groupId, trxId, prefix...)), data)
+}
+
+// RmPublishEntry removes the publish queue entry of the trx, it is not an error if there is none
+func (cs *Storage) RmPublishEntry(groupId, trxId string, prefix ...string) error {
+	key := []byte(s.GetPublishQueueKey(groupId, trxId, prefix...))
+	exist, err := cs.dbmgr.Db.IsExist(key)
+	if err != nil || !exist {
+		return err
+	}
+	return cs.dbmgr.Db.Delete(key)
+}
+
+// GetPublishEntries returns the encoded publish queue entries of the group
+func (cs *Storage) GetPublishEntries(groupId string, prefix ...string) ([][]byte, error) {
+	var entries [][]byte
+	err := cs.dbmgr.Db.PrefixForeach([]byte(s.GetPublishQueuePrefix(groupId, prefix...)), func(k []byte, v []byte, err error) error {
+		if err != nil {
+			return err
+		}
+		entries = append(entries, append([]byte{}, v...))
+		return nil
+	})
+	return entries, err
+}
+
+// GetPublishEntryCount returns the number of the trxs in the publish queue of the group, it does not read the values
+func (cs *Storage) GetPublishEntryCount(groupId string, prefix ...string) (int, error) {
+	key := []byte(s.GetPublishQueuePrefix(groupId, prefix...))
+	return cs.dbmgr.Db.PrefixForeachKey(key, key, false, func(k []byte, err error) error {
+		return err
+	})
+}
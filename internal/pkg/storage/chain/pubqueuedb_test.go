@@ -0,0 +1,45 @@
+package chainstorage
+
+import (
+	"testing"
+)
+
+func TestPublishEntry(t *testing.T) {
+	cs := newMemChainStorage(t)
+	groupId := "5ed3f9fe-81e2-450d-9146-7a329aac2b62"
+	for _, trxId := range []string{"trx-1", "trx-2"} {
+		if err := cs.SetPublishEntry(groupId, trxId, []byte(trxId), "peer"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cs.SetPublishEntry("other", "trx-3", []byte("trx-3"), "peer"); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := cs.GetPublishEntryCount(groupId, "peer"); err != nil || n != 2 {
+		t.Fatalf("expect 2 entries, got %d %v", n, err)
+	}
+	if err := cs.RmPublishEntry(groupId, "trx-1", "peer"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cs.RmPublishEntry(groupId, "unknown", "peer"); err != nil {
+		t.Errorf("removing an unknown entry should not fail: %s", err)
+	}
+	entries, err := cs.GetPublishEntries(groupId, "peer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || string(entries[0]) != "trx-2" {
+		t.Errorf("unexpected entries: %q", entries)
+	}
+
+	if err := cs.RemoveGroupData(groupId, "peer"); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := cs.GetPublishEntryCount(groupId, "peer"); n != 0 {
+		t.Errorf("entries should be removed with the group data, got %d", n)
+	}
+	if n, _ := cs.GetPublishEntryCount("other", "peer"); n != 1 {
+		t.Errorf("entries of other group should be kept, got %d", n)
+	}
+}
@@ -0,0 +1,65 @@
+package chainstorage
+
+import (
+	"google.golang.org/protobuf/proto"
+)
+
+// GroupDataStats is the chain data of a group, the trxs and the bytes are estimated from the latest blocks
+type GroupDataStats struct {
+	Blocks  uint64 // blocks held, the genesis block included and the pruned ones excluded
+	Trxs    uint64
+	Bytes   int64 // the blocks and the trxs saved with them, without the db overhead
+	Sampled int   // blocks read for the estimate
+}
+
+// GetGroupDataStats estimates the chain data of a group from its latest blocks up to currBlockId, at most sample
+// blocks are read, so that the cost does not grow with the chain. The estimate is exact if all blocks are sampled.
+func (cs *Storage) GetGroupDataStats(groupId string, currBlockId uint64, sample int, prefix ...string) (*GroupDataStats, error) {
+	from, err := cs.GetPrunedBefore(groupId, prefix...)
+	if err != nil {
+		return nil, err
+	}
+	if from < 1 {
+		from = 1
+	}
+	res := &GroupDataStats{Blocks: 1}
+	if currBlockId >= from {
+		res.Blocks += currBlockId - from + 1
+	}
+
+	ids := make([]uint64, 0, sample)
+	for blockId := currBlockId; blockId >= from && len(ids) < sample; blockId-- {
+		ids = append(ids, blockId)
+	}
+	if len(ids) < sample {
+		ids = append(ids, 0)
+	}
+
+	var trxs uint64
+	var bytes int64
+	for _, blockId := range ids {
+		exist, err := cs.IsBlockExist(groupId, blockId, false, prefix...)
+		if err != nil {
+			return nil, err
+		}
+		if !exist {
+			continue
+		}
+		block, err := cs.GetBlock(groupId, blockId, false, prefix...)
+		if err != nil {
+			return nil, err
+		}
+		trxs += uint64(len(block.Trxs))
+		bytes += int64(proto.Size(block))
+		for _, trx := range block.Trxs {
+			bytes += int64(proto.Size(trx))
+		}
+		res.Sampled++
+	}
+	if res.Sampled == 0 {
+		return res, nil
+	}
+	res.Trxs = trxs * res.Blocks / uint64(res.Sampled)
+	res.Bytes = bytes * int64(res.Blocks) / int64(res.Sampled)
+	return res, nil
+}
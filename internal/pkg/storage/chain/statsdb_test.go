@@ -0,0 +1,54 @@
+package chainstorage
+
+import (
+	"fmt"
+	"testing"
+
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+func TestGetGroupDataStats(t *testing.T) {
+	cs := newMemChainStorage(t)
+	groupId := "5ed3f9fe-81e2-450d-9146-7a329aac2b62"
+	for i := uint64(0); i < 10; i++ {
+		var trxs []*quorumpb.Trx
+		if i > 0 {
+			trxs = []*quorumpb.Trx{
+				{GroupId: groupId, TrxId: fmt.Sprintf("trx-%d-a", i)},
+				{GroupId: groupId, TrxId: fmt.Sprintf("trx-%d-b", i)},
+			}
+		}
+		block := &quorumpb.Block{GroupId: groupId, BlockId: i, Trxs: trxs}
+		if err := cs.AddBlock(block, false, "peer"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	all, err := cs.GetGroupDataStats(groupId, 9, 100, "peer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if all.Blocks != 10 || all.Trxs != 18 || all.Sampled != 10 || all.Bytes <= 0 {
+		t.Errorf("want 10 blocks and 18 trxs counted exactly, got %+v", all)
+	}
+
+	// the latest 4 blocks have 2 trxs each
+	sampled, err := cs.GetGroupDataStats(groupId, 9, 4, "peer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sampled.Blocks != 10 || sampled.Trxs != 20 || sampled.Sampled != 4 {
+		t.Errorf("want 20 trxs estimated from 4 blocks, got %+v", sampled)
+	}
+
+	if _, err := cs.PruneBlocks(groupId, 6, "peer"); err != nil {
+		t.Fatal(err)
+	}
+	pruned, err := cs.GetGroupDataStats(groupId, 9, 100, "peer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pruned.Blocks != 5 || pruned.Trxs != 8 || pruned.Sampled != 5 {
+		t.Errorf("want the genesis and 4 blocks left, got %+v", pruned)
+	}
+}
@@ -0,0 +1,72 @@
+package chainstorage
+
+import (
+	"fmt"
+
+	s "github.com/rumsystem/quorum/internal/pkg/storage"
+	"github.com/rumsystem/quorum/internal/pkg/storage/def"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+// AddTombstone marks the post deleted by the tombstone trx and removes the post content,
+// the trx of the post is kept in the chain
+func (cs *Storage) AddTombstone(groupId, trxId, tombstoneTrxId string, prefix ...string) error {
+	exist, err := cs.IsTrxExist(groupId, trxId, prefix...)
+	if err != nil {
+		return err
+	}
+	if exist {
+		trx, err := cs.GetTrx(groupId, trxId, def.Chain, prefix...)
+		if err != nil {
+			return err
+		}
+		postKey := []byte(s.GetPostKey(groupId, fmt.Sprint(trx.TimeStamp), trxId, prefix...))
+		if err := cs.dbmgr.Db.Delete(postKey); err != nil {
+			return err
+		}
+	}
+
+	return cs.dbmgr.Db.Set([]byte(s.GetTombstoneKey(groupId, trxId, prefix...)), []byte(tombstoneTrxId))
+}
+
+// GetTombstone returns the id of the tombstone trx which deletes the post, empty if the post is not deleted
+func (cs *Storage) GetTombstone(groupId, trxId string, prefix ...string) (string, error) {
+	key := []byte(s.GetTombstoneKey(groupId, trxId, prefix...))
+	exist, err := cs.dbmgr.Db.IsExist(key)
+	if err != nil || !exist {
+		return "", err
+	}
+	v, err := cs.dbmgr.Db.Get(key)
+	return string(v), err
+}
+
+// AddPostAuthor saves the sender of the post, for the nodes which do not save posts
+func (cs *Storage) AddPostAuthor(groupId, trxId, senderPubkey string, prefix ...string) error {
+	return cs.dbmgr.Db.Set([]byte(s.GetPostAuthorKey(groupId, trxId, prefix...)), []byte(senderPubkey))
+}
+
+// GetPostAuthor returns the sender of the post, empty if the post is unknown
+func (cs *Storage) GetPostAuthor(groupId, trxId string, prefix ...string) (string, error) {
+	key := []byte(s.GetPostAuthorKey(groupId, trxId, prefix...))
+	exist, err := cs.dbmgr.Db.IsExist(key)
+	if err != nil {
+		return "", err
+	}
+	if exist {
+		v, err := cs.dbmgr.Db.Get(key)
+		return string(v), err
+	}
+
+	exist, err = cs.IsTrxExist(groupId, trxId, prefix...)
+	if err != nil || !exist {
+		return "", err
+	}
+	trx, err := cs.GetTrx(groupId, trxId, def.Chain, prefix...)
+	if err != nil {
+		return "", err
+	}
+	if trx.Type != quorumpb.TrxType_POST {
+		return "", nil
+	}
+	return trx.SenderPubkey, nil
+}
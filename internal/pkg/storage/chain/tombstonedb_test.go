@@ -0,0 +1,58 @@
+package chainstorage
+
+import (
+	"testing"
+
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+func TestTombstone(t *testing.T) {
+	cs := newMemChainStorage(t)
+	groupId := "5ed3f9fe-81e2-450d-9146-7a329aac2b62"
+	block := &quorumpb.Block{GroupId: groupId, Trxs: []*quorumpb.Trx{
+		{GroupId: groupId, TrxId: "post-1", Type: quorumpb.TrxType_POST, SenderPubkey: "alice", TimeStamp: 100},
+		{GroupId: groupId, TrxId: "post-2", Type: quorumpb.TrxType_POST, SenderPubkey: "bob", TimeStamp: 200},
+		{GroupId: groupId, TrxId: "announce", Type: quorumpb.TrxType_ANNOUNCE, SenderPubkey: "bob", TimeStamp: 300},
+	}}
+	if err := cs.AddBlock(block, false, "peer"); err != nil {
+		t.Fatal(err)
+	}
+	for _, trx := range block.Trxs {
+		if err := cs.AddTrx(trx, "peer"); err != nil {
+			t.Fatal(err)
+		}
+		if trx.Type == quorumpb.TrxType_POST {
+			if err := cs.AddPost(trx, "peer"); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := cs.AddPostAuthor(groupId, "post-3", "carol", "peer"); err != nil {
+		t.Fatal(err)
+	}
+
+	for trxId, expect := range map[string]string{"post-1": "alice", "post-3": "carol", "announce": "", "unknown": ""} {
+		author, err := cs.GetPostAuthor(groupId, trxId, "peer")
+		if err != nil || author != expect {
+			t.Errorf("%s: expect author %q, got %q %v", trxId, expect, author, err)
+		}
+	}
+
+	if err := cs.AddTombstone(groupId, "post-1", "tombstone-1", "peer"); err != nil {
+		t.Fatalf("AddTombstone failed: %s", err)
+	}
+	if tombstone, err := cs.GetTombstone(groupId, "post-1", "peer"); err != nil || tombstone != "tombstone-1" {
+		t.Errorf("expect post-1 deleted by tombstone-1, got %q %v", tombstone, err)
+	}
+	if tombstone, _ := cs.GetTombstone(groupId, "post-2", "peer"); tombstone != "" {
+		t.Errorf("expect post-2 not deleted, got %q", tombstone)
+	}
+
+	posts, _, _, err := cs.GetPostsByRange(groupId, 0, 0, nil, 0, 0, "peer")
+	if err != nil || len(posts) != 1 || posts[0].TrxId != "post-2" {
+		t.Errorf("expect only post-2 listed, got %v %v", posts, err)
+	}
+	if exist, _ := cs.IsTrxExist(groupId, "post-1", "peer"); !exist {
+		t.Errorf("expect the trx of the deleted post kept")
+	}
+}
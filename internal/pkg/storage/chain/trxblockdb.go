@@ -0,0 +1,38 @@
+package chainstorage
+
+import (
+	"encoding/binary"
+
+	s "github.com/rumsystem/quorum/internal/pkg/storage"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+// AddTrxBlock indexes the trxs of the block by trx id, so that the block including a trx can be found
+func (cs *Storage) AddTrxBlock(block *quorumpb.Block, prefix ...string) error {
+	if len(block.Trxs) == 0 {
+		return nil
+	}
+	keys := make([][]byte, 0, len(block.Trxs))
+	values := make([][]byte, 0, len(block.Trxs))
+	for _, trx := range block.Trxs {
+		v := make([]byte, 8)
+		binary.LittleEndian.PutUint64(v, block.BlockId)
+		keys = append(keys, []byte(s.GetTrxBlockKey(block.GroupId, trx.TrxId, prefix...)))
+		values = append(values, v)
+	}
+	return cs.dbmgr.Db.BatchWrite(keys, values)
+}
+
+// GetTrxBlock returns the id of the block including the trx, false if the trx is not indexed
+func (cs *Storage) GetTrxBlock(groupId string, trxId string, prefix ...string) (uint64, bool, error) {
+	key := []byte(s.GetTrxBlockKey(groupId, trxId, prefix...))
+	exist, err := cs.dbmgr.Db.IsExist(key)
+	if err != nil || !exist {
+		return 0, false, err
+	}
+	v, err := cs.dbmgr.Db.Get(key)
+	if err != nil {
+		return 0, false, err
+	}
+	return binary.LittleEndian.Uint64(v), true, nil
+}
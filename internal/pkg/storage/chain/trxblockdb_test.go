@@ -0,0 +1,43 @@
+package chainstorage
+
+import (
+	"testing"
+
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+func TestTrxBlock(t *testing.T) {
+	cs := newMemChainStorage(t)
+	groupId := "5ed3f9fe-81e2-450d-9146-7a329aac2b62"
+	blocks := []*quorumpb.Block{
+		{GroupId: groupId, BlockId: 7, Trxs: []*quorumpb.Trx{{TrxId: "trx-1"}, {TrxId: "trx-2"}}},
+		{GroupId: groupId, BlockId: 8},
+		{GroupId: groupId, BlockId: 9, Trxs: []*quorumpb.Trx{{TrxId: "trx-3"}}},
+		{GroupId: "other", BlockId: 3, Trxs: []*quorumpb.Trx{{TrxId: "trx-4"}}},
+	}
+	for _, block := range blocks {
+		if err := cs.AddTrxBlock(block, "peer"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for trxId, want := range map[string]uint64{"trx-1": 7, "trx-2": 7, "trx-3": 9} {
+		blockId, ok, err := cs.GetTrxBlock(groupId, trxId, "peer")
+		if err != nil || !ok || blockId != want {
+			t.Errorf("block of %s: got %d %v %v, want %d", trxId, blockId, ok, err, want)
+		}
+	}
+	if _, ok, err := cs.GetTrxBlock(groupId, "trx-4", "peer"); err != nil || ok {
+		t.Errorf("trx of other group should not be found in the group: %v %v", ok, err)
+	}
+
+	if err := cs.RemoveGroupData(groupId, "peer"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := cs.GetTrxBlock(groupId, "trx-1", "peer"); ok {
+		t.Errorf("index should be removed with the group data")
+	}
+	if _, ok, _ := cs.GetTrxBlock("other", "trx-4", "peer"); !ok {
+		t.Errorf("index of other group should be kept")
+	}
+}
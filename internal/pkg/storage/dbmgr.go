@@ -4,6 +4,7 @@ import (
 	"errors"
 	"strconv"
 	"sync"
+	"sync/atomic"
 
 	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
 	"github.com/rumsystem/quorum/internal/pkg/logging"
@@ -19,19 +20,22 @@ type DbMgr struct {
 	Auth        QuorumStorage
 	seq         sync.Map
 	DataPath    string
+	closed      atomic.Bool
+}
+
+// IsOpen reports whether CloseDb has not been called, it does not touch the db
+func (dbMgr *DbMgr) IsOpen() bool {
+	return !dbMgr.closed.Load()
 }
 
 func (dbMgr *DbMgr) CloseDb() {
+	dbMgr.closed.Store(true)
 	dbMgr.GroupInfoDb.Close()
 	dbMgr.Db.Close()
 	//dbMgr.Auth.Close()
 	dbmgr_log.Infof("ChainCtx Db closed")
 }
 
-func (dbMgr *DbMgr) TryMigration(nodeDataVer int) {
-	//no need run migration for the first version
-}
-
 // get block
 func (dbMgr *DbMgr) GetBlock(groupId string, blockId uint64, cached bool, prefix ...string) (*quorumpb.Block, error) {
 	var key string
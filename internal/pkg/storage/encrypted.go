@@ -0,0 +1,404 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	ENC_STATE_MIGRATING = "MIGRATING" // plaintext values after the migration cursor are being encrypted
+	ENC_STATE_DONE      = "DONE"
+
+	encSaltSize = 16
+)
+
+var (
+	// encMetaKey sorts before all other keys and is hidden by EncryptedStore
+	encMetaKey = []byte("\x00enc_meta")
+	encCheck   = []byte("quorum")
+
+	// encMigrateBatchSize is the number of keys encrypted in one write during the migration
+	encMigrateBatchSize = 1000
+	// encScryptN is the scrypt cost of the key derivation
+	encScryptN = 1 << 15
+
+	ErrDbNotEncrypted = errors.New("db is not encrypted, start the node with --db-encrypt-migrate to encrypt it")
+	ErrDbEncrypted    = errors.New("db is encrypted, enable EnableDbEncrypt in the node options")
+)
+
+var (
+	encMu         sync.RWMutex
+	encPassphrase []byte
+	encMigrate    bool
+)
+
+// EnableEncryption makes the stores opened by NewBackendStore encrypt their values with a key derived from passphrase,
+// existing plaintext dbs are only encrypted if migrate is true
+func EnableEncryption(passphrase []byte, migrate bool) error {
+	if len(passphrase) == 0 {
+		return errors.New("db encryption passphrase is empty")
+	}
+	encMu.Lock()
+	defer encMu.Unlock()
+	encPassphrase = append([]byte{}, passphrase...)
+	encMigrate = migrate
+	return nil
+}
+
+// DisableEncryption makes the stores opened afterwards plaintext
+func DisableEncryption() {
+	encMu.Lock()
+	defer encMu.Unlock()
+	encPassphrase = nil
+	encMigrate = false
+}
+
+// DeriveEncryptionKey derives the key of a db from the passphrase and the salt of the db with scrypt
+func DeriveEncryptionKey(passphrase []byte, salt []byte) ([]byte, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("db encryption passphrase is empty")
+	}
+	return scrypt.Key(passphrase, salt, encScryptN, 8, 1, 32)
+}
+
+// encMeta is kept in plaintext, it marks the db as encrypted
+type encMeta struct {
+	Salt     []byte `json:"salt"`
+	Check    []byte `json:"check"` // encCheck sealed with the key, to detect a wrong key early
+	State    string `json:"state"`
+	Migrated []byte `json:"migrated,omitempty"` // last key encrypted by the migration
+}
+
+// EncryptedStore encrypts the values of a store with AES-GCM, the value is bound to its key.
+// Keys are kept in plaintext so that prefix iteration and ordering still work.
+type EncryptedStore struct {
+	QuorumStorage
+	aead cipher.AEAD
+	salt []byte
+
+	mu        sync.RWMutex
+	migrating bool   // values of the keys after migrated are plaintext
+	migrated  []byte // nil if no key is encrypted yet
+}
+
+// wrapEncryption returns db as is if encryption is not enabled
+func wrapEncryption(name string, db QuorumStorage) (QuorumStorage, error) {
+	encMu.RLock()
+	passphrase, migrate := encPassphrase, encMigrate
+	encMu.RUnlock()
+
+	value, err := db.Get(encMetaKey)
+	if err != nil {
+		return nil, err
+	}
+	if passphrase == nil {
+		if value != nil {
+			return nil, fmt.Errorf("%s: %w", name, ErrDbEncrypted)
+		}
+		return db, nil
+	}
+
+	var meta *encMeta
+	salt := make([]byte, encSaltSize)
+	if value != nil {
+		meta = &encMeta{}
+		if err := json.Unmarshal(value, meta); err != nil {
+			return nil, fmt.Errorf("%s: invalid encryption meta: %w", name, err)
+		}
+		salt = meta.Salt
+	} else if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	key, err := DeriveEncryptionKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	s, err := NewEncryptedStore(db, key, salt)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.open(meta, migrate); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return s, nil
+}
+
+// NewEncryptedStore wraps db with a 32 bytes key, salt is the salt the key is derived with
+func NewEncryptedStore(db QuorumStorage, key []byte, salt []byte) (*EncryptedStore, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("invalid db encryption key size %d, should be 32", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptedStore{QuorumStorage: db, aead: aead, salt: salt}, nil
+}
+
+// open checks the key against the meta of the db, a plaintext db is encrypted if migrate is true,
+// an interrupted migration is resumed
+func (s *EncryptedStore) open(meta *encMeta, migrate bool) error {
+	if meta == nil {
+		empty, err := s.isEmpty()
+		if err != nil {
+			return err
+		}
+		if empty {
+			return s.setMeta(ENC_STATE_DONE, nil)
+		}
+		if !migrate {
+			return ErrDbNotEncrypted
+		}
+		return s.Migrate()
+	}
+
+	if check, err := s.decrypt(encMetaKey, meta.Check); err != nil || !bytes.Equal(check, encCheck) {
+		return errors.New("wrong db encryption key")
+	}
+	if meta.State == ENC_STATE_MIGRATING {
+		s.mu.Lock()
+		s.migrating = true
+		s.migrated = meta.Migrated
+		s.mu.Unlock()
+		if !migrate {
+			return errors.New("db encryption was interrupted, start the node with --db-encrypt-migrate to resume it")
+		}
+		return s.Migrate()
+	}
+	return nil
+}
+
+func (s *EncryptedStore) isEmpty() (bool, error) {
+	empty := true
+	errStop := errors.New("stop")
+	_, err := s.QuorumStorage.PrefixForeachKey(nil, nil, false, func(k []byte, err error) error {
+		empty = false
+		return errStop
+	})
+	if err != nil && err != errStop {
+		return false, err
+	}
+	return empty, nil
+}
+
+func (s *EncryptedStore) metaValue(state string, migrated []byte) ([]byte, error) {
+	check, err := s.encrypt(encMetaKey, encCheck)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&encMeta{Salt: s.salt, Check: check, State: state, Migrated: migrated})
+}
+
+func (s *EncryptedStore) setMeta(state string, migrated []byte) error {
+	value, err := s.metaValue(state, migrated)
+	if err != nil {
+		return err
+	}
+	return s.QuorumStorage.Set(encMetaKey, value)
+}
+
+// isPlain reports whether the value of key is not encrypted yet by the migration
+func (s *EncryptedStore) isPlain(key []byte) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.migrating && (s.migrated == nil || bytes.Compare(key, s.migrated) > 0)
+}
+
+// Migrate encrypts the plaintext values in place in key order, batch by batch. The migration cursor is
+// written with each batch, so that it can be resumed after an interruption.
+func (s *EncryptedStore) Migrate() error {
+	s.mu.Lock()
+	s.migrating = true
+	migrated := s.migrated
+	s.mu.Unlock()
+	if migrated == nil {
+		if err := s.setMeta(ENC_STATE_MIGRATING, nil); err != nil {
+			return err
+		}
+	}
+
+	errStop := errors.New("stop")
+	encrypted := 0
+	for {
+		var seek []byte
+		if migrated != nil {
+			seek = append(append([]byte{}, migrated...), 0)
+		}
+		keys := [][]byte{}
+		_, err := s.QuorumStorage.PrefixForeachKey(seek, nil, false, func(k []byte, err error) error {
+			if err != nil {
+				return err
+			}
+			if bytes.Equal(k, encMetaKey) {
+				return nil
+			}
+			keys = append(keys, append([]byte{}, k...))
+			if len(keys) >= encMigrateBatchSize {
+				return errStop
+			}
+			return nil
+		})
+		if err != nil && err != errStop {
+			return err
+		}
+		if len(keys) == 0 {
+			break
+		}
+
+		batchKeys, batchVals := [][]byte{}, [][]byte{}
+		for _, k := range keys {
+			v, err := s.QuorumStorage.Get(k)
+			if err != nil {
+				return err
+			}
+			if v == nil {
+				continue
+			}
+			ev, err := s.encrypt(k, v)
+			if err != nil {
+				return err
+			}
+			batchKeys = append(batchKeys, k)
+			batchVals = append(batchVals, ev)
+		}
+		migrated = keys[len(keys)-1]
+		// the values and the cursor are written together
+		meta, err := s.metaValue(ENC_STATE_MIGRATING, migrated)
+		if err != nil {
+			return err
+		}
+		batchKeys = append(batchKeys, encMetaKey)
+		batchVals = append(batchVals, meta)
+		if err := s.QuorumStorage.BatchWrite(batchKeys, batchVals); err != nil {
+			return err
+		}
+		s.mu.Lock()
+		s.migrated = migrated
+		s.mu.Unlock()
+		encrypted += len(batchKeys) - 1
+		dbmgr_log.Infof("db encryption: %d keys encrypted", encrypted)
+	}
+
+	if err := s.setMeta(ENC_STATE_DONE, nil); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.migrating = false
+	s.migrated = nil
+	s.mu.Unlock()
+	return nil
+}
+
+// encrypt returns the nonce followed by the sealed value
+func (s *EncryptedStore) encrypt(key []byte, value []byte) ([]byte, error) {
+	nonce := make([]byte, s.aead.NonceSize(), s.aead.NonceSize()+len(value)+s.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return s.aead.Seal(nonce, nonce, value, key), nil
+}
+
+func (s *EncryptedStore) decrypt(key []byte, value []byte) ([]byte, error) {
+	if value == nil || s.isPlain(key) {
+		return value, nil
+	}
+	if len(value) < s.aead.NonceSize() {
+		return nil, fmt.Errorf("value of %q is too short", key)
+	}
+	nonce, sealed := value[:s.aead.NonceSize()], value[s.aead.NonceSize():]
+	return s.aead.Open(nil, nonce, sealed, key)
+}
+
+// Unwrap returns the underlying store
+func (s *EncryptedStore) Unwrap() QuorumStorage {
+	return s.QuorumStorage
+}
+
+func (s *EncryptedStore) Set(key []byte, val []byte) error {
+	if len(key) == 0 {
+		return s.QuorumStorage.Set(key, val)
+	}
+	ev, err := s.encrypt(key, val)
+	if err != nil {
+		return err
+	}
+	return s.QuorumStorage.Set(key, ev)
+}
+
+func (s *EncryptedStore) Get(key []byte) ([]byte, error) {
+	v, err := s.QuorumStorage.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return s.decrypt(key, v)
+}
+
+func (s *EncryptedStore) BatchWrite(keys [][]byte, values [][]byte) error {
+	if len(keys) != len(values) {
+		return errors.New("keys' and values' length should be equal")
+	}
+	evs := make([][]byte, len(values))
+	for i, v := range values {
+		ev, err := s.encrypt(keys[i], v)
+		if err != nil {
+			return err
+		}
+		evs[i] = ev
+	}
+	return s.QuorumStorage.BatchWrite(keys, evs)
+}
+
+func (s *EncryptedStore) PrefixCondDelete(prefix []byte, fn func(k []byte, v []byte, err error) (bool, error)) (int, error) {
+	return s.QuorumStorage.PrefixCondDelete(prefix, func(k []byte, v []byte, err error) (bool, error) {
+		if bytes.Equal(k, encMetaKey) {
+			return false, nil
+		}
+		if err == nil {
+			v, err = s.decrypt(k, v)
+		}
+		return fn(k, v, err)
+	})
+}
+
+func (s *EncryptedStore) PrefixDelete(prefix []byte) (int, error) {
+	return s.QuorumStorage.PrefixCondDelete(prefix, func(k []byte, v []byte, err error) (bool, error) {
+		return !bytes.Equal(k, encMetaKey), nil
+	})
+}
+
+func (s *EncryptedStore) PrefixForeachKey(prefix []byte, valid []byte, reverse bool, fn func([]byte, error) error) (int, error) {
+	return s.QuorumStorage.PrefixForeachKey(prefix, valid, reverse, func(k []byte, err error) error {
+		if bytes.Equal(k, encMetaKey) {
+			return nil
+		}
+		return fn(k, err)
+	})
+}
+
+func (s *EncryptedStore) PrefixForeach(prefix []byte, fn func([]byte, []byte, error) error) error {
+	return s.QuorumStorage.PrefixForeach(prefix, func(k []byte, v []byte, err error) error {
+		if bytes.Equal(k, encMetaKey) {
+			return nil
+		}
+		if err == nil {
+			v, err = s.decrypt(k, v)
+		}
+		return fn(k, v, err)
+	})
+}
+
+func (s *EncryptedStore) Foreach(fn func([]byte, []byte, error) error) error {
+	return s.PrefixForeach(nil, fn)
+}
@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func openEncrypted(t *testing.T, db QuorumStorage, passphrase string, migrate bool) (QuorumStorage, error) {
+	t.Helper()
+	if passphrase == "" {
+		DisableEncryption()
+	} else if err := EnableEncryption([]byte(passphrase), migrate); err != nil {
+		t.Fatal(err)
+	}
+	defer DisableEncryption()
+	return wrapEncryption("test", db)
+}
+
+func TestEncryptedStore(t *testing.T) {
+	raw := NewMemStore()
+	db, err := openEncrypted(t, raw, "secret", false)
+	if err != nil {
+		t.Fatalf("open empty db failed: %s", err)
+	}
+
+	if err := db.Set([]byte("k_1"), []byte("v1")); err != nil {
+		t.Fatalf("Set failed: %s", err)
+	}
+	if err := db.BatchWrite([][]byte{[]byte("k_2")}, [][]byte{[]byte("v2")}); err != nil {
+		t.Fatalf("BatchWrite failed: %s", err)
+	}
+	if v, err := db.Get([]byte("k_1")); err != nil || string(v) != "v1" {
+		t.Errorf("Get got %q, %v", v, err)
+	}
+	if v, _ := raw.Get([]byte("k_1")); bytes.Contains(v, []byte("v1")) {
+		t.Errorf("value is stored in plaintext: %q", v)
+	}
+
+	vals := []string{}
+	db.Foreach(func(k []byte, v []byte, err error) error {
+		vals = append(vals, string(v))
+		return err
+	})
+	if len(vals) != 2 || vals[0] != "v1" || vals[1] != "v2" {
+		t.Errorf("Foreach got %v", vals)
+	}
+
+	// value is bound to its key
+	v, _ := raw.Get([]byte("k_1"))
+	raw.Set([]byte("k_2"), v)
+	if _, err := db.Get([]byte("k_2")); err == nil {
+		t.Errorf("expect error for a value moved to another key")
+	}
+
+	// reopened with the salt kept in the db
+	db, err = openEncrypted(t, raw, "secret", false)
+	if err != nil {
+		t.Fatalf("reopen db failed: %s", err)
+	}
+	if v, err := db.Get([]byte("k_1")); err != nil || string(v) != "v1" {
+		t.Errorf("Get after reopen got %q, %v", v, err)
+	}
+
+	if _, err := openEncrypted(t, raw, "", false); !errors.Is(err, ErrDbEncrypted) {
+		t.Errorf("expect ErrDbEncrypted without passphrase, got %v", err)
+	}
+	if _, err := openEncrypted(t, raw, "wrong", false); err == nil {
+		t.Errorf("expect error with wrong passphrase")
+	}
+}
+
+func TestEncryptionRejectEmptyKey(t *testing.T) {
+	if err := EnableEncryption(nil, false); err == nil {
+		t.Errorf("expect error for empty passphrase")
+	}
+	if _, err := DeriveEncryptionKey([]byte{}, []byte("salt")); err == nil {
+		t.Errorf("expect error for empty passphrase")
+	}
+	if _, err := NewEncryptedStore(NewMemStore(), nil, nil); err == nil {
+		t.Errorf("expect error for empty key")
+	}
+}
+
+func TestEncryptedStoreMigrate(t *testing.T) {
+	defer func(n int) { encMigrateBatchSize = n }(encMigrateBatchSize)
+	encMigrateBatchSize = 10
+
+	raw := NewMemStore()
+	want := map[string]string{}
+	for i := 0; i < 25; i++ {
+		k, v := fmt.Sprintf("k_%02d", i), fmt.Sprintf("v%d", i)
+		raw.Set([]byte(k), []byte(v))
+		want[k] = v
+	}
+
+	if _, err := openEncrypted(t, raw, "secret", false); !errors.Is(err, ErrDbNotEncrypted) {
+		t.Fatalf("expect ErrDbNotEncrypted, got %v", err)
+	}
+
+	// interrupted after the first key, the cursor tells the encrypted values from the plaintext ones
+	salt := []byte("0123456789abcdef")
+	key, _ := DeriveEncryptionKey([]byte("secret"), salt)
+	s, err := NewEncryptedStore(raw, key, salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev, _ := s.encrypt([]byte("k_00"), []byte("v0"))
+	raw.Set([]byte("k_00"), ev)
+	s.setMeta(ENC_STATE_MIGRATING, []byte("k_00"))
+	if _, err := openEncrypted(t, raw, "secret", false); err == nil {
+		t.Fatalf("expect error for interrupted migration without migrate")
+	}
+
+	db, err := openEncrypted(t, raw, "secret", true)
+	if err != nil {
+		t.Fatalf("resume migration failed: %s", err)
+	}
+	for k, v := range want {
+		if got, err := db.Get([]byte(k)); err != nil || string(got) != v {
+			t.Errorf("Get %s got %q, %v", k, got, err)
+		}
+		if got, _ := raw.Get([]byte(k)); bytes.Equal(got, []byte(v)) {
+			t.Errorf("value of %s is not encrypted", k)
+		}
+	}
+	n, _ := db.PrefixDelete(nil)
+	if n != len(want) {
+		t.Errorf("PrefixDelete should keep the encryption meta, deleted %d", n)
+	}
+}
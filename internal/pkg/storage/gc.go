@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const DefaultGCFreeRatio = 0.5
+
+// Compactor is implemented by backends which can give the space of deleted data back to the filesystem
+type Compactor interface {
+	Compact() (int64, error)
+}
+
+// GCResult is the outcome of the gc of one db
+type GCResult struct {
+	Db        string `json:"db" example:"db"`
+	Size      int64  `json:"size" example:"536870912"` // bytes on disk before gc
+	Free      int64  `json:"free" example:"268435456"` // bytes of free pages before gc
+	Reclaimed int64  `json:"reclaimed" example:"268435456"`
+	Skipped   bool   `json:"skipped" example:"false"` // free pages under the free ratio, or the backend can not compact
+}
+
+// gcMu makes the scheduled gc and the api calls run one at a time
+var gcMu sync.Mutex
+
+// RunStoreGC compacts db if at least minFreeRatio of its size is free pages
+func RunStoreGC(name string, db QuorumStorage, minFreeRatio float64) (*GCResult, error) {
+	if minFreeRatio < 0 || minFreeRatio >= 1 {
+		return nil, fmt.Errorf("invalid free ratio %v, should be in [0, 1)", minFreeRatio)
+	}
+
+	res := &GCResult{Db: name, Skipped: true}
+	compactor, ok := unwrapStore(db).(Compactor)
+	if !ok {
+		return res, nil
+	}
+	stats, err := GetStoreStats(db)
+	if err != nil {
+		return nil, err
+	}
+	res.Size = stats.Size
+	res.Free = stats.Free
+	if stats.Size == 0 || float64(stats.Free) < float64(stats.Size)*minFreeRatio {
+		return res, nil
+	}
+
+	gcMu.Lock()
+	defer gcMu.Unlock()
+	res.Reclaimed, err = compactor.Compact()
+	if err != nil {
+		return nil, err
+	}
+	res.Skipped = false
+	return res, nil
+}
+
+// CompactDbs compacts the group and data dbs. bolt keeps the pages of deleted data in the file for reuse,
+// only a compaction shrinks the file, it is done when at least minFreeRatio of the file is free.
+// It is safe to call while the node is serving, the db is paused during its compaction.
+func (dbMgr *DbMgr) CompactDbs(minFreeRatio float64) ([]*GCResult, error) {
+	results := []*GCResult{}
+	for _, item := range []struct {
+		name string
+		db   QuorumStorage
+	}{
+		{"groups", dbMgr.GroupInfoDb},
+		{"db", dbMgr.Db},
+	} {
+		res, err := RunStoreGC(item.name, item.db, minFreeRatio)
+		if err != nil {
+			return results, fmt.Errorf("gc %s failed: %s", item.name, err)
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// StartCompaction runs CompactDbs every interval until ctx is done
+func (dbMgr *DbMgr) StartCompaction(ctx context.Context, interval time.Duration, minFreeRatio float64) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				results, err := dbMgr.CompactDbs(minFreeRatio)
+				if err != nil {
+					dbmgr_log.Errorf("storage gc failed: %s", err)
+				}
+				for _, res := range results {
+					if !res.Skipped {
+						dbmgr_log.Infof("storage gc %s reclaimed %d bytes", res.Db, res.Reclaimed)
+					}
+				}
+			}
+		}
+	}()
+}
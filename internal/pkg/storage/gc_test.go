@@ -0,0 +1,134 @@
+//go:build !js
+// +build !js
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestStoreCompact(t *testing.T) {
+	s, err := NewStore(context.Background(), t.TempDir(), "gc")
+	if err != nil {
+		t.Fatalf("NewStore failed: %s", err)
+	}
+	defer s.Close()
+
+	value := make([]byte, 4096)
+	for i := 0; i < 4096; i++ {
+		if err := s.Set([]byte(fmt.Sprintf("del_%05d", i)), value); err != nil {
+			t.Fatalf("Set failed: %s", err)
+		}
+	}
+	if err := s.Set([]byte("keep"), []byte("value")); err != nil {
+		t.Fatalf("Set failed: %s", err)
+	}
+	if _, err := s.PrefixDelete([]byte("del_")); err != nil {
+		t.Fatalf("PrefixDelete failed: %s", err)
+	}
+
+	// readers keep running during the compaction
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if v, err := s.Get([]byte("keep")); err != nil || string(v) != "value" {
+				t.Errorf("Get during compaction got %q, %v", v, err)
+				return
+			}
+		}
+	}()
+
+	res, err := RunStoreGC("gc", s, DefaultGCFreeRatio)
+	close(stop)
+	wg.Wait()
+	if err != nil {
+		t.Fatalf("RunStoreGC failed: %s", err)
+	}
+	if res.Skipped || res.Reclaimed <= 0 {
+		t.Errorf("expect the db to be compacted, got %+v", res)
+	}
+
+	if v, err := s.Get([]byte("keep")); err != nil || string(v) != "value" {
+		t.Errorf("Get after compaction got %q, %v", v, err)
+	}
+	if err := s.Set([]byte("new"), []byte("value")); err != nil {
+		t.Errorf("Set after compaction failed: %s", err)
+	}
+
+	// nothing left to reclaim
+	res, err = RunStoreGC("gc", s, DefaultGCFreeRatio)
+	if err != nil || !res.Skipped {
+		t.Errorf("expect gc to be skipped, got %+v, %v", res, err)
+	}
+}
+
+func TestStoreCompactRestore(t *testing.T) {
+	s, err := NewStore(context.Background(), t.TempDir(), "gc")
+	if err != nil {
+		t.Fatalf("NewStore failed: %s", err)
+	}
+	defer s.Close()
+
+	value := make([]byte, 4096)
+	for i := 0; i < 1024; i++ {
+		if err := s.Set([]byte(fmt.Sprintf("del_%05d", i)), value); err != nil {
+			t.Fatalf("Set failed: %s", err)
+		}
+	}
+	if err := s.Set([]byte("keep"), []byte("value")); err != nil {
+		t.Fatalf("Set failed: %s", err)
+	}
+	if _, err := s.PrefixDelete([]byte("del_")); err != nil {
+		t.Fatalf("PrefixDelete failed: %s", err)
+	}
+	before, _ := fileSize(s.DatabasePath())
+
+	openCompacted = func(path string) (*bolt.DB, error) {
+		return nil, errors.New("open failed")
+	}
+	defer func() { openCompacted = openBolt }()
+	if _, err := s.Compact(); err == nil {
+		t.Fatalf("Compact should fail")
+	}
+
+	// the original db is restored and reopened
+	if after, _ := fileSize(s.DatabasePath()); after != before {
+		t.Errorf("original db should be restored, size %d, want %d", after, before)
+	}
+	if v, err := s.Get([]byte("keep")); err != nil || string(v) != "value" {
+		t.Errorf("Get after failed compaction got %q, %v", v, err)
+	}
+	if err := s.Set([]byte("new"), []byte("value")); err != nil {
+		t.Errorf("Set after failed compaction failed: %s", err)
+	}
+	for _, suffix := range []string{".compact", ".orig"} {
+		if _, err := os.Stat(s.DatabasePath() + suffix); !os.IsNotExist(err) {
+			t.Errorf("%s file should be removed", suffix)
+		}
+	}
+}
+
+func TestRunStoreGCMemStore(t *testing.T) {
+	res, err := RunStoreGC("mem", NewMemStore(), DefaultGCFreeRatio)
+	if err != nil || !res.Skipped {
+		t.Errorf("expect gc of memory store to be skipped, got %+v, %v", res, err)
+	}
+	if _, err := RunStoreGC("mem", NewMemStore(), 1); err == nil {
+		t.Errorf("expect error for free ratio 1")
+	}
+}
@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+const BACKEND_MEMORY = "memory"
+
+func init() {
+	RegisterBackend(BACKEND_MEMORY, newMemStoreBackend)
+}
+
+var (
+	memStoresMu sync.Mutex
+	memStores   = map[string]*memBackend{}
+)
+
+// newMemStoreBackend returns the same backend for the same dir and bucket while it is open,
+// so that a db reopened by path sees the data written before. The data is released when the last one is closed.
+func newMemStoreBackend(ctx context.Context, dir string, bucket string) (Backend, error) {
+	path := filepath.Join(dir, bucket)
+
+	memStoresMu.Lock()
+	defer memStoresMu.Unlock()
+	backend, ok := memStores[path]
+	if !ok {
+		backend = newMemBackend()
+		backend.path = path
+		memStores[path] = backend
+	}
+	backend.refs++
+	return &memHandle{memBackend: backend}, nil
+}
+
+// NewMemStore returns an in-memory store with ordered keys, data is lost when the process exits
+func NewMemStore() QuorumStorage {
+	return newBackendStore(newMemBackend())
+}
+
+// memBackend is an in-memory Backend, keys are kept sorted for the iteration
+type memBackend struct {
+	mu   sync.RWMutex
+	keys []string // sorted
+	data map[string][]byte
+
+	path string // key in memStores, empty if not opened by path
+	refs int    // open handles, guarded by memStoresMu
+}
+
+func newMemBackend() *memBackend {
+	return &memBackend{data: make(map[string][]byte)}
+}
+
+func (s *memBackend) Init(path string) error {
+	return nil
+}
+
+func (s *memBackend) Close() error {
+	return nil
+}
+
+func (s *memBackend) Set(key []byte, val []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.set(string(key), val)
+	return nil
+}
+
+// set must be called with the write lock held
+func (s *memBackend) set(k string, val []byte) {
+	if _, ok := s.data[k]; !ok {
+		i := sort.SearchStrings(s.keys, k)
+		s.keys = append(s.keys, "")
+		copy(s.keys[i+1:], s.keys[i:])
+		s.keys[i] = k
+	}
+	s.data[k] = append([]byte{}, val...)
+}
+
+func (s *memBackend) Delete(key []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := string(key)
+	if _, ok := s.data[k]; !ok {
+		return nil
+	}
+	delete(s.data, k)
+	i := sort.SearchStrings(s.keys, k)
+	s.keys = append(s.keys[:i], s.keys[i+1:]...)
+	return nil
+}
+
+func (s *memBackend) Get(key []byte) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	val, ok := s.data[string(key)]
+	if !ok {
+		return nil, nil
+	}
+	return append([]byte{}, val...), nil
+}
+
+// Iterate works on a snapshot of the keys, so fn can write to the store
+func (s *memBackend) Iterate(prefix []byte, seek []byte, reverse bool, fn func(k []byte, v []byte) error) error {
+	s.mu.RLock()
+	keys := []string{}
+	start := string(seek)
+	if reverse {
+		start = string(prefix)
+	}
+	for i := sort.SearchStrings(s.keys, start); i < len(s.keys); i++ {
+		if !bytes.HasPrefix([]byte(s.keys[i]), prefix) {
+			break
+		}
+		keys = append(keys, s.keys[i])
+	}
+	s.mu.RUnlock()
+
+	if reverse {
+		for i, j := 0, len(keys)-1; i < j; i, j = i+1, j-1 {
+			keys[i], keys[j] = keys[j], keys[i]
+		}
+	}
+	for _, k := range keys {
+		v, _ := s.Get([]byte(k))
+		if v == nil {
+			continue
+		}
+		if err := fn([]byte(k), v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *memBackend) BatchWrite(keys [][]byte, values [][]byte) error {
+	if len(keys) != len(values) {
+		return errors.New("keys' and values' length should be equal")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, k := range keys {
+		s.set(string(k), values[i])
+	}
+	return nil
+}
+
+func (s *memBackend) Stats() (*StoreStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var size int64
+	for k, v := range s.data {
+		size += int64(len(k) + len(v))
+	}
+	return &StoreStats{Backend: BACKEND_MEMORY, Size: size, InUse: size, KeyCount: len(s.keys)}, nil
+}
+
+// memHandle is a backend opened by path, closing the last handle of the path releases the data
+type memHandle struct {
+	*memBackend
+	once sync.Once
+}
+
+func (h *memHandle) Close() error {
+	h.once.Do(func() {
+		memStoresMu.Lock()
+		defer memStoresMu.Unlock()
+		h.refs--
+		if h.refs == 0 && memStores[h.path] == h.memBackend {
+			delete(memStores, h.path)
+		}
+	})
+	return nil
+}
@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMemStoreOrderedIteration(t *testing.T) {
+	s := NewMemStore()
+	for _, k := range []string{"b_2", "a_1", "b_1", "b_3", "c_1"} {
+		if err := s.Set([]byte(k), []byte("v"+k)); err != nil {
+			t.Fatalf("Set %s failed: %s", k, err)
+		}
+	}
+
+	keys := []string{}
+	s.PrefixForeachKey([]byte("b_2"), []byte("b_"), false, func(k []byte, err error) error {
+		keys = append(keys, string(k))
+		return nil
+	})
+	if len(keys) != 2 || keys[0] != "b_2" || keys[1] != "b_3" {
+		t.Errorf("forward iteration from b_2 got %v", keys)
+	}
+
+	keys = keys[:0]
+	s.PrefixForeachKey([]byte("b_"), []byte("b_"), true, func(k []byte, err error) error {
+		keys = append(keys, string(k))
+		return nil
+	})
+	if len(keys) != 3 || keys[0] != "b_3" || keys[2] != "b_1" {
+		t.Errorf("reverse iteration got %v", keys)
+	}
+
+	if n, _ := s.PrefixDelete([]byte("b_")); n != 3 {
+		t.Errorf("want 3 keys deleted, got %d", n)
+	}
+	if ok, _ := s.IsExist([]byte("b_1")); ok {
+		t.Errorf("b_1 should be deleted")
+	}
+	if v, _ := s.Get([]byte("c_1")); string(v) != "vc_1" {
+		t.Errorf("want vc_1, got %s", v)
+	}
+}
+
+func TestMemStoreSequence(t *testing.T) {
+	s := NewMemStore()
+	seq, err := s.GetSequence([]byte("seq"), 100)
+	if err != nil {
+		t.Fatalf("GetSequence failed: %s", err)
+	}
+	for want := uint64(0); want < 3; want++ {
+		if got, _ := seq.Next(); got != want {
+			t.Errorf("want %d, got %d", want, got)
+		}
+	}
+
+	// a new sequence on the same key continues
+	seq, _ = s.GetSequence([]byte("seq"), 100)
+	if got, _ := seq.Next(); got != 3 {
+		t.Errorf("want 3, got %d", got)
+	}
+}
+
+func TestMemoryBackend(t *testing.T) {
+	dir := t.TempDir()
+	dbMgr, err := CreateDbWithBackend(dir, BACKEND_MEMORY)
+	if err != nil {
+		t.Fatalf("CreateDbWithBackend failed: %s", err)
+	}
+	if err := dbMgr.Db.Set([]byte("k"), []byte("v")); err != nil {
+		t.Fatalf("Set failed: %s", err)
+	}
+
+	// reopened by path in the same process
+	db, err := NewBackendStore(context.Background(), BACKEND_MEMORY, dir, "db")
+	if err != nil {
+		t.Fatalf("NewBackendStore failed: %s", err)
+	}
+	if v, _ := db.Get([]byte("k")); string(v) != "v" {
+		t.Errorf("want v, got %s", v)
+	}
+
+	// the data is released with the last open store of the path
+	db.Close()
+	db.Close()
+	if v, _ := dbMgr.Db.Get([]byte("k")); string(v) != "v" {
+		t.Errorf("store should be kept while it is open, got %s", v)
+	}
+	dbMgr.Db.Close()
+	dbMgr.GroupInfoDb.Close()
+	memStoresMu.Lock()
+	n := len(memStores)
+	memStoresMu.Unlock()
+	if n != 0 {
+		t.Errorf("closed stores should be released, %d left", n)
+	}
+	db, _ = NewBackendStore(context.Background(), BACKEND_MEMORY, dir, "db")
+	if v, _ := db.Get([]byte("k")); v != nil {
+		t.Errorf("reopened store should be empty, got %s", v)
+	}
+	db.Close()
+
+	if _, err := NewBackendStore(context.Background(), "unknown", dir, "db"); err == nil {
+		t.Errorf("unknown backend should fail")
+	}
+}
+
+func TestCreateDbWithPaths(t *testing.T) {
+	groupsDir, dataDir := t.TempDir(), t.TempDir()
+	dbMgr, err := CreateDbWithPaths(groupsDir, dataDir, BACKEND_BOLT)
+	if err != nil {
+		t.Fatalf("CreateDbWithPaths failed: %s", err)
+	}
+	defer dbMgr.CloseDb()
+
+	for _, path := range []string{filepath.Join(groupsDir, "groups.db"), filepath.Join(dataDir, "db.db")} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expect db file %s: %s", path, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(groupsDir, "db.db")); err == nil {
+		t.Errorf("expect no data db in the groups dir")
+	}
+	if dbMgr.DataPath != dataDir {
+		t.Errorf("expect data path %s, got %s", dataDir, dbMgr.DataPath)
+	}
+}
@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+const (
+	DBVER_KEY = "dbver" // schema version of the node dbs, in groupinfo db
+	DBMIG_KEY = "dbmig" // migration step in progress, in groupinfo db
+)
+
+// Migration converts the node dbs from Version-1 to Version. Up and Down may be run again
+// after an interruption, so they must be idempotent. Down is nil if the migration can not be rolled back.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(dbMgr *DbMgr) error
+	Down    func(dbMgr *DbMgr) error
+}
+
+// MigrationStep is one migration to run, Down means rolling it back
+type MigrationStep struct {
+	Version int    `json:"version"`
+	Name    string `json:"name"`
+	Down    bool   `json:"down"`
+	Resume  bool   `json:"resume,omitempty"` // interrupted step run again
+}
+
+func (step *MigrationStep) String() string {
+	s := fmt.Sprintf("up %d %s", step.Version, step.Name)
+	if step.Down {
+		s = fmt.Sprintf("down %d %s", step.Version, step.Name)
+	}
+	if step.Resume {
+		s += " (resume)"
+	}
+	return s
+}
+
+var migrations = []*Migration{}
+
+// RegisterMigration adds the next migration, versions start from 1 and must be registered in order
+func RegisterMigration(m *Migration) {
+	if m.Version != len(migrations)+1 {
+		panic(fmt.Sprintf("storage: migration %d %s registered out of order, expect version %d", m.Version, m.Name, len(migrations)+1))
+	}
+	if m.Up == nil {
+		panic(fmt.Sprintf("storage: migration %d %s has no Up", m.Version, m.Name))
+	}
+	migrations = append(migrations, m)
+}
+
+// LatestDataVersion is the schema version of this build
+func LatestDataVersion() int {
+	return len(migrations)
+}
+
+// GetDataVersion returns the schema version recorded in the db, 0 for dbs created before versioning
+func (dbMgr *DbMgr) GetDataVersion() (int, error) {
+	value, err := dbMgr.GroupInfoDb.Get([]byte(DBVER_KEY))
+	if err != nil || value == nil {
+		return 0, err
+	}
+	return strconv.Atoi(string(value))
+}
+
+func (dbMgr *DbMgr) getInterruptedStep() (*MigrationStep, error) {
+	value, err := dbMgr.GroupInfoDb.Get([]byte(DBMIG_KEY))
+	if err != nil || value == nil {
+		return nil, err
+	}
+	step := &MigrationStep{}
+	if err := json.Unmarshal(value, step); err != nil {
+		return nil, err
+	}
+	step.Resume = true
+	return step, nil
+}
+
+// TryMigration migrates the dbs to the target version, a negative target means the latest version,
+// a lower target rolls back the applied migrations. A migration interrupted before is run again first.
+// It returns the steps run, or the steps it would run with dryRun.
+func (dbMgr *DbMgr) TryMigration(target int, dryRun bool) ([]*MigrationStep, error) {
+	return dbMgr.migrate(migrations, target, dryRun)
+}
+
+func (dbMgr *DbMgr) migrate(migs []*Migration, target int, dryRun bool) ([]*MigrationStep, error) {
+	latest := len(migs)
+	if target < 0 {
+		target = latest
+	}
+	if target > latest {
+		return nil, fmt.Errorf("target version %d is newer than the latest version %d of this build", target, latest)
+	}
+
+	current, err := dbMgr.GetDataVersion()
+	if err != nil {
+		return nil, err
+	}
+	steps := []*MigrationStep{}
+	interrupted, err := dbMgr.getInterruptedStep()
+	if err != nil {
+		return nil, err
+	}
+	if interrupted != nil {
+		if interrupted.Version < 1 || interrupted.Version > latest {
+			return nil, fmt.Errorf("interrupted migration %d is unknown to this build", interrupted.Version)
+		}
+		steps = append(steps, interrupted)
+		current = interrupted.Version
+		if interrupted.Down {
+			current -= 1
+		}
+	}
+	if current > latest {
+		return nil, fmt.Errorf("db version %d is newer than the latest version %d of this build", current, latest)
+	}
+
+	for v := current + 1; v <= target; v++ {
+		steps = append(steps, &MigrationStep{Version: v, Name: migs[v-1].Name})
+	}
+	for v := current; v > target; v-- {
+		if migs[v-1].Down == nil {
+			return nil, fmt.Errorf("migration %d %s can not be rolled back", v, migs[v-1].Name)
+		}
+		steps = append(steps, &MigrationStep{Version: v, Name: migs[v-1].Name, Down: true})
+	}
+	if dryRun {
+		return steps, nil
+	}
+
+	for i, step := range steps {
+		if err := dbMgr.runMigrationStep(migs[step.Version-1], step); err != nil {
+			return steps[:i], fmt.Errorf("migration %s failed: %s", step, err)
+		}
+		dbmgr_log.Infof("migration %s done", step)
+	}
+	return steps, nil
+}
+
+// runMigrationStep records the step before running it, so that an interrupted step is found on next start
+func (dbMgr *DbMgr) runMigrationStep(m *Migration, step *MigrationStep) error {
+	value, err := json.Marshal(&MigrationStep{Version: step.Version, Name: step.Name, Down: step.Down})
+	if err != nil {
+		return err
+	}
+	if err := dbMgr.GroupInfoDb.Set([]byte(DBMIG_KEY), value); err != nil {
+		return err
+	}
+
+	version := step.Version
+	if step.Down {
+		err = m.Down(dbMgr)
+		version -= 1
+	} else {
+		err = m.Up(dbMgr)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := dbMgr.GroupInfoDb.Set([]byte(DBVER_KEY), []byte(strconv.Itoa(version))); err != nil {
+		return err
+	}
+	return dbMgr.GroupInfoDb.Delete([]byte(DBMIG_KEY))
+}
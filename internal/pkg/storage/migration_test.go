@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+)
+
+func newTestDbMgr() *DbMgr {
+	return &DbMgr{GroupInfoDb: NewMemStore(), Db: NewMemStore()}
+}
+
+func testMigrations(applied *[]string, failUp int) []*Migration {
+	migs := []*Migration{}
+	for _, name := range []string{"one", "two", "three"} {
+		name := name
+		m := &Migration{Version: len(migs) + 1, Name: name}
+		m.Up = func(dbMgr *DbMgr) error {
+			if m.Version == failUp {
+				return errors.New("failed")
+			}
+			*applied = append(*applied, "up "+name)
+			return nil
+		}
+		if name != "one" {
+			m.Down = func(dbMgr *DbMgr) error {
+				*applied = append(*applied, "down "+name)
+				return nil
+			}
+		}
+		migs = append(migs, m)
+	}
+	return migs
+}
+
+func TestMigrate(t *testing.T) {
+	dbMgr := newTestDbMgr()
+	applied := []string{}
+	migs := testMigrations(&applied, 0)
+
+	steps, err := dbMgr.migrate(migs, -1, true)
+	if err != nil || len(steps) != 3 || len(applied) != 0 {
+		t.Fatalf("dry run: steps %v, applied %v, err %v", steps, applied, err)
+	}
+
+	if _, err := dbMgr.migrate(migs, 2, false); err != nil {
+		t.Fatalf("migrate to 2 failed: %s", err)
+	}
+	if v, _ := dbMgr.GetDataVersion(); v != 2 {
+		t.Errorf("expect version 2, got %d", v)
+	}
+	// idempotent
+	steps, err = dbMgr.migrate(migs, 2, false)
+	if err != nil || len(steps) != 0 {
+		t.Errorf("migrate to 2 again: steps %v, err %v", steps, err)
+	}
+
+	if _, err := dbMgr.migrate(migs, 1, false); err != nil {
+		t.Fatalf("roll back to 1 failed: %s", err)
+	}
+	if _, err := dbMgr.migrate(migs, 0, false); err == nil {
+		t.Errorf("expect error rolling back a migration without Down")
+	}
+	if _, err := dbMgr.migrate(migs, 4, false); err == nil {
+		t.Errorf("expect error for unknown target version")
+	}
+
+	want := []string{"up one", "up two", "down two"}
+	if len(applied) != len(want) {
+		t.Fatalf("expect %v, got %v", want, applied)
+	}
+	for i := range want {
+		if applied[i] != want[i] {
+			t.Errorf("expect %v, got %v", want, applied)
+		}
+	}
+}
+
+func TestMigrateResumeInterrupted(t *testing.T) {
+	dbMgr := newTestDbMgr()
+	applied := []string{}
+
+	if _, err := dbMgr.migrate(testMigrations(&applied, 2), -1, false); err == nil {
+		t.Fatalf("expect migration 2 to fail")
+	}
+	if v, _ := dbMgr.GetDataVersion(); v != 1 {
+		t.Errorf("expect version 1 after failure, got %d", v)
+	}
+
+	steps, err := dbMgr.migrate(testMigrations(&applied, 0), -1, true)
+	if err != nil || len(steps) != 2 || !steps[0].Resume || steps[0].Version != 2 {
+		t.Fatalf("expect interrupted step to be resumed first, got %v, %v", steps, err)
+	}
+	if _, err := dbMgr.migrate(testMigrations(&applied, 0), -1, false); err != nil {
+		t.Fatalf("resume failed: %s", err)
+	}
+	if v, _ := dbMgr.GetDataVersion(); v != 3 {
+		t.Errorf("expect version 3, got %d", v)
+	}
+	if step, _ := dbMgr.getInterruptedStep(); step != nil {
+		t.Errorf("expect no interrupted step, got %v", step)
+	}
+}
@@ -26,11 +26,20 @@ const (
 	ALLW_LIST_PREFIX     = "alw_list"  //allow list
 	DENY_LIST_PREFIX     = "dny_list"  //deny list
 	PRD_TRX_ID_PREFIX    = "prd_trxid" //trxid of latest trx which update group producer list
+	TMB_PREFIX           = "tmb"       //tombstone of post
+	PST_AUTHOR_PREFIX    = "pst_auth"  //author of post
+	PST_EXPIRY_PREFIX    = "pst_exp"   //expiry of post
+	PUBQ_PREFIX          = "pubq"      //publish queue entry of trx sent by this node
+	IDEM_PREFIX          = "idem"      //idempotency key of post sent by this node
+	TRXBLK_PREFIX        = "trxblk"    //block including trx
 
 	// groupinfo db
-	GROUPITEM_PREFIX = "grpitem"
-	GROUPSEED_PREFIX = "grpseed"
-	RELAY_PREFIX     = "rly" //relay
+	GROUPITEM_PREFIX    = "grpitem"
+	GROUPSEED_PREFIX    = "grpseed"
+	GROUPCNSCONF_PREFIX = "grpcnsconf" //consensus config of group
+	GROUPRO_PREFIX      = "grpro"      //read-only flag of group
+	GROUPCNSNAME_PREFIX = "grpcnsname" //consensus implementation of group
+	RELAY_PREFIX        = "rly"        //relay
 
 	// consensus db
 	CNS_BUFD_TRX = "cns_bf_trx" //buffered trx (used by acs)
@@ -84,6 +93,18 @@ func GetGroupItemKey(groupId string) string {
 	return GetGroupItemPrefix() + groupId
 }
 
+func GetGroupConsensusConfigKey(groupId string) string {
+	return GROUPCNSCONF_PREFIX + "_" + groupId
+}
+
+func GetGroupConsensusNameKey(groupId string) string {
+	return GROUPCNSNAME_PREFIX + "_" + groupId
+}
+
+func GetGroupReadOnlyKey(groupId string) string {
+	return GROUPRO_PREFIX + "_" + groupId
+}
+
 func GetChainInfoEpoch(groupId string, prefix ...string) string {
 	nodeprefix := utils.GetPrefix(prefix...)
 	return nodeprefix + CHNINFO_PREFIX + "_" + groupId + "_" + "currepoch"
@@ -99,6 +120,12 @@ func GetChainInfoBlock(groupId string, prefix ...string) string {
 	return nodeprefix + CHNINFO_PREFIX + "_" + groupId + "_" + "currblock"
 }
 
+// GetChainInfoPrunedBefore is the key of the first block kept by pruning
+func GetChainInfoPrunedBefore(groupId string, prefix ...string) string {
+	nodeprefix := utils.GetPrefix(prefix...)
+	return nodeprefix + CHNINFO_PREFIX + "_" + groupId + "_" + "prunedbefore"
+}
+
 func GetPostPrefix(groupId string, prefix ...string) string {
 	nodeprefix := utils.GetPrefix(prefix...)
 	return nodeprefix + GRP_PREFIX + "_" + CNT_PREFIX + "_" + groupId
@@ -109,6 +136,58 @@ func GetPostKey(groupId string, timestamp string, trxid string, prefix ...string
 	return _prefix + "_" + timestamp + "_" + trxid
 }
 
+// GetTombstoneKey is the key of the tombstone trx id which deletes the post
+func GetTombstoneKey(groupId string, trxId string, prefix ...string) string {
+	nodeprefix := utils.GetPrefix(prefix...)
+	return nodeprefix + TMB_PREFIX + "_" + groupId + "_" + trxId
+}
+
+// GetPostAuthorKey is the key of the sender of the post, it is kept by the producer nodes which do not save posts
+func GetPostAuthorKey(groupId string, trxId string, prefix ...string) string {
+	nodeprefix := utils.GetPrefix(prefix...)
+	return nodeprefix + PST_AUTHOR_PREFIX + "_" + groupId + "_" + trxId
+}
+
+func GetPostExpiryPrefix(groupId string, prefix ...string) string {
+	nodeprefix := utils.GetPrefix(prefix...)
+	return nodeprefix + PST_EXPIRY_PREFIX + "_" + groupId + "_"
+}
+
+// GetPostExpiryKey is the key of the expiry timestamp of the post, only posts with an expiry have it
+func GetPostExpiryKey(groupId string, trxId string, prefix ...string) string {
+	return GetPostExpiryPrefix(groupId, prefix...) + trxId
+}
+
+func GetPublishQueuePrefix(groupId string, prefix ...string) string {
+	nodeprefix := utils.GetPrefix(prefix...)
+	return nodeprefix + PUBQ_PREFIX + "_" + groupId + "_"
+}
+
+// GetPublishQueueKey is the key of the publish queue entry of a trx sent by this node and not applied yet
+func GetPublishQueueKey(groupId string, trxId string, prefix ...string) string {
+	return GetPublishQueuePrefix(groupId, prefix...) + trxId
+}
+
+func GetIdempotencyKeyPrefix(groupId string, prefix ...string) string {
+	nodeprefix := utils.GetPrefix(prefix...)
+	return nodeprefix + IDEM_PREFIX + "_" + groupId + "_"
+}
+
+// GetIdempotencyKeyKey is the key of the payload hash and the trx of an idempotency key sent by a client of this node
+func GetIdempotencyKeyKey(groupId string, key string, prefix ...string) string {
+	return GetIdempotencyKeyPrefix(groupId, prefix...) + key
+}
+
+func GetTrxBlockPrefix(groupId string, prefix ...string) string {
+	nodeprefix := utils.GetPrefix(prefix...)
+	return nodeprefix + TRXBLK_PREFIX + "_" + groupId + "_"
+}
+
+// GetTrxBlockKey is the key of the id of the block including a trx
+func GetTrxBlockKey(groupId string, trxId string, prefix ...string) string {
+	return GetTrxBlockPrefix(groupId, prefix...) + trxId
+}
+
 func GetProducerPrefix(groupId string, prefix ...string) string {
 	nodeprefix := utils.GetPrefix(prefix...)
 	return nodeprefix + PRD_PREFIX + "_" + groupId + "_"
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"errors"
+)
+
+// StoreStats is the size of a store, gathered from the engine metadata without reading the values
+type StoreStats struct {
+	Backend  string `json:"backend" example:"bolt"`
+	Path     string `json:"path" example:"data/peer/db.db"`
+	Size     int64  `json:"size" example:"536870912"`  // bytes on disk
+	InUse    int64  `json:"in_use" example:"10485760"` // bytes of pages holding data
+	Free     int64  `json:"free" example:"4096"`       // bytes of free pages, reclaimable by compaction
+	KeyCount int    `json:"key_count" example:"10240"`
+}
+
+// StatsProvider is implemented by backends which can report their size
+type StatsProvider interface {
+	Stats() (*StoreStats, error)
+}
+
+// wrapper is implemented by stores which wrap another one, like EncryptedStore
+type wrapper interface {
+	Unwrap() QuorumStorage
+}
+
+// unwrapStore returns the innermost store of db
+func unwrapStore(db QuorumStorage) QuorumStorage {
+	for {
+		w, ok := db.(wrapper)
+		if !ok {
+			return db
+		}
+		db = w.Unwrap()
+	}
+}
+
+// GetStoreStats returns the stats of the store if its backend supports it
+func GetStoreStats(db QuorumStorage) (*StoreStats, error) {
+	p, ok := unwrapStore(db).(StatsProvider)
+	if !ok {
+		return nil, errors.New("storage backend does not support stats")
+	}
+	return p.Stats()
+}
+
+// CountPrefixKeys counts the keys with the prefix, only keys are read
+func CountPrefixKeys(db QuorumStorage, prefix string) (int, error) {
+	return db.PrefixForeachKey([]byte(prefix), []byte(prefix), false, func(k []byte, err error) error {
+		return err
+	})
+}
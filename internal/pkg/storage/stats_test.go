@@ -0,0 +1,30 @@
+package storage
+
+import "testing"
+
+func TestStoreStats(t *testing.T) {
+	s := NewMemStore()
+	for _, k := range []string{"blk_1", "blk_2", "trx_1", "trx_2_1"} {
+		if err := s.Set([]byte(k), []byte("value")); err != nil {
+			t.Fatalf("Set %s failed: %s", k, err)
+		}
+	}
+
+	stats, err := GetStoreStats(s)
+	if err != nil {
+		t.Fatalf("GetStoreStats failed: %s", err)
+	}
+	if stats.KeyCount != 4 || stats.Size != int64(5+5+5+7+4*5) {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	for prefix, want := range map[string]int{"blk_": 2, "trx_": 2, "trx_2_": 1, "none_": 0} {
+		n, err := CountPrefixKeys(s, prefix)
+		if err != nil {
+			t.Fatalf("CountPrefixKeys %s failed: %s", prefix, err)
+		}
+		if n != want {
+			t.Errorf("CountPrefixKeys %s: expect %d, got %d", prefix, want, n)
+		}
+	}
+}
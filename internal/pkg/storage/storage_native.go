@@ -23,13 +23,29 @@ const (
 	boltAllocSize      = 8 * 1024 * 1024
 	mmapSize           = 536870912 // Specifies the initial mmap size of bolt.
 	sequenceBucketName = "__sequence__"
+	compactTxMaxSize   = 64 * 1024 * 1024
 )
 
+// compactLockTimeout is how long Compact waits for the running transactions to finish
+var compactLockTimeout = 30 * time.Second
+
+// openCompacted opens the db file swapped in by Compact
+var openCompacted = openBolt
+
 type Store struct {
 	db           *bolt.DB
 	databasePath string
 	bucket       []byte
 	ctx          context.Context
+
+	// compactMu is held for reading by every transaction and for writing by Compact while the db file is swapped
+	compactMu sync.RWMutex
+}
+
+func init() {
+	RegisterBackend(BACKEND_BOLT, func(ctx context.Context, dir string, bucket string) (Backend, error) {
+		return NewStore(ctx, dir, bucket)
+	})
 }
 
 func getDBPath(dir string, bucket string) string {
@@ -47,7 +63,10 @@ func OpenDB(dir, bucket string) (*bolt.DB, error) {
 		return nil, err
 	}
 
-	dbPath := getDBPath(dir, bucket)
+	return openBolt(getDBPath(dir, bucket))
+}
+
+func openBolt(dbPath string) (*bolt.DB, error) {
 	db, err := bolt.Open(
 		dbPath,
 		0644,
@@ -116,23 +135,156 @@ func (s *Store) ClearDB() error {
 
 // Close closes the underlying BoltDB database.
 func (s *Store) Close() error {
+	s.compactMu.Lock()
+	defer s.compactMu.Unlock()
 	return s.db.Close()
 }
 
+func (s *Store) view(fn func(*bolt.Tx) error) error {
+	s.compactMu.RLock()
+	defer s.compactMu.RUnlock()
+	return s.db.View(fn)
+}
+
+func (s *Store) update(fn func(*bolt.Tx) error) error {
+	s.compactMu.RLock()
+	defer s.compactMu.RUnlock()
+	return s.db.Update(fn)
+}
+
+// Compact rewrites the db into a new file without free pages and swaps it in, it returns the bytes given back to the filesystem.
+// Transactions are paused during the copy. Compact never queues behind the read lock,
+// so a transaction nested in another one can not deadlock, it gives up if the db stays busy.
+func (s *Store) Compact() (int64, error) {
+	deadline := time.Now().Add(compactLockTimeout)
+	for !s.compactMu.TryLock() {
+		if time.Now().After(deadline) {
+			return 0, fmt.Errorf("db %s is busy, try again later", s.databasePath)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	defer s.compactMu.Unlock()
+
+	before, err := fileSize(s.databasePath)
+	if err != nil {
+		return 0, err
+	}
+
+	tmpPath := s.databasePath + ".compact"
+	if err := os.Remove(tmpPath); err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+	dst, err := bolt.Open(tmpPath, 0644, &bolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return 0, err
+	}
+	if err := bolt.Compact(dst, s.db, compactTxMaxSize); err != nil {
+		dst.Close()
+		os.Remove(tmpPath)
+		return 0, err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(tmpPath)
+		return 0, err
+	}
+
+	if err := s.db.Close(); err != nil {
+		os.Remove(tmpPath)
+		return 0, err
+	}
+	// the original file is kept until the compacted one is opened, it is restored on any error
+	origPath := s.databasePath + ".orig"
+	swapErr := swapDbFile(s.databasePath, tmpPath, origPath)
+	var db *bolt.DB
+	if swapErr == nil {
+		db, swapErr = openCompacted(s.databasePath)
+		if swapErr != nil {
+			dbmgr_log.Errorf("open compacted db %s failed: %s, restore the original one", s.databasePath, swapErr)
+			if err := os.Rename(origPath, s.databasePath); err != nil {
+				dbmgr_log.Errorf("restore db %s failed: %s, the original one is kept in %s", s.databasePath, err, origPath)
+				return 0, swapErr
+			}
+		}
+	}
+	os.Remove(tmpPath)
+	if swapErr != nil {
+		db, err = openBolt(s.databasePath)
+		if err != nil {
+			// s.db stays closed, the calls fail with bolt.ErrDatabaseNotOpen until the node is restarted
+			dbmgr_log.Errorf("reopen db %s after compaction failed: %s", s.databasePath, err)
+			return 0, err
+		}
+		s.db = db
+		return 0, swapErr
+	}
+	s.db = db
+	os.Remove(origPath)
+
+	after, err := fileSize(s.databasePath)
+	if err != nil {
+		return 0, err
+	}
+	return before - after, nil
+}
+
+// swapDbFile moves the file at path to orig and the file at tmp to path, path is left unchanged on error
+func swapDbFile(path string, tmp string, orig string) error {
+	if err := os.Rename(path, orig); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Rename(orig, path)
+		return err
+	}
+	return nil
+}
+
+func fileSize(path string) (int64, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	return fi.Size(), nil
+}
+
 // DatabasePath at which this database writes files.
 func (s *Store) DatabasePath() string {
 	return s.databasePath
 }
 
+// Stats reports the file size and page usage of the db, the read transaction does not block writers
+func (s *Store) Stats() (*StoreStats, error) {
+	s.compactMu.RLock()
+	defer s.compactMu.RUnlock()
+
+	stats := &StoreStats{Backend: BACKEND_BOLT, Path: s.databasePath}
+	if fi, err := os.Stat(s.databasePath); err == nil {
+		stats.Size = fi.Size()
+	}
+
+	pageSize := int64(s.db.Info().PageSize)
+	dbStats := s.db.Stats()
+	stats.Free = int64(dbStats.FreePageN+dbStats.PendingPageN) * pageSize
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		stats.InUse = tx.Size() - stats.Free
+		if bucket := tx.Bucket(s.bucket); bucket != nil {
+			stats.KeyCount = bucket.Stats().KeyN
+		}
+		return nil
+	})
+	return stats, err
+}
+
 func (s *Store) Set(key []byte, val []byte) error {
-	return s.db.Update(func(tx *bolt.Tx) error {
+	return s.update(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket(s.bucket)
 		return bucket.Put(key, val)
 	})
 }
 
 func (s *Store) Delete(key []byte) error {
-	return s.db.Update(func(tx *bolt.Tx) error {
+	return s.update(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket(s.bucket)
 		return bucket.Delete(key)
 	})
@@ -145,7 +297,7 @@ func (s *Store) Get(key []byte) ([]byte, error) {
 	}
 
 	var val []byte
-	err := s.db.View(func(tx *bolt.Tx) error {
+	err := s.view(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket(s.bucket)
 		val = bucket.Get(key)
 		if val != nil {
@@ -171,7 +323,7 @@ func (s *Store) PrefixDelete(prefix []byte) (int, error) {
 
 	matched := 0
 
-	err := s.db.Update(func(tx *bolt.Tx) error {
+	err := s.update(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket(s.bucket)
 		c := bucket.Cursor()
 		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
@@ -190,7 +342,7 @@ func (s *Store) PrefixDelete(prefix []byte) (int, error) {
 func (s *Store) PrefixCondDelete(prefix []byte, fn func(k []byte, v []byte, err error) (bool, error)) (int, error) {
 	matched := 0
 
-	err := s.db.Update(func(tx *bolt.Tx) error {
+	err := s.update(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket(s.bucket)
 		c := bucket.Cursor()
 
@@ -216,7 +368,7 @@ func (s *Store) PrefixCondDelete(prefix []byte, fn func(k []byte, v []byte, err
 func (s *Store) PrefixForeachKey(prefix []byte, valid []byte, reverse bool, fn func([]byte, error) error) (int, error) {
 	matched := 0
 
-	err := s.db.View(func(tx *bolt.Tx) error {
+	err := s.view(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket(s.bucket)
 		c := bucket.Cursor()
 		if reverse {
@@ -245,7 +397,7 @@ func (s *Store) PrefixForeachKey(prefix []byte, valid []byte, reverse bool, fn f
 }
 
 func (s *Store) PrefixForeach(prefix []byte, fn func([]byte, []byte, error) error) error {
-	return s.db.View(func(tx *bolt.Tx) error {
+	return s.view(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket(s.bucket)
 		if bucket == nil {
 			panic("bucket is nil")
@@ -261,8 +413,32 @@ func (s *Store) PrefixForeach(prefix []byte, fn func([]byte, []byte, error) erro
 	})
 }
 
+// Iterate implements Backend, the store uses the cursor directly for the other QuorumStorage methods
+func (s *Store) Iterate(prefix []byte, seek []byte, reverse bool, fn func(k []byte, v []byte) error) error {
+	return s.view(func(tx *bolt.Tx) error {
+		c := tx.Bucket(s.bucket).Cursor()
+		if reverse {
+			for k, v := c.Last(); k != nil; k, v = c.Prev() {
+				if !bytes.HasPrefix(k, prefix) {
+					continue
+				}
+				if err := fn(k, v); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		for k, v := c.Seek(seek); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			if err := fn(k, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 func (s *Store) Foreach(fn func(k []byte, v []byte, err error) error) error {
-	return s.db.View(func(tx *bolt.Tx) error {
+	return s.view(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket(s.bucket)
 		return bucket.ForEach(func(k, v []byte) error {
 			return fn(k, v, nil)
@@ -275,6 +451,9 @@ func (s *Store) BatchWrite(keys [][]byte, vals [][]byte) error {
 		return errors.New("keys' and values' length should be equal")
 	}
 
+	s.compactMu.RLock()
+	defer s.compactMu.RUnlock()
+
 	tx, err := s.db.Begin(true)
 	if err != nil {
 		return err
@@ -315,13 +494,24 @@ func (s *Store) GetSequence(key []byte, bandwidth uint64) (Sequence, error) {
 }
 
 func CreateDb(path string) (*DbMgr, error) {
+	return CreateDbWithBackend(path, DefaultBackend)
+}
+
+// CreateDbWithBackend opens the group and data dbs in path with the storage backend
+func CreateDbWithBackend(path string, backend string) (*DbMgr, error) {
+	return CreateDbWithPaths(path, path, backend)
+}
+
+// CreateDbWithPaths opens the group db in groupsPath and the data db in dataPath with the storage backend
+func CreateDbWithPaths(groupsPath string, dataPath string, backend string) (*DbMgr, error) {
 	ctx := context.Background()
-	groupDb, err := NewStore(ctx, path, "groups")
+	groupDb, err := NewBackendStore(ctx, backend, groupsPath, "groups")
 	if err != nil {
 		return nil, err
 	}
-	dataDb, err := NewStore(ctx, path, "db")
+	dataDb, err := NewBackendStore(ctx, backend, dataPath, "db")
 	if err != nil {
+		groupDb.Close()
 		return nil, err
 	}
 
@@ -329,7 +519,7 @@ func CreateDb(path string) (*DbMgr, error) {
 		GroupInfoDb: groupDb,
 		Db:          dataDb,
 		Auth:        nil,
-		DataPath:    path,
+		DataPath:    dataPath,
 	}
 	return &manager, nil
 }
@@ -382,7 +572,7 @@ func (seq *StoreSequence) Next() (uint64, error) {
 func (seq *StoreSequence) Release() error {
 	seq.lock.Lock()
 	defer seq.lock.Unlock()
-	err := seq.store.db.Update(func(tx *bolt.Tx) error {
+	err := seq.store.update(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket(seq.store.bucket)
 		val := bucket.Get(seq.key)
 
@@ -405,7 +595,7 @@ func (seq *StoreSequence) Release() error {
 }
 
 func (seq *StoreSequence) updateLease() error {
-	return seq.store.db.Update(func(tx *bolt.Tx) error {
+	return seq.store.update(func(tx *bolt.Tx) error {
 		val, err := seq.store.Get(seq.key)
 		if err != nil {
 			return err
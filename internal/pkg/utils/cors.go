@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// DefaultCORSMethods are allowed when no method is configured
+var DefaultCORSMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPatch,
+	http.MethodPost, http.MethodDelete, http.MethodOptions,
+}
+
+// DefaultCORSConfig allows all origins
+var DefaultCORSConfig = middleware.CORSConfig{
+	AllowOrigins: []string{"*"},
+	AllowMethods: DefaultCORSMethods,
+}
+
+// NewCORSConfig returns the cors config of an api server listening on host.
+// Without allowOrigins, all origins are allowed on a loopback host and only loopback origins otherwise.
+func NewCORSConfig(host string, allowOrigins, allowMethods, allowHeaders []string) middleware.CORSConfig {
+	config := middleware.CORSConfig{
+		AllowOrigins: allowOrigins,
+		AllowMethods: allowMethods,
+		AllowHeaders: allowHeaders,
+	}
+	if len(config.AllowMethods) == 0 {
+		config.AllowMethods = DefaultCORSMethods
+	}
+	if len(config.AllowOrigins) == 0 {
+		if IsLoopbackHost(host) {
+			config.AllowOrigins = []string{"*"}
+		} else {
+			config.AllowOriginFunc = IsLoopbackOrigin
+		}
+	}
+
+	return config
+}
+
+// IsLoopbackHost returns true for localhost and loopback ips
+func IsLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// IsLoopbackOrigin returns true if the origin is served from a loopback host
+func IsLoopbackOrigin(origin string) (bool, error) {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false, nil
+	}
+	return IsLoopbackHost(u.Hostname()), nil
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestNewCORSConfig(t *testing.T) {
+	cases := []struct {
+		host    string
+		origins []string
+		origin  string
+		allowed string
+	}{
+		{"localhost", nil, "https://app.example.com", "*"},
+		{"0.0.0.0", nil, "https://app.example.com", ""},
+		{"0.0.0.0", nil, "http://127.0.0.1:3000", "http://127.0.0.1:3000"},
+		{"0.0.0.0", []string{"https://app.example.com"}, "https://app.example.com", "https://app.example.com"},
+		{"127.0.0.1", []string{"https://app.example.com"}, "https://evil.example.com", ""},
+	}
+
+	for _, c := range cases {
+		called := false
+		e := NewEchoWithCORS(false, NewCORSConfig(c.host, c.origins, nil, nil))
+		e.POST("/api/v1/test", func(ctx echo.Context) error {
+			called = true
+			return ctx.NoContent(http.StatusOK)
+		})
+
+		req := httptest.NewRequest(http.MethodOptions, "/api/v1/test", nil)
+		req.Header.Set(echo.HeaderOrigin, c.origin)
+		req.Header.Set(echo.HeaderAccessControlRequestMethod, http.MethodPost)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		if called {
+			t.Errorf("%s %v: preflight should not hit the handler", c.host, c.origins)
+		}
+		if got := rec.Header().Get(echo.HeaderAccessControlAllowOrigin); got != c.allowed {
+			t.Errorf("%s %v origin %s: expect allow origin %q, got %q", c.host, c.origins, c.origin, c.allowed, got)
+		}
+	}
+}
@@ -41,6 +41,11 @@ type (
 )
 
 func NewEcho(debug bool) *echo.Echo {
+	return NewEchoWithCORS(debug, DefaultCORSConfig)
+}
+
+// NewEchoWithCORS creates the echo with the cors config, preflight requests are answered before other middlewares
+func NewEchoWithCORS(debug bool, cors middleware.CORSConfig) *echo.Echo {
 	e := echo.New()
 
 	// hide banner
@@ -83,13 +88,7 @@ func NewEcho(debug bool) *echo.Echo {
 
 	e.Use(middleware.Recover())
 
-	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: []string{"*"},
-		AllowMethods: []string{
-			http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPatch,
-			http.MethodPost, http.MethodDelete, http.MethodOptions,
-		},
-	}))
+	e.Use(middleware.CORSWithConfig(cors))
 
 	e.Use(middleware.GzipWithConfig(middleware.GzipConfig{
 		Level: 5, // hardcode
@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"errors"
+	"os"
+)
+
+// OpenFileCount returns the number of the open file descriptors of the process,
+// it reads /proc/self/fd on linux and /dev/fd on macos and bsd
+func OpenFileCount() (int, error) {
+	for _, dir := range []string{"/proc/self/fd", "/dev/fd"} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		// the dir being read is an open fd too
+		return len(entries) - 1, nil
+	}
+	return 0, errors.New("open file count is not supported on this platform")
+}
@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestOpenFileCount(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("/proc/self/fd")
+	}
+	before, err := OpenFileCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(filepath.Join(t.TempDir(), "fd"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	after, err := OpenFileCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after != before+1 {
+		t.Errorf("expect %d open files, got %d", before+1, after)
+	}
+}
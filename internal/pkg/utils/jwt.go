@@ -7,14 +7,29 @@ import (
 	"github.com/golang-jwt/jwt/v4"
 )
 
+// scopes of a chain jwt, a chain jwt without scope is an admin jwt
+const (
+	JWTScopeRead    = "read"    // GET apis only
+	JWTScopePublish = "publish" // read and post content to groups
+	JWTScopeAdmin   = "admin"   // all apis
+)
+
 // NewJWTToken creates a new jwt token
 func NewJWTToken(name string, role string, allowGroup string, jwtKey string, exp time.Time) (string, error) {
+	return NewScopedJWTToken(name, role, allowGroup, "", jwtKey, exp)
+}
+
+// NewScopedJWTToken creates a new jwt token with the scope claim, empty scope is left out
+func NewScopedJWTToken(name string, role string, allowGroup string, scope string, jwtKey string, exp time.Time) (string, error) {
 
 	token := jwt.New(jwt.SigningMethodHS256)
 	claims := token.Claims.(jwt.MapClaims)
 	claims["name"] = name
 	claims["role"] = role
 	claims["allowGroup"] = allowGroup
+	if scope != "" {
+		claims["scope"] = scope
+	}
 	claims["exp"] = exp.Unix()
 
 	return token.SignedString([]byte(jwtKey))
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"strconv"
+)
+
+// PprofListenAddr returns the address to serve pprof on, a missing host is localhost.
+// It refuses the port of the api server, so that profiles are never exposed with the api.
+func PprofListenAddr(addr string, apiPort uint) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid pprof listen address %s: %s", addr, err)
+	}
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil || p == 0 {
+		return "", fmt.Errorf("invalid pprof listen port %s", port)
+	}
+	if uint(p) == apiPort {
+		return "", fmt.Errorf("pprof can not listen on the api port %d", apiPort)
+	}
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// StartPprofServer serves net/http/pprof on addr in background, it returns the address listened on
+func StartPprofServer(addr string, apiPort uint) (string, error) {
+	addr, err := PprofListenAddr(addr, apiPort)
+	if err != nil {
+		return "", err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", err
+	}
+	go http.Serve(l, mux)
+	return l.Addr().String(), nil
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPprofListenAddr(t *testing.T) {
+	cases := []struct {
+		addr   string
+		expect string
+		err    bool
+	}{
+		{":6060", "127.0.0.1:6060", false},
+		{"0.0.0.0:6060", "0.0.0.0:6060", false},
+		{"localhost:5215", "", true},
+		{"6060", "", true},
+		{"127.0.0.1:0", "", true},
+	}
+	for _, c := range cases {
+		addr, err := PprofListenAddr(c.addr, 5215)
+		if (err != nil) != c.err {
+			t.Errorf("%s: expect error %v, got %v", c.addr, c.err, err)
+			continue
+		}
+		if addr != c.expect {
+			t.Errorf("%s: expect %s, got %s", c.addr, c.expect, addr)
+		}
+	}
+}
+
+func TestStartPprofServer(t *testing.T) {
+	addr, err := StartPprofServer("127.0.0.1:6061", 5215)
+	if err != nil {
+		t.Skipf("listen pprof failed: %s", err)
+	}
+	resp, err := http.Get("http://" + addr + "/debug/pprof/")
+	if err != nil {
+		t.Fatalf("get pprof index failed: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expect 200, got %d", resp.StatusCode)
+	}
+}
@@ -5,6 +5,8 @@ package utils
 import (
 	"bytes"
 	"crypto"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,8 +15,10 @@ import (
 	"os"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/Press-One/go-update"
+	"golang.org/x/mod/semver"
 )
 
 // releases pub key
@@ -33,22 +37,15 @@ func getGithub(url string, isRaw bool) ([]byte, error) {
 
 	client := &http.Client{}
 	req, err := http.NewRequest(http.MethodGet, url, bytes.NewBuffer([]byte("")))
+	if err != nil {
+		return nil, err
+	}
 	req.Header.Set("Authorization", fmt.Sprintf("token %s", ghToken))
 	if isRaw {
 		// this will get the assets download url, see: https://stackoverflow.com/questions/25923939/how-do-i-download-binary-files-of-a-github-release
 		req.Header.Set("Accept", "application/octet-stream")
 	}
-	if err != nil {
-		return nil, err
-	}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	content, _ := ioutil.ReadAll(resp.Body)
-
-	return content, nil
+	return doUpdateRequest(client, req)
 }
 
 func getQingCloud(url string, isRaw bool) ([]byte, error) {
@@ -56,137 +53,313 @@ func getQingCloud(url string, isRaw bool) ([]byte, error) {
 
 	client := &http.Client{}
 	req, err := http.NewRequest(http.MethodGet, url, bytes.NewBuffer([]byte("")))
+	if err != nil {
+		return nil, err
+	}
 	if isRaw {
 		// this will get the assets download url, see: https://stackoverflow.com/questions/25923939/how-do-i-download-binary-files-of-a-github-release
 		req.Header.Set("Accept", "application/octet-stream")
 	}
+	return doUpdateRequest(client, req)
+}
+
+func doUpdateRequest(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := client.Do(req)
+	defer resp.Body.Close()
+	content, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	content, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get %s failed: %s", req.URL, resp.Status)
+	}
 
 	return content, nil
 }
 
-func CheckUpdate(curVersion string, binName string) error {
+// ReleaseInfo is the latest release of the binary for this platform.
+// The manifest lists the sha256 checksums of the release files, "<hex>  <file name>" per line,
+// it is signed by the release key like the release files.
+type ReleaseInfo struct {
+	Version        string `json:"version"`
+	Source         string `json:"source"`
+	AssetName      string `json:"asset_name"`
+	AssetUrl       string `json:"asset_url"`
+	SigUrl         string `json:"sig_url"`
+	ManifestUrl    string `json:"manifest_url"`
+	ManifestSigUrl string `json:"manifest_sig_url"`
+	get            func(url string, isRaw bool) ([]byte, error)
+}
+
+// IsNewerThan reports whether the release is newer than the version, they are compared as semver with an optional "v" prefix,
+// a release or a version which is not semver is never newer
+func (r *ReleaseInfo) IsNewerThan(version string) bool {
+	latest, current := canonicalSemver(r.Version), canonicalSemver(version)
+	if !semver.IsValid(latest) || !semver.IsValid(current) {
+		return false
+	}
+	return semver.Compare(latest, current) > 0
+}
+
+func canonicalSemver(version string) string {
+	if !strings.HasPrefix(version, "v") {
+		return "v" + version
+	}
+	return version
+}
+
+func releaseAssetName(binName, version string) string {
+	baseName := fmt.Sprintf("%s-%s-%s-%s", binName, version, runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		return baseName + ".zip"
+	}
+	return baseName + ".tar.gz"
+}
+
+func releaseManifestName(binName, version string) string {
+	return fmt.Sprintf("%s-%s-SHA256SUMS", binName, version)
+}
+
+// GetLatestRelease returns the latest release from github or qingcloud, nothing is downloaded but the release info
+func GetLatestRelease(from string, binName string) (*ReleaseInfo, error) {
+	switch from {
+	case "github":
+		return getLatestReleaseGithub(binName)
+	case "qingcloud":
+		return getLatestReleaseQingCloud(binName)
+	}
+	return nil, fmt.Errorf("invalid update source: %s", from)
+}
+
+func getLatestReleaseGithub(binName string) (*ReleaseInfo, error) {
 	content, err := getGithub(LatestReleaseUrl, false)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	releaseInfo := GithubReleaseStruct{}
-	err = json.Unmarshal(content, &releaseInfo)
-	if err != nil {
-		return err
+	if err := json.Unmarshal(content, &releaseInfo); err != nil {
+		return nil, err
 	}
 	tagName := releaseInfo.TagName
 	if tagName == "" {
-		return errors.New("Failed to fetch latest version number")
-	}
-	logger.Infof("Found new version: %s, current version: %s\n", tagName, curVersion)
-	if tagName > curVersion {
-		baseName := fmt.Sprintf("%s-%s-%s-%s", binName, tagName, runtime.GOOS, runtime.GOARCH)
-		tarName := baseName + ".tar.gz"
-		if runtime.GOOS == "windows" {
-			tarName = baseName + ".zip"
-		}
-		sigName := tarName + ".sig"
-		tarUrl := ""
-		sigUrl := ""
-
-		for _, asset := range releaseInfo.Assets {
-			if asset.Name == tarName {
-				tarUrl = asset.Url
-			}
-			if asset.Name == sigName {
-				sigUrl = asset.Url
-			}
-		}
+		return nil, errors.New("Failed to fetch latest version number")
+	}
 
-		signature, err := getGithub(sigUrl, true)
-		if err != nil {
-			return err
+	release := &ReleaseInfo{Version: tagName, Source: "github", AssetName: releaseAssetName(binName, tagName), get: getGithub}
+	manifestName := releaseManifestName(binName, tagName)
+	for _, asset := range releaseInfo.Assets {
+		switch asset.Name {
+		case release.AssetName:
+			release.AssetUrl = asset.Url
+		case release.AssetName + ".sig":
+			release.SigUrl = asset.Url
+		case manifestName:
+			release.ManifestUrl = asset.Url
+		case manifestName + ".sig":
+			release.ManifestSigUrl = asset.Url
 		}
-		tarContent, err := getGithub(tarUrl, true)
-		if err != nil {
-			return err
-		}
-		opts := update.Options{
-			Verifier:         update.NewED25519Verifier(),
-			VerifyUseContent: false,
-			PublicKey:        []byte(ED25519PublicKey),
-			Signature:        signature,
-			Hash:             crypto.SHA256,
+	}
+	return release, nil
+}
+
+func getLatestReleaseQingCloud(binName string) (*ReleaseInfo, error) {
+	content, err := getQingCloud(fmt.Sprintf("%s/%s/VERSION.txt", LatestReleaseUrlQingCloud, binName), false)
+	if err != nil {
+		return nil, err
+	}
+	tagName := strings.TrimSpace(strings.Split(string(content), "-")[0])
+	if tagName == "" {
+		return nil, errors.New("Failed to fetch latest version number")
+	}
+
+	release := &ReleaseInfo{Version: tagName, Source: "qingcloud", AssetName: releaseAssetName(binName, tagName), get: getQingCloud}
+	release.AssetUrl = fmt.Sprintf("%s/%s/%s", LatestReleaseUrlQingCloud, binName, release.AssetName)
+	release.SigUrl = release.AssetUrl + ".sig"
+	release.ManifestUrl = fmt.Sprintf("%s/%s/%s", LatestReleaseUrlQingCloud, binName, releaseManifestName(binName, tagName))
+	release.ManifestSigUrl = release.ManifestUrl + ".sig"
+	return release, nil
+}
+
+// verifyReleaseSignature verifies the signify signature of the sha256 of the content by the release key
+func verifyReleaseSignature(content []byte, signature []byte, publicKey []byte) error {
+	checksum := sha256.Sum256(content)
+	return update.NewED25519Verifier().VerifySignature(checksum[:], signature, crypto.SHA256, publicKey)
+}
+
+// parseChecksumManifest returns the sha256 checksums of the manifest by file name
+func parseChecksumManifest(content []byte) (map[string][]byte, error) {
+	checksums := map[string][]byte{}
+	for i, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
 		}
-		if runtime.GOOS == "windows" {
-			opts.IsZip = true
-		} else {
-			opts.IsTarGz = true
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid checksum manifest line %d", i+1)
 		}
-		logger.Infof("Verifying..\n")
-		err = update.Apply(bytes.NewReader(tarContent), opts)
-		if err != nil {
-			return err
+		checksum, err := hex.DecodeString(fields[0])
+		if err != nil || len(checksum) != sha256.Size {
+			return nil, fmt.Errorf("invalid checksum manifest line %d", i+1)
 		}
-		logger.Infof("Update sucess!\n")
+		// "*" marks binary mode in the sha256sum output
+		checksums[strings.TrimPrefix(fields[1], "*")] = checksum
 	}
+	return checksums, nil
+}
 
-	return nil
+// verifiedChecksum downloads the manifest of the release, verifies it by the release key,
+// and returns the checksum of the release file
+func (r *ReleaseInfo) verifiedChecksum(publicKey []byte) ([]byte, error) {
+	if r.ManifestUrl == "" || r.ManifestSigUrl == "" {
+		return nil, fmt.Errorf("no checksum manifest in release %s", r.Version)
+	}
+	manifest, err := r.get(r.ManifestUrl, true)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := r.get(r.ManifestSigUrl, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyReleaseSignature(manifest, signature, publicKey); err != nil {
+		return nil, fmt.Errorf("checksum manifest of release %s is not signed by the release key: %s", r.Version, err)
+	}
+	checksums, err := parseChecksumManifest(manifest)
+	if err != nil {
+		return nil, err
+	}
+	checksum, ok := checksums[r.AssetName]
+	if !ok {
+		return nil, fmt.Errorf("%s is not in the checksum manifest of release %s", r.AssetName, r.Version)
+	}
+	return checksum, nil
 }
 
-func CheckUpdateQingCloud(curVersion string, binName string) error {
-	content, err := getQingCloud(fmt.Sprintf("%s/%s/VERSION.txt", LatestReleaseUrlQingCloud, binName), false)
+// Apply downloads the release file, verifies its checksum against the signed manifest and its signature
+// by the release key, then replaces the binary and keeps the replaced one as PrevBinaryPath.
+// Nothing is replaced if a verification fails, and the previous binary is restored if the new one fails the health check.
+func (r *ReleaseInfo) Apply(curVersion string, opts UpdateOptions) error {
+	if r.AssetUrl == "" || r.SigUrl == "" {
+		return fmt.Errorf("no %s or its signature in release %s", r.AssetName, r.Version)
+	}
+	target, err := opts.Target()
+	if err != nil {
+		return err
+	}
+	publicKey := []byte(ED25519PublicKey)
+	checksum, err := r.verifiedChecksum(publicKey)
 	if err != nil {
 		return err
 	}
-	version := string(content)
-	tagName := strings.TrimSpace(strings.Split(version, "-")[0])
-	logger.Infof("Found new version: %s, current version: %s\n", tagName, curVersion)
-	if tagName > curVersion {
-		baseName := fmt.Sprintf("%s-%s-%s-%s", binName, tagName, runtime.GOOS, runtime.GOARCH)
-		tarName := baseName + ".tar.gz"
-		if runtime.GOOS == "windows" {
-			tarName = baseName + ".zip"
-		}
-		sigName := tarName + ".sig"
-		tarUrl := fmt.Sprintf("%s/%s/%s", LatestReleaseUrlQingCloud, binName, tarName)
-		sigUrl := fmt.Sprintf("%s/%s/%s", LatestReleaseUrlQingCloud, binName, sigName)
 
-		signature, err := getQingCloud(sigUrl, true)
-		if err != nil {
-			return err
-		}
-		tarContent, err := getQingCloud(tarUrl, true)
-		if err != nil {
-			return err
-		}
-		opts := update.Options{
-			Verifier:         update.NewED25519Verifier(),
-			VerifyUseContent: false,
-			PublicKey:        []byte(ED25519PublicKey),
-			Signature:        signature,
-			Hash:             crypto.SHA256,
+	signature, err := r.get(r.SigUrl, true)
+	if err != nil {
+		return err
+	}
+	tarContent, err := r.get(r.AssetUrl, true)
+	if err != nil {
+		return err
+	}
+	updateOpts := update.Options{
+		TargetPath:       target,
+		OldSavePath:      PrevBinaryPath(target),
+		Verifier:         update.NewED25519Verifier(),
+		VerifyUseContent: false,
+		PublicKey:        publicKey,
+		Signature:        signature,
+		Checksum:         checksum,
+		Hash:             crypto.SHA256,
+	}
+	if runtime.GOOS == "windows" {
+		updateOpts.IsZip = true
+	} else {
+		updateOpts.IsTarGz = true
+	}
+	logger.Infof("Verifying..\n")
+	if err := update.Apply(bytes.NewReader(tarContent), updateOpts); err != nil {
+		if rerr := update.RollbackError(err); rerr != nil {
+			return fmt.Errorf("update failed and the binary is not restored: %s", rerr)
 		}
-		if runtime.GOOS == "windows" {
-			opts.IsZip = true
-		} else {
-			opts.IsTarGz = true
+		return fmt.Errorf("update %s aborted: %s", r.AssetName, err)
+	}
+
+	record := UpdateRecord{Action: UpdateActionUpdate, Version: r.Version, PrevVersion: curVersion, Source: r.Source, Time: time.Now().UTC()}
+	if err := checkBinaryHealth(target, opts.HealthCheckArgs, opts.healthCheckTimeout()); err != nil {
+		logger.Errorf("new binary %s failed the health check: %s, rolling back", r.Version, err)
+		if rerr := restorePrevBinary(target); rerr != nil {
+			return fmt.Errorf("new binary failed the health check: %s, and the rollback failed: %s", err, rerr)
 		}
-		logger.Infof("Verifying..\n")
-		err = update.Apply(bytes.NewReader(tarContent), opts)
-		if err != nil {
-			return err
+		record.Action = UpdateActionAutoRollback
+		record.Error = err.Error()
+		if herr := appendUpdateHistory(target, record); herr != nil {
+			logger.Warnf("save update history failed: %s", herr)
 		}
-		logger.Infof("Update sucess!\n")
+		return fmt.Errorf("new binary failed the health check and %s is restored: %s", curVersion, err)
 	}
-
+	if err := appendUpdateHistory(target, record); err != nil {
+		logger.Warnf("save update history failed: %s", err)
+	}
+	logger.Infof("Update sucess! the previous binary is kept as %s\n", PrevBinaryPath(target))
 	return nil
 }
 
+func checkUpdate(from string, curVersion string, binName string, opts UpdateOptions) error {
+	release, err := GetLatestRelease(from, binName)
+	if err != nil {
+		return err
+	}
+	logger.Infof("Found new version: %s, current version: %s\n", release.Version, curVersion)
+	if !release.IsNewerThan(curVersion) {
+		return nil
+	}
+	return release.Apply(curVersion, opts)
+}
+
+// UpdateCheckResult is the latest release found by CheckUpdateOnly
+type UpdateCheckResult struct {
+	CurrentVersion string `json:"current_version"`
+	LatestVersion  string `json:"latest_version"`
+	Source         string `json:"source"`
+	AssetName      string `json:"asset_name"`
+	Available      bool   `json:"available"` // the latest release is newer than the current version
+}
+
+func newUpdateCheckResult(release *ReleaseInfo, curVersion string) *UpdateCheckResult {
+	return &UpdateCheckResult{
+		CurrentVersion: curVersion,
+		LatestVersion:  release.Version,
+		Source:         release.Source,
+		AssetName:      release.AssetName,
+		Available:      release.IsNewerThan(curVersion),
+	}
+}
+
+// CheckUpdateOnly reports whether there is a newer release on github or qingcloud, nothing is downloaded or installed
+func CheckUpdateOnly(from string, curVersion string, binName string) (*UpdateCheckResult, error) {
+	release, err := GetLatestRelease(from, binName)
+	if err != nil {
+		return nil, err
+	}
+	return newUpdateCheckResult(release, curVersion), nil
+}
+
+// CheckUpdateWithOptions updates the binary from github or qingcloud if there is a newer release
+func CheckUpdateWithOptions(from string, curVersion string, binName string, opts UpdateOptions) error {
+	return checkUpdate(from, curVersion, binName, opts)
+}
+
+func CheckUpdate(curVersion string, binName string) error {
+	return checkUpdate("github", curVersion, binName, DefaultUpdateOptions())
+}
+
+func CheckUpdateQingCloud(curVersion string, binName string) error {
+	return checkUpdate("qingcloud", curVersion, binName, DefaultUpdateOptions())
+}
+
 type GithubReleaseStruct struct {
 	Assets  []GithubAssetStruct `json:"assets"`
 	TagName string              `json:"tag_name"`
@@ -0,0 +1,198 @@
+//go:build !js
+// +build !js
+
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	UpdateActionUpdate       = "update"
+	UpdateActionRollback     = "rollback"
+	UpdateActionAutoRollback = "auto-rollback"
+)
+
+const (
+	// DefaultUpdateHealthCheckTimeout is the time the new binary has to start and exit after an update
+	DefaultUpdateHealthCheckTimeout = 30 * time.Second
+	// maxUpdateHistory is the number of the records kept in the update history
+	maxUpdateHistory = 100
+)
+
+// UpdateOptions are the options of the self-update
+type UpdateOptions struct {
+	// TargetPath is the binary to update, empty for the running one
+	TargetPath string
+	// HealthCheckArgs are the args to run the new binary with after the update, e.g. "-version",
+	// the previous binary is restored if it does not exit with 0 within HealthCheckTimeout
+	HealthCheckArgs    []string
+	HealthCheckTimeout time.Duration
+}
+
+func DefaultUpdateOptions() UpdateOptions {
+	return UpdateOptions{
+		HealthCheckArgs:    []string{"-version"},
+		HealthCheckTimeout: DefaultUpdateHealthCheckTimeout,
+	}
+}
+
+// Target returns the binary to update
+func (o UpdateOptions) Target() (string, error) {
+	if o.TargetPath != "" {
+		return o.TargetPath, nil
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(exe)
+}
+
+func (o UpdateOptions) healthCheckTimeout() time.Duration {
+	if o.HealthCheckTimeout <= 0 {
+		return DefaultUpdateHealthCheckTimeout
+	}
+	return o.HealthCheckTimeout
+}
+
+// UpdateRecord is a record of the update history
+type UpdateRecord struct {
+	Action      string    `json:"action"`
+	Version     string    `json:"version"`
+	PrevVersion string    `json:"prev_version,omitempty"`
+	Source      string    `json:"source,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Time        time.Time `json:"time"`
+}
+
+// PrevBinaryPath is where the binary replaced by the last update is kept, e.g. quorum.prev
+func PrevBinaryPath(target string) string {
+	return target + ".prev"
+}
+
+// UpdateHistoryPath is the file of the update history of the binary
+func UpdateHistoryPath(target string) string {
+	return target + ".update-history.json"
+}
+
+// checkBinaryHealth runs the binary with the args, it must exit with 0 within the timeout
+func checkBinaryHealth(path string, args []string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, path, args...).CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("%s %s did not exit within %s", path, strings.Join(args, " "), timeout)
+	}
+	if err != nil {
+		output := strings.TrimSpace(string(out))
+		if len(output) > 512 {
+			output = output[len(output)-512:]
+		}
+		return fmt.Errorf("%s %s failed: %s, output: %s", path, strings.Join(args, " "), err, output)
+	}
+	return nil
+}
+
+// restorePrevBinary moves the previous binary back to the target
+func restorePrevBinary(target string) error {
+	prev := PrevBinaryPath(target)
+	if _, err := os.Stat(prev); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no previous binary %s to restore", prev)
+		}
+		return err
+	}
+
+	// windows can not rename onto an existing file, so move the target aside first
+	failed := filepath.Join(filepath.Dir(target), fmt.Sprintf(".%s.failed", filepath.Base(target)))
+	_ = os.Remove(failed)
+	if err := os.Rename(target, failed); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Rename(prev, target); err != nil {
+		if rerr := os.Rename(failed, target); rerr != nil {
+			return fmt.Errorf("restore %s failed: %s, and %s is left as %s: %s", prev, err, target, failed, rerr)
+		}
+		return err
+	}
+	// windows can not remove the running binary, it is removed by the next rollback
+	_ = os.Remove(failed)
+	return nil
+}
+
+// RollbackUpdate restores the binary replaced by the last update
+func RollbackUpdate(curVersion string, opts UpdateOptions) (*UpdateRecord, error) {
+	target, err := opts.Target()
+	if err != nil {
+		return nil, err
+	}
+	record := &UpdateRecord{Action: UpdateActionRollback, PrevVersion: curVersion, Time: time.Now().UTC()}
+	history, err := GetUpdateHistory(target)
+	if err != nil {
+		logger.Warnf("read update history failed: %s", err)
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Action == UpdateActionUpdate {
+			record.Version = history[i].PrevVersion
+			break
+		}
+	}
+
+	if err := restorePrevBinary(target); err != nil {
+		return nil, err
+	}
+	if err := appendUpdateHistory(target, *record); err != nil {
+		logger.Warnf("save update history failed: %s", err)
+	}
+	return record, nil
+}
+
+// GetUpdateHistory returns the update history of the binary, the oldest first
+func GetUpdateHistory(target string) ([]UpdateRecord, error) {
+	content, err := ioutil.ReadFile(UpdateHistoryPath(target))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	history := []UpdateRecord{}
+	if err := json.Unmarshal(content, &history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+func appendUpdateHistory(target string, record UpdateRecord) error {
+	history, err := GetUpdateHistory(target)
+	if err != nil {
+		// a broken history is replaced, it is informational only
+		logger.Warnf("read update history failed: %s", err)
+		history = nil
+	}
+	history = append(history, record)
+	if len(history) > maxUpdateHistory {
+		history = history[len(history)-maxUpdateHistory:]
+	}
+	content, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := UpdateHistoryPath(target)
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, content, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,103 @@
+//go:build !js
+// +build !js
+
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeTestBinary(t *testing.T, path string, script string) {
+	if err := ioutil.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckBinaryHealth(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts as binaries")
+	}
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "quorum")
+
+	writeTestBinary(t, bin, `[ "$1" = "version" ] && echo v1.0.1`)
+	if err := checkBinaryHealth(bin, []string{"version"}, 5*time.Second); err != nil {
+		t.Errorf("expect healthy binary, got %s", err)
+	}
+
+	writeTestBinary(t, bin, "echo crashed; exit 2")
+	if err := checkBinaryHealth(bin, []string{"version"}, 5*time.Second); err == nil || !strings.Contains(err.Error(), "crashed") {
+		t.Errorf("expect crashed binary failed with its output, got %v", err)
+	}
+
+	writeTestBinary(t, bin, "exec sleep 10")
+	if err := checkBinaryHealth(bin, []string{"version"}, 100*time.Millisecond); err == nil || !strings.Contains(err.Error(), "did not exit") {
+		t.Errorf("expect hanging binary timed out, got %v", err)
+	}
+}
+
+func TestRollbackUpdate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts as binaries")
+	}
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "quorum")
+	opts := UpdateOptions{TargetPath: bin, HealthCheckArgs: []string{"version"}}
+
+	writeTestBinary(t, bin, "echo v1.0.1")
+	if _, err := RollbackUpdate("v1.0.1", opts); err == nil {
+		t.Fatalf("expect error without the previous binary")
+	}
+
+	writeTestBinary(t, PrevBinaryPath(bin), "echo v1.0.0")
+	if err := appendUpdateHistory(bin, UpdateRecord{Action: UpdateActionUpdate, Version: "v1.0.1", PrevVersion: "v1.0.0", Source: "github", Time: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	record, err := RollbackUpdate("v1.0.1", opts)
+	if err != nil {
+		t.Fatalf("RollbackUpdate failed: %s", err)
+	}
+	if record.Version != "v1.0.0" || record.PrevVersion != "v1.0.1" {
+		t.Errorf("expect rollback from v1.0.1 to v1.0.0, got %+v", record)
+	}
+	content, err := ioutil.ReadFile(bin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "v1.0.0") {
+		t.Errorf("expect the previous binary restored, got %s", content)
+	}
+	if _, err := os.Stat(PrevBinaryPath(bin)); !os.IsNotExist(err) {
+		t.Errorf("expect the previous binary moved, got %v", err)
+	}
+
+	history, err := GetUpdateHistory(bin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[1].Action != UpdateActionRollback {
+		t.Errorf("expect update and rollback records, got %+v", history)
+	}
+}
+
+func TestUpdateHistoryLimit(t *testing.T) {
+	bin := filepath.Join(t.TempDir(), "quorum")
+	for i := 0; i < maxUpdateHistory+5; i++ {
+		if err := appendUpdateHistory(bin, UpdateRecord{Action: UpdateActionUpdate, Version: "v1", Time: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	history, err := GetUpdateHistory(bin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != maxUpdateHistory {
+		t.Errorf("expect %d records, got %d", maxUpdateHistory, len(history))
+	}
+}
@@ -0,0 +1,156 @@
+//go:build !js
+// +build !js
+
+package utils
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type testReleaseKey struct {
+	keynum []byte
+	priv   ed25519.PrivateKey
+	pub    ed25519.PublicKey
+}
+
+func newTestReleaseKey(t *testing.T) *testReleaseKey {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testReleaseKey{keynum: []byte("testkey1"), priv: priv, pub: pub}
+}
+
+// publicKey returns the key in the signify format
+func (k *testReleaseKey) publicKey() []byte {
+	buf := append(append([]byte("Ed"), k.keynum...), k.pub...)
+	return []byte(fmt.Sprintf("untrusted comment: signify public key\n%s\n", base64.StdEncoding.EncodeToString(buf)))
+}
+
+// sign returns the embedded signify signature of the sha256 of the content, like `signify -S -e -m hash`
+func (k *testReleaseKey) sign(name string, content []byte) []byte {
+	sum := sha256.Sum256(content)
+	msg := []byte(fmt.Sprintf("SHA256 (%s) = %s\n", name, hex.EncodeToString(sum[:])))
+	buf := append(append([]byte("Ed"), k.keynum...), ed25519.Sign(k.priv, msg)...)
+	return []byte(fmt.Sprintf("untrusted comment: verify with key.pub\n%s\n%s", base64.StdEncoding.EncodeToString(buf), msg))
+}
+
+func TestParseChecksumManifest(t *testing.T) {
+	sum := sha256.Sum256([]byte("rumcli"))
+	manifest := fmt.Sprintf("%x  rumcli-v1.0.0-linux-amd64.tar.gz\n%x *rumcli-v1.0.0-windows-amd64.zip\n\n", sum, sum)
+	checksums, err := parseChecksumManifest([]byte(manifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"rumcli-v1.0.0-linux-amd64.tar.gz", "rumcli-v1.0.0-windows-amd64.zip"} {
+		if !bytes.Equal(checksums[name], sum[:]) {
+			t.Errorf("expect checksum of %s", name)
+		}
+	}
+
+	for _, manifest := range []string{"abc  rumcli.tar.gz", fmt.Sprintf("%x", sum), fmt.Sprintf("%x  a b", sum)} {
+		if _, err := parseChecksumManifest([]byte(manifest)); err == nil {
+			t.Errorf("expect error of manifest %q", manifest)
+		}
+	}
+}
+
+func TestVerifiedChecksum(t *testing.T) {
+	key := newTestReleaseKey(t)
+	assetName := releaseAssetName("rumcli", "v1.0.0")
+	asset := []byte("release of rumcli")
+	sum := sha256.Sum256(asset)
+	manifest := []byte(fmt.Sprintf("%x  %s\n", sum, assetName))
+
+	files := map[string][]byte{}
+	release := &ReleaseInfo{
+		Version:        "v1.0.0",
+		AssetName:      assetName,
+		ManifestUrl:    "manifest",
+		ManifestSigUrl: "manifest.sig",
+		get: func(url string, isRaw bool) ([]byte, error) {
+			content, ok := files[url]
+			if !ok {
+				return nil, fmt.Errorf("get %s failed: 404 Not Found", url)
+			}
+			return content, nil
+		},
+	}
+
+	files["manifest"] = manifest
+	files["manifest.sig"] = key.sign("SHA256SUMS", manifest)
+	checksum, err := release.verifiedChecksum(key.publicKey())
+	if err != nil {
+		t.Fatalf("verifiedChecksum failed: %s", err)
+	}
+	if !bytes.Equal(checksum, sum[:]) {
+		t.Fatalf("expect checksum %x, got %x", sum, checksum)
+	}
+
+	// a manifest replaced after signing
+	files["manifest"] = []byte(fmt.Sprintf("%x  %s\n", sha256.Sum256([]byte("evil")), assetName))
+	if _, err := release.verifiedChecksum(key.publicKey()); err == nil || !strings.Contains(err.Error(), "not signed") {
+		t.Errorf("expect tampered manifest rejected, got %v", err)
+	}
+
+	// a manifest signed by another key
+	files["manifest"] = manifest
+	files["manifest.sig"] = newTestReleaseKey(t).sign("SHA256SUMS", manifest)
+	if _, err := release.verifiedChecksum(key.publicKey()); err == nil {
+		t.Errorf("expect manifest signed by another key rejected")
+	}
+
+	// the asset is not in the manifest
+	other := []byte(fmt.Sprintf("%x  %s\n", sum, releaseAssetName("rumcli", "v0.9.0")))
+	files["manifest"] = other
+	files["manifest.sig"] = key.sign("SHA256SUMS", other)
+	if _, err := release.verifiedChecksum(key.publicKey()); err == nil || !strings.Contains(err.Error(), "not in the checksum manifest") {
+		t.Errorf("expect missing asset rejected, got %v", err)
+	}
+
+	delete(files, "manifest.sig")
+	if _, err := release.verifiedChecksum(key.publicKey()); err == nil {
+		t.Errorf("expect missing manifest signature rejected")
+	}
+}
+
+func TestNewUpdateCheckResult(t *testing.T) {
+	release := &ReleaseInfo{Version: "v1.2.0", Source: "github", AssetName: releaseAssetName("quorum", "v1.2.0")}
+	result := newUpdateCheckResult(release, "v1.1.0")
+	if !result.Available || result.LatestVersion != "v1.2.0" || result.CurrentVersion != "v1.1.0" {
+		t.Errorf("expect v1.2.0 available, got %+v", result)
+	}
+	if result := newUpdateCheckResult(release, "v1.2.0"); result.Available {
+		t.Errorf("expect no update for the same version, got %+v", result)
+	}
+}
+
+func TestReleaseIsNewerThan(t *testing.T) {
+	for _, c := range []struct {
+		latest  string
+		current string
+		newer   bool
+	}{
+		{"v1.10.0", "v1.9.0", true},
+		{"v1.9.0", "v1.10.0", false},
+		{"v2.0.0", "v10.0.0", false},
+		{"v1.0.10", "v1.0.9", true},
+		{"1.2.0", "v1.1.0", true},
+		{"v1.2.0", "v1.2.0", false},
+		{"v1.2.0", "v1.2.0-rc.1", true},
+		{"latest", "v1.2.0", false},
+		{"v1.2.0", "dev", false},
+	} {
+		release := &ReleaseInfo{Version: c.latest}
+		if newer := release.IsNewerThan(c.current); newer != c.newer {
+			t.Errorf("expect %s newer than %s: %v, got %v", c.latest, c.current, c.newer, newer)
+		}
+	}
+}
@@ -5,9 +5,13 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 )
 
+// ProgressFunc reports `done` of `total`, total <= 0 means unknown
+type ProgressFunc func(done, total int64)
+
 // ZipDir zip files in a directory, do not include the directory itself
 func ZipDir(dir string, zipPath string) error {
 	logger.Infof("creating zip archive for %s => %s ...", dir, zipPath)
@@ -19,29 +23,49 @@ func ZipDir(dir string, zipPath string) error {
 	}
 	defer outZipFile.Close()
 
-	zipWriter := zip.NewWriter(outZipFile)
+	if err := ZipDirToWriter(dir, outZipFile, nil); err != nil {
+		return err
+	}
+
+	return outZipFile.Close()
+}
+
+// ZipDirToWriter write the zip stream of files in a directory to `w`, do not include the directory itself,
+// `progress` is optional and reports the bytes of files have been zipped
+func ZipDirToWriter(dir string, w io.Writer, progress ProgressFunc) error {
+	zipWriter := zip.NewWriter(w)
 	defer zipWriter.Close()
 
-	// do not change working directory
-	currentDir, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("get current directory failed: %s", err)
+	if err := ZipAddDir(zipWriter, dir, "", progress); err != nil {
+		return err
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		return fmt.Errorf("zipWriter.Close failed: %s", err)
 	}
-	defer os.Chdir(currentDir)
 
-	// change to the directory
+	return nil
+}
+
+// ZipAddDir add files in a directory to `zipWriter` under `prefix`, the directory itself is the `prefix` entry,
+// `progress` is optional and reports the bytes of files have been zipped
+func ZipAddDir(zipWriter *zip.Writer, dir string, prefix string, progress ProgressFunc) error {
 	absPath, err := filepath.Abs(dir)
 	if err != nil {
 		return err
 	}
 
-	if err = os.Chdir(absPath); err != nil {
-		return err
+	var done, total int64
+	if progress != nil {
+		total, err = dirSize(absPath)
+		if err != nil {
+			return err
+		}
+		progress(done, total)
 	}
 
-	basePath := "." // current directory
-	err = filepath.Walk(basePath, func(path string, info os.FileInfo, err error) error {
-		logger.Debugf("write %s to archive...", path)
+	return filepath.Walk(absPath, func(filePath string, info os.FileInfo, err error) error {
+		logger.Debugf("write %s to archive...", filePath)
 		if err != nil {
 			return err
 		}
@@ -56,10 +80,11 @@ func ZipDir(dir string, zipPath string) error {
 		header.Method = zip.Deflate
 
 		// set relative path of a file as the header name
-		header.Name, err = filepath.Rel(filepath.Dir(basePath), path)
+		name, err := filepath.Rel(absPath, filePath)
 		if err != nil {
 			return err
 		}
+		header.Name = path.Join(prefix, filepath.ToSlash(name))
 
 		if info.IsDir() {
 			header.Name += "/"
@@ -75,28 +100,54 @@ func ZipDir(dir string, zipPath string) error {
 			return nil
 		}
 
-		f, err := os.Open(path)
+		f, err := os.Open(filePath)
 		if err != nil {
 			return err
 		}
 		defer f.Close()
 
-		_, err = io.Copy(headerWriter, f)
+		n, err := io.Copy(headerWriter, f)
+		if progress != nil {
+			done += n
+			progress(done, total)
+		}
 
 		return err
 	})
+}
+
+// ZipAddFile add a file entry `name` with `data` to `zipWriter`
+func ZipAddFile(zipWriter *zip.Writer, name string, data []byte) error {
+	w, err := zipWriter.Create(name)
 	if err != nil {
 		return err
 	}
+	_, err = w.Write(data)
+	return err
+}
 
-	if err := zipWriter.Close(); err != nil {
-		return fmt.Errorf("zipWriter.Close failed: %s", err)
-	}
+// dirSize returns the total size of regular files in the directory
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
 
-	return nil
+	return size, err
 }
 
 func Unzip(zipPath string, dstPath string) error {
+	return UnzipWithProgress(zipPath, dstPath, nil)
+}
+
+// UnzipWithProgress is Unzip with optional `progress`, which reports the count of extracted files
+func UnzipWithProgress(zipPath string, dstPath string, progress ProgressFunc) error {
 	zipReader, err := zip.OpenReader(zipPath)
 	if err != nil {
 		return err
@@ -152,10 +203,14 @@ func Unzip(zipPath string, dstPath string) error {
 		return nil
 	}
 
-	for _, f := range zipReader.File {
+	total := int64(len(zipReader.File))
+	for i, f := range zipReader.File {
 		if err := extractAndWriterFile(f); err != nil {
 			return err
 		}
+		if progress != nil {
+			progress(int64(i+1), total)
+		}
 	}
 
 	return nil
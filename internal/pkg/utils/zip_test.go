@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestZipDirToWriter(t *testing.T) {
+	srcDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(srcDir, "seeds"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(srcDir, "seeds", "a.json"), []byte("seed"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := ZipDirToWriter(srcDir, buf, nil); err != nil {
+		t.Fatalf("ZipDirToWriter failed: %s", err)
+	}
+
+	zipPath := filepath.Join(t.TempDir(), "backup.zip")
+	if err := ioutil.WriteFile(zipPath, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	dstDir := t.TempDir()
+	if err := Unzip(zipPath, dstDir); err != nil {
+		t.Fatalf("Unzip failed: %s", err)
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(dstDir, "seeds", "a.json"))
+	if err != nil {
+		t.Fatalf("read unzipped file failed: %s", err)
+	}
+	if string(content) != "seed" {
+		t.Errorf("unzipped content mismatch: %s", content)
+	}
+}
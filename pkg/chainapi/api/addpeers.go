@@ -15,6 +15,11 @@ import (
 // @Produce json
 // @Param data body handlers.AddPeerParam true "AddPeerParam"
 // @Success 200 {object} handlers.AddPeerResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router /api/v1/network/peers [post]
 func (h *Handler) AddPeers(c echo.Context) (err error) {
 	params := new(handlers.AddPeerParam)
@@ -29,3 +34,44 @@ func (h *Handler) AddPeers(c echo.Context) (err error) {
 
 	return c.JSON(http.StatusOK, result)
 }
+
+// @Tags Node
+// @Summary ListPeers
+// @Description List the connected peers with their connections, protocols and latency
+// @Produce json
+// @Success 200 {object} handlers.ListPeersResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/network/peers [get]
+func (h *Handler) ListPeers(c echo.Context) (err error) {
+	result, err := handlers.ListPeers(h.Node)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Tags Node
+// @Summary DisconnectPeer
+// @Description Close all connections to the peer, the peer may reconnect unless it is blocked
+// @Produce json
+// @Param peer_id path string true "Peer Id"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/network/peers/{peer_id} [delete]
+func (h *Handler) DisconnectPeer(c echo.Context) (err error) {
+	peerId := c.Param("peer_id")
+	if err := handlers.DisconnectPeer(h.Node, peerId); err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"peer_id": peerId})
+}
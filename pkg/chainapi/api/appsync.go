@@ -0,0 +1,99 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rumsystem/quorum/internal/pkg/appdata"
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
+)
+
+// @Tags Apps
+// @Summary GetAppSyncStatus
+// @Description Get the time of the last appsync pass, the error count and the blocks and trxs appsync is behind the chains
+// @Produce json
+// @Success 200 {object} appdata.AppSyncStatus
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/appsync/status [get]
+func (h *Handler) GetAppSyncStatus(c echo.Context) (err error) {
+	res, err := handlers.GetAppSyncStatus(h.AppSync)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// @Tags Apps
+// @Summary TriggerAppSync
+// @Description Run an appsync pass at once instead of waiting for the interval, the status before the pass is returned
+// @Produce json
+// @Success 202 {object} appdata.AppSyncStatus
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/appsync/trigger [post]
+func (h *Handler) TriggerAppSync(c echo.Context) (err error) {
+	res, err := handlers.TriggerAppSync(h.AppSync)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusAccepted, res)
+}
+
+// @Tags Apps
+// @Summary GetAppSyncFilter
+// @Description Get the filter of the groups, trx types and content types synced into the app data
+// @Produce json
+// @Success 200 {object} handlers.AppSyncFilterResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/appsync/filter [get]
+func (h *Handler) GetAppSyncFilter(c echo.Context) (err error) {
+	res, err := handlers.GetAppSyncFilter(h.AppSync)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// @Tags Apps
+// @Summary SetAppSyncFilter
+// @Description Replace the filter of the content synced into the app data, an empty filter syncs all content. The groups whose content changes by the filter are reindexed in the background.
+// @Accept json
+// @Produce json
+// @Param data body appdata.AppSyncFilter true "AppSyncFilter"
+// @Success 200 {object} handlers.AppSyncFilterResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/appsync/filter [put]
+func (h *Handler) SetAppSyncFilter(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(appdata.AppSyncFilter)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	res, err := handlers.SetAppSyncFilter(params, h.AppSync)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
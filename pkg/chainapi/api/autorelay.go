@@ -4,6 +4,8 @@ import (
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
 	handlers "github.com/rumsystem/quorum/pkg/chainapi/handlers"
 )
 
@@ -17,6 +19,11 @@ type AddRelayServersResp struct {
 // @Produce json
 // @Param data body []string true "Peers List"
 // @Success 200 {object} AddRelayServersResp
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router /api/v1/network/relay [post]
 func (h *Handler) AddRelayServers(c echo.Context) (err error) {
 	var input handlers.AddRelayParam
@@ -35,3 +42,51 @@ func (h *Handler) AddRelayServers(c echo.Context) (err error) {
 	resp := AddRelayServersResp{Ok: ok}
 	return c.JSON(http.StatusOK, resp)
 }
+
+// @Tags Node
+// @Summary GetRelayStatus
+// @Description Get the preferred relays and the relays the node holds reservations with
+// @Produce json
+// @Success 200 {object} handlers.RelayStatus
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/network/relay [get]
+func (h *Handler) GetRelayStatus(c echo.Context) (err error) {
+	result, err := handlers.GetRelayStatus(h.Node)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Tags Node
+// @Summary SetPreferredRelays
+// @Description Set the relays tried first for the relay reservation in order, an unreachable relay falls back to the next one, the list is saved to the node options
+// @Accept json
+// @Produce json
+// @Param data body handlers.SetPreferredRelaysParam true "SetPreferredRelaysParam"
+// @Success 200 {object} handlers.RelayStatus
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/network/relay/preferred [post]
+func (h *Handler) SetPreferredRelays(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.SetPreferredRelaysParam)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	result, err := handlers.SetPreferredRelays(h.Node, params)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
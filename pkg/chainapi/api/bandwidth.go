@@ -0,0 +1,37 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
+)
+
+// @Tags Node
+// @Summary GetBandwidth
+// @Description Get the traffic of the node by peer and by protocol since the node started
+// @Produce json
+// @Param view query string false "total for cumulative bytes, rate for bytes per second, default all"
+// @Success 200 {object} handlers.BandwidthResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/network/bandwidth [get]
+func (h *Handler) GetBandwidth(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.GetBandwidthParam)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	result, err := handlers.GetBandwidth(h.Node, params)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
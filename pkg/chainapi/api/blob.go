@@ -0,0 +1,98 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
+)
+
+// @Tags Groups
+// @Summary PostBlobToGroup
+// @Description Save the media in the blob store of the node and post a Blob trx carrying only its hash, the readers fetch the media by the trx. The media of a private group is sealed by the group key, it is refused if the group has no group key
+// @Accept json
+// @Produce json
+// @Param group_id path string  true "Group Id"
+// @Param data body handlers.PostBlobParam true "payload"
+// @Success 200 {object} handlers.PostBlobResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/group/{group_id}/blob [post]
+func (h *Handler) PostBlobToGroup(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	payload := handlers.PostBlobParam{}
+	if err := cc.BindAndValidate(&payload); err != nil {
+		return err
+	}
+
+	res, err := handlers.PostBlobToGroup(&payload)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// @Tags Groups
+// @Summary GetBlob
+// @Description Get a blob from the blob store, verified against the hash. The blob of a private group is sealed, get it by its trx instead
+// @Produce octet-stream
+// @Param hash path string  true "sha256 hex of the blob"
+// @Success 200 {file} file
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/blob/{hash} [get]
+func (h *Handler) GetBlob(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.GetBlobParam)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	data, err := handlers.GetBlob(params)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.Blob(http.StatusOK, http.DetectContentType(data), data)
+}
+
+// @Tags Groups
+// @Summary GetGroupBlob
+// @Description Get the media of a Blob trx of the group from the blob store, verified against the hash of the trx and opened with the group key if the group is private
+// @Produce octet-stream
+// @Param group_id path string  true "Group Id"
+// @Param trx_id path string  true "Trx Id of the Blob post"
+// @Success 200 {file} file
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/group/{group_id}/blob/{trx_id} [get]
+func (h *Handler) GetGroupBlob(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.GetGroupBlobParam)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	data, ref, err := handlers.GetGroupBlob(params)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	contentType := ref.MediaType
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	return c.Blob(http.StatusOK, contentType, data)
+}
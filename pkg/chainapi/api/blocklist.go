@@ -0,0 +1,94 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
+)
+
+// @Tags Group
+// @Summary BlockAuthor
+// @Description Hide the posts of an author from the content, search and feed apis of this node. It is a local view filter only, not a consensus change: nothing is sent to the chain, the trxs of the author are still synced and served to peers, and other nodes are not affected
+// @Accept json
+// @Produce json
+// @Param group_id path string  true "Group Id"
+// @Param data body handlers.BlockAuthorParam true "BlockAuthorParam"
+// @Success 200 {object} appdata.BlockedAuthor
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/group/{group_id}/blocklist [post]
+func (h *Handler) BlockAuthor(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.BlockAuthorParam)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	res, err := handlers.BlockAuthor(params, h.Appdb)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// @Tags Group
+// @Summary UnblockAuthor
+// @Description Remove an author from the local blocklist of the group, the posts of the author are shown again
+// @Accept json
+// @Produce json
+// @Param group_id path string  true "Group Id"
+// @Param data body handlers.UnblockAuthorParam true "UnblockAuthorParam"
+// @Success 200 {object} handlers.BlockedAuthorsResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/group/{group_id}/blocklist [delete]
+func (h *Handler) UnblockAuthor(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.UnblockAuthorParam)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	res, err := handlers.UnblockAuthor(params, h.Appdb)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// @Tags Group
+// @Summary GetBlockedAuthors
+// @Description Get the local blocklist of the group, the authors whose posts are hidden by this node only
+// @Produce json
+// @Param group_id path string  true "Group Id"
+// @Success 200 {object} handlers.BlockedAuthorsResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/group/{group_id}/blocklist [get]
+func (h *Handler) GetBlockedAuthors(c echo.Context) (err error) {
+	groupid := c.Param("group_id")
+	if groupid == "" {
+		return rumerrors.NewBadRequestError(rumerrors.ErrInvalidGroupID)
+	}
+
+	res, err := handlers.GetBlockedAuthors(groupid, h.Appdb)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
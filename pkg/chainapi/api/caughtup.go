@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rumsystem/quorum/internal/pkg/appdata"
+	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
+)
+
+// @Tags Group
+// @Summary GroupCaughtUp
+// @Description Server-Sent Events stream of one caught_up event, sent when the group has applied the highest block seen from peers for a stable interval for the first time, or at once if it was caught up before. The stream is closed after the event
+// @Produce text/event-stream
+// @Param group_id path string  true "Group Id"
+// @Success 200 {object} appdata.CaughtUpEvent
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/group/{group_id}/caughtup [get]
+func (h *Handler) GroupCaughtUp(c echo.Context) (err error) {
+	groupid := c.Param("group_id")
+	if groupid == "" {
+		return rumerrors.NewBadRequestError(rumerrors.ErrInvalidGroupID)
+	}
+	if _, ok := chain.GetGroupMgr().Groups[groupid]; !ok {
+		return rumerrors.NewBadRequestError(fmt.Errorf("Group %s not exist", groupid))
+	}
+
+	// subscribe before checking the saved event, so the event is not missed in between
+	sub := appdata.SubscribeGroupCaughtUp(groupid)
+	defer appdata.UnsubscribeGroupCaughtUp(sub)
+	event, err := h.Appdb.GetGroupCaughtUp(groupid)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	// the stream outlives the server write timeout
+	if err := http.NewResponseController(c.Response().Writer).SetWriteDeadline(time.Time{}); err != nil {
+		streamLogger.Debugf("clear write deadline failed: %s", err)
+	}
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	keepalive := time.NewTicker(streamKeepAlivePeriod)
+	defer keepalive.Stop()
+	for event == nil {
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case <-keepalive.C:
+			if _, err := fmt.Fprint(res, ": keepalive\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		case e, ok := <-sub.C:
+			if !ok {
+				return nil
+			}
+			event = e
+		}
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil
+	}
+	if _, err := fmt.Fprintf(res, "event: caught_up\ndata: %s\n\n", data); err != nil {
+		return nil
+	}
+	res.Flush()
+	return nil
+}
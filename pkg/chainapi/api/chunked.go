@@ -0,0 +1,68 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
+)
+
+// @Tags Groups
+// @Summary PostChunkedToGroup
+// @Description Post an object too large for one post, e.g. an image or a document, as ordered chunk posts. The content api returns it as one item once all chunks are received.
+// @Accept json
+// @Produce json
+// @Param group_id path string  true "Group Id"
+// @Param data body handlers.PostChunkedParam true "payload"
+// @Success 200 {object} handlers.PostChunkedResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/group/{group_id}/content/chunked [post]
+func (h *Handler) PostChunkedToGroup(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	payload := handlers.PostChunkedParam{}
+	if err := cc.BindAndValidate(&payload); err != nil {
+		return err
+	}
+
+	res, err := handlers.PostChunkedToGroup(&payload)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// @Tags Groups
+// @Summary GetChunkedObject
+// @Description Get an object posted in chunks, with the state COMPLETE, INCOMPLETE if some chunks are not received, or INVALID if the chunks do not match the object hash
+// @Produce json
+// @Param group_id path string  true "Group Id"
+// @Param object_id path string  true "Object Id"
+// @Param sender query string  true "sign pubkey of the poster"
+// @Success 200 {object} appdata.ChunkedObject
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/group/{group_id}/object/{object_id} [get]
+func (h *Handler) GetChunkedObject(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.GetChunkedObjectParam)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	res, err := handlers.GetChunkedObject(params, h.Appdb)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
+)
+
+// @Tags Node
+// @Summary GetConnMgr
+// @Description Get the watermarks and grace period of the connection manager
+// @Produce json
+// @Success 200 {object} handlers.ConnMgrResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/network/connmgr [get]
+func (h *Handler) GetConnMgr(c echo.Context) (err error) {
+	result, err := handlers.GetConnMgr(h.Node)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Tags Node
+// @Summary SetConnMgr
+// @Description Change the watermarks and grace period of the connection manager, the conns are trimmed to the low watermark once there are more than the high watermark, the change is lost when the node restarts
+// @Accept json
+// @Produce json
+// @Param data body handlers.SetConnMgrParam true "SetConnMgrParam"
+// @Success 200 {object} handlers.ConnMgrResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/network/connmgr [post]
+func (h *Handler) SetConnMgr(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.SetConnMgrParam)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	result, err := handlers.SetConnMgr(h.Node, params)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+	_ "github.com/rumsystem/quorum/internal/pkg/errors" //import for swaggo
 	"github.com/rumsystem/quorum/internal/pkg/options"
 	"github.com/rumsystem/quorum/internal/pkg/utils"
 	handlers "github.com/rumsystem/quorum/pkg/chainapi/handlers"
@@ -17,6 +18,12 @@ import (
 // @Produce json
 // @Param data body handlers.CreateGroupParam true "GroupInfo"
 // @Success 200 {object} handlers.CreateGroupResult
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} errors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} errors.ErrorResponse "rate limit exceeded"
+// @Failure 500 {object} errors.ErrorResponse
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router /api/v1/group [post]
 func (h *Handler) CreateGroupUrl() echo.HandlerFunc {
 	return func(c echo.Context) error {
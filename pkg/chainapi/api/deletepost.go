@@ -0,0 +1,38 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
+)
+
+// @Tags Groups
+// @Summary DeletePost
+// @Description Delete a post by a tombstone trx, only the author of the post or the group owner can delete it. The post is hidden from the content api, the trx is kept in the chain.
+// @Produce json
+// @Param group_id path string true "Group Id"
+// @Param trx_id path string true "Trx Id of the post"
+// @Success 200 {object} handlers.TrxResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/group/{group_id}/content/{trx_id} [delete]
+func (h *Handler) DeletePost(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.DeletePostParam)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	res, err := handlers.DeletePost(params)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
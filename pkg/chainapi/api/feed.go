@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
+)
+
+// @Tags Group
+// @Summary GroupFeed
+// @Description The latest text posts of the group as an RSS 2.0 or Atom feed, the author is the sender pubkey. The posts of the authors in the local blocklist are left out, a large object posted in chunks is one item at its first chunk.
+// @Produce application/rss+xml
+// @Produce application/atom+xml
+// @Param group_id path string  true "Group Id"
+// @Param params query handlers.GroupFeedParam false "feed format and number of items"
+// @Success 200 {string} string "the feed"
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/group/{group_id}/feed.xml [get]
+func (h *Handler) GroupFeed(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.GroupFeedParam)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	baseUrl := c.Scheme() + "://" + c.Request().Host
+	feed, contentType, err := handlers.GroupFeed(params, h.Appdb, baseUrl)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.Blob(http.StatusOK, contentType, feed)
+}
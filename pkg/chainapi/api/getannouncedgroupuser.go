@@ -14,6 +14,11 @@ import (
 // @Produce json
 // @Param group_id path string true "Group Id"
 // @Success 200 {array} handlers.AnnouncedUserListItem
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router /api/v1/group/{group_id}/announced/users [get]
 func (h *Handler) GetAnnouncedGroupUsers(c echo.Context) error {
 	groupid := c.Param("group_id")
@@ -33,6 +38,11 @@ func (h *Handler) GetAnnouncedGroupUsers(c echo.Context) error {
 // @Param group_id path string true "Group Id"
 // @Param sign_pubkey path string true "User SignPubkey"
 // @Success 200 {object} handlers.AnnouncedUserListItem
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router /api/v1/group/{group_id}/announced/user/{sign_pubkey} [get]
 func (h *Handler) GetAnnouncedGroupUser(c echo.Context) error {
 	groupid := c.Param("group_id")
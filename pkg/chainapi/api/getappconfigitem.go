@@ -15,6 +15,11 @@ import (
 // @Param group_id path string true "Group Id"
 // @Param key path string true "itemKey"
 // @Success 200 {object} handlers.AppConfigKeyItem
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router /api/v1/group/{group_id}/appconfig/{key} [get]
 func (h *Handler) GetAppConfigItem(c echo.Context) (err error) {
 	groupId := c.Param("group_id")
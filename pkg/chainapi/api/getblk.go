@@ -16,9 +16,14 @@ import (
 // @Description Get a block from a group
 // @Produce json
 // @Param group_id path string  true "Group Id"
-// @Param block_id path string  true "Epoch"
+// @Param block_id path integer  true "Block Id"
 // @Success 200 {object} pb.Block
-// @Router /api/v1/block/{group_id}/{epoch} [get]
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/block/{group_id}/{block_id} [get]
 func (h *Handler) GetBlock(c echo.Context) (err error) {
 	groupid := c.Param("group_id")
 	if groupid == "" {
@@ -28,7 +33,7 @@ func (h *Handler) GetBlock(c echo.Context) (err error) {
 	blockIdStr := c.Param("block_id")
 	blockId, err := strconv.ParseUint(blockIdStr, 10, 64)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, err.Error())
+		return rumerrors.NewBadRequestError(err)
 	}
 
 	groupmgr := chain.GetGroupMgr()
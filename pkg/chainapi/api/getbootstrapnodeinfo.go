@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+	_ "github.com/rumsystem/quorum/internal/pkg/errors" //import for swaggo
 	"github.com/rumsystem/quorum/internal/pkg/nodectx"
 )
 
@@ -12,6 +13,10 @@ import (
 // @Description Return the bootstrap node info
 // @Produce json
 // @Success 200 {object} map[string]string
+// @Failure 401 {object} errors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} errors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} errors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router /api/v1/node [get]
 func (h *Handler) GetBootstrapNodeInfo(c echo.Context) (err error) {
 	output := make(map[string]interface{})
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
+)
+
+// @Tags Group
+// @Summary GetGroupContentByRange
+// @Description Get group posts sent and packaged between since and until in chain order, the order of the blocks and the trxs in them, pass next_cursor of the previous page to get the next one. The posts of the authors in the local blocklist are left out, the chunks of a large object are returned once, at the first chunk, as the reassembled object, or of type Chunked with the state if it is not complete.
+// @Produce json
+// @Param group_id path string  true "Group Id"
+// @Param params query handlers.GetGroupCtnRangeParams false "time range and paging params"
+// @Success 200 {object} handlers.GroupCtnPage
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/group/{group_id}/content [get]
+func (h *Handler) GetGroupContentByRange(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.GetGroupCtnRangeParams)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	res, err := handlers.GetGroupContentByRange(params, h.Appdb)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
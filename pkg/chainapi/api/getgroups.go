@@ -31,6 +31,7 @@ type GroupInfo struct {
 	RexSyncerStatus string             `json:"rex_syncer_status" validate:"required" example:"IDLE"`
 	RexSyncerResult *def.RexSyncResult `json:"rex_Syncer_result" validate:"required"`
 	Peers           []peer.ID          `json:"peers" validate:"required" example:"16Uiu2HAkuXLC2hZTRbWToCNztyWB39KDi8g66ou3YrSzeTbsWsFG,16Uiu2HAm8XVpfQrJYaeL7XtrHC3FvfKt2QW7P8R3MBenYyHxu8Kk"`
+	ReadOnly        bool               `json:"read_only" example:"false"`
 }
 
 type GroupInfoList struct {
@@ -51,6 +52,11 @@ func (s *GroupInfoList) Less(i, j int) bool {
 // @Description Get all joined groups
 // @Produce json
 // @Success 200 {object} GroupInfoList
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Failure 500 {object} rumerrors.ErrorResponse
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router /api/v1/groups [get]
 func (h *Handler) GetGroups(c echo.Context) (err error) {
 	var groups []*GroupInfo
@@ -74,6 +80,11 @@ func (h *Handler) GetGroups(c echo.Context) (err error) {
 // @Produce json
 // @Param group_id path string  true "Group Id"
 // @Success 200 {object} GroupInfo
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router /api/v1/group/{group_id} [get]
 func (h *Handler) GetGroupById(c echo.Context) (err error) {
 	groupId := c.Param("group_id")
@@ -125,6 +136,7 @@ func getGroupInfo(groupId string) (*GroupInfo, error) {
 	group.RexSyncerStatus = value.GetRexSyncerStatus()
 	group.RexSyncerResult, _ = value.ChainCtx.GetLastRexSyncResult()
 	group.Peers = nodectx.GetNodeCtx().ListGroupPeers(groupId)
+	group.ReadOnly = value.IsReadOnly()
 
 	return group, nil
 }
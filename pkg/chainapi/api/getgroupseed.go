@@ -17,6 +17,12 @@ import (
 // @Param group_id path string  true "Group Id"
 // @Param include_chain_url query bool false "if include chain url"
 // @Success 200 {object} handlers.GetGroupSeedResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Failure 500 {object} rumerrors.ErrorResponse
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router /api/v1/group/{group_id}/seed [get]
 func (h *Handler) GetGroupSeedHandler(c echo.Context) (err error) {
 	cc := c.(*utils.CustomContext)
@@ -57,3 +63,29 @@ func (h *Handler) GetGroupSeedHandler(c echo.Context) (err error) {
 	}
 	return c.JSON(http.StatusOK, result)
 }
+
+// @Tags Groups
+// @Summary Get compact group seed
+// @Description get the group seed as a compact url safe string for qr codes and links, join the group with it by /api/v2/group/join
+// @Produce json
+// @Param group_id path string  true "Group Id"
+// @Success 200 {object} handlers.GetCompactSeedResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/group/{group_id}/seed/compact [get]
+func (h *Handler) GetCompactSeedHandler(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	var params handlers.GetGroupSeedParam
+	if err := cc.BindAndValidate(&params); err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	res, err := handlers.GetCompactSeed(params.GroupId, h.Appdb)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+	return c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
+	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
+)
+
+// @Tags Node
+// @Summary GetNATStatus
+// @Description Get whether other peers can reach the node, the autonat reachability, the observed and public addrs, and the relay reservations
+// @Produce json
+// @Success 200 {object} handlers.NATStatus
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/network/nat [get]
+func (h *Handler) GetNATStatus(c echo.Context) (err error) {
+	result, err := handlers.GetNATStatus(h.Node)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/rumsystem/quorum/internal/pkg/conn/p2p"
+	_ "github.com/rumsystem/quorum/internal/pkg/errors" //import for swaggo
 	"github.com/rumsystem/quorum/internal/pkg/options"
 	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
 )
@@ -15,6 +16,11 @@ import (
 // @Description Get node's network information
 // @Produce json
 // @Success 200 {object} handlers.NetworkInfo
+// @Failure 401 {object} errors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} errors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} errors.ErrorResponse "rate limit exceeded"
+// @Failure 500 {object} errors.ErrorResponse
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router /api/v1/network [get]
 func (h *Handler) GetNetwork(nodehost *host.Host, nodeinfo *p2p.NodeInfo, nodeopt *options.NodeOptions, ethaddr string) echo.HandlerFunc {
 	return func(c echo.Context) error {
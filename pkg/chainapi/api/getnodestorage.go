@@ -0,0 +1,64 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
+	"github.com/rumsystem/quorum/internal/pkg/nodectx"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
+)
+
+// @Tags Node
+// @Summary GetNodeStorage
+// @Description Return the on-disk size of the node dbs and the key counts of blocks, trxs and contents, refreshed at most once a minute
+// @Produce json
+// @Success 200 {object} handlers.NodeStorageStats
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/node/storage [get]
+func (h *Handler) GetNodeStorage(c echo.Context) (err error) {
+	peers := 0
+	if h.Node != nil && h.Node.Host != nil {
+		peers = len(h.Node.Host.Peerstore().Peers())
+	}
+
+	res, err := handlers.GetNodeStorage(nodectx.GetDbMgr(), h.Appdb, h.NodeCtx.Name, peers)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// @Tags Node
+// @Summary StorageGC
+// @Description Compact the node dbs in which at least free_ratio of the file is free space, and report the reclaimed bytes. Safe to call while the node is serving, each db is paused during its compaction
+// @Accept json
+// @Produce json
+// @Param data body handlers.StorageGCParam true "StorageGCParam"
+// @Success 200 {object} handlers.StorageGCResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/node/storage/gc [post]
+func (h *Handler) StorageGC(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.StorageGCParam)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	res, err := handlers.StorageGC(nodectx.GetDbMgr(), h.Appdb, params)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
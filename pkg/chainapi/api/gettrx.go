@@ -16,6 +16,11 @@ import (
 // @Param group_id path string  true "Group Id"
 // @Param trx_id path string  true "Transaction Id"
 // @Success 200 {object} pb.Trx
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router /api/v1/trx/{group_id}/{trx_id} [get]
 func (h *Handler) GetTrx(c echo.Context) (err error) {
 	cc := c.(*utils.CustomContext)
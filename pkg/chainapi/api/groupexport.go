@@ -0,0 +1,86 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
+)
+
+// @Tags Groups
+// @Summary ExportGroup
+// @Description Export the seed, the keys and the blocks of a group to a gzip compressed archive, the keys are encrypted by the password
+// @Accept json
+// @Produce octet-stream
+// @Param group_id path string true "Group Id"
+// @Param data body handlers.ExportGroupParam true "ExportGroupParam"
+// @Success 200 {file} file
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Failure 500 {object} rumerrors.ErrorResponse
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/group/{group_id}/export [post]
+func (h *Handler) ExportGroup(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.ExportGroupParam)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, echo.MIMEOctetStream)
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%s.rumgroup", params.GroupId))
+	if err := handlers.ExportGroup(params.GroupId, params.Password, h.Appdb, res); err != nil {
+		if res.Committed {
+			// the archive is partially written, abort the response
+			return err
+		}
+		res.Header().Del(echo.HeaderContentDisposition)
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return nil
+}
+
+// @Tags Groups
+// @Summary ImportGroup
+// @Description Join the group of an archive exported by ExportGroup, with the keys and the blocks in the archive
+// @Accept multipart/form-data
+// @Produce json
+// @Param archive formData file true "group archive"
+// @Param password formData string true "password of the archive"
+// @Success 200 {object} handlers.ImportGroupResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/group/import [post]
+func (h *Handler) ImportGroup(c echo.Context) (err error) {
+	password := c.FormValue("password")
+	if password == "" {
+		return rumerrors.NewBadRequestError(errors.New("password is required"))
+	}
+	fh, err := c.FormFile("archive")
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+	defer f.Close()
+
+	res, err := handlers.ImportGroup(f, password, h.Appdb)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
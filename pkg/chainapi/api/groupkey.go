@@ -0,0 +1,94 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
+)
+
+// @Tags Management
+// @Summary GrantGroupKey
+// @Description Owner of a private group issues a share of the group key to a user, the first grant creates the group key and the posts are sealed with it from then on
+// @Accept json
+// @Produce json
+// @Param group_id path string  true "Group Id"
+// @Param data body handlers.GroupKeyMemberParam true "GroupKeyMemberParam"
+// @Success 200 {object} handlers.GroupKeyResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/group/{group_id}/groupkey/grant [post]
+func (h *Handler) GrantGroupKey(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.GroupKeyMemberParam)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	res, err := handlers.GrantGroupKey(params)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// @Tags Management
+// @Summary RevokeGroupKey
+// @Description Owner of a private group rotates the group key without the user, the user can't read the posts sealed from then on. Remove the user by the user api too to stop it from posting
+// @Accept json
+// @Produce json
+// @Param group_id path string  true "Group Id"
+// @Param data body handlers.GroupKeyMemberParam true "GroupKeyMemberParam"
+// @Success 200 {object} handlers.GroupKeyResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/group/{group_id}/groupkey/revoke [post]
+func (h *Handler) RevokeGroupKey(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.GroupKeyMemberParam)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	res, err := handlers.RevokeGroupKey(params)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// @Tags Management
+// @Summary GetGroupKeyStatus
+// @Description Get the current version of the group key of a private group, its members and whether this node holds it
+// @Produce json
+// @Param group_id path string  true "Group Id"
+// @Success 200 {object} handlers.GroupKeyStatus
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/group/{group_id}/groupkey [get]
+func (h *Handler) GetGroupKeyStatus(c echo.Context) (err error) {
+	groupid := c.Param("group_id")
+	if groupid == "" {
+		return rumerrors.NewBadRequestError(rumerrors.ErrInvalidGroupID)
+	}
+
+	res, err := handlers.GetGroupKeyStatus(groupid)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
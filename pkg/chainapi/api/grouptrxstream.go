@@ -0,0 +1,172 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rumsystem/quorum/internal/pkg/appdata"
+	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
+	"github.com/rumsystem/quorum/internal/pkg/logging"
+	"github.com/rumsystem/quorum/internal/pkg/storage/def"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+var streamLogger = logging.Logger("trxstream")
+
+const (
+	streamReplayPageSize  = 100
+	streamKeepAlivePeriod = 15 * time.Second
+)
+
+// @Tags Group
+// @Summary GroupTrxStream
+// @Description Server-Sent Events stream of the content trxs applied to the group, pass since=<trx_id> to resume after a disconnect. The chunks of a large object are sent once, at the first chunk, as the reassembled object
+// @Produce text/event-stream
+// @Param group_id path string  true "Group Id"
+// @Param params query handlers.GroupTrxStreamParams false "stream params"
+// @Success 200 {object} quorumpb.Trx
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/group/{group_id}/stream [get]
+func (h *Handler) GroupTrxStream(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.GroupTrxStreamParams)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	if params.Since == "" {
+		// set by EventSource when it reconnects
+		params.Since = c.Request().Header.Get("Last-Event-ID")
+	}
+
+	group, ok := chain.GetGroupMgr().Groups[params.GroupId]
+	if !ok {
+		return rumerrors.NewBadRequestError(fmt.Errorf("Group %s not exist", params.GroupId))
+	}
+
+	// subscribe before replay, trxs indexed in between are deduplicated below
+	sub := appdata.SubscribeGroupTrx(params.GroupId)
+	defer appdata.UnsubscribeGroupTrx(sub)
+
+	// the stream outlives the server write timeout
+	if err := http.NewResponseController(c.Response().Writer).SetWriteDeadline(time.Time{}); err != nil {
+		streamLogger.Debugf("clear write deadline failed: %s", err)
+	}
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	replayed, err := h.replayGroupTrxs(params.GroupId, params.Since, func(trx *quorumpb.Trx) error {
+		return h.writeTrxEvent(res, group.Item, trx)
+	})
+	if err != nil {
+		streamLogger.Errorf("<%s> replay from %s failed: %s", params.GroupId, params.Since, err)
+		return nil
+	}
+
+	keepalive := time.NewTicker(streamKeepAlivePeriod)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case <-keepalive.C:
+			if _, err := fmt.Fprint(res, ": keepalive\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		case trx, ok := <-sub.C:
+			if !ok {
+				// dropped for being too slow, the client resumes with the last event id
+				return nil
+			}
+			if replayed[trx.TrxId] {
+				continue
+			}
+			if err := h.writeTrxEvent(res, group.Item, trx); err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+// replayGroupTrxs sends the content trxs after since in order, it returns the ids of the sent trxs.
+// An error of send stops the replay and is returned as is
+func (h *Handler) replayGroupTrxs(groupId string, since string, send func(trx *quorumpb.Trx) error) (map[string]bool, error) {
+	replayed := map[string]bool{}
+	start := since
+	for start != "" {
+		trxids, err := h.Appdb.GetGroupContentBySenders(groupId, nil, start, streamReplayPageSize, false, false)
+		if err != nil {
+			return replayed, err
+		}
+		for _, trxid := range trxids {
+			trx, err := h.NodeCtx.GetChainStorage().GetTrx(groupId, trxid, def.Chain, h.NodeCtx.Name)
+			if err != nil {
+				streamLogger.Warningf("<%s> get trx %s failed: %s", groupId, trxid, err)
+				continue
+			}
+			if err := send(trx); err != nil {
+				return replayed, err
+			}
+			replayed[trxid] = true
+		}
+		if len(trxids) < streamReplayPageSize {
+			break
+		}
+		start = trxids[len(trxids)-1]
+	}
+	return replayed, nil
+}
+
+// streamTrx returns a copy of the trx with the decrypted data, the data is dropped if it can't be decrypted.
+// The chunks of an object are streamed once, at the first chunk, as the reassembled object, nil is returned for the others
+func (h *Handler) streamTrx(groupitem *quorumpb.GroupItem, trx *quorumpb.Trx) *quorumpb.Trx {
+	trx = proto.Clone(trx).(*quorumpb.Trx)
+	if err := appdata.DecryptPostTrx(groupitem, trx); err != nil {
+		streamLogger.Warningf("<%s> decrypt trx %s failed: %s", groupitem.GroupId, trx.TrxId, err)
+		trx.Data = nil
+		return trx
+	}
+	data, ok, err := h.Appdb.ChunkContent(h.NodeCtx.GetChainStorage(), h.NodeCtx.Name, groupitem, trx.Data, trx.SenderPubkey)
+	if err != nil {
+		streamLogger.Warningf("<%s> get chunked object of trx %s failed: %s", groupitem.GroupId, trx.TrxId, err)
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+	trx.Data = data
+	return trx
+}
+
+// writeTrxEvent writes the trx as an event, the hidden chunks are skipped
+func (h *Handler) writeTrxEvent(res *echo.Response, groupitem *quorumpb.GroupItem, trx *quorumpb.Trx) error {
+	trx = h.streamTrx(groupitem, trx)
+	if trx == nil {
+		return nil
+	}
+	data, err := json.Marshal(trx)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(res, "id: %s\nevent: trx\ndata: %s\n\n", trx.TrxId, data); err != nil {
+		return err
+	}
+	res.Flush()
+	return nil
+}
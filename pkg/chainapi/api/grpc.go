@@ -0,0 +1,248 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"net"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/rumsystem/ip-cert/pkg/zerossl"
+	"github.com/rumsystem/quorum/internal/pkg/appdata"
+	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
+	rummiddleware "github.com/rumsystem/quorum/internal/pkg/middleware"
+	"github.com/rumsystem/quorum/internal/pkg/options"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	"github.com/rumsystem/quorum/pkg/chainapi/grpcapi"
+	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+	"golang.org/x/crypto/acme/autocert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	grpcServer      *grpc.Server
+	grpcRateLimiter *rummiddleware.RateLimiter
+)
+
+// GRPCServerParam is the listen address and the tls certificate of the grpc api
+type GRPCServerParam struct {
+	Listen   string
+	CertFile string // the certificate of the api host is used if not set, as the http api
+	KeyFile  string
+}
+
+// grpcApi is the grpc api of the full node, it calls the same handlers as the http api
+type grpcApi struct {
+	quorumpb.UnimplementedQuorumApiServer
+	h       *Handler
+	archive bool // the app data is not synced, the content can't be streamed
+}
+
+// StartGRPCServer serves the grpc api on the listen address, the calls are authorized by the chain jwts
+// and rate limited by the limits of the http api
+func StartGRPCServer(param GRPCServerParam, config StartServerParam, h *Handler, nodeopt *options.NodeOptions) error {
+	tlsConfig, err := grpcTLSConfig(param, config)
+	if err != nil {
+		return err
+	}
+	lis, err := net.Listen("tcp", param.Listen)
+	if err != nil {
+		return err
+	}
+
+	limiter := rummiddleware.NewRateLimiter(newRateLimitConfig(nodeopt, ""))
+	s := grpcapi.NewServer(&grpcApi{h: h, archive: config.Archive}, grpcapi.Config{
+		JWTKey:      nodeopt.JWT.Key,
+		RateLimiter: limiter,
+		TLS:         tlsConfig,
+	})
+
+	apiServerMu.Lock()
+	grpcServer = s
+	grpcRateLimiter = limiter
+	apiServerMu.Unlock()
+
+	go func() {
+		serverLogger.Infof("grpc api listening on %s, tls: %t", lis.Addr(), tlsConfig != nil)
+		if err := s.Serve(lis); err != nil {
+			serverLogger.Errorf("grpc api stopped: %s", err)
+		}
+	}()
+	return nil
+}
+
+// grpcTLSConfig returns the tls config of the certificate files, or of the api host the same as the http api.
+// It is nil for a private api host, the grpc api is served in plaintext then.
+func grpcTLSConfig(param GRPCServerParam, config StartServerParam) (*tls.Config, error) {
+	if param.CertFile != "" || param.KeyFile != "" {
+		return grpcapi.LoadTLSConfig(param.CertFile, param.KeyFile)
+	}
+
+	host := config.APIHost
+	if utils.IsDomainName(host) {
+		m := &autocert.Manager{
+			Cache:      autocert.DirCache(config.CertDir),
+			HostPolicy: autocert.HostWhitelist(host),
+			Prompt:     autocert.AcceptTOS,
+		}
+		return m.TLSConfig(), nil
+	} else if utils.IsPublicIP(host) {
+		privKeyPath, certPath, err := zerossl.IssueIPCert(config.CertDir, net.ParseIP(host), config.ZeroAccessKey)
+		if err != nil {
+			return nil, err
+		}
+		return grpcapi.LoadTLSConfig(certPath, privKeyPath)
+	}
+	return nil, nil
+}
+
+// ShutdownGRPCServer stops the grpc api, the streams are closed
+func ShutdownGRPCServer() {
+	apiServerMu.Lock()
+	s := grpcServer
+	grpcServer = nil
+	grpcRateLimiter = nil
+	apiServerMu.Unlock()
+
+	if s != nil {
+		s.Stop()
+	}
+}
+
+func (s *grpcApi) GetNodeInfo(ctx context.Context, req *quorumpb.NodeInfoRequest) (*quorumpb.NodeInfoResponse, error) {
+	info, err := handlers.GetNodeInfo(s.h.Node.NetworkName)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &quorumpb.NodeInfoResponse{
+		NodeId:        info.NodeID,
+		NodePublickey: info.NodePublickey,
+		NodeStatus:    info.NodeStatus,
+		NodeType:      info.NodeType,
+		NodeVersion:   info.NodeVersion,
+	}, nil
+}
+
+func (s *grpcApi) GetGroupStatus(ctx context.Context, req *quorumpb.GroupStatusRequest) (*quorumpb.GroupStatusResponse, error) {
+	res, err := handlers.GetGroupStatus(req.GroupId)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return &quorumpb.GroupStatusResponse{
+		GroupId:       res.GroupId,
+		SyncStatus:    res.SyncStatus,
+		Epoch:         res.Epoch,
+		BlockId:       res.BlockId,
+		PendingTrx:    int64(res.PendingTrx),
+		QueuePosition: int64(res.QueuePos),
+		ReadOnly:      res.ReadOnly,
+	}, nil
+}
+
+func (s *grpcApi) CreateGroup(ctx context.Context, req *quorumpb.CreateGroupRequest) (*quorumpb.CreateGroupResponse, error) {
+	params := &handlers.CreateGroupParam{
+		GroupName:      req.GroupName,
+		ConsensusType:  req.ConsensusType,
+		EncryptionType: req.EncryptionType,
+		AppKey:         req.AppKey,
+		KeyAlias:       req.KeyAlias,
+		Mnemonic:       req.Mnemonic,
+		DerivationPath: req.DerivationPath,
+	}
+	if err := validator.New().Struct(params); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	res, err := handlers.CreateGroupUrl("", params, options.GetNodeOptions(), s.h.Appdb)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &quorumpb.CreateGroupResponse{Seed: res.Seed, GroupId: res.GroupId}, nil
+}
+
+func (s *grpcApi) JoinGroup(ctx context.Context, req *quorumpb.JoinGroupRequest) (*quorumpb.JoinGroupResponse, error) {
+	params := &handlers.JoinGroupParamV2{Seed: req.Seed, KeyAlias: req.KeyAlias, ReadOnly: req.ReadOnly}
+	if err := validator.New().Struct(params); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	res, err := JoinGroup(params, s.h.Appdb)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &quorumpb.JoinGroupResponse{
+		GroupId:           res.GroupId,
+		GroupName:         res.GroupName,
+		OwnerPubkey:       res.OwnerPubkey,
+		UserPubkey:        res.UserPubkey,
+		UserEncryptPubkey: res.UserEncryptPubkey,
+		ConsensusType:     res.ConsensusType,
+		EncryptionType:    res.EncryptionType,
+		CipherKey:         res.CipherKey,
+		AppKey:            res.AppKey,
+		Signature:         res.Signature,
+		ReadOnly:          res.ReadOnly,
+	}, nil
+}
+
+func (s *grpcApi) PostContent(ctx context.Context, req *quorumpb.PostContentRequest) (*quorumpb.PostContentResponse, error) {
+	params := &handlers.PostToGroupParam{GroupId: req.GroupId, TTL: req.TTL}
+	if err := json.Unmarshal([]byte(req.Data), &params.Data); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "data should be a json object: %s", err)
+	}
+	if err := validator.New().Struct(params); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	res, err := handlers.PostToGroup(params)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return &quorumpb.PostContentResponse{TrxId: res.TrxId}, nil
+}
+
+// StreamContent is the grpc version of GroupTrxStream
+func (s *grpcApi) StreamContent(req *quorumpb.StreamContentRequest, stream quorumpb.QuorumApi_StreamContentServer) error {
+	if s.archive {
+		return status.Error(codes.Unavailable, "content is not available in archive mode")
+	}
+	group, ok := chain.GetGroupMgr().Groups[req.GroupId]
+	if !ok {
+		return status.Errorf(codes.NotFound, "Group %s not exist", req.GroupId)
+	}
+
+	// subscribe before replay, trxs indexed in between are deduplicated below
+	sub := appdata.SubscribeGroupTrx(req.GroupId)
+	defer appdata.UnsubscribeGroupTrx(sub)
+
+	replayed, err := s.h.replayGroupTrxs(req.GroupId, req.Since, func(trx *quorumpb.Trx) error {
+		if trx = s.h.streamTrx(group.Item, trx); trx == nil {
+			return nil
+		}
+		return stream.Send(trx)
+	})
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case trx, ok := <-sub.C:
+			if !ok {
+				// dropped for being too slow, the client resumes with the last trx id
+				return status.Error(codes.ResourceExhausted, "stream is too slow")
+			}
+			if replayed[trx.TrxId] {
+				continue
+			}
+			if trx = s.h.streamTrx(group.Item, trx); trx == nil {
+				continue
+			}
+			if err := stream.Send(trx); err != nil {
+				return err
+			}
+		}
+	}
+}
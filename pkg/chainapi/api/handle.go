@@ -18,5 +18,6 @@ type (
 		Appdb            *appdata.AppDb
 		ChainAPIdb       def.APIHandlerIface
 		WebsocketManager *WebsocketManager
+		AppSync          *appdata.AppSync
 	}
 )
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/labstack/echo/v4"
+	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
+	"github.com/rumsystem/quorum/internal/pkg/nodectx"
+	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
+)
+
+// notReady is set on shutdown, so that the load balancers stop routing to the node
+var notReady atomic.Bool
+
+func SetNotReady() {
+	notReady.Store(true)
+}
+
+// @Tags Node
+// @Summary Healthz
+// @Description Liveness probe, 200 if the process is alive and the db is open, 503 otherwise. No auth required.
+// @Produce plain
+// @Success 200 {string} string "ok"
+// @Failure 503 {string} string
+// @Router /healthz [get]
+func (h *Handler) Healthz(c echo.Context) error {
+	if err := handlers.CheckHealth(nodectx.GetDbMgr()); err != nil {
+		return c.String(http.StatusServiceUnavailable, err.Error())
+	}
+	return c.String(http.StatusOK, "ok")
+}
+
+// @Tags Node
+// @Summary Readyz
+// @Description Readiness probe, 200 if the node is listening, has connected peers and the groups are loaded, 503 otherwise or on shutdown. No auth required.
+// @Produce plain
+// @Success 200 {string} string "ok"
+// @Failure 503 {string} string
+// @Router /readyz [get]
+func (h *Handler) Readyz(c echo.Context) error {
+	if notReady.Load() {
+		return c.String(http.StatusServiceUnavailable, "shutting down")
+	}
+	if err := handlers.CheckReady(h.Node, chain.GetGroupMgr()); err != nil {
+		return c.String(http.StatusServiceUnavailable, err.Error())
+	}
+	return c.String(http.StatusOK, "ok")
+}
@@ -0,0 +1,31 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
+	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
+)
+
+// @Tags Node
+// @Summary GetNodeIdentity
+// @Description Get the peer id, eth address, public key, and the listen, announced and observed addrs of the node
+// @Produce json
+// @Success 200 {object} handlers.NodeIdentity
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/node/identity [get]
+func (h *Handler) GetNodeIdentity(ethaddr string) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		result, err := handlers.GetNodeIdentity(h.Node, ethaddr)
+		if err != nil {
+			return rumerrors.NewBadRequestError(err)
+		}
+
+		return c.JSON(http.StatusOK, result)
+	}
+}
@@ -5,11 +5,13 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/labstack/echo/v4"
+	"github.com/rumsystem/quorum/internal/pkg/appdata"
 	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
 	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
 	"github.com/rumsystem/quorum/internal/pkg/nodectx"
@@ -33,15 +35,21 @@ type JoinGroupResult struct {
 	CipherKey         string `json:"cipher_key" validate:"required" example:"076a3cee50f3951744fbe6d973a853171139689fb48554b89f7765c0c6cbf15a"`
 	AppKey            string `json:"app_key" validate:"required" example:"test_app"`
 	Signature         string `json:"signature" validate:"required" example:"3045022100a819a627237e0bb0de1e69e3b29119efbf8677173f7e4d3a20830fc366c5bfd702200ad71e34b53da3ac5bcf3f8a46f1964b058ef36c2687d3b8effe4baec2acd2a6"`
+	ReadOnly          bool   `json:"read_only" example:"false"`
 }
 
 // @Tags Groups
 // @Summary JoinGroup
-// @Description Join a group
+// @Description Join a group by the seed url or the compact seed
 // @Accept json
 // @Produce json
 // @Param data body handlers.JoinGroupParamV2 true "JoinGroupParamV2"
 // @Success 200 {object} JoinGroupResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router /api/v2/group/join [post]
 func (h *Handler) JoinGroupV2() echo.HandlerFunc {
 	return func(c echo.Context) error {
@@ -51,183 +59,232 @@ func (h *Handler) JoinGroupV2() echo.HandlerFunc {
 		if err := cc.BindAndValidate(payload); err != nil {
 			return rumerrors.NewBadRequestError(err)
 		}
-		seed, _, err := handlers.UrlToGroupSeed(payload.Seed)
+		joinGrpResult, err := JoinGroup(payload, h.Appdb)
 		if err != nil {
 			return rumerrors.NewBadRequestError(err)
 		}
-		genesisBlockBytes, err := json.Marshal(seed.GenesisBlock)
-		if err != nil {
-			msg := fmt.Sprintf("unmarshal genesis block failed with msg: %s" + err.Error())
-			return rumerrors.NewBadRequestError(msg)
-		}
+		return c.JSON(http.StatusOK, joinGrpResult)
+	}
+}
 
-		//TBD check if group already exist
-		groupmgr := chain.GetGroupMgr()
-		if _, ok := groupmgr.Groups[seed.GroupId]; ok {
-			msg := fmt.Sprintf("group with group_id <%s> already exist", seed.GroupId)
-			return rumerrors.NewBadRequestError(msg)
-		}
+// JoinGroup joins the group of the seed url or the compact seed and starts syncing it
+func JoinGroup(payload *handlers.JoinGroupParamV2, appdb *appdata.AppDb) (*JoinGroupResult, error) {
+	seed, urls, err := handlers.ParseGroupSeed(payload.Seed)
+	if err != nil {
+		return nil, err
+	}
+	genesisBlockBytes, err := json.Marshal(seed.GenesisBlock)
+	if err != nil {
+		msg := fmt.Sprintf("unmarshal genesis block failed with msg: %s" + err.Error())
+		return nil, errors.New(msg)
+	}
 
-		nodeoptions := options.GetNodeOptions()
-
-		var groupSignPubkey []byte
-		ks := nodectx.GetNodeCtx().Keystore
-		dirks, ok := ks.(*localcrypto.DirKeyStore)
-		if ok {
-			base64key, err := dirks.GetEncodedPubkey(seed.GenesisBlock.GroupId, localcrypto.Sign)
-			if err != nil && strings.HasPrefix(err.Error(), "key not exist") {
-				newsignaddr, err := dirks.NewKeyWithDefaultPassword(seed.GenesisBlock.GroupId, localcrypto.Sign)
-				if err == nil && newsignaddr != "" {
-					_, _ = dirks.NewKeyWithDefaultPassword(seed.GenesisBlock.GroupId, localcrypto.Encrypt)
-					err = nodeoptions.SetSignKeyMap(seed.GenesisBlock.GroupId, newsignaddr)
-					if err != nil {
-						msg := fmt.Sprintf("save key map %s err: %s", newsignaddr, err.Error())
-						return rumerrors.NewBadRequestError(msg)
-					}
-					base64key, _ = dirks.GetEncodedPubkey(seed.GenesisBlock.GroupId, localcrypto.Sign)
-				} else {
-					_, err := dirks.GetKeyFromUnlocked(localcrypto.Sign.NameString(seed.GenesisBlock.GroupId))
-					if err != nil {
-						msg := "create new group key err:" + err.Error()
-						return rumerrors.NewBadRequestError(msg)
-					}
-					base64key, _ = dirks.GetEncodedPubkey(seed.GenesisBlock.GroupId, localcrypto.Sign)
-				}
-			}
-			groupSignPubkey, err = base64.RawURLEncoding.DecodeString(base64key)
-			if err != nil {
-				msg := "group key can't be decoded, err:" + err.Error()
-				return rumerrors.NewBadRequestError(msg)
-			}
-		} else {
-			msg := fmt.Sprintf("unknown keystore type  %v:", ks)
-			return rumerrors.NewBadRequestError(msg)
-		}
+	//TBD check if group already exist
+	groupmgr := chain.GetGroupMgr()
+	if _, ok := groupmgr.Groups[seed.GroupId]; ok {
+		msg := fmt.Sprintf("group with group_id <%s> already exist", seed.GroupId)
+		return nil, errors.New(msg)
+	}
 
-		ownerPubkeyBytes, err := base64.RawURLEncoding.DecodeString(seed.GenesisBlock.ProducerPubkey)
-		if err != nil {
-			msg := "Decode OwnerPubkey failed: " + err.Error()
-			return rumerrors.NewBadRequestError(msg)
-		}
+	nodeoptions := options.GetNodeOptions()
 
-		groupEncryptkey, err := dirks.GetEncodedPubkey(seed.GenesisBlock.GroupId, localcrypto.Encrypt)
-		if err != nil {
-			if strings.HasPrefix(err.Error(), "key not exist") {
-				_, _ = dirks.NewKeyWithDefaultPassword(seed.GenesisBlock.GroupId, localcrypto.Encrypt)
-				_, err := dirks.GetKeyFromUnlocked(localcrypto.Encrypt.NameString(seed.GenesisBlock.GroupId))
-				if err != nil {
-					msg := "Create key pair failed with msg:" + err.Error()
-					return rumerrors.NewBadRequestError(msg)
-				}
-				groupEncryptkey, _ = dirks.GetEncodedPubkey(seed.GenesisBlock.GroupId, localcrypto.Encrypt)
-			} else {
-				msg := "Create key pair failed with msg:" + err.Error()
-				return rumerrors.NewBadRequestError(msg)
-			}
-		}
+	ownerPubkeyBytes, err := base64.RawURLEncoding.DecodeString(seed.GenesisBlock.ProducerPubkey)
+	if err != nil {
+		msg := "Decode OwnerPubkey failed: " + err.Error()
+		return nil, errors.New(msg)
+	}
 
-		r, err := rumchaindata.ValidGenesisBlock(seed.GenesisBlock)
-		if err != nil {
-			return rumerrors.NewBadRequestError(err)
-		}
+	r, err := rumchaindata.ValidGenesisBlock(seed.GenesisBlock)
+	if err != nil {
+		return nil, err
+	}
 
-		if !r {
-			msg := "Join Group failed, verify genesis block failed"
-			return rumerrors.NewBadRequestError(msg)
-		}
+	if !r {
+		msg := "Join Group failed, verify genesis block failed"
+		return nil, errors.New(msg)
+	}
 
-		item := &quorumpb.GroupItem{}
+	ks := nodectx.GetNodeCtx().Keystore
+	var groupSignPubkey []byte
+	var groupEncryptkey string
+	if payload.ReadOnly {
+		groupSignPubkey, err = readOnlyGroupKey(seed, payload.KeyAlias)
+	} else {
+		groupSignPubkey, groupEncryptkey, err = newGroupKeys(seed.GenesisBlock.GroupId, payload.KeyAlias, ks, nodeoptions)
+	}
+	if err != nil {
+		return nil, err
+	}
 
-		//item.OwnerPubKey = seed.GenesisBlock.ProducerPubKey
-		item.OwnerPubKey = seed.OwnerPubkey
-		item.GroupId = seed.GenesisBlock.GroupId
-		item.GroupName = seed.GroupName
-		item.CipherKey = seed.CipherKey
-		item.AppKey = seed.AppKey
+	item := &quorumpb.GroupItem{}
 
-		if seed.ConsensusType == "poa" {
-			item.ConsenseType = quorumpb.GroupConsenseType_POA
-		} else if seed.ConsensusType == "pos" {
-			item.ConsenseType = quorumpb.GroupConsenseType_POS
-		}
+	//item.OwnerPubKey = seed.GenesisBlock.ProducerPubKey
+	item.OwnerPubKey = seed.OwnerPubkey
+	item.GroupId = seed.GenesisBlock.GroupId
+	item.GroupName = seed.GroupName
+	item.CipherKey = seed.CipherKey
+	item.AppKey = seed.AppKey
 
-		item.UserSignPubkey = base64.RawURLEncoding.EncodeToString(groupSignPubkey)
+	if seed.ConsensusType == "poa" {
+		item.ConsenseType = quorumpb.GroupConsenseType_POA
+	} else if seed.ConsensusType == "pos" {
+		item.ConsenseType = quorumpb.GroupConsenseType_POS
+	}
 
-		userEncryptKey, err := dirks.GetEncodedPubkey(seed.GenesisBlock.GroupId, localcrypto.Encrypt)
-		if err != nil {
-			if strings.HasPrefix(err.Error(), "key not exist") {
-				userEncryptKey, err = dirks.NewKeyWithDefaultPassword(seed.GenesisBlock.GroupId, localcrypto.Encrypt)
-				if err != nil {
-					msg := "Create key pair failed with msg:" + err.Error()
-					return rumerrors.NewBadRequestError(msg)
-				}
-			} else {
-				msg := "Create key pair failed with msg:" + err.Error()
-				return rumerrors.NewBadRequestError(msg)
-			}
-		}
+	item.UserSignPubkey = base64.RawURLEncoding.EncodeToString(groupSignPubkey)
 
-		item.UserEncryptPubkey = userEncryptKey
-		if seed.EncryptionType == "public" {
-			item.EncryptType = quorumpb.GroupEncryptType_PUBLIC
-		} else {
-			item.EncryptType = quorumpb.GroupEncryptType_PRIVATE
-		}
+	item.UserEncryptPubkey = groupEncryptkey
+	if seed.EncryptionType == "public" {
+		item.EncryptType = quorumpb.GroupEncryptType_PUBLIC
+	} else {
+		item.EncryptType = quorumpb.GroupEncryptType_PRIVATE
+	}
 
-		item.LastUpdate = seed.GenesisBlock.TimeStamp
-		item.GenesisBlock = seed.GenesisBlock
+	item.LastUpdate = seed.GenesisBlock.TimeStamp
+	item.GenesisBlock = seed.GenesisBlock
 
-		//create the group
-		group := &chain.Group{}
+	//create the group
+	group := &chain.Group{}
+	if payload.ReadOnly {
+		err = group.NewReadOnlyGroup(item)
+	} else {
 		err = group.NewGroup(item)
+	}
 
-		if err != nil {
-			return rumerrors.NewBadRequestError(err)
-		}
+	if err != nil {
+		return nil, err
+	}
 
-		//start sync
-		err = group.StartSync(false)
-		if err != nil {
-			return rumerrors.NewBadRequestError(err)
+	//fast-forward by the snapshot served by the chain api of the seed, the group syncs from genesis without it
+	fastForwardBySnapshot(group, urls)
+
+	//add group to context
+	groupmgr.Groups[group.Item.GroupId] = group
+
+	//start sync, it waits in the sync queue if too many groups are syncing
+	err = groupmgr.EnqueueSync(group)
+	if err != nil {
+		return nil, err
+	}
+
+	var bufferResult bytes.Buffer
+	bufferResult.Write(genesisBlockBytes)
+	bufferResult.Write([]byte(item.GroupId))
+	bufferResult.Write([]byte(item.GroupName))
+	bufferResult.Write(ownerPubkeyBytes)
+	bufferResult.Write(groupSignPubkey)
+	bufferResult.Write([]byte(groupEncryptkey))
+	bufferResult.Write([]byte(item.CipherKey))
+	hashResult := localcrypto.Hash(bufferResult.Bytes())
+	signature, _ := ks.EthSignByKeyName(item.GroupId, hashResult)
+	encodedSign := hex.EncodeToString(signature)
+
+	joinGrpResult := &JoinGroupResult{
+		GroupId:           item.GroupId,
+		GroupName:         item.GroupName,
+		OwnerPubkey:       item.OwnerPubKey,
+		ConsensusType:     seed.ConsensusType,
+		EncryptionType:    seed.EncryptionType,
+		UserPubkey:        item.UserSignPubkey,
+		UserEncryptPubkey: groupEncryptkey,
+		CipherKey:         item.CipherKey,
+		AppKey:            item.AppKey,
+		Signature:         encodedSign,
+		ReadOnly:          payload.ReadOnly,
+	}
+
+	// save group seed to appdata
+	pbGroupSeed := handlers.ToPbGroupSeed(*seed)
+	if err := appdb.SetGroupSeed(&pbGroupSeed); err != nil {
+		return nil, fmt.Errorf("save group seed failed: %s", err)
+	}
+
+	return joinGrpResult, nil
+}
+
+// fastForwardBySnapshot fetch and apply the snapshot of the group, the snapshot must be signed by the owner of the seed
+func fastForwardBySnapshot(group *chain.Group, urls []string) {
+	if len(urls) == 0 || group.ChainCtx.Consensus == nil || group.ChainCtx.Consensus.SnapshotReceiver() == nil {
+		return
+	}
+	snapshot, err := handlers.FetchSnapshot(group.Item.GroupId, urls)
+	if err != nil {
+		serverLogger.Debugf("<%s> no snapshot to fast-forward: %s", group.Item.GroupId, err)
+		return
+	}
+	if err := group.ChainCtx.Consensus.SnapshotReceiver().ApplySnapshot(snapshot); err != nil {
+		serverLogger.Warnf("<%s> apply snapshot failed: %s", group.Item.GroupId, err)
+	}
+}
+
+// newGroupKeys returns the sign pubkey and the encrypt pubkey of the group, the keys are created if not exist
+func newGroupKeys(groupId string, keyAlias string, ks localcrypto.Keystore, nodeoptions *options.NodeOptions) ([]byte, string, error) {
+	if keyAlias != "" {
+		if err := handlers.BindGroupKey(groupId, keyAlias, ks, nodeoptions); err != nil {
+			return nil, "", err
 		}
+	}
+	dirks, ok := ks.(*localcrypto.DirKeyStore)
+	if !ok {
+		return nil, "", fmt.Errorf("unknown keystore type  %v:", ks)
+	}
 
-		//add group to context
-		groupmgr.Groups[group.Item.GroupId] = group
-
-		var bufferResult bytes.Buffer
-		bufferResult.Write(genesisBlockBytes)
-		bufferResult.Write([]byte(item.GroupId))
-		bufferResult.Write([]byte(item.GroupName))
-		bufferResult.Write(ownerPubkeyBytes)
-		bufferResult.Write(groupSignPubkey)
-		bufferResult.Write([]byte(groupEncryptkey))
-		bufferResult.Write([]byte(item.CipherKey))
-		hashResult := localcrypto.Hash(bufferResult.Bytes())
-		signature, _ := ks.EthSignByKeyName(item.GroupId, hashResult)
-		encodedSign := hex.EncodeToString(signature)
-
-		joinGrpResult := &JoinGroupResult{
-			GroupId:           item.GroupId,
-			GroupName:         item.GroupName,
-			OwnerPubkey:       item.OwnerPubKey,
-			ConsensusType:     seed.ConsensusType,
-			EncryptionType:    seed.EncryptionType,
-			UserPubkey:        item.UserSignPubkey,
-			UserEncryptPubkey: groupEncryptkey,
-			CipherKey:         item.CipherKey,
-			AppKey:            item.AppKey,
-			Signature:         encodedSign,
+	base64key, err := dirks.GetEncodedPubkey(groupId, localcrypto.Sign)
+	if err != nil && strings.HasPrefix(err.Error(), "key not exist") {
+		newsignaddr, err := dirks.NewKeyWithDefaultPassword(groupId, localcrypto.Sign)
+		if err == nil && newsignaddr != "" {
+			_, _ = dirks.NewKeyWithDefaultPassword(groupId, localcrypto.Encrypt)
+			err = nodeoptions.SetSignKeyMap(groupId, newsignaddr)
+			if err != nil {
+				return nil, "", fmt.Errorf("save key map %s err: %s", newsignaddr, err.Error())
+			}
+			base64key, _ = dirks.GetEncodedPubkey(groupId, localcrypto.Sign)
+		} else {
+			_, err := dirks.GetKeyFromUnlocked(localcrypto.Sign.NameString(groupId))
+			if err != nil {
+				return nil, "", errors.New("create new group key err:" + err.Error())
+			}
+			base64key, _ = dirks.GetEncodedPubkey(groupId, localcrypto.Sign)
 		}
+	}
+	groupSignPubkey, err := base64.RawURLEncoding.DecodeString(base64key)
+	if err != nil {
+		return nil, "", errors.New("group key can't be decoded, err:" + err.Error())
+	}
 
-		// save group seed to appdata
-		pbGroupSeed := handlers.ToPbGroupSeed(*seed)
-		if err := h.Appdb.SetGroupSeed(&pbGroupSeed); err != nil {
-			msg := fmt.Sprintf("save group seed failed: %s", err)
-			return rumerrors.NewBadRequestError(msg)
+	groupEncryptkey, err := dirks.GetEncodedPubkey(groupId, localcrypto.Encrypt)
+	if err != nil {
+		if !strings.HasPrefix(err.Error(), "key not exist") {
+			return nil, "", errors.New("Create key pair failed with msg:" + err.Error())
+		}
+		_, _ = dirks.NewKeyWithDefaultPassword(groupId, localcrypto.Encrypt)
+		_, err := dirks.GetKeyFromUnlocked(localcrypto.Encrypt.NameString(groupId))
+		if err != nil {
+			return nil, "", errors.New("Create key pair failed with msg:" + err.Error())
 		}
+		groupEncryptkey, _ = dirks.GetEncodedPubkey(groupId, localcrypto.Encrypt)
+	}
+	return groupSignPubkey, groupEncryptkey, nil
+}
 
-		return c.JSON(http.StatusOK, joinGrpResult)
+// readOnlyGroupKey links the sign key of the group to the node key and returns its pubkey,
+// no key is written to the keystore and the group has no encrypt key
+func readOnlyGroupKey(seed *handlers.GroupSeed, keyAlias string) ([]byte, error) {
+	if keyAlias != "" {
+		return nil, errors.New("key_alias can't be used with read_only")
+	}
+	if seed.EncryptionType != "public" {
+		return nil, errors.New("only public group can be joined read-only")
+	}
+	groupId := seed.GenesisBlock.GroupId
+	if err := chain.LinkReadOnlyGroupKey(groupId); err != nil {
+		return nil, fmt.Errorf("link read-only group key failed: %s", err)
+	}
+	base64key, err := nodectx.GetNodeCtx().Keystore.GetEncodedPubkey(groupId, localcrypto.Sign)
+	if err != nil {
+		return nil, err
 	}
+	return base64.RawURLEncoding.DecodeString(base64key)
 }
 
 // JoinGroupByHTTPRequest restore cli use it
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
+	"github.com/rumsystem/quorum/internal/pkg/nodectx"
+	"github.com/rumsystem/quorum/internal/pkg/options"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
+)
+
+// @Tags Keystore
+// @Summary KeystoreSign
+// @Description Sign a message as an eth personal message with key alias or key name, the default key is used if both are empty
+// @Accept json
+// @Produce json
+// @Param data body handlers.KeystoreSignParam true "KeystoreSignParam"
+// @Success 200 {object} handlers.KeystoreSignResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/keystore/sign [post]
+func (h *Handler) KeystoreSign(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.KeystoreSignParam)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	res, err := handlers.KeystoreSign(nodectx.GetNodeCtx().Keystore, params)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// @Tags Keystore
+// @Summary KeystoreVerify
+// @Description Verify a signature made by /api/v1/keystore/sign against an eth address, a pubkey or a local key
+// @Accept json
+// @Produce json
+// @Param data body handlers.KeystoreVerifyParam true "KeystoreVerifyParam"
+// @Success 200 {object} handlers.KeystoreVerifyResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/keystore/verify [post]
+func (h *Handler) KeystoreVerify(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.KeystoreVerifyParam)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	res, err := handlers.KeystoreVerify(nodectx.GetNodeCtx().Keystore, params)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// @Tags Keystore
+// @Summary ChangeKeystorePassword
+// @Description Re-encrypt all keys of the keystore under a new password. The keys are written into a new keystore dir which replaces the old one only on success, the node must be started with the new password afterwards
+// @Accept json
+// @Produce json
+// @Param data body handlers.ChangeKeystorePasswordParam true "ChangeKeystorePasswordParam"
+// @Success 200 {object} handlers.ChangeKeystorePasswordResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/keystore/password [post]
+func (h *Handler) ChangeKeystorePassword(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.ChangeKeystorePasswordParam)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	res, err := handlers.ChangeKeystorePassword(nodectx.GetNodeCtx().Keystore, params)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// @Tags Keystore
+// @Summary ImportKey
+// @Description Import an eth private key, raw hex or keystore json, as a sign key under a new key alias. The key is encrypted with the keystore password
+// @Accept json
+// @Produce json
+// @Param data body handlers.ImportKeyParam true "ImportKeyParam"
+// @Success 200 {object} handlers.ImportKeyResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/keystore/import [post]
+func (h *Handler) ImportKey(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.ImportKeyParam)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	res, err := handlers.ImportKey(nodectx.GetNodeCtx().Keystore, options.GetNodeOptions(), params)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// @Tags Keystore
+// @Summary CreateScopedToken
+// @Description Create a chain jwt with read, publish or admin scope. It needs the keystore password instead of a jwt, the token expires at expires_at and can be revoked by /app/api/v1/token/revoke
+// @Accept json
+// @Produce json
+// @Param data body handlers.CreateScopedTokenParam true "CreateScopedTokenParam"
+// @Success 200 {object} handlers.CreateScopedTokenResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/token [post]
+func (h *Handler) CreateScopedToken(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.CreateScopedTokenParam)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	res, err := handlers.CreateScopedToken(nodectx.GetNodeCtx().Keystore, options.GetNodeOptions(), params)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
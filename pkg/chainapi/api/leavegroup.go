@@ -15,7 +15,12 @@ import (
 // @Accept json
 // @Produce json
 // @Param data body handlers.LeaveGroupParam true "LeaveGroupParam"
-// @success 200 {object} handlers.LeaveGroupResult "LeaveGroupResult"
+// @Success 200 {object} handlers.LeaveGroupResult "LeaveGroupResult"
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router /api/v1/group/leave [post]
 func (h *Handler) LeaveGroup(c echo.Context) (err error) {
 	cc := c.(*utils.CustomContext)
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
+)
+
+// @Tags Node
+// @Summary SetLogLevel
+// @Description Change the log level of a subsystem at runtime, the change is lost when the node restarts
+// @Accept json
+// @Produce json
+// @Param data body handlers.SetLogLevelParam true "SetLogLevelParam"
+// @Success 200 {object} handlers.LogLevelResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/log/level [post]
+func (h *Handler) SetLogLevel(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.SetLogLevelParam)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	res, err := handlers.SetLogLevel(params)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// @Tags Node
+// @Summary GetLogSubsystems
+// @Description List the log subsystems whose level can be changed
+// @Produce json
+// @Success 200 {object} handlers.LogSubsystemsResult
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/log/subsystems [get]
+func (h *Handler) GetLogSubsystems(c echo.Context) (err error) {
+	return c.JSON(http.StatusOK, handlers.GetLogSubsystems())
+}
@@ -10,8 +10,34 @@ import (
 	dto "github.com/prometheus/client_model/go"
 	"github.com/prometheus/common/expfmt"
 	"github.com/prometheus/prom2json"
+	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
+	"github.com/rumsystem/quorum/internal/pkg/conn/p2p"
+	"github.com/rumsystem/quorum/internal/pkg/metric"
 )
 
+// newNodeCollector collects connected peers, block height and publish queue depth of groups for /metrics
+func newNodeCollector(node *p2p.Node) *metric.NodeCollector {
+	return &metric.NodeCollector{
+		PeerCount: func() int {
+			return len(node.Host.Network().Peers())
+		},
+		BlockHeights: func() map[string]uint64 {
+			heights := map[string]uint64{}
+			for groupId, group := range chain.GetGroupMgr().Groups {
+				heights[groupId] = group.GetCurrentBlockId()
+			}
+			return heights
+		},
+		PublishQueueDepth: func() map[string]map[string]int {
+			depths := map[string]map[string]int{}
+			for groupId, group := range chain.GetGroupMgr().Groups {
+				depths[groupId] = group.ChainCtx.GetPublishQueueDepth()
+			}
+			return depths
+		},
+	}
+}
+
 func (h *Handler) Metrics(c echo.Context) error {
 	out := &bytes.Buffer{}
 	metricFamilies, _ := prometheus.DefaultGatherer.Gather()
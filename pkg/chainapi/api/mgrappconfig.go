@@ -16,6 +16,11 @@ import (
 // @Accept json
 // @Param data body handlers.AppConfigParam true "AppConfigParam"
 // @Success 200 {object} handlers.AppConfigResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router /api/v1/group/appconfig [post]
 func (h *Handler) MgrAppConfig(c echo.Context) (err error) {
 	cc := c.(*utils.CustomContext)
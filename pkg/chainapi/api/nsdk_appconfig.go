@@ -27,6 +27,11 @@ type (
 // @Produce json
 // @Param   group_id path string true "Group Id"
 // @Success 200 {object} []handlers.AppConfigKeyListItem
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router  /api/v1/node/{group_id}/appconfig/keylist [get]
 func (h *Handler) GetNSdkAppconfigKeylist(c echo.Context) (err error) {
 	cc := c.(*utils.CustomContext)
@@ -50,6 +55,11 @@ func (h *Handler) GetNSdkAppconfigKeylist(c echo.Context) (err error) {
 // @Param   group_id path string true "Group Id"
 // @Param   key path string true "key name"
 // @Success 200 {object} []handlers.AppConfigKeyItem
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router  /api/v1/node/{group_id}/appconfig/by/{key} [get]
 func (h *Handler) GetNSdkAppconfigByKey(c echo.Context) (err error) {
 	cc := c.(*utils.CustomContext)
@@ -32,6 +32,11 @@ type (
 // @Param   group_id path string true "Group Id"
 // @Param   trx_type path string true "trx type"
 // @Success 200 {object} handlers.TrxAuthItem
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router  /api/v1/node/{group_id}/auth/by/{trx_type} [get]
 func (h *Handler) GetNSdkAuthType(c echo.Context) (err error) {
 	cc := c.(*utils.CustomContext)
@@ -54,6 +59,11 @@ func (h *Handler) GetNSdkAuthType(c echo.Context) (err error) {
 // @Produce json
 // @Param   group_id path string true "Group Id"
 // @Success 200 {object} []handlers.ChainSendTrxRuleListItem
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router  /api/v1/node/{group_id}/auth/alwlist [get]
 func (h *Handler) GetNSdkAllowList(c echo.Context) (err error) {
 	cc := c.(*utils.CustomContext)
@@ -76,6 +86,11 @@ func (h *Handler) GetNSdkAllowList(c echo.Context) (err error) {
 // @Produce json
 // @Param   group_id path string true "Group Id"
 // @Success 200 {object} []handlers.ChainSendTrxRuleListItem
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router  /api/v1/node/{group_id}/auth/denylist [get]
 func (h *Handler) GetNSdkDenyList(c echo.Context) (err error) {
 	cc := c.(*utils.CustomContext)
@@ -19,6 +19,11 @@ import (
 // @Param   group_id path string true "Group Id"
 // @Param   get_content_params  query handlers.GetGroupCtnPrarms  true  "get group content params"
 // @Success 200 {object} []quorumpb.Trx
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router  /api/v1/node/{group_id}/groupctn [get]
 func (h *Handler) GetNSdkContent(c echo.Context) (err error) {
 	cc := c.(*utils.CustomContext)
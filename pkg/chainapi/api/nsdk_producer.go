@@ -31,6 +31,11 @@ type (
 // @Produce json
 // @Param   group_id path string true "Group Id"
 // @Success 200 {object} []handlers.AppConfigKeyItem
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router  /api/v1/node/{group_id}/announced/producer [get]
 func (h *Handler) GetNSdkAnnouncedProducer(c echo.Context) (err error) {
 	cc := c.(*utils.CustomContext)
@@ -53,6 +58,11 @@ func (h *Handler) GetNSdkAnnouncedProducer(c echo.Context) (err error) {
 // @Param   group_id path string true "Group Id"
 // @Param   get_announced_user_params  query GetNSdkAnnouncedUserParams true  "get announced user params"
 // @Success 200 {object} []handlers.AnnouncedUserListItem
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router  /api/v1/node/{group_id}/announced/user [get]
 func (h *Handler) GetNSdkAnnouncedUser(c echo.Context) (err error) {
 	cc := c.(*utils.CustomContext)
@@ -81,6 +91,11 @@ func (h *Handler) GetNSdkAnnouncedUser(c echo.Context) (err error) {
 // @Produce json
 // @Param   group_id path string true "Group Id"
 // @Success 200 {object} []handlers.ProducerListItem
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router  /api/v1/node/{group_id}/producers [get]
 func (h *Handler) GetNSdkGroupProducers(c echo.Context) (err error) {
 	cc := c.(*utils.CustomContext)
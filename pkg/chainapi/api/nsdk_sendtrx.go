@@ -33,6 +33,11 @@ type NSdkSendTrxParams struct {
 // @Param   group_id path string true "Group Id"
 // @Param   data body NSdkSendTrxParams true "send trx params"
 // @Success 200 {object} SendTrxResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt, or the trx auth of the sender can not be checked"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api, or the sender is not allowed to send the trx type"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router /api/v1/node/{group_id}/trx [post]
 func (h *Handler) NSdkSendTrx(c echo.Context) (err error) {
 	cc := c.(*utils.CustomContext)
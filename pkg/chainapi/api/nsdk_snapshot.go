@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
+)
+
+type GetNSdkSnapshotParams struct {
+	GroupId string `param:"group_id" json:"group_id" validate:"required,uuid4"`
+}
+
+// @Tags LightNode
+// @Summary GetNSdkSnapshot
+// @Description get the latest signed snapshot of the group, a joining node fetches it by the chain api url of the seed
+// @Produce json
+// @Param   group_id path string true "Group Id"
+// @Success 200 {object} def.Snapshot
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router  /api/v1/node/{group_id}/snapshot [get]
+func (h *Handler) GetNSdkSnapshot(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(GetNSdkSnapshotParams)
+	if err := cc.BindAndValidate(params); err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	res, err := handlers.GetSnapshot(params.GroupId)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+	return c.JSON(http.StatusOK, res)
+}
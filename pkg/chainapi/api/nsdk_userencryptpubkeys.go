@@ -26,6 +26,12 @@ type (
 // @Produce json
 // @Param   group_id path string true "Group Id"
 // @Success 200 {object} GetUserEncryptPubKeysResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Failure 500 {object} rumerrors.ErrorResponse
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router  /api/v1/node/{group_id}/userencryptpubkeys [get]
 func (h *Handler) GetNSdkUserEncryptPubKeys(c echo.Context) (err error) {
 	cc := c.(*utils.CustomContext)
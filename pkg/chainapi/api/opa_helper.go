@@ -11,17 +11,21 @@ import (
 var opaLogger = logging.Logger("opa")
 
 func opaInputFunc(c echo.Context) interface{} {
+	r := c.Request()
+	input := map[string]interface{}{
+		"method": r.Method,
+		"path":   strings.Split(strings.Trim(r.URL.Path, "/"), "/"),
+	}
+
+	// apis allowed without jwt are still matched by method and path
 	token, err := appapi.GetJWTToken(c)
 	if err != nil {
 		opaLogger.Warnf("get jwt failed: %s", err)
-		return nil
+		return input
 	}
 
-	r := c.Request()
-	return map[string]interface{}{
-		"method":       r.Method,
-		"path":         strings.Split(strings.Trim(r.URL.Path, "/"), "/"),
-		"role":         appapi.GetJWTRole(token),
-		"allow_groups": appapi.GetJWTAllowGroups(token),
-	}
+	input["role"] = appapi.GetJWTRole(token)
+	input["scope"] = appapi.GetJWTScope(token)
+	input["allow_groups"] = appapi.GetJWTAllowGroups(token)
+	return input
 }
@@ -6,9 +6,93 @@ import future.keywords.in
 
 default allow = false
 
-# allow all for chain role
+# allow all for chain role with admin scope
 allow {
 	input.role == "chain"
+	input.scope == "admin"
+}
+
+# Allow all access POST /api/v1/token, it is checked by the keystore password
+allow {
+  input.method == "POST"
+  input.path = ["api", "v1", "token"]
+}
+
+###############################
+# rules for chain role scopes #
+###############################
+
+# allow GET /api/* except /api/quit for read and publish scope
+allow {
+	input.role == "chain"
+	input.scope in ["read", "publish"]
+	input.method == "GET"
+	input.path[0] == "api"
+	input.path != ["api", "quit"]
+}
+
+# Allow publish scope POST /api/v1/group/:group_id/content
+allow {
+  some group_id
+  input.role == "chain"
+  input.scope == "publish"
+  input.method == "POST"
+  input.path = ["api", "v1", "group", group_id, "content"]
+}
+
+# Allow publish scope POST /api/v1/group/:group_id/content/batch
+allow {
+  some group_id
+  input.role == "chain"
+  input.scope == "publish"
+  input.method == "POST"
+  input.path = ["api", "v1", "group", group_id, "content", "batch"]
+}
+
+# Allow publish scope POST /api/v1/group/:group_id/content/chunked
+allow {
+  some group_id
+  input.role == "chain"
+  input.scope == "publish"
+  input.method == "POST"
+  input.path = ["api", "v1", "group", group_id, "content", "chunked"]
+}
+
+# Allow publish scope POST /api/v1/group/:group_id/blob
+allow {
+  some group_id
+  input.role == "chain"
+  input.scope == "publish"
+  input.method == "POST"
+  input.path = ["api", "v1", "group", group_id, "blob"]
+}
+
+# Allow publish scope POST /api/v1/group/:group_id/profile
+allow {
+  some group_id
+  input.role == "chain"
+  input.scope == "publish"
+  input.method == "POST"
+  input.path = ["api", "v1", "group", group_id, "profile"]
+}
+
+# Allow publish scope DELETE /api/v1/group/:group_id/content/:trx_id
+allow {
+  some group_id
+  some trx_id
+  input.role == "chain"
+  input.scope == "publish"
+  input.method == "DELETE"
+  input.path = ["api", "v1", "group", group_id, "content", trx_id]
+}
+
+# Allow publish scope POST /api/v1/node/:group_id/trx
+allow {
+  some group_id
+  input.role == "chain"
+  input.scope == "publish"
+  input.method == "POST"
+  input.path = ["api", "v1", "node", group_id, "trx"]
 }
 
 #######################
@@ -23,6 +107,7 @@ allow {
 
 # allow all for "*" in allow_groups
 allow {
+	input.role == "node"
 	input.allow_groups == ["*"]
 	input.path[0] == "api"
 	input.path[1] == "v1"
@@ -38,6 +123,15 @@ allow {
   input.allow_groups[_] == group_id
 }
 
+# Allow access GET /api/v1/trx/:group_id/:trx_id/status
+allow {
+  some group_id
+  some trx_id
+  input.method == "GET"
+  input.path = ["api", "v1", "trx", group_id, trx_id, "status"]
+  input.allow_groups[_] == group_id
+}
+
 # Allow access /api/v1/node/:group_id/trx
 allow {
   some group_id
@@ -128,6 +222,14 @@ allow {
   input.allow_groups[_] == group_id
 }
 
+# Allow access /api/v1/node/:group_id/snapshot
+allow {
+  some group_id
+  input.method == "GET"
+  input.path = ["api", "v1", "node", group_id, "snapshot"]
+  input.allow_groups[_] == group_id
+}
+
 # Allow access /api/v1/node/:group_id/info
 allow {
   some group_id
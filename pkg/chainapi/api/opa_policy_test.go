@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/open-policy-agent/opa/rego"
+)
+
+func TestPolicyScope(t *testing.T) {
+	ctx := context.Background()
+	query, err := rego.New(
+		rego.Query("x = data.quorum.restapi.authz.allow"),
+		rego.Module("policy.rego", policyStr),
+	).PrepareForEval(ctx)
+	if err != nil {
+		t.Fatalf("prepare policy failed: %s", err)
+	}
+
+	cases := []struct {
+		scope  string
+		method string
+		path   []string
+		allow  bool
+	}{
+		{"admin", "POST", []string{"api", "v1", "group", "leave"}, true},
+		{"read", "GET", []string{"api", "v1", "groups"}, true},
+		{"read", "GET", []string{"api", "quit"}, false},
+		{"read", "POST", []string{"api", "v1", "group", "g1", "content"}, false},
+		{"read", "POST", []string{"api", "v1", "node", "g1", "trx"}, false},
+		{"publish", "POST", []string{"api", "v1", "group", "g1", "content"}, true},
+		{"publish", "POST", []string{"api", "v1", "node", "g1", "trx"}, true},
+		{"publish", "POST", []string{"api", "v1", "group", "g1", "content", "chunked"}, true},
+		{"read", "POST", []string{"api", "v1", "group", "g1", "content", "chunked"}, false},
+		{"publish", "POST", []string{"api", "v1", "group", "g1", "blob"}, true},
+		{"publish", "POST", []string{"api", "v1", "group", "g1", "profile"}, true},
+		{"read", "GET", []string{"api", "v1", "group", "g1", "profile", "pk"}, true},
+		{"publish", "POST", []string{"api", "v1", "group", "leave"}, false},
+		{"", "POST", []string{"api", "v1", "token"}, true},
+	}
+	for _, c := range cases {
+		input := map[string]interface{}{"method": c.method, "path": c.path}
+		if c.scope != "" {
+			input["role"] = "chain"
+			input["scope"] = c.scope
+			input["allow_groups"] = []string{"*"}
+		}
+		results, err := query.Eval(ctx, rego.EvalInput(input))
+		if err != nil {
+			t.Fatalf("eval failed: %s", err)
+		}
+		allow := len(results) > 0 && results[0].Bindings["x"].(bool)
+		if allow != c.allow {
+			t.Errorf("%s %s %v: expect allow %v, got %v", c.scope, c.method, c.path, c.allow, allow)
+		}
+	}
+}
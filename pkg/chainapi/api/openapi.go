@@ -0,0 +1,29 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	_ "github.com/rumsystem/quorum/docs" // the generated api docs, run `make gen-doc` after changing the annotations
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
+	"github.com/swaggo/swag"
+)
+
+// @Tags Node
+// @Summary OpenAPI
+// @Description The swagger 2.0 spec of the api, generated from the annotations of the handlers
+// @Produce json
+// @Success 200 {object} object
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Failure 500 {object} rumerrors.ErrorResponse
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/openapi.json [get]
+func (h *Handler) OpenAPI(c echo.Context) error {
+	doc, err := swag.ReadDoc()
+	if err != nil {
+		return rumerrors.NewInternalServerError(err)
+	}
+	return c.Blob(http.StatusOK, echo.MIMEApplicationJSONCharsetUTF8, []byte(doc))
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
+)
+
+// @Tags Node
+// @Summary GetOutboundLimit
+// @Description Get the limit of the bytes per second written to the chain data exchange streams
+// @Produce json
+// @Success 200 {object} handlers.OutboundLimitResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/network/outbound/limit [get]
+func (h *Handler) GetOutboundLimit(c echo.Context) (err error) {
+	result, err := handlers.GetOutboundLimit(h.Node)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Tags Node
+// @Summary SetOutboundLimit
+// @Description Change the limit of the bytes per second written to the chain data exchange streams, the change is lost when the node restarts
+// @Accept json
+// @Produce json
+// @Param data body handlers.SetOutboundLimitParam true "SetOutboundLimitParam"
+// @Success 200 {object} handlers.OutboundLimitResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/network/outbound/limit [post]
+func (h *Handler) SetOutboundLimit(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.SetOutboundLimitParam)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	result, err := handlers.SetOutboundLimit(h.Node, params)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
+)
+
+func (h *Handler) updatePeerGater(c echo.Context, action string) error {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.PeerGaterParam)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	result, err := handlers.UpdatePeerGater(h.Node, action, params)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Tags Node
+// @Summary BlockPeers
+// @Description Add peers to the blocklist, the peers are disconnected and refused until unblocked
+// @Accept json
+// @Produce json
+// @Param data body handlers.PeerGaterParam true "PeerGaterParam"
+// @Success 200 {object} handlers.PeerGaterResult
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/network/peers/block [post]
+func (h *Handler) BlockPeers(c echo.Context) (err error) {
+	return h.updatePeerGater(c, handlers.PeerGaterBlock)
+}
+
+// @Tags Node
+// @Summary UnblockPeers
+// @Description Remove peers from the blocklist
+// @Accept json
+// @Produce json
+// @Param data body handlers.PeerGaterParam true "PeerGaterParam"
+// @Success 200 {object} handlers.PeerGaterResult
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/network/peers/unblock [post]
+func (h *Handler) UnblockPeers(c echo.Context) (err error) {
+	return h.updatePeerGater(c, handlers.PeerGaterUnblock)
+}
+
+// @Tags Node
+// @Summary AllowPeers
+// @Description Add peers to the allowlist, once the allowlist is not empty only the peers in it can make new connections, the connected peers are kept
+// @Accept json
+// @Produce json
+// @Param data body handlers.PeerGaterParam true "PeerGaterParam"
+// @Success 200 {object} handlers.PeerGaterResult
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/network/peers/allow [post]
+func (h *Handler) AllowPeers(c echo.Context) (err error) {
+	return h.updatePeerGater(c, handlers.PeerGaterAllow)
+}
+
+// @Tags Node
+// @Summary DisallowPeers
+// @Description Remove peers from the allowlist, the connected peers are kept, the new connections are checked by the lists
+// @Accept json
+// @Produce json
+// @Param data body handlers.PeerGaterParam true "PeerGaterParam"
+// @Success 200 {object} handlers.PeerGaterResult
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/network/peers/disallow [post]
+func (h *Handler) DisallowPeers(c echo.Context) (err error) {
+	return h.updatePeerGater(c, handlers.PeerGaterDisallow)
+}
+
+// @Tags Node
+// @Summary GetPeerGater
+// @Description Get the peer allowlist and blocklist
+// @Produce json
+// @Success 200 {object} handlers.PeerGaterResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/network/peers/gater [get]
+func (h *Handler) GetPeerGater(c echo.Context) (err error) {
+	result, err := handlers.GetPeerGater(h.Node)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
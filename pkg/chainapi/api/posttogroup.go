@@ -1,9 +1,11 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
 	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
 	"github.com/rumsystem/quorum/internal/pkg/utils"
 	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
@@ -11,21 +13,60 @@ import (
 
 // @Tags Groups
 // @Summary PostToGroup
-// @Description Post object to a group
+// @Description Post object to a group, with confirm_depth it waits until the trx is confirmed or the timeout, the trx id is returned either way
 // @Accept json
 // @Produce json
 // @Param group_id path string  true "Group Id"
+// @Param Idempotency-Key header string false "client generated key of the post, the same as idempotency_key of the payload. A retry with the key and the same post gets the original trx id, 422 if the post is different"
 // @Param data body handlers.PostToGroupParam true "payload"
-// @Success 200 {object} handlers.TrxResult
+// @Success 200 {object} handlers.PostToGroupResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 422 {object} rumerrors.ErrorResponse "the idempotency key is already used with a different post"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router /api/v1/group/{group_id}/content [post]
 func (h *Handler) PostToGroup(c echo.Context) (err error) {
 	cc := c.(*utils.CustomContext)
-	payload := handlers.PostToGroupParam{}
+	// the idempotency_key of the payload overrides the header
+	payload := handlers.PostToGroupParam{IdempotencyKey: c.Request().Header.Get("Idempotency-Key")}
 	if err := cc.BindAndValidate(&payload); err != nil {
 		return err
 	}
 
 	res, err := handlers.PostToGroup(&payload)
+	if errors.Is(err, chain.ErrIdempotencyKeyReused) {
+		return rumerrors.NewUnprocessableEntityError(err)
+	} else if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// @Tags Groups
+// @Summary BatchPostToGroup
+// @Description Post objects to a group in one call, each item is validated and published separately and gets its own trx id or error
+// @Accept json
+// @Produce json
+// @Param group_id path string  true "Group Id"
+// @Param data body handlers.BatchPostToGroupParam true "payload"
+// @Success 200 {object} handlers.BatchPostToGroupResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/group/{group_id}/content/batch [post]
+func (h *Handler) BatchPostToGroup(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	payload := handlers.BatchPostToGroupParam{}
+	if err := cc.BindAndValidate(&payload); err != nil {
+		return err
+	}
+
+	res, err := handlers.BatchPostToGroup(&payload)
 	if err != nil {
 		return rumerrors.NewBadRequestError(err)
 	}
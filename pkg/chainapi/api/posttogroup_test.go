@@ -1,6 +1,7 @@
 package api
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
@@ -47,3 +48,42 @@ func TestPostToGroup(t *testing.T) {
 		t.Errorf("postToGroup failed: TrxId is empty")
 	}
 }
+
+func TestBatchPostToGroup(t *testing.T) {
+	t.Parallel()
+
+	createGroupParam := handlers.CreateGroupParam{
+		GroupName:      "test-batch-post",
+		ConsensusType:  "poa",
+		EncryptionType: "public",
+		AppKey:         "default",
+	}
+	group, err := createGroup(peerapi, createGroupParam)
+	if err != nil {
+		t.Fatalf("createGroup failed: %s, payload: %+v", err, createGroupParam)
+	}
+
+	payload := handlers.BatchPostToGroupParam{
+		GroupId: group.GroupId,
+		Items: []*handlers.PostToGroupParam{
+			{Data: map[string]interface{}{"type": "Note", "content": "first"}},
+			{Data: nil},
+			{Data: map[string]interface{}{"type": "Note", "content": "third"}},
+		},
+	}
+	path := fmt.Sprintf("/api/v1/group/%s/content/batch", group.GroupId)
+	var result handlers.BatchPostToGroupResult
+	if _, _, err := requestAPI(peerapi, path, "POST", payload, nil, &result, false); err != nil {
+		t.Fatalf("batch post failed: %s", err)
+	}
+
+	if len(result.Results) != len(payload.Items) {
+		t.Fatalf("want %d results, got %d", len(payload.Items), len(result.Results))
+	}
+	if result.Results[0].TrxId == "" || result.Results[2].TrxId == "" {
+		t.Errorf("valid items should be accepted: %+v %+v", result.Results[0], result.Results[2])
+	}
+	if result.Results[1].TrxId != "" || result.Results[1].Error == "" {
+		t.Errorf("item without data should be rejected: %+v", result.Results[1])
+	}
+}
@@ -4,30 +4,66 @@ import (
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
 )
 
 // @Tags Management
 // @Summary AddProducer
-// @Description add a peer to the group producer list
+// @Description add announced peers to or remove peers from the group producer list, only group owner can do this
 // @Accept json
 // @Produce json
 // @Param data body handlers.GrpProducerParam true "GrpProducerParam"
 // @Success 200 {object} handlers.GrpProducerResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router /api/v1/group/producer [post]
 func (h *Handler) GroupProducer(c echo.Context) (err error) {
-	return c.JSON(http.StatusMethodNotAllowed, "API UNDER CONSTRUCTION")
-	/*
-		cc := c.(*utils.CustomContext)
-		params := new(handlers.GrpProducerParam)
-		if err := cc.BindAndValidate(params); err != nil {
-			return err
-		}
-
-		res, err := handlers.GroupProducer(h.ChainAPIdb, params)
-		if err != nil {
-			return rumerrors.NewBadRequestError(err)
-		}
-
-		return c.JSON(http.StatusOK, res)
-	*/
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.GrpProducerParam)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	return h.groupProducer(c, params)
+}
+
+// @Tags Management
+// @Summary RemoveProducer
+// @Description remove peers from the group producer list, only group owner can do this
+// @Accept json
+// @Produce json
+// @Param data body handlers.GrpProducerParam true "GrpProducerParam, action is ignored"
+// @Success 200 {object} handlers.GrpProducerResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/group/producer [delete]
+func (h *Handler) RemoveGroupProducer(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.GrpProducerParam)
+	if err := cc.Bind(params); err != nil {
+		return err
+	}
+	params.Action = "remove"
+	if err := cc.Validate(params); err != nil {
+		return err
+	}
+
+	return h.groupProducer(c, params)
+}
+
+func (h *Handler) groupProducer(c echo.Context, params *handlers.GrpProducerParam) error {
+	res, err := handlers.GroupProducer(h.ChainAPIdb, params)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
 }
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/labstack/echo/v4"
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
+)
+
+// @Tags Groups
+// @Summary PostProfile
+// @Description Post the profile of this node in the group, a post of the content type Profile. It replaces the whole profile posted before, empty fields clear them.
+// @Accept json
+// @Produce json
+// @Param group_id path string  true "Group Id"
+// @Param data body handlers.PostProfileParam true "PostProfileParam"
+// @Success 200 {object} handlers.PostProfileResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/group/{group_id}/profile [post]
+func (h *Handler) PostProfile(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.PostProfileParam)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	res, err := handlers.PostProfile(params)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// @Tags Groups
+// @Summary GetProfile
+// @Description Get the latest profile of a user in the group. The latest is the one in the highest block, so all nodes return the same profile once they have the same blocks
+// @Produce json
+// @Param group_id path string  true "Group Id"
+// @Param pubkey path string  true "sign pubkey of the user, url encoded"
+// @Success 200 {object} appdata.UserProfile
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/group/{group_id}/profile/{pubkey} [get]
+func (h *Handler) GetProfile(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.GetProfileParam)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+	// the pubkey is base64, the router keeps it escaped if it has an escaped '/'
+	if params.Pubkey, err = url.PathUnescape(params.Pubkey); err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	res, err := handlers.GetProfile(params, h.Appdb)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
+)
+
+// @Tags Group
+// @Summary GetPubQueue
+// @Description Get the trxs sent by this node and not applied yet, with their state and attempt count
+// @Produce json
+// @Param group_id path string  true "Group Id"
+// @Param state query string  false "PENDING or DEAD, empty for all"
+// @Success 200 {object} handlers.PubQueueResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/group/{group_id}/pubqueue [get]
+func (h *Handler) GetPubQueue(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.GetPubQueueParam)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	res, err := handlers.GetPubQueue(params.GroupId, params.State)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// @Tags Group
+// @Summary GetDeadLetterTrx
+// @Description Get the trxs sent by this node which exhausted their publish retries without being applied
+// @Produce json
+// @Param group_id path string  true "Group Id"
+// @Success 200 {object} handlers.PubQueueResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/group/{group_id}/pubqueue/deadletter [get]
+func (h *Handler) GetDeadLetterTrx(c echo.Context) (err error) {
+	groupid := c.Param("group_id")
+	if groupid == "" {
+		return rumerrors.NewBadRequestError(rumerrors.ErrInvalidGroupID)
+	}
+
+	res, err := handlers.GetPubQueue(groupid, chain.PUBQUEUE_DEAD)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// @Tags Group
+// @Summary RequeueTrx
+// @Description Move a dead-letter trx back to the publish queue, it is resent immediately with fresh attempts
+// @Produce json
+// @Param group_id path string  true "Group Id"
+// @Param trx_id path string  true "Trx Id"
+// @Success 200 {object} handlers.TrxResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/group/{group_id}/pubqueue/deadletter/{trx_id} [post]
+func (h *Handler) RequeueTrx(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.PubQueueTrxParam)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	res, err := handlers.RequeueTrx(params)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// @Tags Group
+// @Summary RetryTrxNow
+// @Description Resend a trx in the publish queue immediately, a dead-letter trx gets one more attempt
+// @Produce json
+// @Param group_id path string  true "Group Id"
+// @Param trx_id path string  true "Trx Id"
+// @Success 200 {object} handlers.TrxResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/group/{group_id}/pubqueue/{trx_id}/retry [post]
+func (h *Handler) RetryTrxNow(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.PubQueueTrxParam)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	res, err := handlers.RetryTrxNow(params)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// @Tags Group
+// @Summary CancelTrx
+// @Description Remove a trx from the publish queue so that it is not resent, a copy already sent may still be applied
+// @Produce json
+// @Param group_id path string  true "Group Id"
+// @Param trx_id path string  true "Trx Id"
+// @Success 200 {object} handlers.TrxResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/group/{group_id}/pubqueue/{trx_id} [delete]
+func (h *Handler) CancelTrx(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.PubQueueTrxParam)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	res, err := handlers.CancelTrx(params)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
+)
+
+// @Tags Group
+// @Summary SearchGroupContent
+// @Description Search the posts of the group containing all words of the query, ranked by recency. Search should be enabled for the group. The posts of the authors in the local blocklist and the chunks of large objects are left out.
+// @Produce json
+// @Param group_id path string  true "Group Id"
+// @Param params query handlers.SearchGroupContentParam true "query and number of results"
+// @Success 200 {object} handlers.SearchGroupContentResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/group/{group_id}/search [get]
+func (h *Handler) SearchGroupContent(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.SearchGroupContentParam)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	res, err := handlers.SearchGroupContent(params, h.Appdb)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// @Tags Group
+// @Summary SetSearchIndex
+// @Description Enable search of the group, the existing posts are indexed in the background. Disabling deletes the index.
+// @Accept json
+// @Produce json
+// @Param group_id path string  true "Group Id"
+// @Param data body handlers.SearchIndexParam true "SearchIndexParam"
+// @Success 200 {object} appdata.SearchIndexStatus
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/group/{group_id}/search/index [post]
+func (h *Handler) SetSearchIndex(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.SearchIndexParam)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	res, err := handlers.SetSearchIndex(params, h.Appdb)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// @Tags Group
+// @Summary GetSearchIndex
+// @Description Get the search index status of the group
+// @Produce json
+// @Param group_id path string  true "Group Id"
+// @Success 200 {object} appdata.SearchIndexStatus
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/group/{group_id}/search/index [get]
+func (h *Handler) GetSearchIndex(c echo.Context) (err error) {
+	groupid := c.Param("group_id")
+	if groupid == "" {
+		return rumerrors.NewBadRequestError(rumerrors.ErrInvalidGroupID)
+	}
+
+	res, err := handlers.GetSearchIndex(groupid, h.Appdb)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
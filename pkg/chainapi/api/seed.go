@@ -18,6 +18,19 @@ type SeedUrlextendResult struct {
 	ChainapiUrls []string           `json:"urls" validate:"required,gte=1,dive,required,url" example:"http://1.2.3.4:6090?jwt=xxx|https://xxx.com?jwt=yyy"` // multi items separate by `|`
 }
 
+// @Tags Tools
+// @Summary SeedUrlextend
+// @Description Decode the seed url or the compact seed, the chain api urls are returned with the seed
+// @Accept json
+// @Produce json
+// @Param data body SeedUrlextendParam true "SeedUrlextendParam"
+// @Success 200 {object} SeedUrlextendResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/tools/seedurlextend [post]
 func (h *Handler) SeedUrlextend(c echo.Context) error {
 	cc := c.(*utils.CustomContext)
 
@@ -26,7 +39,7 @@ func (h *Handler) SeedUrlextend(c echo.Context) error {
 		return rumerrors.NewBadRequestError(err)
 	}
 
-	seed, urls, err := handlers.UrlToGroupSeed(param.SeedURL)
+	seed, urls, err := handlers.ParseGroupSeed(param.SeedURL)
 	if err != nil {
 		return rumerrors.NewBadRequestError(err)
 	}
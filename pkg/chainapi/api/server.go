@@ -1,15 +1,21 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
+	"sync"
 	"syscall"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rumsystem/ip-cert/pkg/zerossl"
 	"github.com/rumsystem/quorum/internal/pkg/conn/p2p"
+	"github.com/rumsystem/quorum/internal/pkg/logging"
 	rummiddleware "github.com/rumsystem/quorum/internal/pkg/middleware"
 	"github.com/rumsystem/quorum/internal/pkg/options"
 	"github.com/rumsystem/quorum/internal/pkg/utils"
@@ -20,22 +26,93 @@ import (
 
 var quitch chan os.Signal
 
+var (
+	apiServer        *echo.Echo
+	apiRateLimiter   *rummiddleware.RateLimiter
+	apiJWTContextKey string
+	apiServerMu      sync.Mutex
+
+	serverLogger = logging.Logger("apiserver")
+)
+
 type StartServerParam struct {
 	IsDebug       bool
 	APIHost       string
 	APIPort       uint
 	CertDir       string
 	ZeroAccessKey string
+	EnableMetrics bool
+	UnixSocket    string // serve the api on the unix socket too, the tcp listener is disabled if APIPort is 0
+	Archive       bool   // the node only serves blocks, the apis reading the app data are not registered
+}
+
+func newCORSConfig(config StartServerParam, nodeopt *options.NodeOptions) middleware.CORSConfig {
+	return utils.NewCORSConfig(config.APIHost, nodeopt.CORSAllowOrigins, nodeopt.CORSAllowMethods, nodeopt.CORSAllowHeaders)
+}
+
+// newRateLimitConfig logs and ignores the invalid limits, so that the api is still served
+func newRateLimitConfig(nodeopt *options.NodeOptions, contextKey string) rummiddleware.RateLimitConfig {
+	config := rummiddleware.RateLimitConfig{
+		Skipper:       rummiddleware.ProbeSkipper,
+		ContextKey:    contextKey,
+		PublishRoutes: rummiddleware.DefaultPublishRoutes,
+		Routes: map[string]rummiddleware.RateLimit{
+			rummiddleware.TokenRoute: rummiddleware.DefaultTokenLimit,
+		},
+	}
+	parse := func(name, s string) rummiddleware.RateLimit {
+		if s == "" {
+			return rummiddleware.RateLimit{}
+		}
+		limit, err := rummiddleware.ParseRateLimit(s)
+		if err != nil {
+			serverLogger.Errorf("ignore %s: %s", name, err)
+		}
+		return limit
+	}
+	config.Global = parse("api rate limit", nodeopt.APIRateLimit)
+	config.Publish = parse("api publish limit", nodeopt.APIPublishLimit)
+	for route, s := range nodeopt.APIRouteLimits {
+		config.Routes[route] = parse("api route limit "+route, s)
+	}
+	return config
+}
+
+// newRateLimiter returns the limiter of the api server, contextKey is where the jwt middleware stores the token
+func newRateLimiter(nodeopt *options.NodeOptions, contextKey string) *rummiddleware.RateLimiter {
+	limiter := rummiddleware.NewRateLimiter(newRateLimitConfig(nodeopt, contextKey))
+	apiServerMu.Lock()
+	apiRateLimiter = limiter
+	apiJWTContextKey = contextKey
+	apiServerMu.Unlock()
+	return limiter
+}
+
+// ReloadRateLimits applies the api rate limits of the node options to the running api server
+func ReloadRateLimits(nodeopt *options.NodeOptions) {
+	apiServerMu.Lock()
+	limiter := apiRateLimiter
+	contextKey := apiJWTContextKey
+	grpcLimiter := grpcRateLimiter
+	apiServerMu.Unlock()
+
+	if limiter != nil {
+		limiter.SetConfig(newRateLimitConfig(nodeopt, contextKey))
+	}
+	if grpcLimiter != nil {
+		grpcLimiter.SetConfig(newRateLimitConfig(nodeopt, ""))
+	}
 }
 
 // StartAPIServer : Start local web server
 func StartBootstrapNodeServer(config StartServerParam, signalch chan os.Signal, h *Handler, apph *appapi.Handler, node *p2p.Node, nodeopt *options.NodeOptions, ks localcrypto.Keystore, ethaddr string) {
 	quitch = signalch
-	e := utils.NewEcho(config.IsDebug)
+	e := utils.NewEchoWithCORS(config.IsDebug, newCORSConfig(config, nodeopt))
 	customJWTConfig := appapi.CustomJWTConfig(nodeopt.JWT.Key)
 	e.Use(middleware.JWTWithConfig(customJWTConfig))
+	e.Use(newRateLimiter(nodeopt, customJWTConfig.ContextKey).Middleware())
 	e.Use(rummiddleware.OpaWithConfig(rummiddleware.OpaConfig{
-		Skipper:   rummiddleware.LocalhostSkipper,
+		Skipper:   rummiddleware.LocalhostOrProbeSkipper,
 		Policy:    policyStr,
 		Query:     "x = data.quorum.restapi.authz.allow", // FIXME: hardcode
 		InputFunc: opaInputFunc,
@@ -45,51 +122,69 @@ func StartBootstrapNodeServer(config StartServerParam, signalch chan os.Signal,
 	r.GET("/quit", quitapp)
 	r.GET("/v1/node", h.GetBootstrapNodeInfo)
 
-	// start https or http server
-	host := config.APIHost
-	if utils.IsDomainName(host) { // domain
-		e.AutoTLSManager.Cache = autocert.DirCache(config.CertDir)
-		e.AutoTLSManager.HostPolicy = autocert.HostWhitelist(config.APIHost)
-		e.AutoTLSManager.Prompt = autocert.AcceptTOS
-		e.Logger.Fatal(e.StartAutoTLS(fmt.Sprintf(":%d", config.APIPort)))
-	} else if utils.IsPublicIP(host) { // public ip
-		ip := net.ParseIP(host)
-		privKeyPath, certPath, err := zerossl.IssueIPCert(config.CertDir, ip, config.ZeroAccessKey)
-		if err != nil {
-			e.Logger.Fatal(err)
-		}
-		e.Logger.Fatal(e.StartTLS(fmt.Sprintf(":%d", config.APIPort), certPath, privKeyPath))
-	} else { // start http server
-		e.Logger.Fatal(e.Start(fmt.Sprintf("%s:%d", host, config.APIPort)))
-	}
+	startServer(e, config)
 }
 
 func StartProducerServer(config StartServerParam, signalch chan os.Signal, h *Handler, node *p2p.Node, nodeopt *options.NodeOptions, ks localcrypto.Keystore, ethaddr string) {
 	quitch = signalch
-	e := utils.NewEcho(config.IsDebug)
+	e := utils.NewEchoWithCORS(config.IsDebug, newCORSConfig(config, nodeopt))
 	customJWTConfig := appapi.CustomJWTConfig(nodeopt.JWT.Key)
 	e.Use(middleware.JWTWithConfig(customJWTConfig))
+	e.Use(newRateLimiter(nodeopt, customJWTConfig.ContextKey).Middleware())
 	e.Use(rummiddleware.OpaWithConfig(rummiddleware.OpaConfig{
-		Skipper:   rummiddleware.LocalhostSkipper,
+		Skipper:   rummiddleware.LocalhostOrProbeSkipper,
 		Policy:    policyStr,
 		Query:     "x = data.quorum.restapi.authz.allow", // FIXME: hardcode
 		InputFunc: opaInputFunc,
 	}))
+	e.GET("/healthz", h.Healthz)
+	e.GET("/readyz", h.Readyz)
+
 	r := e.Group("/api")
 	r.GET("/quit", quitapp)
+	r.POST("/v1/token", h.CreateScopedToken)
+	r.GET("/v1/openapi.json", h.OpenAPI)
 
 	//r.POST("/v1/group", h.CreateGroupUrl())
 	//r.POST("/v1/group/join", h.JoinGroup())
 	r.POST("/v2/group/join", h.JoinGroupV2())
 	r.POST("/v1/group/leave", h.LeaveGroup)
+	r.POST("/v1/group/import", h.ImportGroup)
+	r.POST("/v1/group/:group_id/export", h.ExportGroup)
 	r.POST("/v1/group/clear", h.ClearGroupData)
 	r.POST("/v1/group/announce", h.Announce)
+	r.PUT("/v1/group/:group_id/consensus/config", h.UpdGroupConsensusConfig)
 
 	r.GET("/v1/node", h.GetNodeInfo)
+	r.GET("/v1/node/identity", h.GetNodeIdentity(ethaddr))
+	r.GET("/v1/node/stats", h.GetNodeStats)
+	r.GET("/v1/node/storage", h.GetNodeStorage)
+	r.POST("/v1/node/storage/gc", h.StorageGC)
+	r.GET("/v1/log/subsystems", h.GetLogSubsystems)
+	r.POST("/v1/log/level", h.SetLogLevel)
 	r.GET("/v1/network", h.GetNetwork(&node.Host, node.Info, nodeopt, ethaddr))
 	//r.GET("/v1/network/stats", h.GetNetworkStatsSummary)
+	r.GET("/v1/network/peers", h.ListPeers)
+	r.DELETE("/v1/network/peers/:peer_id", h.DisconnectPeer)
+	r.GET("/v1/network/peers/gater", h.GetPeerGater)
+	r.GET("/v1/network/bandwidth", h.GetBandwidth)
+	r.GET("/v1/stats", h.GetStats)
+	r.GET("/v1/stats/export", h.ExportStats)
+	r.POST("/v1/stats/reset", h.ResetStats)
+	r.GET("/v1/network/nat", h.GetNATStatus)
+	r.GET("/v1/network/peerstore", h.ExportPeerstore)
+	r.GET("/v1/network/outbound/limit", h.GetOutboundLimit)
+	r.POST("/v1/network/outbound/limit", h.SetOutboundLimit)
+	r.GET("/v1/network/connmgr", h.GetConnMgr)
+	r.POST("/v1/network/connmgr", h.SetConnMgr)
+	r.POST("/v1/network/peers/block", h.BlockPeers)
+	r.POST("/v1/network/peers/unblock", h.UnblockPeers)
+	r.POST("/v1/network/peers/allow", h.AllowPeers)
+	r.POST("/v1/network/peers/disallow", h.DisallowPeers)
+	r.POST("/v1/ping", h.Ping)
 	r.GET("/v1/block/:group_id/:block_id", h.GetBlock)
 	r.GET("/v1/trx/:group_id/:trx_id", h.GetTrx)
+	r.GET("/v1/trx/:group_id/:trx_id/status", h.GetTrxStatus)
 
 	r.GET("/v1/groups", h.GetGroups)
 	r.GET("/v1/group/:group_id", h.GetGroupById)
@@ -101,32 +196,22 @@ func StartProducerServer(config StartServerParam, signalch chan os.Signal, h *Ha
 	r.GET("/v1/group/:group_id/announced/user/:sign_pubkey", h.GetAnnouncedGroupUser)
 	r.GET("/v1/group/:group_id/announced/producers", h.GetAnnouncedGroupProducer)
 	r.GET("/v1/group/:group_id/seed", h.GetGroupSeedHandler)
+	r.GET("/v1/group/:group_id/seed/compact", h.GetCompactSeedHandler)
+	r.GET("/v1/group/:group_id/snapshot", h.GetSnapshot)
+	r.GET("/v1/group/:group_id/consensus", h.GetGroupConsensus)
+	r.GET("/v1/group/:group_id/status", h.GetGroupStatus)
+	r.GET("/v1/group/:group_id/stats", h.GetGroupStats)
 
-	// start https or http server
-	host := config.APIHost
-	if utils.IsDomainName(host) { // domain
-		e.AutoTLSManager.Cache = autocert.DirCache(config.CertDir)
-		e.AutoTLSManager.HostPolicy = autocert.HostWhitelist(config.APIHost)
-		e.AutoTLSManager.Prompt = autocert.AcceptTOS
-		e.Logger.Fatal(e.StartAutoTLS(fmt.Sprintf(":%d", config.APIPort)))
-	} else if utils.IsPublicIP(host) { // public ip
-		ip := net.ParseIP(host)
-		privKeyPath, certPath, err := zerossl.IssueIPCert(config.CertDir, ip, config.ZeroAccessKey)
-		if err != nil {
-			e.Logger.Fatal(err)
-		}
-		e.Logger.Fatal(e.StartTLS(fmt.Sprintf(":%d", config.APIPort), certPath, privKeyPath))
-	} else { // start http server
-		e.Logger.Fatal(e.Start(fmt.Sprintf("%s:%d", host, config.APIPort)))
-	}
+	startServer(e, config)
 }
 
 // StartAPIServer : Start local web server
 func StartFullNodeServer(config StartServerParam, signalch chan os.Signal, h *Handler, apph *appapi.Handler, node *p2p.Node, nodeopt *options.NodeOptions, ks localcrypto.Keystore, ethaddr string) {
 	quitch = signalch
-	e := utils.NewEcho(config.IsDebug)
+	e := utils.NewEchoWithCORS(config.IsDebug, newCORSConfig(config, nodeopt))
 	customJWTConfig := appapi.CustomJWTConfig(nodeopt.JWT.Key)
 	e.Use(middleware.JWTWithConfig(customJWTConfig))
+	e.Use(newRateLimiter(nodeopt, customJWTConfig.ContextKey).Middleware())
 	e.Use(rummiddleware.OpaWithConfig(rummiddleware.OpaConfig{
 		Skipper:   rummiddleware.JWTSkipper,
 		Policy:    policyStr,
@@ -135,7 +220,15 @@ func StartFullNodeServer(config StartServerParam, signalch chan os.Signal, h *Ha
 	}))
 
 	// prometheus metric
-	e.GET("/metrics", h.Metrics)
+	if config.EnableMetrics {
+		if err := prometheus.Register(newNodeCollector(node)); err != nil {
+			e.Logger.Warnf("register node metrics failed: %s", err)
+		}
+		e.GET("/metrics", h.Metrics)
+	}
+
+	e.GET("/healthz", h.Healthz)
+	e.GET("/readyz", h.Readyz)
 
 	r := e.Group("/api")
 	a := e.Group("/app/api")
@@ -144,24 +237,67 @@ func StartFullNodeServer(config StartServerParam, signalch chan os.Signal, h *Ha
 	r.POST("/v1/group", h.CreateGroupUrl())
 	r.POST("/v2/group/join", h.JoinGroupV2())
 	r.POST("/v1/group/leave", h.LeaveGroup)
+	r.POST("/v1/group/import", h.ImportGroup)
+	r.POST("/v1/group/:group_id/export", h.ExportGroup)
 	r.POST("/v1/group/clear", h.ClearGroupData)
 	r.POST("/v1/network/peers", h.AddPeers)
+	r.POST("/v1/network/peers/block", h.BlockPeers)
+	r.POST("/v1/network/peers/unblock", h.UnblockPeers)
+	r.POST("/v1/network/peers/allow", h.AllowPeers)
+	r.POST("/v1/network/peers/disallow", h.DisallowPeers)
+	r.POST("/v1/ping", h.Ping)
 	r.POST("/v1/group/:group_id/startsync", h.StartSync) //deprecated
+	r.POST("/v1/group/:group_id/sync/pause", h.PauseSync)
+	r.POST("/v1/group/:group_id/sync/resume", h.ResumeSync)
 	r.POST("/v1/tools/pubkeytoaddr", h.PubkeyToEthaddr)
 	r.POST("/v1/tools/seedurlextend", h.SeedUrlextend)
 	r.POST("/v1/group/:group_id/content", h.PostToGroup)
+	r.POST("/v1/group/:group_id/content/batch", h.BatchPostToGroup)
+	r.POST("/v1/group/:group_id/content/chunked", h.PostChunkedToGroup)
+	r.POST("/v1/group/:group_id/blob", h.PostBlobToGroup)
+	r.POST("/v1/group/:group_id/profile", h.PostProfile)
+	r.DELETE("/v1/group/:group_id/content/:trx_id", h.DeletePost)
 	r.POST("/v1/group/appconfig", h.MgrAppConfig)
 	r.POST("/v1/group/chainconfig", h.MgrChainConfig)
 	r.POST("/v1/group/producer", h.GroupProducer)
+	r.DELETE("/v1/group/producer", h.RemoveGroupProducer)
 	r.POST("/v1/group/user", h.GroupUser)
+	r.POST("/v1/group/:group_id/groupkey/grant", h.GrantGroupKey)
+	r.POST("/v1/group/:group_id/groupkey/revoke", h.RevokeGroupKey)
 	r.POST("/v1/group/announce", h.Announce)
+	r.POST("/v1/group/:group_id/snapshot", h.ApplySnapshot)
+	r.PUT("/v1/group/:group_id/consensus/config", h.UpdGroupConsensusConfig)
+	r.POST("/v1/group/:group_id/prune", h.PruneGroup)
+	r.POST("/v1/group/:group_id/pubqueue/deadletter/:trx_id", h.RequeueTrx)
+	r.POST("/v1/group/:group_id/pubqueue/:trx_id/retry", h.RetryTrxNow)
+	r.DELETE("/v1/group/:group_id/pubqueue/:trx_id", h.CancelTrx)
 
 	r.GET("/v1/node", h.GetNodeInfo)
+	r.GET("/v1/node/identity", h.GetNodeIdentity(ethaddr))
+	r.GET("/v1/node/stats", h.GetNodeStats)
+	r.GET("/v1/node/storage", h.GetNodeStorage)
+	r.POST("/v1/node/storage/gc", h.StorageGC)
+	r.GET("/v1/log/subsystems", h.GetLogSubsystems)
+	r.POST("/v1/log/level", h.SetLogLevel)
 	r.GET("/v1/network", h.GetNetwork(&node.Host, node.Info, nodeopt, ethaddr))
 	//r.GET("/v1/network/stats", h.GetNetworkStatsSummary)
 	//r.GET("/v1/network/peers/ping", h.PingPeers(node))
+	r.GET("/v1/network/peers", h.ListPeers)
+	r.DELETE("/v1/network/peers/:peer_id", h.DisconnectPeer)
+	r.GET("/v1/network/peers/gater", h.GetPeerGater)
+	r.GET("/v1/network/bandwidth", h.GetBandwidth)
+	r.GET("/v1/stats", h.GetStats)
+	r.GET("/v1/stats/export", h.ExportStats)
+	r.POST("/v1/stats/reset", h.ResetStats)
+	r.GET("/v1/network/nat", h.GetNATStatus)
+	r.GET("/v1/network/peerstore", h.ExportPeerstore)
+	r.GET("/v1/network/outbound/limit", h.GetOutboundLimit)
+	r.POST("/v1/network/outbound/limit", h.SetOutboundLimit)
+	r.GET("/v1/network/connmgr", h.GetConnMgr)
+	r.POST("/v1/network/connmgr", h.SetConnMgr)
 	r.GET("/v1/block/:group_id/:block_id", h.GetBlock)
 	r.GET("/v1/trx/:group_id/:trx_id", h.GetTrx)
+	r.GET("/v1/trx/:group_id/:trx_id/status", h.GetTrxStatus)
 	r.GET("/v1/groups", h.GetGroups)
 	r.GET("/v1/group/:group_id", h.GetGroupById)
 	r.GET("/v1/group/:group_id/trx/allowlist", h.GetChainTrxAllowList)
@@ -171,9 +307,43 @@ func StartFullNodeServer(config StartServerParam, signalch chan os.Signal, h *Ha
 	r.GET("/v1/group/:group_id/announced/users", h.GetAnnouncedGroupUsers)
 	r.GET("/v1/group/:group_id/announced/user/:sign_pubkey", h.GetAnnouncedGroupUser)
 	r.GET("/v1/group/:group_id/announced/producers", h.GetAnnouncedGroupProducer)
+	r.GET("/v1/group/:group_id/pubqueue", h.GetPubQueue)
+	r.GET("/v1/group/:group_id/pubqueue/deadletter", h.GetDeadLetterTrx)
 	r.GET("/v1/group/:group_id/appconfig/keylist", h.GetAppConfigKey)
 	r.GET("/v1/group/:group_id/appconfig/:key", h.GetAppConfigItem)
 	r.GET("/v1/group/:group_id/seed", h.GetGroupSeedHandler)
+	r.GET("/v1/group/:group_id/seed/compact", h.GetCompactSeedHandler)
+	r.GET("/v1/group/:group_id/snapshot", h.GetSnapshot)
+	r.GET("/v1/group/:group_id/consensus", h.GetGroupConsensus)
+	r.GET("/v1/group/:group_id/status", h.GetGroupStatus)
+	r.GET("/v1/group/:group_id/stats", h.GetGroupStats)
+	r.GET("/v1/group/:group_id/caughtup", h.GroupCaughtUp)
+	r.GET("/v1/group/:group_id/groupkey", h.GetGroupKeyStatus)
+
+	//content and timeline read from the app data, an archive node doesn't sync it
+	if !config.Archive {
+		r.POST("/v1/group/:group_id/webhook", h.AddWebhook)
+		r.POST("/v1/group/:group_id/search/index", h.SetSearchIndex)
+		r.DELETE("/v1/group/:group_id/webhook/:webhook_id", h.RemoveWebhook)
+		r.POST("/v1/group/:group_id/blocklist", h.BlockAuthor)
+		r.DELETE("/v1/group/:group_id/blocklist", h.UnblockAuthor)
+		r.GET("/v1/appsync/status", h.GetAppSyncStatus)
+		r.POST("/v1/appsync/trigger", h.TriggerAppSync)
+		r.GET("/v1/appsync/filter", h.GetAppSyncFilter)
+		r.PUT("/v1/appsync/filter", h.SetAppSyncFilter)
+		r.GET("/v1/group/:group_id/content", h.GetGroupContentByRange)
+		r.GET("/v1/group/:group_id/stream", h.GroupTrxStream)
+		r.GET("/v1/group/:group_id/webhook", h.GetWebhooks)
+		r.GET("/v1/group/:group_id/blocklist", h.GetBlockedAuthors)
+		r.GET("/v1/group/:group_id/search", h.SearchGroupContent)
+		r.GET("/v1/group/:group_id/feed.xml", h.GroupFeed)
+		r.GET("/v1/group/:group_id/search/index", h.GetSearchIndex)
+		r.GET("/v1/group/:group_id/object/:object_id", h.GetChunkedObject)
+		r.GET("/v1/group/:group_id/profile/:pubkey", h.GetProfile)
+		r.GET("/v1/blob/:hash", h.GetBlob)
+		r.GET("/v1/group/:group_id/blob/:trx_id", h.GetGroupBlob)
+		a.GET("/v1/group/:group_id/content", apph.ContentByPeers)
+	}
 
 	//app api
 	a.POST("/v1/token", apph.CreateToken)
@@ -182,14 +352,20 @@ func StartFullNodeServer(config StartServerParam, signalch chan os.Signal, h *Ha
 	a.POST("/v1/token/revoke", apph.RevokeToken)
 	a.GET("/v1/token/list", apph.ListToken)
 
-	a.GET("/v1/group/:group_id/content", apph.ContentByPeers)
-
 	if nodeopt.EnableRelay {
 		r.POST("/v1/network/relay", h.AddRelayServers)
+		r.POST("/v1/network/relay/preferred", h.SetPreferredRelays)
 	}
+	r.GET("/v1/network/relay", h.GetRelayStatus)
 
 	//utils
 	r.POST("/v1/keystore/signtx", h.SignTx)
+	r.POST("/v1/keystore/sign", h.KeystoreSign)
+	r.POST("/v1/keystore/verify", h.KeystoreVerify)
+	r.POST("/v1/keystore/password", h.ChangeKeystorePassword)
+	r.POST("/v1/keystore/import", h.ImportKey)
+	r.POST("/v1/token", h.CreateScopedToken)
+	r.GET("/v1/openapi.json", h.OpenAPI)
 
 	// websocket
 	r.GET("/v1/ws/trx", h.WebsocketManager.WsConnect)
@@ -199,7 +375,9 @@ func StartFullNodeServer(config StartServerParam, signalch chan os.Signal, h *Ha
 		n := e.Group("/api/v1/node")
 
 		n.POST("/:group_id/trx", h.NSdkSendTrx)
-		n.GET("/:group_id/groupctn", h.GetNSdkContent)
+		if !config.Archive {
+			n.GET("/:group_id/groupctn", h.GetNSdkContent)
+		}
 
 		// auth
 		n.GET("/:group_id/auth/by/:trx_type", h.GetNSdkAuthType)
@@ -215,28 +393,84 @@ func StartFullNodeServer(config StartServerParam, signalch chan os.Signal, h *Ha
 		n.GET("/:group_id/announced/producer", h.GetNSdkAnnouncedProducer)
 		n.GET("/:group_id/announced/user", h.GetNSdkAnnouncedUser)
 		n.GET("/:group_id/producers", h.GetNSdkGroupProducers)
+		n.GET("/:group_id/snapshot", h.GetNSdkSnapshot)
 
 		n.GET("/:group_id/info", h.GetNSdkGroupInfo)
 		n.GET("/:group_id/encryptpubkeys", h.GetNSdkUserEncryptPubKeys)
 	}
 
-	// start https or http server
+	startServer(e, config)
+}
+
+// startServer start https or http server, it returns after ShutdownServer is called
+func startServer(e *echo.Echo, config StartServerParam) {
+	apiServerMu.Lock()
+	apiServer = e
+	apiServerMu.Unlock()
+
+	if config.UnixSocket != "" {
+		unixErr, err := startUnixServer(e, config.UnixSocket)
+		if err != nil {
+			e.Logger.Fatal(err)
+		}
+		if config.APIPort == 0 {
+			if err := <-unixErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+				e.Logger.Fatal(err)
+			}
+			return
+		}
+	}
+
+	var err error
 	host := config.APIHost
 	if utils.IsDomainName(host) { // domain
 		e.AutoTLSManager.Cache = autocert.DirCache(config.CertDir)
 		e.AutoTLSManager.HostPolicy = autocert.HostWhitelist(config.APIHost)
 		e.AutoTLSManager.Prompt = autocert.AcceptTOS
-		e.Logger.Fatal(e.StartAutoTLS(fmt.Sprintf(":%d", config.APIPort)))
+		err = e.StartAutoTLS(fmt.Sprintf(":%d", config.APIPort))
 	} else if utils.IsPublicIP(host) { // public ip
 		ip := net.ParseIP(host)
-		privKeyPath, certPath, err := zerossl.IssueIPCert(config.CertDir, ip, config.ZeroAccessKey)
-		if err != nil {
-			e.Logger.Fatal(err)
+		privKeyPath, certPath, certErr := zerossl.IssueIPCert(config.CertDir, ip, config.ZeroAccessKey)
+		if certErr != nil {
+			e.Logger.Fatal(certErr)
 		}
-		e.Logger.Fatal(e.StartTLS(fmt.Sprintf(":%d", config.APIPort), certPath, privKeyPath))
+		err = e.StartTLS(fmt.Sprintf(":%d", config.APIPort), certPath, privKeyPath)
 	} else { // start http server
-		e.Logger.Fatal(e.Start(fmt.Sprintf("%s:%d", host, config.APIPort)))
+		err = e.Start(fmt.Sprintf("%s:%d", host, config.APIPort))
+	}
+
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		e.Logger.Fatal(err)
+	}
+}
+
+// ServerListening reports whether the api server accepts requests on tcp or the unix socket
+func ServerListening() bool {
+	apiServerMu.Lock()
+	e, unix := apiServer, unixServer
+	apiServerMu.Unlock()
+
+	if e == nil {
+		return false
+	}
+	return unix != nil || e.ListenerAddr() != nil || e.TLSListenerAddr() != nil
+}
+
+// ShutdownServer stop accepting new requests and wait for active requests until ctx is done
+func ShutdownServer(ctx context.Context) error {
+	SetNotReady()
+
+	apiServerMu.Lock()
+	e := apiServer
+	apiServerMu.Unlock()
+
+	if err := shutdownUnixServer(ctx); err != nil {
+		return err
+	}
+	if e == nil {
+		return nil
 	}
+	return e.Shutdown(ctx)
 }
 
 func quitapp(c echo.Context) (err error) {
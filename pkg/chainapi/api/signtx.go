@@ -92,6 +92,11 @@ func loadSignTxParam(param SignTxParam) (*FmtSignTxParam, error) {
 // @Produce json
 // @Param data body SignTxParam true "tx param"
 // @Success 200 {object} SignTxResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router /api/v1/keystore/signtx [post]
 func (h *Handler) SignTx(c echo.Context) (err error) {
 	cc := c.(*utils.CustomContext)
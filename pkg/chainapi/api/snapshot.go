@@ -0,0 +1,69 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
+	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
+	"github.com/rumsystem/quorum/pkg/consensus/def"
+)
+
+// @Tags Management
+// @Summary GetSnapshot
+// @Description Get the latest signed snapshot of the group created by this producer
+// @Produce json
+// @Param group_id path string  true "Group Id"
+// @Success 200 {object} def.Snapshot
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/group/{group_id}/snapshot [get]
+func (h *Handler) GetSnapshot(c echo.Context) (err error) {
+	groupid := c.Param("group_id")
+	if groupid == "" {
+		return rumerrors.NewBadRequestError(rumerrors.ErrInvalidGroupID)
+	}
+
+	res, err := handlers.GetSnapshot(groupid)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// @Tags Management
+// @Summary ApplySnapshot
+// @Description Verify a snapshot fetched from a producer and fast-forward the group to it
+// @Accept json
+// @Produce json
+// @Param group_id path string  true "Group Id"
+// @Param data body def.Snapshot true "Snapshot"
+// @Success 200 {object} handlers.ApplySnapshotResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/group/{group_id}/snapshot [post]
+func (h *Handler) ApplySnapshot(c echo.Context) (err error) {
+	groupid := c.Param("group_id")
+	if groupid == "" {
+		return rumerrors.NewBadRequestError(rumerrors.ErrInvalidGroupID)
+	}
+
+	snapshot := new(def.Snapshot)
+	if err := c.Bind(snapshot); err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	res, err := handlers.ApplySnapshot(groupid, snapshot)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
@@ -19,6 +19,11 @@ type StartSyncResult struct {
 // @Produce json
 // @Param group_id path string  true "Group Id"
 // @Success 200 {object} StartSyncResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router /api/v1/group/{group_id}/startsync [post]
 func (h *Handler) StartSync(c echo.Context) (err error) {
 	groupid := c.Param("group_id")
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
+)
+
+// @Tags Node
+// @Summary GetStats
+// @Description Get the peer connects and disconnects, sync rounds, blocks applied and bytes transferred of the node in time buckets
+// @Produce json
+// @Param from query int false "unix seconds, inclusive, default 24 hours before to"
+// @Param to query int false "unix seconds, exclusive, default now"
+// @Param group query string false "group id, node for the node level metrics, default all"
+// @Param step query string false "bucket size in whole minutes like 5m or 1h, default 1m, or 1h if from is older than the minute stats"
+// @Success 200 {object} handlers.StatsResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/stats [get]
+func (h *Handler) GetStats(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.GetStatsParam)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	result, err := handlers.GetStats(params)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Tags Node
+// @Summary ResetStats
+// @Description Zero the counters of the node recorded in the stats db, e.g. before a benchmark, confirm must be true
+// @Accept json
+// @Produce json
+// @Param data body handlers.ResetStatsParam true "ResetStatsParam"
+// @Success 200 {object} handlers.ResetStatsResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/stats/reset [post]
+func (h *Handler) ResetStats(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.ResetStatsParam)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	result, err := handlers.ResetStats(params)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Tags Node
+// @Summary ExportStats
+// @Description Dump the counters of the node in time buckets as csv, a row per point with the columns time, metric, group and value, or as json
+// @Produce text/csv
+// @Produce json
+// @Param from query int false "unix seconds, inclusive, default 24 hours before to"
+// @Param to query int false "unix seconds, exclusive, default now"
+// @Param group query string false "group id, node for the node level metrics, default all"
+// @Param step query string false "bucket size in whole minutes like 5m or 1h, default 1m, or 1h if from is older than the minute stats"
+// @Param format query string false "csv or json, default csv"
+// @Success 200 {string} string "the series"
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/stats/export [get]
+func (h *Handler) ExportStats(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	params := new(handlers.ExportStatsParam)
+	if err := cc.BindAndValidate(params); err != nil {
+		return err
+	}
+
+	data, contentType, err := handlers.ExportStats(params)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.Blob(http.StatusOK, contentType, data)
+}
@@ -24,6 +24,11 @@ type PubkeyToEthaddrResult struct {
 // @Produce json
 // @Param data body PubkeyParam true "PubkeyParam"
 // @Success 200 {object} PubkeyToEthaddrResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router /api/v1/tools/pubkeytoaddr [post]
 func (h *Handler) PubkeyToEthaddr(c echo.Context) (err error) {
 	cc := c.(*utils.CustomContext)
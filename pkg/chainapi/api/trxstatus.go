@@ -0,0 +1,38 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	"github.com/rumsystem/quorum/pkg/chainapi/handlers"
+)
+
+// @Tags Chain
+// @Summary GetTrxStatus
+// @Description Get the delivery status of a trx: queued, sent, included in a block, failed with the reason, or unknown to this node
+// @Produce json
+// @Param group_id path string  true "Group Id"
+// @Param trx_id path string  true "Transaction Id"
+// @Success 200 {object} handlers.TrxStatusResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
+// @Router /api/v1/trx/{group_id}/{trx_id}/status [get]
+func (h *Handler) GetTrxStatus(c echo.Context) (err error) {
+	cc := c.(*utils.CustomContext)
+	var params handlers.GetTrxParam
+	if err := cc.BindAndValidate(&params); err != nil {
+		return err
+	}
+
+	res, err := handlers.GetTrxStatus(params.GroupId, params.TrxId)
+	if err != nil {
+		return rumerrors.NewBadRequestError(err)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
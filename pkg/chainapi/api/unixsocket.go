@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/labstack/echo/v4"
+)
+
+var unixServer *http.Server
+
+// listenUnixSocket removes a socket file left by a crashed node and listens on path,
+// only the user running the node can connect to it
+func listenUnixSocket(path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if fi, err := os.Stat(path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, errors.New("api unix socket path exists and is not a socket: " + path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// startUnixServer serves plain http on the unix socket with the handlers of e
+func startUnixServer(e *echo.Echo, path string) (<-chan error, error) {
+	l, err := listenUnixSocket(path)
+	if err != nil {
+		return nil, err
+	}
+
+	srv := &http.Server{
+		Handler:      e,
+		ReadTimeout:  e.Server.ReadTimeout,
+		WriteTimeout: e.Server.WriteTimeout,
+	}
+	apiServerMu.Lock()
+	unixServer = srv
+	apiServerMu.Unlock()
+
+	serverLogger.Infof("api server listens on unix socket %s", path)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Serve(l)
+	}()
+	return errCh, nil
+}
+
+// shutdownUnixServer stops the unix socket server, the socket file is removed by closing the listener
+func shutdownUnixServer(ctx context.Context) error {
+	apiServerMu.Lock()
+	srv := unixServer
+	unixServer = nil
+	apiServerMu.Unlock()
+
+	if srv == nil {
+		return nil
+	}
+	return srv.Shutdown(ctx)
+}
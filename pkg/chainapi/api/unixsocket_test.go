@@ -0,0 +1,53 @@
+package api
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestUnixSocketServer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quorum.sock")
+	// a socket file left by a crashed node
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close()
+
+	e := echo.New()
+	e.GET("/api/v1/node", func(c echo.Context) error { return c.String(http.StatusOK, "ok") })
+	if _, err := startUnixServer(e, path); err != nil {
+		t.Fatalf("startUnixServer failed: %s", err)
+	}
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return net.Dial("unix", path)
+		},
+	}}
+	resp, err := client.Get("http://localhost/api/v1/node")
+	if err != nil {
+		t.Fatalf("request over unix socket failed: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expect 200, got %d", resp.StatusCode)
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("expect socket file with mode 0600, got %v %v", fi, err)
+	}
+
+	if err := shutdownUnixServer(context.Background()); err != nil {
+		t.Fatalf("shutdownUnixServer failed: %s", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expect socket file removed on shutdown, got %v", err)
+	}
+}
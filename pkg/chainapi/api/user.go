@@ -41,6 +41,11 @@ type GrpUserParam struct {
 // @Produce json
 // @Param data body GrpUserParam true "GrpUserParam"
 // @Success 200 {object} GrpUserResult
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router /api/v1/group/user [post]
 func (h *Handler) GroupUser(c echo.Context) (err error) {
 	cc := c.(*utils.CustomContext)
@@ -12,6 +12,7 @@ import (
 	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
 	"github.com/rumsystem/quorum/internal/pkg/logging"
 	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+	"google.golang.org/protobuf/proto"
 )
 
 const (
@@ -107,6 +108,15 @@ func (manager *WebsocketManager) handleEvent(event *appdata.OnChainTrxEvent) {
 	if trx == nil {
 		return
 	}
+	// an object posted in chunks is announced once, at the first chunk, the client reads the reassembled object by its id
+	if trx.Type == quorumpb.TrxType_POST {
+		decrypted := proto.Clone(trx).(*quorumpb.Trx)
+		if appdata.DecryptPostTrx(group.Item, decrypted) == nil {
+			if chunk := appdata.ParseChunk(decrypted.Data); chunk != nil && chunk.Index != 0 {
+				return
+			}
+		}
+	}
 
 	for _, c := range manager.Clients {
 		wsLogger.Debugf("put event %+v to client: %s", event, c.Id)
@@ -16,11 +16,17 @@ import (
 
 // @Tags Apps
 // @Summary GetGroupContents
-// @Description Get contents in a group
+// @Description Get contents in a group, the chunks of a large object are returned as one item of the object, or of type Chunked with the state if it is not complete. The posts of the authors in the local blocklist are left out.
 // @Produce json
 // @Param group_id path string  true "Group Id"
 // @Param params query handlers.GetGroupCtnPrarms false "get group contents params"
 // @Success 200 {array} []quorumpb.Trx
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Failure 500 {object} rumerrors.ErrorResponse
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router /app/api/v1/group/{group_id}/content [get]
 func (h *Handler) ContentByPeers(c echo.Context) (err error) {
 	cc := c.(*utils.CustomContext)
@@ -57,9 +63,8 @@ func (h *Handler) ContentByPeers(c echo.Context) (err error) {
 
 		//decrypt trx data
 		if trx.Type == quorumpb.TrxType_POST && groupitem.EncryptType == quorumpb.GroupEncryptType_PRIVATE {
-			//for post, private group, encrypted by age for all announced group user
-			ks := localcrypto.GetKeystore()
-			decryptData, err := ks.Decrypt(params.GroupId, trx.Data)
+			//for post, private group, sealed by the group key or encrypted by age for all announced group user
+			decryptData, err := chain.DecryptPrivatePost(groupitem, trx.Data)
 			if err != nil {
 				//can't decrypt, replace it
 				trx.Data = nil
@@ -82,6 +87,17 @@ func (h *Handler) ContentByPeers(c echo.Context) (err error) {
 			trx.Data = decryptData
 		}
 
+		// the chunks of an object are listed once, as the reassembled object
+		data, ok, err := h.Appdb.ChunkContent(h.Trxdb, h.NodeName, groupitem, trx.Data, trx.SenderPubkey)
+		if err != nil {
+			logger.Warnf("get chunked object groupid: %s trxid: %s failed: %s", params.GroupId, trxid, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		trx.Data = data
+
 		res = append(res, trx)
 	}
 	return c.JSON(http.StatusOK, res)
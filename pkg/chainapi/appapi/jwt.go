@@ -68,6 +68,13 @@ func CustomJWTConfig(jwtKey string) middleware.JWTConfig {
 			return utils.ParseJWTToken(auth, jwtKey)
 		},
 		Skipper: rummiddleware.JWTSkipper,
+		// a missing jwt is 401 as an invalid one, echo returns 400 for it
+		ErrorHandler: func(err error) error {
+			if err == middleware.ErrJWTMissing {
+				return rumerrors.NewUnauthorizedError(middleware.ErrJWTMissing.Message)
+			}
+			return &echo.HTTPError{Code: http.StatusUnauthorized, Message: middleware.ErrJWTInvalid.Message, Internal: err}
+		},
 	}
 
 	return config
@@ -93,6 +100,20 @@ func GetJWTRole(token *jwt.Token) string {
 	return role.(string)
 }
 
+// GetJWTScope returns the scope of a chain jwt, a chain jwt created without scope has the admin scope
+func GetJWTScope(token *jwt.Token) string {
+	claims := token.Claims.(jwt.MapClaims)
+	scope, ok := claims["scope"].(string)
+	if !ok || scope == "" {
+		if GetJWTRole(token) == "chain" {
+			return utils.JWTScopeAdmin
+		}
+		return ""
+	}
+
+	return scope
+}
+
 func GetJWTAllowGroups(token *jwt.Token) []string {
 	groups := []string{}
 	claims := token.Claims.(jwt.MapClaims)
@@ -117,6 +138,12 @@ func GetJWTAllowGroups(token *jwt.Token) []string {
 // @Produce json
 // @Param   create_jwt_params  body CreateJWTParams  true  "create jwt params"
 // @Success 200 {object} TokenItem  "a new auth token"
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Failure 500 {object} rumerrors.ErrorResponse
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router /app/api/v1/token [post]
 func (h *Handler) CreateToken(c echo.Context) error {
 	var err error
@@ -152,6 +179,12 @@ func (h *Handler) CreateToken(c echo.Context) error {
 // @Param Authorization header string true "current auth token"
 // @Param   revoke_jwt_params  body RevokeJWTParams  true  "revoke jwt params"
 // @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Failure 500 {object} rumerrors.ErrorResponse
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router /app/api/v1/token/revoke [post]
 func (h *Handler) RevokeToken(c echo.Context) error {
 	cc := c.(*utils.CustomContext)
@@ -185,6 +218,12 @@ func (h *Handler) RevokeToken(c echo.Context) error {
 // @Param Authorization header string true "current auth token"
 // @Param   remove_jwt_params  query RemoveJWTParams  true  "remove jwt params"
 // @Success 200 {object} utils.SuccessResponse
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Failure 500 {object} rumerrors.ErrorResponse
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router /app/api/v1/token [delete]
 func (h *Handler) RemoveToken(c echo.Context) error {
 	cc := c.(*utils.CustomContext)
@@ -217,6 +256,12 @@ func (h *Handler) RemoveToken(c echo.Context) error {
 // @Produce json
 // @Param Authorization header string true "current auth token"
 // @Success 200 {object} TokenItem  "a new auth token"
+// @Failure 400 {object} rumerrors.ErrorResponse
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Failure 500 {object} rumerrors.ErrorResponse
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router /app/api/v1/token/refresh [post]
 func (h *Handler) RefreshToken(c echo.Context) error {
 	nodeOpt := options.GetNodeOptions()
@@ -242,7 +287,8 @@ func (h *Handler) RefreshToken(c echo.Context) error {
 		if !nodeOpt.IsValidChainJWT(token.Raw) {
 			return rumerrors.NewBadRequestError(errors.New("invalid token"))
 		}
-		newTokenStr, err = nodeOpt.NewChainJWT(name, exp)
+		// keep the scope, a refresh must not widen the access of the token
+		newTokenStr, err = nodeOpt.NewScopedChainJWT(name, GetJWTScope(token), exp)
 		if err != nil {
 			return err
 		}
@@ -267,6 +313,11 @@ func (h *Handler) RefreshToken(c echo.Context) error {
 // @Produce json
 // @Param Authorization header string true "current auth token"
 // @Success 200 {object} options.JWT
+// @Failure 401 {object} rumerrors.ErrorResponse "missing, invalid or expired jwt"
+// @Failure 403 {object} rumerrors.ErrorResponse "the jwt is not allowed to call the api"
+// @Failure 429 {object} rumerrors.ErrorResponse "rate limit exceeded"
+// @Failure 500 {object} rumerrors.ErrorResponse
+// @Header 429 {integer} Retry-After "seconds to wait before the next request"
 // @Router /app/api/v1/token/list [get]
 func (h *Handler) ListToken(c echo.Context) error {
 	nodeOpt := options.GetNodeOptions()
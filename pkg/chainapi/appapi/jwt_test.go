@@ -0,0 +1,50 @@
+package appapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+)
+
+func TestCustomJWTConfigErrors(t *testing.T) {
+	jwtKey := "test-jwt-key"
+	valid, err := utils.NewJWTToken("alice", "chain", "", jwtKey, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged, err := utils.NewJWTToken("alice", "chain", "", "another-key", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := echo.New()
+	e.Use(middleware.JWTWithConfig(CustomJWTConfig(jwtKey)))
+	e.GET("/api/v1/node", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+	cases := []struct {
+		name string
+		auth string
+		code int
+	}{
+		{"missing", "", http.StatusUnauthorized},
+		{"malformed", "Basic abc", http.StatusUnauthorized},
+		{"forged", "Bearer " + forged, http.StatusUnauthorized},
+		{"valid", "Bearer " + valid, http.StatusOK},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/node", nil)
+		if c.auth != "" {
+			req.Header.Set(echo.HeaderAuthorization, c.auth)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		if rec.Code != c.code {
+			t.Errorf("%s jwt: got %d, want %d", c.name, rec.Code, c.code)
+		}
+	}
+}
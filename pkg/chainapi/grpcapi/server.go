@@ -0,0 +1,158 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/tls"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	rummiddleware "github.com/rumsystem/quorum/internal/pkg/middleware"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	appapi "github.com/rumsystem/quorum/pkg/chainapi/appapi"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// MethodScopes are the chain jwt scopes allowed to call the methods, the admin scope is allowed to call all
+var MethodScopes = map[string][]string{
+	"/quorum.pb.QuorumApi/GetNodeInfo":    {"read", "publish"},
+	"/quorum.pb.QuorumApi/GetGroupStatus": {"read", "publish"},
+	"/quorum.pb.QuorumApi/StreamContent":  {"read", "publish"},
+	"/quorum.pb.QuorumApi/PostContent":    {"publish"},
+}
+
+// MethodRoutes are the http api routes of the methods, a call is rate limited as a request of its route
+var MethodRoutes = map[string]string{
+	"/quorum.pb.QuorumApi/GetNodeInfo":    "GET /api/v1/node",
+	"/quorum.pb.QuorumApi/GetGroupStatus": "GET /api/v1/group/:group_id/status",
+	"/quorum.pb.QuorumApi/CreateGroup":    "POST /api/v1/group",
+	"/quorum.pb.QuorumApi/JoinGroup":      "POST /api/v2/group/join",
+	"/quorum.pb.QuorumApi/PostContent":    "POST /api/v1/group/:group_id/content",
+	"/quorum.pb.QuorumApi/StreamContent":  "GET /api/v1/group/:group_id/stream",
+}
+
+// Config is the config of the grpc server
+type Config struct {
+	JWTKey      string
+	RateLimiter *rummiddleware.RateLimiter // optional, the calls are not limited if nil
+	TLS         *tls.Config                // optional, the api is served in plaintext if nil
+}
+
+// NewServer returns the grpc server of the api, the calls are authorized by the chain jwts
+// and rate limited like the http api
+func NewServer(api quorumpb.QuorumApiServer, config Config) *grpc.Server {
+	i := &interceptor{jwtKey: config.JWTKey, limiter: config.RateLimiter}
+	opts := []grpc.ServerOption{
+		grpc.UnaryInterceptor(i.unary),
+		grpc.StreamInterceptor(i.stream),
+	}
+	if config.TLS != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(config.TLS)))
+	}
+	s := grpc.NewServer(opts...)
+	quorumpb.RegisterQuorumApiServer(s, api)
+	return s
+}
+
+// LoadTLSConfig loads the certificate and the private key of the grpc server
+func LoadTLSConfig(certFile, keyFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}, nil
+}
+
+type interceptor struct {
+	jwtKey  string
+	limiter *rummiddleware.RateLimiter
+}
+
+func (i *interceptor) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := i.check(ctx, info.FullMethod, func(md metadata.MD) error { return grpc.SetHeader(ctx, md) }); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (i *interceptor) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := i.check(ss.Context(), info.FullMethod, ss.SetHeader); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// check authorizes the call, then takes a rate limit token of the jwt subject or the remote ip
+func (i *interceptor) check(ctx context.Context, method string, setHeader func(metadata.MD) error) error {
+	token, err := i.authorize(ctx, method)
+	if err != nil {
+		return err
+	}
+	if i.limiter == nil {
+		return nil
+	}
+
+	route, ok := MethodRoutes[method]
+	if !ok {
+		route = "GRPC " + method
+	}
+	allowed, wait := i.limiter.Reserve(route, rummiddleware.RateLimitClient(token, remoteIP(ctx)))
+	if !allowed {
+		retry := int(math.Ceil(wait.Seconds()))
+		if retry < 1 {
+			retry = 1
+		}
+		setHeader(metadata.Pairs("retry-after", strconv.Itoa(retry)))
+		return status.Errorf(codes.ResourceExhausted, "rate limit exceeded, retry after %s", time.Duration(retry)*time.Second)
+	}
+	return nil
+}
+
+// authorize skips the calls from localhost, the same as the http api, the token is nil for them
+func (i *interceptor) authorize(ctx context.Context, method string) (*jwt.Token, error) {
+	if ip := net.ParseIP(remoteIP(ctx)); ip != nil && ip.IsLoopback() {
+		return nil, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	auth := md.Get("authorization")
+	if len(auth) == 0 || !strings.HasPrefix(auth[0], "Bearer ") {
+		return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+	token, err := utils.ParseJWTToken(strings.TrimPrefix(auth[0], "Bearer "), i.jwtKey)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if appapi.GetJWTRole(token) != "chain" {
+		return nil, status.Error(codes.PermissionDenied, "chain jwt is required")
+	}
+	scope := appapi.GetJWTScope(token)
+	if scope == "admin" {
+		return token, nil
+	}
+	for _, s := range MethodScopes[method] {
+		if s == scope {
+			return token, nil
+		}
+	}
+	return nil, status.Errorf(codes.PermissionDenied, "scope %s is not allowed to call %s", scope, method)
+}
+
+// remoteIP returns the ip of the tcp peer, empty for the other transports
+func remoteIP(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok {
+		if addr, ok := p.Addr.(*net.TCPAddr); ok {
+			return addr.IP.String()
+		}
+	}
+	return ""
+}
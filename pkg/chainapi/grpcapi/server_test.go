@@ -0,0 +1,299 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	rummiddleware "github.com/rumsystem/quorum/internal/pkg/middleware"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testJWTKey = "grpc-test-key"
+
+type fakeApi struct {
+	quorumpb.UnimplementedQuorumApiServer
+}
+
+func (fakeApi) GetNodeInfo(ctx context.Context, req *quorumpb.NodeInfoRequest) (*quorumpb.NodeInfoResponse, error) {
+	return &quorumpb.NodeInfoResponse{NodeStatus: "NODE_ONLINE"}, nil
+}
+
+func (fakeApi) GetGroupStatus(ctx context.Context, req *quorumpb.GroupStatusRequest) (*quorumpb.GroupStatusResponse, error) {
+	return &quorumpb.GroupStatusResponse{GroupId: req.GroupId}, nil
+}
+
+func (fakeApi) CreateGroup(ctx context.Context, req *quorumpb.CreateGroupRequest) (*quorumpb.CreateGroupResponse, error) {
+	return &quorumpb.CreateGroupResponse{GroupId: "created"}, nil
+}
+
+func (fakeApi) JoinGroup(ctx context.Context, req *quorumpb.JoinGroupRequest) (*quorumpb.JoinGroupResponse, error) {
+	return &quorumpb.JoinGroupResponse{GroupId: "joined"}, nil
+}
+
+func (fakeApi) PostContent(ctx context.Context, req *quorumpb.PostContentRequest) (*quorumpb.PostContentResponse, error) {
+	return &quorumpb.PostContentResponse{TrxId: "trx"}, nil
+}
+
+func (fakeApi) StreamContent(req *quorumpb.StreamContentRequest, stream quorumpb.QuorumApi_StreamContentServer) error {
+	return stream.Send(&quorumpb.Trx{TrxId: "trx", GroupId: req.GroupId})
+}
+
+// dialBufconn serves the api on an in-memory listener, the calls have no tcp peer so none is skipped as localhost
+func dialBufconn(t *testing.T, config Config) quorumpb.QuorumApiClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	s := NewServer(fakeApi{}, config)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial failed: %s", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return quorumpb.NewQuorumApiClient(conn)
+}
+
+func withToken(t *testing.T, role, scope string) context.Context {
+	t.Helper()
+	token, err := utils.NewScopedJWTToken("alice", role, "", scope, testJWTKey, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+// callAll calls every rpc of the api, the errors are by method
+func callAll(ctx context.Context, client quorumpb.QuorumApiClient) map[string]error {
+	errs := map[string]error{}
+	_, errs["GetNodeInfo"] = client.GetNodeInfo(ctx, &quorumpb.NodeInfoRequest{})
+	_, errs["GetGroupStatus"] = client.GetGroupStatus(ctx, &quorumpb.GroupStatusRequest{GroupId: "g"})
+	_, errs["CreateGroup"] = client.CreateGroup(ctx, &quorumpb.CreateGroupRequest{GroupName: "g"})
+	_, errs["JoinGroup"] = client.JoinGroup(ctx, &quorumpb.JoinGroupRequest{Seed: "seed"})
+	_, errs["PostContent"] = client.PostContent(ctx, &quorumpb.PostContentRequest{GroupId: "g", Data: "{}"})
+
+	stream, err := client.StreamContent(ctx, &quorumpb.StreamContentRequest{GroupId: "g"})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	errs["StreamContent"] = err
+	return errs
+}
+
+func TestInterceptorScopes(t *testing.T) {
+	client := dialBufconn(t, Config{JWTKey: testJWTKey})
+
+	methods := []string{"GetNodeInfo", "GetGroupStatus", "CreateGroup", "JoinGroup", "PostContent", "StreamContent"}
+	allowed := map[string]map[string]bool{
+		"admin": {"GetNodeInfo": true, "GetGroupStatus": true, "CreateGroup": true, "JoinGroup": true, "PostContent": true, "StreamContent": true},
+		// a chain jwt without scope is an admin jwt
+		"":        {"GetNodeInfo": true, "GetGroupStatus": true, "CreateGroup": true, "JoinGroup": true, "PostContent": true, "StreamContent": true},
+		"publish": {"GetNodeInfo": true, "GetGroupStatus": true, "PostContent": true, "StreamContent": true},
+		"read":    {"GetNodeInfo": true, "GetGroupStatus": true, "StreamContent": true},
+	}
+
+	for scope, allow := range allowed {
+		errs := callAll(withToken(t, "chain", scope), client)
+		for _, method := range methods {
+			err := errs[method]
+			if allow[method] {
+				if err != nil {
+					t.Errorf("scope %q calls %s: %s", scope, method, err)
+				}
+			} else if status.Code(err) != codes.PermissionDenied {
+				t.Errorf("scope %q calls %s: got %v, want PermissionDenied", scope, method, err)
+			}
+		}
+	}
+}
+
+func TestInterceptorDeny(t *testing.T) {
+	client := dialBufconn(t, Config{JWTKey: testJWTKey})
+
+	forged, err := utils.NewJWTToken("alice", "chain", "", "another-key", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, err := utils.NewJWTToken("alice", "chain", "", testJWTKey, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		ctx  context.Context
+		code codes.Code
+	}{
+		{"no token", context.Background(), codes.Unauthenticated},
+		{"not bearer", metadata.AppendToOutgoingContext(context.Background(), "authorization", "Basic abc"), codes.Unauthenticated},
+		{"forged token", metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+forged), codes.Unauthenticated},
+		{"expired token", metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+expired), codes.Unauthenticated},
+		{"node jwt", withToken(t, "node", ""), codes.PermissionDenied},
+	}
+	for _, c := range cases {
+		for method, err := range callAll(c.ctx, client) {
+			if status.Code(err) != c.code {
+				t.Errorf("%s calls %s: got %v, want %s", c.name, method, err, c.code)
+			}
+		}
+	}
+}
+
+func TestInterceptorRateLimit(t *testing.T) {
+	limiter := rummiddleware.NewRateLimiter(rummiddleware.RateLimitConfig{
+		Global:        rummiddleware.RateLimit{Rate: 0.001, Burst: 2},
+		Publish:       rummiddleware.RateLimit{Rate: 0.001, Burst: 1},
+		PublishRoutes: []string{MethodRoutes["/quorum.pb.QuorumApi/PostContent"]},
+	})
+	client := dialBufconn(t, Config{JWTKey: testJWTKey, RateLimiter: limiter})
+	ctx := withToken(t, "chain", "admin")
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetNodeInfo(ctx, &quorumpb.NodeInfoRequest{}); err != nil {
+			t.Fatalf("call %d: %s", i, err)
+		}
+	}
+	var header metadata.MD
+	_, err := client.GetNodeInfo(ctx, &quorumpb.NodeInfoRequest{}, grpc.Header(&header))
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("got %v, want ResourceExhausted", err)
+	}
+	if retry := header.Get("retry-after"); len(retry) != 1 || retry[0] == "" {
+		t.Errorf("retry-after header: %v", retry)
+	}
+
+	// the publish bucket is shared with the http api and not drained by the reads
+	if _, err := client.PostContent(ctx, &quorumpb.PostContentRequest{GroupId: "g", Data: "{}"}); err != nil {
+		t.Fatalf("post: %s", err)
+	}
+	if _, err := client.PostContent(ctx, &quorumpb.PostContentRequest{GroupId: "g", Data: "{}"}); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("second post: got %v, want ResourceExhausted", err)
+	}
+
+	// another subject has its own bucket
+	token, err := utils.NewJWTToken("bob", "chain", "", testJWTKey, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+	if _, err := client.GetNodeInfo(bob, &quorumpb.NodeInfoRequest{}); err != nil {
+		t.Fatalf("bob: %s", err)
+	}
+
+	// the stream is limited too
+	limiter.SetConfig(rummiddleware.RateLimitConfig{Global: rummiddleware.RateLimit{Rate: 0.001, Burst: 1}})
+	errs := callAll(ctx, client)
+	if errs["GetNodeInfo"] != nil {
+		t.Fatalf("after reset: %s", errs["GetNodeInfo"])
+	}
+	if status.Code(errs["StreamContent"]) != codes.ResourceExhausted {
+		t.Fatalf("stream: got %v, want ResourceExhausted", errs["StreamContent"])
+	}
+}
+
+func TestServerTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	certPEM := writeSelfSignedCert(t, certFile, keyFile)
+
+	tlsConfig, err := LoadTLSConfig(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTLSConfig(certFile, filepath.Join(dir, "missing.pem")); err == nil {
+		t.Fatal("loaded the tls config without the key")
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer(fakeApi{}, Config{JWTKey: testJWTKey, TLS: tlsConfig})
+	go s.Serve(lis)
+	defer s.Stop()
+
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(certPEM)
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(credentials.NewClientTLSFromCert(pool, "localhost")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	// the calls from localhost need no jwt
+	res, err := quorumpb.NewQuorumApiClient(conn).GetNodeInfo(context.Background(), &quorumpb.NodeInfoRequest{})
+	if err != nil {
+		t.Fatalf("call over tls: %s", err)
+	}
+	if res.NodeStatus != "NODE_ONLINE" {
+		t.Errorf("node status: %s", res.NodeStatus)
+	}
+
+	// a plaintext client can't talk to the tls server
+	plain, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer plain.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if _, err := quorumpb.NewQuorumApiClient(plain).GetNodeInfo(ctx, &quorumpb.NodeInfoRequest{}); err == nil {
+		t.Fatal("plaintext call succeeded on the tls server")
+	}
+}
+
+// writeSelfSignedCert writes a self signed certificate of localhost, it returns the certificate pem
+func writeSelfSignedCert(t *testing.T, certFile, keyFile string) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if err := os.WriteFile(certFile, certPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certPEM
+}
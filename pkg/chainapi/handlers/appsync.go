@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/rumsystem/quorum/internal/pkg/appdata"
+)
+
+// GetAppSyncStatus returns the last pass of appsync and how far it is behind the chains
+func GetAppSyncStatus(appsync *appdata.AppSync) (*appdata.AppSyncStatus, error) {
+	if appsync == nil {
+		return nil, errors.New("appsync is not running")
+	}
+	return appsync.GetStatus(), nil
+}
+
+// TriggerAppSync starts an appsync pass at once, it does not wait for the pass
+func TriggerAppSync(appsync *appdata.AppSync) (*appdata.AppSyncStatus, error) {
+	if appsync == nil {
+		return nil, errors.New("appsync is not running")
+	}
+	appsync.Trigger()
+	return appsync.GetStatus(), nil
+}
+
+type AppSyncFilterResult struct {
+	Filter    *appdata.AppSyncFilter `json:"filter"`    // null if all content is synced
+	Reindexed []string               `json:"reindexed"` // groups reindexed by the new filter
+}
+
+func GetAppSyncFilter(appsync *appdata.AppSync) (*AppSyncFilterResult, error) {
+	if appsync == nil {
+		return nil, errors.New("appsync is not running")
+	}
+	return &AppSyncFilterResult{Filter: appsync.GetFilter(), Reindexed: []string{}}, nil
+}
+
+// SetAppSyncFilter replaces the filter, an empty filter syncs all content
+func SetAppSyncFilter(params *appdata.AppSyncFilter, appsync *appdata.AppSync) (*AppSyncFilterResult, error) {
+	if appsync == nil {
+		return nil, errors.New("appsync is not running")
+	}
+	filter := params
+	if reflect.ValueOf(*params).IsZero() {
+		filter = nil
+	}
+	reindexed, err := appsync.SetFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	return &AppSyncFilterResult{Filter: filter, Reindexed: reindexed}, nil
+}
@@ -4,8 +4,12 @@
 package handlers
 
 import (
+	"archive/zip"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"path"
 	"path/filepath"
 
 	"filippo.io/age"
@@ -26,10 +30,35 @@ type BackupParam struct {
 	ConfigDir    string `json:"config_dir" validate:"required"`
 	SeedDir      string `json:"seed_dir" validate:"required"`
 	DataDir      string `json:"data_dir" validate:"required"`
+
+	// age X25519 recipients, the backup is encrypted with password if empty
+	Recipients []string     `json:"recipients"`
+	Progress   ProgressFunc `json:"-"` // optional
+	// optional, the backup data is collected in it and kept on failure, so a re-run resumes the block export,
+	// the backup is written straight into the encrypted zip stream if empty
+	WorkDir string `json:"work_dir"`
+	// optional, the dbs are in <data_dir>/<peername> if nil
+	Paths *options.NodePaths `json:"-"`
+}
+
+func (param BackupParam) nodePaths() *options.NodePaths {
+	return getNodePaths(param.Paths, param.DataDir, param.Peername)
 }
 
 func GetDataPath(dataDir, peerName string) string {
-	return filepath.Join(dataDir, peerName)
+	return options.NewNodePaths(dataDir, peerName).Root
+}
+
+func getNodePaths(paths *options.NodePaths, dataDir, peerName string) *options.NodePaths {
+	if paths != nil {
+		return paths
+	}
+	return options.NewNodePaths(dataDir, peerName)
+}
+
+// createNodeDb opens the groups db and the chain db of the node
+func createNodeDb(paths *options.NodePaths) (*storage.DbMgr, error) {
+	return storage.CreateDbWithPaths(paths.GroupsDb, paths.ChainDb, storage.DefaultBackend)
 }
 
 func getSeedBackupPath(dstPath string) string {
@@ -58,105 +87,279 @@ func getBlockPrefixKey() string {
 }
 
 // Backup backup block from data db and {config,keystore,seeds} directory
-func Backup(param BackupParam) {
+func Backup(param BackupParam) error {
 	// get keystore password
 	password, err := GetKeystorePassword(param.Password)
 	if err != nil {
-		logger.Fatalf("handlers.GetKeystorePassword failed: %s", err)
-	}
-
-	// check keystore signature and encrypt
-	if err := CheckSignAndEncryptWithKeystore(param.KeystoreName, param.KeystoreDir, param.ConfigDir, param.Peername, password); err != nil {
-		logger.Fatalf("check keystore failed: %s", err)
+		return fmt.Errorf("handlers.GetKeystorePassword failed: %s", err)
 	}
 
 	dstPath := param.BackupFile
 	// check dst path
 	if utils.DirExist(dstPath) || utils.FileExist(dstPath) {
-		logger.Fatalf("backup directory %s is exists", dstPath)
+		return fmt.Errorf("backup directory %s is exists", dstPath)
 	}
 
 	dstPath, err = filepath.Abs(dstPath)
 	if err != nil {
-		logger.Fatalf("get abs path for %s failed: %s", dstPath, err)
+		return fmt.Errorf("get abs path for %s failed: %s", dstPath, err)
+	}
+
+	// the backup is written next to the destination and renamed at the end, a killed backup leaves no partial .zip.enc
+	encZipPath := fmt.Sprintf("%s.zip.enc", dstPath)
+	partPath := encZipPath + ".part"
+	encZipFile, err := os.Create(partPath)
+	if err != nil {
+		return fmt.Errorf("os.Create(%s) failed: %s", partPath, err)
+	}
+	defer encZipFile.Close()
+
+	manifest, err := backupToWriter(param, password, encZipFile)
+	if err != nil {
+		encZipFile.Close()
+		utils.RemoveAll(partPath)
+		return err
+	}
+
+	if err := encZipFile.Close(); err != nil {
+		utils.RemoveAll(partPath)
+		return fmt.Errorf("close %s failed: %s", partPath, err)
+	}
+	if err := os.Rename(partPath, encZipPath); err != nil {
+		utils.RemoveAll(partPath)
+		return fmt.Errorf("rename %s => %s failed: %s", partPath, encZipPath, err)
+	}
+
+	sidecarPath := GetBackupManifestSidecarPath(dstPath)
+	if err := SaveBackupManifest(manifest, sidecarPath); err != nil {
+		return fmt.Errorf("save backup manifest failed: %s", err)
+	}
+
+	logger.Infof("success! backup file: %s", encZipPath)
+	return nil
+}
+
+// BackupToWriter backup block from data db and {config,keystore,seeds} directory,
+// write the encrypted zip stream to `w` without staging the zip file on disk
+func BackupToWriter(param BackupParam, password string, w io.Writer) error {
+	_, err := backupToWriter(param, password, w)
+	return err
+}
+
+func backupToWriter(param BackupParam, password string, w io.Writer) (*BackupManifest, error) {
+	// check keystore signature and encrypt
+	if err := CheckSignAndEncryptWithKeystore(param.KeystoreName, param.KeystoreDir, param.ConfigDir, param.Peername, password); err != nil {
+		return nil, fmt.Errorf("check keystore failed: %s", err)
+	}
+
+	recipients, err := getBackupRecipients(password, param.Recipients)
+	if err != nil {
+		return nil, err
+	}
+
+	if param.WorkDir != "" {
+		return backupWorkDirToWriter(param, password, recipients, w)
+	}
+
+	// older releases staged the backup under the chain db, remove the copies left by killed runs
+	removeStaleBackupStaging(param.nodePaths().ChainDb)
+
+	var manifest *BackupManifest
+	err = encryptZipToWriter(recipients, w, param.Progress, func(zw io.Writer) error {
+		var err error
+		manifest, err = writeBackupZip(param, zw)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// writeBackupZip write {config,keystore,seeds} directory, blocks and manifest straight into the zip stream of `w`
+func writeBackupZip(param BackupParam, w io.Writer) (*BackupManifest, error) {
+	zipWriter := zip.NewWriter(w)
+	defer zipWriter.Close()
+
+	// zip entries are relative to the root of the backup
+	param.Progress.report(ProgressStageConfig, 0, 1)
+	if err := utils.ZipAddDir(zipWriter, param.ConfigDir, filepath.ToSlash(getConfigBackupPath("")), nil); err != nil {
+		return nil, fmt.Errorf("zip %s failed: %s", param.ConfigDir, err)
+	}
+	param.Progress.report(ProgressStageConfig, 1, 1)
+
+	param.Progress.report(ProgressStageKeystore, 0, 1)
+	if err := utils.ZipAddDir(zipWriter, param.KeystoreDir, filepath.ToSlash(getKeystoreBackupPath("")), nil); err != nil {
+		return nil, fmt.Errorf("zip %s failed: %s", param.KeystoreDir, err)
+	}
+	param.Progress.report(ProgressStageKeystore, 1, 1)
+
+	appdb, err := appdata.CreateAppDb(param.nodePaths().AppDb)
+	if err != nil {
+		return nil, fmt.Errorf("appdata.CreateAppDb failed: %s", err)
+	}
+	defer appdb.Db.Close()
+
+	seedDir := filepath.ToSlash(getSeedBackupPath(""))
+	param.Progress.report(ProgressStageSeeds, 0, 1)
+	if _, err := zipWriter.Create(seedDir + "/"); err != nil {
+		return nil, err
+	}
+	err = forEachGroupSeedFile(appdb, func(name string, data []byte) error {
+		return utils.ZipAddFile(zipWriter, path.Join(seedDir, name), data)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("save group seeds failed: %s", err)
+	}
+	param.Progress.report(ProgressStageSeeds, 1, 1)
+
+	blocks, err := exportBlocksToZip(param.nodePaths(), zipWriter, getBlockPrefixKey(), nil, param.Progress)
+	if err != nil {
+		return nil, err
+	}
+
+	// write manifest, it is the watermark of the next incremental backup
+	manifest := newBackupManifest(BackupTypeFull, param.Peername, nil, blocks)
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := utils.ZipAddFile(zipWriter, backupManifestFileName, data); err != nil {
+		return nil, fmt.Errorf("save backup manifest failed: %s", err)
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		return nil, fmt.Errorf("zipWriter.Close failed: %s", err)
+	}
+
+	return manifest, nil
+}
+
+// removeStaleBackupStaging remove the staging directories in `dataPath`
+func removeStaleBackupStaging(dataPath string) {
+	dirs, err := filepath.Glob(filepath.Join(dataPath, ".backup-staging-*"))
+	if err != nil {
+		return
+	}
+	for _, dir := range dirs {
+		logger.Warnf("remove stale backup staging directory %s", dir)
+		utils.RemoveAll(dir)
+	}
+}
+
+// backupWorkDirToWriter collect the backup data in the work directory, then stream it to `w`,
+// the work directory is kept on failure, so a re-run resumes the block export
+func backupWorkDirToWriter(param BackupParam, password string, recipients []age.Recipient, w io.Writer) (*BackupManifest, error) {
+	dstPath := param.WorkDir
+	if err := utils.EnsureDir(dstPath); err != nil {
+		return nil, fmt.Errorf("create work directory %s failed: %s", dstPath, err)
 	}
 
 	// backup config directory
 	configDstPath := getConfigBackupPath(dstPath)
+	param.Progress.report(ProgressStageConfig, 0, 1)
 	if err := utils.Copy(param.ConfigDir, configDstPath); err != nil {
-		logger.Fatalf("copy %s => %s failed: %s", param.ConfigDir, dstPath, err)
+		return nil, fmt.Errorf("copy %s => %s failed: %s", param.ConfigDir, dstPath, err)
 	}
+	param.Progress.report(ProgressStageConfig, 1, 1)
 
 	// backup keystore
 	keystoreDstPath := getKeystoreBackupPath(dstPath)
+	param.Progress.report(ProgressStageKeystore, 0, 1)
 	if err := utils.Copy(param.KeystoreDir, keystoreDstPath); err != nil {
-		logger.Fatalf("copy %s => %s failed: %s", param.KeystoreDir, dstPath, err)
+		return nil, fmt.Errorf("copy %s => %s failed: %s", param.KeystoreDir, dstPath, err)
 	}
+	param.Progress.report(ProgressStageKeystore, 1, 1)
 
 	// SaveAllGroupSeeds
-	dataPath := GetDataPath(param.DataDir, param.Peername)
-	appdb, err := appdata.CreateAppDb(dataPath)
+	appdb, err := appdata.CreateAppDb(param.nodePaths().AppDb)
 	if err != nil {
-		logger.Fatalf("appdata.CreateAppDb failed: %s", err)
+		return nil, fmt.Errorf("appdata.CreateAppDb failed: %s", err)
 	}
 	defer appdb.Db.Close()
 
 	seedDstPath := getSeedBackupPath(dstPath)
-	SaveAllGroupSeeds(appdb, seedDstPath)
+	param.Progress.report(ProgressStageSeeds, 0, 1)
+	if err := SaveAllGroupSeeds(appdb, seedDstPath); err != nil {
+		return nil, fmt.Errorf("save group seeds failed: %s", err)
+	}
+	param.Progress.report(ProgressStageSeeds, 1, 1)
 
 	// backup block
-	dataDstPath := getDataBackupPath(dstPath, param.Peername)
-	BackupBlock(param.DataDir, param.Peername, dataDstPath)
+	blocks, err := BackupBlock(param.nodePaths(), getBlockSegmentPath(dstPath), param.Progress)
+	if err != nil {
+		return nil, err
+	}
 
-	// zip backup directory
-	zipFilePath := fmt.Sprintf("%s.zip", dstPath)
-	defer utils.RemoveAll(dstPath)
-	defer utils.RemoveAll(zipFilePath)
-	if err := utils.ZipDir(dstPath, zipFilePath); err != nil {
-		logger.Fatalf("utils.ZipDir(%s, %s) failed: %s", dstPath, zipFilePath, err)
+	// write manifest, it is the watermark of the next incremental backup
+	manifest := newBackupManifest(BackupTypeFull, param.Peername, nil, blocks)
+	if err := SaveBackupManifest(manifest, getBackupManifestPath(dstPath)); err != nil {
+		return nil, fmt.Errorf("save backup manifest failed: %s", err)
 	}
 
 	// check keystore signature and encrypt
 	if err := CheckSignAndEncryptWithKeystore(param.KeystoreName, keystoreDstPath, configDstPath, param.Peername, password); err != nil {
-		logger.Fatalf("check keystore failed: %s", err)
+		return nil, fmt.Errorf("check keystore failed: %s", err)
 	}
 
 	// load keystore and try to decrypt trx data
 	nodeoptions, err := options.InitNodeOptions(configDstPath, param.Peername)
 	if err != nil {
-		logger.Fatalf("load restored config failed: %s", err)
+		return nil, fmt.Errorf("load restored config failed: %s", err)
 	}
 	ks, _, err := localcrypto.InitDirKeyStore(param.KeystoreName, keystoreDstPath)
 	if err != nil {
-		logger.Fatalf("init restored keystore failed: %s", err)
+		return nil, fmt.Errorf("init restored keystore failed: %s", err)
 	}
 	ks.Unlock(nodeoptions.SignKeyMap, password)
-	if err := loadAndDecryptTrx(dataDstPath, seedDstPath, ks); err != nil {
-		logger.Fatalf("check backuped block data failed: %s", err)
+	if err := loadAndDecryptTrx(dstPath, param.Peername, seedDstPath, ks); err != nil {
+		return nil, fmt.Errorf("check backuped block data failed: %s", err)
 	}
 
-	// encrypt the backup zip file
-	r, err := age.NewScryptRecipient(password)
-	if err != nil {
-		logger.Fatalf("age.NewScryptRecipient failed: %s", err)
-	}
-	// encrypt keystore content
-	zipFile, err := os.Open(zipFilePath)
-	if err != nil {
-		logger.Fatalf("os.Open(%s) failed: %s", zipFilePath, err)
+	// zip and encrypt the backup directory
+	if err := encryptZipDirToWriter(dstPath, recipients, w, param.Progress); err != nil {
+		return nil, err
 	}
-	defer zipFile.Close()
 
-	encZipPath := fmt.Sprintf("%s.enc", zipFilePath)
-	encZipFile, err := os.Create(encZipPath)
-	if err != nil {
-		logger.Fatalf("os.Create(%s) failed", zipFilePath, err)
+	utils.RemoveAll(dstPath)
+
+	return manifest, nil
+}
+
+// encryptZipDirToWriter zip the directory and encrypt the zip stream to `w`
+func encryptZipDirToWriter(dir string, recipients []age.Recipient, w io.Writer, progress ProgressFunc) error {
+	return encryptZipToWriter(recipients, w, progress, func(zw io.Writer) error {
+		if err := utils.ZipDirToWriter(dir, zw, progress.stage(ProgressStageZip)); err != nil {
+			return fmt.Errorf("utils.ZipDirToWriter(%s) failed: %s", dir, err)
+		}
+		return nil
+	})
+}
+
+// encryptZipToWriter encrypt the zip stream written by `writeZip` to `w`
+func encryptZipToWriter(recipients []age.Recipient, w io.Writer, progress ProgressFunc, writeZip func(zw io.Writer) error) error {
+	pr, pw := io.Pipe()
+	zipErr := make(chan error, 1)
+	go func() {
+		err := writeZip(pw)
+		pw.CloseWithError(err)
+		zipErr <- err
+	}()
+
+	if progress != nil {
+		w = &progressWriter{w: w, stage: ProgressStageEncrypt, progress: progress}
 	}
-	if err := localcrypto.AgeEncrypt([]age.Recipient{r}, zipFile, encZipFile); err != nil {
-		logger.Fatalf("AgeEncrypt failed", err)
+	if err := localcrypto.AgeEncrypt(recipients, pr, w); err != nil {
+		// unblock the zip goroutine
+		pr.CloseWithError(err)
+		if zerr := <-zipErr; zerr != nil {
+			return zerr
+		}
+		return fmt.Errorf("AgeEncrypt failed: %s", err)
 	}
 
-	logger.Infof("success! backup file: %s", encZipPath)
+	return <-zipErr
 }
 
 // GetKeystorePassword get password for keystore
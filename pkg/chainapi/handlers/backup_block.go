@@ -4,42 +4,437 @@
 package handlers
 
 import (
+	"archive/zip"
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/rumsystem/quorum/internal/pkg/options"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/rumsystem/quorum/internal/pkg/storage"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
 )
 
-// BackupBlock get block from data db and backup to `backupPath`
-func BackupBlock(dataDir, peerName, backupDataPath string) {
-	datapath := dataDir + "/" + peerName
-	dbManager, err := storage.CreateDb(datapath)
+const (
+	blockSegmentSize        = 1000 // blocks per segment file
+	blockCheckpointFileName = "checkpoint.json"
+)
+
+// blockCheckpoint records the last fully-written segment
+type blockCheckpoint struct {
+	Segment int    `json:"segment"` // -1 means no segment has been written
+	Items   int    `json:"items"`   // number of blocks of the export
+	Digest  string `json:"digest"`  // sha256 of the sorted block keys, segments are only reusable for the same block set
+}
+
+type blockKeyItem struct {
+	groupId string
+	blockId uint64
+	key     []byte
+}
+
+func getBlockSegmentPath(dstPath string) string {
+	return filepath.Join(dstPath, "blocks")
+}
+
+func getBlockSegmentFile(segmentDir string, segment int) string {
+	return filepath.Join(segmentDir, fmt.Sprintf("segment_%06d.bin", segment))
+}
+
+// BackupBlock export blocks from data db to segment files in `segmentDir`, return the highest block id per group,
+// the export resumes from the checkpoint if `segmentDir` contains one
+func BackupBlock(paths *options.NodePaths, segmentDir string, progress ProgressFunc) (map[string]uint64, error) {
+	return backupBlockSince(paths, segmentDir, nil, progress)
+}
+
+// backupBlockSince export blocks which block id is greater than `since[groupId]`,
+// all blocks of the group will be exported if group not in `since`
+func backupBlockSince(paths *options.NodePaths, segmentDir string, since map[string]uint64, progress ProgressFunc) (map[string]uint64, error) {
+	return exportBlocks(paths, segmentDir, getBlockPrefixKey(), since, progress)
+}
+
+// exportBlocks write blocks match `prefix` in sorted group/block id order into fixed-size segment files
+func exportBlocks(paths *options.NodePaths, segmentDir, prefix string, since map[string]uint64, progress ProgressFunc) (map[string]uint64, error) {
+	dbManager, err := createNodeDb(paths)
 	if err != nil {
-		logger.Fatalf("storage.CreateDb failed: %s", err)
+		return nil, fmt.Errorf("storage.CreateDb failed: %s", err)
 	}
 	defer dbManager.Db.Close()
 	defer dbManager.GroupInfoDb.Close()
 
-	// backup block
-	backupDbMgr, err := storage.CreateDb(backupDataPath)
+	items, highest, err := listBlockItems(dbManager.Db, prefix, since)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := utils.EnsureDir(segmentDir); err != nil {
+		return nil, err
+	}
+	checkpoint, err := loadBlockCheckpoint(segmentDir)
+	if err != nil {
+		return nil, err
+	}
+	digest := getBlockKeysDigest(items)
+	if checkpoint.Segment >= 0 && (checkpoint.Items != len(items) || checkpoint.Digest != digest) {
+		return nil, fmt.Errorf("blocks changed since the aborted export in %s (%d blocks, now %d), remove it and run again", segmentDir, checkpoint.Items, len(items))
+	}
+	// remove partially-written segments of the aborted run
+	if err := removeSegmentsAfter(segmentDir, checkpoint.Segment); err != nil {
+		return nil, err
+	}
+
+	total := int64(len(items))
+	segments := (len(items) + blockSegmentSize - 1) / blockSegmentSize
+	for segment := checkpoint.Segment + 1; segment < segments; segment++ {
+		start := segment * blockSegmentSize
+		end := start + blockSegmentSize
+		if end > len(items) {
+			end = len(items)
+		}
+
+		if err := writeBlockSegment(dbManager.Db, getBlockSegmentFile(segmentDir, segment), items[start:end]); err != nil {
+			return nil, err
+		}
+		if err := saveBlockCheckpoint(segmentDir, blockCheckpoint{Segment: segment, Items: len(items), Digest: digest}); err != nil {
+			return nil, err
+		}
+		progress.report(ProgressStageBlocks, int64(end), total)
+	}
+
+	if segments == 0 {
+		// no block, still write the checkpoint
+		if err := saveBlockCheckpoint(segmentDir, blockCheckpoint{Segment: -1, Digest: digest}); err != nil {
+			return nil, err
+		}
+	}
+
+	return highest, nil
+}
+
+// exportBlocksToZip write blocks match `prefix` as segment entries of `zipWriter` with a final checkpoint,
+// the blocks are checked as they are written, nothing is staged on disk
+func exportBlocksToZip(paths *options.NodePaths, zipWriter *zip.Writer, prefix string, since map[string]uint64, progress ProgressFunc) (map[string]uint64, error) {
+	dbManager, err := createNodeDb(paths)
+	if err != nil {
+		return nil, fmt.Errorf("storage.CreateDb failed: %s", err)
+	}
+	defer dbManager.Db.Close()
+	defer dbManager.GroupInfoDb.Close()
+
+	items, highest, err := listBlockItems(dbManager.Db, prefix, since)
+	if err != nil {
+		return nil, err
+	}
+
+	// zip entries are relative to the root of the backup
+	segmentDir := getBlockSegmentPath("")
+	if _, err := zipWriter.Create(filepath.ToSlash(segmentDir) + "/"); err != nil {
+		return nil, err
+	}
+
+	total := int64(len(items))
+	segments := (len(items) + blockSegmentSize - 1) / blockSegmentSize
+	for segment := 0; segment < segments; segment++ {
+		start := segment * blockSegmentSize
+		end := start + blockSegmentSize
+		if end > len(items) {
+			end = len(items)
+		}
+
+		w, err := zipWriter.Create(filepath.ToSlash(getBlockSegmentFile(segmentDir, segment)))
+		if err != nil {
+			return nil, err
+		}
+		if err := writeBlockRecords(dbManager.Db, w, items[start:end], checkBackupBlock); err != nil {
+			return nil, err
+		}
+		progress.report(ProgressStageBlocks, int64(end), total)
+	}
+
+	checkpoint, err := json.Marshal(blockCheckpoint{Segment: segments - 1, Items: len(items), Digest: getBlockKeysDigest(items)})
 	if err != nil {
-		logger.Fatalf("storage.CreateDb %s failed: %s", backupDataPath, err)
+		return nil, err
+	}
+	if err := utils.ZipAddFile(zipWriter, filepath.ToSlash(filepath.Join(segmentDir, blockCheckpointFileName)), checkpoint); err != nil {
+		return nil, err
 	}
-	defer backupDbMgr.Db.Close()
-	defer backupDbMgr.GroupInfoDb.Close()
 
-	key := getBlockPrefixKey()
-	err = dbManager.Db.PrefixForeach([]byte(key), func(k []byte, v []byte, err error) error {
+	return highest, nil
+}
+
+// listBlockItems returns the keys of blocks match `prefix` sorted by group/block id and the highest block id per group,
+// blocks which block id is not greater than `since[groupId]` are skipped
+func listBlockItems(db storage.QuorumStorage, prefix string, since map[string]uint64) ([]blockKeyItem, map[string]uint64, error) {
+	items := []blockKeyItem{}
+	_, err := db.PrefixForeachKey([]byte(prefix), []byte(prefix), false, func(k []byte, err error) error {
 		if err != nil {
 			return err
 		}
 
-		if err := backupDbMgr.Db.Set(k, v); err != nil {
-			return fmt.Errorf("backupDbMgr.Db.Set failed: %s", err)
+		groupId, blockId, err := parseBlockKey(k)
+		if err != nil {
+			return err
+		}
+		if watermark, ok := since[groupId]; ok && blockId <= watermark {
+			return nil
 		}
+
+		key := make([]byte, len(k))
+		copy(key, k)
+		items = append(items, blockKeyItem{groupId: groupId, blockId: blockId, key: key})
 		return nil
 	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("dbManager.Db.PrefixForeachKey failed: %s", err)
+	}
+
+	// keys are sorted as string in db, sort by block id for each group
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].groupId != items[j].groupId {
+			return items[i].groupId < items[j].groupId
+		}
+		return items[i].blockId < items[j].blockId
+	})
+
+	highest := map[string]uint64{}
+	for _, item := range items {
+		if item.blockId >= highest[item.groupId] {
+			highest[item.groupId] = item.blockId
+		}
+	}
+	// groups without new blocks keep the watermark
+	for groupId, watermark := range since {
+		if _, ok := highest[groupId]; !ok {
+			highest[groupId] = watermark
+		}
+	}
+
+	return items, highest, nil
+}
+
+// getBlockKeysDigest returns the hex sha256 of the sorted block keys
+func getBlockKeysDigest(items []blockKeyItem) string {
+	h := sha256.New()
+	for _, item := range items {
+		writeSegmentRecord(h, item.key)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeBlockSegment write blocks as length-prefixed key/value records
+func writeBlockSegment(db storage.QuorumStorage, path string, items []blockKeyItem) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("create segment file %s failed: %s", path, err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	if err := writeBlockRecords(db, w, items, nil); err != nil {
+		return err
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	return f.Close()
+}
 
+// writeBlockRecords write blocks as length-prefixed key/value records to `w`, `check` is optional
+func writeBlockRecords(db storage.QuorumStorage, w io.Writer, items []blockKeyItem, check func(k, v []byte) error) error {
+	for _, item := range items {
+		value, err := db.Get(item.key)
+		if err != nil {
+			return fmt.Errorf("get block %s failed: %s", item.key, err)
+		}
+		if check != nil {
+			if err := check(item.key, value); err != nil {
+				return err
+			}
+		}
+		if err := writeSegmentRecord(w, item.key); err != nil {
+			return err
+		}
+		if err := writeSegmentRecord(w, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeSegmentRecord(w io.Writer, data []byte) error {
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(data)))
+	if _, err := w.Write(size[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+func readSegmentRecord(r io.Reader) ([]byte, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, binary.BigEndian.Uint32(size[:]))
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func loadBlockCheckpoint(segmentDir string) (blockCheckpoint, error) {
+	checkpoint := blockCheckpoint{Segment: -1}
+	data, err := ioutil.ReadFile(filepath.Join(segmentDir, blockCheckpointFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return checkpoint, nil
+		}
+		return checkpoint, err
+	}
+
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return checkpoint, fmt.Errorf("unmarshal block checkpoint failed: %s", err)
+	}
+
+	return checkpoint, nil
+}
+
+// saveBlockCheckpoint write checkpoint to a temp file then rename it, so the checkpoint is never partially written
+func saveBlockCheckpoint(segmentDir string, checkpoint blockCheckpoint) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(segmentDir, blockCheckpointFileName)
+	tmpPath := path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+func removeSegmentsAfter(segmentDir string, segment int) error {
+	files, err := ioutil.ReadDir(segmentDir)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		var idx int
+		if _, err := fmt.Sscanf(f.Name(), "segment_%06d.bin", &idx); err != nil {
+			continue
+		}
+		if idx > segment {
+			if err := os.Remove(filepath.Join(segmentDir, f.Name())); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// forEachSegmentBlock read blocks from segment files in order,
+// segments after the checkpoint are partially written by an aborted export and ignored
+func forEachSegmentBlock(segmentDir string, fn func(k, v []byte) error) error {
+	checkpoint, err := loadBlockCheckpoint(segmentDir)
+	if err != nil {
+		return err
+	}
+
+	for segment := 0; segment <= checkpoint.Segment; segment++ {
+		path := getBlockSegmentFile(segmentDir, segment)
+		if err := readBlockSegment(path, fn); err != nil {
+			return fmt.Errorf("read segment %s failed: %s", path, err)
+		}
+	}
+
+	return nil
+}
+
+func readBlockSegment(path string, fn func(k, v []byte) error) error {
+	f, err := os.Open(path)
 	if err != nil {
-		logger.Fatalf("backupDbMgr.Db.PrefixForeach failed: %s", err)
+		return err
 	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for {
+		key, err := readSegmentRecord(r)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		value, err := readSegmentRecord(r)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(key, value); err != nil {
+			return err
+		}
+	}
+}
+
+// forEachBackupBlock read blocks from the unzipped backup,
+// supports both segment files and the block db of old backups
+func forEachBackupBlock(unZipDir, peerName string, fn func(k, v []byte) error) error {
+	segmentDir := getBlockSegmentPath(unZipDir)
+	if utils.DirExist(segmentDir) {
+		return forEachSegmentBlock(segmentDir, fn)
+	}
+
+	dataDir := getDataBackupPath(unZipDir, peerName)
+	if !utils.DirExist(dataDir) {
+		return fmt.Errorf("block data not found in backup")
+	}
+
+	dbMgr, err := storage.CreateDb(dataDir)
+	if err != nil {
+		return fmt.Errorf("storage.CreateDb %s failed: %s", dataDir, err)
+	}
+	defer dbMgr.Db.Close()
+	defer dbMgr.GroupInfoDb.Close()
+
+	return dbMgr.Db.PrefixForeach([]byte(getBlockPrefixKey()), func(k []byte, v []byte, err error) error {
+		if err != nil {
+			return err
+		}
+		return fn(k, v)
+	})
+}
+
+// importBackupBlocks write blocks of the unzipped backup into the data db, `filter` is optional
+func importBackupBlocks(unZipDir, peerName string, dstDb storage.QuorumStorage, filter func(k []byte) bool) error {
+	return forEachBackupBlock(unZipDir, peerName, func(k, v []byte) error {
+		if !strings.HasPrefix(string(k), getBlockPrefixKey()) {
+			return fmt.Errorf("invalid block key: %s", k)
+		}
+		if filter != nil && !filter(k) {
+			return nil
+		}
+		return dstDb.Set(k, v)
+	})
 }
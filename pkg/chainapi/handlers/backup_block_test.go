@@ -0,0 +1,184 @@
+//go:build !js
+// +build !js
+
+package handlers
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/rumsystem/quorum/internal/pkg/options"
+	"github.com/rumsystem/quorum/internal/pkg/storage"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+func createTestBlockDb(t *testing.T, dataDir, peerName string, blocks map[string]uint64) {
+	dbMgr, err := storage.CreateDb(GetDataPath(dataDir, peerName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dbMgr.Db.Close()
+	defer dbMgr.GroupInfoDb.Close()
+
+	keys := [][]byte{}
+	values := [][]byte{}
+	for groupId, count := range blocks {
+		for i := uint64(0); i < count; i++ {
+			value, err := proto.Marshal(&quorumpb.Block{GroupId: groupId, BlockId: i})
+			if err != nil {
+				t.Fatal(err)
+			}
+			keys = append(keys, []byte(storage.GetBlockKey(groupId, i, nodename)))
+			values = append(values, value)
+		}
+	}
+	if err := dbMgr.Db.BatchWrite(keys, values); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readSegments(t *testing.T, segmentDir string) [][]byte {
+	segments := [][]byte{}
+	checkpoint, err := loadBlockCheckpoint(segmentDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i <= checkpoint.Segment; i++ {
+		data, err := ioutil.ReadFile(getBlockSegmentFile(segmentDir, i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		segments = append(segments, data)
+	}
+	return segments
+}
+
+func TestBackupBlockNodePaths(t *testing.T) {
+	dataDir, chainDir := t.TempDir(), t.TempDir()
+	createTestBlockDb(t, chainDir, "chain", map[string]uint64{"g1": 5})
+	paths := options.NewNodePaths(dataDir, "peer").Override(&options.NodePaths{
+		GroupsDb: GetDataPath(chainDir, "chain"),
+		ChainDb:  GetDataPath(chainDir, "chain"),
+	})
+
+	highest, err := BackupBlock(paths, t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("BackupBlock failed: %s", err)
+	}
+	if highest["g1"] != 4 {
+		t.Errorf("expect the blocks of the chain db moved out of the data dir, got %v", highest)
+	}
+}
+
+func TestExportBlocksResume(t *testing.T) {
+	dataDir := t.TempDir()
+	createTestBlockDb(t, dataDir, "peer", map[string]uint64{"g1": blockSegmentSize + 10, "g2": 20})
+
+	segmentDir := t.TempDir()
+	highest, err := BackupBlock(options.NewNodePaths(dataDir, "peer"), segmentDir, nil)
+	if err != nil {
+		t.Fatalf("BackupBlock failed: %s", err)
+	}
+	if highest["g1"] != blockSegmentSize+9 || highest["g2"] != 19 {
+		t.Errorf("unexpected highest blocks: %v", highest)
+	}
+	full := readSegments(t, segmentDir)
+	if len(full) != 2 {
+		t.Fatalf("expect 2 segments, got %d", len(full))
+	}
+
+	// blocks are read in group/block id order
+	var lastGroup string
+	var lastId uint64
+	count := 0
+	err = forEachSegmentBlock(segmentDir, func(k, v []byte) error {
+		groupId, blockId, err := parseBlockKey(k)
+		if err != nil {
+			return err
+		}
+		if count > 0 && (groupId < lastGroup || (groupId == lastGroup && blockId <= lastId)) {
+			t.Errorf("block %s/%d is out of order", groupId, blockId)
+		}
+		lastGroup, lastId = groupId, blockId
+		count++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("forEachSegmentBlock failed: %s", err)
+	}
+	if count != blockSegmentSize+30 {
+		t.Errorf("expect %d blocks, got %d", blockSegmentSize+30, count)
+	}
+
+	// simulate an aborted run: the last segment is partially written after the checkpoint
+	checkpoint, err := loadBlockCheckpoint(segmentDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkpoint.Segment = 0
+	if err := saveBlockCheckpoint(segmentDir, checkpoint); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(getBlockSegmentFile(segmentDir, 1), full[1][:len(full[1])/2], 0600); err != nil {
+		t.Fatal(err)
+	}
+	count = 0
+	if err := forEachSegmentBlock(segmentDir, func(k, v []byte) error { count++; return nil }); err != nil {
+		t.Fatalf("forEachSegmentBlock should ignore the partial segment: %s", err)
+	}
+	if count != blockSegmentSize {
+		t.Errorf("expect %d blocks before the checkpoint, got %d", blockSegmentSize, count)
+	}
+
+	// re-run resumes from the checkpoint and produces identical segments
+	info, err := os.Stat(getBlockSegmentFile(segmentDir, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := BackupBlock(options.NewNodePaths(dataDir, "peer"), segmentDir, nil); err != nil {
+		t.Fatalf("resume BackupBlock failed: %s", err)
+	}
+	info2, err := os.Stat(getBlockSegmentFile(segmentDir, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info2.ModTime().Equal(info.ModTime()) {
+		t.Errorf("completed segment should not be rewritten")
+	}
+	resumed := readSegments(t, segmentDir)
+	if len(resumed) != len(full) {
+		t.Fatalf("expect %d segments, got %d", len(full), len(resumed))
+	}
+	for i := range full {
+		if !bytes.Equal(full[i], resumed[i]) {
+			t.Errorf("segment %d mismatch after resume", i)
+		}
+	}
+}
+
+func TestExportBlocksResumeMismatch(t *testing.T) {
+	dataDir := t.TempDir()
+	createTestBlockDb(t, dataDir, "peer", map[string]uint64{"g1": blockSegmentSize + 10})
+
+	segmentDir := t.TempDir()
+	if _, err := BackupBlock(options.NewNodePaths(dataDir, "peer"), segmentDir, nil); err != nil {
+		t.Fatalf("BackupBlock failed: %s", err)
+	}
+	checkpoint, err := loadBlockCheckpoint(segmentDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkpoint.Segment = 0
+	if err := saveBlockCheckpoint(segmentDir, checkpoint); err != nil {
+		t.Fatal(err)
+	}
+
+	// new blocks arrived after the aborted run, the completed segments do not match the block set anymore
+	createTestBlockDb(t, dataDir, "peer", map[string]uint64{"g0": 5})
+	if _, err := BackupBlock(options.NewNodePaths(dataDir, "peer"), segmentDir, nil); err == nil {
+		t.Errorf("resume should fail when the block set changed")
+	}
+}
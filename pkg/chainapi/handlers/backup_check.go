@@ -11,7 +11,6 @@ import (
 	"math/rand"
 
 	"github.com/rumsystem/quorum/internal/pkg/options"
-	"github.com/rumsystem/quorum/internal/pkg/storage"
 	"github.com/rumsystem/quorum/internal/pkg/utils"
 	"github.com/rumsystem/quorum/pkg/crypto"
 	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
@@ -117,17 +116,10 @@ func checkEncrypt(ks *localcrypto.DirKeyStore, keyname, password string) error {
 	return nil
 }
 
-func loadAndDecryptTrx(dir, seedDir string, ks *localcrypto.DirKeyStore) error {
-	if !utils.DirExist(dir) {
-		return fmt.Errorf("%s not exist", dir)
-	}
-
-	dbMgr, err := storage.CreateDb(dir)
-	if err != nil {
-		logger.Fatalf("init backuped db failed: %s", err)
+func loadAndDecryptTrx(dstPath, peerName, seedDir string, ks *localcrypto.DirKeyStore) error {
+	if err := verifyBackupBlocks(dstPath, peerName, map[string]uint64{}); err != nil {
+		return err
 	}
-	defer dbMgr.Db.Close()
-	defer dbMgr.GroupInfoDb.Close()
 
 	/* commented by cuicat
 	pubCount, privCount := 0, 0
@@ -0,0 +1,56 @@
+//go:build !js
+// +build !js
+
+package handlers
+
+import (
+	"fmt"
+	"os"
+
+	"filippo.io/age"
+	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
+)
+
+// getBackupRecipients returns the age X25519 recipients if any, or the scrypt recipient of password
+func getBackupRecipients(password string, recipients []string) ([]age.Recipient, error) {
+	if len(recipients) == 0 {
+		r, err := age.NewScryptRecipient(password)
+		if err != nil {
+			return nil, fmt.Errorf("age.NewScryptRecipient failed: %s", err)
+		}
+		return []age.Recipient{r}, nil
+	}
+
+	result := []age.Recipient{}
+	for _, item := range recipients {
+		r, err := age.ParseX25519Recipient(item)
+		if err != nil {
+			return nil, fmt.Errorf("parse recipient %s failed: %s", item, err)
+		}
+		result = append(result, r)
+	}
+
+	return result, nil
+}
+
+// GetBackupIdentities returns the identities in `identityFile` if not empty, or the scrypt identity of password
+func GetBackupIdentities(password, identityFile string) ([]age.Identity, error) {
+	if identityFile == "" {
+		return []age.Identity{
+			&localcrypto.LazyScryptIdentity{Password: password},
+		}, nil
+	}
+
+	f, err := os.Open(identityFile)
+	if err != nil {
+		return nil, fmt.Errorf("os.Open(%s) failed: %s", identityFile, err)
+	}
+	defer f.Close()
+
+	identities, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("parse identity file %s failed: %s", identityFile, err)
+	}
+
+	return identities, nil
+}
@@ -0,0 +1,291 @@
+//go:build !js
+// +build !js
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rumsystem/quorum/internal/pkg/appdata"
+	"github.com/rumsystem/quorum/internal/pkg/options"
+	"github.com/rumsystem/quorum/internal/pkg/storage"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
+)
+
+func getGroupSeedFileName(groupId string) string {
+	return fmt.Sprintf("%s.json", groupId)
+}
+
+// getGroupKeyFiles returns the keystore file names of group sign key and encrypt key
+func getGroupKeyFiles(groupId string) []string {
+	return []string{
+		localcrypto.Sign.NameString(groupId),
+		localcrypto.Encrypt.NameString(groupId),
+	}
+}
+
+// BackupGroup backup the seed, blocks and keys of one group to `param.BackupFile`
+func BackupGroup(param BackupParam, groupId string) error {
+	password, err := GetKeystorePassword(param.Password)
+	if err != nil {
+		return fmt.Errorf("handlers.GetKeystorePassword failed: %s", err)
+	}
+
+	dstPath := param.BackupFile
+	if utils.DirExist(dstPath) || utils.FileExist(dstPath) {
+		return fmt.Errorf("backup directory %s is exists", dstPath)
+	}
+
+	dstPath, err = filepath.Abs(dstPath)
+	if err != nil {
+		return fmt.Errorf("get abs path for %s failed: %s", dstPath, err)
+	}
+	defer utils.RemoveAll(dstPath)
+
+	// group seed
+	appdb, err := appdata.CreateAppDb(param.nodePaths().AppDb)
+	if err != nil {
+		return fmt.Errorf("appdata.CreateAppDb failed: %s", err)
+	}
+	defer appdb.Db.Close()
+
+	pbSeed, err := appdb.GetGroupSeed(groupId)
+	if err != nil {
+		return fmt.Errorf("get group seed failed: %s", err)
+	}
+	if pbSeed == nil {
+		return fmt.Errorf("group seed of %s not found", groupId)
+	}
+	seed := FromPbGroupSeed(pbSeed)
+	seedUrl, err := GroupSeedToUrl(1, nil, &seed)
+	if err != nil {
+		return fmt.Errorf("GroupSeedToUrl failed: %s", err)
+	}
+	seedByte, err := json.MarshalIndent(CreateGroupResult{Seed: seedUrl, GroupId: groupId}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal group seed failed: %s", err)
+	}
+	seedDstPath := getSeedBackupPath(dstPath)
+	if err := utils.EnsureDir(seedDstPath); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(seedDstPath, getGroupSeedFileName(groupId)), seedByte, 0644); err != nil {
+		return fmt.Errorf("write group seed failed: %s", err)
+	}
+
+	// group keys, they are needed to keep producing
+	nodeoptions, err := options.InitNodeOptions(param.ConfigDir, param.Peername)
+	if err != nil {
+		return fmt.Errorf("options.InitNodeOptions failed: %s", err)
+	}
+	signKeyMap := map[string]string{}
+	if addr, ok := nodeoptions.SignKeyMap[groupId]; ok {
+		signKeyMap[groupId] = addr
+	}
+
+	keystoreDstPath := getKeystoreBackupPath(dstPath)
+	if err := utils.EnsureDir(keystoreDstPath); err != nil {
+		return err
+	}
+	for _, name := range getGroupKeyFiles(groupId) {
+		src := filepath.Join(param.KeystoreDir, name)
+		if !utils.FileExist(src) {
+			continue
+		}
+		if err := utils.Copy(src, filepath.Join(keystoreDstPath, name)); err != nil {
+			return fmt.Errorf("copy %s failed: %s", src, err)
+		}
+	}
+
+	// group blocks
+	prefix := storage.GetBlockPrefix(groupId, nodename)
+	blocks, err := exportBlocks(param.nodePaths(), getBlockSegmentPath(dstPath), prefix, nil, param.Progress)
+	if err != nil {
+		return err
+	}
+
+	manifest := newBackupManifest(BackupTypeGroup, param.Peername, nil, blocks)
+	manifest.GroupId = groupId
+	manifest.SignKeyMap = signKeyMap
+	if err := SaveBackupManifest(manifest, getBackupManifestPath(dstPath)); err != nil {
+		return fmt.Errorf("save backup manifest failed: %s", err)
+	}
+
+	encZipPath := fmt.Sprintf("%s.zip.enc", dstPath)
+	encZipFile, err := os.Create(encZipPath)
+	if err != nil {
+		return fmt.Errorf("os.Create(%s) failed: %s", encZipPath, err)
+	}
+	defer encZipFile.Close()
+
+	recipients, err := getBackupRecipients(password, param.Recipients)
+	if err != nil {
+		return err
+	}
+	if err := encryptZipDirToWriter(dstPath, recipients, encZipFile, param.Progress); err != nil {
+		encZipFile.Close()
+		utils.RemoveAll(encZipPath)
+		return err
+	}
+	if err := encZipFile.Close(); err != nil {
+		return fmt.Errorf("close %s failed: %s", encZipPath, err)
+	}
+
+	logger.Infof("success! group backup file: %s", encZipPath)
+	return nil
+}
+
+// RestoreGroup merge the group backup into an existing node,
+// it refuses to restore if the group exists with a different genesis block
+func RestoreGroup(params RestoreParam) (string, error) {
+	encZipPath := params.BackupFile
+	if exist := utils.FileExist(encZipPath); !exist {
+		return "", fmt.Errorf("can not find %s", encZipPath)
+	}
+
+	identities, err := GetBackupIdentities(params.Password, params.IdentityFile)
+	if err != nil {
+		return "", err
+	}
+	unZipDir, err := decryptAndUnzipBackup(encZipPath, identities, filepath.Dir(encZipPath), params.Progress)
+	if err != nil {
+		return "", err
+	}
+	defer utils.RemoveAll(unZipDir)
+
+	manifest, err := LoadBackupManifest(getBackupManifestPath(unZipDir))
+	if err != nil {
+		return "", fmt.Errorf("load group backup manifest failed: %s", err)
+	}
+	if manifest.Type != BackupTypeGroup || manifest.GroupId == "" {
+		return "", fmt.Errorf("backup type is %s, expect %s", manifest.Type, BackupTypeGroup)
+	}
+	groupId := manifest.GroupId
+
+	// check genesis block
+	seedFileName := getGroupSeedFileName(groupId)
+	srcSeedPath := filepath.Join(getSeedBackupPath(unZipDir), seedFileName)
+	seed, err := loadGroupSeedUrlFile(srcSeedPath)
+	if err != nil {
+		return "", fmt.Errorf("load group seed failed: %s", err)
+	}
+	if err := checkGroupGenesis(params, seed); err != nil {
+		return "", err
+	}
+
+	// merge keys, keep the existing key files
+	if err := utils.CheckAndCreateDir(params.KeystoreDir); err != nil {
+		return "", fmt.Errorf("create directory %s failed: %s", params.KeystoreDir, err)
+	}
+	for _, name := range getGroupKeyFiles(groupId) {
+		src := filepath.Join(getKeystoreBackupPath(unZipDir), name)
+		dst := filepath.Join(params.KeystoreDir, name)
+		if !utils.FileExist(src) || utils.FileExist(dst) {
+			continue
+		}
+		if err := utils.Copy(src, dst); err != nil {
+			return "", fmt.Errorf("copy %s => %s failed: %s", src, dst, err)
+		}
+	}
+	if len(manifest.SignKeyMap) > 0 {
+		nodeoptions, err := options.InitNodeOptions(params.ConfigDir, params.Peername)
+		if err != nil {
+			return "", fmt.Errorf("options.InitNodeOptions failed: %s", err)
+		}
+		for keyname, addr := range manifest.SignKeyMap {
+			if _, ok := nodeoptions.SignKeyMap[keyname]; ok {
+				continue
+			}
+			if err := nodeoptions.SetSignKeyMap(keyname, addr); err != nil {
+				return "", fmt.Errorf("set sign key map failed: %s", err)
+			}
+		}
+	}
+
+	// merge blocks
+	dstDBDir := params.nodePaths().ChainDb
+	dstDbMgr, err := createNodeDb(params.nodePaths())
+	if err != nil {
+		return "", fmt.Errorf("storage.CreateDb %s failed: %s", dstDBDir, err)
+	}
+	defer dstDbMgr.Db.Close()
+	defer dstDbMgr.GroupInfoDb.Close()
+
+	prefix := storage.GetBlockPrefix(groupId, nodename)
+	err = importBackupBlocks(unZipDir, manifest.Peername, dstDbMgr.Db, func(k []byte) bool {
+		if !strings.HasPrefix(string(k), prefix) {
+			return false
+		}
+		// do not overwrite existing blocks
+		exist, err := dstDbMgr.Db.IsExist(k)
+		return err == nil && !exist
+	})
+	if err != nil {
+		return "", fmt.Errorf("restore group blocks failed: %s", err)
+	}
+
+	// copy seed, it is used to join the group
+	if err := utils.CheckAndCreateDir(params.SeedDir); err != nil {
+		return "", fmt.Errorf("create directory %s failed: %s", params.SeedDir, err)
+	}
+	if err := utils.Copy(srcSeedPath, filepath.Join(params.SeedDir, seedFileName)); err != nil {
+		return "", fmt.Errorf("copy seed failed: %s", err)
+	}
+
+	return groupId, nil
+}
+
+// checkGroupGenesis returns error if the group seed exists with a different genesis block
+func checkGroupGenesis(params RestoreParam, seed *GroupSeed) error {
+	if seed.GenesisBlock == nil {
+		return fmt.Errorf("genesis block not found in seed of group %s", seed.GroupId)
+	}
+
+	dataPath := params.nodePaths().AppDb
+	if !utils.DirExist(dataPath) {
+		return nil
+	}
+
+	appdb, err := appdata.CreateAppDb(dataPath)
+	if err != nil {
+		return fmt.Errorf("appdata.CreateAppDb failed: %s", err)
+	}
+	defer appdb.Db.Close()
+
+	existSeed, err := appdb.GetGroupSeed(seed.GroupId)
+	if err != nil {
+		return fmt.Errorf("get group seed failed: %s", err)
+	}
+	if existSeed == nil {
+		return nil
+	}
+
+	if existSeed.GenesisBlock == nil || !bytes.Equal(existSeed.GenesisBlock.BlockHash, seed.GenesisBlock.BlockHash) {
+		return fmt.Errorf("group %s exists with a different genesis block", seed.GroupId)
+	}
+
+	return nil
+}
+
+// loadGroupSeedUrlFile load seed from file saved by SaveAllGroupSeeds
+func loadGroupSeedUrlFile(path string) (*GroupSeed, error) {
+	seedByte, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var result CreateGroupResult
+	if err := json.Unmarshal(seedByte, &result); err != nil {
+		return nil, err
+	}
+
+	seed, _, err := UrlToGroupSeed(result.Seed)
+	return seed, err
+}
@@ -45,6 +45,16 @@ func SaveAllGroupSeeds(appdb *appdata.AppDb, seedDir string) error {
 		return err
 	}
 
+	return forEachGroupSeedFile(appdb, func(name string, data []byte) error {
+		if err := ioutil.WriteFile(filepath.Join(seedDir, name), data, 0644); err != nil {
+			return fmt.Errorf("write group seed failed: %s", err)
+		}
+		return nil
+	})
+}
+
+// forEachGroupSeedFile call `fn` with the file name and content of each group seed
+func forEachGroupSeedFile(appdb *appdata.AppDb, fn func(name string, data []byte) error) error {
 	seeds, err := GetAllGroupSeeds(appdb)
 	if err != nil {
 		return err
@@ -56,9 +66,8 @@ func SaveAllGroupSeeds(appdb *appdata.AppDb, seedDir string) error {
 			return fmt.Errorf("marshal group seed failed: %s", err)
 		}
 
-		path := filepath.Join(seedDir, fmt.Sprintf("%s.json", seed.GroupId))
-		if err := ioutil.WriteFile(path, seedByte, 0644); err != nil {
-			return fmt.Errorf("write group seed failed: %s", err)
+		if err := fn(fmt.Sprintf("%s.json", seed.GroupId), seedByte); err != nil {
+			return err
 		}
 	}
 
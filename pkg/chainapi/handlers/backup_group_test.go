@@ -0,0 +1,47 @@
+//go:build !js
+// +build !js
+
+package handlers
+
+import (
+	"testing"
+
+	"github.com/rumsystem/quorum/internal/pkg/appdata"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+func TestCheckGroupGenesis(t *testing.T) {
+	params := RestoreParam{Peername: "peer", DataDir: t.TempDir()}
+
+	appdb, err := appdata.CreateAppDb(GetDataPath(params.DataDir, params.Peername))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exist := &quorumpb.GroupSeed{
+		GroupId:      "g1",
+		GenesisBlock: &quorumpb.Block{GroupId: "g1", BlockHash: []byte("hash-a")},
+	}
+	if err := appdb.SetGroupSeed(exist); err != nil {
+		t.Fatal(err)
+	}
+	appdb.Db.Close()
+
+	tests := []struct {
+		name    string
+		seed    *GroupSeed
+		wantErr bool
+	}{
+		{"same genesis", &GroupSeed{GroupId: "g1", GenesisBlock: &quorumpb.Block{BlockHash: []byte("hash-a")}}, false},
+		{"different genesis", &GroupSeed{GroupId: "g1", GenesisBlock: &quorumpb.Block{BlockHash: []byte("hash-b")}}, true},
+		{"new group", &GroupSeed{GroupId: "g2", GenesisBlock: &quorumpb.Block{BlockHash: []byte("hash-c")}}, false},
+		{"no genesis", &GroupSeed{GroupId: "g3"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkGroupGenesis(params, tt.seed); (err != nil) != tt.wantErr {
+				t.Errorf("checkGroupGenesis() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+//go:build !js
+// +build !js
+
+package handlers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/rumsystem/quorum/internal/pkg/appdata"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+)
+
+// BackupIncremental backup group seeds and the blocks newer than the watermark recorded in `baseManifestFile`,
+// the result should be restored on top of the base backup
+func BackupIncremental(param BackupParam, baseManifestFile string) error {
+	password, err := GetKeystorePassword(param.Password)
+	if err != nil {
+		return fmt.Errorf("handlers.GetKeystorePassword failed: %s", err)
+	}
+
+	base, err := LoadBackupManifest(baseManifestFile)
+	if err != nil {
+		return fmt.Errorf("load base backup manifest failed: %s", err)
+	}
+	if base.Peername != param.Peername {
+		return fmt.Errorf("base backup peername %s mismatch with %s", base.Peername, param.Peername)
+	}
+
+	dstPath := param.BackupFile
+	if utils.DirExist(dstPath) || utils.FileExist(dstPath) {
+		return fmt.Errorf("backup directory %s is exists", dstPath)
+	}
+
+	dstPath, err = filepath.Abs(dstPath)
+	if err != nil {
+		return fmt.Errorf("get abs path for %s failed: %s", dstPath, err)
+	}
+	defer utils.RemoveAll(dstPath)
+
+	// save all group seeds, new joined groups need them
+	appdb, err := appdata.CreateAppDb(param.nodePaths().AppDb)
+	if err != nil {
+		return fmt.Errorf("appdata.CreateAppDb failed: %s", err)
+	}
+	defer appdb.Db.Close()
+
+	if err := SaveAllGroupSeeds(appdb, getSeedBackupPath(dstPath)); err != nil {
+		return fmt.Errorf("save group seeds failed: %s", err)
+	}
+
+	// backup blocks after watermark
+	blocks, err := backupBlockSince(param.nodePaths(), getBlockSegmentPath(dstPath), base.Blocks, param.Progress)
+	if err != nil {
+		return err
+	}
+
+	manifest := newBackupManifest(BackupTypeIncremental, param.Peername, base.Blocks, blocks)
+	if err := SaveBackupManifest(manifest, getBackupManifestPath(dstPath)); err != nil {
+		return fmt.Errorf("save backup manifest failed: %s", err)
+	}
+
+	encZipPath := fmt.Sprintf("%s.zip.enc", dstPath)
+	encZipFile, err := os.Create(encZipPath)
+	if err != nil {
+		return fmt.Errorf("os.Create(%s) failed: %s", encZipPath, err)
+	}
+	defer encZipFile.Close()
+
+	recipients, err := getBackupRecipients(password, param.Recipients)
+	if err != nil {
+		return err
+	}
+	if err := encryptZipDirToWriter(dstPath, recipients, encZipFile, param.Progress); err != nil {
+		encZipFile.Close()
+		utils.RemoveAll(encZipPath)
+		return err
+	}
+	if err := encZipFile.Close(); err != nil {
+		return fmt.Errorf("close %s failed: %s", encZipPath, err)
+	}
+
+	if err := SaveBackupManifest(manifest, GetBackupManifestSidecarPath(dstPath)); err != nil {
+		return fmt.Errorf("save backup manifest failed: %s", err)
+	}
+
+	logger.Infof("success! incremental backup file: %s", encZipPath)
+	return nil
+}
+
+// restoreIncremental apply the incremental backup on top of restored data,
+// refuse it if the base of incremental backup mismatch with the restored blocks
+func restoreIncremental(params RestoreParam, encZipPath string) error {
+	identities, err := GetBackupIdentities(params.Password, params.IdentityFile)
+	if err != nil {
+		return err
+	}
+	unZipDir, err := decryptAndUnzipBackup(encZipPath, identities, filepath.Dir(encZipPath), params.Progress)
+	if err != nil {
+		return err
+	}
+	defer utils.RemoveAll(unZipDir)
+
+	manifest, err := LoadBackupManifest(getBackupManifestPath(unZipDir))
+	if err != nil {
+		return fmt.Errorf("load incremental backup manifest failed: %s", err)
+	}
+
+	dstDBDir := params.nodePaths().ChainDb
+	dstDbMgr, err := createNodeDb(params.nodePaths())
+	if err != nil {
+		return fmt.Errorf("storage.CreateDb %s failed: %s", dstDBDir, err)
+	}
+	defer dstDbMgr.Db.Close()
+	defer dstDbMgr.GroupInfoDb.Close()
+
+	restored, err := GetHighestBlocks(dstDbMgr.Db)
+	if err != nil {
+		return fmt.Errorf("get restored blocks failed: %s", err)
+	}
+	if err := checkIncrementalBase(manifest, restored); err != nil {
+		return fmt.Errorf("can not apply %s: %s", encZipPath, err)
+	}
+
+	if err := importBackupBlocks(unZipDir, manifest.Peername, dstDbMgr.Db, nil); err != nil {
+		return fmt.Errorf("restore incremental blocks failed: %s", err)
+	}
+
+	// seeds of the incremental backup are newer
+	if err := utils.Copy(getSeedBackupPath(unZipDir), params.SeedDir); err != nil {
+		return fmt.Errorf("copy seeds failed: %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+//go:build !js
+// +build !js
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rumsystem/quorum/internal/pkg/storage"
+)
+
+const (
+	BackupTypeFull        = "full"
+	BackupTypeIncremental = "incremental"
+	BackupTypeGroup       = "group"
+
+	backupManifestVersion  = 1
+	backupManifestFileName = "manifest.json"
+)
+
+// BackupManifest describes the blocks contained in a backup archive.
+// An incremental backup records the highest block id per group of the
+// backup it builds on, so restore can refuse to apply it on a mismatched base.
+type BackupManifest struct {
+	Version   int               `json:"version"`
+	Type      string            `json:"type"`
+	Peername  string            `json:"peername"`
+	CreatedAt int64             `json:"created_at"`
+	Base      map[string]uint64 `json:"base,omitempty"` // group id => highest block id of the base backup
+	Blocks    map[string]uint64 `json:"blocks"`         // group id => highest block id in this backup
+
+	// only for group backup
+	GroupId    string            `json:"group_id,omitempty"`
+	SignKeyMap map[string]string `json:"sign_key_map,omitempty"`
+}
+
+func getBackupManifestPath(dstPath string) string {
+	return filepath.Join(dstPath, backupManifestFileName)
+}
+
+// GetBackupManifestSidecarPath returns the plain manifest path written next to the encrypted backup file,
+// it is used as the watermark for the next incremental backup
+func GetBackupManifestSidecarPath(backupFile string) string {
+	return fmt.Sprintf("%s.%s", backupFile, backupManifestFileName)
+}
+
+func newBackupManifest(_type, peerName string, base, blocks map[string]uint64) *BackupManifest {
+	return &BackupManifest{
+		Version:   backupManifestVersion,
+		Type:      _type,
+		Peername:  peerName,
+		CreatedAt: time.Now().Unix(),
+		Base:      base,
+		Blocks:    blocks,
+	}
+}
+
+func LoadBackupManifest(path string) (*BackupManifest, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest BackupManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("unmarshal backup manifest failed: %s", err)
+	}
+	if manifest.Version != backupManifestVersion {
+		return nil, fmt.Errorf("unsupported backup manifest version: %d", manifest.Version)
+	}
+	if manifest.Blocks == nil {
+		manifest.Blocks = map[string]uint64{}
+	}
+
+	return &manifest, nil
+}
+
+func SaveBackupManifest(manifest *BackupManifest, path string) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// parseBlockKey get group id and block id from block key, e.g. default_blk_<group_id>_<block_id>
+func parseBlockKey(key []byte) (string, uint64, error) {
+	prefix := getBlockPrefixKey()
+	if !strings.HasPrefix(string(key), prefix) {
+		return "", 0, fmt.Errorf("invalid block key: %s", key)
+	}
+
+	suffix := strings.TrimPrefix(string(key), prefix)
+	idx := strings.LastIndex(suffix, "_")
+	if idx <= 0 {
+		return "", 0, fmt.Errorf("invalid block key: %s", key)
+	}
+
+	blockId, err := strconv.ParseUint(suffix[idx+1:], 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid block id in key %s: %s", key, err)
+	}
+
+	return suffix[:idx], blockId, nil
+}
+
+// GetHighestBlocks returns the highest block id per group in db
+func GetHighestBlocks(db storage.QuorumStorage) (map[string]uint64, error) {
+	result := map[string]uint64{}
+	key := getBlockPrefixKey()
+	err := db.PrefixForeach([]byte(key), func(k []byte, v []byte, err error) error {
+		if err != nil {
+			return err
+		}
+
+		groupId, blockId, err := parseBlockKey(k)
+		if err != nil {
+			return err
+		}
+		if highest, ok := result[groupId]; !ok || blockId > highest {
+			result[groupId] = blockId
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+// checkIncrementalBase check the incremental backup builds on the restored blocks
+func checkIncrementalBase(manifest *BackupManifest, restored map[string]uint64) error {
+	if manifest.Type != BackupTypeIncremental {
+		return fmt.Errorf("backup type is %s, expect %s", manifest.Type, BackupTypeIncremental)
+	}
+
+	for groupId, base := range manifest.Base {
+		highest, ok := restored[groupId]
+		if !ok {
+			return fmt.Errorf("group %s not found in restored data, expect highest block %d", groupId, base)
+		}
+		if highest != base {
+			return fmt.Errorf("group %s highest block mismatch, restored: %d, incremental base: %d", groupId, highest, base)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+//go:build !js
+// +build !js
+
+package handlers
+
+import (
+	"testing"
+)
+
+func TestParseBlockKey(t *testing.T) {
+	key := getBlockPrefixKey() + "a7c3d5e2-5b1f-4c8a-9e2d-1f3b5a7c9e0d_12"
+	groupId, blockId, err := parseBlockKey([]byte(key))
+	if err != nil {
+		t.Fatalf("parseBlockKey failed: %s", err)
+	}
+	if groupId != "a7c3d5e2-5b1f-4c8a-9e2d-1f3b5a7c9e0d" || blockId != 12 {
+		t.Errorf("parseBlockKey got %s %d", groupId, blockId)
+	}
+
+	if _, _, err := parseBlockKey([]byte("default_trx_xxx_1")); err == nil {
+		t.Errorf("parseBlockKey should fail for non block key")
+	}
+}
+
+func TestCheckIncrementalBase(t *testing.T) {
+	manifest := newBackupManifest(BackupTypeIncremental, "peer", map[string]uint64{"g1": 10}, map[string]uint64{"g1": 15})
+
+	if err := checkIncrementalBase(manifest, map[string]uint64{"g1": 10, "g2": 3}); err != nil {
+		t.Errorf("checkIncrementalBase failed: %s", err)
+	}
+	if err := checkIncrementalBase(manifest, map[string]uint64{"g1": 9}); err == nil {
+		t.Errorf("checkIncrementalBase should fail on mismatched base")
+	}
+	if err := checkIncrementalBase(manifest, map[string]uint64{}); err == nil {
+		t.Errorf("checkIncrementalBase should fail on missing group")
+	}
+
+	full := newBackupManifest(BackupTypeFull, "peer", nil, map[string]uint64{"g1": 10})
+	if err := checkIncrementalBase(full, map[string]uint64{"g1": 10}); err == nil {
+		t.Errorf("checkIncrementalBase should fail on full backup")
+	}
+}
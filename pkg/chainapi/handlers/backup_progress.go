@@ -0,0 +1,70 @@
+//go:build !js
+// +build !js
+
+package handlers
+
+import (
+	"io"
+)
+
+// ProgressFunc reports the progress of backup and restore stages, total <= 0 means unknown
+type ProgressFunc func(stage string, done, total int64)
+
+const (
+	ProgressStageConfig   = "config"
+	ProgressStageKeystore = "keystore"
+	ProgressStageSeeds    = "seeds"
+	ProgressStageBlocks   = "blocks"
+	ProgressStageZip      = "zip"
+	ProgressStageEncrypt  = "encrypt"
+	ProgressStageDecrypt  = "decrypt"
+	ProgressStageUnzip    = "unzip"
+)
+
+func (f ProgressFunc) report(stage string, done, total int64) {
+	if f != nil {
+		f(stage, done, total)
+	}
+}
+
+// stage returns the progress function of the stage, nil if f is nil
+func (f ProgressFunc) stage(stage string) func(done, total int64) {
+	if f == nil {
+		return nil
+	}
+	return func(done, total int64) {
+		f(stage, done, total)
+	}
+}
+
+// progressWriter reports the bytes written to the underlying writer
+type progressWriter struct {
+	w        io.Writer
+	stage    string
+	done     int64
+	total    int64
+	progress ProgressFunc
+}
+
+func (pw *progressWriter) Write(p []byte) (int, error) {
+	n, err := pw.w.Write(p)
+	pw.done += int64(n)
+	pw.progress.report(pw.stage, pw.done, pw.total)
+	return n, err
+}
+
+// progressReader reports the bytes read from the underlying reader
+type progressReader struct {
+	r        io.Reader
+	stage    string
+	done     int64
+	total    int64
+	progress ProgressFunc
+}
+
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.r.Read(p)
+	pr.done += int64(n)
+	pr.progress.report(pr.stage, pr.done, pr.total)
+	return n, err
+}
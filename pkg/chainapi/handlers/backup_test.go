@@ -0,0 +1,104 @@
+//go:build !js
+// +build !js
+
+package handlers
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rumsystem/quorum/internal/pkg/options"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
+)
+
+func TestWriteBackupZip(t *testing.T) {
+	dataDir, configDir, keystoreDir := t.TempDir(), t.TempDir(), t.TempDir()
+	createTestBlockDb(t, dataDir, "peer", map[string]uint64{"g1": blockSegmentSize + 10, "g2": 3})
+	if err := ioutil.WriteFile(filepath.Join(configDir, "peer_options.toml"), []byte("peername = \"peer\""), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(keystoreDir, localcrypto.Sign.Prefix()+"default"), []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	param := BackupParam{Peername: "peer", ConfigDir: configDir, KeystoreDir: keystoreDir, DataDir: dataDir}
+
+	// a staging directory left by a killed backup of an older release
+	staging := filepath.Join(param.nodePaths().ChainDb, ".backup-staging-123")
+	if err := utils.EnsureDir(staging); err != nil {
+		t.Fatal(err)
+	}
+	removeStaleBackupStaging(param.nodePaths().ChainDb)
+	if utils.DirExist(staging) {
+		t.Errorf("stale staging directory should be removed")
+	}
+
+	encZipPath := filepath.Join(t.TempDir(), "backup.zip.enc")
+	f, err := os.Create(encZipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest *BackupManifest
+	err = encryptZipToWriter(mustGetBackupRecipients(t, "123456"), f, nil, func(w io.Writer) error {
+		var err error
+		manifest, err = writeBackupZip(param, w)
+		return err
+	})
+	f.Close()
+	if err != nil {
+		t.Fatalf("writeBackupZip failed: %s", err)
+	}
+	if manifest.Blocks["g1"] != blockSegmentSize+9 || manifest.Blocks["g2"] != 2 {
+		t.Errorf("unexpected manifest blocks: %v", manifest.Blocks)
+	}
+
+	// nothing is staged under the data directory
+	if dirs, _ := filepath.Glob(filepath.Join(param.nodePaths().ChainDb, ".backup-staging-*")); len(dirs) != 0 {
+		t.Errorf("backup should not stage data on disk, got %v", dirs)
+	}
+
+	summary, err := VerifyBackup(encZipPath, "123456")
+	if err != nil {
+		t.Fatalf("VerifyBackup failed: %s", err)
+	}
+	if summary.Manifest == nil || summary.GroupCount != 2 || summary.BlockCount["g1"] != blockSegmentSize+10 || summary.BlockCount["g2"] != 3 || summary.KeystoreKeys != 1 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+
+	// the streamed backup restores as the staged one
+	identities, err := GetBackupIdentities("123456", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unZipDir, err := decryptAndUnzipBackup(encZipPath, identities, t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("decryptAndUnzipBackup failed: %s", err)
+	}
+	content, err := ioutil.ReadFile(filepath.Join(getConfigBackupPath(unZipDir), "peer_options.toml"))
+	if err != nil {
+		t.Fatalf("read restored config failed: %s", err)
+	}
+	if string(content) != "peername = \"peer\"" {
+		t.Errorf("restored config mismatch: %s", content)
+	}
+	restoredDir := t.TempDir()
+	dbMgr, err := createNodeDb(options.NewNodePaths(restoredDir, "peer"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dbMgr.Db.Close()
+	defer dbMgr.GroupInfoDb.Close()
+	if err := importBackupBlocks(unZipDir, "peer", dbMgr.Db, nil); err != nil {
+		t.Fatalf("importBackupBlocks failed: %s", err)
+	}
+	items, _, err := listBlockItems(dbMgr.Db, getBlockPrefixKey(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != blockSegmentSize+13 {
+		t.Errorf("expect %d restored blocks, got %d", blockSegmentSize+13, len(items))
+	}
+}
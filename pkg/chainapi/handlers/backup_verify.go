@@ -0,0 +1,184 @@
+//go:build !js
+// +build !js
+
+package handlers
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// BackupSummary is the result of VerifyBackup
+type BackupSummary struct {
+	Manifest     *BackupManifest   `json:"manifest,omitempty"` // nil for backups without manifest
+	Peername     string            `json:"peername"`
+	GroupCount   int               `json:"group_count"`
+	SeedCount    int               `json:"seed_count"`
+	BlockCount   map[string]uint64 `json:"block_count"` // group id => block count
+	KeystoreKeys int               `json:"keystore_keys"`
+}
+
+// VerifyBackup decrypt and unzip the backup file into a temp directory,
+// check config/keystore/seeds/block db are present and every block can be unmarshaled
+func VerifyBackup(backupFile, password string) (*BackupSummary, error) {
+	identities, err := GetBackupIdentities(password, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return VerifyBackupWithIdentities(backupFile, identities)
+}
+
+// VerifyBackupWithIdentities is VerifyBackup for backups encrypted to age recipients
+func VerifyBackupWithIdentities(backupFile string, identities []age.Identity) (*BackupSummary, error) {
+	if !utils.FileExist(backupFile) {
+		return nil, fmt.Errorf("can not find %s", backupFile)
+	}
+
+	workDir, err := os.MkdirTemp("", "quorum-verify-")
+	if err != nil {
+		return nil, fmt.Errorf("create temp directory failed: %s", err)
+	}
+	defer utils.RemoveAll(workDir)
+
+	unZipDir, err := decryptAndUnzipBackup(backupFile, identities, workDir, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := BackupSummary{BlockCount: map[string]uint64{}}
+	manifestPath := getBackupManifestPath(unZipDir)
+	if utils.FileExist(manifestPath) {
+		manifest, err := LoadBackupManifest(manifestPath)
+		if err != nil {
+			return nil, fmt.Errorf("load backup manifest failed: %s", err)
+		}
+		summary.Manifest = manifest
+		summary.Peername = manifest.Peername
+	} else {
+		// backup without manifest, there should be only one peer in data directory
+		peername, err := getBackupPeername(unZipDir)
+		if err != nil {
+			return nil, err
+		}
+		summary.Peername = peername
+	}
+
+	isFull := summary.Manifest == nil || summary.Manifest.Type == BackupTypeFull
+	if isFull {
+		// full backup should contains config and keystore
+		if !utils.DirExist(getConfigBackupPath(unZipDir)) {
+			return nil, fmt.Errorf("config directory not found in backup")
+		}
+
+		keystoreDir := getKeystoreBackupPath(unZipDir)
+		if !utils.DirExist(keystoreDir) {
+			return nil, fmt.Errorf("keystore directory not found in backup")
+		}
+		keyCount, err := countKeystoreKeys(keystoreDir)
+		if err != nil {
+			return nil, err
+		}
+		if keyCount == 0 {
+			return nil, fmt.Errorf("no key found in backup keystore")
+		}
+		summary.KeystoreKeys = keyCount
+	}
+
+	seedDir := getSeedBackupPath(unZipDir)
+	if !utils.DirExist(seedDir) {
+		return nil, fmt.Errorf("seeds directory not found in backup")
+	}
+	seeds, err := ioutil.ReadDir(seedDir)
+	if err != nil {
+		return nil, fmt.Errorf("read seeds directory failed: %s", err)
+	}
+	for _, seed := range seeds {
+		if !seed.IsDir() && strings.HasSuffix(seed.Name(), ".json") {
+			summary.SeedCount++
+		}
+	}
+
+	if err := verifyBackupBlocks(unZipDir, summary.Peername, summary.BlockCount); err != nil {
+		return nil, err
+	}
+	summary.GroupCount = len(summary.BlockCount)
+
+	return &summary, nil
+}
+
+// verifyBackupBlocks unmarshal every block in backup, count blocks per group
+func verifyBackupBlocks(unZipDir, peerName string, blockCount map[string]uint64) error {
+	return forEachBackupBlock(unZipDir, peerName, func(k []byte, v []byte) error {
+		if err := checkBackupBlock(k, v); err != nil {
+			return err
+		}
+
+		groupId, _, _ := parseBlockKey(k)
+		blockCount[groupId]++
+		return nil
+	})
+}
+
+// checkBackupBlock unmarshal the block and check it matches the key
+func checkBackupBlock(k, v []byte) error {
+	groupId, blockId, err := parseBlockKey(k)
+	if err != nil {
+		return err
+	}
+
+	var block quorumpb.Block
+	if err := proto.Unmarshal(v, &block); err != nil {
+		return fmt.Errorf("unmarshal block %d of group %s failed: %s", blockId, groupId, err)
+	}
+	if block.GroupId != groupId || block.BlockId != blockId {
+		return fmt.Errorf("block %d of group %s mismatch with key %s", block.BlockId, block.GroupId, k)
+	}
+
+	return nil
+}
+
+func getBackupPeername(unZipDir string) (string, error) {
+	items, err := ioutil.ReadDir(filepath.Join(unZipDir, "data"))
+	if err != nil {
+		return "", fmt.Errorf("read data directory in backup failed: %s", err)
+	}
+
+	peers := []string{}
+	for _, item := range items {
+		if item.IsDir() {
+			peers = append(peers, item.Name())
+		}
+	}
+	if len(peers) != 1 {
+		return "", fmt.Errorf("expect one peer in backup data directory, got: %v", peers)
+	}
+
+	return peers[0], nil
+}
+
+func countKeystoreKeys(keystoreDir string) (int, error) {
+	files, err := ioutil.ReadDir(keystoreDir)
+	if err != nil {
+		return 0, fmt.Errorf("read keystore directory failed: %s", err)
+	}
+
+	count := 0
+	for _, f := range files {
+		name := f.Name()
+		if strings.HasPrefix(name, localcrypto.Sign.Prefix()) || strings.HasPrefix(name, localcrypto.Encrypt.Prefix()) {
+			count++
+		}
+	}
+
+	return count, nil
+}
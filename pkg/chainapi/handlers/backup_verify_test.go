@@ -0,0 +1,78 @@
+//go:build !js
+// +build !js
+
+package handlers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rumsystem/quorum/internal/pkg/storage"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestVerifyBackup(t *testing.T) {
+	srcDir := t.TempDir()
+	for _, dir := range []string{getConfigBackupPath(srcDir), getKeystoreBackupPath(srcDir), getSeedBackupPath(srcDir)} {
+		if err := utils.EnsureDir(dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	keyfile := filepath.Join(getKeystoreBackupPath(srcDir), localcrypto.Sign.Prefix()+"default")
+	if err := ioutil.WriteFile(keyfile, []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(getSeedBackupPath(srcDir), "g1.json"), []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	dbMgr, err := storage.CreateDb(getDataBackupPath(srcDir, "peer"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := uint64(0); i < 3; i++ {
+		block := &quorumpb.Block{GroupId: "g1", BlockId: i}
+		value, err := proto.Marshal(block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key := storage.GetBlockKey(block.GroupId, block.BlockId, nodename)
+		if err := dbMgr.Db.Set([]byte(key), value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dbMgr.Db.Close()
+	dbMgr.GroupInfoDb.Close()
+
+	manifest := newBackupManifest(BackupTypeFull, "peer", nil, map[string]uint64{"g1": 2})
+	if err := SaveBackupManifest(manifest, getBackupManifestPath(srcDir)); err != nil {
+		t.Fatal(err)
+	}
+
+	encZipPath := filepath.Join(t.TempDir(), "backup.zip.enc")
+	f, err := os.Create(encZipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := encryptZipDirToWriter(srcDir, mustGetBackupRecipients(t, "123456"), f, nil); err != nil {
+		t.Fatalf("encryptZipDirToWriter failed: %s", err)
+	}
+	f.Close()
+
+	summary, err := VerifyBackup(encZipPath, "123456")
+	if err != nil {
+		t.Fatalf("VerifyBackup failed: %s", err)
+	}
+	if summary.GroupCount != 1 || summary.BlockCount["g1"] != 3 || summary.SeedCount != 1 || summary.KeystoreKeys != 1 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+
+	if _, err := VerifyBackup(encZipPath, "654321"); err == nil {
+		t.Errorf("VerifyBackup should fail with wrong password")
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/libp2p/go-libp2p/core/metrics"
+	"github.com/rumsystem/quorum/internal/pkg/conn/p2p"
+)
+
+const (
+	BandwidthViewAll   = "all"
+	BandwidthViewTotal = "total"
+	BandwidthViewRate  = "rate"
+)
+
+type GetBandwidthParam struct {
+	View string `query:"view" json:"view" validate:"omitempty,oneof=all total rate" example:"rate"` // total for cumulative bytes, rate for bytes per second, default all
+}
+
+// BandwidthStats is the traffic since the node started, totals in bytes and rates in bytes per second
+type BandwidthStats struct {
+	TotalIn  *int64   `json:"total_in,omitempty" example:"10240"`
+	TotalOut *int64   `json:"total_out,omitempty" example:"20480"`
+	RateIn   *float64 `json:"rate_in,omitempty" example:"12.5"`
+	RateOut  *float64 `json:"rate_out,omitempty" example:"30.2"`
+}
+
+type BandwidthResult struct {
+	Total     BandwidthStats            `json:"total"`
+	Peers     map[string]BandwidthStats `json:"peers"`     // by peer id
+	Protocols map[string]BandwidthStats `json:"protocols"` // by protocol id
+}
+
+func newBandwidthStats(stats metrics.Stats, view string) BandwidthStats {
+	var res BandwidthStats
+	if view != BandwidthViewRate {
+		res.TotalIn, res.TotalOut = &stats.TotalIn, &stats.TotalOut
+	}
+	if view != BandwidthViewTotal {
+		res.RateIn, res.RateOut = &stats.RateIn, &stats.RateOut
+	}
+	return res
+}
+
+// GetBandwidth returns the traffic of the node by peer and by protocol, the counters are reset only on restart
+func GetBandwidth(node *p2p.Node, params *GetBandwidthParam) (*BandwidthResult, error) {
+	validate := validator.New()
+	if err := validate.Struct(params); err != nil {
+		return nil, err
+	}
+	if node == nil || node.Bandwidth == nil {
+		return nil, errors.New("bandwidth reporter is not available")
+	}
+
+	res := &BandwidthResult{
+		Total:     newBandwidthStats(node.Bandwidth.GetBandwidthTotals(), params.View),
+		Peers:     make(map[string]BandwidthStats),
+		Protocols: make(map[string]BandwidthStats),
+	}
+	for pid, stats := range node.Bandwidth.GetBandwidthByPeer() {
+		res.Peers[pid.String()] = newBandwidthStats(stats, params.View)
+	}
+	for proto, stats := range node.Bandwidth.GetBandwidthByProtocol() {
+		res.Protocols[string(proto)] = newBandwidthStats(stats, params.View)
+	}
+	return res, nil
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/metrics"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/rumsystem/quorum/internal/pkg/conn/p2p"
+)
+
+func TestGetBandwidth(t *testing.T) {
+	pid, err := peer.Decode("16Uiu2HAm5waftP3s4oE1EzGF2SyWeK726P5B8BSgFJqSiz6xScGz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	node := &p2p.Node{Bandwidth: metrics.NewBandwidthCounter()}
+	node.Bandwidth.LogSentMessageStream(100, "/quorum/test", pid)
+	node.Bandwidth.LogRecvMessageStream(50, "/quorum/test", pid)
+
+	res, err := GetBandwidth(node, &GetBandwidthParam{})
+	if err != nil {
+		t.Fatalf("get bandwidth failed: %s", err)
+	}
+	stats, ok := res.Peers[pid.String()]
+	if !ok || stats.TotalIn == nil || stats.RateIn == nil {
+		t.Errorf("expect totals and rates of peer %s, got %+v", pid, res.Peers)
+	}
+	if _, ok := res.Protocols["/quorum/test"]; !ok {
+		t.Errorf("expect stats of protocol /quorum/test, got %+v", res.Protocols)
+	}
+
+	res, err = GetBandwidth(node, &GetBandwidthParam{View: BandwidthViewRate})
+	if err != nil {
+		t.Fatalf("get bandwidth rate failed: %s", err)
+	}
+	if res.Total.TotalIn != nil || res.Total.RateOut == nil {
+		t.Errorf("expect rates only, got %+v", res.Total)
+	}
+
+	res, err = GetBandwidth(node, &GetBandwidthParam{View: BandwidthViewTotal})
+	if err != nil {
+		t.Fatalf("get bandwidth total failed: %s", err)
+	}
+	if res.Total.RateIn != nil || res.Total.TotalOut == nil {
+		t.Errorf("expect totals only, got %+v", res.Total)
+	}
+
+	if _, err := GetBandwidth(node, &GetBandwidthParam{View: "bad"}); err == nil {
+		t.Errorf("expect error for invalid view")
+	}
+	if _, err := GetBandwidth(&p2p.Node{}, &GetBandwidthParam{}); err == nil {
+		t.Errorf("expect error without bandwidth reporter")
+	}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/rumsystem/quorum/internal/pkg/appdata"
+	"github.com/rumsystem/quorum/internal/pkg/blobstore"
+	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
+	"github.com/rumsystem/quorum/internal/pkg/nodectx"
+	"github.com/rumsystem/quorum/internal/pkg/storage/def"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+type PostBlobParam struct {
+	GroupId     string             `param:"group_id" json:"group_id" validate:"required,uuid4" example:"ac0eea7c-2f3c-4c67-80b3-136e46b924a8"`
+	Content     []byte             `json:"content" validate:"required" swaggertype:"string" format:"base64" example:"aGVsbG8gcXVvcnVt"` // the bytes of the media in base64
+	MediaType   string             `json:"media_type,omitempty" validate:"omitempty,max=128" example:"image/png"`
+	Name        string             `json:"name,omitempty" validate:"omitempty,max=256" example:"cat.png"`
+	RetryPolicy *PublishRetryParam `json:"retry_policy,omitempty"`
+}
+
+type PostBlobResult struct {
+	TrxId string `json:"trx_id" example:"9e54c173-c1dd-429d-91fa-a6b43c14da77"`
+	Hash  string `json:"hash" example:"b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"`
+	Size  int    `json:"size" example:"11"`
+}
+
+type GetBlobParam struct {
+	Hash string `param:"hash" json:"-" validate:"required,len=64,hexadecimal" example:"b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"`
+}
+
+type GetGroupBlobParam struct {
+	GroupId string `param:"group_id" json:"-" validate:"required,uuid4" example:"ac0eea7c-2f3c-4c67-80b3-136e46b924a8"`
+	TrxId   string `param:"trx_id" json:"-" validate:"required,uuid4" example:"9e54c173-c1dd-429d-91fa-a6b43c14da77"` // the Blob trx
+}
+
+// PostBlobToGroup saves the content in the blob store and posts a Blob trx carrying only its hash.
+// The content of a private group is sealed by the group key before it is saved, it is refused if the group has no group key
+func PostBlobToGroup(payload *PostBlobParam) (*PostBlobResult, error) {
+	group, err := getGroup(payload.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	store, err := blobstore.GetStore()
+	if err != nil {
+		return nil, err
+	}
+
+	content := payload.Content
+	if group.Item.EncryptType == quorumpb.GroupEncryptType_PRIVATE {
+		if content, err = chain.SealPrivateBlob(group.Item, content); err != nil {
+			return nil, err
+		}
+	}
+	hash, err := store.Put(content)
+	if err != nil {
+		return nil, fmt.Errorf("save blob failed: %s", err)
+	}
+	data, err := blobstore.NewBlobPost(&blobstore.BlobRef{Hash: hash, Size: len(payload.Content), MediaType: payload.MediaType, Name: payload.Name})
+	if err != nil {
+		return nil, err
+	}
+	trxId, err := group.PostToGroupWithExpiry(data, 0, payload.RetryPolicy.toPolicy())
+	if err != nil {
+		return nil, err
+	}
+	return &PostBlobResult{TrxId: trxId, Hash: hash, Size: len(payload.Content)}, nil
+}
+
+// GetBlob fetches the blob from the blob store, it is verified against the hash.
+// The blob of a private group is returned sealed, see GetGroupBlob
+func GetBlob(params *GetBlobParam) ([]byte, error) {
+	store, err := blobstore.GetStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.Get(params.Hash)
+}
+
+// GetGroupBlob resolves the Blob trx of the group, it fetches the blob verified against the hash of the trx
+// and opens it with the group key if the group is private
+func GetGroupBlob(params *GetGroupBlobParam) ([]byte, *blobstore.BlobRef, error) {
+	group, err := getGroup(params.GroupId)
+	if err != nil {
+		return nil, nil, err
+	}
+	store, err := blobstore.GetStore()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx := nodectx.GetNodeCtx()
+	trx, err := ctx.GetChainStorage().GetTrx(params.GroupId, params.TrxId, def.Chain, ctx.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if trx.TrxId == "" || trx.Type != quorumpb.TrxType_POST {
+		return nil, nil, fmt.Errorf("post %s not found", params.TrxId)
+	}
+	if err := appdata.DecryptPostTrx(group.Item, trx); err != nil {
+		return nil, nil, err
+	}
+	ref := blobstore.ParseBlobPost(trx.Data)
+	if ref == nil {
+		return nil, nil, fmt.Errorf("trx %s is not a Blob post", params.TrxId)
+	}
+
+	stored, err := store.Get(ref.Hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := openBlob(group.Item, ref, stored)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, ref, nil
+}
+
+// openBlob opens the verified blob of the group, the media should have the size of the post
+func openBlob(item *quorumpb.GroupItem, ref *blobstore.BlobRef, stored []byte) ([]byte, error) {
+	data := stored
+	if item.EncryptType == quorumpb.GroupEncryptType_PRIVATE {
+		opened, err := chain.OpenPrivateBlob(item, stored)
+		if err != nil {
+			return nil, fmt.Errorf("open blob %s failed: %s", ref.Hash, err)
+		}
+		data = opened
+	}
+	if len(data) != ref.Size {
+		return nil, fmt.Errorf("blob %s has %d bytes, the post says %d", ref.Hash, len(data), ref.Size)
+	}
+	return data, nil
+}
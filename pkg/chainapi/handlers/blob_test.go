@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/rumsystem/quorum/internal/pkg/blobstore"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+func TestOpenBlob(t *testing.T) {
+	data := []byte("hello world")
+	ref := &blobstore.BlobRef{Hash: blobstore.Hash(data), Size: len(data)}
+
+	public := &quorumpb.GroupItem{GroupId: "5ed3f9fe-81e2-450d-9146-7a329aac2b62", EncryptType: quorumpb.GroupEncryptType_PUBLIC}
+	if got, err := openBlob(public, ref, data); err != nil || string(got) != string(data) {
+		t.Errorf("want %s, got %s %v", data, got, err)
+	}
+	if _, err := openBlob(public, &blobstore.BlobRef{Hash: ref.Hash, Size: 5}, data); err == nil {
+		t.Errorf("a blob not of the size of the post should be rejected")
+	}
+
+	// a blob of a private group is sealed by the group key, the plain bytes are not served
+	private := &quorumpb.GroupItem{GroupId: public.GroupId, EncryptType: quorumpb.GroupEncryptType_PRIVATE}
+	if _, err := openBlob(private, ref, data); err == nil {
+		t.Errorf("a blob of a private group not sealed should be rejected")
+	}
+}
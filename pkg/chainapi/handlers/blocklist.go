@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"github.com/rumsystem/quorum/internal/pkg/appdata"
+)
+
+type BlockAuthorParam struct {
+	GroupId string `param:"group_id" json:"-" validate:"required,uuid4" example:"5ed3f9fe-81e2-450d-9146-7a329aac2b62"`
+	Pubkey  string `json:"pubkey" validate:"required" example:"CAISIQOlA37+ghb05D5ZAKExjsto/H7eeCmkagcZ+BY/pjSOKw=="` // sign pubkey of the author, in the libp2p or the eth base64 format
+	Memo    string `json:"memo" validate:"max=256" example:"spam"`
+}
+
+type UnblockAuthorParam struct {
+	GroupId string `param:"group_id" json:"-" validate:"required,uuid4" example:"5ed3f9fe-81e2-450d-9146-7a329aac2b62"`
+	Pubkey  string `json:"pubkey" validate:"required" example:"CAISIQOlA37+ghb05D5ZAKExjsto/H7eeCmkagcZ+BY/pjSOKw=="` // sign pubkey of the author, in the libp2p or the eth base64 format
+}
+
+type BlockedAuthorsResult struct {
+	GroupId string                   `json:"group_id" example:"5ed3f9fe-81e2-450d-9146-7a329aac2b62"`
+	Authors []*appdata.BlockedAuthor `json:"authors"`
+}
+
+// BlockAuthor hides the posts of the author from the content, search and feed apis of this node,
+// it is a local view filter, nothing is sent to the chain
+func BlockAuthor(params *BlockAuthorParam, appdb *appdata.AppDb) (*appdata.BlockedAuthor, error) {
+	if _, err := getGroup(params.GroupId); err != nil {
+		return nil, err
+	}
+	return appdb.BlockAuthor(params.GroupId, params.Pubkey, params.Memo)
+}
+
+func UnblockAuthor(params *UnblockAuthorParam, appdb *appdata.AppDb) (*BlockedAuthorsResult, error) {
+	if _, err := getGroup(params.GroupId); err != nil {
+		return nil, err
+	}
+	if err := appdb.UnblockAuthor(params.GroupId, params.Pubkey); err != nil {
+		return nil, err
+	}
+	return GetBlockedAuthors(params.GroupId, appdb)
+}
+
+func GetBlockedAuthors(groupId string, appdb *appdata.AppDb) (*BlockedAuthorsResult, error) {
+	if _, err := getGroup(groupId); err != nil {
+		return nil, err
+	}
+	authors, err := appdb.GetBlockedAuthors(groupId)
+	if err != nil {
+		return nil, err
+	}
+	return &BlockedAuthorsResult{GroupId: groupId, Authors: authors}, nil
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/rumsystem/quorum/internal/pkg/appdata"
+	"github.com/rumsystem/quorum/internal/pkg/conn/p2p"
+	"github.com/rumsystem/quorum/internal/pkg/nodectx"
+)
+
+// a chunk post is about 4/3 of the chunk after base64, it should be within the trx data limit and the max message size
+const MAX_CHUNK_SIZE = 192 * 1024
+
+type PostChunkedParam struct {
+	GroupId string `param:"group_id" json:"group_id" validate:"required,uuid4" example:"ac0eea7c-2f3c-4c67-80b3-136e46b924a8"`
+	// the object, e.g. {"type":"Create","object":{"type":"Image","mediaType":"image/png","content":"<base64>"}}
+	Data        map[string]interface{} `json:"data" validate:"required"`
+	RetryPolicy *PublishRetryParam     `json:"retry_policy,omitempty"`
+}
+
+type PostChunkedResult struct {
+	ObjectId string   `json:"object_id" example:"4b2f6e1c-8d3a-4f5e-9a7b-2c1d0e9f8a6b"`
+	TrxIds   []string `json:"trx_ids"` // the chunk trxs in order
+}
+
+type GetChunkedObjectParam struct {
+	GroupId  string `param:"group_id" json:"-" validate:"required,uuid4" example:"ac0eea7c-2f3c-4c67-80b3-136e46b924a8"`
+	ObjectId string `param:"object_id" json:"-" validate:"required,uuid4" example:"4b2f6e1c-8d3a-4f5e-9a7b-2c1d0e9f8a6b"`
+	Sender   string `query:"sender" json:"-" validate:"required" example:"CAISIQOlA37+ghb05D5ZAKExjsto/H7eeCmkagcZ+BY/pjSOKw=="` // sign pubkey of the poster
+}
+
+// ChunkSize is the bytes of the object in a chunk post
+func ChunkSize() int {
+	size := p2p.MaxMessageSize() / 2
+	if size > MAX_CHUNK_SIZE {
+		size = MAX_CHUNK_SIZE
+	}
+	return size
+}
+
+// PostChunkedToGroup posts an object too large for one post as ordered chunk posts, they are reassembled by the content api.
+// If a chunk fails to be sent the result has the trxs sent before it, the object is incomplete to the readers.
+func PostChunkedToGroup(payload *PostChunkedParam) (*PostChunkedResult, error) {
+	group, err := getGroup(payload.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(payload.Data)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid Data field, not json object, json.Marshal failed: %s", err)
+	}
+
+	objectId, posts, err := appdata.SplitObject(data, ChunkSize())
+	if err != nil {
+		return nil, err
+	}
+	res := &PostChunkedResult{ObjectId: objectId, TrxIds: make([]string, 0, len(posts))}
+	for i, post := range posts {
+		trxId, err := group.PostToGroupWithExpiry(post, 0, payload.RetryPolicy.toPolicy())
+		if err != nil {
+			return res, fmt.Errorf("send chunk %d of %d of object %s failed: %s", i, len(posts), objectId, err)
+		}
+		res.TrxIds = append(res.TrxIds, trxId)
+	}
+	return res, nil
+}
+
+// GetChunkedObject reassembles the object posted by the sender from its chunks
+func GetChunkedObject(params *GetChunkedObjectParam, appdb *appdata.AppDb) (*appdata.ChunkedObject, error) {
+	group, err := getGroup(params.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	ctx := nodectx.GetNodeCtx()
+	return appdb.GetChunkedObject(ctx.GetChainStorage(), ctx.Name, group.Item, params.ObjectId, params.Sender)
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	guuid "github.com/google/uuid"
+	"github.com/rumsystem/quorum/internal/pkg/appdata"
+	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
+	rumchaindata "github.com/rumsystem/quorum/pkg/data"
+	"github.com/rumsystem/quorum/pkg/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// CompactSeedPrefix is the prefix of the compact seed, the rest is the url safe base64 of
+// a version byte, the protobuf encoded GroupSeed and a 4 bytes checksum
+const CompactSeedPrefix = "rum://join/"
+
+const (
+	compactSeedVersion  = 1
+	compactSeedChecksum = 4
+)
+
+var ErrInvalidCompactSeed = errors.New("invalid compact seed")
+
+type GetCompactSeedResult struct {
+	Seed string `json:"seed" validate:"required" example:"rum://join/AQqkAQokYzAwMjA5NDEtZTY0OC00MGM5LTkyZGMtNjgyNjQ1YWNkMTdl"`
+}
+
+// ToCompactSeed encodes the group seed to a string short enough for a qr code or a link
+func ToCompactSeed(seed *GroupSeed) (string, error) {
+	pbSeed := ToPbGroupSeed(*seed)
+	data, err := proto.Marshal(&pbSeed)
+	if err != nil {
+		return "", err
+	}
+	raw := append([]byte{compactSeedVersion}, data...)
+	raw = append(raw, compactSeedSum(raw)...)
+	return CompactSeedPrefix + base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// FromCompactSeed decodes the compact seed, the truncated or corrupt seeds and the seeds with an invalid genesis block are rejected
+func FromCompactSeed(compact string) (*GroupSeed, error) {
+	if !strings.HasPrefix(compact, CompactSeedPrefix) {
+		return nil, fmt.Errorf("%w: should start with %s", ErrInvalidCompactSeed, CompactSeedPrefix)
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(compact[len(CompactSeedPrefix):]))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCompactSeed, err)
+	}
+	if len(raw) <= 1+compactSeedChecksum {
+		return nil, fmt.Errorf("%w: truncated", ErrInvalidCompactSeed)
+	}
+	body, sum := raw[:len(raw)-compactSeedChecksum], raw[len(raw)-compactSeedChecksum:]
+	if !bytes.Equal(compactSeedSum(body), sum) {
+		return nil, fmt.Errorf("%w: checksum mismatch, the seed may be truncated or corrupt", ErrInvalidCompactSeed)
+	}
+	if body[0] != compactSeedVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidCompactSeed, body[0])
+	}
+
+	pbSeed := &pb.GroupSeed{}
+	if err := proto.Unmarshal(body[1:], pbSeed); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCompactSeed, err)
+	}
+	seed := FromPbGroupSeed(pbSeed)
+	if err := validateGroupSeed(&seed); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCompactSeed, err)
+	}
+	return &seed, nil
+}
+
+// ParseGroupSeed decodes the seed url or the compact seed, the compact seed has no chain api urls
+func ParseGroupSeed(seed string) (*GroupSeed, []string, error) {
+	if strings.HasPrefix(seed, CompactSeedPrefix) {
+		s, err := FromCompactSeed(seed)
+		return s, nil, err
+	}
+	return UrlToGroupSeed(seed)
+}
+
+// GetCompactSeed returns the compact seed of the joined group
+func GetCompactSeed(groupId string, appdb *appdata.AppDb) (*GetCompactSeedResult, error) {
+	seed, err := GetGroupSeed(groupId, appdb)
+	if err != nil {
+		return nil, err
+	}
+	compact, err := ToCompactSeed(seed)
+	if err != nil {
+		return nil, err
+	}
+	return &GetCompactSeedResult{Seed: compact}, nil
+}
+
+func compactSeedSum(data []byte) []byte {
+	return localcrypto.Hash(data)[:compactSeedChecksum]
+}
+
+// validateGroupSeed checks the fields of the seed against its genesis block
+func validateGroupSeed(seed *GroupSeed) error {
+	if seed.GenesisBlock == nil {
+		return errors.New("genesis block is missing")
+	}
+	if _, err := guuid.Parse(seed.GroupId); err != nil {
+		return fmt.Errorf("invalid group id %q", seed.GroupId)
+	}
+	if seed.GroupId != seed.GenesisBlock.GroupId {
+		return errors.New("group id mismatches the genesis block")
+	}
+	if seed.OwnerPubkey != seed.GenesisBlock.ProducerPubkey {
+		return errors.New("owner pubkey mismatches the genesis block")
+	}
+	if seed.GroupName == "" || seed.AppKey == "" {
+		return errors.New("group name and app key are required")
+	}
+	if seed.ConsensusType != "poa" && seed.ConsensusType != "pos" {
+		return fmt.Errorf("invalid consensus type %q", seed.ConsensusType)
+	}
+	if seed.EncryptionType != "public" && seed.EncryptionType != "private" {
+		return fmt.Errorf("invalid encryption type %q", seed.EncryptionType)
+	}
+	if key, err := hex.DecodeString(seed.CipherKey); err != nil || len(key) != 32 {
+		return errors.New("invalid cipher key")
+	}
+	ok, err := rumchaindata.ValidGenesisBlock(seed.GenesisBlock)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("verify genesis block failed")
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+//go:build !js
+// +build !js
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	guuid "github.com/google/uuid"
+	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
+	rumchaindata "github.com/rumsystem/quorum/pkg/data"
+)
+
+func TestCompactSeed(t *testing.T) {
+	if _, err := localcrypto.InitKeystore("compactseedtest", t.TempDir()); err != nil {
+		t.Fatalf("init keystore failed: %s", err)
+	}
+	ks := localcrypto.GetKeystore()
+	groupId := guuid.New().String()
+	if _, err := ks.NewKey(groupId, localcrypto.Sign, "key.Passw0rd"); err != nil {
+		t.Fatalf("new sign key failed: %s", err)
+	}
+	pubkey, err := ks.GetEncodedPubkey(groupId, localcrypto.Sign)
+	if err != nil {
+		t.Fatal(err)
+	}
+	genesis, err := rumchaindata.CreateGenesisBlockByEthKey(groupId, pubkey, ks, "")
+	if err != nil {
+		t.Fatalf("create genesis block failed: %s", err)
+	}
+	seed := &GroupSeed{
+		GenesisBlock:   genesis,
+		GroupId:        groupId,
+		GroupName:      "compact",
+		OwnerPubkey:    pubkey,
+		ConsensusType:  "poa",
+		EncryptionType: "public",
+		CipherKey:      hex.EncodeToString(bytes.Repeat([]byte{1}, 32)),
+		AppKey:         "test_app",
+	}
+
+	compact, err := ToCompactSeed(seed)
+	if err != nil {
+		t.Fatalf("ToCompactSeed failed: %s", err)
+	}
+	if !strings.HasPrefix(compact, CompactSeedPrefix) {
+		t.Fatalf("expect prefix %s, got %s", CompactSeedPrefix, compact)
+	}
+	decoded, urls, err := ParseGroupSeed(compact)
+	if err != nil {
+		t.Fatalf("ParseGroupSeed failed: %s", err)
+	}
+	if len(urls) != 0 || decoded.GroupId != groupId || decoded.CipherKey != seed.CipherKey || decoded.GroupName != seed.GroupName {
+		t.Errorf("expect seed of group %s, got %+v", groupId, decoded)
+	}
+
+	corrupt := []byte(compact)
+	i := len(CompactSeedPrefix) + 10
+	if corrupt[i] == 'A' {
+		corrupt[i] = 'B'
+	} else {
+		corrupt[i] = 'A'
+	}
+	for name, s := range map[string]string{
+		"truncated":  compact[:len(compact)-6],
+		"corrupt":    string(corrupt),
+		"empty":      CompactSeedPrefix,
+		"not base64": CompactSeedPrefix + "!!!",
+	} {
+		if _, err := FromCompactSeed(s); !errors.Is(err, ErrInvalidCompactSeed) {
+			t.Errorf("expect %s seed rejected, got %v", name, err)
+		}
+	}
+
+	other := *seed
+	other.GroupId = guuid.New().String()
+	mismatch, err := ToCompactSeed(&other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FromCompactSeed(mismatch); err == nil {
+		t.Errorf("expect seed with a mismatched group id rejected")
+	}
+}
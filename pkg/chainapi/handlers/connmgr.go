@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/rumsystem/quorum/internal/pkg/conn/p2p"
+)
+
+type SetConnMgrParam struct {
+	LowWater    int    `json:"low_water" validate:"gte=0" example:"10"`
+	HighWater   int    `json:"high_water" validate:"gtfield=LowWater" example:"200"`
+	GracePeriod string `json:"grace_period" validate:"required" example:"1m"` // duration of the new conns kept from trimming, e.g. 30s
+}
+
+type ConnMgrResult struct {
+	LowWater    int       `json:"low_water" example:"10"`
+	HighWater   int       `json:"high_water" example:"200"`
+	GracePeriod string    `json:"grace_period" example:"1m0s"`
+	ConnCount   int       `json:"conn_count" example:"42"`
+	LastTrim    time.Time `json:"last_trim" example:"2023-01-28T08:10:36.675204+00:00"`
+}
+
+func GetConnMgr(node *p2p.Node) (*ConnMgrResult, error) {
+	if node == nil || node.ConnMgr == nil {
+		return nil, errors.New("connection manager is not available")
+	}
+	info := node.ConnMgr.Info()
+	return &ConnMgrResult{
+		LowWater:    info.LowWater,
+		HighWater:   info.HighWater,
+		GracePeriod: info.GracePeriod.String(),
+		ConnCount:   info.ConnCount,
+		LastTrim:    info.LastTrim,
+	}, nil
+}
+
+// SetConnMgr changes the watermarks and the grace period of the connection manager, the change is lost when the node restarts
+func SetConnMgr(node *p2p.Node, params *SetConnMgrParam) (*ConnMgrResult, error) {
+	validate := validator.New()
+	if err := validate.Struct(params); err != nil {
+		return nil, err
+	}
+	if node == nil || node.ConnMgr == nil {
+		return nil, errors.New("connection manager is not available")
+	}
+	grace, err := time.ParseDuration(params.GracePeriod)
+	if err != nil {
+		return nil, err
+	}
+	if err := node.ConnMgr.SetWatermarks(params.LowWater, params.HighWater, grace); err != nil {
+		return nil, err
+	}
+	return GetConnMgr(node)
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rumsystem/quorum/internal/pkg/conn/p2p"
+)
+
+func TestSetConnMgr(t *testing.T) {
+	cm, err := p2p.NewConnMgr(10, 100, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cm.Close()
+	node := &p2p.Node{ConnMgr: cm}
+
+	res, err := SetConnMgr(node, &SetConnMgrParam{LowWater: 20, HighWater: 400, GracePeriod: "30s"})
+	if err != nil {
+		t.Fatalf("set connmgr failed: %s", err)
+	}
+	if res.LowWater != 20 || res.HighWater != 400 || res.GracePeriod != "30s" {
+		t.Errorf("expect new watermarks, got %+v", res)
+	}
+
+	for _, params := range []*SetConnMgrParam{
+		{LowWater: 20, HighWater: 20, GracePeriod: "30s"},
+		{LowWater: -1, HighWater: 20, GracePeriod: "30s"},
+		{LowWater: 20, HighWater: 40, GracePeriod: "-1s"},
+		{LowWater: 20, HighWater: 40, GracePeriod: "bad"},
+	} {
+		if _, err := SetConnMgr(node, params); err == nil {
+			t.Errorf("expect error for %+v", params)
+		}
+	}
+	if low, high, _ := cm.Watermarks(); low != 20 || high != 400 {
+		t.Errorf("expect watermarks unchanged by invalid params, got %d %d", low, high)
+	}
+}
@@ -22,10 +22,21 @@ type CreateGroupParam struct {
 	EncryptionType  string `from:"encryption_type" json:"encryption_type" validate:"required,oneof=public private" example:"public"`
 	AppKey          string `from:"app_key"         json:"app_key"         validate:"required,max=20,min=4" example:"test_app"`
 	IncludeChainUrl bool   `json:"include_chain_url" example:"true"`
+	KeyAlias        string `json:"keyalias" example:"my_identity"` // sign with the key of the alias in this group, a new key is created if empty
+
+	// derive the group id, the owner key and the cipher key from the BIP39 mnemonic, the same mnemonic and path recreate the group
+	Mnemonic       string `json:"mnemonic" example:"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"`
+	DerivationPath string `json:"derivation_path" example:"m/5395789'/0'/0'/0/0"` // path of the owner key, defaults to the quorum path m/5395789'/0'/0'/0/0 (purpose "RUM"), not an eth account of the mnemonic
 }
 
 type JoinGroupParamV2 struct {
 	Seed string `json:"seed" validate:"required" example:"rum://seed?v=1&e=0&n=0&b=tknSczG2RC6hEBTXZyig7w&c=Za8zI2nAWaTNSvSv6cnPPxHCZef9sGtKtgsZ8iSxj0E&g=SfGcugfLTZ68Hc-xscFwMQ&k=AnRP4sojIvAH-Ugqnd7ZaM1H8j_c1pX6clyeXgAORiGZ&s=mrcA0LDzo54zUujZTINvWM_k2HSifv2T4JfYHAY2EzsCRGdR5vxHbvVNStlJOOBK_ohT6vFGs0FDk2pWYVRPUQE&t=FyvyFrtDGC0&a=timeline.dev&y=group_timeline&u=http%3A%2F%2F1.2.3.4%3A6090%3Fjwt%3DeyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJhbGxvd0dyb3VwcyI6WyI0OWYxOWNiYS0wN2NiLTRkOWUtYmMxZC1jZmIxYjFjMTcwMzEiXSwiZXhwIjoxODI3Mzc0MjgyLCJuYW1lIjoiYWxsb3ctNDlmMTljYmEtMDdjYi00ZDllLWJjMWQtY2ZiMWIxYzE3MDMxIiwicm9sZSI6Im5vZGUifQ.rr_tYm0aUdmOeM0EYVzNpKmoNDOpSGzD38s6tjlxuCo"` // seed url
+
+	// join with the key of the alias, a new key is created if empty
+	KeyAlias string `json:"keyalias" example:"my_identity"`
+
+	// join a public group to sync and serve it only, no key is created and publishing is refused
+	ReadOnly bool `json:"read_only" example:"false"`
 }
 
 type GroupSeed struct {
@@ -47,6 +58,7 @@ type GroupSeed struct {
 	CipherKey      string    `json:"cipher_key" validate:"required" example:"8e9bd83f84cf1408484d24f486861947a1db3fbe6eb3c61e31af55a4803aedc1"`
 	AppKey         string    `json:"app_key" validate:"required" example:"test_app"`
 	Signature      string    `json:"signature" validate:"required" example:"304502206897c3c67247cba2e8d5991501b3fd471fcca06f15915efdcd814b9e99c9a48a022100aa3024eb5663da6cbbde150132a4ff52c6c6aeeb49e0c039b4c28e72b071382f"`
+	DerivationPath string    `json:"derivation_path,omitempty" example:"m/5395789'/0'/0'/0/0"` // set if the genesis keys are derived from a mnemonic
 }
 
 type CreateGroupResult struct {
@@ -72,18 +84,63 @@ func CreateGroup(params *CreateGroupParam, nodeoptions *options.NodeOptions, app
 
 	ks := nodectx.GetNodeCtx().Keystore
 
+	var derived *derivedGroup
+	if params.Mnemonic != "" {
+		if params.KeyAlias != "" {
+			return nil, errors.New("keyalias can't be used with mnemonic")
+		}
+		var err error
+		derived, err = deriveGroup(params.Mnemonic, params.DerivationPath)
+		if err != nil {
+			return nil, err
+		}
+		groupid = derived.GroupId
+	}
+
+	// the keys created or copied below for the group are removed if the group is not created,
+	// a derived group id may already have its keys on this node
+	existed, err := groupKeyExist(groupid.String(), ks)
+	if err != nil {
+		return nil, err
+	}
+	created := false
+	defer func() {
+		if !created && !existed {
+			if err := RemoveGroupKeys(groupid.String(), ks, nodeoptions); err != nil {
+				logger.Warnf("remove keys of group %s failed: %s", groupid.String(), err)
+			}
+		}
+	}()
+
+	if derived != nil {
+		if err := importDerivedSignKey(groupid.String(), derived, ks, nodeoptions); err != nil {
+			return nil, err
+		}
+	}
+
+	if params.KeyAlias != "" {
+		if err := BindGroupKey(groupid.String(), params.KeyAlias, ks, nodeoptions); err != nil {
+			return nil, err
+		}
+	}
+
 	/* init sign key */
 	b64key, err := initSignKey(groupid.String(), ks, nodeoptions)
 	if err != nil {
 		return nil, errors.New("group key can't be decoded, err:" + err.Error())
 	}
 
-	genesisBlock, err := rumchaindata.CreateGenesisBlockByEthKey(groupid.String(), b64key, ks, "")
-	if err != nil {
-		return nil, err
+	var genesisBlock *pb.Block
+	var cipherKey []byte
+	if derived != nil {
+		genesisBlock, err = rumchaindata.CreateGenesisBlockAt(groupid.String(), b64key, ks, "", derivedGenesisTimestamp)
+		cipherKey = derived.CipherKey
+	} else {
+		genesisBlock, err = rumchaindata.CreateGenesisBlockByEthKey(groupid.String(), b64key, ks, "")
+		if err == nil {
+			cipherKey, err = localcrypto.CreateAesKey()
+		}
 	}
-
-	cipherKey, err := localcrypto.CreateAesKey()
 	if err != nil {
 		return nil, err
 	}
@@ -120,6 +177,7 @@ func CreateGroup(params *CreateGroupParam, nodeoptions *options.NodeOptions, app
 	if err != nil {
 		return nil, err
 	}
+	created = true
 
 	groupmgr := chain.GetGroupMgr()
 	groupmgr.Groups[group.Item.GroupId] = group
@@ -138,6 +196,9 @@ func CreateGroup(params *CreateGroupParam, nodeoptions *options.NodeOptions, app
 		AppKey:         params.AppKey,
 		//Signature:      "", // updated by GenerateGroupSeedSignature
 	}
+	if derived != nil {
+		createGrpResult.DerivationPath = derived.Path
+	}
 
 	// generate signature
 	//if err := GenerateGroupSeedSignature(createGrpResult); err != nil {
@@ -165,6 +226,7 @@ func ToPbGroupSeed(s GroupSeed) pb.GroupSeed {
 		CipherKey:      s.CipherKey,
 		AppKey:         s.AppKey,
 		Signature:      s.Signature,
+		DerivationPath: s.DerivationPath,
 	}
 }
 
@@ -180,5 +242,6 @@ func FromPbGroupSeed(s *pb.GroupSeed) GroupSeed {
 		CipherKey:      s.CipherKey,
 		AppKey:         s.AppKey,
 		Signature:      s.Signature,
+		DerivationPath: s.DerivationPath,
 	}
 }
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"fmt"
+
+	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
+)
+
+type DeletePostParam struct {
+	GroupId string `param:"group_id" json:"-" validate:"required,uuid4" example:"ac0eea7c-2f3c-4c67-80b3-136e46b924a8"`
+	TrxId   string `param:"trx_id" json:"-" validate:"required,uuid4" example:"9e54c173-c1dd-429d-91fa-a6b43c14da77"`
+}
+
+// DeletePost sends a tombstone trx of the post, the post is hidden from the content api after the trx is applied
+func DeletePost(params *DeletePostParam) (*TrxResult, error) {
+	group, ok := chain.GetGroupMgr().Groups[params.GroupId]
+	if !ok {
+		return nil, fmt.Errorf("Group %s not exist", params.GroupId)
+	}
+
+	trxId, err := group.DeletePost(params.TrxId)
+	if err != nil {
+		return nil, err
+	}
+	return &TrxResult{TrxId: trxId}, nil
+}
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rumsystem/quorum/internal/pkg/appdata"
+	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
+	"github.com/rumsystem/quorum/internal/pkg/nodectx"
+	"github.com/rumsystem/quorum/internal/pkg/storage/def"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+const (
+	DEFAULT_FEED_NUM = 20
+	feedTitleLength  = 80
+)
+
+type GroupFeedParam struct {
+	GroupId string `param:"group_id" json:"-" validate:"required,uuid4" example:"ac0eea7c-2f3c-4c67-80b3-136e46b924a8"`
+	Format  string `query:"format" json:"format" validate:"omitempty,oneof=rss atom" example:"atom"` // rss by default
+	Num     int    `query:"num" json:"num" validate:"omitempty,min=1,max=100" example:"20"`
+}
+
+// FeedItem is a text post of the group feed
+type FeedItem struct {
+	TrxId     string
+	Author    string
+	Title     string
+	Content   string
+	TimeStamp time.Time
+}
+
+// GroupFeed renders the latest text posts of the group as an rss or atom feed, baseUrl is the url of the api server.
+// It returns the feed and its content type.
+func GroupFeed(params *GroupFeedParam, appdb *appdata.AppDb, baseUrl string) ([]byte, string, error) {
+	group, ok := chain.GetGroupMgr().Groups[params.GroupId]
+	if !ok {
+		return nil, "", fmt.Errorf("Group %s not exist", params.GroupId)
+	}
+	num := params.Num
+	if num <= 0 {
+		num = DEFAULT_FEED_NUM
+	}
+
+	trxids, err := appdb.GetGroupContentBySenders(params.GroupId, nil, "", num, true, false)
+	if err != nil {
+		return nil, "", err
+	}
+	nodename := nodectx.GetNodeCtx().Name
+	items := []*FeedItem{}
+	for _, trxid := range trxids {
+		trx, err := nodectx.GetNodeCtx().GetChainStorage().GetTrx(params.GroupId, trxid, def.Chain, nodename)
+		if err != nil || trx.TrxId == "" {
+			continue
+		}
+		if err := appdata.DecryptPostTrx(group.Item, trx); err != nil || len(trx.Data) == 0 {
+			continue
+		}
+		// an object posted in chunks is one item at its first chunk
+		data, ok, err := appdb.ChunkContent(nodectx.GetNodeCtx().GetChainStorage(), nodename, group.Item, trx.Data, trx.SenderPubkey)
+		if err != nil || !ok {
+			continue
+		}
+		trx.Data = data
+		if item := newFeedItem(trx); item != nil {
+			items = append(items, item)
+		}
+	}
+
+	link := fmt.Sprintf("%s/api/v1/group/%s/feed.xml", baseUrl, params.GroupId)
+	if params.Format == "atom" {
+		feed, err := renderAtomFeed(group.Item, link, baseUrl, items)
+		return feed, "application/atom+xml; charset=utf-8", err
+	}
+	feed, err := renderRSSFeed(group.Item, link, baseUrl, items)
+	return feed, "application/rss+xml; charset=utf-8", err
+}
+
+// newFeedItem returns the text of the post, "content" of the json object or of its "object",
+// it returns nil if the post has no text
+func newFeedItem(trx *quorumpb.Trx) *FeedItem {
+	var obj struct {
+		Name    string `json:"name"`
+		Content string `json:"content"`
+		Object  struct {
+			Name    string `json:"name"`
+			Content string `json:"content"`
+		} `json:"object"`
+	}
+	if err := json.Unmarshal(trx.Data, &obj); err != nil {
+		return nil
+	}
+	title, content := obj.Object.Name, obj.Object.Content
+	if content == "" {
+		title, content = obj.Name, obj.Content
+	}
+	if strings.TrimSpace(content) == "" {
+		return nil
+	}
+	if title == "" {
+		title = feedTitle(content)
+	}
+	return &FeedItem{
+		TrxId:     trx.TrxId,
+		Author:    trx.SenderPubkey,
+		Title:     title,
+		Content:   content,
+		TimeStamp: time.Unix(0, trx.TimeStamp).UTC(),
+	}
+}
+
+// feedTitle is the first line of the content, cut at feedTitleLength runes
+func feedTitle(content string) string {
+	title := strings.TrimSpace(content)
+	if i := strings.IndexByte(title, '\n'); i >= 0 {
+		title = strings.TrimSpace(title[:i])
+	}
+	if utf8.RuneCountInString(title) > feedTitleLength {
+		title = string([]rune(title)[:feedTitleLength]) + "…"
+	}
+	return title
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	DC      string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string     `xml:"title"`
+	Link          string     `xml:"link"`
+	Description   string     `xml:"description"`
+	LastBuildDate string     `xml:"lastBuildDate"`
+	Items         []*rssItem `xml:"item"`
+}
+
+type rssGuid struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Guid        rssGuid `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+	Creator     string  `xml:"dc:creator"`
+	Description string  `xml:"description"`
+}
+
+func renderRSSFeed(group *quorumpb.GroupItem, link, baseUrl string, items []*FeedItem) ([]byte, error) {
+	feed := &rssFeed{
+		Version: "2.0",
+		DC:      "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:         group.GroupName,
+			Link:          link,
+			Description:   fmt.Sprintf("posts of group %s", group.GroupId),
+			LastBuildDate: time.Now().UTC().Format(time.RFC1123Z),
+			Items:         make([]*rssItem, 0, len(items)),
+		},
+	}
+	for _, item := range items {
+		feed.Channel.Items = append(feed.Channel.Items, &rssItem{
+			Title:       item.Title,
+			Link:        feedItemLink(baseUrl, group.GroupId, item.TrxId),
+			Guid:        rssGuid{Value: item.TrxId},
+			PubDate:     item.TimeStamp.Format(time.RFC1123Z),
+			Creator:     item.Author,
+			Description: item.Content,
+		})
+	}
+	return marshalFeed(feed)
+}
+
+type atomFeed struct {
+	XMLName xml.Name     `xml:"http://www.w3.org/2005/Atom feed"`
+	Id      string       `xml:"id"`
+	Title   string       `xml:"title"`
+	Updated string       `xml:"updated"`
+	Link    atomLink     `xml:"link"`
+	Entries []*atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomContent struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	Id      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    atomLink    `xml:"link"`
+	Author  atomAuthor  `xml:"author"`
+	Content atomContent `xml:"content"`
+}
+
+func renderAtomFeed(group *quorumpb.GroupItem, link, baseUrl string, items []*FeedItem) ([]byte, error) {
+	updated := time.Now().UTC()
+	if len(items) > 0 {
+		updated = items[0].TimeStamp
+	}
+	feed := &atomFeed{
+		Id:      "urn:uuid:" + group.GroupId,
+		Title:   group.GroupName,
+		Updated: updated.Format(time.RFC3339),
+		Link:    atomLink{Rel: "self", Href: link},
+		Entries: make([]*atomEntry, 0, len(items)),
+	}
+	for _, item := range items {
+		feed.Entries = append(feed.Entries, &atomEntry{
+			Id:      "urn:uuid:" + item.TrxId,
+			Title:   item.Title,
+			Updated: item.TimeStamp.Format(time.RFC3339),
+			Link:    atomLink{Href: feedItemLink(baseUrl, group.GroupId, item.TrxId)},
+			Author:  atomAuthor{Name: item.Author},
+			Content: atomContent{Type: "text", Value: item.Content},
+		})
+	}
+	return marshalFeed(feed)
+}
+
+func feedItemLink(baseUrl, groupId, trxId string) string {
+	return fmt.Sprintf("%s/api/v1/trx/%s/%s", baseUrl, groupId, trxId)
+}
+
+func marshalFeed(feed interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
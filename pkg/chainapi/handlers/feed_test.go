@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+func TestNewFeedItem(t *testing.T) {
+	ts := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	cases := []struct {
+		data  string
+		title string
+	}{
+		{`{"type":"Create","object":{"type":"Note","name":"hi","content":"hello world"}}`, "hi"},
+		{`{"type":"Note","content":"first line\nsecond line"}`, "first line"},
+		{`{"type":"Note","content":"` + strings.Repeat("长", 100) + `"}`, strings.Repeat("长", feedTitleLength) + "…"},
+		{`{"type":"Like","object":{"id":"x"}}`, ""},
+		{`not json`, ""},
+	}
+	for i, c := range cases {
+		item := newFeedItem(&quorumpb.Trx{TrxId: "trx", SenderPubkey: "pubkey", TimeStamp: ts.UnixNano(), Data: []byte(c.data)})
+		if c.title == "" {
+			if item != nil {
+				t.Errorf("case %d: expect no item, got %+v", i, item)
+			}
+			continue
+		}
+		if item == nil || item.Title != c.title || item.Author != "pubkey" || !item.TimeStamp.Equal(ts) {
+			t.Errorf("case %d: expect title %q, got %+v", i, c.title, item)
+		}
+	}
+}
+
+func TestRenderFeed(t *testing.T) {
+	group := &quorumpb.GroupItem{GroupId: "ac0eea7c-2f3c-4c67-80b3-136e46b924a8", GroupName: "my group"}
+	items := []*FeedItem{
+		{TrxId: "trx-2", Author: "alice", Title: "second", Content: "a <b> & c", TimeStamp: time.Unix(200, 0).UTC()},
+		{TrxId: "trx-1", Author: "bob", Title: "first", Content: "hello", TimeStamp: time.Unix(100, 0).UTC()},
+	}
+
+	body, err := renderRSSFeed(group, "http://localhost/feed.xml", "http://localhost", items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rss := &rssFeed{}
+	if err := xml.Unmarshal(body, rss); err != nil {
+		t.Fatalf("invalid rss: %s\n%s", err, body)
+	}
+	if rss.Channel.Title != "my group" || len(rss.Channel.Items) != 2 || rss.Channel.Items[0].Description != "a <b> & c" {
+		t.Errorf("unexpected rss %s", body)
+	}
+	if rss.Channel.Items[1].Link != "http://localhost/api/v1/trx/"+group.GroupId+"/trx-1" {
+		t.Errorf("unexpected item link %s", rss.Channel.Items[1].Link)
+	}
+
+	body, err = renderAtomFeed(group, "http://localhost/feed.xml", "http://localhost", items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	atom := &atomFeed{}
+	if err := xml.Unmarshal(body, atom); err != nil {
+		t.Fatalf("invalid atom: %s\n%s", err, body)
+	}
+	if atom.Updated != "1970-01-01T00:03:20Z" || len(atom.Entries) != 2 || atom.Entries[1].Author.Name != "bob" {
+		t.Errorf("unexpected atom %s", body)
+	}
+	if !strings.Contains(string(body), `<feed xmlns="http://www.w3.org/2005/Atom">`) {
+		t.Errorf("expect atom namespace in %s", body)
+	}
+}
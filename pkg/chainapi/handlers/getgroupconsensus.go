@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"github.com/go-playground/validator/v10"
+	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
+	"github.com/rumsystem/quorum/pkg/consensus"
+	"github.com/rumsystem/quorum/pkg/consensus/def"
+)
+
+type GroupConsensusInfo struct {
+	GroupId   string               `json:"group_id" example:"c8795b55-90bf-4b58-aaa0-86d11fe4e16a"`
+	Consensus string               `json:"consensus" example:"molasses"`
+	Epoch     uint64               `json:"epoch" example:"100"`
+	BlockId   uint64               `json:"block_id" example:"100"`
+	Threshold float64              `json:"threshold" example:"3000"` // milliseconds without heartbeat before a producer is considered missed
+	Heartbeat *def.HBStats         `json:"heartbeat,omitempty"`      // nil if the node is not a producer of the group
+	Config    *def.ConsensusConfig `json:"config"`
+}
+
+// GetGroupConsensus returns the consensus state and heartbeat stats of the group
+func GetGroupConsensus(groupId string) (*GroupConsensusInfo, error) {
+	cons, err := getGroupConsensus(groupId)
+	if err != nil {
+		return nil, err
+	}
+
+	group := chain.GetGroupMgr().Groups[groupId]
+	info := &GroupConsensusInfo{
+		GroupId:   groupId,
+		Consensus: cons.Name(),
+		Epoch:     group.ChainCtx.GetCurrEpoch(),
+		BlockId:   group.ChainCtx.GetCurrBlockId(),
+	}
+	info.Config, err = consensus.GetConsensusConfig(groupId)
+	if err != nil {
+		return nil, err
+	}
+	info.Threshold = float64(consensus.HBMissIntervals * info.Config.ProposeInterval)
+	if reporter, ok := cons.Producer().(def.HBStatsReporter); ok {
+		info.Heartbeat = reporter.HBStats()
+	}
+
+	return info, nil
+}
+
+// UpdGroupConsensusConfig saves the consensus config of the group and recreates the bft of the local producer to apply it
+func UpdGroupConsensusConfig(groupId string, cfg *def.ConsensusConfig) (*def.ConsensusConfig, error) {
+	validate := validator.New()
+	if err := validate.Struct(cfg); err != nil {
+		return nil, err
+	}
+
+	cons, err := getGroupConsensus(groupId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := consensus.SetConsensusConfig(groupId, cfg); err != nil {
+		return nil, err
+	}
+	if cons.Producer() != nil {
+		cons.Producer().RecreateBft()
+	}
+
+	return consensus.GetConsensusConfig(groupId)
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/rumsystem/quorum/internal/pkg/appdata"
+	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
+	"github.com/rumsystem/quorum/internal/pkg/nodectx"
+	chainstorage "github.com/rumsystem/quorum/internal/pkg/storage/chain"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+const (
+	DEFAULT_CONTENT_PAGE_LIMIT = 20
+	MAX_CONTENT_PAGE_LIMIT     = 500
+)
+
+type GetGroupCtnRangeParams struct {
+	GroupId string `param:"group_id" json:"group_id" url:"-" validate:"required,uuid4"`
+	Since   int64  `query:"since" json:"since" url:"since,omitempty"` // unix nano, inclusive
+	Until   int64  `query:"until" json:"until" url:"until,omitempty"` // unix nano, inclusive
+	Limit   int    `query:"limit" json:"limit" url:"limit,omitempty" validate:"omitempty,min=1,max=500"`
+	Cursor  string `query:"cursor" json:"cursor" url:"cursor,omitempty"` // next_cursor of the previous page
+}
+
+type GroupCtnPage struct {
+	Posts      []*quorumpb.PostItem `json:"posts"`
+	NextCursor string               `json:"next_cursor"` // empty if no trx is scanned and no cursor given
+	HasMore    bool                 `json:"has_more"`    // a page may hold less than limit posts while has_more is true
+}
+
+func EncodeCtnCursor(pos *chainstorage.PostPosition) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(pos.String()))
+}
+
+func DecodeCtnCursor(cursor string) (*chainstorage.PostPosition, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	pos, err := chainstorage.ParsePostPosition(string(b))
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	return pos, nil
+}
+
+// GetGroupContentByRange returns a page of group posts in chain order, the order of the blocks and the trxs in them,
+// the cursor points to the last scanned trx, posts arriving later are in new blocks, so they are not skipped or duplicated.
+// The posts of the blocked authors are left out, the cursor moves past them. The chunks of an object are returned
+// once, at the first chunk, as the reassembled object
+func GetGroupContentByRange(params *GetGroupCtnRangeParams, appdb *appdata.AppDb) (*GroupCtnPage, error) {
+	if params.Until > 0 && params.Since > params.Until {
+		return nil, errors.New("since must not be later than until")
+	}
+	group, ok := chain.GetGroupMgr().Groups[params.GroupId]
+	if !ok {
+		return nil, fmt.Errorf("Group %s not exist", params.GroupId)
+	}
+
+	limit := params.Limit
+	if limit <= 0 {
+		limit = DEFAULT_CONTENT_PAGE_LIMIT
+	} else if limit > MAX_CONTENT_PAGE_LIMIT {
+		limit = MAX_CONTENT_PAGE_LIMIT
+	}
+
+	var after *chainstorage.PostPosition
+	if params.Cursor != "" {
+		pos, err := DecodeCtnCursor(params.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		after = pos
+	}
+
+	blocked, err := appdb.GetBlockedSet(params.GroupId)
+	if err != nil {
+		return nil, err
+	}
+
+	nodename := nodectx.GetNodeCtx().Name
+	posts, last, more, err := nodectx.GetNodeCtx().GetChainStorage().GetPostsByRange(params.GroupId, params.Since, params.Until, after, group.GetCurrentBlockId(), limit, nodename)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &GroupCtnPage{Posts: []*quorumpb.PostItem{}, NextCursor: params.Cursor, HasMore: more}
+	if last != nil {
+		page.NextCursor = EncodeCtnCursor(last)
+	}
+	for _, post := range posts {
+		if blocked.Has(post.SenderPubkey) {
+			continue
+		}
+		content, ok, err := appdb.ChunkContent(nodectx.GetNodeCtx().GetChainStorage(), nodename, group.Item, post.Content, post.SenderPubkey)
+		if err != nil {
+			logger.Warnf("get chunked object groupid: %s trxid: %s failed: %s", params.GroupId, post.TrxId, err)
+			continue
+		}
+		if ok {
+			post.Content = content
+			page.Posts = append(page.Posts, post)
+		}
+	}
+	return page, nil
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"testing"
+
+	chainstorage "github.com/rumsystem/quorum/internal/pkg/storage/chain"
+)
+
+func TestCtnCursor(t *testing.T) {
+	pos := &chainstorage.PostPosition{BlockId: 1024, Index: 3}
+	cursor := EncodeCtnCursor(pos)
+
+	got, err := DecodeCtnCursor(cursor)
+	if err != nil {
+		t.Fatalf("DecodeCtnCursor failed: %s", err)
+	}
+	if *got != *pos {
+		t.Errorf("cursor mismatch, want %+v got %+v", pos, got)
+	}
+
+	for _, bad := range []string{"!!!", "YWJj", base64.RawURLEncoding.EncodeToString([]byte("1_-1"))} {
+		if _, err := DecodeCtnCursor(bad); err == nil {
+			t.Errorf("DecodeCtnCursor(%q) should fail", bad)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
+	chaindef "github.com/rumsystem/quorum/internal/pkg/chainsdk/def"
+)
+
+type GroupStatus struct {
+	GroupId    string                 `json:"group_id" example:"5ed3f9fe-81e2-450d-9146-7a329aac2b62"`
+	SyncStatus string                 `json:"sync_status" example:"SYNCING"`
+	Epoch      uint64                 `json:"epoch" example:"800"`
+	BlockId    uint64                 `json:"block_id" example:"800"`
+	Progress   *chaindef.SyncProgress `json:"progress"`
+	PendingTrx int                    `json:"pending_trx" example:"0"`    // trxs sent by this node and not applied yet
+	QueuePos   int                    `json:"queue_position" example:"0"` // position in the sync queue, 0 if not queued
+	SyncQueue  []string               `json:"sync_queue"`                 // groups waiting to start sync in order
+	ReadOnly   bool                   `json:"read_only" example:"false"`  // joined read-only, publishing is refused
+}
+
+// GetGroupStatus returns the sync status and progress of the group
+func GetGroupStatus(groupId string) (*GroupStatus, error) {
+	if groupId == "" {
+		return nil, errors.New("group_id can't be nil.")
+	}
+
+	group, ok := chain.GetGroupMgr().Groups[groupId]
+	if !ok {
+		return nil, fmt.Errorf("Group %s not exist", groupId)
+	}
+
+	return &GroupStatus{
+		GroupId:    groupId,
+		SyncStatus: group.GetRexSyncerStatus(),
+		Epoch:      group.GetCurrentEpoch(),
+		BlockId:    group.ChainCtx.GetCurrBlockId(),
+		Progress:   group.GetSyncProgress(),
+		PendingTrx: group.ChainCtx.GetPendingTrxCount(),
+		QueuePos:   chain.GetGroupMgr().GetSyncQueuePosition(groupId),
+		SyncQueue:  chain.GetGroupMgr().GetSyncQueue(),
+		ReadOnly:   group.IsReadOnly(),
+	}, nil
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/p2p/protocol/identify"
+	maddr "github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+	"github.com/rumsystem/quorum/internal/pkg/conn/p2p"
+)
+
+type NATStatus struct {
+	Peerid        string   `json:"peer_id" example:"16Uiu2HAm8XVpfQrJYaeL7XtrHC3FvfKt2QW7P8R3MBenYyHxu8Kk"`
+	Reachability  string   `json:"reachability" example:"Private"` // autonat verdict, one of Unknown, Public, Private
+	ObservedAddrs []string `json:"observed_addrs"`                 // addrs other peers see us dialing from, e.g. ["/ip4/94.23.17.189/tcp/62777"]
+	PublicAddrs   []string `json:"public_addrs"`                   // public addrs the node announces, including the port mapped addrs
+	RelayAddrs    []string `json:"relay_addrs"`                    // circuit addrs of the held relay reservations
+	HasRelay      bool     `json:"has_relay" example:"false"`      // whether a relay reservation is held
+	NatEnabled    bool     `json:"nat_enabled" example:"true"`     // whether the node serves autonat to other peers
+	RelayEnabled  bool     `json:"relay_enabled" example:"true"`   // whether the node reserves relays when it is private
+}
+
+// GetNATStatus returns whether other peers can reach the node, by the reachability of autonat,
+// the observed addrs of identify, and the addrs of the relay reservations
+func GetNATStatus(node *p2p.Node) (*NATStatus, error) {
+	if node == nil || node.Host == nil {
+		return nil, errors.New("node is not available")
+	}
+
+	result := &NATStatus{
+		Reachability:  network.ReachabilityUnknown.String(),
+		ObservedAddrs: []string{},
+		PublicAddrs:   []string{},
+		RelayAddrs:    []string{},
+		Peerid:        node.Host.ID().String(),
+	}
+	if node.Info != nil {
+		result.Reachability = node.Info.NATType.String()
+	}
+	if node.Nodeopt != nil {
+		result.NatEnabled = node.Nodeopt.EnableNat
+		result.RelayEnabled = node.Nodeopt.EnableRelay
+	}
+
+	// the routed host of the dht hides the identify service, the observed addrs are in the public addrs once they are confirmed
+	if h, ok := node.Host.(interface{ IDService() identify.IDService }); ok && h.IDService() != nil {
+		for _, addr := range h.IDService().OwnObservedAddrs() {
+			result.ObservedAddrs = append(result.ObservedAddrs, addr.String())
+		}
+	}
+
+	for _, addr := range node.Host.Addrs() {
+		if _, err := addr.ValueForProtocol(maddr.P_CIRCUIT); err == nil {
+			result.RelayAddrs = append(result.RelayAddrs, addr.String())
+			continue
+		}
+		if manet.IsPublicAddr(addr) {
+			result.PublicAddrs = append(result.PublicAddrs, addr.String())
+		}
+	}
+	result.HasRelay = len(result.RelayAddrs) > 0
+
+	return result, nil
+}
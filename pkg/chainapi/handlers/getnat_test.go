@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/rumsystem/quorum/internal/pkg/conn/p2p"
+)
+
+func TestGetNATStatus(t *testing.T) {
+	h, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	node := &p2p.Node{Host: h, Info: &p2p.NodeInfo{NATType: network.ReachabilityPrivate}}
+	res, err := GetNATStatus(node)
+	if err != nil {
+		t.Fatalf("get nat status failed: %s", err)
+	}
+	if res.Reachability != "Private" || res.Peerid != h.ID().String() {
+		t.Errorf("expect private reachability of %s, got %+v", h.ID(), res)
+	}
+	if len(res.PublicAddrs) != 0 || res.HasRelay {
+		t.Errorf("expect no public addrs nor relay on localhost, got %+v", res)
+	}
+
+	if _, err := GetNATStatus(&p2p.Node{}); err == nil {
+		t.Errorf("expect error without host")
+	}
+}
@@ -95,9 +95,15 @@ func GetNodeInfo(networkName string) (*NodeInfo, error) {
 	peers := nodectx.GetNodeCtx().PeersProtocol()
 	info.Peers = *peers
 
+	info.Mem = readMemStats()
+
+	return &info, nil
+}
+
+func readMemStats() NodeInfoMem {
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
-	info.Mem = NodeInfoMem{
+	return NodeInfoMem{
 		Sys:        ByteSize(m.Sys),
 		HeapSys:    ByteSize(m.HeapSys),
 		HeapAlloc:  ByteSize(m.HeapAlloc),
@@ -106,6 +112,4 @@ func GetNodeInfo(networkName string) (*NodeInfo, error) {
 		StackInuse: ByteSize(m.StackInuse),
 		NumGC:      m.NumGC,
 	}
-
-	return &info, nil
 }
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rumsystem/quorum/internal/pkg/appdata"
+	"github.com/rumsystem/quorum/internal/pkg/storage"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+)
+
+// key counts need a scan of the keys, they are cached for a while
+const nodeStorageStatsTTL = time.Minute
+
+type NodeStorageStats struct {
+	TotalSize int64                          `json:"total_size" example:"1073741824"` // bytes on disk of all dbs
+	Dbs       map[string]*storage.StoreStats `json:"dbs"`                             // by db name: groups, db, appdb
+	KeyCounts map[string]int                 `json:"key_counts"`                      // by kind: blocks, cached_blocks, trxs, posts, app_contents
+	Peers     int                            `json:"peerstore_peers" example:"20"`    // peers in the in-memory peerstore
+	UpdatedAt int64                          `json:"updated_at" example:"1663900000000000000"`
+}
+
+var (
+	nodeStorageMu    sync.Mutex
+	nodeStorageCache *NodeStorageStats
+)
+
+// GetNodeStorage returns the size of the node dbs, appdb is nil on nodes without appdata
+func GetNodeStorage(dbMgr *storage.DbMgr, appdb *appdata.AppDb, nodename string, peers int) (*NodeStorageStats, error) {
+	nodeStorageMu.Lock()
+	defer nodeStorageMu.Unlock()
+
+	now := time.Now()
+	if nodeStorageCache != nil && now.Sub(time.Unix(0, nodeStorageCache.UpdatedAt)) < nodeStorageStatsTTL {
+		res := *nodeStorageCache
+		res.Peers = peers
+		return &res, nil
+	}
+
+	dbs := map[string]storage.QuorumStorage{
+		"groups": dbMgr.GroupInfoDb,
+		"db":     dbMgr.Db,
+	}
+	if appdb != nil {
+		dbs["appdb"] = appdb.Db
+	}
+
+	res := &NodeStorageStats{
+		Dbs:       map[string]*storage.StoreStats{},
+		KeyCounts: map[string]int{},
+		Peers:     peers,
+		UpdatedAt: now.UnixNano(),
+	}
+	for name, db := range dbs {
+		stats, err := storage.GetStoreStats(db)
+		if err != nil {
+			return nil, err
+		}
+		res.Dbs[name] = stats
+		res.TotalSize += stats.Size
+	}
+
+	nodeprefix := utils.GetPrefix(nodename)
+	prefixes := map[string]string{
+		"blocks":        storage.GetBlockPrefix("", nodename),
+		"cached_blocks": storage.GetCachedBlockPrefix("", nodename),
+		"trxs":          nodeprefix + storage.TRX_PREFIX + "_",
+		"posts":         storage.GetPostPrefix("", nodename),
+	}
+	for kind, prefix := range prefixes {
+		n, err := storage.CountPrefixKeys(dbMgr.Db, prefix)
+		if err != nil {
+			return nil, err
+		}
+		res.KeyCounts[kind] = n
+	}
+	if appdb != nil {
+		n, err := storage.CountPrefixKeys(appdb.Db, appdata.CNT_PREFIX+appdata.GRP_PREFIX)
+		if err != nil {
+			return nil, err
+		}
+		res.KeyCounts["app_contents"] = n
+	}
+
+	nodeStorageCache = res
+	return res, nil
+}
+
+// resetNodeStorageCache makes the next GetNodeStorage read the sizes again
+func resetNodeStorageCache() {
+	nodeStorageMu.Lock()
+	defer nodeStorageMu.Unlock()
+	nodeStorageCache = nil
+}
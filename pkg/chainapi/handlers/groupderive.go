@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+
+	ethkeystore "github.com/ethereum/go-ethereum/accounts/keystore"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	guuid "github.com/google/uuid"
+	"github.com/rumsystem/quorum/internal/pkg/options"
+	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
+)
+
+// derivedGenesisTimestamp is the timestamp of the genesis block of a derived group, so the block is recreated as is
+const derivedGenesisTimestamp = 0
+
+// derivedGroup is the identity of a group derived from a mnemonic
+type derivedGroup struct {
+	Path      string
+	GroupId   guuid.UUID
+	SignKey   *ecdsa.PrivateKey
+	CipherKey []byte
+}
+
+// deriveGroup derives the owner key of the path from the mnemonic, the group id and the cipher key are derived from the owner key
+func deriveGroup(mnemonic string, path string) (*derivedGroup, error) {
+	if path == "" {
+		path = localcrypto.DefaultDerivationPath
+	}
+	seed, err := localcrypto.MnemonicToSeed(mnemonic, "")
+	if err != nil {
+		return nil, err
+	}
+	key, err := localcrypto.DeriveKey(seed, path)
+	if err != nil {
+		return nil, err
+	}
+
+	privkey := ethcrypto.FromECDSA(key)
+	var groupId guuid.UUID
+	copy(groupId[:], deriveGroupSecret(privkey, "quorum group id"))
+	groupId[6] = (groupId[6] & 0x0f) | 0x40 // version 4
+	groupId[8] = (groupId[8] & 0x3f) | 0x80 // variant 10
+
+	return &derivedGroup{
+		Path:      path,
+		GroupId:   groupId,
+		SignKey:   key,
+		CipherKey: deriveGroupSecret(privkey, "quorum cipher key"),
+	}, nil
+}
+
+func deriveGroupSecret(privkey []byte, label string) []byte {
+	mac := hmac.New(sha256.New, privkey)
+	mac.Write([]byte(label))
+	return mac.Sum(nil)
+}
+
+// importDerivedSignKey saves the derived owner key as the sign key of the group, an existing sign key should be the same key
+func importDerivedSignKey(groupId string, derived *derivedGroup, ks localcrypto.Keystore, nodeoptions *options.NodeOptions) error {
+	dirks, ok := ks.(*localcrypto.DirKeyStore)
+	if !ok {
+		return fmt.Errorf("unknown keystore type %v", ks)
+	}
+	signKeyName := localcrypto.Sign.NameString(groupId)
+	exist, err := dirks.IfKeyExist(signKeyName)
+	if err != nil {
+		return err
+	}
+	addr := ethcrypto.PubkeyToAddress(derived.SignKey.PublicKey)
+	if exist {
+		key, err := dirks.GetKeyFromUnlocked(signKeyName)
+		if err != nil {
+			return err
+		}
+		if k, ok := key.(*ethkeystore.Key); !ok || k.Address != addr {
+			return fmt.Errorf("group %s already has a different sign key", groupId)
+		}
+		return nil
+	}
+
+	newaddr, err := dirks.ImportSignKey(groupId, derived.SignKey)
+	if err != nil {
+		return err
+	}
+	if err := nodeoptions.SetSignKeyMap(groupId, newaddr); err != nil {
+		return fmt.Errorf("save key map %s err: %s", newaddr, err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+func TestDeriveGroup(t *testing.T) {
+	mnemonic := strings.Repeat("abandon ", 11) + "about"
+	g1, err := deriveGroup(mnemonic, "")
+	if err != nil {
+		t.Fatalf("deriveGroup failed: %s", err)
+	}
+	g2, err := deriveGroup(mnemonic, "m/5395789'/0'/0'/0/0")
+	if err != nil {
+		t.Fatalf("deriveGroup failed: %s", err)
+	}
+	if g1.GroupId != g2.GroupId || !bytes.Equal(g1.CipherKey, g2.CipherKey) || !g1.SignKey.Equal(g2.SignKey) {
+		t.Errorf("expect the same group derived from the same mnemonic and path")
+	}
+	if g1.GroupId.Version() != 4 {
+		t.Errorf("expect uuid4 group id, got %s", g1.GroupId)
+	}
+
+	g3, err := deriveGroup(mnemonic, "m/5395789'/0'/0'/0/1")
+	if err != nil {
+		t.Fatalf("deriveGroup failed: %s", err)
+	}
+	if g3.GroupId == g1.GroupId || bytes.Equal(g3.CipherKey, g1.CipherKey) {
+		t.Errorf("expect another group derived from another path")
+	}
+
+	// the owner key is not the first eth account of the mnemonic, which the wallets use
+	eth, err := deriveGroup(mnemonic, "m/44'/60'/0'/0/0")
+	if err != nil {
+		t.Fatalf("deriveGroup failed: %s", err)
+	}
+	if ethcrypto.PubkeyToAddress(eth.SignKey.PublicKey).Hex() != "0x9858EfFD232B4033E47d90003D41EC34EcaEda94" {
+		t.Fatalf("unexpected first eth account of the test mnemonic")
+	}
+	if eth.SignKey.Equal(g1.SignKey) {
+		t.Errorf("expect the default owner key is not the first eth account")
+	}
+
+	if _, err := deriveGroup(strings.Repeat("abandon ", 12), ""); err == nil {
+		t.Errorf("expect error with an invalid mnemonic")
+	}
+}
+
+func TestDerivedGroupSeedUrl(t *testing.T) {
+	key, _ := ethcrypto.GenerateKey()
+	seed := &GroupSeed{
+		GenesisBlock: &quorumpb.Block{
+			GroupId:        "5ed3f9fe-81e2-450d-9146-7a329aac2b62",
+			ProducerPubkey: hex.EncodeToString(ethcrypto.CompressPubkey(&key.PublicKey)),
+			Sudo:           true,
+		},
+		GroupName:      "derived",
+		ConsensusType:  "poa",
+		EncryptionType: "public",
+		CipherKey:      hex.EncodeToString(bytes.Repeat([]byte{1}, 32)),
+		AppKey:         "test_app",
+		DerivationPath: "m/44'/60'/0'/0/1",
+	}
+	seedUrl, err := GroupSeedToUrl(GroupSeedVersion, nil, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(seedUrl, "v=2&") {
+		t.Errorf("expect version 2 seed url, got %s", seedUrl)
+	}
+	decoded, _, err := UrlToGroupSeed(seedUrl)
+	if err != nil {
+		t.Fatalf("UrlToGroupSeed failed: %s", err)
+	}
+	if decoded.DerivationPath != seed.DerivationPath || decoded.GroupId != seed.GenesisBlock.GroupId {
+		t.Errorf("expect derivation path %s of group %s, got %s of %s", seed.DerivationPath, seed.GenesisBlock.GroupId, decoded.DerivationPath, decoded.GroupId)
+	}
+
+	if _, _, err := UrlToGroupSeed(strings.Replace(seedUrl, "v=2&", "v=3&", 1)); err == nil {
+		t.Errorf("expect error with an unknown seed version")
+	}
+}
@@ -0,0 +1,427 @@
+//go:build !js
+// +build !js
+
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	ethkeystore "github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/rumsystem/quorum/internal/pkg/appdata"
+	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
+	"github.com/rumsystem/quorum/internal/pkg/nodectx"
+	"github.com/rumsystem/quorum/internal/pkg/options"
+	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
+	rumchaindata "github.com/rumsystem/quorum/pkg/data"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+const (
+	GroupArchiveVersion = 1
+
+	// blocks applied to the chain at a time when importing
+	groupArchiveApplyBatch = 100
+)
+
+// GroupArchiveHeader is the first record of a group archive, the blocks from the genesis block follow it.
+// The keys are encrypted by the archive password.
+type GroupArchiveHeader struct {
+	Version    int       `json:"version"`
+	GroupId    string    `json:"group_id"`
+	Seed       string    `json:"seed"`
+	SignKey    []byte    `json:"sign_key"`              // eth keystore json of the group sign key
+	EncryptKey []byte    `json:"encrypt_key,omitempty"` // age encrypted group encrypt key
+	Blocks     uint64    `json:"blocks"`                // the number of blocks, including the genesis block
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type ExportGroupParam struct {
+	GroupId  string `param:"group_id" json:"-" validate:"required,uuid4" example:"ac0eea7c-2f3c-4c67-80b3-136e46b924a8"`
+	Password string `json:"password" validate:"required,min=8" example:"my.Passw0rd"` // encrypts the keys in the archive
+}
+
+type ImportGroupResult struct {
+	GroupId   string `json:"group_id" example:"ac0eea7c-2f3c-4c67-80b3-136e46b924a8"`
+	GroupName string `json:"group_name" example:"demo group"`
+	Blocks    uint64 `json:"blocks" example:"1024"` // the highest block id imported
+}
+
+// groupArchive is a validated archive, the blocks after the genesis block are spooled to a temp file
+type groupArchive struct {
+	header     *GroupArchiveHeader
+	seed       *GroupSeed
+	signKey    *ethkeystore.Key
+	encryptKey *age.X25519Identity
+	blocks     *os.File
+}
+
+func (a *groupArchive) Close() {
+	if a.blocks != nil {
+		a.blocks.Close()
+		os.Remove(a.blocks.Name())
+	}
+}
+
+// ExportGroup writes the seed, the keys and the blocks of the group to w as a gzip compressed archive,
+// the archive is imported on another node by ImportGroup
+func ExportGroup(groupId, password string, appdb *appdata.AppDb, w io.Writer) error {
+	group, ok := chain.GetGroupMgr().Groups[groupId]
+	if !ok {
+		return fmt.Errorf("Group %s not exist", groupId)
+	}
+
+	nodename := nodectx.GetNodeCtx().Name
+	chainStorage := nodectx.GetNodeCtx().GetChainStorage()
+	prunedBefore, err := chainStorage.GetPrunedBefore(groupId, nodename)
+	if err != nil {
+		return err
+	}
+	if prunedBefore > 1 {
+		return fmt.Errorf("the blocks before %d of group %s are pruned", prunedBefore, groupId)
+	}
+
+	seed, err := GetGroupSeed(groupId, appdb)
+	if err != nil {
+		return err
+	}
+	seedUrl, err := GroupSeedToUrl(1, nil, seed)
+	if err != nil {
+		return err
+	}
+
+	dirks, err := getDirKeyStore(nodectx.GetNodeCtx().Keystore)
+	if err != nil {
+		return err
+	}
+	header := &GroupArchiveHeader{
+		Version:   GroupArchiveVersion,
+		GroupId:   groupId,
+		Seed:      seedUrl,
+		Blocks:    group.GetCurrentBlockId() + 1,
+		CreatedAt: time.Now().UTC(),
+	}
+	header.SignKey, header.EncryptKey, err = exportGroupKeys(dirks, groupId, password)
+	if err != nil {
+		return err
+	}
+
+	return writeGroupArchive(w, header, func(blockId uint64) (*quorumpb.Block, error) {
+		return chainStorage.GetBlock(groupId, blockId, false, nodename)
+	})
+}
+
+// exportGroupKeys returns the sign key and the encrypt key of the group encrypted by password
+func exportGroupKeys(dirks *localcrypto.DirKeyStore, groupId, password string) ([]byte, []byte, error) {
+	key, err := dirks.GetKeyFromUnlocked(localcrypto.Sign.NameString(groupId))
+	if err != nil {
+		return nil, nil, fmt.Errorf("get sign key of group %s failed: %s", groupId, err)
+	}
+	signKey, ok := key.(*ethkeystore.Key)
+	if !ok {
+		return nil, nil, fmt.Errorf("the key of group %s is not a sign key", groupId)
+	}
+	signKeyJson, err := ethkeystore.EncryptKey(signKey, password, ethkeystore.StandardScryptN, ethkeystore.StandardScryptP)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key, err = dirks.GetKeyFromUnlocked(localcrypto.Encrypt.NameString(groupId))
+	if err != nil {
+		// the group may have no encrypt key, a new one is created by the import
+		return signKeyJson, nil, nil
+	}
+	encryptKey, ok := key.(*age.X25519Identity)
+	if !ok {
+		return nil, nil, fmt.Errorf("the key of group %s is not an encrypt key", groupId)
+	}
+	r, err := age.NewScryptRecipient(password)
+	if err != nil {
+		return nil, nil, err
+	}
+	var buf bytes.Buffer
+	if err := localcrypto.AgeEncrypt([]age.Recipient{r}, strings.NewReader(encryptKey.String()), &buf); err != nil {
+		return nil, nil, err
+	}
+
+	return signKeyJson, buf.Bytes(), nil
+}
+
+func writeGroupArchive(w io.Writer, header *GroupArchiveHeader, getBlock func(uint64) (*quorumpb.Block, error)) error {
+	zw := gzip.NewWriter(w)
+	enc := json.NewEncoder(zw)
+	if err := enc.Encode(header); err != nil {
+		return err
+	}
+	for blockId := uint64(0); blockId < header.Blocks; blockId++ {
+		block, err := getBlock(blockId)
+		if err != nil {
+			return fmt.Errorf("get block %d failed: %s", blockId, err)
+		}
+		if err := enc.Encode(block); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// readGroupArchive decodes the archive and decrypts the keys, the genesis block is checked against the seed
+// and every block against its parent, so a tampered archive is rejected before anything is written
+func readGroupArchive(r io.Reader, password string) (*groupArchive, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid group archive: %s", err)
+	}
+	defer zr.Close()
+	dec := json.NewDecoder(zr)
+
+	header := &GroupArchiveHeader{}
+	if err := dec.Decode(header); err != nil {
+		return nil, fmt.Errorf("decode group archive header failed: %s", err)
+	}
+	if header.Version != GroupArchiveVersion {
+		return nil, fmt.Errorf("unsupported group archive version %d", header.Version)
+	}
+	if header.Blocks == 0 {
+		return nil, errors.New("genesis block not found in group archive")
+	}
+
+	seed, _, err := UrlToGroupSeed(header.Seed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid group seed: %s", err)
+	}
+	if seed.GroupId != header.GroupId {
+		return nil, fmt.Errorf("group id %s mismatch with the seed %s", header.GroupId, seed.GroupId)
+	}
+	if valid, err := rumchaindata.ValidGenesisBlock(seed.GenesisBlock); !valid {
+		return nil, fmt.Errorf("verify genesis block of the seed failed: %v", err)
+	}
+
+	archive := &groupArchive{header: header, seed: seed}
+	if archive.signKey, err = ethkeystore.DecryptKey(header.SignKey, password); err != nil {
+		return nil, fmt.Errorf("decrypt sign key failed: %s", err)
+	}
+	if len(header.EncryptKey) > 0 {
+		if archive.encryptKey, err = localcrypto.AgeDecryptIdentityWithPassword(bytes.NewReader(header.EncryptKey), nil, password); err != nil {
+			return nil, fmt.Errorf("decrypt encrypt key failed: %s", err)
+		}
+	}
+
+	genesis := &quorumpb.Block{}
+	if err := dec.Decode(genesis); err != nil {
+		return nil, fmt.Errorf("decode genesis block failed: %s", err)
+	}
+	if genesis.BlockId != 0 || genesis.GroupId != seed.GroupId || !bytes.Equal(genesis.BlockHash, seed.GenesisBlock.BlockHash) {
+		return nil, errors.New("genesis block mismatch with the seed")
+	}
+
+	archive.blocks, err = os.CreateTemp("", "rumgroup-*.blocks")
+	if err != nil {
+		return nil, err
+	}
+	enc := json.NewEncoder(archive.blocks)
+	parent := seed.GenesisBlock
+	for i := uint64(1); i < header.Blocks; i++ {
+		block := &quorumpb.Block{}
+		if err := dec.Decode(block); err != nil {
+			archive.Close()
+			return nil, fmt.Errorf("decode block %d failed: %s", i, err)
+		}
+		if block.GroupId != seed.GroupId {
+			archive.Close()
+			return nil, fmt.Errorf("block %d belongs to group %s", block.BlockId, block.GroupId)
+		}
+		if valid, err := rumchaindata.ValidBlockWithParent(block, parent); !valid {
+			archive.Close()
+			return nil, fmt.Errorf("verify block %d failed: %v", i, err)
+		}
+		if err := enc.Encode(block); err != nil {
+			archive.Close()
+			return nil, err
+		}
+		parent = block
+	}
+	if dec.More() {
+		archive.Close()
+		return nil, errors.New("unexpected data after the last block")
+	}
+	if _, err := archive.blocks.Seek(0, io.SeekStart); err != nil {
+		archive.Close()
+		return nil, err
+	}
+
+	return archive, nil
+}
+
+// ImportGroup joins the group of an archive written by ExportGroup with the keys in it.
+// The blocks of the archive are applied and checked before the group is registered and its seed is saved,
+// the group data and the keys imported are removed if the import fails.
+func ImportGroup(r io.Reader, password string, appdb *appdata.AppDb) (*ImportGroupResult, error) {
+	archive, err := readGroupArchive(r, password)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	seed := archive.seed
+	groupmgr := chain.GetGroupMgr()
+	if _, ok := groupmgr.Groups[seed.GroupId]; ok {
+		return nil, fmt.Errorf("group with group_id <%s> already exist", seed.GroupId)
+	}
+
+	ks := nodectx.GetNodeCtx().Keystore
+	nodeoptions := options.GetNodeOptions()
+	dirks, err := getDirKeyStore(ks)
+	if err != nil {
+		return nil, err
+	}
+	existed, err := groupKeyExist(seed.GroupId, ks)
+	if err != nil {
+		return nil, err
+	}
+	var group *chain.Group
+	imported := false
+	defer func() {
+		if imported {
+			return
+		}
+		if group != nil {
+			if err := rollbackImportedGroup(group); err != nil {
+				logger.Warnf("remove data of group %s failed: %s", seed.GroupId, err)
+			}
+		}
+		if !existed {
+			if err := RemoveGroupKeys(seed.GroupId, ks, nodeoptions); err != nil {
+				logger.Warnf("remove keys of group %s failed: %s", seed.GroupId, err)
+			}
+		}
+	}()
+
+	item, err := importGroupKeys(dirks, nodeoptions, archive)
+	if err != nil {
+		return nil, err
+	}
+
+	item.GroupId = seed.GroupId
+	item.GroupName = seed.GroupName
+	item.OwnerPubKey = seed.OwnerPubkey
+	item.CipherKey = seed.CipherKey
+	item.AppKey = seed.AppKey
+	item.LastUpdate = seed.GenesisBlock.TimeStamp
+	item.GenesisBlock = seed.GenesisBlock
+	if seed.ConsensusType == "pos" {
+		item.ConsenseType = quorumpb.GroupConsenseType_POS
+	} else {
+		item.ConsenseType = quorumpb.GroupConsenseType_POA
+	}
+	if seed.EncryptionType == "public" {
+		item.EncryptType = quorumpb.GroupEncryptType_PUBLIC
+	} else {
+		item.EncryptType = quorumpb.GroupEncryptType_PRIVATE
+	}
+
+	group = &chain.Group{}
+	if err := group.NewGroup(item); err != nil {
+		return nil, err
+	}
+
+	var lastBlockId uint64
+	dec := json.NewDecoder(archive.blocks)
+	batch := make([]*quorumpb.Block, 0, groupArchiveApplyBatch)
+	for dec.More() {
+		block := &quorumpb.Block{}
+		if err := dec.Decode(block); err != nil {
+			return nil, err
+		}
+		batch = append(batch, block)
+		lastBlockId = block.BlockId
+		if len(batch) == groupArchiveApplyBatch || !dec.More() {
+			if err := group.ChainCtx.ApplyBlocks(batch); err != nil {
+				return nil, fmt.Errorf("apply blocks failed: %s", err)
+			}
+			batch = batch[:0]
+		}
+	}
+	if current := group.GetCurrentBlockId(); current != lastBlockId {
+		return nil, fmt.Errorf("applied blocks up to %d of the %d blocks in the archive", current, lastBlockId)
+	}
+
+	pbGroupSeed := ToPbGroupSeed(*seed)
+	if err := appdb.SetGroupSeed(&pbGroupSeed); err != nil {
+		return nil, fmt.Errorf("save group seed failed: %s", err)
+	}
+	groupmgr.Groups[group.Item.GroupId] = group
+	imported = true
+
+	if err := groupmgr.EnqueueSync(group); err != nil {
+		return nil, err
+	}
+
+	return &ImportGroupResult{GroupId: item.GroupId, GroupName: item.GroupName, Blocks: group.GetCurrentBlockId()}, nil
+}
+
+// rollbackImportedGroup removes the group created by ImportGroup, it is not registered to the group manager yet
+func rollbackImportedGroup(group *chain.Group) error {
+	if err := group.ClearGroupData(); err != nil {
+		return err
+	}
+	return group.LeaveGrp()
+}
+
+// importGroupKeys saves the keys of the archive to the keystore, the existing group keys should be the same keys.
+// It returns the group item with the user pubkeys.
+func importGroupKeys(dirks *localcrypto.DirKeyStore, nodeoptions *options.NodeOptions, archive *groupArchive) (*quorumpb.GroupItem, error) {
+	groupId := archive.header.GroupId
+	signKeyName := localcrypto.Sign.NameString(groupId)
+	exist, err := dirks.IfKeyExist(signKeyName)
+	if err != nil {
+		return nil, err
+	}
+	addr := archive.signKey.Address.String()
+	if exist {
+		key, err := dirks.GetKeyFromUnlocked(signKeyName)
+		if err != nil {
+			return nil, err
+		}
+		if k, ok := key.(*ethkeystore.Key); !ok || k.Address != archive.signKey.Address {
+			return nil, fmt.Errorf("group %s already has a different sign key", groupId)
+		}
+	} else if addr, err = dirks.ImportSignKey(groupId, archive.signKey.PrivateKey); err != nil {
+		return nil, err
+	}
+	if err := nodeoptions.SetSignKeyMap(groupId, addr); err != nil {
+		return nil, fmt.Errorf("save key map %s err: %s", addr, err)
+	}
+
+	exist, err = dirks.IfKeyExist(localcrypto.Encrypt.NameString(groupId))
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		if archive.encryptKey != nil {
+			_, err = dirks.ImportEncryptKey(groupId, archive.encryptKey)
+		} else {
+			_, err = dirks.NewKeyWithDefaultPassword(groupId, localcrypto.Encrypt)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	item := &quorumpb.GroupItem{}
+	if item.UserSignPubkey, err = dirks.GetEncodedPubkey(groupId, localcrypto.Sign); err != nil {
+		return nil, err
+	}
+	if item.UserEncryptPubkey, err = dirks.GetEncodedPubkey(groupId, localcrypto.Encrypt); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
@@ -0,0 +1,143 @@
+//go:build !js
+// +build !js
+
+package handlers
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	guuid "github.com/google/uuid"
+	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
+	rumchaindata "github.com/rumsystem/quorum/pkg/data"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestGroupArchive(t *testing.T) {
+	if _, err := localcrypto.InitKeystore("archivetest", t.TempDir()); err != nil {
+		t.Fatalf("init keystore failed: %s", err)
+	}
+	ks := localcrypto.GetKeystore()
+	dirks := ks.(*localcrypto.DirKeyStore)
+
+	groupId := guuid.New().String()
+	if _, err := dirks.NewKey(groupId, localcrypto.Sign, "key.Passw0rd"); err != nil {
+		t.Fatalf("new sign key failed: %s", err)
+	}
+	if _, err := dirks.NewKey(groupId, localcrypto.Encrypt, "key.Passw0rd"); err != nil {
+		t.Fatalf("new encrypt key failed: %s", err)
+	}
+	pubkey, err := dirks.GetEncodedPubkey(groupId, localcrypto.Sign)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	genesis, err := rumchaindata.CreateGenesisBlockByEthKey(groupId, pubkey, ks, "")
+	if err != nil {
+		t.Fatalf("create genesis block failed: %s", err)
+	}
+	blocks := []*quorumpb.Block{genesis}
+	for i := 1; i < 5; i++ {
+		block, err := rumchaindata.CreateBlockByEthKey(blocks[i-1], uint64(i), nil, false, pubkey, ks, "")
+		if err != nil {
+			t.Fatalf("create block %d failed: %s", i, err)
+		}
+		blocks = append(blocks, block)
+	}
+
+	seed := &GroupSeed{
+		GenesisBlock:   genesis,
+		GroupId:        groupId,
+		GroupName:      "archive",
+		OwnerPubkey:    pubkey,
+		ConsensusType:  "poa",
+		EncryptionType: "public",
+		CipherKey:      hex.EncodeToString(bytes.Repeat([]byte{1}, 32)),
+		AppKey:         "test_app",
+	}
+	seedUrl, err := GroupSeedToUrl(1, nil, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	password := "my.Passw0rd"
+	signKey, encryptKey, err := exportGroupKeys(dirks, groupId, password)
+	if err != nil {
+		t.Fatalf("exportGroupKeys failed: %s", err)
+	}
+	header := &GroupArchiveHeader{
+		Version:    GroupArchiveVersion,
+		GroupId:    groupId,
+		Seed:       seedUrl,
+		SignKey:    signKey,
+		EncryptKey: encryptKey,
+		Blocks:     uint64(len(blocks)),
+		CreatedAt:  time.Now().UTC(),
+	}
+	write := func(getBlock func(uint64) (*quorumpb.Block, error)) *bytes.Buffer {
+		var buf bytes.Buffer
+		if err := writeGroupArchive(&buf, header, getBlock); err != nil {
+			t.Fatalf("writeGroupArchive failed: %s", err)
+		}
+		return &buf
+	}
+	getBlock := func(blockId uint64) (*quorumpb.Block, error) {
+		return blocks[blockId], nil
+	}
+
+	archive, err := readGroupArchive(write(getBlock), password)
+	if err != nil {
+		t.Fatalf("readGroupArchive failed: %s", err)
+	}
+	defer archive.Close()
+	_, addr, err := dirks.GetPeerInfo(groupId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if archive.signKey.Address.String() != addr {
+		t.Fatalf("expect sign key %s, got %s", addr, archive.signKey.Address)
+	}
+	recipient, err := dirks.GetEncodedPubkey(groupId, localcrypto.Encrypt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if archive.encryptKey == nil || archive.encryptKey.Recipient().String() != recipient {
+		t.Fatalf("expect encrypt key %s", recipient)
+	}
+	dec := json.NewDecoder(archive.blocks)
+	var spooled uint64
+	for dec.More() {
+		block := &quorumpb.Block{}
+		if err := dec.Decode(block); err != nil {
+			t.Fatal(err)
+		}
+		spooled++
+		if block.BlockId != spooled {
+			t.Fatalf("expect block %d, got %d", spooled, block.BlockId)
+		}
+	}
+	if spooled != 4 {
+		t.Fatalf("expect 4 blocks after the genesis block, got %d", spooled)
+	}
+
+	if _, err := readGroupArchive(write(getBlock), "wrong.password"); err == nil {
+		t.Errorf("expect error with a wrong password")
+	}
+
+	tampered := func(blockId uint64) (*quorumpb.Block, error) {
+		if blockId != 3 {
+			return blocks[blockId], nil
+		}
+		b := proto.Clone(blocks[3]).(*quorumpb.Block)
+		b.TimeStamp++
+		return b, nil
+	}
+	if _, err := readGroupArchive(write(tampered), password); err == nil || !strings.Contains(err.Error(), "block 3") {
+		t.Errorf("expect tampered block 3 rejected, got %v", err)
+	}
+}
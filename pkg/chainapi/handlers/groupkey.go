@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
+	"github.com/rumsystem/quorum/internal/pkg/nodectx"
+	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+type GroupKeyMemberParam struct {
+	GroupId    string `param:"group_id" json:"-" validate:"required,uuid4" example:"5ed3f9fe-81e2-450d-9146-7a329aac2b62"`
+	UserPubkey string `json:"user_pubkey" validate:"required" example:"CAISIQOxCH2yVZPR8t6gVvZapxcIPBwMh9jB80pDLNeuA5s8hQ=="` // sign pubkey of a user of the group
+}
+
+type GroupKeyResult struct {
+	GroupId string   `json:"group_id" example:"5ed3f9fe-81e2-450d-9146-7a329aac2b62"`
+	Version uint32   `json:"version" example:"2"`                                    // the version new posts are sealed with
+	TrxIds  []string `json:"trx_ids" example:"9e54c173-c1dd-429d-91fa-a6b43c14da77"` // the app config trxs of the version with its shares, or of the share
+}
+
+type GroupKeyStatus struct {
+	GroupId string   `json:"group_id" example:"5ed3f9fe-81e2-450d-9146-7a329aac2b62"`
+	Version uint32   `json:"version" example:"2"`                                                    // 0 if the posts are encrypted by age to the users
+	Members []string `json:"members" example:"CAISIQOxCH2yVZPR8t6gVvZapxcIPBwMh9jB80pDLNeuA5s8hQ=="` // sign pubkeys holding a share of the version
+	HasKey  bool     `json:"has_key" example:"true"`                                                 // whether this node can read and post with the version
+}
+
+// getPrivateGroupOfOwner returns the group if it is private and this node is the owner
+func getPrivateGroupOfOwner(groupId string) (*chain.Group, error) {
+	group, err := getGroup(groupId)
+	if err != nil {
+		return nil, err
+	}
+	if group.Item.EncryptType != quorumpb.GroupEncryptType_PRIVATE {
+		return nil, fmt.Errorf("group %s is not a private group", groupId)
+	}
+	if group.Item.OwnerPubKey != group.Item.UserSignPubkey {
+		return nil, rumerrors.ErrOnlyGroupOwner
+	}
+	return group, nil
+}
+
+// GrantGroupKey issues a share of the current group key to a user of the group, the first grant creates the group key
+func GrantGroupKey(params *GroupKeyMemberParam) (*GroupKeyResult, error) {
+	group, err := getPrivateGroupOfOwner(params.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	encryptPubkey, ok := group.ChainCtx.GetUserEncryptPubkey(params.UserPubkey)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a user of the group, add the user first", params.UserPubkey)
+	}
+
+	version, err := chain.GetGroupKeyVersion(params.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	if version == 0 {
+		key, err := localcrypto.CreateAesKey()
+		if err != nil {
+			return nil, err
+		}
+		ownerEncryptPubkey, err := nodectx.GetNodeCtx().Keystore.GetEncodedPubkey(params.GroupId, localcrypto.Encrypt)
+		if err != nil {
+			return nil, err
+		}
+		members := map[string]string{group.Item.UserSignPubkey: ownerEncryptPubkey, params.UserPubkey: encryptPubkey}
+		return issueGroupKey(group, 1, key, members, true)
+	}
+
+	key, err := chain.GetGroupKey(group.Item, version)
+	if err != nil {
+		return nil, err
+	}
+	return issueGroupKey(group, version, key, map[string]string{params.UserPubkey: encryptPubkey}, false)
+}
+
+// RevokeGroupKey rotates the group key, the new version is shared with the members of the current version except the user.
+// The user can't read the posts sealed from now on, the posts before stay readable by it
+func RevokeGroupKey(params *GroupKeyMemberParam) (*GroupKeyResult, error) {
+	group, err := getPrivateGroupOfOwner(params.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	if params.UserPubkey == group.Item.UserSignPubkey {
+		return nil, fmt.Errorf("the owner can't be revoked")
+	}
+
+	version, err := chain.GetGroupKeyVersion(params.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	if version == 0 {
+		return nil, fmt.Errorf("group %s has no group key", params.GroupId)
+	}
+	holders, err := chain.GetGroupKeyMembers(params.GroupId, version)
+	if err != nil {
+		return nil, err
+	}
+
+	ownerEncryptPubkey, err := nodectx.GetNodeCtx().Keystore.GetEncodedPubkey(params.GroupId, localcrypto.Encrypt)
+	if err != nil {
+		return nil, err
+	}
+	members := map[string]string{group.Item.UserSignPubkey: ownerEncryptPubkey}
+	for _, pubkey := range holders {
+		if pubkey == params.UserPubkey || pubkey == group.Item.UserSignPubkey {
+			continue
+		}
+		// the users removed from the group are not shared with either
+		if encryptPubkey, ok := group.ChainCtx.GetUserEncryptPubkey(pubkey); ok {
+			members[pubkey] = encryptPubkey
+		}
+	}
+
+	key, err := localcrypto.CreateAesKey()
+	if err != nil {
+		return nil, err
+	}
+	return issueGroupKey(group, version+1, key, members, true)
+}
+
+// issueGroupKey sends the shares of the key to the members. A new version is sent with the shares in one trx,
+// so the members never see the version before their shares, the shares of an existing version are sent one by one
+func issueGroupKey(group *chain.Group, version uint32, key []byte, members map[string]string, newVersion bool) (*GroupKeyResult, error) {
+	res := &GroupKeyResult{GroupId: group.Item.GroupId, Version: version, TrxIds: []string{}}
+	shares := map[string]string{}
+	for pubkey, encryptPubkey := range members {
+		share, err := chain.NewGroupKeyShare(key, encryptPubkey)
+		if err != nil {
+			return nil, err
+		}
+		shares[pubkey] = share
+	}
+
+	items := []*quorumpb.AppConfigItem{}
+	if newVersion {
+		value, err := json.Marshal(shares)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &quorumpb.AppConfigItem{
+			GroupId: group.Item.GroupId,
+			Action:  quorumpb.ActionType_ADD,
+			Name:    chain.GroupKeyName(version),
+			Type:    quorumpb.AppConfigType_STRING,
+			Value:   string(value),
+			Memo:    "group key version",
+		})
+	} else {
+		for pubkey, share := range shares {
+			items = append(items, &quorumpb.AppConfigItem{
+				GroupId: group.Item.GroupId,
+				Action:  quorumpb.ActionType_ADD,
+				Name:    chain.GroupKeyShareName(version, pubkey),
+				Type:    quorumpb.AppConfigType_STRING,
+				Value:   share,
+				Memo:    "group key share",
+			})
+		}
+	}
+
+	for _, item := range items {
+		trxId, err := sendAppConfigItem(group, item)
+		if err != nil {
+			return nil, err
+		}
+		res.TrxIds = append(res.TrxIds, trxId)
+	}
+	return res, nil
+}
+
+func sendAppConfigItem(group *chain.Group, item *quorumpb.AppConfigItem) (string, error) {
+	if err := signAppConfigItem(group, item); err != nil {
+		return "", err
+	}
+	return group.UpdAppConfig(item)
+}
+
+// GetGroupKeyStatus returns the current version of the group key and its members
+func GetGroupKeyStatus(groupId string) (*GroupKeyStatus, error) {
+	group, err := getGroup(groupId)
+	if err != nil {
+		return nil, err
+	}
+	version, err := chain.GetGroupKeyVersion(groupId)
+	if err != nil {
+		return nil, err
+	}
+	res := &GroupKeyStatus{GroupId: groupId, Version: version, Members: []string{}}
+	if version == 0 {
+		return res, nil
+	}
+	if res.Members, err = chain.GetGroupKeyMembers(groupId, version); err != nil {
+		return nil, err
+	}
+	_, err = chain.GetGroupKey(group.Item, version)
+	res.HasKey = err == nil
+	return res, nil
+}
@@ -19,7 +19,18 @@ import (
 	"github.com/rumsystem/quorum/pkg/pb"
 )
 
+const (
+	// GroupSeedVersion is the seed url version of the groups with random genesis keys
+	GroupSeedVersion = 1
+	// GroupSeedVersionDerived is the seed url version of the groups derived from a mnemonic, the url has the derivation path
+	GroupSeedVersionDerived = 2
+)
+
 func GroupSeedToUrl(version int, urls []string, seed *GroupSeed) (string, error) {
+	if seed.DerivationPath != "" && version < GroupSeedVersionDerived {
+		version = GroupSeedVersionDerived
+	}
+
 	urllist := []string{}
 	for _, u := range urls {
 		urllist = append(urllist, url.QueryEscape(u))
@@ -69,6 +80,9 @@ func GroupSeedToUrl(version int, urls []string, seed *GroupSeed) (string, error)
 	values.Add("t", b64timestampstr)
 	values.Add("s", b64sign)
 	values.Add("c", b64cipher)
+	if seed.DerivationPath != "" {
+		values.Add("d", seed.DerivationPath)
+	}
 	query := values.Encode()
 	query = fmt.Sprintf("rum://seed?v=%d&e=%d&n=%d&%s&a=%s&y=%s&u=%s", version, intencrypttype, intconsensustype, query, url.QueryEscape(seed.GroupName), url.QueryEscape(seed.AppKey), strings.Join(urllist, "|"))
 	return query, nil
@@ -87,9 +101,17 @@ func UrlToGroupSeed(seedurl string) (*GroupSeed, []string, error) {
 		return nil, nil, err
 	}
 	version := q.Get("v")
-	if version != "1" {
+	if version != strconv.Itoa(GroupSeedVersion) && version != strconv.Itoa(GroupSeedVersionDerived) {
 		return nil, nil, errors.New("unsupport seed url version")
 	}
+	derivationPath := q.Get("d")
+	if version == strconv.Itoa(GroupSeedVersionDerived) {
+		if _, err := localcrypto.ParseDerivationPath(derivationPath); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		derivationPath = ""
+	}
 
 	b64gstr := q.Get("g")
 	b64gbyte, err := base64.RawURLEncoding.DecodeString(b64gstr)
@@ -173,9 +195,10 @@ func UrlToGroupSeed(seedurl string) (*GroupSeed, []string, error) {
 		GroupId:        genesisBlock.GroupId,
 		//OwnerPubkey:    genesisBlock.ProducerPubKey,
 		//Signature:      hex.EncodeToString(genesisBlock.Signature),
-		OwnerPubkey: genesisBlock.ProducerPubkey,
-		Signature:   hex.EncodeToString(genesisBlock.ProducerSign),
-		AppKey:      appkey,
+		OwnerPubkey:    genesisBlock.ProducerPubkey,
+		Signature:      hex.EncodeToString(genesisBlock.ProducerSign),
+		AppKey:         appkey,
+		DerivationPath: derivationPath,
 	}
 
 	urlstr := q.Get("u")
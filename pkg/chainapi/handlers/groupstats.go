@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"github.com/rumsystem/quorum/internal/pkg/nodectx"
+)
+
+// the latest blocks read to estimate the trxs and the size of a group
+const groupStatsSample = 64
+
+type GroupStatsResult struct {
+	GroupId    string `json:"group_id" example:"ac0eea7c-2f3c-4c67-80b3-136e46b924a8"`
+	Epoch      uint64 `json:"epoch" example:"1024"`
+	BlockId    uint64 `json:"block_id" example:"1024"`
+	Blocks     uint64 `json:"blocks" example:"1025"`    // blocks held, the genesis block included and the pruned ones excluded
+	Trxs       uint64 `json:"trxs" example:"2048"`      // estimated from the latest blocks
+	DiskSize   int64  `json:"disk_size" example:"8192"` // estimated bytes of the blocks and trxs, without the db overhead
+	Sampled    int    `json:"sampled" example:"64"`     // blocks read for the estimates, they are exact if all blocks are read
+	Producers  int    `json:"producers" example:"1"`
+	PendingTrx int    `json:"pending_trx" example:"0"`          // trxs sent by this node and not applied yet
+	LastSynced int64  `json:"last_synced" example:"1663900000"` // unix seconds of the last sync response, 0 if never synced
+	SyncStatus string `json:"sync_status" example:"IDLE"`
+}
+
+// GetGroupStats returns the size and the state of the group, the trxs and the disk size are estimated from the latest
+// blocks, so that it is cheap to poll for all groups
+func GetGroupStats(groupId string) (*GroupStatsResult, error) {
+	group, err := getGroup(groupId)
+	if err != nil {
+		return nil, err
+	}
+
+	producers, err := group.GetProducers()
+	if err != nil {
+		return nil, err
+	}
+	blockId := group.GetCurrentBlockId()
+	data, err := nodectx.GetNodeCtx().GetChainStorage().GetGroupDataStats(groupId, blockId, groupStatsSample, group.Nodename)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GroupStatsResult{
+		GroupId:    groupId,
+		Epoch:      group.GetCurrentEpoch(),
+		BlockId:    blockId,
+		Blocks:     data.Blocks,
+		Trxs:       data.Trxs,
+		DiskSize:   data.Bytes,
+		Sampled:    data.Sampled,
+		Producers:  len(producers),
+		PendingTrx: group.ChainCtx.GetPendingTrxCount(),
+		LastSynced: group.ChainCtx.GetLastSyncTime(),
+		SyncStatus: group.GetRexSyncerStatus(),
+	}, nil
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"errors"
+
+	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
+)
+
+type GroupSyncResult struct {
+	GroupId string `json:"group_id" example:"5ed3f9fe-81e2-450d-9146-7a329aac2b62"`
+	Status  string `json:"status" example:"PAUSED"`
+}
+
+// PauseSync stops fetching blocks of the group, the node keeps serving blocks to peers
+func PauseSync(groupId string) (*GroupSyncResult, error) {
+	return updGroupSync(groupId, chain.GetGroupMgr().PauseSync)
+}
+
+// ResumeSync starts fetching blocks of a paused group
+func ResumeSync(groupId string) (*GroupSyncResult, error) {
+	return updGroupSync(groupId, chain.GetGroupMgr().ResumeSync)
+}
+
+func updGroupSync(groupId string, fn func(groupId string) error) (*GroupSyncResult, error) {
+	if groupId == "" {
+		return nil, errors.New("group_id can't be nil.")
+	}
+
+	if err := fn(groupId); err != nil {
+		return nil, err
+	}
+
+	group := chain.GetGroupMgr().Groups[groupId]
+	return &GroupSyncResult{GroupId: groupId, Status: group.GetRexSyncerStatus()}, nil
+}
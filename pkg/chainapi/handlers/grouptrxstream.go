@@ -0,0 +1,6 @@
+package handlers
+
+type GroupTrxStreamParams struct {
+	GroupId string `param:"group_id" json:"group_id" url:"-" validate:"required,uuid4"`
+	Since   string `query:"since" json:"since" url:"since,omitempty" validate:"omitempty,uuid4"` // resume after this trx
+}
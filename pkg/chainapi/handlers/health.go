@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"errors"
+
+	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
+	"github.com/rumsystem/quorum/internal/pkg/conn/p2p"
+	"github.com/rumsystem/quorum/internal/pkg/storage"
+)
+
+// CheckHealth returns an error if the db is not open, it never reads the chain data
+func CheckHealth(dbMgr *storage.DbMgr) error {
+	if dbMgr == nil || !dbMgr.IsOpen() {
+		return errors.New("db is not open")
+	}
+	return nil
+}
+
+// CheckReady returns an error if the node is not listening, has no connected peers or the groups are not loaded
+func CheckReady(node *p2p.Node, groupMgr *chain.GroupMgr) error {
+	if node == nil || node.Host == nil {
+		return errors.New("node is not started")
+	}
+	if len(node.Host.Network().ListenAddresses()) == 0 {
+		return errors.New("node is not listening")
+	}
+	if len(node.Host.Network().Peers()) == 0 {
+		return errors.New("no connected peers")
+	}
+	if groupMgr == nil || !groupMgr.Loaded() {
+		return errors.New("groups are not loaded")
+	}
+	return nil
+}
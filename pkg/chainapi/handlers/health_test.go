@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/peer"
+	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
+	"github.com/rumsystem/quorum/internal/pkg/conn/p2p"
+	"github.com/rumsystem/quorum/internal/pkg/nodectx"
+	"github.com/rumsystem/quorum/internal/pkg/storage"
+)
+
+func TestCheckHealth(t *testing.T) {
+	dbMgr := &storage.DbMgr{GroupInfoDb: storage.NewMemStore(), Db: storage.NewMemStore()}
+	if err := CheckHealth(dbMgr); err != nil {
+		t.Errorf("expect healthy with an open db, got %s", err)
+	}
+	dbMgr.CloseDb()
+	if err := CheckHealth(dbMgr); err == nil {
+		t.Errorf("expect unhealthy after the db is closed")
+	}
+	if err := CheckHealth(nil); err == nil {
+		t.Errorf("expect unhealthy without db")
+	}
+}
+
+func TestCheckReady(t *testing.T) {
+	h1, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h1.Close()
+	h2, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h2.Close()
+
+	dbMgr := &storage.DbMgr{GroupInfoDb: storage.NewMemStore(), Db: storage.NewMemStore()}
+	nodectx.InitCtx(context.Background(), "test", nil, dbMgr, nil, "", "", nodectx.FULL_NODE)
+	if err := chain.InitGroupMgr(); err != nil {
+		t.Fatal(err)
+	}
+	groupMgr := chain.GetGroupMgr()
+
+	node := &p2p.Node{Host: h1}
+	if err := CheckReady(nil, groupMgr); err == nil {
+		t.Errorf("expect not ready without node")
+	}
+	if err := CheckReady(node, groupMgr); err == nil {
+		t.Errorf("expect not ready without connected peers")
+	}
+
+	if err := h1.Connect(context.Background(), peer.AddrInfo{ID: h2.ID(), Addrs: h2.Addrs()}); err != nil {
+		t.Fatalf("connect failed: %s", err)
+	}
+	if err := CheckReady(node, groupMgr); err == nil {
+		t.Errorf("expect not ready before the groups are loaded")
+	}
+
+	if err := groupMgr.LoadAllGroups(); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckReady(node, groupMgr); err != nil {
+		t.Errorf("expect ready, got %s", err)
+	}
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	p2pcrypto "github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/p2p/protocol/identify"
+	maddr "github.com/multiformats/go-multiaddr"
+	"github.com/rumsystem/quorum/internal/pkg/conn/p2p"
+)
+
+type NodeIdentity struct {
+	PeerId         string   `json:"peer_id" example:"16Uiu2HAm8XVpfQrJYaeL7XtrHC3FvfKt2QW7P8R3MBenYyHxu8Kk"`
+	EthAddr        string   `json:"eth_addr" example:"0x2db09CC5a4aF9e3a3ea07e9a6f27e6c1D5E3Ac9a"`
+	Pubkey         string   `json:"pubkey" example:"CAISIQJCVubdxsT/FKvnBT9r68W4Nmh0/2it7KY+dA7x25NtYg=="` // libp2p public key, base64 of the protobuf
+	ListenAddrs    []string `json:"listen_addrs"`                                                          // addrs the node listens on, the unspecified ips are expanded to the interface ips
+	AnnouncedAddrs []string `json:"announced_addrs"`                                                       // addrs the node announces to other peers
+	ObservedAddrs  []string `json:"observed_addrs"`                                                        // addrs other peers see us dialing from, empty until other peers are connected
+	P2pAddrs       []string `json:"p2p_addrs"`                                                             // the announced addrs with the peer id, e.g. as the bootstrap addrs of other nodes
+}
+
+// GetNodeIdentity returns the peer id, the keys and the addrs of the node
+func GetNodeIdentity(node *p2p.Node, ethaddr string) (*NodeIdentity, error) {
+	if node == nil || node.Host == nil {
+		return nil, errors.New("node is not available")
+	}
+
+	h := node.Host
+	pubkeybytes, err := p2pcrypto.MarshalPublicKey(h.Peerstore().PubKey(h.ID()))
+	if err != nil {
+		return nil, err
+	}
+	result := &NodeIdentity{
+		PeerId:         h.ID().String(),
+		EthAddr:        ethaddr,
+		Pubkey:         p2pcrypto.ConfigEncodeKey(pubkeybytes),
+		ListenAddrs:    []string{},
+		AnnouncedAddrs: []string{},
+		ObservedAddrs:  []string{},
+		P2pAddrs:       []string{},
+	}
+
+	listenAddrs, err := h.Network().InterfaceListenAddresses()
+	if err != nil {
+		listenAddrs = h.Network().ListenAddresses()
+	}
+	for _, addr := range listenAddrs {
+		// the relay transport listens on /p2p-circuit, the reserved circuit addrs are in the announced addrs
+		if _, err := addr.ValueForProtocol(maddr.P_CIRCUIT); err == nil {
+			continue
+		}
+		result.ListenAddrs = append(result.ListenAddrs, addr.String())
+	}
+	for _, addr := range h.Addrs() {
+		result.AnnouncedAddrs = append(result.AnnouncedAddrs, addr.String())
+		result.P2pAddrs = append(result.P2pAddrs, fmt.Sprintf("%s/p2p/%s", addr, h.ID()))
+	}
+	// the routed host of the dht hides the identify service, see GetNATStatus
+	if ids, ok := h.(interface{ IDService() identify.IDService }); ok && ids.IDService() != nil {
+		for _, addr := range ids.IDService().OwnObservedAddrs() {
+			result.ObservedAddrs = append(result.ObservedAddrs, addr.String())
+		}
+	}
+
+	return result, nil
+}
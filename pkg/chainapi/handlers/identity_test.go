@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/libp2p/go-libp2p"
+	p2pcrypto "github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/rumsystem/quorum/internal/pkg/conn/p2p"
+)
+
+func TestGetNodeIdentity(t *testing.T) {
+	h, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	ethaddr := "0x2db09CC5a4aF9e3a3ea07e9a6f27e6c1D5E3Ac9a"
+	res, err := GetNodeIdentity(&p2p.Node{Host: h}, ethaddr)
+	if err != nil {
+		t.Fatalf("get node identity failed: %s", err)
+	}
+	if res.PeerId != h.ID().String() || res.EthAddr != ethaddr {
+		t.Errorf("expect identity of %s, got %+v", h.ID(), res)
+	}
+
+	pubkeybytes, err := p2pcrypto.ConfigDecodeKey(res.Pubkey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubkey, err := p2pcrypto.UnmarshalPublicKey(pubkeybytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pid, err := peer.IDFromPublicKey(pubkey); err != nil || pid != h.ID() {
+		t.Errorf("expect pubkey of %s, got %s", h.ID(), pid)
+	}
+
+	if len(res.ListenAddrs) != 1 || !strings.HasPrefix(res.ListenAddrs[0], "/ip4/127.0.0.1/tcp/") {
+		t.Errorf("expect listen addr on localhost, got %v", res.ListenAddrs)
+	}
+	if len(res.P2pAddrs) != len(res.AnnouncedAddrs) || len(res.P2pAddrs) == 0 {
+		t.Fatalf("expect p2p addrs of the announced addrs, got %+v", res)
+	}
+	info, err := peer.AddrInfoFromString(res.P2pAddrs[0])
+	if err != nil || info.ID != h.ID() {
+		t.Errorf("expect dialable p2p addr of %s, got %s", h.ID(), res.P2pAddrs[0])
+	}
+
+	if _, err := GetNodeIdentity(&p2p.Node{}, ethaddr); err == nil {
+		t.Errorf("expect error without host")
+	}
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"strings"
+
+	ethkeystore "github.com/ethereum/go-ethereum/accounts/keystore"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	guuid "github.com/google/uuid"
+	"github.com/rumsystem/quorum/internal/pkg/options"
+	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
+)
+
+type ImportKeyParam struct {
+	KeyAlias    string `json:"keyalias" validate:"required" example:"my_eth_key"`
+	PrivateKey  string `json:"private_key" validate:"required_without=KeyJson" example:"0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"` // hex encoded raw private key
+	KeyJson     string `json:"key_json" validate:"required_without=PrivateKey"`                                                                              // eth keystore v3 json
+	KeyPassword string `json:"key_password"`                                                                                                                 // password of the keystore json
+}
+
+type ImportKeyResult struct {
+	KeyAlias string `json:"keyalias" example:"my_eth_key"`
+	KeyName  string `json:"keyname" example:"a6aac332-7c8d-4632-bf3c-725368bb89d5"`
+	Address  string `json:"address" example:"0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"`
+	PeerId   string `json:"peer_id" example:"16Uiu2HAmTPd2W8BXXWT4t3ABU5Pdh7MNpJoP8XD5R7g9K6jTk4UG"`
+	PubKey   string `json:"pubkey" example:"AoHjXu_3bg0Y5VjYydhSMA5AmSdvLnPx4hPcJ4zQH-Ow"`
+}
+
+// ParseEthPrivateKey returns the secp256k1 key of a hex encoded raw private key, or of an eth keystore json
+func ParseEthPrivateKey(privkey, keyjson, password string) (*ecdsa.PrivateKey, error) {
+	if privkey != "" && keyjson != "" {
+		return nil, errors.New("only one of private key and key json is allowed")
+	}
+	if keyjson != "" {
+		key, err := ethkeystore.DecryptKey([]byte(keyjson), password)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt key json failed: %s", err)
+		}
+		return key.PrivateKey, nil
+	}
+
+	privkey = strings.TrimPrefix(strings.TrimSpace(privkey), "0x")
+	key, err := ethcrypto.HexToECDSA(privkey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %s", err)
+	}
+	return key, nil
+}
+
+// ImportKey imports an eth private key as a sign key under a new alias, the key is encrypted with the keystore password
+func ImportKey(ks localcrypto.Keystore, nodeoptions *options.NodeOptions, params *ImportKeyParam) (*ImportKeyResult, error) {
+	dirks, err := getDirKeyStore(ks)
+	if err != nil {
+		return nil, err
+	}
+	if dirks.AliasToKeyname(params.KeyAlias) != "" {
+		return nil, fmt.Errorf("The key alias %s exists", params.KeyAlias)
+	}
+
+	privkey, err := ParseEthPrivateKey(params.PrivateKey, params.KeyJson, params.KeyPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	keyname := guuid.New().String()
+	addr, err := dirks.ImportSignKey(keyname, privkey)
+	if err != nil {
+		return nil, err
+	}
+	if err := nodeoptions.SetSignKeyMap(keyname, addr); err != nil {
+		return nil, fmt.Errorf("save key map %s err: %s", addr, err)
+	}
+	if err := dirks.NewAlias(params.KeyAlias, keyname, ""); err != nil {
+		return nil, err
+	}
+
+	peerid, address, err := dirks.GetPeerInfo(keyname)
+	if err != nil {
+		return nil, err
+	}
+	pubkey, err := dirks.GetEncodedPubkey(keyname, localcrypto.Sign)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ImportKeyResult{
+		KeyAlias: params.KeyAlias,
+		KeyName:  keyname,
+		Address:  address,
+		PeerId:   peerid.String(),
+		PubKey:   pubkey,
+	}, nil
+}
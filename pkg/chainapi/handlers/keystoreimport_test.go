@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"testing"
+
+	ethkeystore "github.com/ethereum/go-ethereum/accounts/keystore"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	guuid "github.com/google/uuid"
+	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
+)
+
+func TestImportKey(t *testing.T) {
+	nodeoptions := getTestNodeOptions(t)
+	ks, _, err := localcrypto.InitDirKeyStore("importkeytest", t.TempDir())
+	if err != nil {
+		t.Fatalf("init keystore failed: %s", err)
+	}
+	ks.Unlock(nodeoptions.SignKeyMap, "my.Passw0rd")
+
+	privkey, err := ethcrypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := ethcrypto.PubkeyToAddress(privkey.PublicKey).Hex()
+
+	res, err := ImportKey(ks, nodeoptions, &ImportKeyParam{KeyAlias: "raw", PrivateKey: "0x" + hex.EncodeToString(ethcrypto.FromECDSA(privkey))})
+	if err != nil {
+		t.Fatalf("import raw key failed: %s", err)
+	}
+	if res.Address != address {
+		t.Errorf("expect address %s, got %s", address, res.Address)
+	}
+	if ks.AliasToKeyname("raw") != res.KeyName || nodeoptions.SignKeyMap[res.KeyName] != address {
+		t.Errorf("expect alias and sign key map of %s", res.KeyName)
+	}
+
+	if _, err := ImportKey(ks, nodeoptions, &ImportKeyParam{KeyAlias: "raw", PrivateKey: hex.EncodeToString(ethcrypto.FromECDSA(privkey))}); err == nil {
+		t.Errorf("expect error for existing alias")
+	}
+	if _, err := ImportKey(ks, nodeoptions, &ImportKeyParam{KeyAlias: "bad", PrivateKey: "0x1234"}); err == nil {
+		t.Errorf("expect error for invalid key")
+	}
+
+	keyjson, err := ethkeystore.EncryptKey(&ethkeystore.Key{Id: guuid.New(), Address: ethcrypto.PubkeyToAddress(privkey.PublicKey), PrivateKey: privkey}, "json.Passw0rd", ethkeystore.LightScryptN, ethkeystore.LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ImportKey(ks, nodeoptions, &ImportKeyParam{KeyAlias: "json", KeyJson: string(keyjson), KeyPassword: "wrong"}); err == nil {
+		t.Errorf("expect error for wrong key json password")
+	}
+	res, err = ImportKey(ks, nodeoptions, &ImportKeyParam{KeyAlias: "json", KeyJson: string(keyjson), KeyPassword: "json.Passw0rd"})
+	if err != nil {
+		t.Fatalf("import key json failed: %s", err)
+	}
+	if res.Address != address {
+		t.Errorf("expect address %s, got %s", address, res.Address)
+	}
+}
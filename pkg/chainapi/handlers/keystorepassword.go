@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
+)
+
+type ChangeKeystorePasswordParam struct {
+	OldPassword string `json:"old_password" validate:"required" example:"old.Passw0rd"`
+	NewPassword string `json:"new_password" validate:"required,nefield=OldPassword" example:"new.Passw0rd"`
+}
+
+type ChangeKeystorePasswordResult struct {
+	Ok bool `json:"ok" example:"true"`
+}
+
+// ChangeKeystorePassword re-encrypts the keystore under the new password, the node must be started with it afterwards
+func ChangeKeystorePassword(ks localcrypto.Keystore, params *ChangeKeystorePasswordParam) (*ChangeKeystorePasswordResult, error) {
+	dirks, err := getDirKeyStore(ks)
+	if err != nil {
+		return nil, err
+	}
+	if err := dirks.ChangePassword(params.OldPassword, params.NewPassword); err != nil {
+		return nil, err
+	}
+	return &ChangeKeystorePasswordResult{Ok: true}, nil
+}
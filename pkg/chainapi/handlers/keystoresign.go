@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
+)
+
+// the key used when neither key alias nor key name is given
+const defaultSignKeyName = "default"
+
+type KeystoreSignParam struct {
+	KeyAlias string `json:"keyalias" example:"default"`
+	KeyName  string `json:"keyname" example:"default"`
+	Data     []byte `json:"data" validate:"required" swaggertype:"string" example:"aGVsbG8gcXVvcnVt"` // base64 encoded message
+}
+
+type KeystoreSignResult struct {
+	Signature string `json:"signature" example:"0x5b2e...1b"` // hex encoded r || s || v, v is 27 or 28 as eth personal_sign
+	Address   string `json:"address" example:"0xC90B320afad63d82Fa2c888C47B54ADd5CDD2452"`
+	PubKey    string `json:"pubkey" example:"AoHjXu_3bg0Y5VjYydhSMA5AmSdvLnPx4hPcJ4zQH-Ow"`
+}
+
+type KeystoreVerifyParam struct {
+	Data      []byte `json:"data" validate:"required" swaggertype:"string" example:"aGVsbG8gcXVvcnVt"` // base64 encoded message
+	Signature string `json:"signature" validate:"required" example:"0x5b2e...1b"`
+	Address   string `json:"address" validate:"omitempty,eth_addr" example:"0xC90B320afad63d82Fa2c888C47B54ADd5CDD2452"`
+	PubKey    string `json:"pubkey" example:"AoHjXu_3bg0Y5VjYydhSMA5AmSdvLnPx4hPcJ4zQH-Ow"`
+	KeyAlias  string `json:"keyalias" example:"default"`
+	KeyName   string `json:"keyname" example:"default"`
+}
+
+type KeystoreVerifyResult struct {
+	Valid   bool   `json:"valid" example:"true"`
+	Address string `json:"address" example:"0xC90B320afad63d82Fa2c888C47B54ADd5CDD2452"` // signer recovered from the signature
+}
+
+func getDirKeyStore(ks localcrypto.Keystore) (*localcrypto.DirKeyStore, error) {
+	dirks, ok := ks.(*localcrypto.DirKeyStore)
+	if !ok {
+		return nil, errors.New("Open keystore failed")
+	}
+	return dirks, nil
+}
+
+func resolveSignKeyName(dirks *localcrypto.DirKeyStore, keyalias, keyname string) (string, error) {
+	if keyalias != "" {
+		keyname = dirks.AliasToKeyname(keyalias)
+		if keyname == "" {
+			return "", fmt.Errorf("The key alias %s is not exist", keyalias)
+		}
+	}
+	if keyname == "" {
+		keyname = defaultSignKeyName
+	}
+	return keyname, nil
+}
+
+// KeystoreSign signs data as an eth personal message, so that the signature can be checked by ecrecover
+func KeystoreSign(ks localcrypto.Keystore, params *KeystoreSignParam) (*KeystoreSignResult, error) {
+	dirks, err := getDirKeyStore(ks)
+	if err != nil {
+		return nil, err
+	}
+	keyname, err := resolveSignKeyName(dirks, params.KeyAlias, params.KeyName)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := dirks.EthSignByKeyName(keyname, accounts.TextHash(params.Data))
+	if err != nil {
+		return nil, err
+	}
+	sig[ethcrypto.RecoveryIDOffset] += 27
+
+	_, address, err := dirks.GetPeerInfo(keyname)
+	if err != nil {
+		return nil, err
+	}
+	pubkey, err := dirks.GetEncodedPubkey(keyname, localcrypto.Sign)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeystoreSignResult{Signature: hexutil.Encode(sig), Address: address, PubKey: pubkey}, nil
+}
+
+// pubkeyToEthaddr tries the compressed key returned by KeystoreSign before the libp2p encoding,
+// a raw url base64 key without '-' and '_' is also valid std base64 and would be taken as libp2p key
+func pubkeyToEthaddr(pubkey string) (string, error) {
+	if b, err := base64.RawURLEncoding.DecodeString(pubkey); err == nil {
+		if ethpubkey, err := ethcrypto.DecompressPubkey(b); err == nil {
+			return ethcrypto.PubkeyToAddress(*ethpubkey).Hex(), nil
+		}
+	}
+	return localcrypto.Libp2pPubkeyToEthaddr(pubkey)
+}
+
+// KeystoreVerify recovers the signer of a signature made by KeystoreSign and compares it with
+// the address, the pubkey or the local key given
+func KeystoreVerify(ks localcrypto.Keystore, params *KeystoreVerifyParam) (*KeystoreVerifyResult, error) {
+	var expected string
+	switch {
+	case params.Address != "":
+		expected = params.Address
+	case params.PubKey != "":
+		addr, err := pubkeyToEthaddr(params.PubKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pubkey: %s", err)
+		}
+		expected = addr
+	case params.KeyAlias != "" || params.KeyName != "":
+		dirks, err := getDirKeyStore(ks)
+		if err != nil {
+			return nil, err
+		}
+		keyname, err := resolveSignKeyName(dirks, params.KeyAlias, params.KeyName)
+		if err != nil {
+			return nil, err
+		}
+		if _, expected, err = dirks.GetPeerInfo(keyname); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("one of address, pubkey, keyalias and keyname is required")
+	}
+
+	sig, err := hexutil.Decode(params.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %s", err)
+	}
+	if len(sig) != ethcrypto.SignatureLength {
+		return nil, fmt.Errorf("invalid signature length %d", len(sig))
+	}
+	if sig[ethcrypto.RecoveryIDOffset] >= 27 {
+		sig[ethcrypto.RecoveryIDOffset] -= 27
+	}
+
+	pubkey, err := ethcrypto.SigToPub(accounts.TextHash(params.Data), sig)
+	if err != nil {
+		return &KeystoreVerifyResult{Valid: false}, nil
+	}
+	address := ethcrypto.PubkeyToAddress(*pubkey)
+
+	return &KeystoreVerifyResult{
+		Valid:   strings.EqualFold(address.Hex(), common.HexToAddress(expected).Hex()),
+		Address: address.Hex(),
+	}, nil
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"testing"
+
+	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
+)
+
+func TestKeystoreSignVerify(t *testing.T) {
+	ks, _, err := localcrypto.InitDirKeyStore("signtest", t.TempDir())
+	if err != nil {
+		t.Fatalf("init keystore failed: %s", err)
+	}
+	for _, name := range []string{"default", "other"} {
+		if _, err := ks.NewKey(name, localcrypto.Sign, "my.Passw0rd"); err != nil {
+			t.Fatalf("new key %s failed: %s", name, err)
+		}
+	}
+
+	data := []byte("hello quorum")
+	signed, err := KeystoreSign(ks, &KeystoreSignParam{Data: data})
+	if err != nil {
+		t.Fatalf("KeystoreSign failed: %s", err)
+	}
+	other, err := KeystoreSign(ks, &KeystoreSignParam{KeyName: "other", Data: data})
+	if err != nil {
+		t.Fatalf("KeystoreSign with other key failed: %s", err)
+	}
+	if other.Address == signed.Address {
+		t.Fatalf("expect different signer for key other")
+	}
+
+	cases := []struct {
+		name   string
+		params *KeystoreVerifyParam
+		valid  bool
+	}{
+		{"address", &KeystoreVerifyParam{Data: data, Signature: signed.Signature, Address: signed.Address}, true},
+		{"pubkey", &KeystoreVerifyParam{Data: data, Signature: signed.Signature, PubKey: signed.PubKey}, true},
+		{"keyname", &KeystoreVerifyParam{Data: data, Signature: signed.Signature, KeyName: "default"}, true},
+		{"wrong key", &KeystoreVerifyParam{Data: data, Signature: signed.Signature, KeyName: "other"}, false},
+		{"tampered data", &KeystoreVerifyParam{Data: []byte("hello"), Signature: signed.Signature, Address: signed.Address}, false},
+	}
+	for _, c := range cases {
+		res, err := KeystoreVerify(ks, c.params)
+		if err != nil {
+			t.Errorf("%s: KeystoreVerify failed: %s", c.name, err)
+			continue
+		}
+		if res.Valid != c.valid {
+			t.Errorf("%s: expect valid %v, got %v", c.name, c.valid, res.Valid)
+		}
+	}
+
+	if _, err := KeystoreVerify(ks, &KeystoreVerifyParam{Data: data, Signature: signed.Signature}); err == nil {
+		t.Errorf("expect error without address, pubkey or key")
+	}
+}
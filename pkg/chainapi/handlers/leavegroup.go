@@ -29,6 +29,7 @@ func LeaveGroup(params *LeaveGroupParam, appdb *appdata.AppDb) (*LeaveGroupResul
 	}
 
 	group.StopSync()
+	groupmgr.ReleaseSync(params.GroupId)
 	if err := group.LeaveGrp(); err != nil {
 		return nil, err
 	}
@@ -58,5 +59,29 @@ func LeaveGroup(params *LeaveGroupParam, appdb *appdata.AppDb) (*LeaveGroupResul
 		return nil, fmt.Errorf("save group seed failed: %s", err)
 	}
 
+	if err := appdb.DelGroupWebhooks(params.GroupId); err != nil {
+		return nil, fmt.Errorf("delete group webhooks failed: %s", err)
+	}
+
+	if err := appdb.DelSearchIndex(params.GroupId); err != nil {
+		return nil, fmt.Errorf("delete group search index failed: %s", err)
+	}
+
+	if err := appdb.DelGroupChunks(params.GroupId); err != nil {
+		return nil, fmt.Errorf("delete group chunk index failed: %s", err)
+	}
+
+	if err := appdb.DelGroupProfiles(params.GroupId); err != nil {
+		return nil, fmt.Errorf("delete group profiles failed: %s", err)
+	}
+
+	if err := appdb.DelGroupBlockedAuthors(params.GroupId); err != nil {
+		return nil, fmt.Errorf("delete group blocklist failed: %s", err)
+	}
+
+	if err := appdb.DelGroupCaughtUp(params.GroupId); err != nil {
+		return nil, fmt.Errorf("delete group caught up status failed: %s", err)
+	}
+
 	return &LeaveGroupResult{GroupId: params.GroupId}, nil
 }
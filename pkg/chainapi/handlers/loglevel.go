@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rumsystem/quorum/internal/pkg/logging"
+)
+
+type SetLogLevelParam struct {
+	Subsystem string `json:"subsystem" validate:"required" example:"chain"` // logger name, "*" for all loggers
+	Level     string `json:"level" validate:"required,oneof=debug info warn error dpanic panic fatal" example:"debug"`
+}
+
+type LogLevelResult struct {
+	Subsystem string `json:"subsystem" example:"chain"`
+	Level     string `json:"level" example:"debug"`
+}
+
+type LogSubsystemsResult struct {
+	Subsystems []string `json:"subsystems" example:"chain,appsync,pubsub"`
+}
+
+// SetLogLevel changes the level of a logger until the node restarts
+func SetLogLevel(params *SetLogLevelParam) (*LogLevelResult, error) {
+	if err := logging.SetLogLevel(params.Subsystem, params.Level); err != nil {
+		if errors.Is(err, logging.ErrNoSuchLogger) {
+			return nil, fmt.Errorf("no such log subsystem: %s", params.Subsystem)
+		}
+		return nil, err
+	}
+	return &LogLevelResult{Subsystem: params.Subsystem, Level: params.Level}, nil
+}
+
+func GetLogSubsystems() *LogSubsystemsResult {
+	return &LogSubsystemsResult{Subsystems: logging.GetSubsystems()}
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/rumsystem/quorum/internal/pkg/logging"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSetLogLevel(t *testing.T) {
+	logger := logging.Logger("loglevel-test")
+
+	if _, err := SetLogLevel(&SetLogLevelParam{Subsystem: "loglevel-test", Level: "debug"}); err != nil {
+		t.Fatalf("SetLogLevel failed: %s", err)
+	}
+	if !logger.(interface{ Desugar() *zap.Logger }).Desugar().Core().Enabled(zapcore.DebugLevel) {
+		t.Errorf("expect debug level enabled")
+	}
+	if _, err := SetLogLevel(&SetLogLevelParam{Subsystem: "not-exist", Level: "debug"}); err == nil {
+		t.Errorf("expect error for unknown subsystem")
+	}
+
+	found := false
+	for _, sub := range GetLogSubsystems().Subsystems {
+		if sub == "loglevel-test" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expect loglevel-test in subsystems")
+	}
+}
@@ -43,17 +43,6 @@ func MgrAppConfig(params *AppConfigParam) (*AppConfigResult, error) {
 	} else if group.Item.OwnerPubKey != group.Item.UserSignPubkey {
 		return nil, rumerrors.ErrOnlyGroupOwner
 	} else {
-		ks := nodectx.GetNodeCtx().Keystore
-
-		base64key, err := ks.GetEncodedPubkey(params.GroupId, localcrypto.Sign)
-		groupSignPubkey, err := base64.RawURLEncoding.DecodeString(base64key)
-		//pubkeybytes, err := hex.DecodeString(hexkey)
-		//p2ppubkey, err := p2pcrypto.UnmarshalSecp256k1PublicKey(pubkeybytes)
-		//groupSignPubkey, err := p2pcrypto.MarshalPublicKey(p2ppubkey)
-		if err != nil {
-			return nil, errors.New("group key can't be decoded, err:" + err.Error())
-		}
-
 		item := &quorumpb.AppConfigItem{}
 		item.GroupId = params.GroupId
 		if params.Action == "add" {
@@ -81,26 +70,10 @@ func MgrAppConfig(params *AppConfigParam) (*AppConfigResult, error) {
 		item.Value = params.Value
 		item.Memo = params.Memo
 
-		var buffer bytes.Buffer
-		buffer.Write([]byte(item.GroupId))
-		buffer.Write([]byte(item.Action.String()))
-		buffer.Write([]byte(item.Name))
-		buffer.Write([]byte(item.Type.String()))
-		buffer.Write([]byte(item.Value))
-		buffer.Write([]byte(item.Memo))
-		buffer.Write(groupSignPubkey)
-		hash := localcrypto.Hash(buffer.Bytes())
-
-		signature, err := ks.EthSignByKeyName(item.GroupId, hash)
-
-		if err != nil {
+		if err := signAppConfigItem(group, item); err != nil {
 			return nil, err
 		}
 
-		item.OwnerPubkey = group.Item.OwnerPubKey
-		item.OwnerSign = hex.EncodeToString(signature)
-		item.TimeStamp = time.Now().UnixNano()
-
 		trxId, err := group.UpdAppConfig(item)
 		if err != nil {
 			return nil, err
@@ -108,10 +81,44 @@ func MgrAppConfig(params *AppConfigParam) (*AppConfigResult, error) {
 
 		result := &AppConfigResult{
 			GroupId: item.GroupId,
-			Sign:    hex.EncodeToString(signature),
+			Sign:    item.OwnerSign,
 			TrxId:   trxId,
 		}
 
 		return result, nil
 	}
 }
+
+// signAppConfigItem signs the item by the owner key of the group
+func signAppConfigItem(group *chain.Group, item *quorumpb.AppConfigItem) error {
+	ks := nodectx.GetNodeCtx().Keystore
+
+	base64key, err := ks.GetEncodedPubkey(item.GroupId, localcrypto.Sign)
+	groupSignPubkey, err := base64.RawURLEncoding.DecodeString(base64key)
+	//pubkeybytes, err := hex.DecodeString(hexkey)
+	//p2ppubkey, err := p2pcrypto.UnmarshalSecp256k1PublicKey(pubkeybytes)
+	//groupSignPubkey, err := p2pcrypto.MarshalPublicKey(p2ppubkey)
+	if err != nil {
+		return errors.New("group key can't be decoded, err:" + err.Error())
+	}
+
+	var buffer bytes.Buffer
+	buffer.Write([]byte(item.GroupId))
+	buffer.Write([]byte(item.Action.String()))
+	buffer.Write([]byte(item.Name))
+	buffer.Write([]byte(item.Type.String()))
+	buffer.Write([]byte(item.Value))
+	buffer.Write([]byte(item.Memo))
+	buffer.Write(groupSignPubkey)
+	hash := localcrypto.Hash(buffer.Bytes())
+
+	signature, err := ks.EthSignByKeyName(item.GroupId, hash)
+	if err != nil {
+		return err
+	}
+
+	item.OwnerPubkey = group.Item.OwnerPubKey
+	item.OwnerSign = hex.EncodeToString(signature)
+	item.TimeStamp = time.Now().UnixNano()
+	return nil
+}
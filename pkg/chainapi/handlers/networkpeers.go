@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/rumsystem/quorum/internal/pkg/conn/p2p"
+)
+
+type PeerConnInfo struct {
+	Addr           string    `json:"addr" example:"/ip4/94.23.17.189/tcp/62777"`
+	Direction      string    `json:"direction" example:"Outbound"`
+	ConnectedSince time.Time `json:"connected_since" example:"2023-01-28T08:10:36.675204+00:00"`
+	Transient      bool      `json:"transient"` // relayed connection
+}
+
+type ConnectedPeer struct {
+	PeerId    string         `json:"peer_id" example:"16Uiu2HAm5waftP3s4oE1EzGF2SyWeK726P5B8BSgFJqSiz6xScGz"`
+	Conns     []PeerConnInfo `json:"conns"`
+	Protocols []string       `json:"protocols"`
+	Latency   float64        `json:"latency" example:"12.5"` // smoothed round trip time in milliseconds, 0 if unknown
+}
+
+type ListPeersResult struct {
+	Peers []ConnectedPeer `json:"peers"`
+}
+
+// ListPeers returns the connected peers of the node, the latency is the ewma of the ping service and identify round trips
+func ListPeers(node *p2p.Node) (*ListPeersResult, error) {
+	if node == nil || node.Host == nil {
+		return nil, errors.New("node is not available")
+	}
+
+	h := node.Host
+	result := &ListPeersResult{Peers: []ConnectedPeer{}}
+	for _, pid := range h.Network().Peers() {
+		item := ConnectedPeer{PeerId: pid.String(), Conns: []PeerConnInfo{}, Protocols: []string{}}
+		for _, conn := range h.Network().ConnsToPeer(pid) {
+			stat := conn.Stat()
+			item.Conns = append(item.Conns, PeerConnInfo{
+				Addr:           conn.RemoteMultiaddr().String(),
+				Direction:      stat.Direction.String(),
+				ConnectedSince: stat.Opened,
+				Transient:      stat.Transient,
+			})
+		}
+		if protos, err := h.Peerstore().GetProtocols(pid); err == nil {
+			for _, proto := range protos {
+				item.Protocols = append(item.Protocols, string(proto))
+			}
+			sort.Strings(item.Protocols)
+		}
+		item.Latency = float64(h.Peerstore().LatencyEWMA(pid)) / float64(time.Millisecond)
+		result.Peers = append(result.Peers, item)
+	}
+	sort.Slice(result.Peers, func(i, j int) bool { return result.Peers[i].PeerId < result.Peers[j].PeerId })
+
+	return result, nil
+}
+
+// DisconnectPeer closes all connections to the peer, the peer may reconnect unless it is blocked
+func DisconnectPeer(node *p2p.Node, peerId string) error {
+	if node == nil || node.Host == nil {
+		return errors.New("node is not available")
+	}
+	pid, err := peer.Decode(peerId)
+	if err != nil {
+		return fmt.Errorf("invalid peer id %s: %s", peerId, err)
+	}
+	if len(node.Host.Network().ConnsToPeer(pid)) == 0 {
+		return fmt.Errorf("peer %s is not connected", peerId)
+	}
+	return node.Host.Network().ClosePeer(pid)
+}
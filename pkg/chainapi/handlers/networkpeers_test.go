@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/rumsystem/quorum/internal/pkg/conn/p2p"
+)
+
+func TestListAndDisconnectPeers(t *testing.T) {
+	h1, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h1.Close()
+	h2, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h2.Close()
+
+	if err := h1.Connect(context.Background(), peer.AddrInfo{ID: h2.ID(), Addrs: h2.Addrs()}); err != nil {
+		t.Fatalf("connect failed: %s", err)
+	}
+
+	node := &p2p.Node{Host: h1}
+	res, err := ListPeers(node)
+	if err != nil {
+		t.Fatalf("list peers failed: %s", err)
+	}
+	if len(res.Peers) != 1 || res.Peers[0].PeerId != h2.ID().String() {
+		t.Fatalf("expect peer %s, got %+v", h2.ID(), res.Peers)
+	}
+	if len(res.Peers[0].Conns) == 0 || res.Peers[0].Conns[0].Direction != "Outbound" {
+		t.Errorf("expect an outbound conn, got %+v", res.Peers[0].Conns)
+	}
+
+	if err := DisconnectPeer(node, h2.ID().String()); err != nil {
+		t.Fatalf("disconnect peer failed: %s", err)
+	}
+	if len(h1.Network().ConnsToPeer(h2.ID())) != 0 {
+		t.Errorf("expect peer %s disconnected", h2.ID())
+	}
+	if err := DisconnectPeer(node, h2.ID().String()); err == nil {
+		t.Errorf("expect error for a peer not connected")
+	}
+	if err := DisconnectPeer(node, "invalid"); err == nil {
+		t.Errorf("expect error for an invalid peer id")
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/rumsystem/quorum/internal/pkg/appdata"
+	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
+	"github.com/rumsystem/quorum/internal/pkg/conn/p2p"
+	"github.com/rumsystem/quorum/internal/pkg/storage"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+)
+
+type NodeStats struct {
+	Goroutines    int                    `json:"goroutines" example:"320"`
+	Mem           NodeInfoMem            `json:"mem"`
+	OpenFiles     int                    `json:"open_files" example:"128"`     // open file descriptors, -1 if unknown on the platform
+	DbSize        int64                  `json:"db_size" example:"1073741824"` // bytes on disk of all dbs
+	DbSizes       map[string]int64       `json:"db_sizes"`                     // by db name: groups, db, appdb
+	Peers         int                    `json:"peers" example:"12"`           // connected peers
+	Groups        int                    `json:"groups" example:"3"`
+	SyncingGroups int                    `json:"syncing_groups" example:"1"` // groups catching up
+	QueuedGroups  int                    `json:"queued_groups" example:"0"`  // groups waiting to start sync
+	GroupStats    map[string]*GroupStats `json:"group_stats"`                // by group id
+	Time          int64                  `json:"time" example:"1663900000000000000"`
+}
+
+type GroupStats struct {
+	BlockId   uint64  `json:"block_id" example:"800"`
+	ApplyRate float64 `json:"apply_rate" example:"12.5"` // blocks applied per second recently
+	Percent   float64 `json:"percent" example:"80"`      // applied blocks of the highest block seen from peers in percent
+}
+
+// GetNodeStats returns the runtime, db, network and group counters of the node, nothing is scanned,
+// so that it is cheap to poll. node and appdb are nil if not available.
+func GetNodeStats(node *p2p.Node, dbMgr *storage.DbMgr, appdb *appdata.AppDb) (*NodeStats, error) {
+	res := &NodeStats{
+		Goroutines: runtime.NumGoroutine(),
+		Mem:        readMemStats(),
+		OpenFiles:  -1,
+		DbSizes:    map[string]int64{},
+		GroupStats: map[string]*GroupStats{},
+		Time:       time.Now().UnixNano(),
+	}
+	if n, err := utils.OpenFileCount(); err == nil {
+		res.OpenFiles = n
+	}
+
+	if dbMgr != nil {
+		dbs := map[string]storage.QuorumStorage{
+			"groups": dbMgr.GroupInfoDb,
+			"db":     dbMgr.Db,
+		}
+		if appdb != nil {
+			dbs["appdb"] = appdb.Db
+		}
+		for name, db := range dbs {
+			stats, err := storage.GetStoreStats(db)
+			if err != nil {
+				return nil, err
+			}
+			res.DbSizes[name] = stats.Size
+			res.DbSize += stats.Size
+		}
+	}
+
+	if node != nil && node.Host != nil {
+		res.Peers = len(node.Host.Network().Peers())
+	}
+
+	if groupmgr := chain.GetGroupMgr(); groupmgr != nil {
+		res.Groups = len(groupmgr.Groups)
+		res.SyncingGroups = groupmgr.GetSyncingCount()
+		res.QueuedGroups = len(groupmgr.GetSyncQueue())
+		for groupId, grp := range groupmgr.Groups {
+			progress := grp.GetSyncProgress()
+			res.GroupStats[groupId] = &GroupStats{
+				BlockId:   progress.Applied,
+				ApplyRate: progress.Rate,
+				Percent:   progress.Percent,
+			}
+		}
+	}
+
+	return res, nil
+}
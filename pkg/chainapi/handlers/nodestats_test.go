@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/rumsystem/quorum/internal/pkg/conn/p2p"
+	"github.com/rumsystem/quorum/internal/pkg/storage"
+)
+
+func TestGetNodeStats(t *testing.T) {
+	h, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	dbMgr := &storage.DbMgr{GroupInfoDb: storage.NewMemStore(), Db: storage.NewMemStore()}
+	if err := dbMgr.Db.Set([]byte("key"), []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := GetNodeStats(&p2p.Node{Host: h}, dbMgr, nil)
+	if err != nil {
+		t.Fatalf("get node stats failed: %s", err)
+	}
+	if res.Goroutines <= 0 || res.Mem.HeapInuse == 0 {
+		t.Errorf("expect runtime stats, got %+v", res)
+	}
+	if res.DbSizes["db"] != int64(len("key")+len("value")) || res.DbSize != res.DbSizes["db"]+res.DbSizes["groups"] {
+		t.Errorf("expect db sizes of the mem stores, got %v", res.DbSizes)
+	}
+	if _, ok := res.DbSizes["appdb"]; ok {
+		t.Errorf("expect no appdb size without appdb")
+	}
+	if res.Peers != 0 {
+		t.Errorf("expect no connected peers, got %d", res.Peers)
+	}
+
+	if _, err := GetNodeStats(nil, nil, nil); err != nil {
+		t.Errorf("expect stats without node and dbs, got %s", err)
+	}
+}
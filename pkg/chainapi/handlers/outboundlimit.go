@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/rumsystem/quorum/internal/pkg/conn/p2p"
+)
+
+type SetOutboundLimitParam struct {
+	BytesPerSec *int `json:"bytes_per_sec" validate:"required,gte=0" example:"1048576"` // 0 means no limit
+}
+
+type OutboundLimitResult struct {
+	BytesPerSec int `json:"bytes_per_sec" example:"1048576"` // 0 means no limit
+}
+
+func GetOutboundLimit(node *p2p.Node) (*OutboundLimitResult, error) {
+	if node == nil || node.OutboundThrottle == nil {
+		return nil, errors.New("outbound throttle is not available")
+	}
+	return &OutboundLimitResult{BytesPerSec: node.OutboundThrottle.Limit()}, nil
+}
+
+// SetOutboundLimit changes the limit of the bytes written to the rumexchange streams, the change is lost when the node restarts
+func SetOutboundLimit(node *p2p.Node, params *SetOutboundLimitParam) (*OutboundLimitResult, error) {
+	validate := validator.New()
+	if err := validate.Struct(params); err != nil {
+		return nil, err
+	}
+	if node == nil || node.OutboundThrottle == nil {
+		return nil, errors.New("outbound throttle is not available")
+	}
+	node.OutboundThrottle.SetLimit(*params.BytesPerSec)
+	return GetOutboundLimit(node)
+}
@@ -0,0 +1,80 @@
+//go:build !js
+// +build !js
+
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/rumsystem/quorum/internal/pkg/conn/p2p"
+)
+
+const (
+	PeerGaterBlock    = "block"
+	PeerGaterUnblock  = "unblock"
+	PeerGaterAllow    = "allow"
+	PeerGaterDisallow = "disallow"
+)
+
+type PeerGaterParam struct {
+	PeerIds []string `json:"peer_ids" validate:"required,min=1,dive,required" example:"16Uiu2HAm5waftP3s4oE1EzGF2SyWeK726P5B8BSgFJqSiz6xScGz"`
+}
+
+type PeerGaterResult struct {
+	AllowList []string `json:"allow_list"` // empty allows all peers not blocked
+	BlockList []string `json:"block_list"`
+}
+
+// UpdatePeerGater applies the action to the peers and returns the peer lists, the lists are saved to the node options
+func UpdatePeerGater(node *p2p.Node, action string, params *PeerGaterParam) (*PeerGaterResult, error) {
+	validate := validator.New()
+	if err := validate.Struct(params); err != nil {
+		return nil, err
+	}
+	if node == nil || node.PeerGater == nil {
+		return nil, errors.New("peer gater is not available")
+	}
+
+	var apply func(peer.ID) error
+	switch action {
+	case PeerGaterBlock:
+		apply = node.BlockPeer
+	case PeerGaterUnblock:
+		apply = node.UnblockPeer
+	case PeerGaterAllow:
+		apply = node.AllowPeer
+	case PeerGaterDisallow:
+		apply = node.DisallowPeer
+	default:
+		return nil, fmt.Errorf("unknown peer gater action %s", action)
+	}
+
+	pids := make([]peer.ID, 0, len(params.PeerIds))
+	for _, s := range params.PeerIds {
+		pid, err := peer.Decode(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid peer id %s: %s", s, err)
+		}
+		if pid == node.Host.ID() {
+			return nil, errors.New("can not gate the node itself")
+		}
+		pids = append(pids, pid)
+	}
+	for _, pid := range pids {
+		if err := apply(pid); err != nil {
+			return nil, err
+		}
+	}
+
+	return GetPeerGater(node)
+}
+
+func GetPeerGater(node *p2p.Node) (*PeerGaterResult, error) {
+	if node == nil || node.PeerGater == nil {
+		return nil, errors.New("peer gater is not available")
+	}
+	return &PeerGaterResult{AllowList: node.PeerGater.AllowList(), BlockList: node.PeerGater.BlockList()}, nil
+}
@@ -0,0 +1,15 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/rumsystem/quorum/internal/pkg/conn/p2p"
+)
+
+// ExportPeerstore returns the peers known by the node, the file can be imported by a new node with --peerstore-import
+func ExportPeerstore(node *p2p.Node) (*p2p.PeerstoreFile, error) {
+	if node == nil || node.Host == nil {
+		return nil, errors.New("node is not available")
+	}
+	return p2p.ExportPeerstore(node.Host.Peerstore(), node.Host.ID()), nil
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/libp2p/go-libp2p/core/peer"
+	maddr "github.com/multiformats/go-multiaddr"
+	"github.com/rumsystem/quorum/internal/pkg/conn/p2p"
+)
+
+type PingParam struct {
+	Peer     string `json:"peer" validate:"required" example:"/ip4/94.23.17.189/tcp/62777/p2p/16Uiu2HAm5waftP3s4oE1EzGF2SyWeK726P5B8BSgFJqSiz6xScGz"`
+	Count    int    `json:"count" validate:"gte=0,lte=100" example:"4"`         // default 4
+	Interval int    `json:"interval" validate:"gte=0,lte=60000" example:"1000"` // milliseconds between rounds, default 1000
+	Timeout  int    `json:"timeout" validate:"gte=0,lte=60000" example:"5000"`  // milliseconds to wait for each reply, default 5000
+}
+
+type PingResult struct {
+	Peer   string    `json:"peer" example:"/ip4/94.23.17.189/tcp/62777/p2p/16Uiu2HAm5waftP3s4oE1EzGF2SyWeK726P5B8BSgFJqSiz6xScGz"`
+	PeerId string    `json:"peer_id" example:"16Uiu2HAm5waftP3s4oE1EzGF2SyWeK726P5B8BSgFJqSiz6xScGz"`
+	RTTs   []float64 `json:"rtts"` // round trip time of each replied round in milliseconds
+	Sent   int       `json:"sent" example:"4"`
+	Lost   int       `json:"lost" example:"0"`
+	Min    float64   `json:"min" example:"1.2"`
+	Avg    float64   `json:"avg" example:"1.5"`
+	Max    float64   `json:"max" example:"2.1"`
+	Stddev float64   `json:"stddev" example:"0.3"`
+	Error  string    `json:"error,omitempty"` // error of the last failed round
+}
+
+// Ping connect to the peer and ping it `Count` rounds with the ping service of the node
+func Ping(ctx context.Context, node *p2p.Node, params *PingParam) (*PingResult, error) {
+	validate := validator.New()
+	if err := validate.Struct(params); err != nil {
+		return nil, err
+	}
+	if node == nil || node.PingService == nil {
+		return nil, errors.New("ping service is not available")
+	}
+
+	ma, err := maddr.NewMultiaddr(params.Peer)
+	if err != nil {
+		return nil, err
+	}
+	addrinfo, err := peer.AddrInfoFromP2pAddr(ma)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := node.Host.Connect(ctx, *addrinfo); err != nil {
+		return nil, err
+	}
+
+	opt := p2p.PingOptions{
+		Count:    params.Count,
+		Interval: time.Duration(params.Interval) * time.Millisecond,
+		Timeout:  time.Duration(params.Timeout) * time.Millisecond,
+	}
+	if params.Interval == 0 {
+		opt.Interval = p2p.DefaultPingInterval
+	}
+	results := node.PingService.PingPeer(ctx, addrinfo.ID, opt)
+
+	result := NewPingResult(params.Peer, results)
+	result.PeerId = addrinfo.ID.String()
+	return result, nil
+}
+
+// NewPingResult summarize the results of ping rounds, rounds with error are counted as lost
+func NewPingResult(addr string, results []p2p.Result) *PingResult {
+	result := &PingResult{Peer: addr, RTTs: []float64{}, Sent: len(results)}
+	for _, res := range results {
+		if res.Error != nil {
+			result.Lost++
+			result.Error = res.Error.Error()
+			continue
+		}
+		result.RTTs = append(result.RTTs, durationToMs(res.RTT))
+	}
+
+	result.Min, result.Avg, result.Max = getRTTStats(result.RTTs)
+	result.Stddev = getRTTStddev(result.RTTs, result.Avg)
+	return result
+}
+
+func getRTTStats(rtts []float64) (min, avg, max float64) {
+	if len(rtts) == 0 {
+		return
+	}
+
+	min, max = rtts[0], rtts[0]
+	sum := 0.0
+	for _, rtt := range rtts {
+		if rtt < min {
+			min = rtt
+		}
+		if rtt > max {
+			max = rtt
+		}
+		sum += rtt
+	}
+
+	return min, sum / float64(len(rtts)), max
+}
+
+// getRTTStddev returns the population standard deviation of the rtts
+func getRTTStddev(rtts []float64, avg float64) float64 {
+	if len(rtts) == 0 {
+		return 0
+	}
+
+	sum := 0.0
+	for _, rtt := range rtts {
+		sum += (rtt - avg) * (rtt - avg)
+	}
+	return math.Sqrt(sum / float64(len(rtts)))
+}
+
+func durationToMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rumsystem/quorum/internal/pkg/conn/p2p"
+)
+
+func TestGetRTTStats(t *testing.T) {
+	tests := []struct {
+		rtts          []float64
+		min, avg, max float64
+	}{
+		{nil, 0, 0, 0},
+		{[]float64{2}, 2, 2, 2},
+		{[]float64{3, 1, 2}, 1, 2, 3},
+	}
+
+	for _, test := range tests {
+		min, avg, max := getRTTStats(test.rtts)
+		if min != test.min || avg != test.avg || max != test.max {
+			t.Errorf("getRTTStats(%v) = %v, %v, %v; want %v, %v, %v", test.rtts, min, avg, max, test.min, test.avg, test.max)
+		}
+	}
+}
+
+func TestNewPingResult(t *testing.T) {
+	results := []p2p.Result{
+		{RTT: 2 * time.Millisecond},
+		{Error: p2p.ErrPingTimeout},
+		{RTT: 4 * time.Millisecond},
+	}
+
+	result := NewPingResult("addr", results)
+	if result.Sent != 3 || result.Lost != 1 || len(result.RTTs) != 2 {
+		t.Errorf("unexpected ping result: %+v", result)
+	}
+	if result.Min != 2 || result.Avg != 3 || result.Max != 4 {
+		t.Errorf("unexpected rtt stats: %+v", result)
+	}
+	if result.Stddev != 1 {
+		t.Errorf("expect stddev 1, got %v", result.Stddev)
+	}
+	if result.Error != p2p.ErrPingTimeout.Error() {
+		t.Errorf("expect error %q, got %q", p2p.ErrPingTimeout, result.Error)
+	}
+}
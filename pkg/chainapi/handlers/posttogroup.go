@@ -1,10 +1,15 @@
 package handlers
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
+	"github.com/go-playground/validator/v10"
 	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
 )
 
@@ -21,26 +26,141 @@ type PostToGroupParam struct {
 	}
 	*/
 	Data map[string]interface{} `json:"data" validate:"required"` // json object
+	// resend policy if the trx is not applied in time, the node default is used if not set
+	RetryPolicy *PublishRetryParam `json:"retry_policy,omitempty"`
+	// the post disappears from the content apis after ttl seconds (at most 10 years), it never expires if not set
+	TTL int64 `json:"ttl,omitempty" validate:"omitempty,min=1,max=315360000" example:"86400"`
+	// a client generated key of the post, a retry with the same key and post in the key window returns the original trx id,
+	// the key used with another post in the window is refused
+	IdempotencyKey string `json:"idempotency_key,omitempty" validate:"omitempty,max=128" example:"3f9c2d0e-post-1"`
+	// wait until the trx is included in a block and confirm_depth-1 blocks are added on top of it, no wait if not set, ignored in batch
+	ConfirmDepth uint64 `json:"confirm_depth,omitempty" validate:"omitempty,max=100" example:"1"`
+	// seconds to wait for the confirmation, 30 if not set
+	ConfirmTimeout int64 `json:"confirm_timeout,omitempty" validate:"omitempty,min=1,max=300" example:"30"`
 }
 
 type TrxResult struct {
 	TrxId string `json:"trx_id" validate:"required,uuid4" example:"9e54c173-c1dd-429d-91fa-a6b43c14da77"`
 }
 
-func PostToGroup(payload *PostToGroupParam) (*TrxResult, error) {
+type TrxConfirmation struct {
+	Confirmed bool   `json:"confirmed" example:"true"`          // false if the trx is not confirmed before the timeout
+	BlockId   uint64 `json:"block_id,omitempty" example:"1024"` // the block including the trx, empty if not included yet
+	Epoch     uint64 `json:"epoch,omitempty" example:"1024"`
+	Depth     uint64 `json:"depth,omitempty" example:"1"` // blocks from the including block to the head, the including block counted
+}
+
+type PostToGroupResult struct {
+	TrxId        string           `json:"trx_id" validate:"required,uuid4" example:"9e54c173-c1dd-429d-91fa-a6b43c14da77"`
+	Confirmation *TrxConfirmation `json:"confirmation,omitempty"` // set if confirm_depth is set
+}
+
+type BatchPostToGroupParam struct {
+	GroupId string              `param:"group_id" json:"group_id" validate:"required,uuid4" example:"ac0eea7c-2f3c-4c67-80b3-136e46b924a8"`
+	Items   []*PostToGroupParam `json:"items" validate:"required,min=1,max=100"` // group_id of items is ignored
+}
+
+type BatchPostItemResult struct {
+	TrxId string `json:"trx_id,omitempty" example:"9e54c173-c1dd-429d-91fa-a6b43c14da77"`
+	Error string `json:"error,omitempty"`
+}
+
+type BatchPostToGroupResult struct {
+	Results []*BatchPostItemResult `json:"results"` // in the order of items
+}
+
+const defaultConfirmTimeout = 30 * time.Second
+
+func PostToGroup(payload *PostToGroupParam) (*PostToGroupResult, error) {
 	groupmgr := chain.GetGroupMgr()
 	group, ok := groupmgr.Groups[payload.GroupId]
 	if !ok {
 		return nil, fmt.Errorf("Group %s not exist", payload.GroupId)
 	}
-	data, err := json.Marshal(payload.Data)
+
+	trxId, err := postToGroup(group, payload)
 	if err != nil {
-		return nil, errors.New(fmt.Sprintf("Invalid Data field, not json object, json.Marshal failed: %s", err))
+		return nil, err
+	}
+	res := &PostToGroupResult{TrxId: trxId}
+	if payload.ConfirmDepth > 0 {
+		timeout := defaultConfirmTimeout
+		if payload.ConfirmTimeout > 0 {
+			timeout = time.Duration(payload.ConfirmTimeout) * time.Second
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		res.Confirmation = waitTrxConfirmed(ctx, group, trxId, payload.ConfirmDepth)
 	}
+	return res, nil
+}
+
+// waitTrxConfirmed returns the confirmation of the trx, with confirmed false if it does not reach depth before ctx is done
+func waitTrxConfirmed(ctx context.Context, group *chain.Group, trxId string, depth uint64) *TrxConfirmation {
+	conf, err := group.ChainCtx.WaitTrxConfirmed(ctx, trxId, depth)
+	res := &TrxConfirmation{Confirmed: err == nil}
+	if conf != nil {
+		res.BlockId = conf.BlockId
+		res.Epoch = conf.Epoch
+		res.Depth = conf.Depth
+	}
+	return res
+}
 
-	trxId, err := group.PostToGroup(data)
+// BatchPostToGroup posts the items one by one, a failed item does not stop the others
+func BatchPostToGroup(payload *BatchPostToGroupParam) (*BatchPostToGroupResult, error) {
+	groupmgr := chain.GetGroupMgr()
+	group, ok := groupmgr.Groups[payload.GroupId]
+	if !ok {
+		return nil, fmt.Errorf("Group %s not exist", payload.GroupId)
+	}
+
+	validate := validator.New()
+	res := &BatchPostToGroupResult{Results: make([]*BatchPostItemResult, len(payload.Items))}
+	for i, item := range payload.Items {
+		result := &BatchPostItemResult{}
+		res.Results[i] = result
+		if item == nil {
+			result.Error = "item is empty"
+			continue
+		}
+		item.GroupId = payload.GroupId
+		if err := validate.Struct(item); err != nil {
+			result.Error = err.Error()
+			continue
+		}
+
+		trxId, err := postToGroup(group, item)
+		if err != nil {
+			result.Error = err.Error()
+			continue
+		}
+		result.TrxId = trxId
+	}
+	return res, nil
+}
+
+func postToGroup(group *chain.Group, payload *PostToGroupParam) (string, error) {
+	data, err := json.Marshal(payload.Data)
 	if err != nil {
-		return nil, err
+		return "", errors.New(fmt.Sprintf("Invalid Data field, not json object, json.Marshal failed: %s", err))
 	}
-	return &TrxResult{trxId}, nil
+
+	var expired int64
+	if payload.TTL > 0 {
+		expired = time.Now().Add(time.Duration(payload.TTL) * time.Second).UnixNano()
+	}
+	trxId, _, err := group.ChainCtx.PublishOnce(payload.IdempotencyKey, payloadHash(data, payload.TTL), func() (string, error) {
+		return group.PostToGroupWithExpiry(data, expired, payload.RetryPolicy.toPolicy())
+	})
+	return trxId, err
+}
+
+// payloadHash is the hash of the post checked against the idempotency key, the json of data has sorted keys.
+// The retry policy and the confirmation params do not change the post, they are not hashed.
+func payloadHash(data []byte, ttl int64) string {
+	h := sha256.New()
+	h.Write(data)
+	fmt.Fprintf(h, "\nttl:%d", ttl)
+	return hex.EncodeToString(h.Sum(nil))
 }
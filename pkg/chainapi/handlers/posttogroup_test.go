@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPayloadHash(t *testing.T) {
+	hash := func(body string, ttl int64) string {
+		var data map[string]interface{}
+		if err := json.Unmarshal([]byte(body), &data); err != nil {
+			t.Fatal(err)
+		}
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return payloadHash(encoded, ttl)
+	}
+
+	post := hash(`{"type":"Create","object":{"type":"Note","content":"hello"}}`, 0)
+	if got := hash(`{"object":{"content":"hello","type":"Note"},"type":"Create"}`, 0); got != post {
+		t.Errorf("the key order should not change the hash")
+	}
+	if got := hash(`{"type":"Create","object":{"type":"Note","content":"hello!"}}`, 0); got == post {
+		t.Errorf("another post should have another hash")
+	}
+	if got := hash(`{"type":"Create","object":{"type":"Note","content":"hello"}}`, 60); got == post {
+		t.Errorf("another ttl should have another hash")
+	}
+}
@@ -25,12 +25,17 @@ type GrpProducerResult struct {
 }
 
 type GrpProducerParam struct {
+	Action         string   `from:"action"          json:"action"           validate:"omitempty,oneof=add remove" example:"add"` // add if empty
 	ProducerPubkey []string `from:"producer_pubkey" json:"producer_pubkey"  validate:"required" example:"CAISIQOxCH2yVZPR8t6gVvZapxcIPBwMh9jB80pDLNeuA5s8hQ=="`
 	GroupId        string   `json:"group_id" validate:"required,uuid4" example:"5ed3f9fe-81e2-450d-9146-7a329aac2b62"`
 	Memo           string   `from:"memo"            json:"memo" example:"comment/remark"`
 }
 
+// GroupProducer add producers to or remove producers from the group, the new producer list is recorded on chain by a PRODUCER trx signed by the owner
 func GroupProducer(chainapidb def.APIHandlerIface, params *GrpProducerParam) (*GrpProducerResult, error) {
+	if params.Action == "" {
+		params.Action = "add"
+	}
 	validate := validator.New()
 
 	if err := validate.Struct(params); err != nil {
@@ -38,35 +43,58 @@ func GroupProducer(chainapidb def.APIHandlerIface, params *GrpProducerParam) (*G
 	}
 
 	groupmgr := chain.GetGroupMgr()
-	if group, ok := groupmgr.Groups[params.GroupId]; !ok {
+	group, ok := groupmgr.Groups[params.GroupId]
+	if !ok {
 		return nil, rumerrors.ErrGroupNotFound
 	} else if group.Item.OwnerPubKey != group.Item.UserSignPubkey {
 		return nil, rumerrors.ErrOnlyGroupOwner
-	} else {
-		if len(params.ProducerPubkey) == 0 {
-			return nil, errors.New("producer pubkey list empty")
-		}
+	}
 
-		//check if pubkey in producer list are unique
-		bundle := make(map[string]bool)
+	if len(params.ProducerPubkey) == 0 {
+		return nil, errors.New("producer pubkey list empty")
+	}
 
-		bftProducerBundle := &quorumpb.BFTProducerBundleItem{}
-		producers := []*quorumpb.ProducerItem{}
+	//check if pubkey in producer list are unique
+	bundle := make(map[string]bool)
+	for _, producerPubkey := range params.ProducerPubkey {
+		if ok := bundle[producerPubkey]; ok {
+			return nil, errors.New("producer pubkey should be unique")
+		}
+		if producerPubkey == group.Item.OwnerPubKey {
+			return nil, errors.New("group owner is always a producer")
+		}
+		bundle[producerPubkey] = true
+	}
 
-		for _, producerPubkey := range params.ProducerPubkey {
+	//current producers except owner
+	currProducers, err := chainapidb.GetProducers(group.GroupId, group.Nodename)
+	if err != nil {
+		return nil, err
+	}
+	currItems := map[string]*quorumpb.ProducerItem{}
+	var currPubkeys []string
+	for _, item := range currProducers {
+		if item.ProducerPubkey == group.Item.OwnerPubKey {
+			continue
+		}
+		currItems[item.ProducerPubkey] = item
+		currPubkeys = append(currPubkeys, item.ProducerPubkey)
+	}
 
-			if ok := bundle[producerPubkey]; ok {
-				return nil, errors.New(fmt.Errorf("producer pubkey should be unique").Error())
-			}
-			bundle[producerPubkey] = true
+	newPubkeys, err := updateProducerList(currPubkeys, params.Action, params.ProducerPubkey)
+	if err != nil {
+		return nil, err
+	}
 
+	if params.Action == "add" {
+		for _, producerPubkey := range params.ProducerPubkey {
 			isAnnounced, err := chainapidb.IsProducerAnnounced(group.GroupId, producerPubkey, group.Nodename)
 			if err != nil {
 				return nil, err
 			}
 
 			if !isAnnounced {
-				return nil, errors.New(fmt.Errorf("producer %s is not announced", producerPubkey).Error())
+				return nil, fmt.Errorf("producer %s is not announced", producerPubkey)
 			}
 
 			producer, err := group.GetAnnouncedProducer(producerPubkey)
@@ -75,49 +103,94 @@ func GroupProducer(chainapidb def.APIHandlerIface, params *GrpProducerParam) (*G
 			}
 
 			if producer.Action == quorumpb.ActionType_REMOVE {
-				return nil, errors.New(fmt.Errorf("can not add a non-active producer %s", producerPubkey).Error())
-			}
-
-			item := &quorumpb.ProducerItem{}
-			item.GroupId = params.GroupId
-			item.ProducerPubkey = producerPubkey
-			item.GroupOwnerPubkey = group.Item.OwnerPubKey
-
-			var buffer bytes.Buffer
-			buffer.Write([]byte(item.GroupId))
-			buffer.Write([]byte(item.ProducerPubkey))
-			buffer.Write([]byte(item.GroupOwnerPubkey))
-			hash := localcrypto.Hash(buffer.Bytes())
-
-			ks := nodectx.GetNodeCtx().Keystore
-			signature, err := ks.EthSignByKeyName(item.GroupId, hash)
-
-			if err != nil {
-				return nil, err
+				return nil, fmt.Errorf("can not add a non-active producer %s", producerPubkey)
 			}
+		}
+	}
 
-			item.GroupOwnerSign = hex.EncodeToString(signature)
-			item.Memo = params.Memo
-			item.TimeStamp = time.Now().UnixNano()
+	producers := []*quorumpb.ProducerItem{}
+	for _, producerPubkey := range newPubkeys {
+		if item, ok := currItems[producerPubkey]; ok {
 			producers = append(producers, item)
+			continue
 		}
 
-		bftProducerBundle.Producers = producers
+		item := &quorumpb.ProducerItem{}
+		item.GroupId = params.GroupId
+		item.ProducerPubkey = producerPubkey
+		item.GroupOwnerPubkey = group.Item.OwnerPubKey
+
+		var buffer bytes.Buffer
+		buffer.Write([]byte(item.GroupId))
+		buffer.Write([]byte(item.ProducerPubkey))
+		buffer.Write([]byte(item.GroupOwnerPubkey))
+		hash := localcrypto.Hash(buffer.Bytes())
+
+		ks := nodectx.GetNodeCtx().Keystore
+		signature, err := ks.EthSignByKeyName(item.GroupId, hash)
 
-		trxId, err := group.UpdProducer(bftProducerBundle)
 		if err != nil {
 			return nil, err
 		}
 
-		totalProducers := len(bundle) + 1    /* owner*/
-		failable := (totalProducers - 1) / 3 /* 3F < N */
+		item.GroupOwnerSign = hex.EncodeToString(signature)
+		item.Action = quorumpb.ActionType_ADD
+		item.Memo = params.Memo
+		item.TimeStamp = time.Now().UnixNano()
+		producers = append(producers, item)
+	}
+
+	bftProducerBundle := &quorumpb.BFTProducerBundleItem{Producers: producers}
+	trxId, err := group.UpdProducer(bftProducerBundle)
+	if err != nil {
+		return nil, err
+	}
+
+	totalProducers := len(producers) + 1 /* owner*/
+	failable := (totalProducers - 1) / 3 /* 3F < N */
+
+	return &GrpProducerResult{
+		GroupId:   group.Item.GroupId,
+		Producers: bftProducerBundle.Producers,
+		Failable:  &failable,
+		Memo:      params.Memo,
+		TrxId:     trxId,
+	}, nil
+}
+
+// updateProducerList returns the producer list after adding or removing pubkeys, the order of current producers is kept
+func updateProducerList(current []string, action string, pubkeys []string) ([]string, error) {
+	exist := map[string]bool{}
+	for _, pubkey := range current {
+		exist[pubkey] = true
+	}
 
-		blockGrpUserResult := &GrpProducerResult{
-			GroupId:   group.Item.GroupId,
-			Producers: bftProducerBundle.Producers,
-			Failable:  &failable,
-			Memo:      params.Memo, TrxId: trxId,
+	switch action {
+	case "add":
+		result := append([]string{}, current...)
+		for _, pubkey := range pubkeys {
+			if exist[pubkey] {
+				return nil, fmt.Errorf("producer %s already exists", pubkey)
+			}
+			result = append(result, pubkey)
+		}
+		return result, nil
+	case "remove":
+		removed := map[string]bool{}
+		for _, pubkey := range pubkeys {
+			if !exist[pubkey] {
+				return nil, fmt.Errorf("producer %s not found", pubkey)
+			}
+			removed[pubkey] = true
+		}
+		result := []string{}
+		for _, pubkey := range current {
+			if !removed[pubkey] {
+				result = append(result, pubkey)
+			}
 		}
-		return blockGrpUserResult, nil
+		return result, nil
+	default:
+		return nil, fmt.Errorf("unknown action %s", action)
 	}
 }
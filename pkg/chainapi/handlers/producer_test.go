@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUpdateProducerList(t *testing.T) {
+	current := []string{"p1", "p2"}
+
+	result, err := updateProducerList(current, "add", []string{"p3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result, []string{"p1", "p2", "p3"}) {
+		t.Errorf("unexpected producers after add: %v", result)
+	}
+
+	result, err = updateProducerList(current, "remove", []string{"p1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result, []string{"p2"}) {
+		t.Errorf("unexpected producers after remove: %v", result)
+	}
+	if !reflect.DeepEqual(current, []string{"p1", "p2"}) {
+		t.Errorf("current producers should not be modified: %v", current)
+	}
+
+	if _, err := updateProducerList(current, "add", []string{"p2"}); err == nil {
+		t.Errorf("adding an existing producer should fail")
+	}
+	if _, err := updateProducerList(current, "remove", []string{"p3"}); err == nil {
+		t.Errorf("removing an unknown producer should fail")
+	}
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/rumsystem/quorum/internal/pkg/appdata"
+)
+
+type PostProfileParam struct {
+	GroupId     string             `param:"group_id" json:"-" validate:"required,uuid4" example:"5ed3f9fe-81e2-450d-9146-7a329aac2b62"`
+	Name        string             `json:"name" example:"alice"`                                                         // nickname, at most 64 characters
+	Avatar      string             `json:"avatar" example:"bafkreihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvyku"` // blob hash or url of the avatar image, at most 512 bytes
+	Bio         string             `json:"bio" example:"hello quorum"`                                                   // at most 1024 characters
+	RetryPolicy *PublishRetryParam `json:"retry_policy,omitempty"`
+}
+
+type PostProfileResult struct {
+	TrxId string `json:"trx_id" example:"9e54c173-c1dd-429d-91fa-a6b43c14da77"`
+}
+
+type GetProfileParam struct {
+	GroupId string `param:"group_id" json:"-" validate:"required,uuid4" example:"5ed3f9fe-81e2-450d-9146-7a329aac2b62"`
+	Pubkey  string `param:"pubkey" json:"-" validate:"required" example:"CAISIQOlA37+ghb05D5ZAKExjsto/H7eeCmkagcZ+BY/pjSOKw=="` // sign pubkey of the user
+}
+
+// PostProfile posts the profile of this node in the group, it replaces the profile posted before once it is in a block
+func PostProfile(params *PostProfileParam) (*PostProfileResult, error) {
+	group, err := getGroup(params.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	post, err := appdata.NewProfilePost(&appdata.Profile{Name: params.Name, Avatar: params.Avatar, Bio: params.Bio})
+	if err != nil {
+		return nil, err
+	}
+	trxId, err := group.PostToGroupWithExpiry(post, 0, params.RetryPolicy.toPolicy())
+	if err != nil {
+		return nil, err
+	}
+	return &PostProfileResult{TrxId: trxId}, nil
+}
+
+// GetProfile returns the latest profile of the user in the group
+func GetProfile(params *GetProfileParam, appdb *appdata.AppDb) (*appdata.UserProfile, error) {
+	if _, err := getGroup(params.GroupId); err != nil {
+		return nil, err
+	}
+	profile, err := appdb.GetProfile(params.GroupId, params.Pubkey)
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil {
+		return nil, fmt.Errorf("profile of %s not found", params.Pubkey)
+	}
+	return profile, nil
+}
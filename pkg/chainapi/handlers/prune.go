@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rumsystem/quorum/internal/pkg/appdata"
+	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
+	"github.com/rumsystem/quorum/internal/pkg/nodectx"
+)
+
+type PruneGroupParam struct {
+	GroupId    string `param:"group_id" json:"-" validate:"required,uuid4" example:"ac0eea7c-2f3c-4c67-80b3-136e46b924a8"`
+	KeepBlocks uint64 `json:"keep_blocks" validate:"required_without=Before,omitempty,min=1" example:"10000"`              // keep the latest blocks
+	Before     int64  `json:"before" validate:"required_without=KeepBlocks,omitempty,min=1" example:"1672502400000000000"` // unix nano, drop the blocks older than it
+}
+
+type PruneGroupResult struct {
+	GroupId      string `json:"group_id" example:"ac0eea7c-2f3c-4c67-80b3-136e46b924a8"`
+	Checkpoint   uint64 `json:"checkpoint" example:"12000"`   // the snapshot block, the chain is verifiable from it
+	PrunedBefore uint64 `json:"pruned_before" example:"2000"` // the blocks before it are pruned, except the genesis block
+	PrunedBlocks uint64 `json:"pruned_blocks" example:"1000"` // blocks removed by this call
+	ExpiredPosts int    `json:"expired_posts" example:"12"`   // expired posts removed by this call
+}
+
+// PruneGroup removes the old blocks with their trxs and posts, and the content of the expired posts, the group state is kept.
+// It needs a snapshot of the group, only the blocks below the snapshot block are pruned, so new blocks are still
+// validated and the snapshot can be served. The blocks not yet handled by appsync, the webhooks or the search index are kept.
+func PruneGroup(params *PruneGroupParam, appdb *appdata.AppDb) (*PruneGroupResult, error) {
+	group, ok := chain.GetGroupMgr().Groups[params.GroupId]
+	if !ok {
+		return nil, fmt.Errorf("Group %s not exist", params.GroupId)
+	}
+	if params.KeepBlocks > 0 && params.Before > 0 {
+		return nil, errors.New("only one of keep_blocks and before is allowed")
+	}
+
+	nodename := nodectx.GetNodeCtx().Name
+	chainStorage := nodectx.GetNodeCtx().GetChainStorage()
+
+	snapshot, err := GetSnapshot(params.GroupId)
+	if err != nil {
+		return nil, fmt.Errorf("can not prune without a snapshot: %s", err)
+	}
+	checkpoint := snapshot.BlockId
+	if current := group.GetCurrentBlockId(); current < checkpoint {
+		return nil, fmt.Errorf("snapshot block %d is ahead of the current block %d", checkpoint, current)
+	}
+
+	prunedBefore, err := chainStorage.GetPrunedBefore(params.GroupId, nodename)
+	if err != nil {
+		return nil, err
+	}
+
+	var before uint64
+	if params.KeepBlocks > 0 {
+		if checkpoint+1 > params.KeepBlocks {
+			before = checkpoint + 1 - params.KeepBlocks
+		}
+	} else {
+		before = prunedBefore
+		if before < 1 {
+			before = 1
+		}
+		for ; before < checkpoint; before++ {
+			exist, err := chainStorage.IsBlockExist(params.GroupId, before, false, nodename)
+			if err != nil {
+				return nil, err
+			}
+			if !exist {
+				continue
+			}
+			block, err := chainStorage.GetBlock(params.GroupId, before, false, nodename)
+			if err != nil {
+				return nil, err
+			}
+			if block.TimeStamp >= params.Before {
+				break
+			}
+		}
+	}
+
+	limit, err := appdataPendingBlock(params.GroupId, appdb)
+	if err != nil {
+		return nil, err
+	}
+	before = pruneLimit(before, checkpoint, limit)
+
+	result := &PruneGroupResult{GroupId: params.GroupId, Checkpoint: checkpoint, PrunedBefore: prunedBefore}
+	if result.ExpiredPosts, err = chainStorage.PruneExpiredPosts(params.GroupId, time.Now(), nodename); err != nil {
+		return nil, err
+	}
+	if before <= prunedBefore {
+		return result, nil
+	}
+	pruned, err := chainStorage.PruneBlocks(params.GroupId, before, nodename)
+	result.PrunedBlocks = pruned
+	if err != nil {
+		return nil, fmt.Errorf("pruned %d blocks, then failed: %s", pruned, err)
+	}
+	result.PrunedBefore = before
+	return result, nil
+}
+
+// pruneLimit caps the first block kept by the snapshot block and the first block pending in appdata,
+// the blocks below it are pruned
+func pruneLimit(before, checkpoint, pending uint64) uint64 {
+	for _, l := range []uint64{checkpoint, pending} {
+		if before > l {
+			before = l
+		}
+	}
+	return before
+}
+
+// appdataPendingBlock returns the first block not yet handled by appsync, the webhooks or the search index of the group
+func appdataPendingBlock(groupId string, appdb *appdata.AppDb) (uint64, error) {
+	var synced uint64
+	syncedStr, err := appdb.GetGroupStatus(groupId, "Block")
+	if err != nil {
+		return 0, err
+	}
+	if syncedStr != "" {
+		if synced, err = strconv.ParseUint(syncedStr, 10, 64); err != nil {
+			return 0, err
+		}
+	}
+
+	webhooks, err := appdb.GetWebhooks(groupId)
+	if err != nil {
+		return 0, err
+	}
+	for _, w := range webhooks {
+		if w.LastBlock < synced {
+			synced = w.LastBlock
+		}
+	}
+
+	status, err := appdb.GetSearchIndexStatus(groupId)
+	if err != nil {
+		return 0, err
+	}
+	if status.Enabled && status.IndexedBlock < synced {
+		synced = status.IndexedBlock
+	}
+
+	return synced + 1, nil
+}
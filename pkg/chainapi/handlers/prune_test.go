@@ -0,0 +1,22 @@
+package handlers
+
+import "testing"
+
+func TestPruneLimit(t *testing.T) {
+	cases := []struct {
+		before, checkpoint, pending, expect uint64
+	}{
+		{10, 100, 200, 10},
+		// the snapshot block and the blocks after it are kept
+		{150, 100, 200, 100},
+		{100, 100, 200, 100},
+		// the blocks not yet handled by appdata are kept
+		{150, 100, 50, 50},
+		{0, 100, 200, 0},
+	}
+	for _, c := range cases {
+		if got := pruneLimit(c.before, c.checkpoint, c.pending); got != c.expect {
+			t.Errorf("pruneLimit(%d, %d, %d): expect %d, got %d", c.before, c.checkpoint, c.pending, c.expect, got)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
+)
+
+// PublishRetryParam overrides the node default retry policy of a trx, zero fields keep the default
+type PublishRetryParam struct {
+	MaxAttempts int `json:"max_attempts" validate:"omitempty,min=1" example:"5"`
+	BaseBackoff int `json:"base_backoff" validate:"omitempty,min=1" example:"10000"` // ms
+	MaxBackoff  int `json:"max_backoff" validate:"omitempty,min=1" example:"300000"` // ms
+}
+
+type PubQueueItem struct {
+	TrxId       string `json:"trx_id" example:"9e54c173-c1dd-429d-91fa-a6b43c14da77"`
+	TrxType     string `json:"trx_type" example:"POST"`
+	State       string `json:"state" example:"DEAD"`
+	Attempts    int    `json:"attempts" example:"5"`
+	MaxAttempts int    `json:"max_attempts" example:"5"`
+	LastAttempt int64  `json:"last_attempt" example:"1663900000000000000"` // unix nano
+	NextRetry   int64  `json:"next_retry" example:"1663900010000000000"`   // unix nano
+	LastError   string `json:"last_error"`
+
+	IdempotencyKey string `json:"idempotency_key,omitempty" example:"3f9c2d0e-post-1"`
+}
+
+type PubQueueResult struct {
+	GroupId       string          `json:"group_id" example:"ac0eea7c-2f3c-4c67-80b3-136e46b924a8"`
+	Items         []*PubQueueItem `json:"items"`
+	InFlight      int             `json:"in_flight" example:"2"`      // trxs of the group being resent
+	Workers       int             `json:"workers" example:"4"`        // publish queue workers of the node, 0 if the publish queue is disabled
+	ActiveWorkers int             `json:"active_workers" example:"1"` // workers resending the trxs of any group
+}
+
+type GetPubQueueParam struct {
+	GroupId string `param:"group_id" validate:"required,uuid4" example:"ac0eea7c-2f3c-4c67-80b3-136e46b924a8"`
+	State   string `query:"state" validate:"omitempty,oneof=PENDING DEAD" example:"PENDING"` // empty for all states
+}
+
+type PubQueueTrxParam struct {
+	GroupId string `param:"group_id" validate:"required,uuid4" example:"ac0eea7c-2f3c-4c67-80b3-136e46b924a8"`
+	TrxId   string `param:"trx_id" validate:"required,uuid4" example:"9e54c173-c1dd-429d-91fa-a6b43c14da77"`
+}
+
+func (p *PublishRetryParam) toPolicy() *chain.PublishRetryPolicy {
+	if p == nil {
+		return nil
+	}
+	return &chain.PublishRetryPolicy{
+		MaxAttempts: p.MaxAttempts,
+		BaseBackoff: time.Duration(p.BaseBackoff) * time.Millisecond,
+		MaxBackoff:  time.Duration(p.MaxBackoff) * time.Millisecond,
+	}
+}
+
+func getGroup(groupId string) (*chain.Group, error) {
+	if groupId == "" {
+		return nil, errors.New("group_id can't be nil.")
+	}
+	group, ok := chain.GetGroupMgr().Groups[groupId]
+	if !ok {
+		return nil, fmt.Errorf("Group %s not exist", groupId)
+	}
+	return group, nil
+}
+
+// GetPubQueue returns the trxs sent by this node and not applied yet in the given state, empty for all states
+func GetPubQueue(groupId string, state string) (*PubQueueResult, error) {
+	group, err := getGroup(groupId)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []*PubQueueItem{}
+	for _, entry := range group.ChainCtx.GetPublishQueue(state) {
+		items = append(items, &PubQueueItem{
+			TrxId:       entry.Trx.TrxId,
+			TrxType:     entry.Trx.Type.String(),
+			State:       entry.State,
+			Attempts:    entry.Attempts,
+			MaxAttempts: entry.Policy.MaxAttempts,
+			LastAttempt: entry.LastAttempt.UnixNano(),
+			NextRetry:   entry.NextRetry.UnixNano(),
+			LastError:   entry.LastError,
+
+			IdempotencyKey: entry.IdempotencyKey,
+		})
+	}
+	res := &PubQueueResult{GroupId: groupId, Items: items, InFlight: group.ChainCtx.GetPublishInFlight()}
+	res.Workers, res.ActiveWorkers = chain.GetGroupMgr().GetPublishWorkers()
+	return res, nil
+}
+
+// RequeueTrx moves a dead-letter trx back to the publish queue
+func RequeueTrx(params *PubQueueTrxParam) (*TrxResult, error) {
+	group, err := getGroup(params.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	if err := group.ChainCtx.RequeueTrx(params.TrxId); err != nil {
+		return nil, err
+	}
+	return &TrxResult{params.TrxId}, nil
+}
+
+// RetryTrxNow resends a queued trx immediately
+func RetryTrxNow(params *PubQueueTrxParam) (*TrxResult, error) {
+	group, err := getGroup(params.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	if err := group.ChainCtx.RetryTrxNow(params.TrxId); err != nil {
+		return nil, err
+	}
+	return &TrxResult{params.TrxId}, nil
+}
+
+// CancelTrx removes a trx from the publish queue, a copy already sent may still be applied
+func CancelTrx(params *PubQueueTrxParam) (*TrxResult, error) {
+	group, err := getGroup(params.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	if err := group.ChainCtx.CancelTrx(params.TrxId); err != nil {
+		return nil, err
+	}
+	return &TrxResult{params.TrxId}, nil
+}
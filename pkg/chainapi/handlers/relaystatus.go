@@ -0,0 +1,78 @@
+//go:build !js
+// +build !js
+
+package handlers
+
+import (
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/rumsystem/quorum/internal/pkg/conn/p2p"
+)
+
+type RelayReservation struct {
+	RelayId       string   `json:"relay_id" example:"16Uiu2HAm5waftP3s4oE1EzGF2SyWeK726P5B8BSgFJqSiz6xScGz"`
+	Addrs         []string `json:"addrs"`                      // circuit addrs of the node through the relay
+	PreferredRank int      `json:"preferred_rank" example:"0"` // index in the preferred relays, -1 if it is not preferred
+}
+
+type RelayStatus struct {
+	Enabled      bool               `json:"enabled" example:"true"`
+	Preferred    []string           `json:"preferred"`    // p2p multiaddrs of the preferred relays in order
+	Reservations []RelayReservation `json:"reservations"` // relays in use
+}
+
+type SetPreferredRelaysParam struct {
+	Relays []string `json:"relays" validate:"dive,required" example:"/ip4/94.23.17.189/tcp/62777/p2p/16Uiu2HAm5waftP3s4oE1EzGF2SyWeK726P5B8BSgFJqSiz6xScGz"` // empty to prefer no relay
+}
+
+// GetRelayStatus returns the preferred relays and the relays the node holds reservations with
+func GetRelayStatus(node *p2p.Node) (*RelayStatus, error) {
+	if node == nil || node.Host == nil {
+		return nil, errors.New("node is not available")
+	}
+	result := &RelayStatus{Preferred: []string{}, Reservations: []RelayReservation{}}
+	if node.RelaySource == nil {
+		return result, nil
+	}
+	result.Enabled = true
+
+	rank := make(map[string]int)
+	for i, pi := range node.RelaySource.Preferred() {
+		rank[pi.ID.String()] = i
+		addrs, err := peer.AddrInfoToP2pAddrs(&pi)
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			result.Preferred = append(result.Preferred, addr.String())
+		}
+	}
+	for _, r := range node.RelayReservations() {
+		item := RelayReservation{RelayId: r.RelayId, PreferredRank: -1}
+		if i, ok := rank[r.RelayId]; ok {
+			item.PreferredRank = i
+		}
+		for _, addr := range r.Addrs {
+			item.Addrs = append(item.Addrs, addr.String())
+		}
+		result.Reservations = append(result.Reservations, item)
+	}
+	return result, nil
+}
+
+// SetPreferredRelays saves the relays tried first for the relay reservation, an unreachable relay falls back to the next one
+func SetPreferredRelays(node *p2p.Node, params *SetPreferredRelaysParam) (*RelayStatus, error) {
+	validate := validator.New()
+	if err := validate.Struct(params); err != nil {
+		return nil, err
+	}
+	if node == nil {
+		return nil, errors.New("node is not available")
+	}
+	if err := node.SetPreferredRelays(params.Relays); err != nil {
+		return nil, err
+	}
+	return GetRelayStatus(node)
+}
@@ -4,15 +4,15 @@
 package handlers
 
 import (
-	"bytes"
-	"io/ioutil"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"filippo.io/age"
+	"github.com/rumsystem/quorum/internal/pkg/options"
 	"github.com/rumsystem/quorum/internal/pkg/utils"
-	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
 )
 
 type RestoreParam struct {
@@ -23,85 +23,159 @@ type RestoreParam struct {
 	ConfigDir   string `json:"config_dir" validate:"required"`
 	SeedDir     string `json:"seed_dir" validate:"required"`
 	DataDir     string `json:"data_dir" validate:"required"`
+
+	IdentityFile     string       `json:"identity_file"`     // age identity file, decrypt with password if empty
+	IncrementalFiles []string     `json:"incremental_files"` // applied in order after the full backup
+	DryRun           bool         `json:"dry_run"`           // only print what would change
+	Progress         ProgressFunc `json:"-"`                 // optional
+	// optional, the dbs are restored to <data_dir>/<peername> if nil
+	Paths *options.NodePaths `json:"-"`
+}
+
+func (params RestoreParam) nodePaths() *options.NodePaths {
+	return getNodePaths(params.Paths, params.DataDir, params.Peername)
 }
 
 // Restore restores the keystore and config from backup data
-func Restore(params RestoreParam) {
+func Restore(params RestoreParam) error {
 	encZipPath := params.BackupFile
 
 	// check restore path
 	if exist := utils.FileExist(encZipPath); !exist {
-		logger.Fatalf("can not find %s", encZipPath)
+		return fmt.Errorf("can not find %s", encZipPath)
 	}
 
-	// age identities
-	identities := []age.Identity{
-		&localcrypto.LazyScryptIdentity{Password: params.Password},
-	}
-
-	encZipFile, err := os.Open(encZipPath)
-	if err != nil {
-		logger.Fatalf("os.Open(%s) failed: %s", encZipPath, err)
+	if params.DryRun {
+		plan, err := PlanRestore(params)
+		if err != nil {
+			return err
+		}
+		PrintRestorePlan(plan)
+		return nil
 	}
-	defer encZipFile.Close()
 
-	zipFile, err := age.Decrypt(encZipFile, identities...)
-	if err != nil {
-		logger.Fatalf("decrypt encrypted zip file failed: %v", err)
-	}
-	zipFilePath := strings.Replace(encZipPath, ".enc", "", 1)
-	absZipFilePath, err := filepath.Abs(zipFilePath)
+	identities, err := GetBackupIdentities(params.Password, params.IdentityFile)
 	if err != nil {
-		logger.Fatalf("filepath.Abs(%s) failed: %s", zipFilePath, err)
+		return err
 	}
-	defer utils.RemoveAll(absZipFilePath)
-
-	buf := new(bytes.Buffer)
-	_, err = buf.ReadFrom(zipFile)
+	absUnZipDir, err := decryptAndUnzipBackup(encZipPath, identities, filepath.Dir(encZipPath), params.Progress)
 	if err != nil {
-		logger.Fatalf("buf.ReadFrom failed: %s", err)
+		return err
 	}
-	if err := ioutil.WriteFile(absZipFilePath, buf.Bytes(), 0600); err != nil {
-		logger.Fatalf("ioutil.WriteFile failed: %s", err)
-	}
-
-	absUnZipDir := utils.PathTrimExt(absZipFilePath)
 	defer utils.RemoveAll(absUnZipDir)
-	if err := utils.Unzip(zipFilePath, absUnZipDir); err != nil {
-		logger.Fatalf("unzip backup zip archive failed: %v", err)
-	}
 
 	// copy config dir
 	if err := utils.CheckAndCreateDir(params.ConfigDir); err != nil {
-		logger.Fatalf("create directory %s failed: %s", params.ConfigDir, err)
+		return fmt.Errorf("create directory %s failed: %s", params.ConfigDir, err)
 	}
 	srcConfigDir := getConfigBackupPath(absUnZipDir)
+	params.Progress.report(ProgressStageConfig, 0, 1)
 	if err := utils.Copy(srcConfigDir, params.ConfigDir); err != nil {
-		logger.Fatalf("copy %s => %s failed: %s", srcConfigDir, params.ConfigDir, err)
+		return fmt.Errorf("copy %s => %s failed: %s", srcConfigDir, params.ConfigDir, err)
 	}
+	params.Progress.report(ProgressStageConfig, 1, 1)
 
 	// copy keystore dir
 	if err := utils.CheckAndCreateDir(params.KeystoreDir); err != nil {
-		logger.Fatalf("create directory %s failed: %s", params.KeystoreDir, err)
+		return fmt.Errorf("create directory %s failed: %s", params.KeystoreDir, err)
 	}
 	srcKeystoreDir := getKeystoreBackupPath(absUnZipDir)
+	params.Progress.report(ProgressStageKeystore, 0, 1)
 	if err := utils.Copy(srcKeystoreDir, params.KeystoreDir); err != nil {
-		logger.Fatalf("copy %s => %s failed: %s", srcKeystoreDir, params.KeystoreDir, err)
+		return fmt.Errorf("copy %s => %s failed: %s", srcKeystoreDir, params.KeystoreDir, err)
 	}
+	params.Progress.report(ProgressStageKeystore, 1, 1)
 
 	// copy seed dir
 	if err := utils.CheckAndCreateDir(params.SeedDir); err != nil {
-		logger.Fatalf("create directory %s failed: %s", params.SeedDir, err)
+		return fmt.Errorf("create directory %s failed: %s", params.SeedDir, err)
 	}
 	srcSeedDir := getSeedBackupPath(absUnZipDir)
+	params.Progress.report(ProgressStageSeeds, 0, 1)
 	if err := utils.Copy(srcSeedDir, params.SeedDir); err != nil {
-		logger.Fatalf("copy %s => %s failed: %s", srcSeedDir, params.SeedDir, err)
+		return fmt.Errorf("copy %s => %s failed: %s", srcSeedDir, params.SeedDir, err)
 	}
+	params.Progress.report(ProgressStageSeeds, 1, 1)
 
 	// restore block db
-	srcDBDir := filepath.Join(absUnZipDir, "data", params.Peername)
-	dstDBDir := GetDataPath(params.DataDir, params.Peername)
-	if err := utils.Copy(srcDBDir, dstDBDir); err != nil {
-		logger.Fatalf("restore data failed: %s", err)
+	params.Progress.report(ProgressStageBlocks, 0, 1)
+	if err := restoreBlocks(absUnZipDir, params.Peername, params.nodePaths()); err != nil {
+		return fmt.Errorf("restore data failed: %s", err)
+	}
+	params.Progress.report(ProgressStageBlocks, 1, 1)
+
+	// apply incremental backups in order
+	for _, incFile := range params.IncrementalFiles {
+		if exist := utils.FileExist(incFile); !exist {
+			return fmt.Errorf("can not find %s", incFile)
+		}
+		if err := restoreIncremental(params, incFile); err != nil {
+			return fmt.Errorf("restore incremental backup %s failed: %s", incFile, err)
+		}
+	}
+
+	return nil
+}
+
+// restoreBlocks import blocks of the unzipped backup into the chain db of `paths`
+func restoreBlocks(unZipDir, peerName string, paths *options.NodePaths) error {
+	dbMgr, err := createNodeDb(paths)
+	if err != nil {
+		return fmt.Errorf("storage.CreateDb %s failed: %s", paths.ChainDb, err)
+	}
+	defer dbMgr.Db.Close()
+	defer dbMgr.GroupInfoDb.Close()
+
+	return importBackupBlocks(unZipDir, peerName, dbMgr.Db, nil)
+}
+
+// decryptAndUnzipBackup decrypt the backup file and unzip it into `workDir`, return the unzip directory
+func decryptAndUnzipBackup(encZipPath string, identities []age.Identity, workDir string, progress ProgressFunc) (string, error) {
+	encZipFile, err := os.Open(encZipPath)
+	if err != nil {
+		return "", fmt.Errorf("os.Open(%s) failed: %s", encZipPath, err)
+	}
+	defer encZipFile.Close()
+
+	var encReader io.Reader = encZipFile
+	if progress != nil {
+		info, err := encZipFile.Stat()
+		if err != nil {
+			return "", fmt.Errorf("stat %s failed: %s", encZipPath, err)
+		}
+		encReader = &progressReader{r: encZipFile, stage: ProgressStageDecrypt, total: info.Size(), progress: progress}
+	}
+
+	zipReader, err := age.Decrypt(encReader, identities...)
+	if err != nil {
+		return "", fmt.Errorf("decrypt encrypted zip file failed: %v", err)
+	}
+
+	zipFileName := strings.Replace(filepath.Base(encZipPath), ".enc", "", 1)
+	absZipFilePath, err := filepath.Abs(filepath.Join(workDir, zipFileName))
+	if err != nil {
+		return "", fmt.Errorf("filepath.Abs(%s) failed: %s", zipFileName, err)
+	}
+	defer utils.RemoveAll(absZipFilePath)
+
+	// stream decrypt to the zip file, do not hold the whole backup in memory
+	zipFile, err := os.OpenFile(absZipFilePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", fmt.Errorf("os.OpenFile(%s) failed: %s", absZipFilePath, err)
 	}
+	if _, err := io.Copy(zipFile, zipReader); err != nil {
+		zipFile.Close()
+		return "", fmt.Errorf("decrypt encrypted zip file failed: %s", err)
+	}
+	if err := zipFile.Close(); err != nil {
+		return "", fmt.Errorf("close %s failed: %s", absZipFilePath, err)
+	}
+
+	absUnZipDir := utils.PathTrimExt(absZipFilePath)
+	if err := utils.UnzipWithProgress(absZipFilePath, absUnZipDir, progress.stage(ProgressStageUnzip)); err != nil {
+		utils.RemoveAll(absUnZipDir)
+		return "", fmt.Errorf("unzip backup zip archive failed: %v", err)
+	}
+
+	return absUnZipDir, nil
 }
@@ -0,0 +1,186 @@
+//go:build !js
+// +build !js
+
+package handlers
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rumsystem/quorum/internal/pkg/appdata"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
+)
+
+// RestorePlan describes what Restore would change
+type RestorePlan struct {
+	Groups          []string `json:"groups"`             // groups to be joined
+	JoinedGroups    []string `json:"joined_groups"`      // groups in backup which already joined
+	KeystoreKeys    []string `json:"keystore_keys"`      // keystore keys to be added
+	Overwritten     []string `json:"overwritten"`        // existing files would be overwritten
+	DataDirNotEmpty bool     `json:"data_dir_not_empty"` // data directory of the peer is not empty
+}
+
+// PlanRestore decrypt and inspect the backup, returns what Restore would change without writing to the restore directories
+func PlanRestore(params RestoreParam) (*RestorePlan, error) {
+	encZipPath := params.BackupFile
+	if exist := utils.FileExist(encZipPath); !exist {
+		return nil, fmt.Errorf("can not find %s", encZipPath)
+	}
+
+	identities, err := GetBackupIdentities(params.Password, params.IdentityFile)
+	if err != nil {
+		return nil, err
+	}
+
+	workDir, err := os.MkdirTemp("", "quorum-restore-plan-")
+	if err != nil {
+		return nil, fmt.Errorf("create temp directory failed: %s", err)
+	}
+	defer utils.RemoveAll(workDir)
+
+	unZipDir, err := decryptAndUnzipBackup(encZipPath, identities, workDir, params.Progress)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := RestorePlan{}
+
+	// groups
+	seeds, err := ioutil.ReadDir(getSeedBackupPath(unZipDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read seeds directory failed: %s", err)
+	}
+	for _, seed := range seeds {
+		if seed.IsDir() || !strings.HasSuffix(seed.Name(), ".json") {
+			continue
+		}
+		plan.Groups = append(plan.Groups, strings.TrimSuffix(seed.Name(), ".json"))
+	}
+
+	paths := params.nodePaths()
+	if items, err := ioutil.ReadDir(paths.ChainDb); err == nil && len(items) > 0 {
+		plan.DataDirNotEmpty = true
+
+		joined, err := getJoinedGroups(paths.AppDb, plan.Groups)
+		if err != nil {
+			return nil, err
+		}
+		plan.JoinedGroups = joined
+	}
+
+	// keystore keys
+	keystoreDir := getKeystoreBackupPath(unZipDir)
+	keys, err := ioutil.ReadDir(keystoreDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read keystore directory failed: %s", err)
+	}
+	for _, key := range keys {
+		name := key.Name()
+		if !strings.HasPrefix(name, localcrypto.Sign.Prefix()) && !strings.HasPrefix(name, localcrypto.Encrypt.Prefix()) {
+			continue
+		}
+		if !utils.FileExist(filepath.Join(params.KeystoreDir, name)) {
+			plan.KeystoreKeys = append(plan.KeystoreKeys, name)
+		}
+	}
+
+	// files would be overwritten
+	pairs := [][2]string{
+		{getConfigBackupPath(unZipDir), params.ConfigDir},
+		{keystoreDir, params.KeystoreDir},
+		{getSeedBackupPath(unZipDir), params.SeedDir},
+		{getDataBackupPath(unZipDir, params.Peername), paths.ChainDb},
+	}
+	for _, pair := range pairs {
+		files, err := getOverwrittenFiles(pair[0], pair[1])
+		if err != nil {
+			return nil, err
+		}
+		plan.Overwritten = append(plan.Overwritten, files...)
+	}
+
+	return &plan, nil
+}
+
+// PrintRestorePlan print the restore plan to stdout
+func PrintRestorePlan(plan *RestorePlan) {
+	if plan.DataDirNotEmpty {
+		fmt.Println("WARNING: data directory is not empty")
+	}
+
+	sections := []struct {
+		title string
+		items []string
+	}{
+		{"groups to be joined", plan.Groups},
+		{"groups already joined", plan.JoinedGroups},
+		{"keystore keys to be added", plan.KeystoreKeys},
+		{"existing files to be overwritten", plan.Overwritten},
+	}
+	for _, section := range sections {
+		fmt.Printf("%s (%d):\n", section.title, len(section.items))
+		for _, item := range section.items {
+			fmt.Printf("  %s\n", item)
+		}
+	}
+}
+
+// getJoinedGroups returns groups which seed exists in the app db
+func getJoinedGroups(dataPath string, groups []string) ([]string, error) {
+	// do not create the app db
+	if !utils.FileExist(filepath.Join(dataPath, "appdb.db")) {
+		return nil, nil
+	}
+
+	appdb, err := appdata.CreateAppDb(dataPath)
+	if err != nil {
+		return nil, fmt.Errorf("appdata.CreateAppDb failed: %s", err)
+	}
+	defer appdb.Db.Close()
+
+	joined := []string{}
+	for _, groupId := range groups {
+		seed, err := appdb.GetGroupSeed(groupId)
+		if err != nil {
+			return nil, fmt.Errorf("get group seed failed: %s", err)
+		}
+		if seed != nil {
+			joined = append(joined, groupId)
+		}
+	}
+
+	return joined, nil
+}
+
+// getOverwrittenFiles returns files in `dstDir` which have the same relative path in `srcDir`
+func getOverwrittenFiles(srcDir, dstDir string) ([]string, error) {
+	if !utils.DirExist(srcDir) || !utils.DirExist(dstDir) {
+		return nil, nil
+	}
+
+	files := []string{}
+	err := filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(dstDir, rel)
+		if utils.FileExist(dst) {
+			files = append(files, dst)
+		}
+		return nil
+	})
+
+	return files, err
+}
@@ -0,0 +1,35 @@
+//go:build !js
+// +build !js
+
+package handlers
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetOverwrittenFiles(t *testing.T) {
+	srcDir, dstDir := t.TempDir(), t.TempDir()
+	for _, name := range []string{"a.json", "b.json"} {
+		if err := ioutil.WriteFile(filepath.Join(srcDir, name), []byte("{}"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(dstDir, "a.json"), []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := getOverwrittenFiles(srcDir, dstDir)
+	if err != nil {
+		t.Fatalf("getOverwrittenFiles failed: %s", err)
+	}
+	if len(files) != 1 || files[0] != filepath.Join(dstDir, "a.json") {
+		t.Errorf("unexpected overwritten files: %v", files)
+	}
+
+	files, err = getOverwrittenFiles(srcDir, filepath.Join(dstDir, "none"))
+	if err != nil || len(files) != 0 {
+		t.Errorf("expect no overwritten files for not exist directory, got: %v %v", files, err)
+	}
+}
@@ -0,0 +1,136 @@
+//go:build !js
+// +build !js
+
+package handlers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+)
+
+func TestDecryptAndUnzipBackup(t *testing.T) {
+	srcDir := t.TempDir()
+	if err := utils.EnsureDir(getSeedBackupPath(srcDir)); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(getSeedBackupPath(srcDir), "seed.json"), []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	workDir := t.TempDir()
+	encZipPath := filepath.Join(workDir, "backup.zip.enc")
+	f, err := os.Create(encZipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := encryptZipDirToWriter(srcDir, mustGetBackupRecipients(t, "123456"), f, nil); err != nil {
+		t.Fatalf("encryptZipDirToWriter failed: %s", err)
+	}
+	f.Close()
+
+	corruptPath := filepath.Join(workDir, "corrupt.zip.enc")
+	if err := ioutil.WriteFile(corruptPath, []byte("not a backup"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		password string
+		wantErr  bool
+	}{
+		{"ok", encZipPath, "123456", false},
+		{"wrong password", encZipPath, "654321", true},
+		{"corrupt file", corruptPath, "123456", true},
+		{"not exist", filepath.Join(workDir, "none.zip.enc"), "123456", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identities, err := GetBackupIdentities(tt.password, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			unZipDir, err := decryptAndUnzipBackup(tt.path, identities, t.TempDir(), nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decryptAndUnzipBackup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer utils.RemoveAll(unZipDir)
+
+			if !utils.FileExist(filepath.Join(getSeedBackupPath(unZipDir), "seed.json")) {
+				t.Errorf("seed file not found in %s", unZipDir)
+			}
+		})
+	}
+}
+
+func TestRestoreMissingBackupFile(t *testing.T) {
+	dir := t.TempDir()
+	param := RestoreParam{
+		Peername:   "peer",
+		Password:   "123456",
+		BackupFile: filepath.Join(dir, "none.zip.enc"),
+	}
+	if err := Restore(param); err == nil {
+		t.Errorf("Restore should fail when backup file not exist")
+	}
+}
+
+func mustGetBackupRecipients(t *testing.T, password string, recipients ...string) []age.Recipient {
+	result, err := getBackupRecipients(password, recipients)
+	if err != nil {
+		t.Fatalf("getBackupRecipients failed: %s", err)
+	}
+	return result
+}
+
+func TestDecryptAndUnzipBackupWithIdentityFile(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	workDir := t.TempDir()
+	identityFile := filepath.Join(workDir, "identity.txt")
+	if err := ioutil.WriteFile(identityFile, []byte(identity.String()+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	srcDir := t.TempDir()
+	if err := utils.EnsureDir(getSeedBackupPath(srcDir)); err != nil {
+		t.Fatal(err)
+	}
+
+	encZipPath := filepath.Join(workDir, "backup.zip.enc")
+	f, err := os.Create(encZipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := encryptZipDirToWriter(srcDir, mustGetBackupRecipients(t, "", identity.Recipient().String()), f, nil); err != nil {
+		t.Fatalf("encryptZipDirToWriter failed: %s", err)
+	}
+	f.Close()
+
+	identities, err := GetBackupIdentities("", identityFile)
+	if err != nil {
+		t.Fatalf("GetBackupIdentities failed: %s", err)
+	}
+	unZipDir, err := decryptAndUnzipBackup(encZipPath, identities, t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("decryptAndUnzipBackup failed: %s", err)
+	}
+	defer utils.RemoveAll(unZipDir)
+
+	// the passphrase can not decrypt it
+	identities, _ = GetBackupIdentities("123456", "")
+	if _, err := decryptAndUnzipBackup(encZipPath, identities, t.TempDir(), nil); err == nil {
+		t.Errorf("decrypt with passphrase should fail")
+	}
+}
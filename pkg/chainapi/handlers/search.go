@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/rumsystem/quorum/internal/pkg/appdata"
+	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
+	"github.com/rumsystem/quorum/internal/pkg/nodectx"
+	"github.com/rumsystem/quorum/internal/pkg/storage/def"
+)
+
+const DEFAULT_SEARCH_NUM = 20
+
+type SearchGroupContentParam struct {
+	GroupId string `param:"group_id" json:"-" validate:"required,uuid4" example:"ac0eea7c-2f3c-4c67-80b3-136e46b924a8"`
+	Query   string `query:"q" json:"q" validate:"required,max=256" example:"hello quorum"` // posts containing all words are returned
+	Num     int    `query:"num" json:"num" validate:"omitempty,min=1,max=100" example:"20"`
+}
+
+type SearchGroupContentResult struct {
+	GroupId string                      `json:"group_id"`
+	Trxs    []*appdata.SearchResultItem `json:"trxs"` // from the newest to the oldest
+}
+
+type SearchIndexParam struct {
+	GroupId string `param:"group_id" json:"-" validate:"required,uuid4" example:"ac0eea7c-2f3c-4c67-80b3-136e46b924a8"`
+	Enable  bool   `json:"enable" example:"true"` // false deletes the index of the group
+}
+
+// SearchGroupContent returns the posts of the group containing all words of the query, search should be enabled for the group.
+// The posts of the blocked authors and the chunk posts indexed before the chunks were left out of the index are left out
+func SearchGroupContent(params *SearchGroupContentParam, appdb *appdata.AppDb) (*SearchGroupContentResult, error) {
+	group, ok := chain.GetGroupMgr().Groups[params.GroupId]
+	if !ok {
+		return nil, fmt.Errorf("Group %s not exist", params.GroupId)
+	}
+	status, err := appdb.GetSearchIndexStatus(params.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	if !status.Enabled {
+		return nil, fmt.Errorf("search is not enabled for group %s", params.GroupId)
+	}
+
+	num := params.Num
+	if num <= 0 {
+		num = DEFAULT_SEARCH_NUM
+	}
+	blocked, err := appdb.GetBlockedSet(params.GroupId)
+	if err != nil {
+		return nil, err
+	}
+	nodename := nodectx.GetNodeCtx().Name
+	skip := func(trxId string) bool {
+		trx, err := nodectx.GetNodeCtx().GetChainStorage().GetTrx(params.GroupId, trxId, def.Chain, nodename)
+		if err != nil {
+			return false
+		}
+		if blocked.Has(trx.SenderPubkey) {
+			return true
+		}
+		return appdata.DecryptPostTrx(group.Item, trx) == nil && appdata.ParseChunk(trx.Data) != nil
+	}
+	trxs, err := appdb.SearchGroupContent(params.GroupId, params.Query, num, skip)
+	if err != nil {
+		return nil, err
+	}
+	return &SearchGroupContentResult{GroupId: params.GroupId, Trxs: trxs}, nil
+}
+
+// SetSearchIndex enables search of the group, the existing posts are indexed in the background,
+// or disables it and deletes the index
+func SetSearchIndex(params *SearchIndexParam, appdb *appdata.AppDb) (*appdata.SearchIndexStatus, error) {
+	if _, ok := chain.GetGroupMgr().Groups[params.GroupId]; !ok {
+		return nil, fmt.Errorf("Group %s not exist", params.GroupId)
+	}
+	if params.Enable {
+		return appdb.EnableSearchIndex(params.GroupId)
+	}
+	if err := appdb.DelSearchIndex(params.GroupId); err != nil {
+		return nil, err
+	}
+	return &appdata.SearchIndexStatus{GroupId: params.GroupId}, nil
+}
+
+func GetSearchIndex(groupId string, appdb *appdata.AppDb) (*appdata.SearchIndexStatus, error) {
+	return appdb.GetSearchIndexStatus(groupId)
+}
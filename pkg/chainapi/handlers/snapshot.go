@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	"github.com/rumsystem/quorum/pkg/consensus/def"
+)
+
+type ApplySnapshotResult struct {
+	GroupId string `json:"group_id" example:"c8795b55-90bf-4b58-aaa0-86d11fe4e16a"`
+	BlockId uint64 `json:"block_id" example:"1000"`
+	Epoch   uint64 `json:"epoch" example:"1000"`
+}
+
+func getGroupConsensus(groupId string) (def.Consensus, error) {
+	if groupId == "" {
+		return nil, errors.New("group_id can't be nil.")
+	}
+
+	group, ok := chain.GetGroupMgr().Groups[groupId]
+	if !ok {
+		return nil, fmt.Errorf("Group %s not exist", groupId)
+	}
+	if group.ChainCtx == nil || group.ChainCtx.Consensus == nil {
+		return nil, fmt.Errorf("consensus of group %s is not created", groupId)
+	}
+
+	return group.ChainCtx.Consensus, nil
+}
+
+// GetSnapshot returns the latest snapshot created by the local producer of the group
+func GetSnapshot(groupId string) (*def.Snapshot, error) {
+	cons, err := getGroupConsensus(groupId)
+	if err != nil {
+		return nil, err
+	}
+	if cons.SnapshotSender() == nil {
+		return nil, fmt.Errorf("snapshot is not enabled for group %s", groupId)
+	}
+
+	snapshot := cons.SnapshotSender().GetSnapshot()
+	if snapshot == nil {
+		return nil, fmt.Errorf("no snapshot for group %s yet", groupId)
+	}
+	return snapshot, nil
+}
+
+// ApplySnapshot verify the snapshot fetched from a producer and fast-forward the group to it
+func ApplySnapshot(groupId string, snapshot *def.Snapshot) (*ApplySnapshotResult, error) {
+	cons, err := getGroupConsensus(groupId)
+	if err != nil {
+		return nil, err
+	}
+	if cons.SnapshotReceiver() == nil {
+		return nil, fmt.Errorf("group %s can not apply snapshot", groupId)
+	}
+
+	if err := cons.SnapshotReceiver().ApplySnapshot(snapshot); err != nil {
+		return nil, err
+	}
+
+	return &ApplySnapshotResult{GroupId: snapshot.GroupId, BlockId: snapshot.BlockId, Epoch: snapshot.Epoch}, nil
+}
+
+// FetchSnapshot fetch the latest snapshot of the group by the chain api urls of the seed, it returns the first one fetched,
+// the snapshot is not verified
+func FetchSnapshot(groupId string, urls []string) (*def.Snapshot, error) {
+	var lastErr error
+	for _, u := range urls {
+		baseUrl, jwt, err := utils.ParseChainapiURL(u)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		parsed, err := url.Parse(baseUrl)
+		if err != nil || parsed.Host == "" {
+			continue
+		}
+		parsed.Path = fmt.Sprintf("/api/v1/node/%s/snapshot", groupId)
+		parsed.RawQuery = ""
+
+		headers := http.Header{}
+		headers.Set("Authorization", fmt.Sprintf("Bearer %s", jwt))
+		snapshot := new(def.Snapshot)
+		statusCode, content, err := utils.RequestAPI(parsed.String(), "GET", nil, headers, snapshot)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if statusCode != http.StatusOK {
+			lastErr = fmt.Errorf("fetch snapshot from %s failed: %s", parsed.Host, content)
+			continue
+		}
+		return snapshot, nil
+	}
+
+	if lastErr == nil {
+		lastErr = errors.New("no chain api url to fetch snapshot")
+	}
+	return nil, lastErr
+}
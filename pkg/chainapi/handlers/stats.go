@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/rumsystem/quorum/internal/pkg/stats"
+)
+
+// the range of the stats query if from is not given
+const defaultStatsRange = 24 * time.Hour
+
+type GetStatsParam struct {
+	From  int64  `query:"from" json:"from" validate:"omitempty,min=0" example:"1663813600"`  // unix seconds, inclusive, default 24 hours before to
+	To    int64  `query:"to" json:"to" validate:"omitempty,min=0" example:"1663900000"`      // unix seconds, exclusive, default now
+	Group string `query:"group" json:"group" example:"ac0eea7c-2f3c-4c67-80b3-136e46b924a8"` // group id, node for the node level metrics, default all
+	Step  string `query:"step" json:"step" example:"5m"`                                     // bucket size in whole minutes, default 1m, or 1h if from is older than the minute stats
+}
+
+type StatsResult struct {
+	From   int64           `json:"from" example:"1663813600"`
+	To     int64           `json:"to" example:"1663900000"`
+	Series []*stats.Series `json:"series"`
+}
+
+type ResetStatsParam struct {
+	Confirm bool `json:"confirm" validate:"required" example:"true"` // must be true, the counters can't be restored
+}
+
+type ResetStatsResult struct {
+	Time int64 `json:"time" example:"1663900000"` // unix seconds of the reset
+}
+
+type ExportStatsParam struct {
+	GetStatsParam
+	Format string `query:"format" json:"format" validate:"omitempty,oneof=csv json" example:"csv"` // default csv
+}
+
+// GetStats returns the counters of the node recorded in the stats db, summed in time buckets
+func GetStats(params *GetStatsParam) (*StatsResult, error) {
+	validate := validator.New()
+	if err := validate.Struct(params); err != nil {
+		return nil, err
+	}
+
+	to := time.Now()
+	if params.To > 0 {
+		to = time.Unix(params.To, 0)
+	}
+	from := to.Add(-defaultStatsRange)
+	if params.From > 0 {
+		from = time.Unix(params.From, 0)
+	}
+	var step time.Duration
+	if params.Step != "" {
+		var err error
+		if step, err = time.ParseDuration(params.Step); err != nil {
+			return nil, errors.New("invalid step, should be a duration like 5m or 1h")
+		}
+	}
+
+	series, err := stats.Query(from, to, params.Group, step)
+	if err != nil {
+		return nil, err
+	}
+	return &StatsResult{From: from.Unix(), To: to.Unix(), Series: series}, nil
+}
+
+// ResetStats zeroes the counters of the node, e.g. before a benchmark
+func ResetStats(params *ResetStatsParam) (*ResetStatsResult, error) {
+	validate := validator.New()
+	if err := validate.Struct(params); err != nil {
+		return nil, err
+	}
+	if err := stats.Reset(); err != nil {
+		return nil, err
+	}
+	return &ResetStatsResult{Time: time.Now().Unix()}, nil
+}
+
+// ExportStats returns the series of GetStats as csv, a row per point with the columns time, metric, group and value,
+// or as json. It returns the content type of the export.
+func ExportStats(params *ExportStatsParam) ([]byte, string, error) {
+	validate := validator.New()
+	if err := validate.Struct(params); err != nil {
+		return nil, "", err
+	}
+	res, err := GetStats(&params.GetStatsParam)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if params.Format == "json" {
+		data, err := json.Marshal(res)
+		return data, "application/json", err
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"time", "metric", "group", "value"})
+	for _, series := range res.Series {
+		for _, p := range series.Points {
+			w.Write([]string{strconv.FormatInt(p.Time, 10), series.Metric, series.Group, strconv.FormatUint(p.Value, 10)})
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "text/csv", nil
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"github.com/rumsystem/quorum/internal/pkg/appdata"
+	"github.com/rumsystem/quorum/internal/pkg/storage"
+)
+
+type StorageGCParam struct {
+	FreeRatio float64 `json:"free_ratio" validate:"gte=0,lt=1" example:"0.5"` // 0 means the default ratio 0.5
+}
+
+type StorageGCResult struct {
+	Dbs       []*storage.GCResult `json:"dbs"`
+	Reclaimed int64               `json:"reclaimed" example:"268435456"` // total bytes given back to the filesystem
+}
+
+// StorageGC compacts the node dbs which have enough free space, appdb is nil on nodes without appdata
+func StorageGC(dbMgr *storage.DbMgr, appdb *appdata.AppDb, params *StorageGCParam) (*StorageGCResult, error) {
+	ratio := params.FreeRatio
+	if ratio == 0 {
+		ratio = storage.DefaultGCFreeRatio
+	}
+
+	dbs, err := dbMgr.CompactDbs(ratio)
+	if err != nil {
+		return nil, err
+	}
+	if appdb != nil {
+		res, err := storage.RunStoreGC("appdb", appdb.Db, ratio)
+		if err != nil {
+			return nil, err
+		}
+		dbs = append(dbs, res)
+	}
+
+	result := &StorageGCResult{Dbs: dbs}
+	for _, res := range dbs {
+		result.Reclaimed += res.Reclaimed
+	}
+	if result.Reclaimed > 0 {
+		resetNodeStorageCache()
+	}
+	return result, nil
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/rumsystem/quorum/internal/pkg/options"
+	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
+)
+
+type CreateScopedTokenParam struct {
+	Name      string    `json:"name" validate:"required" example:"dashboard"`
+	Scope     string    `json:"scope" validate:"required,oneof=read publish admin" example:"read"`
+	ExpiresAt time.Time `json:"expires_at" validate:"required" example:"2023-12-28T08:10:36.675204+00:00"`
+	Password  string    `json:"password" validate:"required" example:"my.Passw0rd"` // keystore password of the node
+}
+
+type CreateScopedTokenResult struct {
+	Token     string    `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	Scope     string    `json:"scope" example:"read"`
+	ExpiresAt time.Time `json:"expires_at" example:"2023-12-28T08:10:36.675204+00:00"`
+}
+
+// CreateScopedToken mints a chain jwt limited to the scope, the caller proves to own the node by the keystore password
+func CreateScopedToken(ks localcrypto.Keystore, nodeoptions *options.NodeOptions, params *CreateScopedTokenParam) (*CreateScopedTokenResult, error) {
+	dirks, err := getDirKeyStore(ks)
+	if err != nil {
+		return nil, err
+	}
+	if !dirks.VerifyPassword(params.Password) {
+		return nil, errors.New("incorrect keystore password")
+	}
+	if !params.ExpiresAt.After(time.Now()) {
+		return nil, errors.New("expires_at should be in the future")
+	}
+
+	token, err := nodeoptions.NewScopedChainJWT(params.Name, params.Scope, params.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CreateScopedTokenResult{Token: token, Scope: params.Scope, ExpiresAt: params.ExpiresAt}, nil
+}
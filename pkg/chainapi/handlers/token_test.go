@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/rumsystem/quorum/internal/pkg/utils"
+	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
+)
+
+func TestCreateScopedToken(t *testing.T) {
+	nodeoptions := getTestNodeOptions(t)
+	ks, _, err := localcrypto.InitDirKeyStore("tokentest", t.TempDir())
+	if err != nil {
+		t.Fatalf("init keystore failed: %s", err)
+	}
+	ks.Unlock(nodeoptions.SignKeyMap, "my.Passw0rd")
+
+	exp := time.Now().Add(time.Hour)
+	if _, err := CreateScopedToken(ks, nodeoptions, &CreateScopedTokenParam{Name: "dashboard", Scope: utils.JWTScopeRead, ExpiresAt: exp, Password: "wrong"}); err == nil {
+		t.Errorf("expect error for wrong password")
+	}
+	if _, err := CreateScopedToken(ks, nodeoptions, &CreateScopedTokenParam{Name: "dashboard", Scope: utils.JWTScopeRead, ExpiresAt: time.Now().Add(-time.Hour), Password: "my.Passw0rd"}); err == nil {
+		t.Errorf("expect error for expired token")
+	}
+
+	res, err := CreateScopedToken(ks, nodeoptions, &CreateScopedTokenParam{Name: "dashboard", Scope: utils.JWTScopeRead, ExpiresAt: exp, Password: "my.Passw0rd"})
+	if err != nil {
+		t.Fatalf("CreateScopedToken failed: %s", err)
+	}
+	token, err := utils.ParseJWTToken(res.Token, nodeoptions.JWT.Key)
+	if err != nil {
+		t.Fatalf("parse token failed: %s", err)
+	}
+	if scope := token.Claims.(jwt.MapClaims)["scope"]; scope != utils.JWTScopeRead {
+		t.Errorf("expect scope %s, got %v", utils.JWTScopeRead, scope)
+	}
+	if !nodeoptions.IsValidChainJWT(res.Token) {
+		t.Errorf("expect valid chain jwt")
+	}
+	if err := nodeoptions.RevokeChainJWT(res.Token); err != nil {
+		t.Fatalf("revoke token failed: %s", err)
+	}
+	if nodeoptions.IsValidChainJWT(res.Token) {
+		t.Errorf("expect revoked jwt to be invalid")
+	}
+}
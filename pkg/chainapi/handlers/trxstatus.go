@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
+)
+
+const (
+	TRX_STATUS_QUEUED   = "QUEUED"   // the last send failed, waiting to be resent
+	TRX_STATUS_SENT     = "SENT"     // sent, not included in a block yet
+	TRX_STATUS_INCLUDED = "INCLUDED" // included in a block and applied
+	TRX_STATUS_FAILED   = "FAILED"   // retries exhausted, in dead-letter
+	TRX_STATUS_UNKNOWN  = "UNKNOWN"  // neither queued nor applied by this node
+)
+
+type TrxStatusResult struct {
+	GroupId  string `json:"group_id" example:"ac0eea7c-2f3c-4c67-80b3-136e46b924a8"`
+	TrxId    string `json:"trx_id" example:"9e54c173-c1dd-429d-91fa-a6b43c14da77"`
+	Status   string `json:"status" example:"INCLUDED"`
+	BlockId  uint64 `json:"block_id,omitempty" example:"1024"` // the including block, unknown for the trxs applied before the blocks were indexed
+	Epoch    uint64 `json:"epoch,omitempty" example:"1024"`
+	Depth    uint64 `json:"depth,omitempty" example:"3"`
+	Attempts int    `json:"attempts,omitempty" example:"1"` // sends of a queued trx
+	// unix nano of the next resend of a queued trx
+	NextRetry int64  `json:"next_retry,omitempty" example:"1663900010000000000"`
+	Reason    string `json:"reason,omitempty"` // the last send error
+}
+
+// GetTrxStatus tells what happened to a trx, from the publish queue, the recent blocks and the chain storage
+func GetTrxStatus(groupId string, trxId string) (*TrxStatusResult, error) {
+	group, err := getGroup(groupId)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &TrxStatusResult{GroupId: groupId, TrxId: trxId, Status: TRX_STATUS_UNKNOWN}
+	if conf := group.ChainCtx.GetTrxConfirmation(trxId); conf != nil {
+		setIncluded(res, conf)
+		return res, nil
+	}
+
+	if entry := group.ChainCtx.GetPublishEntry(trxId); entry != nil {
+		setQueued(res, entry)
+		return res, nil
+	}
+
+	// applied long ago or sent by another node, the block is looked up from the chain storage
+	conf, err := group.ChainCtx.GetTrxBlock(trxId)
+	if err != nil {
+		return nil, err
+	}
+	if conf != nil {
+		setIncluded(res, conf)
+		return res, nil
+	}
+	trx, err := group.GetTrx(trxId)
+	if err != nil {
+		return nil, err
+	}
+	if trx != nil && trx.TrxId == trxId {
+		res.Status = TRX_STATUS_INCLUDED
+	}
+	return res, nil
+}
+
+func setIncluded(res *TrxStatusResult, conf *chain.TrxConfirmation) {
+	res.Status = TRX_STATUS_INCLUDED
+	res.BlockId = conf.BlockId
+	res.Epoch = conf.Epoch
+	res.Depth = conf.Depth
+}
+
+// setQueued sets the status of a trx in the publish queue, a trx being resent has no error
+func setQueued(res *TrxStatusResult, entry *chain.PublishEntry) {
+	res.Attempts = entry.Attempts
+	res.Reason = entry.LastError
+	switch {
+	case entry.State == chain.PUBQUEUE_DEAD:
+		res.Status = TRX_STATUS_FAILED
+	case entry.LastError != "":
+		res.Status = TRX_STATUS_QUEUED
+		res.NextRetry = entry.NextRetry.UnixNano()
+	default:
+		res.Status = TRX_STATUS_SENT
+		res.NextRetry = entry.NextRetry.UnixNano()
+	}
+}
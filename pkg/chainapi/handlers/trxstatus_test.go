@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
+)
+
+func TestTrxStatusOfQueued(t *testing.T) {
+	next := time.Unix(1663900010, 0)
+	cases := []struct {
+		name   string
+		entry  chain.PublishEntry
+		status string
+		reason string
+		retry  int64
+	}{
+		{"sent", chain.PublishEntry{State: chain.PUBQUEUE_PENDING, Attempts: 1, NextRetry: next}, TRX_STATUS_SENT, "", next.UnixNano()},
+		{"failed send", chain.PublishEntry{State: chain.PUBQUEUE_PENDING, Attempts: 2, NextRetry: next, LastError: "no peer"}, TRX_STATUS_QUEUED, "no peer", next.UnixNano()},
+		{"dead-letter", chain.PublishEntry{State: chain.PUBQUEUE_DEAD, Attempts: 5, NextRetry: next, LastError: "no peer"}, TRX_STATUS_FAILED, "no peer", 0},
+	}
+	for _, c := range cases {
+		res := &TrxStatusResult{}
+		setQueued(res, &c.entry)
+		if res.Status != c.status || res.Reason != c.reason || res.NextRetry != c.retry || res.Attempts != c.entry.Attempts {
+			t.Errorf("%s: unexpected status %+v", c.name, res)
+		}
+	}
+}
+
+func TestTrxStatusOfIncluded(t *testing.T) {
+	res := &TrxStatusResult{Status: TRX_STATUS_UNKNOWN}
+	setIncluded(res, &chain.TrxConfirmation{BlockId: 7, Epoch: 6, Depth: 3})
+	if res.Status != TRX_STATUS_INCLUDED || res.BlockId != 7 || res.Epoch != 6 || res.Depth != 3 {
+		t.Errorf("unexpected status %+v", res)
+	}
+}
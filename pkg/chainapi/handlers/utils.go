@@ -41,3 +41,88 @@ func initEncryptKey(groupId string, bks localcrypto.Keystore) (string, error) {
 	}
 	return userEncryptKey, nil
 }
+
+// BindGroupKey makes the group sign and encrypt with the keys of keyalias instead of keys of its own,
+// so that groups joined with different aliases show different identities. keyalias may also be a key name.
+func BindGroupKey(groupId, keyalias string, ks localcrypto.Keystore, nodeoptions *options.NodeOptions) error {
+	dirks, ok := ks.(*localcrypto.DirKeyStore)
+	if !ok {
+		return fmt.Errorf("unknown keystore type %v", ks)
+	}
+	keyname := dirks.AliasToKeyname(keyalias)
+	if keyname == "" {
+		exist, err := dirks.IfKeyExist(localcrypto.Sign.NameString(keyalias))
+		if err != nil {
+			return err
+		}
+		if !exist {
+			return fmt.Errorf("The key alias %s is not exist", keyalias)
+		}
+		keyname = keyalias
+	}
+
+	pubkey, err := dirks.GetEncodedPubkey(keyname, localcrypto.Sign)
+	if err != nil {
+		return err
+	}
+	exist, err := dirks.IfKeyExist(localcrypto.Sign.NameString(groupId))
+	if err != nil {
+		return err
+	}
+	if exist {
+		grouppubkey, err := dirks.GetEncodedPubkey(groupId, localcrypto.Sign)
+		if err != nil {
+			return err
+		}
+		if grouppubkey != pubkey {
+			return fmt.Errorf("group %s already has a different sign key", groupId)
+		}
+		return nil
+	}
+
+	addr, err := dirks.CopyKey(keyname, groupId, localcrypto.Sign)
+	if err != nil {
+		return err
+	}
+	if err := nodeoptions.SetSignKeyMap(groupId, addr); err != nil {
+		RemoveGroupKeys(groupId, ks, nodeoptions)
+		return fmt.Errorf("save key map %s err: %s", addr, err)
+	}
+	// a key without encrypt key leaves the group to create its own
+	if _, err := dirks.GetEncodedPubkey(keyname, localcrypto.Encrypt); err == nil {
+		if _, err := dirks.CopyKey(keyname, groupId, localcrypto.Encrypt); err != nil {
+			RemoveGroupKeys(groupId, ks, nodeoptions)
+			return err
+		}
+	}
+	return nil
+}
+
+// groupKeyExist reports whether the sign key of the group is in the keystore
+func groupKeyExist(groupId string, ks localcrypto.Keystore) (bool, error) {
+	dirks, ok := ks.(*localcrypto.DirKeyStore)
+	if !ok {
+		return false, fmt.Errorf("unknown keystore type %v", ks)
+	}
+	return dirks.IfKeyExist(localcrypto.Sign.NameString(groupId))
+}
+
+// RemoveGroupKeys removes the sign and encrypt keys of the group and its sign key map entry, the keys they were copied from are kept
+func RemoveGroupKeys(groupId string, ks localcrypto.Keystore, nodeoptions *options.NodeOptions) error {
+	dirks, ok := ks.(*localcrypto.DirKeyStore)
+	if !ok {
+		return fmt.Errorf("unknown keystore type %v", ks)
+	}
+	for _, keytype := range []localcrypto.KeyType{localcrypto.Sign, localcrypto.Encrypt} {
+		exist, err := dirks.IfKeyExist(keytype.NameString(groupId))
+		if err != nil {
+			return err
+		}
+		if exist {
+			if err := dirks.RemoveKey(groupId, keytype); err != nil {
+				return err
+			}
+		}
+	}
+	return nodeoptions.DelSignKeyMap(groupId)
+}
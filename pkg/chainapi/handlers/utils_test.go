@@ -2,6 +2,10 @@ package handlers
 
 import (
 	"testing"
+
+	"github.com/rumsystem/quorum/internal/pkg/options"
+	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
+	"github.com/spf13/viper"
 )
 
 func TestUrlToGroupSeed(t *testing.T) {
@@ -18,3 +22,74 @@ func TestUrlToGroupSeed(t *testing.T) {
 	//verify
 	*/
 }
+
+// getTestNodeOptions inits the node options in a temp dir of the test, the global viper is reset
+// so that the config paths of other tests are not searched
+func getTestNodeOptions(t *testing.T) *options.NodeOptions {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	nodeoptions, err := options.InitNodeOptions(t.TempDir(), "handlerstest")
+	if err != nil {
+		t.Fatalf("init node options failed: %s", err)
+	}
+	return nodeoptions
+}
+
+func TestBindGroupKey(t *testing.T) {
+	nodeoptions := getTestNodeOptions(t)
+	ks, _, err := localcrypto.InitDirKeyStore("bindkeytest", t.TempDir())
+	if err != nil {
+		t.Fatalf("init keystore failed: %s", err)
+	}
+	ks.Unlock(nodeoptions.SignKeyMap, "my.Passw0rd")
+	addr, err := ks.NewKeyWithDefaultPassword("identity", localcrypto.Sign)
+	if err != nil {
+		t.Fatalf("new key failed: %s", err)
+	}
+	if err := nodeoptions.SetSignKeyMap("identity", addr); err != nil {
+		t.Fatalf("set sign key map failed: %s", err)
+	}
+	if err := ks.NewAlias("my_identity", "identity", "my.Passw0rd"); err != nil {
+		t.Fatalf("new alias failed: %s", err)
+	}
+
+	groupA, groupB := "7ddb0a47-5bf8-4a2b-9b3c-1f1c3f4f0a01", "7ddb0a47-5bf8-4a2b-9b3c-1f1c3f4f0a02"
+	for _, groupId := range []string{groupA, groupB} {
+		if err := BindGroupKey(groupId, "my_identity", ks, nodeoptions); err != nil {
+			t.Fatalf("bind group key failed: %s", err)
+		}
+	}
+	pubkey, _ := ks.GetEncodedPubkey("identity", localcrypto.Sign)
+	for _, groupId := range []string{groupA, groupB} {
+		if grouppubkey, err := initSignKey(groupId, ks, nodeoptions); err != nil || grouppubkey != pubkey {
+			t.Errorf("group %s should sign with the alias key, got %s, %v", groupId, grouppubkey, err)
+		}
+	}
+
+	// a group without alias gets a key of its own
+	other, err := initSignKey("7ddb0a47-5bf8-4a2b-9b3c-1f1c3f4f0a03", ks, nodeoptions)
+	if err != nil || other == pubkey {
+		t.Errorf("expect a new identity for group without alias, got %s, %v", other, err)
+	}
+
+	if err := BindGroupKey(groupA, "unknown", ks, nodeoptions); err == nil {
+		t.Errorf("expect error for unknown alias")
+	}
+
+	// the copies are removed, the alias key is kept
+	if err := RemoveGroupKeys(groupB, ks, nodeoptions); err != nil {
+		t.Fatalf("remove group keys failed: %s", err)
+	}
+	if exist, _ := groupKeyExist(groupB, ks); exist {
+		t.Errorf("sign key of group %s should be removed", groupB)
+	}
+	if _, err := ks.GetKeyFromUnlocked(localcrypto.Sign.NameString(groupB)); err == nil {
+		t.Errorf("unlocked key of group %s should be removed", groupB)
+	}
+	if _, ok := nodeoptions.SignKeyMap[groupB]; ok {
+		t.Errorf("sign key map of group %s should be removed", groupB)
+	}
+	if p, err := ks.GetEncodedPubkey("identity", localcrypto.Sign); err != nil || p != pubkey {
+		t.Errorf("alias key should be kept, got %s, %v", p, err)
+	}
+}
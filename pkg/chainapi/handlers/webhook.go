@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	guuid "github.com/google/uuid"
+	"github.com/rumsystem/quorum/internal/pkg/appdata"
+	chain "github.com/rumsystem/quorum/internal/pkg/chainsdk/core"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+type AddWebhookParam struct {
+	GroupId  string   `param:"group_id" json:"-" validate:"required,uuid4" example:"ac0eea7c-2f3c-4c67-80b3-136e46b924a8"`
+	Url      string   `json:"url" validate:"required,url" example:"https://example.com/quorum/hook"`
+	Secret   string   `json:"secret" example:"s3cret"`  // used to sign the payload with HMAC-SHA256
+	TrxTypes []string `json:"trx_types" example:"POST"` // deliver only these trx types, empty for all
+}
+
+type WebhookParam struct {
+	GroupId   string `param:"group_id" validate:"required,uuid4" example:"ac0eea7c-2f3c-4c67-80b3-136e46b924a8"`
+	WebhookId string `param:"webhook_id" validate:"required,uuid4" example:"22d5c38d-5921-4b75-8562-c110dcfd5ee8"`
+}
+
+type WebhookListResult struct {
+	GroupId  string             `json:"group_id"`
+	Webhooks []*appdata.Webhook `json:"webhooks"`
+}
+
+// AddWebhook registers a webhook which receives the trxs of blocks applied from now on
+func AddWebhook(params *AddWebhookParam, appdb *appdata.AppDb) (*appdata.Webhook, error) {
+	if _, ok := chain.GetGroupMgr().Groups[params.GroupId]; !ok {
+		return nil, fmt.Errorf("Group %s not exist", params.GroupId)
+	}
+	for _, t := range params.TrxTypes {
+		if _, ok := quorumpb.TrxType_value[t]; !ok {
+			return nil, fmt.Errorf("unknown trx type %s", t)
+		}
+	}
+
+	var lastBlock uint64
+	synced, err := appdb.GetGroupStatus(params.GroupId, "Block")
+	if err != nil {
+		return nil, err
+	}
+	if synced != "" {
+		if lastBlock, err = strconv.ParseUint(synced, 10, 64); err != nil {
+			return nil, err
+		}
+	}
+
+	// the caught up event is only delivered to the webhooks added before the group is caught up
+	caughtUp, err := appdb.GetGroupCaughtUp(params.GroupId)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &appdata.Webhook{
+		Id:           guuid.NewString(),
+		GroupId:      params.GroupId,
+		Url:          params.Url,
+		Secret:       params.Secret,
+		TrxTypes:     params.TrxTypes,
+		LastBlock:    lastBlock,
+		CaughtUpSent: caughtUp != nil,
+		CreatedAt:    time.Now().UnixNano(),
+	}
+	if err := appdb.SetWebhook(w); err != nil {
+		return nil, err
+	}
+
+	return hideWebhookSecret(w), nil
+}
+
+func GetWebhooks(groupId string, appdb *appdata.AppDb) (*WebhookListResult, error) {
+	webhooks, err := appdb.GetWebhooks(groupId)
+	if err != nil {
+		return nil, err
+	}
+	for i, w := range webhooks {
+		webhooks[i] = hideWebhookSecret(w)
+	}
+	return &WebhookListResult{GroupId: groupId, Webhooks: webhooks}, nil
+}
+
+func RemoveWebhook(params *WebhookParam, appdb *appdata.AppDb) (*appdata.Webhook, error) {
+	w, err := appdb.GetWebhook(params.GroupId, params.WebhookId)
+	if err != nil {
+		return nil, err
+	}
+	if w == nil {
+		return nil, fmt.Errorf("webhook %s not exist", params.WebhookId)
+	}
+	if err := appdb.DelWebhook(params.GroupId, params.WebhookId); err != nil {
+		return nil, err
+	}
+	return hideWebhookSecret(w), nil
+}
+
+func hideWebhookSecret(w *appdata.Webhook) *appdata.Webhook {
+	res := *w
+	res.Secret = ""
+	return &res
+}
@@ -1,9 +1,11 @@
 package consensus
 
 type Config struct {
-	N         int      // participating nodes
-	f         int      // faulty nodes
-	Nodes     []string // pubkey list for all partticipating nodes
-	BatchSize int      // maximum number of trxs will be commited in one epoch
-	MyPubkey  string   // my pubkey
+	N               int      // participating nodes
+	f               int      // faulty nodes
+	Nodes           []string // pubkey list for all partticipating nodes
+	BatchSize       int      // maximum number of trxs will be commited in one epoch
+	MyPubkey        string   // my pubkey
+	ProposeInterval int      // milliseconds to wait before each propose round
+	ProposeTimeout  int      // milliseconds to wait for a propose round, 0 means no timeout
 }
@@ -0,0 +1,46 @@
+package consensus
+
+import (
+	"encoding/json"
+
+	"github.com/rumsystem/quorum/internal/pkg/nodectx"
+	"github.com/rumsystem/quorum/pkg/consensus/def"
+)
+
+var DEFAULT_PROPOSE_TIMEOUT = 0 // no timeout
+
+// GetConsensusConfig returns the consensus config of group, defaults are used if not set
+func GetConsensusConfig(groupId string) (*def.ConsensusConfig, error) {
+	cfg := &def.ConsensusConfig{}
+	data, err := nodectx.GetNodeCtx().GetChainStorage().GetGroupConsensusConfig(groupId)
+	if err != nil {
+		return nil, err
+	}
+	if data != nil {
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	return withDefaultConsensusConfig(cfg), nil
+}
+
+// SetConsensusConfig saves the consensus config of group, the producer should RecreateBft to apply it
+func SetConsensusConfig(groupId string, cfg *def.ConsensusConfig) error {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	return nodectx.GetNodeCtx().GetChainStorage().SetGroupConsensusConfig(groupId, data)
+}
+
+func withDefaultConsensusConfig(cfg *def.ConsensusConfig) *def.ConsensusConfig {
+	result := *cfg
+	if result.ProposeInterval <= 0 {
+		result.ProposeInterval = DEFAULT_PROPOSE_PULSE
+	}
+	if result.ProposeTimeout <= 0 {
+		result.ProposeTimeout = DEFAULT_PROPOSE_TIMEOUT
+	}
+	return &result
+}
@@ -0,0 +1,19 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/rumsystem/quorum/pkg/consensus/def"
+)
+
+func TestWithDefaultConsensusConfig(t *testing.T) {
+	cfg := withDefaultConsensusConfig(&def.ConsensusConfig{})
+	if cfg.ProposeInterval != DEFAULT_PROPOSE_PULSE || cfg.ProposeTimeout != DEFAULT_PROPOSE_TIMEOUT {
+		t.Errorf("unexpected default config: %+v", cfg)
+	}
+
+	cfg = withDefaultConsensusConfig(&def.ConsensusConfig{ProposeInterval: 5000, ProposeTimeout: 60000})
+	if cfg.ProposeInterval != 5000 || cfg.ProposeTimeout != 60000 {
+		t.Errorf("config should not be overridden: %+v", cfg)
+	}
+}
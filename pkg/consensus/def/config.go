@@ -0,0 +1,7 @@
+package def
+
+// ConsensusConfig is the per group options of consensus
+type ConsensusConfig struct {
+	ProposeInterval int `json:"propose_interval" validate:"gte=0" example:"1000"` // milliseconds to wait before each propose round, 0 means default
+	ProposeTimeout  int `json:"propose_timeout" validate:"gte=0" example:"30000"` // milliseconds to wait for a propose round before starting a new one, 0 means no timeout
+}
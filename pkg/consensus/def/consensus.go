@@ -4,7 +4,11 @@ type Consensus interface {
 	Name() string
 	Producer() Producer
 	User() User
+	SnapshotSender() SnapshotSender
+	SnapshotReceiver() SnapshotReceiver
 	SetProducer(p Producer)
 	SetUser(u User)
+	SetSnapshotSender(s SnapshotSender)
+	SetSnapshotReceiver(r SnapshotReceiver)
 	StartPropose()
 }
@@ -0,0 +1,25 @@
+package def
+
+// HBProducerStats is the heartbeat state of a producer seen by the local producer
+type HBProducerStats struct {
+	Pubkey   string `json:"pubkey" example:"AgPnM0Pgg2zr71R4B8Ha2ueoF8l2iEzfZNZJVbhVbHy8"`
+	LastSeen int64  `json:"last_seen" example:"1665648000000000000"`
+	Count    uint64 `json:"count" example:"120"`
+	Missed   bool   `json:"missed" example:"false"` // true if no heartbeat is received beyond the threshold
+}
+
+// HBStats is the heartbeat state of a group
+type HBStats struct {
+	Epoch        uint64             `json:"epoch" example:"100"` // epoch of the last received heartbeat
+	Count        uint64             `json:"count" example:"360"`
+	LastReceived int64              `json:"last_received" example:"1665648000000000000"`
+	LastInterval float64            `json:"last_interval"` // milliseconds between the last two heartbeats
+	AvgInterval  float64            `json:"avg_interval"`  // milliseconds
+	MaxInterval  float64            `json:"max_interval"`  // milliseconds
+	Producers    []*HBProducerStats `json:"producers"`
+}
+
+// HBStatsReporter is implemented by producers which record heartbeat stats
+type HBStatsReporter interface {
+	HBStats() *HBStats
+}
@@ -0,0 +1,30 @@
+package def
+
+import (
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+// Snapshot is the chain state of a group at a block, signed by a producer
+type Snapshot struct {
+	GroupId      string                   `json:"group_id"`
+	BlockId      uint64                   `json:"block_id"`
+	Epoch        uint64                   `json:"epoch"`
+	Block        *quorumpb.Block          `json:"block"`     // the block at BlockId, the chain continues from it
+	Producers    []*quorumpb.ProducerItem `json:"producers"` // producers at BlockId
+	TimeStamp    int64                    `json:"timestamp"`
+	SignerPubkey string                   `json:"signer_pubkey"`
+	Signature    []byte                   `json:"signature"`
+}
+
+// SnapshotSender creates snapshots periodically
+type SnapshotSender interface {
+	NewSnapshotSender(item *quorumpb.GroupItem, nodename string, iface ChainMolassesIface, interval uint64)
+	OnBlock(block *quorumpb.Block) error // called when a block is added to the chain
+	GetSnapshot() *Snapshot              // the latest snapshot, nil if not created
+}
+
+// SnapshotReceiver verifies a snapshot and fast-forwards the chain to it
+type SnapshotReceiver interface {
+	NewSnapshotReceiver(item *quorumpb.GroupItem, nodename string, iface ChainMolassesIface)
+	ApplySnapshot(snapshot *Snapshot) error
+}
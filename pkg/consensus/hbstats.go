@@ -0,0 +1,206 @@
+package consensus
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rumsystem/quorum/internal/pkg/metric"
+	"github.com/rumsystem/quorum/pkg/consensus/def"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// HBMissIntervals is the number of heartbeat intervals without heartbeat after which a producer is considered missed
+var HBMissIntervals = 3
+
+type hbProducer struct {
+	lastSeen time.Time
+	count    uint64
+	missed   bool
+}
+
+// hbRecorder records the heartbeat timing of a group, a ticker marks the producers missed while any is heard from
+type hbRecorder struct {
+	groupId string
+
+	mu            sync.Mutex
+	interval      time.Duration // expected heartbeat interval, the propose interval of the group
+	ticking       bool
+	epoch         uint64
+	count         uint64
+	lastReceived  time.Time
+	lastInterval  time.Duration
+	totalInterval time.Duration
+	maxInterval   time.Duration
+	producers     map[string]*hbProducer
+}
+
+func newHBRecorder(groupId string, interval time.Duration) *hbRecorder {
+	return &hbRecorder{groupId: groupId, interval: interval, producers: map[string]*hbProducer{}}
+}
+
+// SetInterval updates the expected heartbeat interval, it is applied by the next tick
+func (r *hbRecorder) SetInterval(interval time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interval = interval
+}
+
+// threshold is the duration without heartbeat after which a producer is missed, caller must hold the lock
+func (r *hbRecorder) threshold() time.Duration {
+	return time.Duration(HBMissIntervals) * r.interval
+}
+
+// Record updates the stats with a received heartbeat and warns about missed heartbeats
+func (r *hbRecorder) Record(hbmsg *quorumpb.HBMsgv1, now time.Time) {
+	sender := getHBSender(hbmsg)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.lastReceived.IsZero() {
+		r.lastInterval = now.Sub(r.lastReceived)
+		r.totalInterval += r.lastInterval
+		if r.lastInterval > r.maxInterval {
+			r.maxInterval = r.lastInterval
+		}
+		if r.lastInterval > r.threshold() {
+			molaproducer_log.Warningf("heartbeat missed: group_id=%s interval=%s threshold=%s epoch=%d", r.groupId, r.lastInterval, r.threshold(), hbmsg.Epoch)
+		}
+		metric.HeartbeatInterval.WithLabelValues(r.groupId).Set(r.lastInterval.Seconds())
+	}
+	metric.HeartbeatTotal.WithLabelValues(r.groupId).Inc()
+	r.count++
+	r.lastReceived = now
+	if hbmsg.Epoch > r.epoch {
+		r.epoch = hbmsg.Epoch
+	}
+
+	if sender != "" {
+		p, ok := r.producers[sender]
+		if !ok {
+			p = &hbProducer{}
+			r.producers[sender] = p
+		}
+		if p.missed {
+			molaproducer_log.Infof("heartbeat recovered: group_id=%s producer=%s silent=%s", r.groupId, sender, now.Sub(p.lastSeen))
+		}
+		p.lastSeen = now
+		p.count++
+		p.missed = false
+	}
+	r.checkMissed(now)
+
+	if !r.ticking && r.interval > 0 {
+		r.ticking = true
+		go r.tick()
+	}
+}
+
+// tick checks the missed producers every heartbeat interval, it stops once all producers are missed
+// and is started again by the next heartbeat, so that a left group does not keep it running
+func (r *hbRecorder) tick() {
+	r.mu.Lock()
+	interval := r.interval
+	r.mu.Unlock()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		r.mu.Lock()
+		if r.checkMissed(now) == 0 {
+			r.ticking = false
+			r.mu.Unlock()
+			return
+		}
+		if r.interval != interval && r.interval > 0 {
+			interval = r.interval
+			ticker.Reset(interval)
+		}
+		r.mu.Unlock()
+	}
+}
+
+// checkMissed warns once for each producer without heartbeat beyond the threshold,
+// it returns the number of producers not missed, caller must hold the lock
+func (r *hbRecorder) checkMissed(now time.Time) int {
+	alive := 0
+	for pubkey, p := range r.producers {
+		if p.missed {
+			continue
+		}
+		if now.Sub(p.lastSeen) <= r.threshold() {
+			alive++
+			continue
+		}
+		p.missed = true
+		molaproducer_log.Warningf("heartbeat missed: group_id=%s producer=%s last_seen=%s threshold=%s", r.groupId, pubkey, p.lastSeen.Format(time.RFC3339), r.threshold())
+	}
+	return alive
+}
+
+// Stats returns a copy of the current stats
+func (r *hbRecorder) Stats(now time.Time) *def.HBStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checkMissed(now)
+	stats := &def.HBStats{
+		Epoch:        r.epoch,
+		Count:        r.count,
+		LastInterval: durationToMs(r.lastInterval),
+		MaxInterval:  durationToMs(r.maxInterval),
+		Producers:    []*def.HBProducerStats{},
+	}
+	if !r.lastReceived.IsZero() {
+		stats.LastReceived = r.lastReceived.UnixNano()
+	}
+	if r.count > 1 {
+		stats.AvgInterval = durationToMs(r.totalInterval / time.Duration(r.count-1))
+	}
+	for pubkey, p := range r.producers {
+		stats.Producers = append(stats.Producers, &def.HBProducerStats{
+			Pubkey:   pubkey,
+			LastSeen: p.lastSeen.UnixNano(),
+			Count:    p.count,
+			Missed:   p.missed,
+		})
+	}
+	sort.Slice(stats.Producers, func(i, j int) bool { return stats.Producers[i].Pubkey < stats.Producers[j].Pubkey })
+
+	return stats
+}
+
+// getHBSender returns the pubkey of the producer who sent the heartbeat, empty if unknown
+func getHBSender(hbmsg *quorumpb.HBMsgv1) string {
+	switch hbmsg.PayloadType {
+	case quorumpb.HBMsgPayloadType_RBC:
+		rbcMsg := &quorumpb.RBCMsg{}
+		if err := proto.Unmarshal(hbmsg.Payload, rbcMsg); err != nil {
+			return ""
+		}
+		switch rbcMsg.Type {
+		case quorumpb.RBCMsgType_INIT_PROPOSE:
+			initp := &quorumpb.InitPropose{}
+			if err := proto.Unmarshal(rbcMsg.Payload, initp); err == nil {
+				return initp.ProposerPubkey
+			}
+		case quorumpb.RBCMsgType_ECHO:
+			echo := &quorumpb.Echo{}
+			if err := proto.Unmarshal(rbcMsg.Payload, echo); err == nil {
+				return echo.EchoProviderPubkey
+			}
+		case quorumpb.RBCMsgType_READY:
+			ready := &quorumpb.Ready{}
+			if err := proto.Unmarshal(rbcMsg.Payload, ready); err == nil {
+				return ready.ReadyProviderPubkey
+			}
+		}
+	}
+	return ""
+}
+
+func durationToMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
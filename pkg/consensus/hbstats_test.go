@@ -0,0 +1,83 @@
+package consensus
+
+import (
+	"testing"
+	"time"
+
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+func newTestHBMsg(t *testing.T, epoch uint64, proposer string) *quorumpb.HBMsgv1 {
+	initp, err := proto.Marshal(&quorumpb.InitPropose{ProposerPubkey: proposer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rbc, err := proto.Marshal(&quorumpb.RBCMsg{Type: quorumpb.RBCMsgType_INIT_PROPOSE, Payload: initp})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &quorumpb.HBMsgv1{Epoch: epoch, PayloadType: quorumpb.HBMsgPayloadType_RBC, Payload: rbc}
+}
+
+func TestHBRecorder(t *testing.T) {
+	r := newHBRecorder("g1", 10*time.Second)
+	now := time.Now()
+
+	r.Record(newTestHBMsg(t, 1, "p1"), now)
+	r.Record(newTestHBMsg(t, 2, "p2"), now.Add(time.Second))
+	r.Record(newTestHBMsg(t, 2, "p2"), now.Add(3*time.Second))
+
+	stats := r.Stats(now.Add(3 * time.Second))
+	if stats.Count != 3 || stats.Epoch != 2 {
+		t.Errorf("unexpected count %d or epoch %d", stats.Count, stats.Epoch)
+	}
+	if stats.LastInterval != 2000 || stats.MaxInterval != 2000 || stats.AvgInterval != 1500 {
+		t.Errorf("unexpected intervals: last %f max %f avg %f", stats.LastInterval, stats.MaxInterval, stats.AvgInterval)
+	}
+	if len(stats.Producers) != 2 || stats.Producers[0].Pubkey != "p1" || stats.Producers[1].Count != 2 {
+		t.Fatalf("unexpected producers: %+v", stats.Producers)
+	}
+
+	// p1 drops out
+	threshold := time.Duration(HBMissIntervals) * 10 * time.Second
+	stats = r.Stats(now.Add(threshold + time.Second))
+	if !stats.Producers[0].Missed || stats.Producers[1].Missed {
+		t.Errorf("only p1 should be missed: %+v %+v", stats.Producers[0], stats.Producers[1])
+	}
+
+	// p1 comes back
+	r.Record(newTestHBMsg(t, 3, "p1"), now.Add(threshold+2*time.Second))
+	stats = r.Stats(now.Add(threshold + 2*time.Second))
+	if stats.Producers[0].Missed {
+		t.Errorf("p1 should be recovered")
+	}
+}
+
+func TestHBRecorderTicker(t *testing.T) {
+	r := newHBRecorder("g1", 10*time.Millisecond)
+	r.Record(newTestHBMsg(t, 1, "p1"), time.Now())
+
+	// the ticker marks p1 missed without any further heartbeat or stats query, then stops
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		r.mu.Lock()
+		missed, ticking := r.producers["p1"].missed, r.ticking
+		r.mu.Unlock()
+		if missed && !ticking {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("p1 is not marked missed by the ticker: missed %v ticking %v", missed, ticking)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// the next heartbeat starts the ticker again
+	r.Record(newTestHBMsg(t, 2, "p1"), time.Now())
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.producers["p1"].missed || !r.ticking {
+		t.Errorf("p1 should be recovered and the ticker restarted")
+	}
+}
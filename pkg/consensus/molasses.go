@@ -5,9 +5,11 @@ import (
 )
 
 type Molasses struct {
-	name     string
-	producer def.Producer
-	user     def.User
+	name             string
+	producer         def.Producer
+	user             def.User
+	snapshotSender   def.SnapshotSender
+	snapshotReceiver def.SnapshotReceiver
 }
 
 func NewMolasses(p def.Producer, u def.User) *Molasses {
@@ -26,6 +28,22 @@ func (m *Molasses) User() def.User {
 	return m.user
 }
 
+func (m *Molasses) SnapshotSender() def.SnapshotSender {
+	return m.snapshotSender
+}
+
+func (m *Molasses) SnapshotReceiver() def.SnapshotReceiver {
+	return m.snapshotReceiver
+}
+
+func (m *Molasses) SetSnapshotSender(s def.SnapshotSender) {
+	m.snapshotSender = s
+}
+
+func (m *Molasses) SetSnapshotReceiver(r def.SnapshotReceiver) {
+	m.snapshotReceiver = r
+}
+
 func (m *Molasses) SetProducer(p def.Producer) {
 	m.producer = p
 }
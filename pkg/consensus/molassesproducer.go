@@ -1,6 +1,8 @@
 package consensus
 
 import (
+	"time"
+
 	"github.com/rumsystem/quorum/internal/pkg/logging"
 	"github.com/rumsystem/quorum/internal/pkg/nodectx"
 	"github.com/rumsystem/quorum/pkg/consensus/def"
@@ -16,6 +18,8 @@ type MolassesProducer struct {
 	cIface   def.ChainMolassesIface
 	groupId  string
 	bft      *TrxBft
+	snapshot def.SnapshotSender
+	hb       *hbRecorder
 }
 
 func (producer *MolassesProducer) NewProducer(item *quorumpb.GroupItem, nodename string, iface def.ChainMolassesIface) {
@@ -24,6 +28,7 @@ func (producer *MolassesProducer) NewProducer(item *quorumpb.GroupItem, nodename
 	producer.cIface = iface
 	producer.nodename = nodename
 	producer.groupId = item.GroupId
+	producer.hb = newHBRecorder(item.GroupId, time.Duration(DEFAULT_PROPOSE_PULSE)*time.Millisecond)
 
 	config, err := producer.createBftConfig()
 	if err != nil {
@@ -31,6 +36,7 @@ func (producer *MolassesProducer) NewProducer(item *quorumpb.GroupItem, nodename
 		molaproducer_log.Error(err.Error())
 		return
 	}
+	producer.hb.SetInterval(time.Duration(config.ProposeInterval) * time.Millisecond)
 	producer.bft = NewTrxBft(*config, producer)
 }
 
@@ -64,7 +70,17 @@ func (producer *MolassesProducer) RecreateBft() {
 		return
 	}
 
+	//stop the running bft before replacing it, buffered trxs are kept in db and proposed by the new one
+	running := producer.bft != nil && producer.bft.Status() == RUNNING
+	if running {
+		producer.bft.StopPropose()
+	}
+
+	producer.hb.SetInterval(time.Duration(config.ProposeInterval) * time.Millisecond)
 	producer.bft = NewTrxBft(*config, producer)
+	if running {
+		producer.bft.StartPropose()
+	}
 }
 
 func (producer *MolassesProducer) createBftConfig() (*Config, error) {
@@ -96,12 +112,20 @@ func (producer *MolassesProducer) createBftConfig() (*Config, error) {
 
 	molaproducer_log.Debugf("batchSize <%d>", batchSize)
 
+	cnsConfig, err := GetConsensusConfig(producer.groupId)
+	if err != nil {
+		return nil, err
+	}
+	molaproducer_log.Debugf("propose interval <%d> ms, propose timeout <%d> ms", cnsConfig.ProposeInterval, cnsConfig.ProposeTimeout)
+
 	config := &Config{
-		N:         N,
-		f:         f,
-		Nodes:     nodes,
-		BatchSize: batchSize,
-		MyPubkey:  producer.grpItem.UserSignPubkey,
+		N:               N,
+		f:               f,
+		Nodes:           nodes,
+		BatchSize:       batchSize,
+		MyPubkey:        producer.grpItem.UserSignPubkey,
+		ProposeInterval: cnsConfig.ProposeInterval,
+		ProposeTimeout:  cnsConfig.ProposeTimeout,
 	}
 
 	return config, nil
@@ -178,6 +202,8 @@ func (producer *MolassesProducer) AddBlock(block *quorumpb.Block) error {
 					return err
 				}
 
+				producer.onBlock(blk)
+
 				if blk.BlockId > producer.cIface.GetCurrBlockId() {
 					//update latest group info
 					molaproducer_log.Debugf("<%s> UpdChainInfo, blockId from <%d> to <%d>",
@@ -240,5 +266,21 @@ func (producer *MolassesProducer) AddTrx(trx *quorumpb.Trx) {
 }
 
 func (producer *MolassesProducer) HandleHBMsg(hbmsg *quorumpb.HBMsgv1) error {
+	producer.hb.Record(hbmsg, time.Now())
 	return producer.bft.HandleMessage(hbmsg)
 }
+
+func (producer *MolassesProducer) HBStats() *def.HBStats {
+	return producer.hb.Stats(time.Now())
+}
+
+// onBlock notify the chain and the snapshot sender that a block is added to the chain
+func (producer *MolassesProducer) onBlock(block *quorumpb.Block) {
+	producer.cIface.OnBlockAdded(block)
+	if producer.snapshot == nil {
+		return
+	}
+	if err := producer.snapshot.OnBlock(block); err != nil {
+		molaproducer_log.Warningf("<%s> create snapshot at block <%d> failed: %s", producer.groupId, block.BlockId, err)
+	}
+}
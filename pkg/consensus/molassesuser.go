@@ -96,6 +96,7 @@ func (user *MolassesUser) AddBlock(block *quorumpb.Block) error {
 				if err != nil {
 					return err
 				}
+				user.cIface.OnBlockAdded(bc)
 
 				if bc.BlockId > user.cIface.GetCurrBlockId() {
 					//update latest group epoch
@@ -0,0 +1,116 @@
+package consensus
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/rumsystem/quorum/internal/pkg/logging"
+	"github.com/rumsystem/quorum/pkg/consensus/def"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+var registry_log = logging.Logger("consensus")
+
+// DefaultConsensus is used if no consensus is specified
+const DefaultConsensus = "molasses"
+
+// Factory creates the consensus of a group, it creates the producer if `withProducer` and the user if `withUser`
+type Factory func(item *quorumpb.GroupItem, nodename string, iface def.ChainMolassesIface, withProducer, withUser bool) def.Consensus
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = map[string]Factory{}
+)
+
+func init() {
+	Register(DefaultConsensus, newMolassesConsensus)
+}
+
+// Register makes a consensus implementation available by the name, it panics if the name is registered twice
+func Register(name string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if factory == nil {
+		panic("consensus: Register factory is nil")
+	}
+	if _, ok := factories[name]; ok {
+		panic("consensus: Register called twice for " + name)
+	}
+	factories[name] = factory
+}
+
+// IsRegistered returns true if the consensus is registered, empty name means the default consensus
+func IsRegistered(name string) bool {
+	if name == "" {
+		return true
+	}
+
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+	_, ok := factories[name]
+	return ok
+}
+
+// Names returns the sorted names of registered consensus
+func Names() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New creates the consensus by the name, empty name means the default consensus
+func New(name string, item *quorumpb.GroupItem, nodename string, iface def.ChainMolassesIface, withProducer, withUser bool) (def.Consensus, error) {
+	if name == "" {
+		name = DefaultConsensus
+	}
+
+	factoriesMu.RLock()
+	factory, ok := factories[name]
+	factoriesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown consensus: %s, registered: %v", name, Names())
+	}
+
+	return factory(item, nodename, iface, withProducer, withUser), nil
+}
+
+func newMolassesConsensus(item *quorumpb.GroupItem, nodename string, iface def.ChainMolassesIface, withProducer, withUser bool) def.Consensus {
+	var user def.User
+	var producer def.Producer
+	var sender def.SnapshotSender
+	var receiver def.SnapshotReceiver
+
+	if withProducer {
+		registry_log.Infof("<%s> Create and initial molasses producer", item.GroupId)
+		molassesProducer := &MolassesProducer{}
+		molassesProducer.NewProducer(item, nodename, iface)
+		if SnapshotInterval > 0 {
+			sender = &MolassesSnapshotSender{}
+			sender.NewSnapshotSender(item, nodename, iface, SnapshotInterval)
+			molassesProducer.snapshot = sender
+		}
+		molassesProducer.StartPropose()
+		producer = molassesProducer
+	}
+
+	if withUser {
+		registry_log.Infof("<%s> Create and initial molasses user", item.GroupId)
+		user = &MolassesUser{}
+		user.NewUser(item, nodename, iface)
+		receiver = &MolassesSnapshotReceiver{}
+		receiver.NewSnapshotReceiver(item, nodename, iface)
+	}
+
+	molasses := NewMolasses(producer, user)
+	molasses.SetSnapshotSender(sender)
+	molasses.SetSnapshotReceiver(receiver)
+	return molasses
+}
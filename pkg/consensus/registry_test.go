@@ -0,0 +1,40 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/rumsystem/quorum/pkg/consensus/def"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+func TestRegistry(t *testing.T) {
+	if !IsRegistered("") || !IsRegistered(DefaultConsensus) {
+		t.Fatalf("default consensus should be registered")
+	}
+
+	Register("test", func(item *quorumpb.GroupItem, nodename string, iface def.ChainMolassesIface, withProducer, withUser bool) def.Consensus {
+		return NewMolasses(nil, nil)
+	})
+	if !IsRegistered("test") {
+		t.Errorf("consensus test should be registered")
+	}
+
+	cons, err := New("test", &quorumpb.GroupItem{}, "default", nil, false, false)
+	if err != nil {
+		t.Fatalf("New failed: %s", err)
+	}
+	if cons.Producer() != nil || cons.User() != nil {
+		t.Errorf("unexpected producer or user")
+	}
+
+	if _, err := New("notexist", &quorumpb.GroupItem{}, "default", nil, false, false); err == nil {
+		t.Errorf("New should fail for unknown consensus")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Register should panic when called twice")
+		}
+	}()
+	Register("test", newMolassesConsensus)
+}
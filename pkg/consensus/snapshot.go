@@ -0,0 +1,257 @@
+package consensus
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/rumsystem/quorum/internal/pkg/logging"
+	"github.com/rumsystem/quorum/internal/pkg/nodectx"
+	"github.com/rumsystem/quorum/pkg/consensus/def"
+	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+var snapshot_log = logging.Logger("snapshot")
+
+// SnapshotInterval is the number of blocks between snapshots, 0 disables snapshot
+var SnapshotInterval uint64 = 1000
+
+// GetSnapshotHash returns the hash of the snapshot without signature
+func GetSnapshotHash(s *def.Snapshot) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(s.GroupId)
+	binary.Write(&buf, binary.BigEndian, s.BlockId)
+	binary.Write(&buf, binary.BigEndian, s.Epoch)
+	binary.Write(&buf, binary.BigEndian, s.TimeStamp)
+	buf.WriteString(s.SignerPubkey)
+
+	opts := proto.MarshalOptions{Deterministic: true}
+	if s.Block != nil {
+		b, err := opts.Marshal(s.Block)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(b)
+	}
+	for _, p := range s.Producers {
+		b, err := opts.Marshal(p)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(b)
+	}
+
+	return localcrypto.Hash(buf.Bytes()), nil
+}
+
+// VerifySnapshot verify the signature of the snapshot and the block in it
+func VerifySnapshot(s *def.Snapshot) error {
+	if s.Block == nil {
+		return errors.New("block not found in snapshot")
+	}
+	if s.Block.GroupId != s.GroupId || s.Block.BlockId != s.BlockId || s.Block.Epoch != s.Epoch {
+		return errors.New("block mismatch with snapshot")
+	}
+	if len(s.Signature) == 0 {
+		return errors.New("snapshot is not signed")
+	}
+
+	hash, err := GetSnapshotHash(s)
+	if err != nil {
+		return err
+	}
+	if err := verifyEthSignature(s.SignerPubkey, hash, s.Signature); err != nil {
+		return fmt.Errorf("invalid snapshot signature: %s", err)
+	}
+
+	return nil
+}
+
+// VerifyProducerItem verify the producer item is signed by the group owner
+func VerifyProducerItem(item *quorumpb.ProducerItem, ownerPubkey string) error {
+	if item.GroupOwnerPubkey != ownerPubkey {
+		return fmt.Errorf("producer %s is not signed by the group owner", item.ProducerPubkey)
+	}
+	sig, err := hex.DecodeString(item.GroupOwnerSign)
+	if err != nil {
+		return fmt.Errorf("decode owner signature of producer %s failed: %s", item.ProducerPubkey, err)
+	}
+
+	var buffer bytes.Buffer
+	buffer.Write([]byte(item.GroupId))
+	buffer.Write([]byte(item.ProducerPubkey))
+	buffer.Write([]byte(item.GroupOwnerPubkey))
+	hash := localcrypto.Hash(buffer.Bytes())
+	if err := verifyEthSignature(ownerPubkey, hash, sig); err != nil {
+		return fmt.Errorf("invalid owner signature of producer %s: %s", item.ProducerPubkey, err)
+	}
+
+	return nil
+}
+
+// verifyEthSignature verify the signature with recovery id by the base64 encoded compressed pubkey
+func verifyEthSignature(pubkey string, hash, sig []byte) error {
+	if len(sig) == 0 {
+		return errors.New("empty signature")
+	}
+	if pk, _ := localcrypto.Libp2pPubkeyToEthBase64(pubkey); pk != "" {
+		pubkey = pk
+	}
+	bytespubkey, err := base64.RawURLEncoding.DecodeString(pubkey)
+	if err != nil {
+		return fmt.Errorf("decode pubkey failed: %s", err)
+	}
+	ethpubkey, err := ethcrypto.DecompressPubkey(bytespubkey)
+	if err != nil {
+		return fmt.Errorf("decompress pubkey failed: %s", err)
+	}
+
+	if !ethcrypto.VerifySignature(ethcrypto.FromECDSAPub(ethpubkey), hash, sig[:len(sig)-1]) { // remove recovery id
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+// MolassesSnapshotSender creates a signed snapshot every `interval` blocks
+type MolassesSnapshotSender struct {
+	grpItem  *quorumpb.GroupItem
+	nodename string
+	cIface   def.ChainMolassesIface
+	interval uint64
+
+	mu       sync.RWMutex
+	snapshot *def.Snapshot
+}
+
+func (sender *MolassesSnapshotSender) NewSnapshotSender(item *quorumpb.GroupItem, nodename string, iface def.ChainMolassesIface, interval uint64) {
+	sender.grpItem = item
+	sender.nodename = nodename
+	sender.cIface = iface
+	sender.interval = interval
+}
+
+func (sender *MolassesSnapshotSender) OnBlock(block *quorumpb.Block) error {
+	if sender.interval == 0 || block.BlockId == 0 || block.BlockId%sender.interval != 0 {
+		return nil
+	}
+
+	producers, err := nodectx.GetNodeCtx().GetChainStorage().GetProducers(block.GroupId, sender.nodename)
+	if err != nil {
+		return err
+	}
+
+	snapshot := &def.Snapshot{
+		GroupId:      block.GroupId,
+		BlockId:      block.BlockId,
+		Epoch:        block.Epoch,
+		Block:        block,
+		Producers:    producers,
+		TimeStamp:    time.Now().UnixNano(),
+		SignerPubkey: sender.grpItem.UserSignPubkey,
+	}
+	hash, err := GetSnapshotHash(snapshot)
+	if err != nil {
+		return err
+	}
+	snapshot.Signature, err = nodectx.GetNodeCtx().Keystore.EthSignByKeyName(block.GroupId, hash, sender.nodename)
+	if err != nil {
+		return fmt.Errorf("sign snapshot failed: %s", err)
+	}
+
+	snapshot_log.Debugf("<%s> create snapshot at block <%d>", block.GroupId, block.BlockId)
+	sender.mu.Lock()
+	sender.snapshot = snapshot
+	sender.mu.Unlock()
+	return nil
+}
+
+func (sender *MolassesSnapshotSender) GetSnapshot() *def.Snapshot {
+	sender.mu.RLock()
+	defer sender.mu.RUnlock()
+	return sender.snapshot
+}
+
+// MolassesSnapshotReceiver accepts snapshots signed by the group owner of the seed, or a producer of the chain
+// which is signed by the owner
+type MolassesSnapshotReceiver struct {
+	grpItem  *quorumpb.GroupItem
+	nodename string
+	cIface   def.ChainMolassesIface
+}
+
+func (receiver *MolassesSnapshotReceiver) NewSnapshotReceiver(item *quorumpb.GroupItem, nodename string, iface def.ChainMolassesIface) {
+	receiver.grpItem = item
+	receiver.nodename = nodename
+	receiver.cIface = iface
+}
+
+func (receiver *MolassesSnapshotReceiver) ApplySnapshot(s *def.Snapshot) error {
+	if s.GroupId != receiver.grpItem.GroupId {
+		return fmt.Errorf("snapshot of group %s mismatch with group %s", s.GroupId, receiver.grpItem.GroupId)
+	}
+	if s.BlockId <= receiver.cIface.GetCurrBlockId() {
+		return fmt.Errorf("snapshot at block %d is not ahead of current block %d", s.BlockId, receiver.cIface.GetCurrBlockId())
+	}
+
+	// the owner comes from the seed, the other producers must carry the owner signature,
+	// so that a producer item saved locally without it is never trusted
+	ownerPubkey := receiver.grpItem.OwnerPubKey
+	chainStorage := nodectx.GetNodeCtx().GetChainStorage()
+	trusted := s.SignerPubkey == ownerPubkey
+	if !trusted {
+		producers, err := chainStorage.GetProducers(s.GroupId, receiver.nodename)
+		if err != nil {
+			return err
+		}
+		for _, p := range producers {
+			if p.ProducerPubkey == s.SignerPubkey && VerifyProducerItem(p, ownerPubkey) == nil {
+				trusted = true
+				break
+			}
+		}
+	}
+	if !trusted {
+		return fmt.Errorf("snapshot signer %s is not the owner or a producer of the group", s.SignerPubkey)
+	}
+	if err := VerifySnapshot(s); err != nil {
+		return err
+	}
+
+	// the owner is the first producer of every node, other producers are verified before any is saved
+	producers := []*quorumpb.ProducerItem{}
+	for _, p := range s.Producers {
+		if p.ProducerPubkey == ownerPubkey {
+			continue
+		}
+		if p.GroupId != s.GroupId {
+			return fmt.Errorf("producer %s of group %s mismatch with snapshot", p.ProducerPubkey, p.GroupId)
+		}
+		if err := VerifyProducerItem(p, ownerPubkey); err != nil {
+			return err
+		}
+		producers = append(producers, p)
+	}
+
+	if err := chainStorage.AddBlock(s.Block, false, receiver.nodename); err != nil {
+		return err
+	}
+	for _, p := range producers {
+		if err := chainStorage.AddProducer(p, receiver.nodename); err != nil {
+			return err
+		}
+	}
+
+	snapshot_log.Infof("<%s> fast-forward to block <%d> epoch <%d> by snapshot", s.GroupId, s.BlockId, s.Epoch)
+	receiver.cIface.SetCurrBlockId(s.BlockId)
+	receiver.cIface.SetCurrEpoch(s.Epoch)
+	receiver.cIface.SetLastUpdate(s.Block.TimeStamp)
+	return receiver.cIface.SaveChainInfoToDb()
+}
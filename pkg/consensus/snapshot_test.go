@@ -0,0 +1,95 @@
+package consensus
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/rumsystem/quorum/pkg/consensus/def"
+	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+func TestVerifySnapshot(t *testing.T) {
+	key, err := ethcrypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot := &def.Snapshot{
+		GroupId:      "g1",
+		BlockId:      1000,
+		Epoch:        1000,
+		Block:        &quorumpb.Block{GroupId: "g1", BlockId: 1000, Epoch: 1000},
+		Producers:    []*quorumpb.ProducerItem{{GroupId: "g1", ProducerPubkey: "p1"}},
+		TimeStamp:    1,
+		SignerPubkey: base64.RawURLEncoding.EncodeToString(ethcrypto.CompressPubkey(&key.PublicKey)),
+	}
+	hash, err := GetSnapshotHash(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot.Signature, err = ethcrypto.Sign(hash, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifySnapshot(snapshot); err != nil {
+		t.Fatalf("VerifySnapshot failed: %s", err)
+	}
+
+	snapshot.Producers[0].ProducerPubkey = "p2"
+	if err := VerifySnapshot(snapshot); err == nil {
+		t.Errorf("tampered snapshot should fail to verify")
+	}
+	snapshot.Producers[0].ProducerPubkey = "p1"
+
+	snapshot.Block.BlockId = 999
+	if err := VerifySnapshot(snapshot); err == nil {
+		t.Errorf("snapshot with mismatched block should fail to verify")
+	}
+}
+
+func TestVerifyProducerItem(t *testing.T) {
+	owner, err := ethcrypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := ethcrypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ownerPubkey := base64.RawURLEncoding.EncodeToString(ethcrypto.CompressPubkey(&owner.PublicKey))
+	otherPubkey := base64.RawURLEncoding.EncodeToString(ethcrypto.CompressPubkey(&other.PublicKey))
+
+	item := &quorumpb.ProducerItem{GroupId: "g1", ProducerPubkey: otherPubkey, GroupOwnerPubkey: ownerPubkey}
+	sign := func(key *ecdsa.PrivateKey) {
+		var buffer bytes.Buffer
+		buffer.Write([]byte(item.GroupId))
+		buffer.Write([]byte(item.ProducerPubkey))
+		buffer.Write([]byte(item.GroupOwnerPubkey))
+		sig, err := ethcrypto.Sign(localcrypto.Hash(buffer.Bytes()), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		item.GroupOwnerSign = hex.EncodeToString(sig)
+	}
+
+	sign(owner)
+	if err := VerifyProducerItem(item, ownerPubkey); err != nil {
+		t.Fatalf("VerifyProducerItem failed: %s", err)
+	}
+
+	// a producer signs itself in
+	sign(other)
+	if err := VerifyProducerItem(item, ownerPubkey); err == nil {
+		t.Errorf("producer not signed by the owner should fail to verify")
+	}
+	item.GroupOwnerPubkey = otherPubkey
+	if err := VerifyProducerItem(item, ownerPubkey); err == nil {
+		t.Errorf("producer of another owner should fail to verify")
+	}
+}
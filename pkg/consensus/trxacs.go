@@ -17,6 +17,9 @@ type TrxACS struct {
 	rbcInstances map[string]*TrxRBC
 	rbcOutput    map[string]bool
 	rbcResults   map[string][]byte
+
+	done      bool // the result is handed to bft, guarded by TrxBft.mu
+	cancelled bool // the propose round is given up, guarded by TrxBft.mu
 }
 
 func NewTrxACS(cfg Config, bft *TrxBft, epoch uint64) *TrxACS {
@@ -65,7 +68,11 @@ func (a *TrxACS) RbcDone(proposerPubkey string) {
 			a.rbcResults[rbcInst] = a.rbcInstances[rbcInst].Output()
 		}
 
-		//call hbb to get result
+		//call hbb to get result, unless the round timed out or is killed
+		if !a.bft.finishAcs(a) {
+			trx_acs_log.Debugf("acs of epoch <%d> is cancelled, drop the result", a.Epoch)
+			return
+		}
 		a.bft.AcsDone(a.Epoch, a.rbcResults)
 	} else {
 		trx_acs_log.Debugf("Wait for enough RBC done")
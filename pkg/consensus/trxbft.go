@@ -3,6 +3,7 @@ package consensus
 import (
 	"errors"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/golang/protobuf/proto"
@@ -24,6 +25,8 @@ type ProposeTask struct {
 	Epoch          uint64
 	ProposedData   []byte
 	DelayStartTime int
+
+	cancelled bool // the task timed out before its acs was created, guarded by TrxBft.mu
 }
 
 type ProposeStatus uint
@@ -45,7 +48,9 @@ type TrxBft struct {
 
 	taskdone   chan struct{}
 	stopnotify chan struct{}
+	quit       chan struct{} // closed by StopPropose
 
+	mu     sync.Mutex // guards status, CurrTask, acsInsts and the acs state
 	status ProposeStatus
 }
 
@@ -59,19 +64,35 @@ func NewTrxBft(cfg Config, producer *MolassesProducer) *TrxBft {
 		taskq:      make(chan *ProposeTask),
 		taskdone:   make(chan struct{}),
 		stopnotify: make(chan struct{}),
+		quit:       make(chan struct{}),
 		status:     IDLE,
 	}
 }
 
+// Status returns the propose status of bft
+func (bft *TrxBft) Status() ProposeStatus {
+	bft.mu.Lock()
+	defer bft.mu.Unlock()
+	return bft.status
+}
+
 func (bft *TrxBft) StartPropose() {
 	trx_bft_log.Debugf("<%s> StartPropose called", bft.groupId)
+	bft.mu.Lock()
+	bft.status = RUNNING
+	bft.mu.Unlock()
 
 	//start taskq
 	go func() {
-		for task := range bft.taskq {
-			bft.runTask(task)
+		defer close(bft.stopnotify)
+		for {
+			select {
+			case task := <-bft.taskq:
+				bft.runTask(task)
+			case <-bft.quit:
+				return
+			}
 		}
-		bft.stopnotify <- struct{}{}
 	}()
 
 	//add first task
@@ -82,8 +103,19 @@ func (bft *TrxBft) StartPropose() {
 func (bft *TrxBft) KillAndRunNextRound() {
 	trx_bft_log.Debugf("<%s> KillCurrentTask called", bft.groupId)
 
+	//the acs of current task is not needed anymore
+	bft.mu.Lock()
+	if bft.acsInsts != nil {
+		bft.acsInsts.cancelled = true
+	}
+	bft.mu.Unlock()
+
 	//finish current task
-	bft.taskdone <- struct{}{}
+	select {
+	case bft.taskdone <- struct{}{}:
+	case <-bft.quit:
+		return
+	}
 
 	//bft.CurrTask = nil
 	//bft.acsInsts = nil
@@ -95,8 +127,9 @@ func (bft *TrxBft) KillAndRunNextRound() {
 func (bft *TrxBft) addTask(task *ProposeTask) {
 	trx_bft_log.Debugf("<%s> bft addTask called", bft.groupId)
 	go func() {
-		if bft.status != CLOSED {
-			bft.taskq <- task
+		select {
+		case bft.taskq <- task:
+		case <-bft.quit:
 		}
 	}()
 }
@@ -108,16 +141,76 @@ func (bft *TrxBft) runTask(task *ProposeTask) error {
 		trx_bft_log.Debugf("<%s> wait <%d> ms", bft.groupId, task.DelayStartTime)
 		time.Sleep(time.Duration(task.DelayStartTime) * time.Millisecond)
 
+		bft.mu.Lock()
+		if bft.status == CLOSED || task.cancelled {
+			bft.mu.Unlock()
+			return
+		}
+		acs := NewTrxACS(bft.Config, bft, task.Epoch)
 		bft.CurrTask = task
-		bft.acsInsts = NewTrxACS(bft.Config, bft, task.Epoch)
-		bft.acsInsts.InputValue(task.ProposedData)
+		bft.acsInsts = acs
+		bft.mu.Unlock()
+
+		acs.InputValue(task.ProposedData)
 	}()
 
 	//wait here
-	<-bft.taskdone
+	var timeout <-chan time.Time
+	if bft.ProposeTimeout > 0 {
+		timeout = time.After(time.Duration(task.DelayStartTime+bft.ProposeTimeout) * time.Millisecond)
+	}
+
+	select {
+	case <-bft.taskdone:
+	case <-bft.quit:
+	case <-timeout:
+		if !bft.cancelTask(task) {
+			//acs is done just now, wait for it to finish the task
+			select {
+			case <-bft.taskdone:
+			case <-bft.quit:
+			}
+			return nil
+		}
+		trx_bft_log.Warningf("<%s> propose of epoch <%d> timeout after <%d> ms, start a new round", bft.groupId, task.Epoch, task.DelayStartTime+bft.ProposeTimeout)
+		next, err := bft.NewProposeTask()
+		if err != nil {
+			trx_bft_log.Warningf("<%s> create propose task failed <%s>", bft.groupId, err.Error())
+			return err
+		}
+		bft.addTask(next)
+	}
 	return nil
 }
 
+// cancelTask cancels the timed out task and its acs, so that a late acs result does not finish the next task,
+// it returns false if the acs of the task is already done
+func (bft *TrxBft) cancelTask(task *ProposeTask) bool {
+	bft.mu.Lock()
+	defer bft.mu.Unlock()
+	if bft.CurrTask != task {
+		//acs of the task is not created yet
+		task.cancelled = true
+		return true
+	}
+	if bft.acsInsts.done {
+		return false
+	}
+	bft.acsInsts.cancelled = true
+	return true
+}
+
+// finishAcs marks acs as done, it returns false if acs has been cancelled or bft is stopped
+func (bft *TrxBft) finishAcs(acs *TrxACS) bool {
+	bft.mu.Lock()
+	defer bft.mu.Unlock()
+	if bft.status == CLOSED || acs.cancelled || acs.done {
+		return false
+	}
+	acs.done = true
+	return true
+}
+
 func (bft *TrxBft) NewProposeTask() (*ProposeTask, error) {
 	trx_bft_log.Debugf("<%s> NewProposeTask called", bft.groupId)
 
@@ -170,44 +263,29 @@ func (bft *TrxBft) NewProposeTask() (*ProposeTask, error) {
 	task := &ProposeTask{
 		Epoch:          proposedEpoch,
 		ProposedData:   datab,
-		DelayStartTime: DEFAULT_PROPOSE_PULSE,
+		DelayStartTime: bft.ProposeInterval,
 	}
 
 	return task, nil
 }
 
+// StopPropose stops the task loop and waits for it to exit, a stopped bft can not be started again
 func (bft *TrxBft) StopPropose() {
 	trx_bft_log.Debugf("<%s> StopPropose called", bft.groupId)
+	bft.mu.Lock()
+	running := bft.status == RUNNING
 	bft.status = CLOSED
-	safeCloseTaskQ(bft.taskq)
-	safeClose(bft.taskdone)
-	if bft.stopnotify != nil {
-		signcount := 1
-		for range bft.stopnotify {
-			signcount++
-			//wait stop sign and set idle
-			if signcount == 1 { // taskq
-				close(bft.stopnotify)
-				trx_bft_log.Debugf("<%s> bft stop propose done.")
-			}
-		}
-	}
-}
+	bft.mu.Unlock()
 
-func safeClose(ch chan struct{}) (recovered bool) {
-	defer func() {
-		if recover() != nil {
-			recovered = true
-		}
-	}()
-	if ch == nil {
-		return false
+	safeClose(bft.quit)
+	if running {
+		//the task loop closes stopnotify when it exits
+		<-bft.stopnotify
+		trx_bft_log.Debugf("<%s> bft stop propose done.", bft.groupId)
 	}
-	close(ch)
-	return false
 }
 
-func safeCloseTaskQ(ch chan *ProposeTask) (recovered bool) {
+func safeClose(ch chan struct{}) (recovered bool) {
 	defer func() {
 		if recover() != nil {
 			recovered = true
@@ -321,7 +399,11 @@ func (bft *TrxBft) AcsDone(epoch uint64, result map[string][]byte) {
 	trx_bft_log.Debugf("<%s> ChainInfo updated", bft.producer.groupId)
 
 	//finish current task
-	bft.taskdone <- struct{}{}
+	select {
+	case bft.taskdone <- struct{}{}:
+	case <-bft.quit:
+		return
+	}
 
 	task, _ := bft.NewProposeTask()
 	bft.addTask(task)
@@ -379,6 +461,7 @@ func (bft *TrxBft) buildBlock(epoch uint64, trxs map[string]*quorumpb.Trx) error
 		if err != nil {
 			return err
 		}
+		bft.producer.onBlock(newBlock)
 
 		//apply trxs
 		if nodectx.GetNodeCtx().NodeType == nodectx.PRODUCER_NODE {
@@ -0,0 +1,81 @@
+package consensus
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestTrxBft() *TrxBft {
+	return &TrxBft{
+		taskq:      make(chan *ProposeTask),
+		taskdone:   make(chan struct{}),
+		stopnotify: make(chan struct{}),
+		quit:       make(chan struct{}),
+		status:     RUNNING,
+	}
+}
+
+func TestTrxBftCancelTask(t *testing.T) {
+	bft := newTestTrxBft()
+
+	// the task times out before its acs is created
+	task := &ProposeTask{Epoch: 1}
+	if !bft.cancelTask(task) || !task.cancelled {
+		t.Errorf("task without acs should be cancelled")
+	}
+
+	// the task times out while its acs is running, a late result is dropped
+	task = &ProposeTask{Epoch: 2}
+	acs := &TrxACS{Epoch: 2}
+	bft.CurrTask, bft.acsInsts = task, acs
+	if !bft.cancelTask(task) || !acs.cancelled {
+		t.Errorf("running acs should be cancelled")
+	}
+	if bft.finishAcs(acs) {
+		t.Errorf("cancelled acs should not finish the task")
+	}
+
+	// the acs is done just before the timeout
+	task = &ProposeTask{Epoch: 3}
+	acs = &TrxACS{Epoch: 3}
+	bft.CurrTask, bft.acsInsts = task, acs
+	if !bft.finishAcs(acs) {
+		t.Fatalf("acs should finish the task")
+	}
+	if bft.cancelTask(task) {
+		t.Errorf("done acs should not be cancelled")
+	}
+	if bft.finishAcs(acs) {
+		t.Errorf("acs should finish the task only once")
+	}
+}
+
+func TestTrxBftStopPropose(t *testing.T) {
+	bft := newTestTrxBft()
+	go func() {
+		defer close(bft.stopnotify)
+		<-bft.quit
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		bft.StopPropose()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("StopPropose does not return")
+	}
+	if bft.Status() != CLOSED {
+		t.Errorf("bft should be closed")
+	}
+
+	// neither adding a task nor a late acs result blocks or panics after stop
+	bft.addTask(&ProposeTask{})
+	acs := &TrxACS{}
+	if bft.finishAcs(acs) {
+		t.Errorf("acs should not finish a task after stop")
+	}
+	bft.StopPropose()
+}
@@ -44,6 +44,10 @@ type DirKeyStore struct {
 func InitDirKeyStore(name string, keydir string) (*DirKeyStore, int, error) {
 	keydir, _ = filepath.Abs(keydir)
 
+	if err := recoverKeystoreDir(keydir); err != nil {
+		return nil, 0, err
+	}
+
 	_, err := os.Stat(keydir)
 	if os.IsNotExist(err) {
 		const dirPerm = 0700
@@ -139,6 +143,25 @@ func (ks *DirKeyStore) Lock() error {
 	return nil
 }
 
+// LinkKey makes the target key usable by keyname in memory, no key file is written and the link is gone after Lock
+func (ks *DirKeyStore) LinkKey(keyname, target string, keytype KeyType) error {
+	key, err := ks.GetKeyFromUnlocked(keytype.NameString(target))
+	if err != nil {
+		return err
+	}
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.unlocked[keytype.NameString(keyname)] = key
+	return nil
+}
+
+// UnlinkKey removes the key linked by LinkKey, the target key is kept
+func (ks *DirKeyStore) UnlinkKey(keyname string, keytype KeyType) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	delete(ks.unlocked, keytype.NameString(keyname))
+}
+
 func (ks *DirKeyStore) GetPeerInfo(keyname string) (peerid peer.ID, ethaddr string, err error) {
 	key, err := ks.GetKeyFromUnlocked(Sign.NameString(keyname))
 	if err != nil {
@@ -347,6 +370,44 @@ func (ks *DirKeyStore) ImportEcdsaPrivKey(keyname string, privkey *ecdsa.Private
 	return key.Address.String(), nil
 }
 
+// ImportSignKey stores privkey as the sign key of keyname with the default password and keeps it unlocked.
+// It returns the eth address of the key.
+func (ks *DirKeyStore) ImportSignKey(keyname string, privkey *ecdsa.PrivateKey) (string, error) {
+	name := Sign.NameString(keyname)
+	address, err := ks.ImportEcdsaPrivKey(name, privkey, ks.password)
+	if err != nil {
+		return "", err
+	}
+	key, err := ks.LoadSignKey(name, common.HexToAddress(address), ks.password)
+	if err != nil {
+		return "", err
+	}
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.unlocked[name] = key
+	return address, nil
+}
+
+// ImportEncryptKey stores key as the encrypt key of keyname with the default password and keeps it unlocked.
+// It returns the recipient of the key.
+func (ks *DirKeyStore) ImportEncryptKey(keyname string, key *age.X25519Identity) (string, error) {
+	name := Encrypt.NameString(keyname)
+	exist, err := ks.IfKeyExist(name)
+	if err != nil {
+		return "", err
+	}
+	if exist {
+		return "", fmt.Errorf("Key '%s' exists", name)
+	}
+	if err := ks.StoreEncryptKey(name, key, ks.password); err != nil {
+		return "", err
+	}
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.unlocked[name] = key
+	return key.Recipient().String(), nil
+}
+
 func (ks *DirKeyStore) NewKeyWithDefaultPassword(keyname string, keytype KeyType) (string, error) {
 	return ks.NewKey(keyname, keytype, ks.password)
 }
@@ -404,6 +465,57 @@ func (ks *DirKeyStore) NewKey(keyname string, keytype KeyType, password string)
 	}
 }
 
+// CopyKey stores the key of keyname under newkeyname with the default password, both names then use the same identity.
+// It returns the eth address of a sign key or the recipient of an encrypt key.
+func (ks *DirKeyStore) CopyKey(keyname, newkeyname string, keytype KeyType) (string, error) {
+	key, err := ks.GetKeyFromUnlocked(keytype.NameString(keyname))
+	if err != nil {
+		return "", err
+	}
+	newname := keytype.NameString(newkeyname)
+	exist, err := ks.IfKeyExist(newname)
+	if err != nil {
+		return "", err
+	}
+	if exist {
+		return "", fmt.Errorf("Key '%s' exists", newname)
+	}
+
+	switch keytype {
+	case Sign:
+		signk, ok := key.(*ethkeystore.Key)
+		if !ok {
+			return "", fmt.Errorf("The key %s is not a Sign key", keyname)
+		}
+		id, err := uuid.NewRandom()
+		if err != nil {
+			return "", err
+		}
+		newkey := &ethkeystore.Key{Id: id, Address: signk.Address, PrivateKey: signk.PrivateKey}
+		if err := ks.StoreSignKey(newname, newkey, ks.password); err != nil {
+			return "", err
+		}
+		ks.mu.Lock()
+		defer ks.mu.Unlock()
+		ks.unlocked[newname] = newkey
+		return newkey.Address.String(), nil
+	case Encrypt:
+		encryptk, ok := key.(*age.X25519Identity)
+		if !ok {
+			return "", fmt.Errorf("The key %s is not a encrypt key", keyname)
+		}
+		if err := ks.StoreEncryptKey(newname, encryptk, ks.password); err != nil {
+			return "", err
+		}
+		ks.mu.Lock()
+		defer ks.mu.Unlock()
+		ks.unlocked[newname] = encryptk
+		return encryptk.Recipient().String(), nil
+	default:
+		return "", fmt.Errorf("unsupported key type")
+	}
+}
+
 func (ks *DirKeyStore) Import(keyname string, encodedkey string, keytype KeyType, password string) (string, error) {
 	ks.mu.Lock()
 	defer ks.mu.Unlock()
@@ -666,8 +778,13 @@ func (ks *DirKeyStore) RemoveKey(keyname string, keytype KeyType) (err error) {
 	if exist != true {
 		return fmt.Errorf("Key '%s' not exists", keyname)
 	}
-	ks.DeleteKeyfile(keyname)
+	if err := ks.DeleteKeyfile(keyname); err != nil {
+		return err
+	}
 
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	delete(ks.unlocked, keyname)
 	return nil
 }
 
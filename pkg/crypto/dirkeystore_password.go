@@ -0,0 +1,158 @@
+//go:build !js
+// +build !js
+
+package crypto
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ethkeystore "github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	newKeystoreSuffix = ".new" // keystore being written under the new password
+	oldKeystoreSuffix = ".old" // keystore replaced by the new one, removed after the swap
+)
+
+// recoverKeystoreDir finishes or reverts a password change interrupted by a crash,
+// the old keystore is restored unless the new one was fully in place
+func recoverKeystoreDir(keydir string) error {
+	newdir, olddir := keydir+newKeystoreSuffix, keydir+oldKeystoreSuffix
+	if _, err := os.Stat(keydir); os.IsNotExist(err) {
+		if _, err := os.Stat(olddir); err == nil {
+			cryptolog.Warningf("keystore password change was interrupted, restore the old keystore %s", olddir)
+			if err := os.Rename(olddir, keydir); err != nil {
+				return err
+			}
+		}
+	}
+	if err := os.RemoveAll(newdir); err != nil {
+		return err
+	}
+	return os.RemoveAll(olddir)
+}
+
+// VerifyPassword returns true if password is the one the keystore is unlocked with
+func (ks *DirKeyStore) VerifyPassword(password string) bool {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return ks.password != "" && subtle.ConstantTimeCompare([]byte(password), []byte(ks.password)) == 1
+}
+
+// ChangePassword re-encrypts every key under newpassword. The keys are written into a new
+// keystore dir which replaces the current one only when all of them are verified.
+func (ks *DirKeyStore) ChangePassword(oldpassword, newpassword string) error {
+	if newpassword == "" {
+		return errors.New("new password is empty")
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if subtle.ConstantTimeCompare([]byte(oldpassword), []byte(ks.password)) != 1 {
+		return errors.New("old password is incorrect")
+	}
+
+	newdir := ks.KeystorePath + newKeystoreSuffix
+	olddir := ks.KeystorePath + oldKeystoreSuffix
+	if err := os.RemoveAll(newdir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(newdir, 0700); err != nil {
+		return err
+	}
+
+	files, err := ioutil.ReadDir(ks.KeystorePath)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		name := f.Name()
+		src := filepath.Join(ks.KeystorePath, name)
+		dst := filepath.Join(newdir, name)
+		switch {
+		case strings.HasPrefix(name, Sign.Prefix()):
+			err = ks.reencryptSignKey(src, dst, name, oldpassword, newpassword)
+		case strings.HasPrefix(name, Encrypt.Prefix()):
+			err = ks.reencryptEncryptKey(src, dst, oldpassword, newpassword)
+		default:
+			err = copyKeystoreFile(src, dst, f.Mode())
+		}
+		if err != nil {
+			os.RemoveAll(newdir)
+			return fmt.Errorf("re-encrypt %s failed: %s", name, err)
+		}
+	}
+
+	if err := os.RemoveAll(olddir); err != nil {
+		return err
+	}
+	if err := os.Rename(ks.KeystorePath, olddir); err != nil {
+		return err
+	}
+	if err := os.Rename(newdir, ks.KeystorePath); err != nil {
+		// put the old keystore back
+		if rerr := os.Rename(olddir, ks.KeystorePath); rerr != nil {
+			cryptolog.Errorf("restore keystore %s failed: %s", olddir, rerr)
+		}
+		return err
+	}
+	if err := os.RemoveAll(olddir); err != nil {
+		cryptolog.Warningf("remove old keystore %s failed: %s", olddir, err)
+	}
+
+	ks.password = newpassword
+	return nil
+}
+
+func (ks *DirKeyStore) reencryptSignKey(src, dst, name, oldpassword, newpassword string) error {
+	keyjson, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	key, err := ethkeystore.DecryptKey(keyjson, oldpassword)
+	if err != nil {
+		return err
+	}
+	if addr, ok := ks.signkeymap[name[len(Sign.Prefix()):]]; ok && key.Address != common.HexToAddress(addr) {
+		return fmt.Errorf("key content mismatch: have account %x, want %s", key.Address, addr)
+	}
+	// StoreSignKey verifies the written file
+	return ks.StoreSignKey(dst, key, newpassword)
+}
+
+func (ks *DirKeyStore) reencryptEncryptKey(src, dst, oldpassword, newpassword string) error {
+	key, err := ks.LoadEncryptKey(src, oldpassword)
+	if err != nil {
+		return err
+	}
+	if err := ks.StoreEncryptKey(dst, key, newpassword); err != nil {
+		return err
+	}
+	written, err := ks.LoadEncryptKey(dst, newpassword)
+	if err != nil {
+		return err
+	}
+	if written.String() != key.String() {
+		return errors.New("verify re-encrypted key failed")
+	}
+	return nil
+}
+
+func copyKeystoreFile(src, dst string, mode os.FileMode) error {
+	content, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(dst, content, mode.Perm())
+}
@@ -0,0 +1,77 @@
+//go:build !js
+// +build !js
+
+package crypto
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestChangePassword(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "keystore")
+	ks, _, err := InitDirKeyStore("pwdtest", dir)
+	if err != nil {
+		t.Fatalf("keystore init err: %s", err)
+	}
+	ks.Unlock(map[string]string{}, "old.Passw0rd")
+	addr, err := ks.NewKeyWithDefaultPassword("default", Sign)
+	if err != nil {
+		t.Fatalf("new sign key err: %s", err)
+	}
+	if _, err := ks.NewKeyWithDefaultPassword("default", Encrypt); err != nil {
+		t.Fatalf("new encrypt key err: %s", err)
+	}
+
+	if err := ks.ChangePassword("wrong", "new.Passw0rd"); err == nil {
+		t.Fatalf("expect error with wrong old password")
+	}
+	if err := ks.ChangePassword("old.Passw0rd", "new.Passw0rd"); err != nil {
+		t.Fatalf("change password err: %s", err)
+	}
+
+	// reopen with the new password
+	ks2, count, err := InitDirKeyStore("pwdtest", dir)
+	if err != nil || count != 1 {
+		t.Fatalf("reopen keystore: count %d, err %v", count, err)
+	}
+	ks2.Unlock(map[string]string{"default": addr}, "new.Passw0rd")
+	if _, err := ks2.GetKeyFromUnlocked(Sign.NameString("default")); err != nil {
+		t.Errorf("unlock sign key with new password failed: %s", err)
+	}
+	if _, err := ks2.GetKeyFromUnlocked(Encrypt.NameString("default")); err != nil {
+		t.Errorf("unlock encrypt key with new password failed: %s", err)
+	}
+	if _, err := ks2.LoadEncryptKey(Encrypt.NameString("default"), "old.Passw0rd"); err == nil {
+		t.Errorf("expect old password to be rejected")
+	}
+}
+
+func TestRecoverInterruptedPasswordChange(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "keystore")
+	ks, _, err := InitDirKeyStore("pwdtest", dir)
+	if err != nil {
+		t.Fatalf("keystore init err: %s", err)
+	}
+	ks.Unlock(map[string]string{}, "old.Passw0rd")
+	if _, err := ks.NewKeyWithDefaultPassword("default", Sign); err != nil {
+		t.Fatalf("new sign key err: %s", err)
+	}
+
+	// crashed between moving the old keystore away and moving the new one in
+	if err := os.Rename(dir, dir+oldKeystoreSuffix); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dir+newKeystoreSuffix, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	_, count, err := InitDirKeyStore("pwdtest", dir)
+	if err != nil || count != 1 {
+		t.Fatalf("expect old keystore to be restored: count %d, err %v", count, err)
+	}
+	if _, err := os.Stat(dir + newKeystoreSuffix); !os.IsNotExist(err) {
+		t.Errorf("expect the unfinished new keystore to be removed")
+	}
+}
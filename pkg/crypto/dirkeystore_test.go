@@ -5,6 +5,7 @@ package crypto
 
 import (
 	"fmt"
+	"io/ioutil"
 	"testing"
 
 	"github.com/rumsystem/quorum/internal/pkg/logging"
@@ -137,3 +138,44 @@ func TestEthSignByKeyName(t *testing.T) {
 		t.Errorf("eth verify signature by keyname failure")
 	}
 }
+
+func TestLinkKey(t *testing.T) {
+	name := "testlinkkey"
+	password := "my.Passw0rd"
+	tempdir := fmt.Sprintf("%s/%s", t.TempDir(), name)
+	ks, _, err := InitDirKeyStore(name, tempdir)
+	if err != nil {
+		t.Fatalf("keystore init err: %s", err)
+	}
+	if _, err := ks.NewKey("default", Sign, password); err != nil {
+		t.Fatalf("new key failed: %s", err)
+	}
+	files, _ := ioutil.ReadDir(tempdir)
+
+	if err := ks.LinkKey("group1", "default", Sign); err != nil {
+		t.Fatalf("link key failed: %s", err)
+	}
+	pubkey, err := ks.GetEncodedPubkey("group1", Sign)
+	if err != nil {
+		t.Fatalf("get linked pubkey failed: %s", err)
+	}
+	defaultPubkey, _ := ks.GetEncodedPubkey("default", Sign)
+	if pubkey != defaultPubkey {
+		t.Errorf("expect linked pubkey %s, got %s", defaultPubkey, pubkey)
+	}
+	if after, _ := ioutil.ReadDir(tempdir); len(after) != len(files) {
+		t.Errorf("expect no key file written by the link, got %d files, want %d", len(after), len(files))
+	}
+
+	ks.UnlinkKey("group1", Sign)
+	if _, err := ks.GetEncodedPubkey("group1", Sign); err == nil {
+		t.Errorf("expect unlinked key not exist")
+	}
+	if _, err := ks.GetEncodedPubkey("default", Sign); err != nil {
+		t.Errorf("expect target key kept, got %s", err)
+	}
+
+	if err := ks.LinkKey("group2", "nokey", Sign); err == nil {
+		t.Errorf("expect error linking to a key not exist")
+	}
+}
@@ -0,0 +1,46 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+// groupKeyMagic starts the data sealed by a group key, the age encrypted data starts with "age-encryption.org"
+var groupKeyMagic = []byte("RGK1")
+
+var ErrNotGroupKeySealed = errors.New("data is not sealed by a group key")
+
+// GroupKeySeal encrypts data with the group key of the version, the version is kept in the clear so the readers
+// pick the key to open it
+func GroupKeySeal(version uint32, key []byte, data []byte) ([]byte, error) {
+	encrypted, err := AesEncrypt(data, key)
+	if err != nil {
+		return nil, err
+	}
+	sealed := make([]byte, len(groupKeyMagic)+4, len(groupKeyMagic)+4+len(encrypted))
+	copy(sealed, groupKeyMagic)
+	binary.BigEndian.PutUint32(sealed[len(groupKeyMagic):], version)
+	return append(sealed, encrypted...), nil
+}
+
+// GroupKeyVersion returns the version of the group key which sealed data, ErrNotGroupKeySealed if data is not sealed
+func GroupKeyVersion(data []byte) (uint32, error) {
+	if len(data) < len(groupKeyMagic)+4 || !bytes.Equal(data[:len(groupKeyMagic)], groupKeyMagic) {
+		return 0, ErrNotGroupKeySealed
+	}
+	return binary.BigEndian.Uint32(data[len(groupKeyMagic):]), nil
+}
+
+// GroupKeyOpen decrypts data sealed by GroupKeySeal with the key of its version
+func GroupKeyOpen(key []byte, data []byte) ([]byte, error) {
+	if _, err := GroupKeyVersion(data); err != nil {
+		return nil, err
+	}
+	encrypted := data[len(groupKeyMagic)+4:]
+	// nonce and tag of aes gcm
+	if len(encrypted) < 12+16 {
+		return nil, errors.New("group key sealed data is too short")
+	}
+	return AesDecode(encrypted, key)
+}
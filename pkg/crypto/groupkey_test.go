@@ -0,0 +1,34 @@
+package crypto
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGroupKeySeal(t *testing.T) {
+	key, err := CreateAesKey()
+	if err != nil {
+		t.Fatalf("CreateAesKey failed: %s", err)
+	}
+	plaintext := []byte("hello private group")
+
+	sealed, err := GroupKeySeal(3, key, plaintext)
+	if err != nil {
+		t.Fatalf("GroupKeySeal failed: %s", err)
+	}
+	if version, err := GroupKeyVersion(sealed); err != nil || version != 3 {
+		t.Fatalf("want version 3, got %d err: %v", version, err)
+	}
+	opened, err := GroupKeyOpen(key, sealed)
+	if err != nil || string(opened) != string(plaintext) {
+		t.Fatalf("want %s, got %s err: %v", plaintext, opened, err)
+	}
+
+	other, _ := CreateAesKey()
+	if _, err := GroupKeyOpen(other, sealed); err == nil {
+		t.Errorf("open with another key should fail")
+	}
+	if _, err := GroupKeyVersion([]byte("age-encryption.org/v1")); !errors.Is(err, ErrNotGroupKeySealed) {
+		t.Errorf("want ErrNotGroupKeySealed, got %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/text/unicode/norm"
+)
+
+// DefaultDerivationPath is the path of the group owner key, the BIP43 purpose 5395789 is "RUM" in ascii,
+// so the owner key is not any eth account of the mnemonic (m/44'/60'/...)
+const DefaultDerivationPath = "m/5395789'/0'/0'/0/0"
+
+const hardenedKeyStart = 0x80000000
+
+var (
+	wordIndexOnce sync.Once
+	wordIndex     map[string]int
+)
+
+func mnemonicWordIndex() map[string]int {
+	wordIndexOnce.Do(func() {
+		wordIndex = make(map[string]int, len(wordlist))
+		for i, w := range wordlist {
+			wordIndex[w] = i
+		}
+	})
+	return wordIndex
+}
+
+// ValidateMnemonic checks the words and the checksum of the BIP39 english mnemonic
+func ValidateMnemonic(mnemonic string) error {
+	words := strings.Fields(mnemonic)
+	n := len(words)
+	if n < 12 || n > 24 || n%3 != 0 {
+		return fmt.Errorf("invalid mnemonic word count %d, should be 12, 15, 18, 21 or 24", n)
+	}
+
+	index := mnemonicWordIndex()
+	bits := new(big.Int)
+	for _, w := range words {
+		i, ok := index[strings.ToLower(w)]
+		if !ok {
+			return fmt.Errorf("invalid mnemonic word %q", w)
+		}
+		bits.Lsh(bits, 11)
+		bits.Or(bits, big.NewInt(int64(i)))
+	}
+
+	// n words are n*11 bits, the entropy of n*32/3 bits and the checksum of n/3 bits
+	checksumBits := uint(n / 3)
+	checksum := new(big.Int).And(bits, big.NewInt(1<<checksumBits-1)).Uint64()
+	entropy := new(big.Int).Rsh(bits, checksumBits).FillBytes(make([]byte, n*4/3))
+	hash := sha256.Sum256(entropy)
+	if uint64(hash[0]>>(8-checksumBits)) != checksum {
+		return errors.New("invalid mnemonic checksum")
+	}
+	return nil
+}
+
+// MnemonicToSeed returns the BIP39 seed of the mnemonic and the passphrase
+func MnemonicToSeed(mnemonic string, passphrase string) ([]byte, error) {
+	if err := ValidateMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+	words := strings.ToLower(strings.Join(strings.Fields(mnemonic), " "))
+	password := norm.NFKD.String(words)
+	salt := norm.NFKD.String("mnemonic" + passphrase)
+	return pbkdf2.Key([]byte(password), []byte(salt), 2048, 64, sha512.New), nil
+}
+
+// ParseDerivationPath parses the BIP32 path like m/44'/60'/0'/0/0, a hardened index ends with ' or h
+func ParseDerivationPath(path string) ([]uint32, error) {
+	elems := strings.Split(strings.TrimSpace(path), "/")
+	if len(elems) == 0 || elems[0] != "m" {
+		return nil, fmt.Errorf("invalid derivation path %q, should start with m", path)
+	}
+	indexes := []uint32{}
+	for _, elem := range elems[1:] {
+		var offset uint32
+		if strings.HasSuffix(elem, "'") || strings.HasSuffix(elem, "h") {
+			offset = hardenedKeyStart
+			elem = elem[:len(elem)-1]
+		}
+		i, err := strconv.ParseUint(elem, 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("invalid derivation path %q: %s", path, err)
+		}
+		indexes = append(indexes, uint32(i)+offset)
+	}
+	return indexes, nil
+}
+
+// DeriveKey derives the secp256k1 key of the path from the BIP32 seed
+func DeriveKey(seed []byte, path string) (*ecdsa.PrivateKey, error) {
+	indexes, err := ParseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	key, chainCode := sum[:32], sum[32:]
+
+	curveN := ethcrypto.S256().Params().N
+	for _, i := range indexes {
+		var data []byte
+		if i >= hardenedKeyStart {
+			data = append([]byte{0}, key...)
+		} else {
+			priv, err := ethcrypto.ToECDSA(key)
+			if err != nil {
+				return nil, err
+			}
+			data = ethcrypto.CompressPubkey(&priv.PublicKey)
+		}
+		data = binary.BigEndian.AppendUint32(data, i)
+
+		mac := hmac.New(sha512.New, chainCode)
+		mac.Write(data)
+		sum := mac.Sum(nil)
+		il := new(big.Int).SetBytes(sum[:32])
+		if il.Cmp(curveN) >= 0 {
+			return nil, fmt.Errorf("invalid child key at index %d of path %s", i, path)
+		}
+		k := il.Add(il, new(big.Int).SetBytes(key))
+		k.Mod(k, curveN)
+		if k.Sign() == 0 {
+			return nil, fmt.Errorf("invalid child key at index %d of path %s", i, path)
+		}
+		key, chainCode = k.FillBytes(make([]byte, 32)), sum[32:]
+	}
+	return ethcrypto.ToECDSA(key)
+}
@@ -0,0 +1,50 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestMnemonicToSeed(t *testing.T) {
+	mnemonic := strings.Repeat("abandon ", 11) + "about"
+	seed, err := MnemonicToSeed(mnemonic, "TREZOR")
+	if err != nil {
+		t.Fatalf("MnemonicToSeed failed: %s", err)
+	}
+	expect := "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04"
+	if hex.EncodeToString(seed) != expect {
+		t.Errorf("expect seed %s, got %x", expect, seed)
+	}
+
+	for _, m := range []string{
+		strings.Repeat("abandon ", 12),
+		strings.Repeat("abandon ", 11) + "notaword",
+		"abandon about",
+	} {
+		if _, err := MnemonicToSeed(m, ""); err == nil {
+			t.Errorf("expect invalid mnemonic %q", m)
+		}
+	}
+}
+
+func TestDeriveKey(t *testing.T) {
+	// BIP32 test vector 1
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	key, err := DeriveKey(seed, "m/0'/1/2'/2/1000000000")
+	if err != nil {
+		t.Fatalf("DeriveKey failed: %s", err)
+	}
+	expect := "471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8"
+	if got := hex.EncodeToString(ethcrypto.FromECDSA(key)); got != expect {
+		t.Errorf("expect key %s, got %s", expect, got)
+	}
+
+	for _, path := range []string{"", "44'/60'", "m/x", "m/2147483648"} {
+		if _, err := DeriveKey(seed, path); err == nil {
+			t.Errorf("expect invalid path %q", path)
+		}
+	}
+}
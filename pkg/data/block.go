@@ -87,6 +87,11 @@ func RegenrateBlockWithParent(parentBlock *quorumpb.Block, orphanBlock *quorumpb
 }
 
 func CreateGenesisBlockByEthKey(groupId string, groupPublicKey string, keystore localcrypto.Keystore, keyalias string) (*quorumpb.Block, error) {
+	return CreateGenesisBlockAt(groupId, groupPublicKey, keystore, keyalias, time.Now().UnixNano())
+}
+
+// CreateGenesisBlockAt creates the genesis block with the timestamp, the same group id, key and timestamp give the same block
+func CreateGenesisBlockAt(groupId string, groupPublicKey string, keystore localcrypto.Keystore, keyalias string, timestamp int64) (*quorumpb.Block, error) {
 	genesisBlock := &quorumpb.Block{
 		GroupId:        groupId,
 		BlockId:        0,
@@ -95,7 +100,7 @@ func CreateGenesisBlockByEthKey(groupId string, groupPublicKey string, keystore
 		ProducerPubkey: groupPublicKey,
 		Trxs:           nil,
 		Sudo:           true,
-		TimeStamp:      time.Now().UnixNano(),
+		TimeStamp:      timestamp,
 	}
 
 	bbytes, err := proto.Marshal(genesisBlock)
@@ -126,25 +131,10 @@ func CreateGenesisBlockByEthKey(groupId string, groupPublicKey string, keystore
 func ValidBlockWithParent(newBlock, parentBlock *quorumpb.Block) (bool, error) {
 
 	//step 1, check hash for newBlock
-	blkWithOutHashAndSign := &quorumpb.Block{
-		GroupId:        newBlock.GroupId,
-		BlockId:        newBlock.BlockId,
-		Epoch:          newBlock.Epoch,
-		PrevHash:       newBlock.PrevHash,
-		ProducerPubkey: newBlock.ProducerPubkey,
-		Trxs:           newBlock.Trxs,
-		Sudo:           newBlock.Sudo,
-		TimeStamp:      newBlock.TimeStamp,
-		BlockHash:      nil,
-		ProducerSign:   nil,
-	}
-
-	tbytes, err := proto.Marshal(blkWithOutHashAndSign)
+	hash, err := blockHash(newBlock)
 	if err != nil {
 		return false, err
 	}
-
-	hash := localcrypto.Hash(tbytes)
 	if !bytes.Equal(hash, newBlock.BlockHash) {
 		return false, fmt.Errorf("hash for new block is invalid")
 	}
@@ -158,13 +148,42 @@ func ValidBlockWithParent(newBlock, parentBlock *quorumpb.Block) (bool, error) {
 		return false, errors.New("prevhash mismatch with parent block")
 	}
 
-	//step 3, check producer sign
-	bytespubkey, err := base64.RawURLEncoding.DecodeString(newBlock.ProducerPubkey)
+	//step 3, check producer sign, skipped if it is verified ahead
+	if verifiedBlocks.take(hash, newBlock) {
+		return true, nil
+	}
+	return verifyProducerSign(hash, newBlock)
+}
+
+// blockHash returns the hash of the block without its hash and sign
+func blockHash(block *quorumpb.Block) ([]byte, error) {
+	blkWithOutHashAndSign := &quorumpb.Block{
+		GroupId:        block.GroupId,
+		BlockId:        block.BlockId,
+		Epoch:          block.Epoch,
+		PrevHash:       block.PrevHash,
+		ProducerPubkey: block.ProducerPubkey,
+		Trxs:           block.Trxs,
+		Sudo:           block.Sudo,
+		TimeStamp:      block.TimeStamp,
+		BlockHash:      nil,
+		ProducerSign:   nil,
+	}
+
+	tbytes, err := proto.Marshal(blkWithOutHashAndSign)
+	if err != nil {
+		return nil, err
+	}
+	return localcrypto.Hash(tbytes), nil
+}
+
+func verifyProducerSign(hash []byte, block *quorumpb.Block) (bool, error) {
+	bytespubkey, err := base64.RawURLEncoding.DecodeString(block.ProducerPubkey)
 	if err == nil { //try eth key
 		ethpubkey, err := ethcrypto.DecompressPubkey(bytespubkey)
 		if err == nil {
 			ks := localcrypto.GetKeystore()
-			r := ks.EthVerifySign(hash, newBlock.ProducerSign, ethpubkey)
+			r := ks.EthVerifySign(hash, block.ProducerSign, ethpubkey)
 			return r, nil
 		}
 		return false, err
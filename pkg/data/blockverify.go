@@ -0,0 +1,137 @@
+package data
+
+import (
+	"bytes"
+	"container/list"
+	"sync"
+
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+// maxVerifiedBlocks is the number of the verified blocks cached per group, the least recently verified ones
+// are dropped first, e.g. the blocks of a failed batch which are never applied
+const maxVerifiedBlocks = 8192
+
+// verifiedBlocks are the blocks whose hash and producer sign are verified ahead by VerifyBlocksAhead,
+// ValidBlockWithParent checks only their link to the parent when they are applied
+var verifiedBlocks = newBlockVerifyCache(maxVerifiedBlocks)
+
+// blockVerifyCache is a LRU cache of the verified blocks per group, so that a group syncing a long chain
+// does not drop the blocks verified for the other groups
+type blockVerifyCache struct {
+	mu     sync.Mutex
+	size   int
+	groups map[string]*verifiedLRU
+}
+
+type verifiedLRU struct {
+	order *list.List // of the keys, the front is the most recently verified
+	keys  map[string]*list.Element
+}
+
+func newBlockVerifyCache(size int) *blockVerifyCache {
+	return &blockVerifyCache{size: size, groups: make(map[string]*verifiedLRU)}
+}
+
+// the key covers the hash of the block content, the sign and the signer, so a block is skipped only if all of them match
+func verifiedKey(hash []byte, block *quorumpb.Block) string {
+	return string(hash) + string(block.ProducerSign) + block.ProducerPubkey
+}
+
+func (c *blockVerifyCache) add(hash []byte, block *quorumpb.Block) {
+	key := verifiedKey(hash, block)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	lru, ok := c.groups[block.GroupId]
+	if !ok {
+		lru = &verifiedLRU{order: list.New(), keys: make(map[string]*list.Element)}
+		c.groups[block.GroupId] = lru
+	}
+	if e, ok := lru.keys[key]; ok {
+		lru.order.MoveToFront(e)
+		return
+	}
+	lru.keys[key] = lru.order.PushFront(key)
+	if lru.order.Len() > c.size {
+		oldest := lru.order.Back()
+		lru.order.Remove(oldest)
+		delete(lru.keys, oldest.Value.(string))
+	}
+}
+
+// take returns true if the block is verified ahead, a block is skipped once
+func (c *blockVerifyCache) take(hash []byte, block *quorumpb.Block) bool {
+	key := verifiedKey(hash, block)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	lru, ok := c.groups[block.GroupId]
+	if !ok {
+		return false
+	}
+	e, ok := lru.keys[key]
+	if !ok {
+		return false
+	}
+	lru.order.Remove(e)
+	delete(lru.keys, key)
+	if len(lru.keys) == 0 {
+		delete(c.groups, block.GroupId)
+	}
+	return true
+}
+
+// len returns the number of the cached blocks of the group
+func (c *blockVerifyCache) len(groupId string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if lru, ok := c.groups[groupId]; ok {
+		return len(lru.keys)
+	}
+	return 0
+}
+
+// verifyBlock checks the hash and the producer sign of the block, they don't depend on the other blocks
+func verifyBlock(block *quorumpb.Block) bool {
+	hash, err := blockHash(block)
+	if err != nil || !bytes.Equal(hash, block.BlockHash) {
+		return false
+	}
+	if ok, err := verifyProducerSign(hash, block); err != nil || !ok {
+		return false
+	}
+	verifiedBlocks.add(hash, block)
+	return true
+}
+
+// VerifyBlocksAhead verifies the hashes and the producer signs of the blocks by workers in parallel,
+// the i-th returned channel is closed once the i-th block is verified, so that the caller applies the blocks
+// strictly in order while the later ones are still being verified.
+// A block failed to verify is not cached, ValidBlockWithParent verifies and rejects it again when it is applied.
+func VerifyBlocksAhead(blocks []*quorumpb.Block, workers int) []chan struct{} {
+	done := make([]chan struct{}, len(blocks))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(blocks) {
+		workers = len(blocks)
+	}
+
+	// the blocks are taken in order, so the first ones are verified first
+	jobs := make(chan int, len(blocks))
+	for i := range blocks {
+		jobs <- i
+	}
+	close(jobs)
+	for w := 0; w < workers; w++ {
+		go func() {
+			for i := range jobs {
+				verifyBlock(blocks[i])
+				close(done[i])
+			}
+		}()
+	}
+	return done
+}
@@ -0,0 +1,173 @@
+package data
+
+import (
+	"encoding/base64"
+	"fmt"
+	"runtime"
+	"testing"
+
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
+	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+)
+
+// newSignedBlocks returns a chain of n blocks after the parent signed by a new producer key
+func newSignedBlocks(tb testing.TB, parent *quorumpb.Block, n int) []*quorumpb.Block {
+	key, err := ethcrypto.GenerateKey()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	pubkey := base64.RawURLEncoding.EncodeToString(ethcrypto.CompressPubkey(&key.PublicKey))
+	blocks := make([]*quorumpb.Block, n)
+	for i := range blocks {
+		block := &quorumpb.Block{
+			GroupId:        parent.GroupId,
+			BlockId:        parent.BlockId + 1,
+			Epoch:          parent.Epoch + 1,
+			PrevHash:       parent.BlockHash,
+			ProducerPubkey: pubkey,
+			Trxs:           []*quorumpb.Trx{{GroupId: parent.GroupId, TrxId: fmt.Sprintf("trx-%d", i)}},
+			TimeStamp:      int64(i),
+		}
+		hash, err := blockHash(block)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		sign, err := ethcrypto.Sign(hash, key)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		block.BlockHash = hash
+		block.ProducerSign = sign
+		blocks[i] = block
+		parent = block
+	}
+	return blocks
+}
+
+func initBlockVerifyKeystore(tb testing.TB) {
+	if _, err := localcrypto.InitKeystore("defaultkeystore", tb.TempDir()); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func TestVerifyBlocksAhead(t *testing.T) {
+	initBlockVerifyKeystore(t)
+	genesis := &quorumpb.Block{GroupId: "a4a3cb4e-c2b2-4f1b-8c1f-6e8f3f0d2a11", BlockHash: []byte("genesis")}
+	blocks := newSignedBlocks(t, genesis, 64)
+	// a forged sign and a tampered content are verified ahead but not cached
+	blocks[20].ProducerSign = append([]byte{}, blocks[21].ProducerSign...)
+	blocks[40].Trxs = []*quorumpb.Trx{{GroupId: genesis.GroupId, TrxId: "tampered"}}
+
+	verified := VerifyBlocksAhead(blocks, 4)
+	if len(verified) != len(blocks) {
+		t.Fatalf("expect %d channels, got %d", len(blocks), len(verified))
+	}
+	for _, done := range verified {
+		<-done
+	}
+	if n := verifiedBlocks.len(genesis.GroupId); n != len(blocks)-2 {
+		t.Errorf("expect %d blocks verified, got %d", len(blocks)-2, n)
+	}
+
+	parent := genesis
+	for i, block := range blocks {
+		ok, err := ValidBlockWithParent(block, parent)
+		switch i {
+		case 20, 40:
+			if ok && err == nil {
+				t.Errorf("block %d should be rejected when it is applied", i)
+			}
+		default:
+			if !ok || err != nil {
+				t.Errorf("block %d should be valid: %v", i, err)
+			}
+		}
+		parent = block
+	}
+	if n := verifiedBlocks.len(genesis.GroupId); n != 0 {
+		t.Errorf("the applied blocks should be taken out of the cache, %d left", n)
+	}
+}
+
+func TestVerifyBlocksAheadEmpty(t *testing.T) {
+	if verified := VerifyBlocksAhead(nil, runtime.NumCPU()); len(verified) != 0 {
+		t.Errorf("expect no channels, got %d", len(verified))
+	}
+}
+
+func TestBlockVerifyCacheLRU(t *testing.T) {
+	cache := newBlockVerifyCache(3)
+	blocks := map[string][]*quorumpb.Block{}
+	for _, groupId := range []string{"group-1", "group-2"} {
+		for i := 0; i < 4; i++ {
+			block := &quorumpb.Block{GroupId: groupId, BlockId: uint64(i), ProducerSign: []byte{byte(i)}}
+			blocks[groupId] = append(blocks[groupId], block)
+		}
+	}
+	for _, block := range blocks["group-1"][:3] {
+		cache.add([]byte("hash"), block)
+	}
+	// verified again, so block 0 is the most recent one
+	cache.add([]byte("hash"), blocks["group-1"][0])
+	cache.add([]byte("hash"), blocks["group-1"][3])
+	cache.add([]byte("hash"), blocks["group-2"][0])
+
+	if n := cache.len("group-1"); n != 3 {
+		t.Fatalf("expect 3 blocks cached for group-1, got %d", n)
+	}
+	if cache.take([]byte("hash"), blocks["group-1"][1]) {
+		t.Errorf("the least recently verified block should be dropped")
+	}
+	for _, i := range []int{0, 2, 3} {
+		if !cache.take([]byte("hash"), blocks["group-1"][i]) {
+			t.Errorf("block %d of group-1 should be cached", i)
+		}
+	}
+	if cache.take([]byte("hash"), blocks["group-1"][0]) {
+		t.Errorf("a block should be taken once")
+	}
+	if cache.take([]byte("other hash"), blocks["group-2"][0]) {
+		t.Errorf("a block of another hash should not be taken")
+	}
+	if !cache.take([]byte("hash"), blocks["group-2"][0]) {
+		t.Errorf("block of group-2 should not be dropped by group-1")
+	}
+	if len(cache.groups) != 0 {
+		t.Errorf("the groups without blocks should be dropped, got %d", len(cache.groups))
+	}
+}
+
+// BenchmarkVerifyBlocksAhead applies a synced chain in order like Chain.ApplyBlocks, with the signs verified
+// ahead by the workers or one by one when they are applied
+func BenchmarkVerifyBlocksAhead(b *testing.B) {
+	initBlockVerifyKeystore(b)
+	genesis := &quorumpb.Block{GroupId: "0c1e6b1a-93d4-4b9e-b6c5-2f5d7e8a9b10", BlockHash: []byte("genesis")}
+	blocks := newSignedBlocks(b, genesis, 20000)
+
+	apply := func(b *testing.B, verified []chan struct{}) {
+		parent := genesis
+		for i, block := range blocks {
+			if verified != nil {
+				<-verified[i]
+			}
+			if ok, err := ValidBlockWithParent(block, parent); !ok || err != nil {
+				b.Fatalf("block %d should be valid: %v", i, err)
+			}
+			parent = block
+		}
+	}
+
+	b.Run("sequential", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			apply(b, nil)
+		}
+	})
+	for _, workers := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("workers-%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				apply(b, VerifyBlocksAhead(blocks, workers))
+			}
+		})
+	}
+}
@@ -21,6 +21,34 @@ const (
 	Sec   = 30
 )
 
+// TrxExpiryGrace tolerates the clock skew between nodes, a trx is expired after ExpiresAt + TrxExpiryGrace
+const TrxExpiryGrace = 30 * time.Second
+
+// IsTrxExpired reports whether the trx carries an expiry timestamp (unix nano) and it has passed,
+// trx.Expired is ignored, old releases set it to TimeStamp + 30s on every trx
+func IsTrxExpired(trx *quorumpb.Trx, now time.Time) bool {
+	return IsExpired(trx.ExpiresAt, now)
+}
+
+// IsExpired reports whether the expiry timestamp (unix nano) has passed, zero means never expire
+func IsExpired(expired int64, now time.Time) bool {
+	return expired > 0 && now.UnixNano() > expired+int64(TrxExpiryGrace)
+}
+
+// CheckTrxExpiry rejects a trx expiring before it is created or already expired
+func CheckTrxExpiry(trx *quorumpb.Trx, now time.Time) error {
+	if trx.ExpiresAt == 0 {
+		return nil
+	}
+	if trx.ExpiresAt <= trx.TimeStamp {
+		return fmt.Errorf("trx %s expires before it is created", trx.TrxId)
+	}
+	if IsTrxExpired(trx, now) {
+		return fmt.Errorf("trx %s is expired", trx.TrxId)
+	}
+	return nil
+}
+
 func CreateTrxWithoutSign(nodename string, version string, groupItem *quorumpb.GroupItem, msgType quorumpb.TrxType, data []byte, encryptto ...[]string) (*quorumpb.Trx, []byte, error) {
 	var trx quorumpb.Trx
 
@@ -31,8 +59,10 @@ func CreateTrxWithoutSign(nodename string, version string, groupItem *quorumpb.G
 
 	var encryptdData []byte
 	if msgType == quorumpb.TrxType_POST && groupItem.EncryptType == quorumpb.GroupEncryptType_PRIVATE {
-		//for post, private group, encrypted by age for all announced group users
-		if len(encryptto) == 1 {
+		//for post, private group, encrypted by age for all announced group users, or sealed by the group key already
+		if _, err := localcrypto.GroupKeyVersion(data); err == nil {
+			encryptdData = data
+		} else if len(encryptto) == 1 {
 			var err error
 			ks := localcrypto.GetKeystore()
 			if len(encryptto[0]) == 0 {
@@ -70,10 +100,27 @@ func CreateTrxWithoutSign(nodename string, version string, groupItem *quorumpb.G
 }
 
 func CreateTrxByEthKey(nodename string, version string, groupItem *quorumpb.GroupItem, msgType quorumpb.TrxType, data []byte, keyalias string, encryptto ...[]string) (*quorumpb.Trx, error) {
+	return CreateTrxByEthKeyWithExpiry(nodename, version, groupItem, msgType, data, keyalias, 0, encryptto...)
+}
+
+// CreateTrxByEthKeyWithExpiry creates a trx expiring at `expired` (unix nano), zero means never expire.
+// The expiry is signed with the trx.
+func CreateTrxByEthKeyWithExpiry(nodename string, version string, groupItem *quorumpb.GroupItem, msgType quorumpb.TrxType, data []byte, keyalias string, expired int64, encryptto ...[]string) (*quorumpb.Trx, error) {
 	trx, hash, err := CreateTrxWithoutSign(nodename, version, groupItem, msgType, data, encryptto...)
 	if err != nil {
 		return trx, err
 	}
+	if expired != 0 {
+		trx.ExpiresAt = expired
+		if err := CheckTrxExpiry(trx, time.Now()); err != nil {
+			return trx, err
+		}
+		bytes, err := proto.Marshal(trx)
+		if err != nil {
+			return trx, err
+		}
+		hash = localcrypto.Hash(bytes)
+	}
 
 	ks := localcrypto.GetKeystore()
 	var signature []byte
@@ -102,6 +149,7 @@ func VerifyTrx(trx *quorumpb.Trx) (bool, error) {
 		TimeStamp:    trx.TimeStamp,
 		Version:      trx.Version,
 		Expired:      trx.Expired,
+		ExpiresAt:    trx.ExpiresAt,
 	}
 
 	bytes, err := proto.Marshal(clonetrxmsg)
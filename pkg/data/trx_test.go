@@ -2,10 +2,12 @@ package data
 
 import (
 	"testing"
+	"time"
 
 	"github.com/rumsystem/quorum/internal/pkg/logging"
 	localcrypto "github.com/rumsystem/quorum/pkg/crypto"
 	quorumpb "github.com/rumsystem/quorum/pkg/pb"
+	"google.golang.org/protobuf/proto"
 )
 
 var (
@@ -47,10 +49,9 @@ func GetKeyStorePubKey(groupid string, path string) (string, string, error) {
 
 func TestVerifyTrx(t *testing.T) {
 	keystoreDir := t.TempDir()
-	tn := &TestNonce{}
 	trxFactory := &TrxFactory{}
 	groupitem := GetGroupItem()
-	trxFactory.Init("1.0.0", groupitem, "default", tn)
+	trxFactory.Init("1.0.0", groupitem, "default")
 	addr, pubkey, err := GetKeyStorePubKey(groupitem.GroupId, keystoreDir)
 	logger.Debugf("new eth key addr:%s", addr)
 	if err != nil {
@@ -61,7 +62,11 @@ func TestVerifyTrx(t *testing.T) {
 	target := &quorumpb.Object{Id: groupitem.GroupId, Type: "Group"}
 	postobj := &quorumpb.Activity{Type: "Add", Object: obj, Target: target}
 
-	trx, err := trxFactory.GetPostAnyTrx("", postobj)
+	content, err := proto.Marshal(postobj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trx, err := trxFactory.GetPostAnyTrx("", content)
 	result, err := VerifyTrx(trx)
 	if result != true {
 		t.Errorf("verify trx sig with pubkey error")
@@ -70,10 +75,9 @@ func TestVerifyTrx(t *testing.T) {
 
 func TestVerifyTrxByAddress(t *testing.T) {
 	keystoreDir := t.TempDir()
-	tn := &TestNonce{}
 	trxFactory := &TrxFactory{}
 	groupitem := GetGroupItem()
-	trxFactory.Init("1.0.0", groupitem, "default", tn)
+	trxFactory.Init("1.0.0", groupitem, "default")
 	addr, _, err := GetKeyStorePubKey(groupitem.GroupId, keystoreDir)
 	logger.Debugf("new eth key addr:%s", addr)
 	if err != nil {
@@ -84,9 +88,70 @@ func TestVerifyTrxByAddress(t *testing.T) {
 	target := &quorumpb.Object{Id: groupitem.GroupId, Type: "Group"}
 	postobj := &quorumpb.Activity{Type: "Add", Object: obj, Target: target}
 
-	trx, err := trxFactory.GetPostAnyTrx("", postobj)
+	content, err := proto.Marshal(postobj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trx, err := trxFactory.GetPostAnyTrx("", content)
 	result, err := VerifyTrx(trx)
 	if result != true {
 		t.Errorf("verify trx sig with pubkey error:%s", err)
 	}
 }
+
+func TestTrxExpiry(t *testing.T) {
+	now := time.Now()
+	created := now.Add(-24 * time.Hour).UnixNano()
+
+	// old releases set Expired = TimeStamp + 30s on every trx, it is not a ttl
+	legacy := &quorumpb.Trx{TrxId: "legacy", TimeStamp: created, Expired: created + int64(30*time.Second)}
+	if IsTrxExpired(legacy, now) {
+		t.Errorf("trx of old releases should never expire")
+	}
+	if err := CheckTrxExpiry(legacy, now); err != nil {
+		t.Errorf("trx of old releases should be accepted: %s", err)
+	}
+
+	expired := &quorumpb.Trx{TrxId: "expired", TimeStamp: created, ExpiresAt: created + int64(time.Hour)}
+	if !IsTrxExpired(expired, now) {
+		t.Errorf("trx should expire at ExpiresAt")
+	}
+	if err := CheckTrxExpiry(expired, now); err == nil {
+		t.Errorf("expired trx should be rejected")
+	}
+
+	grace := &quorumpb.Trx{TrxId: "grace", TimeStamp: created, ExpiresAt: now.Add(-time.Second).UnixNano()}
+	if IsTrxExpired(grace, now) {
+		t.Errorf("trx in the grace window should not expire")
+	}
+
+	invalid := &quorumpb.Trx{TrxId: "invalid", TimeStamp: created, ExpiresAt: created}
+	if err := CheckTrxExpiry(invalid, now); err == nil {
+		t.Errorf("trx expiring before it is created should be rejected")
+	}
+}
+
+func TestVerifyTrxExpiresAt(t *testing.T) {
+	trxFactory := &TrxFactory{}
+	groupitem := GetGroupItem()
+	trxFactory.Init("1.0.0", groupitem, "default")
+	_, pubkey, err := GetKeyStorePubKey(groupitem.GroupId, t.TempDir())
+	if err != nil {
+		t.Fatalf("keystore new key err : %s", err)
+	}
+	groupitem.UserSignPubkey = pubkey
+
+	trx, err := trxFactory.GetPostAnyTrxWithExpiry("", []byte("content"), time.Now().Add(time.Hour).UnixNano())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyTrx(trx); !ok {
+		t.Fatalf("verify trx with expiry failed: %v", err)
+	}
+
+	// the expiry is signed, it can not be extended
+	trx.ExpiresAt += int64(time.Hour)
+	if ok, _ := VerifyTrx(trx); ok {
+		t.Errorf("verify trx should fail after the expiry is changed")
+	}
+}
@@ -110,3 +110,17 @@ func (factory *TrxFactory) GetPostAnyTrx(keyalias string, content []byte, encryp
 
 	return factory.CreateTrxByEthKey(quorumpb.TrxType_POST, content, keyalias, encryptto...)
 }
+
+// GetPostAnyTrxWithExpiry creates a POST trx expiring at `expired` (unix nano), zero means never expire
+func (factory *TrxFactory) GetPostAnyTrxWithExpiry(keyalias string, content []byte, expired int64, encryptto ...[]string) (*quorumpb.Trx, error) {
+	if _, err := IsTrxDataWithinSizeLimit(content); err != nil {
+		return nil, err
+	}
+
+	return CreateTrxByEthKeyWithExpiry(factory.nodename, factory.version, factory.groupItem, quorumpb.TrxType_POST, content, keyalias, expired, encryptto...)
+}
+
+// GetTombstoneTrx deletes the post trxId, it is signed by the author of the post or the group owner
+func (factory *TrxFactory) GetTombstoneTrx(keyalias string, trxId string) (*quorumpb.Trx, error) {
+	return factory.CreateTrxByEthKey(quorumpb.TrxType_TOMBSTONE, []byte(trxId), keyalias)
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"fmt"
 
+	rumerrors "github.com/rumsystem/quorum/internal/pkg/errors"
 	"github.com/rumsystem/quorum/internal/pkg/logging"
 )
 
@@ -13,7 +14,7 @@ var dataLog = logging.Logger("data")
 func IsTrxDataWithinSizeLimit(data []byte) (bool, error) {
 	size := binary.Size(data)
 	if size > TRX_DATA_SIZE_LIMIT {
-		e := fmt.Errorf("trx.Data size %dkb over %dkb", size/1024, TRX_DATA_SIZE_LIMIT/1024)
+		e := fmt.Errorf("%w: trx.Data size %dkb over %dkb, split the content into several trxs or post a link to it instead", rumerrors.ErrMessageTooLarge, size/1024, TRX_DATA_SIZE_LIMIT/1024)
 		dataLog.Warn(e)
 		return false, e
 	}
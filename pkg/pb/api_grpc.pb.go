@@ -0,0 +1,315 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v3.18.1
+// source: api.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// QuorumApiClient is the client API for QuorumApi service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type QuorumApiClient interface {
+	GetNodeInfo(ctx context.Context, in *NodeInfoRequest, opts ...grpc.CallOption) (*NodeInfoResponse, error)
+	GetGroupStatus(ctx context.Context, in *GroupStatusRequest, opts ...grpc.CallOption) (*GroupStatusResponse, error)
+	CreateGroup(ctx context.Context, in *CreateGroupRequest, opts ...grpc.CallOption) (*CreateGroupResponse, error)
+	JoinGroup(ctx context.Context, in *JoinGroupRequest, opts ...grpc.CallOption) (*JoinGroupResponse, error)
+	PostContent(ctx context.Context, in *PostContentRequest, opts ...grpc.CallOption) (*PostContentResponse, error)
+	// StreamContent replays the content trxs after `since` and then streams the new ones
+	StreamContent(ctx context.Context, in *StreamContentRequest, opts ...grpc.CallOption) (QuorumApi_StreamContentClient, error)
+}
+
+type quorumApiClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewQuorumApiClient(cc grpc.ClientConnInterface) QuorumApiClient {
+	return &quorumApiClient{cc}
+}
+
+func (c *quorumApiClient) GetNodeInfo(ctx context.Context, in *NodeInfoRequest, opts ...grpc.CallOption) (*NodeInfoResponse, error) {
+	out := new(NodeInfoResponse)
+	err := c.cc.Invoke(ctx, "/quorum.pb.QuorumApi/GetNodeInfo", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *quorumApiClient) GetGroupStatus(ctx context.Context, in *GroupStatusRequest, opts ...grpc.CallOption) (*GroupStatusResponse, error) {
+	out := new(GroupStatusResponse)
+	err := c.cc.Invoke(ctx, "/quorum.pb.QuorumApi/GetGroupStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *quorumApiClient) CreateGroup(ctx context.Context, in *CreateGroupRequest, opts ...grpc.CallOption) (*CreateGroupResponse, error) {
+	out := new(CreateGroupResponse)
+	err := c.cc.Invoke(ctx, "/quorum.pb.QuorumApi/CreateGroup", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *quorumApiClient) JoinGroup(ctx context.Context, in *JoinGroupRequest, opts ...grpc.CallOption) (*JoinGroupResponse, error) {
+	out := new(JoinGroupResponse)
+	err := c.cc.Invoke(ctx, "/quorum.pb.QuorumApi/JoinGroup", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *quorumApiClient) PostContent(ctx context.Context, in *PostContentRequest, opts ...grpc.CallOption) (*PostContentResponse, error) {
+	out := new(PostContentResponse)
+	err := c.cc.Invoke(ctx, "/quorum.pb.QuorumApi/PostContent", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *quorumApiClient) StreamContent(ctx context.Context, in *StreamContentRequest, opts ...grpc.CallOption) (QuorumApi_StreamContentClient, error) {
+	stream, err := c.cc.NewStream(ctx, &QuorumApi_ServiceDesc.Streams[0], "/quorum.pb.QuorumApi/StreamContent", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &quorumApiStreamContentClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type QuorumApi_StreamContentClient interface {
+	Recv() (*Trx, error)
+	grpc.ClientStream
+}
+
+type quorumApiStreamContentClient struct {
+	grpc.ClientStream
+}
+
+func (x *quorumApiStreamContentClient) Recv() (*Trx, error) {
+	m := new(Trx)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// QuorumApiServer is the server API for QuorumApi service.
+// All implementations must embed UnimplementedQuorumApiServer
+// for forward compatibility
+type QuorumApiServer interface {
+	GetNodeInfo(context.Context, *NodeInfoRequest) (*NodeInfoResponse, error)
+	GetGroupStatus(context.Context, *GroupStatusRequest) (*GroupStatusResponse, error)
+	CreateGroup(context.Context, *CreateGroupRequest) (*CreateGroupResponse, error)
+	JoinGroup(context.Context, *JoinGroupRequest) (*JoinGroupResponse, error)
+	PostContent(context.Context, *PostContentRequest) (*PostContentResponse, error)
+	// StreamContent replays the content trxs after `since` and then streams the new ones
+	StreamContent(*StreamContentRequest, QuorumApi_StreamContentServer) error
+	mustEmbedUnimplementedQuorumApiServer()
+}
+
+// UnimplementedQuorumApiServer must be embedded to have forward compatible implementations.
+type UnimplementedQuorumApiServer struct {
+}
+
+func (UnimplementedQuorumApiServer) GetNodeInfo(context.Context, *NodeInfoRequest) (*NodeInfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNodeInfo not implemented")
+}
+func (UnimplementedQuorumApiServer) GetGroupStatus(context.Context, *GroupStatusRequest) (*GroupStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetGroupStatus not implemented")
+}
+func (UnimplementedQuorumApiServer) CreateGroup(context.Context, *CreateGroupRequest) (*CreateGroupResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateGroup not implemented")
+}
+func (UnimplementedQuorumApiServer) JoinGroup(context.Context, *JoinGroupRequest) (*JoinGroupResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method JoinGroup not implemented")
+}
+func (UnimplementedQuorumApiServer) PostContent(context.Context, *PostContentRequest) (*PostContentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PostContent not implemented")
+}
+func (UnimplementedQuorumApiServer) StreamContent(*StreamContentRequest, QuorumApi_StreamContentServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamContent not implemented")
+}
+func (UnimplementedQuorumApiServer) mustEmbedUnimplementedQuorumApiServer() {}
+
+// UnsafeQuorumApiServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to QuorumApiServer will
+// result in compilation errors.
+type UnsafeQuorumApiServer interface {
+	mustEmbedUnimplementedQuorumApiServer()
+}
+
+func RegisterQuorumApiServer(s grpc.ServiceRegistrar, srv QuorumApiServer) {
+	s.RegisterService(&QuorumApi_ServiceDesc, srv)
+}
+
+func _QuorumApi_GetNodeInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NodeInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QuorumApiServer).GetNodeInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/quorum.pb.QuorumApi/GetNodeInfo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QuorumApiServer).GetNodeInfo(ctx, req.(*NodeInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QuorumApi_GetGroupStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GroupStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QuorumApiServer).GetGroupStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/quorum.pb.QuorumApi/GetGroupStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QuorumApiServer).GetGroupStatus(ctx, req.(*GroupStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QuorumApi_CreateGroup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateGroupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QuorumApiServer).CreateGroup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/quorum.pb.QuorumApi/CreateGroup",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QuorumApiServer).CreateGroup(ctx, req.(*CreateGroupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QuorumApi_JoinGroup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JoinGroupRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QuorumApiServer).JoinGroup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/quorum.pb.QuorumApi/JoinGroup",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QuorumApiServer).JoinGroup(ctx, req.(*JoinGroupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QuorumApi_PostContent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PostContentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QuorumApiServer).PostContent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/quorum.pb.QuorumApi/PostContent",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QuorumApiServer).PostContent(ctx, req.(*PostContentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QuorumApi_StreamContent_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamContentRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(QuorumApiServer).StreamContent(m, &quorumApiStreamContentServer{stream})
+}
+
+type QuorumApi_StreamContentServer interface {
+	Send(*Trx) error
+	grpc.ServerStream
+}
+
+type quorumApiStreamContentServer struct {
+	grpc.ServerStream
+}
+
+func (x *quorumApiStreamContentServer) Send(m *Trx) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// QuorumApi_ServiceDesc is the grpc.ServiceDesc for QuorumApi service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var QuorumApi_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "quorum.pb.QuorumApi",
+	HandlerType: (*QuorumApiServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetNodeInfo",
+			Handler:    _QuorumApi_GetNodeInfo_Handler,
+		},
+		{
+			MethodName: "GetGroupStatus",
+			Handler:    _QuorumApi_GetGroupStatus_Handler,
+		},
+		{
+			MethodName: "CreateGroup",
+			Handler:    _QuorumApi_CreateGroup_Handler,
+		},
+		{
+			MethodName: "JoinGroup",
+			Handler:    _QuorumApi_JoinGroup_Handler,
+		},
+		{
+			MethodName: "PostContent",
+			Handler:    _QuorumApi_PostContent_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamContent",
+			Handler:       _QuorumApi_StreamContent_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "api.proto",
+}
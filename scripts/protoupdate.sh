@@ -8,3 +8,7 @@ mv pkg/pb/github.com/rumsystem/quorum/pkg/pb/rumexchange.pb.go pkg/pb/rumexchang
 protoc -I=pkg/pb --go_out=pkg/pb pkg/pb/activity_stream.proto
 mv pkg/pb/github.com/rumsystem/quorum/pkg/pb/activity_stream.pb.go pkg/pb/activity_stream.pb.go
 sed -i 's/TimeStamp,omitempty/TimeStamp,omitempty,string/g' pkg/pb/activity_stream.pb.go
+
+protoc -I=pkg/pb --go_out=pkg/pb --go-grpc_out=pkg/pb pkg/pb/api.proto
+mv pkg/pb/github.com/rumsystem/quorum/pkg/pb/api.pb.go pkg/pb/api.pb.go
+mv pkg/pb/github.com/rumsystem/quorum/pkg/pb/api_grpc.pb.go pkg/pb/api_grpc.pb.go